@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// console owns stdin during the run: a line answers the pending prompt
+// (request_user_input, confirmations), otherwise it is a command (p - pause, r - resume)
+type console struct {
+	mu     sync.Mutex
+	answer chan string       // Set while a prompt waits for a line
+	eof    bool              // Stdin closed
+	onLine func(line string) // Commands outside prompts
+	out    io.Writer         // Where prompts are printed
+}
+
+func newConsole(out io.Writer) *console {
+	c := &console{out: out}
+	go c.read()
+	return c
+}
+
+func (c *console) read() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		c.mu.Lock()
+		answer, onLine := c.answer, c.onLine
+		c.answer = nil
+		c.mu.Unlock()
+		switch {
+		case answer != nil:
+			answer <- line
+		case onLine != nil:
+			onLine(strings.TrimSpace(line))
+		}
+	}
+	c.mu.Lock()
+	c.eof = true
+	if c.answer != nil {
+		close(c.answer)
+		c.answer = nil
+	}
+	c.mu.Unlock()
+}
+
+// handle sets the command handler for lines typed outside prompts
+func (c *console) handle(onLine func(line string)) {
+	c.mu.Lock()
+	c.onLine = onLine
+	c.mu.Unlock()
+}
+
+// prompt is the tools.PromptFunc reading the answer from the shared stdin
+func (c *console) prompt(ctx context.Context, message string) (string, error) {
+	answer := make(chan string, 1)
+	c.mu.Lock()
+	if c.eof {
+		c.mu.Unlock()
+		return "", io.EOF
+	}
+	c.answer = answer
+	c.mu.Unlock()
+
+	fmt.Fprintf(c.out, "\n=== Требуется ввод ===\n%s\n> ", message)
+	select {
+	case line, ok := <-answer:
+		if !ok {
+			return "", io.EOF
+		}
+		return strings.TrimSpace(line), nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		if c.answer == answer {
+			c.answer = nil
+		}
+		c.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// pauser is the part of the orchestrator driven by console commands
+type pauser interface {
+	Pause() bool
+	Resume() bool
+}
+
+// runControls maps p/r lines to pause and resume of the run, replying on out
+func runControls(run pauser, out io.Writer) func(line string) {
+	return func(line string) {
+		switch strings.ToLower(line) {
+		case "p", "pause", "з":
+			if run.Pause() {
+				fmt.Fprintln(out, "⏸  Пауза после текущего шага. Можно работать в браузере; r — продолжить.")
+			}
+		case "r", "resume", "к":
+			if run.Resume() {
+				fmt.Fprintln(out, "▶️  Продолжаю со свежим снапшотом страницы.")
+			}
+		case "":
+		default:
+			fmt.Fprintln(out, "Команды во время прогона: p — пауза, r — продолжить.")
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+)
+
+// defaultFinishTemplate reproduces the human-readable finish line; an unsuccessful finish
+// (the planner gave up or could not complete everything) is marked as such
+const defaultFinishTemplate = `{{if .Success}}✅ {{.FinalMessage}}{{else if .FinalMessage}}⚠️ {{.FinalMessage}}{{end}}`
+
+// parseFinishTemplate parses --finish-template and dry-runs it against a sample result,
+// so typos in field names fail at startup instead of after a long run
+func parseFinishTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = defaultFinishTemplate
+	}
+	tmpl, err := template.New("finish").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse finish template: %w", err)
+	}
+	sample := agent.RunResult{
+		Success:      true,
+		FinalMessage: "sample",
+		Steps:        1,
+		Output:       "sample",
+		Artifacts:    []string{"state.json"},
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("finish template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printFinish renders the run result with the finish template, adding a trailing newline
+func printFinish(w io.Writer, tmpl *template.Template, result agent.RunResult) error {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, result); err != nil {
+		return err
+	}
+	out := b.String()
+	if out == "" {
+		return nil
+	}
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// printJSON writes the full RunResult (history, usage, failure reason) or ReplayResult as indented JSON
+func printJSON(w io.Writer, result any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+)
+
+func TestFinishTemplates(t *testing.T) {
+	done := agent.RunResult{
+		Success:      true,
+		FinalMessage: "Order #42 placed",
+		Steps:        7,
+		Output:       "total: 10 EUR",
+		Artifacts:    []string{"state.json", "receipt.pdf"},
+	}
+	partial := agent.RunResult{Success: false, FinalMessage: "Coupon field not found", Steps: 12}
+	failed := agent.RunResult{Steps: 30, FailureReason: &agent.FailureReason{Kind: agent.FailureStepLimit, Detail: "max steps"}}
+
+	tests := []struct {
+		name   string
+		tmpl   string
+		result agent.RunResult
+		want   string
+	}{
+		{"default success", "", done, "✅ Order #42 placed\n"},
+		{"default unsuccessful finish", "", partial, "⚠️ Coupon field not found\n"},
+		{"default error run prints nothing", "", failed, ""},
+		{"tab separated", "{{.Success}}\t{{.Steps}}\t{{.FinalMessage}}", partial, "false\t12\tCoupon field not found\n"},
+		{"artifacts", `{{range .Artifacts}}{{.}} {{end}}`, done, "state.json receipt.pdf \n"},
+		{"output", "{{.Output}}\n", done, "total: 10 EUR\n"},
+		{"failure reason", `{{with .FailureReason}}{{.Kind}}: {{.Detail}}{{end}}`, failed, "step_limit: max steps\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseFinishTemplate(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := printFinish(&b, tmpl, tt.result); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFinishTemplateErrorsAtStartup(t *testing.T) {
+	for _, text := range []string{"{{.Sucess}}", "{{if .Success}}", "{{.Steps.Count}}"} {
+		if _, err := parseFinishTemplate(text); err == nil {
+			t.Errorf("parseFinishTemplate(%q) accepted a broken template", text)
+		}
+	}
+}
@@ -3,18 +3,23 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent/prompts"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
@@ -27,33 +32,169 @@ type cliOptions struct {
 	saveState   string
 	maxSteps    int
 	temperature float64
+	version     bool
+	autoConsent bool
+	finishTmpl  string
+	shotDir     string
+	vision      bool
+	jsonSchema  bool
+	supervised  bool
+	safeForms   bool
+	maxCost     float64
+	provider    string
+	jsonOutput  bool
+	historyPath string
+	llmTitle    bool
+	resume      string
+	batchItem   string
+	batchMax    int
+	batchSteps  int
+	trajectory  string
+	replay      string
+	model       string
+	formAllow   []string
+	ssoDomains  []string
+	uploadDir   string
+	downloadDir string
+	prompts     string
+	allowHosts  []string
+	confirm     string
+	confirmGen  string
+	riskCheck   bool
+	blockHosts  []string
+	maxTime     time.Duration
+	retention   retentionPolicy
+	stepTime    time.Duration
+	maxWait     time.Duration
+	localize    bool
+	compact     bool
+	maxPages    int
+	verify      bool
+	headers     string
+	deliver     bool
+	viewport    bool
+	snapMax     int
+	snapChars   int
+	serve       string
+	serveJobs   int
+	serveQueue  int
+	llmPing     time.Duration
+	abandon     time.Duration
+	maxHeapMB   int
 }
 
 func main() {
 	_ = godotenv.Load()
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelftest(os.Args[2:]))
+	}
 	opts := parseFlags()
-	if opts.task == "" {
-		task, cancelled, err := promptTask()
+	if opts.version {
+		printVersion()
+		return
+	}
+	// With -json-output stdout carries only the RunResult JSON, console output moves to stderr
+	var out io.Writer = os.Stdout
+	if opts.jsonOutput {
+		out = os.Stderr
+	}
+	finishTmpl, err := parseFinishTemplate(opts.finishTmpl)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid --finish-template")
+	}
+	var resume *agent.Checkpoint
+	if opts.resume != "" {
+		cp, err := agent.LoadCheckpoint(opts.resume)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid -resume")
+		}
+		if opts.task != "" && opts.task != cp.Task {
+			log.Warn().Str("checkpoint_task", cp.Task).Msg("-task differs from the checkpoint task - using -task")
+		}
+		if opts.task == "" {
+			opts.task = cp.Task
+		}
+		if opts.historyPath == "" {
+			opts.historyPath = opts.resume // Keep checkpointing into the resumed file
+		}
+		resume = cp
+	}
+	if opts.task == "" && opts.replay == "" && opts.serve == "" {
+		task, cancelled, err := promptTask(out)
 		if err != nil {
 			log.Fatal().Err(err).Msg("prompt task failed")
 		}
 		if cancelled {
-			fmt.Println("Отменено.")
+			fmt.Fprintln(out, "Отменено.")
 			return
 		}
 		opts.task = task
 	}
+	// {slug} in artifact paths names them after the task; serve mode expands it per task
+	slug := agent.TaskSlug(opts.task)
+	stores := artifactStores([]string{opts.shotDir, opts.historyPath, opts.trajectory}, slug, opts.downloadDir)
+	if opts.serve == "" {
+		opts.shotDir = agent.ExpandSlug(opts.shotDir, slug)
+		opts.historyPath = agent.ExpandSlug(opts.historyPath, slug)
+		opts.trajectory = agent.ExpandSlug(opts.trajectory, slug)
+	}
 
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	// AGENT_LOG_LEVEL=info hides debug diagnostics (CDP statistics, LLM request details)
+	if raw := strings.TrimSpace(os.Getenv("AGENT_LOG_LEVEL")); raw != "" {
+		level, err := zerolog.ParseLevel(strings.ToLower(raw))
+		if err != nil {
+			log.Warn().Str("value", raw).Msg("invalid AGENT_LOG_LEVEL, keeping all levels")
+		} else {
+			zerolog.SetGlobalLevel(level)
+		}
+	}
+
+	pruneArtifacts(stores, opts.retention, time.Now(), log.With().Str("comp", "retention").Logger())
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	llmClient, err := llm.NewClientWithLogger(log.With().Str("comp", "llm").Logger())
+	if opts.replay != "" {
+		if err := runReplay(ctx, opts, out); err != nil {
+			log.Error().Err(err).Msg("replay failed")
+			stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	provider := llm.ResolveProvider(opts.provider)
+	llmClient, err := llm.NewClientWithOptions(log.With().Str("comp", "llm").Logger(), llm.ClientOptions{
+		Provider: provider,
+		Model:    opts.model,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("llm init - configure the provider or pick another with -provider")
+	}
+	log.Info().Str("provider", provider).Str("model", llmClient.Name()).Msg("llm client")
+
+	if opts.maxCost > 0 {
+		if _, ok := llm.EstimateCost(llmClient.Name(), llm.Usage{}); !ok {
+			log.Warn().Str("model", llmClient.Name()).Msg("no price for model - -max-cost will not be enforced")
+		}
+	}
+
+	if info := llmClient.ModelInfo(); info.Fallback {
+		log.Warn().Str("model", llmClient.Name()).Int("context_tokens", info.ContextTokens).
+			Msg("unknown model context window - using a conservative default (set LLM_CONTEXT_TOKENS)")
+	}
+
+	if err := prompts.LoadOverrides(opts.prompts); err != nil {
+		log.Fatal().Err(err).Msg("prompt overrides")
+	}
+	extraHeaders, err := originHeaders(opts)
 	if err != nil {
-		log.Fatal().Err(err).Msg("llm init")
+		log.Fatal().Err(err).Msg("extra headers")
 	}
+	// OCR fallback is enabled only when tesseract is installed
+	ocr := snapshot.NewTesseractOCR()
 
 	launcher, err := browser.NewLauncher(ctx)
 	if err != nil {
@@ -61,38 +202,185 @@ func main() {
 	}
 	defer launcher.Close()
 
-	ctrl, err := launcher.NewController(ctx, opts.storage)
+	if opts.serve != "" {
+		if err := runServe(ctx, opts, llmClient, launcher, extraHeaders, ocr); err != nil {
+			log.Error().Err(err).Msg("serve failed")
+			launcher.Close()
+			stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctrl, err := liveController(ctx, launcher, opts.storage)
 	if err != nil {
 		log.Fatal().Err(err).Msg("browser controller")
 	}
 	defer ctrl.Close(ctx)
+	if err := guardController(ctrl, opts, extraHeaders); err != nil {
+		log.Fatal().Err(err).Msg("browser guards")
+	}
+
+	lang := agent.DetectLanguage(opts.task)
+	if ocr != nil {
+		log.Info().Str("lang", lang).Msg("OCR fallback enabled (tesseract)")
+	}
+
+	con := newConsole(out)
+	orch, toolbox := newOrchestrator(opts, llmClient, ctrl, con.prompt, ocr, lang, resume, out, log.With().Str("comp", "orch").Logger())
 
-	toolbox := tools.New(ctrl, terminalPrompt())
-	planner := agent.NewPlanner(llmClient)
+	newRunSummary(opts, provider, llmClient.Name(), launcher.Headless(), ocr != nil, len(toolbox.Describe())).log(log.Logger)
+
+	con.handle(runControls(orch, out))
+	fmt.Fprintln(out, "Начинаю задачу... (p + Enter — пауза, r + Enter — продолжить)")
+	task := agent.Task{Description: opts.task, Slug: slug}
+	if opts.batchItem != "" {
+		task.Batch = &agent.BatchSpec{ItemTask: opts.batchItem, MaxItems: opts.batchMax, StepsPerItem: opts.batchSteps}
+	}
+	collector := snapshot.NewCollector(snapshotOptions(opts, ocr, lang))
+	result, err := orch.Run(ctx, task, func(c context.Context) (snapshot.Summary, error) {
+		return collector.Collect(c, ctrl)
+	})
+	if usage, err := ctrl.ResourceUsage(ctx); err == nil {
+		log.Info().Int("pages", usage.Pages).Int64("js_heap_bytes", usage.JSHeapBytes).Msg("browser resources")
+	}
+	if rs := result.Recovery; rs != nil {
+		log.Info().Int("attempts", rs.Attempts).Int("successes", rs.Successes).Interface("by_strategy", rs.ByStrategy).Msg("error recovery")
+	}
+	if err != nil {
+		ev := log.Error().Err(err)
+		if fr := result.FailureReason; fr != nil {
+			ev = ev.Str("failure_kind", fr.Kind).Int("failure_step", fr.Step).Str("failure_action", fr.Action).Str("failure_url", fr.URL)
+		}
+		ev.Msg("run finished with error")
+	} else if opts.saveState != "" {
+		used, err := ctrl.SaveState(ctx, opts.saveState)
+		var fallback *browser.StateFallbackError
+		switch {
+		case errors.As(err, &fallback):
+			log.Warn().Err(fallback.Err).Str("requested", opts.saveState).Str("path", used).Msg("storage saved to fallback path")
+			result.Artifacts = append(result.Artifacts, used)
+		case err != nil:
+			log.Error().Err(err).Str("path", opts.saveState).Msg("save state failed - session not persisted")
+		default:
+			log.Info().Str("path", used).Msg("storage saved")
+			result.Artifacts = append(result.Artifacts, used)
+		}
+	}
+	if opts.retention.enabled() {
+		if err := recordRun(stores, err != nil || !result.Success, time.Now(), result.Artifacts); err != nil {
+			log.Warn().Err(err).Msg("record run for artifact retention")
+		}
+	}
+	if err := printFinish(out, finishTmpl, result); err != nil {
+		log.Error().Err(err).Msg("render finish template")
+	}
+	if opts.jsonOutput {
+		if err := printJSON(os.Stdout, result); err != nil {
+			log.Error().Err(err).Msg("write json output")
+		}
+	}
+}
+
+// newOrchestrator builds the toolbox and orchestrator of one run from the flags;
+// prompt answers the agent's questions and confirmations
+func newOrchestrator(opts cliOptions, llmClient llm.Client, ctrl browser.Controller, prompt tools.PromptFunc, ocr snapshot.OCR, lang string, resume *agent.Checkpoint, out io.Writer, logger zerolog.Logger) (*agent.Orchestrator, tools.Toolbox) {
+	var riskClassifier agent.RiskClassifier
+	if opts.riskCheck {
+		riskClassifier = agent.NewLLMRiskClassifier(llmClient)
+	}
+	var finishVerifier agent.FinishVerifier
+	if opts.verify {
+		finishVerifier = agent.NewLLMFinishVerifier(llmClient)
+	}
+	var deliverables agent.DeliverableChecker
+	if opts.deliver {
+		deliverables = agent.NewLLMDeliverableChecker(llmClient)
+	}
+	var titler agent.TaskTitler
+	if opts.llmTitle {
+		titler = agent.NewLLMTaskTitler(llmClient)
+	}
+
+	toolbox := tools.NewWithOptions(ctrl, prompt, tools.Options{OCR: ocr, Language: lang, CredentialDomains: opts.ssoDomains, UploadDir: opts.uploadDir, ScreenshotDir: opts.shotDir, MaxWait: opts.maxWait})
+	planner := agent.NewPlannerWithOptions(llmClient, agent.PlannerOptions{
+		ForceJSONSchema: opts.jsonSchema,
+		Temperature:     float32(opts.temperature),
+	})
 
 	// Create orchestrator with unified planner (no sub-agents needed)
 	// Planner adapts to task type automatically via dynamic system prompt
 	orch := agent.NewOrchestrator(
-		agent.Config{MaxSteps: opts.maxSteps},
+		agent.Config{
+			MaxSteps:           opts.maxSteps,
+			AutoDismissConsent: opts.autoConsent,
+			ScreenshotDir:      opts.shotDir,
+			UseVision:          opts.vision,
+			Supervised:         opts.supervised,
+			Model:              llmClient.Name(),
+			MaxCost:            opts.maxCost,
+			HistoryPath:        opts.historyPath,
+			Resume:             resume,
+			TrajectoryPath:     opts.trajectory,
+			AllowedDomains:     opts.allowHosts,
+			ConfirmationPolicy: confirmationPolicy(opts),
+			RiskClassifier:     riskClassifier,
+			VerifyFinish:       finishVerifier,
+			Deliverables:       deliverables,
+			BlockedDomains:     opts.blockHosts,
+			MaxDuration:        opts.maxTime,
+			StepTimeout:        opts.stepTime,
+			LocalizeURLs:       opts.localize,
+			SummarizeHistory:   opts.compact,
+			TaskTitler:         titler,
+			Output:             out,
+		},
 		planner,
 		toolbox,
-		log.With().Str("comp", "orch").Logger(),
+		logger,
 	)
 
-	fmt.Println("Начинаю задачу...")
-	task := agent.Task{Description: opts.task}
-	err = orch.Run(ctx, task, func(c context.Context) (snapshot.Summary, error) {
-		return snapshot.Collect(c, ctrl)
-	})
-	if err != nil {
-		log.Error().Err(err).Msg("run finished with error")
-	} else if opts.saveState != "" {
-		if err := ctrl.SaveState(ctx, opts.saveState); err != nil {
-			log.Error().Err(err).Msg("save state")
-		} else {
-			log.Info().Str("path", opts.saveState).Msg("storage saved")
+	return orch, toolbox
+}
+
+// snapshotOptions configures the page snapshots of one run
+func snapshotOptions(opts cliOptions, ocr snapshot.OCR, lang string) snapshot.Options {
+	return snapshot.Options{
+		OCR:             ocr,
+		Language:        lang,
+		ViewportOnly:    opts.viewport,
+		MaxElements:     opts.snapMax,
+		MaxVisibleChars: opts.snapChars,
+		Logger:          log.With().Str("comp", "snapshot").Logger(),
+	}
+}
+
+// originHeaders loads the -headers file, AGENT_EXTRA_HEADERS(_FILE) without it
+func originHeaders(opts cliOptions) (browser.OriginHeaders, error) {
+	if opts.headers != "" {
+		return browser.LoadOriginHeaders(opts.headers)
+	}
+	return browser.OriginHeadersFromEnv()
+}
+
+// guardController applies the browser-side guards of the flags to a new controller
+func guardController(ctrl browser.Controller, opts cliOptions, headers browser.OriginHeaders) error {
+	if opts.safeForms {
+		if err := ctrl.EnableFormGuard(opts.formAllow); err != nil {
+			return fmt.Errorf("form guard: %w", err)
 		}
 	}
+	if err := ctrl.EnableDomainGuard(browser.NewDomainPolicy(opts.allowHosts, opts.blockHosts)); err != nil {
+		return fmt.Errorf("domain guard: %w", err)
+	}
+	ctrl.LimitPages(opts.maxPages)
+	if err := ctrl.EnableDownloads(opts.downloadDir); err != nil {
+		return fmt.Errorf("downloads: %w", err)
+	}
+	if err := ctrl.EnableOriginHeaders(headers); err != nil {
+		return fmt.Errorf("extra headers: %w", err)
+	}
+	return nil
 }
 
 func parseFlags() cliOptions {
@@ -101,19 +389,163 @@ func parseFlags() cliOptions {
 	save := flag.String("save-state", "", "Path to save updated storage state")
 	maxSteps := flag.Int("max-steps", 40, "Max agent steps")
 	temp := flag.Float64("temperature", 0.1, "LLM temperature")
+	version := flag.Bool("version", false, "Print version, system prompt hash and tools, then exit")
+	autoConsent := flag.Bool("auto-consent", false, "Dismiss cookie consent banners automatically (once per domain)")
+	finishTmpl := flag.String("finish-template", "", "Go text/template for the final stdout line over RunResult (.Success, .FinalMessage, .Steps, .Output, .Artifacts)")
+	shotDir := flag.String("screenshot-dir", "", "Save a screenshot after every step (step_NNN.png + index.json) into this directory")
+	vision := flag.Bool("vision", false, "Attach a viewport screenshot to every planner request (needs a vision-capable model)")
+	jsonSchema := flag.Bool("json-schema", false, "Constrain planner output to the decision JSON schema (OpenAI structured outputs)")
+	supervised := flag.Bool("supervised", false, "Ask for approval (approve/edit/skip/abort) before every action")
+	safeForms := flag.Bool("safe-forms", false, "Block form submissions to another site unless allowlisted (-form-allow)")
+	formAllow := flag.String("form-allow", "", "Comma-separated hosts allowed as cross-site form targets with -safe-forms")
+	downloadDir := flag.String("download-dir", "downloads", "Directory for files the pages download (wait_for_download); empty disables saving")
+	promptsPath := flag.String("prompts", "", "JSON file replacing system prompts of auxiliary model calls by name (finish_validation, extraction, coverage, risk, title, summary, reflection)")
+	uploadDir := flag.String("upload-dir", "", "Directory with files the agent may upload (upload_file); uploads are disabled without it")
+	ssoDomains := flag.String("sso-domains", "", "Comma-separated related sites (SSO) that may share credentials the user supplied on one of them")
+	maxCost := flag.Float64("max-cost", 0, "Abort the run when the estimated LLM cost exceeds this many USD (0 = no limit)")
+	provider := flag.String("provider", "", "LLM provider: anthropic, openai, ollama or gemini (overrides LLM_PROVIDER)")
+	model := flag.String("model", "", "LLM model (overrides the provider's *_MODEL variable)")
+	jsonOutput := flag.Bool("json-output", false, "Write the RunResult as JSON to stdout (console output goes to stderr)")
+	historyPath := flag.String("history", "", "Write a checkpoint (history, memory, URL) after every step to this file")
+	resume := flag.String("resume", "", "Resume a run from a checkpoint written with -history")
+	batchItem := flag.String("batch-item", "", "Batch mode: -task collects a list, then this task runs per item ({item} is replaced)")
+	batchMax := flag.Int("batch-max", 20, "Batch mode: max items to process")
+	batchSteps := flag.Int("batch-steps", 10, "Batch mode: max steps per item")
+	trajectory := flag.String("trajectory", "", "Append one JSON line per step (snapshot, decision, result) to this file")
+	replay := flag.String("replay", "", "Re-execute a trajectory recorded with -trajectory without the LLM, stop with a diff report on divergence")
+	allowDomains := flag.String("allow-domains", "", "Comma-separated hosts the agent may open (*.example.com includes subdomains)")
+	blockDomains := flag.String("block-domains", "", "Comma-separated hosts the agent must not open (*.example.com includes subdomains)")
+	confirm := flag.String("confirm", agent.ConfirmPrompt, "Destructive actions (buy, delete, submit...): prompt, auto-approve or deny")
+	confirmGen := flag.String("confirm-generic", "", "Mode for the generic tier (delete, submit, cancel...) if it differs from -confirm; payments keep -confirm")
+	riskCheck := flag.Bool("risk-check", false, "Ask the LLM to rate ambiguous clicks (generic keywords, unlabelled buttons) before running them")
+	maxTime := flag.Duration("max-duration", 0, "Stop the run after this much wall-clock time, pauses excluded (0 = no limit)")
+	stepTime := flag.Duration("step-timeout", 0, "Time limit for one step (snapshot + plan + action); a timed-out step is reported to the planner (0 = no limit)")
+	maxWait := flag.Duration("max-wait", 30*time.Second, "Longest pause one wait or wait_for_lazy_list call may take")
+	localize := flag.Bool("localize-urls", false, "Add the task language parameter (hl=, lang=) to URLs of sites known to support it")
+	llmTitle := flag.Bool("llm-title", false, "Ask the model for a short task title for logs and RunResult (one extra small call)")
+	compact := flag.Bool("compact-history", false, "Keep a progress summary of older steps (user data, action counts, pages) in the planner prompt")
+	maxPages := flag.Int("max-pages", 0, "Close pages (popups, new tabs) opened beyond this many per browser context (0 = no limit)")
+	verify := flag.Bool("verify-finish", false, "Before finishing, ask the LLM whether the page confirms the task is done (up to 2 rejections)")
+	headers := flag.String("headers", "", "JSON file {\"https://origin\": {\"Header\": \"value\"}} with extra request headers sent only to those origins")
+	deliver := flag.Bool("deliverables", false, "Extract the outputs the task asks for into a checklist and reject finishes that miss some of them (up to 2 rejections)")
+	viewport := flag.Bool("viewport-only", false, "Snapshot only elements in the viewport (faster, no CDP tree); the planner is told how much is below the fold")
+	snapMax := flag.Int("snapshot-max-elements", 0, "Max elements collected per snapshot (0 = default 200); heavy dashboards may need more, simple pages fewer tokens")
+	retainAge := flag.Duration("retain-age", 0, "Delete run artifacts ({slug} entries of -screenshot-dir/-history/-trajectory, downloads) older than this at startup (0 = keep)")
+	retainMB := flag.Int("retain-mb", 0, "Keep each artifact directory under this many MB by deleting the oldest runs at startup (0 = no limit)")
+	keepFailed := flag.Int("keep-failed", 5, "Artifacts of this many most recent failed runs survive -retain-age/-retain-mb")
+	snapChars := flag.Int("snapshot-text-chars", 0, "Max characters of visible page text per snapshot (0 = default 1200)")
+	serve := flag.String("serve", "", "Run an HTTP server on this address (e.g. :8080) taking tasks via POST /tasks, with /healthz and /readyz")
+	serveJobs := flag.Int("serve-workers", 1, "Serve mode: tasks run at once, each in its own browser context")
+	serveQueue := flag.Int("serve-queue", 16, "Serve mode: tasks that may wait for a worker; more get 503")
+	llmPing := flag.Duration("llm-ping", 5*time.Minute, "Serve mode: interval of the LLM connectivity check behind /readyz (0 = off)")
+	abandon := flag.Duration("serve-abandon", 0, "Serve mode: stop tasks whose status nobody requested for this long and close their browser context (0 = never)")
+	maxHeapMB := flag.Int("serve-max-heap", 0, "Serve mode: stop a task whose browser context uses more JS heap than this many MB (0 = no limit)")
 	flag.Parse()
+	if *serve != "" {
+		if *task != "" || *resume != "" || *replay != "" || *supervised || *save != "" {
+			fmt.Fprintln(os.Stderr, "-serve takes tasks over HTTP: it cannot be combined with -task, -resume, -replay, -supervised or -save-state")
+			os.Exit(2)
+		}
+		if *serveJobs < 1 || *serveQueue < 1 || *llmPing < 0 || *abandon < 0 || *maxHeapMB < 0 {
+			fmt.Fprintln(os.Stderr, "invalid -serve-workers/-serve-queue/-llm-ping/-serve-abandon/-serve-max-heap: workers and queue must be positive, the others not negative")
+			os.Exit(2)
+		}
+	}
+	if *snapMax < 0 || *snapChars < 0 {
+		fmt.Fprintln(os.Stderr, "invalid -snapshot-max-elements/-snapshot-text-chars: must not be negative")
+		os.Exit(2)
+	}
+	if *maxTime < 0 || *stepTime < 0 {
+		fmt.Fprintln(os.Stderr, "invalid -max-duration/-step-timeout: must not be negative")
+		os.Exit(2)
+	}
+	if *maxWait <= 0 {
+		fmt.Fprintln(os.Stderr, "invalid -max-wait: must be positive")
+		os.Exit(2)
+	}
+	if *retainAge < 0 || *retainMB < 0 || *keepFailed < 0 {
+		fmt.Fprintln(os.Stderr, "invalid -retain-age/-retain-mb/-keep-failed: must not be negative")
+		os.Exit(2)
+	}
+	if *temp < 0 || *temp > 2 {
+		fmt.Fprintf(os.Stderr, "invalid -temperature %g: must be between 0 and 2\n", *temp)
+		os.Exit(2)
+	}
+	for _, mode := range []string{*confirm, *confirmGen} {
+		if mode != "" && !agent.ValidConfirmMode(mode) {
+			fmt.Fprintf(os.Stderr, "invalid confirmation mode %q: use prompt, auto-approve or deny\n", mode)
+			os.Exit(2)
+		}
+	}
 	return cliOptions{
 		task:        strings.TrimSpace(*task),
 		storage:     strings.TrimSpace(*storage),
 		saveState:   strings.TrimSpace(*save),
 		maxSteps:    *maxSteps,
 		temperature: *temp,
+		version:     *version,
+		autoConsent: *autoConsent,
+		finishTmpl:  *finishTmpl,
+		shotDir:     strings.TrimSpace(*shotDir),
+		vision:      *vision,
+		jsonSchema:  *jsonSchema,
+		supervised:  *supervised,
+		safeForms:   *safeForms,
+		maxCost:     *maxCost,
+		provider:    strings.TrimSpace(*provider),
+		jsonOutput:  *jsonOutput,
+		historyPath: strings.TrimSpace(*historyPath),
+		llmTitle:    *llmTitle,
+		resume:      strings.TrimSpace(*resume),
+		batchItem:   strings.TrimSpace(*batchItem),
+		batchMax:    *batchMax,
+		batchSteps:  *batchSteps,
+		trajectory:  strings.TrimSpace(*trajectory),
+		replay:      strings.TrimSpace(*replay),
+		model:       strings.TrimSpace(*model),
+		formAllow:   splitList(*formAllow),
+		ssoDomains:  splitList(*ssoDomains),
+		uploadDir:   *uploadDir,
+		downloadDir: *downloadDir,
+		prompts:     strings.TrimSpace(*promptsPath),
+		allowHosts:  splitList(*allowDomains),
+		confirm:     *confirm,
+		confirmGen:  *confirmGen,
+		riskCheck:   *riskCheck,
+		blockHosts:  splitList(*blockDomains),
+		maxTime:     *maxTime,
+		retention:   retentionPolicy{maxAge: *retainAge, maxBytes: int64(*retainMB) << 20, keepFailed: *keepFailed},
+		stepTime:    *stepTime,
+		maxWait:     *maxWait,
+		localize:    *localize,
+		compact:     *compact,
+		maxPages:    *maxPages,
+		verify:      *verify,
+		headers:     strings.TrimSpace(*headers),
+		deliver:     *deliver,
+		viewport:    *viewport,
+		snapMax:     *snapMax,
+		snapChars:   *snapChars,
+		serve:       strings.TrimSpace(*serve),
+		serveJobs:   *serveJobs,
+		serveQueue:  *serveQueue,
+		llmPing:     *llmPing,
+		abandon:     *abandon,
+		maxHeapMB:   *maxHeapMB,
 	}
 }
 
-func promptTask() (string, bool, error) {
+// printVersion prints the same reproducibility info that is embedded into RunResult
+func printVersion() {
+	toolNames := agent.ToolNames(tools.New(nil, nil).Describe())
+	fmt.Printf("agent %s\nprompt hash: %s\ntools (%d): %s\n",
+		agent.BuildVersion(), agent.SystemPromptHash(), len(toolNames), strings.Join(toolNames, ", "))
+}
+
+// promptTask reads the task from stdin, printing the prompt to out
+func promptTask(out io.Writer) (string, bool, error) {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Введите задачу (оставьте пустым, чтобы отменить): ")
+	fmt.Fprint(out, "Введите задачу (оставьте пустым, чтобы отменить): ")
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		return "", false, err
@@ -125,9 +557,9 @@ func promptTask() (string, bool, error) {
 
 	// Validate and sanitize input
 	const maxTaskLength = 2000
-	if len(line) > maxTaskLength {
-		fmt.Printf("Задача слишком длинная (макс. %d символов), обрезана\n", maxTaskLength)
-		line = line[:maxTaskLength]
+	if utf8.RuneCountInString(line) > maxTaskLength {
+		fmt.Fprintf(out, "Задача слишком длинная (макс. %d символов), обрезана\n", maxTaskLength)
+		line = string([]rune(line)[:maxTaskLength])
 	}
 
 	// Basic sanitization: remove control characters except newlines/tabs
@@ -141,19 +573,42 @@ func promptTask() (string, bool, error) {
 	return sanitized.String(), false, nil
 }
 
-func terminalPrompt() tools.PromptFunc {
-	reader := bufio.NewReader(os.Stdin)
-	return func(ctx context.Context, message string) (string, error) {
-		fmt.Printf("\n=== Требуется ввод ===\n%s\n> ", message)
-		text, err := reader.ReadString('\n')
-		if err != nil {
-			return "", err
+// splitList parses a comma-separated flag value, dropping empty items
+// liveController creates a controller on a live browser: a Chromium that died since launch
+// (OOM killer, crash on startup) is relaunched once instead of failing the run
+func liveController(ctx context.Context, launcher *browser.Launcher, storage string) (browser.Controller, error) {
+	if !launcher.Alive() {
+		log.Warn().Msg("browser is not connected - relaunching")
+		if err := launcher.Relaunch(ctx); err != nil {
+			return nil, fmt.Errorf("relaunch browser: %w", err)
 		}
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
+	}
+	ctrl, err := launcher.NewController(ctx, storage)
+	if err == nil || launcher.Alive() {
+		return ctrl, err
+	}
+	log.Warn().Err(err).Msg("browser died while creating the controller - relaunching")
+	if err := launcher.Relaunch(ctx); err != nil {
+		return nil, fmt.Errorf("relaunch browser: %w", err)
+	}
+	return launcher.NewController(ctx, storage)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
-		return strings.TrimSpace(text), nil
 	}
+	return items
+}
+
+// confirmationPolicy maps -confirm / -confirm-generic to the orchestrator policy
+func confirmationPolicy(opts cliOptions) agent.ConfirmationPolicy {
+	policy := agent.ConfirmationPolicy{Mode: opts.confirm}
+	if opts.confirmGen != "" {
+		policy.SeverityModes = map[string]string{agent.SeverityGeneric: opts.confirmGen}
+	}
+	return policy
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestTemperatureReachesTheLLM(t *testing.T) {
+	finish, _ := json.Marshal(map[string]any{
+		"thinking": "", "evaluation_previous_goal": "", "memory": "", "next_goal": "",
+		"action": "finish", "input": map[string]any{"message": "done", "success": true},
+	})
+	client := llm.NewScriptedClient([]string{string(finish)})
+	opts := cliOptions{maxSteps: 3, temperature: 0.7, maxWait: time.Second, confirm: agent.ConfirmDeny}
+	orch, _ := newOrchestrator(opts, client, browser.NewFakeController(browser.FakePage{URL: "https://example.com"}), nil, nil, "", nil, io.Discard, zerolog.Nop())
+	if _, err := orch.Run(context.Background(), agent.Task{Description: "say hi"}, func(context.Context) (snapshot.Summary, error) {
+		return snapshot.Summary{URL: "https://example.com"}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	reqs := client.Requests()
+	if len(reqs) == 0 {
+		t.Fatal("no LLM request")
+	}
+	for _, req := range reqs {
+		if req.Temperature != float32(0.7) {
+			t.Fatalf("request temperature = %g, want the -temperature value 0.7", req.Temperature)
+		}
+	}
+}
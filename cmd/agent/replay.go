@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// runReplay re-executes a -trajectory recording in a fresh browser, no LLM client is created.
+// Progress goes to out, the -json-output result to stdout.
+func runReplay(ctx context.Context, opts cliOptions, out io.Writer) error {
+	steps, err := agent.LoadTrajectory(opts.replay)
+	if err != nil {
+		return err
+	}
+
+	launcher, err := browser.NewLauncher(ctx)
+	if err != nil {
+		return fmt.Errorf("browser init: %w", err)
+	}
+	defer launcher.Close()
+
+	ctrl, err := liveController(ctx, launcher, opts.storage)
+	if err != nil {
+		return fmt.Errorf("browser controller: %w", err)
+	}
+	defer ctrl.Close(ctx)
+
+	replayer := agent.NewReplayer(tools.New(ctrl, newConsole(out).prompt), log.With().Str("comp", "replay").Logger())
+	fmt.Fprintf(out, "Повторяю траекторию: %d шагов...\n", len(steps))
+	result, err := replayer.Replay(ctx, steps, func(c context.Context) (snapshot.Summary, error) {
+		return snapshot.CollectWithOptions(c, ctrl, snapshot.Options{})
+	})
+	switch {
+	case result.Diff != nil:
+		fmt.Fprint(out, result.Diff.String())
+	case err != nil:
+	case result.Unfinished:
+		fmt.Fprintf(out, "⚠️ replay: %d actions applied, but the recorded run never finished\n", result.Replayed)
+		err = fmt.Errorf("recording ended without finish")
+	default:
+		fmt.Fprintf(out, "✅ replay: %d actions applied\n", result.Replayed)
+	}
+	if opts.jsonOutput {
+		if jsonErr := printJSON(os.Stdout, result); jsonErr != nil {
+			log.Error().Err(jsonErr).Msg("write json output")
+		}
+	}
+	return err
+}
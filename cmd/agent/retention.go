@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// retentionLedger is the file in an artifact root remembering how the runs of its entries ended
+const retentionLedger = ".retention.json"
+
+// retentionPolicy bounds the artifact directories: entries older than maxAge are removed, then
+// the oldest ones until the directory fits maxBytes. The current run's entries and the keepFailed
+// most recent failed runs are never removed - failures are the runs worth reading later.
+type retentionPolicy struct {
+	maxAge     time.Duration // 0 = no age limit
+	maxBytes   int64         // 0 = no size limit
+	keepFailed int
+}
+
+func (p retentionPolicy) enabled() bool {
+	return p.maxAge > 0 || p.maxBytes > 0
+}
+
+// artifactStore is a directory whose entries (run directories or files) are pruned as a whole
+type artifactStore struct {
+	root   string
+	active map[string]bool // Entries of the current run
+	// tracked stores are shared directories (downloads): only the files the ledger lists as
+	// written by the agent are pruned, anything else in them is never touched
+	tracked bool
+}
+
+// artifactEntry is one run directory or file of a store
+type artifactEntry struct {
+	name     string
+	size     int64
+	modified time.Time // Newest modification inside the entry
+	failed   bool
+	finished time.Time // From the ledger, zero when the run was not recorded
+}
+
+// runRecord is the ledger line of an entry
+type runRecord struct {
+	Failed   bool      `json:"failed"`
+	Finished time.Time `json:"finished"`
+}
+
+// artifactStores finds the directories to prune: the parent of the {slug} element of each
+// artifact path pattern ("runs/{slug}/shots" -> runs, entry <slug>) and the download directory,
+// which is tracked: it may be shared (".", ~/Downloads), so only recorded downloads go.
+// Paths without {slug} are overwritten by every run and need no retention; a top-level {slug}
+// would make the working directory a store, so such paths are left alone too.
+func artifactStores(patterns []string, slug, downloadDir string) []artifactStore {
+	byRoot := make(map[string]*artifactStore)
+	var order []string
+	add := func(root, entry string) {
+		root = filepath.Clean(root)
+		st, ok := byRoot[root]
+		if !ok {
+			st = &artifactStore{root: root, active: make(map[string]bool)}
+			byRoot[root] = st
+			order = append(order, root)
+		}
+		if entry != "" {
+			st.active[entry] = true
+		}
+	}
+	for _, pattern := range patterns {
+		parts := strings.Split(filepath.ToSlash(pattern), "/")
+		for i, part := range parts {
+			if !strings.Contains(part, "{slug}") {
+				continue
+			}
+			if root := strings.Join(parts[:i], "/"); root != "" && root != "." {
+				add(filepath.FromSlash(root), strings.ReplaceAll(part, "{slug}", slug))
+			}
+			break
+		}
+	}
+	if strings.TrimSpace(downloadDir) != "" {
+		add(downloadDir, "")
+		byRoot[filepath.Clean(downloadDir)].tracked = true
+	}
+	stores := make([]artifactStore, 0, len(order))
+	for _, root := range order {
+		stores = append(stores, *byRoot[root])
+	}
+	return stores
+}
+
+// pruneArtifacts applies policy to every store, logging each deletion; a store that fails is
+// logged and skipped
+func pruneArtifacts(stores []artifactStore, policy retentionPolicy, now time.Time, logger zerolog.Logger) {
+	if !policy.enabled() {
+		return
+	}
+	for _, st := range stores {
+		if err := st.prune(policy, now, logger); err != nil {
+			logger.Warn().Err(err).Str("dir", st.root).Msg("artifact retention failed")
+		}
+	}
+}
+
+func (st artifactStore) prune(policy retentionPolicy, now time.Time, logger zerolog.Logger) error {
+	entries, ledger, err := st.scan()
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		present[e.name] = true
+	}
+	for name := range ledger {
+		if !present[name] && !st.active[name] {
+			delete(ledger, name) // Removed by hand since
+		}
+	}
+	// Newest first: what survives the size limit is the most recent runs
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modified.After(entries[j].modified) })
+
+	protected := make(map[string]bool, len(st.active))
+	for name := range st.active {
+		protected[name] = true
+	}
+	var failed []artifactEntry
+	for _, e := range entries {
+		if e.failed {
+			failed = append(failed, e)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].finished.After(failed[j].finished) })
+	for i := 0; i < len(failed) && i < policy.keepFailed; i++ {
+		protected[failed[i].name] = true
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	remove := func(e artifactEntry, reason string) {
+		if err := os.RemoveAll(filepath.Join(st.root, e.name)); err != nil {
+			logger.Warn().Err(err).Str("path", filepath.Join(st.root, e.name)).Msg("remove old artifact")
+			return
+		}
+		logger.Info().Str("path", filepath.Join(st.root, e.name)).Int64("bytes", e.size).
+			Time("modified", e.modified).Bool("failed", e.failed).Str("reason", reason).Msg("removed old artifact")
+		total -= e.size
+		delete(ledger, e.name)
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if !protected[e.name] && policy.maxAge > 0 && now.Sub(e.modified) > policy.maxAge {
+			remove(e, "age")
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept) - 1; i >= 0 && policy.maxBytes > 0 && total > policy.maxBytes; i-- {
+		if !protected[kept[i].name] {
+			remove(kept[i], "size")
+		}
+	}
+	if total > policy.maxBytes && policy.maxBytes > 0 {
+		logger.Warn().Str("dir", st.root).Int64("bytes", total).Int64("limit", policy.maxBytes).
+			Msg("artifact dir over the size limit with only protected entries left")
+	}
+	return st.writeLedger(ledger)
+}
+
+// scan lists the entries of the store with their size and newest modification time; a tracked
+// store lists only the entries of its ledger
+func (st artifactStore) scan() ([]artifactEntry, map[string]runRecord, error) {
+	dirents, err := os.ReadDir(st.root)
+	if os.IsNotExist(err) {
+		return nil, map[string]runRecord{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	ledger := st.readLedger()
+	entries := make([]artifactEntry, 0, len(dirents))
+	for _, d := range dirents {
+		if d.Name() == retentionLedger {
+			continue
+		}
+		if _, recorded := ledger[d.Name()]; st.tracked && !recorded {
+			continue
+		}
+		e := artifactEntry{name: d.Name()}
+		err := filepath.WalkDir(filepath.Join(st.root, d.Name()), func(_ string, de fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Vanished or unreadable parts count as nothing
+			}
+			info, err := de.Info()
+			if err != nil {
+				return nil
+			}
+			if !de.IsDir() {
+				e.size += info.Size()
+			}
+			if info.ModTime().After(e.modified) {
+				e.modified = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if rec, ok := ledger[e.name]; ok {
+			e.failed, e.finished = rec.Failed, rec.Finished
+		}
+		if e.finished.IsZero() {
+			e.finished = e.modified
+		}
+		entries = append(entries, e)
+	}
+	return entries, ledger, nil
+}
+
+// recordRun marks the current run's entries as failed or not in the ledger of every store;
+// files lists what the run wrote (RunResult.Artifacts), the entries of tracked stores
+func recordRun(stores []artifactStore, failed bool, now time.Time, files []string) error {
+	for _, st := range stores {
+		names := make([]string, 0, len(st.active))
+		for name := range st.active {
+			names = append(names, name)
+		}
+		if st.tracked {
+			names = append(names, st.entriesOf(files)...)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		ledger := st.readLedger()
+		for _, name := range names {
+			ledger[name] = runRecord{Failed: failed, Finished: now}
+		}
+		if err := st.writeLedger(ledger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entriesOf returns the names of the files lying directly in the store
+func (st artifactStore) entriesOf(files []string) []string {
+	root, err := filepath.Abs(st.root)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err == nil && filepath.Dir(abs) == root {
+			names = append(names, filepath.Base(abs))
+		}
+	}
+	return names
+}
+
+// readLedger returns the recorded runs; a missing or broken ledger reads as empty
+func (st artifactStore) readLedger() map[string]runRecord {
+	ledger := make(map[string]runRecord)
+	raw, err := os.ReadFile(filepath.Join(st.root, retentionLedger))
+	if err == nil {
+		_ = json.Unmarshal(raw, &ledger)
+	}
+	return ledger
+}
+
+func (st artifactStore) writeLedger(ledger map[string]runRecord) error {
+	if len(ledger) == 0 {
+		if err := os.Remove(filepath.Join(st.root, retentionLedger)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(st.root, 0o755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(st.root, retentionLedger), raw, 0o644); err != nil {
+		return fmt.Errorf("write retention ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// writeEntry creates root/name (a directory with one file of size bytes when dir) dated at t
+func writeEntry(tb testing.TB, root, name string, dir bool, size int, at time.Time) {
+	tb.Helper()
+	path := filepath.Join(root, name)
+	file := path
+	if dir {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		file = filepath.Join(path, "step_001.png")
+	}
+	if err := os.WriteFile(file, make([]byte, size), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	for _, p := range []string{file, path} {
+		if err := os.Chtimes(p, at, at); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestArtifactStores(t *testing.T) {
+	stores := artifactStores([]string{"runs/{slug}/shots", "runs/{slug}.jsonl", "{slug}.json", "fixed/history.json", ""}, "task", "downloads")
+	if len(stores) != 2 {
+		t.Fatalf("stores = %+v, want runs and downloads", stores)
+	}
+	if stores[0].root != "runs" || !stores[0].active["task"] || !stores[0].active["task.jsonl"] || stores[0].tracked {
+		t.Errorf("runs store = %+v", stores[0])
+	}
+	if stores[1].root != "downloads" || len(stores[1].active) != 0 || !stores[1].tracked {
+		t.Errorf("download store = %+v", stores[1])
+	}
+}
+
+func TestPruneByAgeKeepsActiveAndFailed(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	root := filepath.Join(t.TempDir(), "runs")
+	writeEntry(t, root, "current", true, 10, now.Add(-30*24*time.Hour))
+	writeEntry(t, root, "old-ok", true, 10, now.Add(-10*24*time.Hour))
+	writeEntry(t, root, "old-failed", true, 10, now.Add(-9*24*time.Hour))
+	writeEntry(t, root, "recent", true, 10, now.Add(-time.Hour))
+	st := artifactStore{root: root, active: map[string]bool{"old-failed": true}}
+	if err := recordRun([]artifactStore{st}, true, now.Add(-9*24*time.Hour), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	st.active = map[string]bool{"current": true}
+	pruneArtifacts([]artifactStore{st}, retentionPolicy{maxAge: 7 * 24 * time.Hour, keepFailed: 1}, now, zerolog.Nop())
+	for name, want := range map[string]bool{"current": true, "old-ok": false, "old-failed": true, "recent": true} {
+		if got := exists(filepath.Join(root, name)); got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+
+	pruneArtifacts([]artifactStore{st}, retentionPolicy{maxAge: 7 * 24 * time.Hour}, now, zerolog.Nop())
+	if exists(filepath.Join(root, "old-failed")) {
+		t.Error("old failed run kept with -keep-failed 0")
+	}
+}
+
+func TestPruneBySizeRemovesOldestFirst(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	root := t.TempDir()
+	for i, name := range []string{"a", "b", "c", "d"} {
+		writeEntry(t, root, name, true, 1000, now.Add(time.Duration(i-4)*time.Hour))
+	}
+	st := artifactStore{root: root, active: map[string]bool{"a": true}}
+	pruneArtifacts([]artifactStore{st}, retentionPolicy{maxBytes: 3500}, now, zerolog.Nop())
+	for name, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if got := exists(filepath.Join(root, name)); got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPruneSharedDownloadDirOnlyRemovesAgentDownloads(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	root := t.TempDir()
+	writeEntry(t, root, "report.pdf", false, 100, old)
+	writeEntry(t, root, "taxes-2025.pdf", false, 100, old)
+	writeEntry(t, root, "photos", true, 100, old)
+
+	stores := artifactStores(nil, "task", root)
+	if err := recordRun(stores, false, old, []string{filepath.Join(root, "report.pdf"), "/elsewhere/shot.png"}); err != nil {
+		t.Fatal(err)
+	}
+	pruneArtifacts(stores, retentionPolicy{maxAge: 24 * time.Hour, maxBytes: 1}, now, zerolog.Nop())
+	if exists(filepath.Join(root, "report.pdf")) {
+		t.Error("old agent download kept")
+	}
+	if !exists(filepath.Join(root, "taxes-2025.pdf")) || !exists(filepath.Join(root, "photos", "step_001.png")) {
+		t.Error("retention removed files the agent did not download")
+	}
+	if exists(filepath.Join(root, retentionLedger)) {
+		t.Error("ledger left behind with no recorded downloads")
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const (
+	// selftestTimeout bounds one scenario (browser start excluded)
+	selftestTimeout = 90 * time.Second
+	// selftestMaxScrolls bounds scrolling of the lazy list before the scenario fails
+	selftestMaxScrolls = 6
+	// Fixture credentials; they avoid the placeholder words fill_by_index refuses
+	selftestEmail    = "anna@shop.local"
+	selftestPassword = "k7-harbor-19"
+	// selftestReport is the file the download scenario saves
+	selftestReport = "order,total\n1042,18.50\n"
+)
+
+// selftestScenario is one end-to-end run: a task on the fixture site, the planner decisions
+// and a check of the final browser state
+type selftestScenario struct {
+	name  string
+	task  string // {base} is the fixture server URL
+	steps []selftestStep
+	check func(env *selftestEnv, result agent.RunResult) error
+}
+
+// selftestEnv is what steps and checks of one scenario see
+type selftestEnv struct {
+	base string // Fixture server URL
+	dir  string // Scenario directory for artifacts (saved state, extracted table)
+	ctrl browser.Controller
+}
+
+// expand replaces {base} and {dir} in a task or a decision input
+func (e *selftestEnv) expand(s string) string {
+	return strings.NewReplacer("{base}", e.base, "{dir}", e.dir).Replace(s)
+}
+
+// selftestStep produces the decision for a planner request from its prompt; again keeps the
+// step for the next request, an empty decision passes the same prompt to the next step
+type selftestStep func(env *selftestEnv, prompt string) (decision string, again bool, err error)
+
+// runSelftest runs the smoke scenarios (`agent selftest [-run name]`) with a real browser and a
+// scripted planner, prints PASS/FAIL per scenario and returns the process exit code
+func runSelftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	only := fs.String("run", "", "Run only scenarios whose name contains this text")
+	keep := fs.Bool("keep", false, "Keep scenario artifacts (saved state, extracted table) and print their directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(zerolog.WarnLevel).With().Timestamp().Logger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := httptest.NewServer(selftestFixture())
+	defer server.Close()
+
+	// CI machines have no display; an explicit AGENT_HEADLESS still wins
+	if os.Getenv("AGENT_HEADLESS") == "" {
+		_ = os.Setenv("AGENT_HEADLESS", "true")
+	}
+	launcher, err := browser.NewLauncher(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL browser init: %v\n", err)
+		return 1
+	}
+	defer launcher.Close()
+
+	root, err := os.MkdirTemp("", "agent-selftest-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL temp dir: %v\n", err)
+		return 1
+	}
+	if *keep {
+		fmt.Printf("artifacts: %s\n", root)
+	} else {
+		defer os.RemoveAll(root)
+	}
+
+	failed, ran := 0, 0
+	for _, sc := range selftestScenarios() {
+		if *only != "" && !strings.Contains(sc.name, *only) {
+			continue
+		}
+		ran++
+		start := time.Now()
+		steps, err := runScenario(ctx, launcher, server.URL, filepath.Join(root, sc.name), sc, logger)
+		elapsed := time.Since(start).Round(100 * time.Millisecond)
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %s (%d steps, %s): %v\n", sc.name, steps, elapsed, err)
+			continue
+		}
+		fmt.Printf("PASS %s (%d steps, %s)\n", sc.name, steps, elapsed)
+	}
+	if ran == 0 {
+		fmt.Fprintf(os.Stderr, "no scenario matches -run %q\n", *only)
+		return 2
+	}
+	fmt.Printf("%d/%d scenarios passed\n", ran-failed, ran)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runScenario(ctx context.Context, launcher *browser.Launcher, base, dir string, sc selftestScenario, logger zerolog.Logger) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, selftestTimeout)
+	defer cancel()
+
+	// A scenario that crashed Chromium must not fail the ones after it
+	ctrl, err := liveController(ctx, launcher, "")
+	if err != nil {
+		return 0, fmt.Errorf("browser controller: %w", err)
+	}
+	defer ctrl.Close(ctx)
+	if err := ctrl.EnableDownloads(filepath.Join(dir, "downloads")); err != nil {
+		return 0, err
+	}
+
+	env := &selftestEnv{base: base, dir: dir, ctrl: ctrl}
+	client := &selftestClient{env: env, steps: sc.steps}
+	orch := agent.NewOrchestrator(
+		agent.Config{
+			MaxSteps:           len(sc.steps) + selftestMaxScrolls,
+			Quiet:              true,
+			Model:              client.Name(),
+			ConfirmationPolicy: agent.ConfirmationPolicy{Mode: agent.ConfirmAutoApprove},
+		},
+		agent.NewPlanner(client),
+		tools.New(ctrl, func(context.Context, string) (string, error) {
+			return "", fmt.Errorf("selftest has no user to ask")
+		}),
+		logger.With().Str("comp", "orch").Str("scenario", sc.name).Logger(),
+	)
+	collector := snapshot.NewCollector(snapshot.Options{Logger: logger.With().Str("comp", "snapshot").Logger()})
+	result, err := orch.Run(ctx, agent.Task{Description: env.expand(sc.task)}, func(c context.Context) (snapshot.Summary, error) {
+		return collector.Collect(c, ctrl)
+	})
+	if err != nil {
+		return result.Steps, err
+	}
+	if !result.Success {
+		return result.Steps, fmt.Errorf("run did not finish: %s", result.FinalMessage)
+	}
+	return result.Steps, sc.check(env, result)
+}
+
+// selftestClient is the scripted planner model: each request is answered by the current step,
+// so decisions can use element indices of the live snapshot
+type selftestClient struct {
+	mu    sync.Mutex
+	env   *selftestEnv
+	steps []selftestStep
+	next  int
+}
+
+func (c *selftestClient) Name() string { return "selftest" }
+
+func (c *selftestClient) ModelInfo() llm.ModelInfo {
+	return llm.ModelInfo{ContextTokens: 200000, MaxOutputTokens: 8192}
+}
+
+func (c *selftestClient) Generate(ctx context.Context, req llm.Request) (llm.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return llm.Response{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prompt := ""
+	if len(req.Messages) > 0 {
+		prompt = req.Messages[len(req.Messages)-1].Content
+	}
+	for c.next < len(c.steps) {
+		decision, again, err := c.steps[c.next](c.env, prompt)
+		if err != nil {
+			return llm.Response{}, fmt.Errorf("selftest step %d: %w", c.next+1, err)
+		}
+		if !again {
+			c.next++
+		}
+		if decision != "" {
+			return llm.Response{Text: decision}, nil
+		}
+	}
+	return llm.Response{}, fmt.Errorf("selftest script exhausted after %d steps", len(c.steps))
+}
+
+// decisionJSON is a planner reply running action with input
+func decisionJSON(action string, input map[string]any) string {
+	raw, _ := json.Marshal(map[string]any{
+		"thinking":                 "selftest",
+		"evaluation_previous_goal": "",
+		"memory":                   "",
+		"next_goal":                action,
+		"action":                   action,
+		"input":                    input,
+	})
+	return string(raw)
+}
+
+// act runs action with a fixed input; string values may use {base} and {dir}
+func act(action string, input map[string]any) selftestStep {
+	return func(env *selftestEnv, _ string) (string, bool, error) {
+		expanded := make(map[string]any, len(input))
+		for k, v := range input {
+			if s, ok := v.(string); ok {
+				v = env.expand(s)
+			}
+			expanded[k] = v
+		}
+		return decisionJSON(action, expanded), false, nil
+	}
+}
+
+// elementPattern matches the prompt line [N]role:"text" of an element
+func elementPattern(role, text string) *regexp.Regexp {
+	return regexp.MustCompile(`\[(\d+)\](?i:` + regexp.QuoteMeta(role) + `):` + regexp.QuoteMeta(strconv.Quote(text)))
+}
+
+// byIndex runs action on the element listed as [N]role:"text", failing when it is not listed
+func byIndex(action, role, text string, input map[string]any) selftestStep {
+	pattern := elementPattern(role, text)
+	return func(_ *selftestEnv, prompt string) (string, bool, error) {
+		m := pattern.FindStringSubmatch(prompt)
+		if m == nil {
+			return "", false, fmt.Errorf("%s %q is not in the elements list", role, text)
+		}
+		index, _ := strconv.Atoi(m[1])
+		withIndex := map[string]any{"index": index}
+		for k, v := range input {
+			withIndex[k] = v
+		}
+		return decisionJSON(action, withIndex), false, nil
+	}
+}
+
+// scrollUntil scrolls to the bottom until the element is listed (at most selftestMaxScrolls times)
+func scrollUntil(role, text string) selftestStep {
+	pattern := elementPattern(role, text)
+	scrolls := 0
+	return func(_ *selftestEnv, prompt string) (string, bool, error) {
+		if pattern.MatchString(prompt) {
+			return "", false, nil
+		}
+		if scrolls == selftestMaxScrolls {
+			return "", false, fmt.Errorf("%s %q did not load after %d scrolls", role, text, scrolls)
+		}
+		scrolls++
+		return decisionJSON("scroll_page", map[string]any{"direction": "bottom"}), true, nil
+	}
+}
+
+// promptHas fails the scenario unless the planner prompt contains text; it plans nothing itself
+func promptHas(text string) selftestStep {
+	return func(_ *selftestEnv, prompt string) (string, bool, error) {
+		if !strings.Contains(prompt, text) {
+			return "", false, fmt.Errorf("prompt does not mention %q", text)
+		}
+		return "", false, nil
+	}
+}
+
+func finish(message string) selftestStep {
+	return act("finish", map[string]any{"message": message})
+}
+
+func selftestScenarios() []selftestScenario {
+	return []selftestScenario{
+		{
+			name: "form",
+			task: "Sign in at {base}/login, read the orders table and save the session",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/login"}),
+				byIndex("fill_by_index", "textbox", "Email", map[string]any{"text": selftestEmail}),
+				act("fill", map[string]any{"selector": "#password", "text": selftestPassword}),
+				byIndex("click_by_index", "button", "Sign in", nil),
+				act("extract_table", map[string]any{}),
+				act("save_state", map[string]any{"path": "{dir}/state.json"}),
+				finish("Signed in, orders table read, session saved"),
+			},
+			check: checkFormScenario,
+		},
+		{
+			name: "scroll-list",
+			task: "Open {base}/list and open Item 75 of the catalog",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/list"}),
+				scrollUntil("link", "Item 75"),
+				byIndex("click_by_index", "link", "Item 75", nil),
+				finish("Item 75 is open"),
+			},
+			check: func(env *selftestEnv, _ agent.RunResult) error {
+				if url := env.ctrl.Page().URL(); !strings.HasSuffix(url, "/item/75") {
+					return fmt.Errorf("ended on %s, want /item/75", url)
+				}
+				return nil
+			},
+		},
+		{
+			name: "iframe",
+			task: "Open {base}/frame and show the details from the embedded widget",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/frame"}),
+				byIndex("click_by_index", "button", "Show details", nil),
+				act("read_page", map[string]any{"selector": "#status"}),
+				finish("Details shown"),
+			},
+			check: func(_ *selftestEnv, result agent.RunResult) error {
+				if !strings.Contains(result.Output, "Details: 42") {
+					return fmt.Errorf("status after the iframe click is %q, want Details: 42", result.Output)
+				}
+				return nil
+			},
+		},
+		{
+			name: "icons",
+			task: "Open {base}/toolbar, delete the message and archive it",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/toolbar"}),
+				byIndex("click_by_index", "button", "Delete message", nil),
+				byIndex("click_by_index", "button", "Archive", nil),
+				act("read_page", map[string]any{"selector": "#status"}),
+				finish("Message deleted and archived"),
+			},
+			check: func(_ *selftestEnv, result agent.RunResult) error {
+				if !strings.Contains(result.Output, "deleted archived") {
+					return fmt.Errorf("status after the icon clicks is %q, want deleted archived", result.Output)
+				}
+				return nil
+			},
+		},
+		{
+			name: "download",
+			task: "Download the report from {base}/report",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/report"}),
+				byIndex("click_by_index", "link", "Download report", nil),
+				act("wait_for_download", map[string]any{"timeout_ms": 10000}),
+				finish("Report downloaded"),
+			},
+			check: checkDownloadScenario,
+		},
+		{
+			name: "hydration",
+			task: "Open {base}/app and open the orders",
+			steps: []selftestStep{
+				act("navigate", map[string]any{"url": "{base}/app"}),
+				promptHas("initial render was empty; waited"),
+				byIndex("click_by_index", "button", "Open orders", nil),
+				act("read_page", map[string]any{"selector": "#status"}),
+				finish("Orders opened"),
+			},
+			check: func(_ *selftestEnv, result agent.RunResult) error {
+				if !strings.Contains(result.Output, "Orders open") {
+					return fmt.Errorf("status after the click is %q, want Orders open", result.Output)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// checkFormScenario wants the dashboard, the orders table written to orders.json and the session
+// cookie in the saved state
+func checkFormScenario(env *selftestEnv, result agent.RunResult) error {
+	if url := env.ctrl.Page().URL(); !strings.HasSuffix(url, "/dashboard") {
+		return fmt.Errorf("not logged in: ended on %s", url)
+	}
+	var table struct {
+		Headers []string   `json:"headers"`
+		Rows    [][]string `json:"rows"`
+	}
+	if err := json.Unmarshal([]byte(result.Output), &table); err != nil {
+		return fmt.Errorf("extract_table output is not JSON: %w", err)
+	}
+	if len(table.Rows) != 3 || len(table.Headers) != 3 || table.Rows[0][0] != "1042" {
+		return fmt.Errorf("unexpected orders table: %s", result.Output)
+	}
+	raw, _ := json.MarshalIndent(table, "", "  ")
+	if err := os.WriteFile(filepath.Join(env.dir, "orders.json"), raw, 0o644); err != nil {
+		return err
+	}
+	state, err := os.ReadFile(filepath.Join(env.dir, "state.json"))
+	if err != nil {
+		return fmt.Errorf("storage not saved: %w", err)
+	}
+	if !strings.Contains(string(state), `"selftest_session"`) {
+		return fmt.Errorf("saved state has no session cookie")
+	}
+	return nil
+}
+
+// checkDownloadScenario wants the data-URL report saved under its suggested name and listed
+// in the artifacts
+func checkDownloadScenario(env *selftestEnv, result agent.RunResult) error {
+	want := filepath.Join(env.dir, "downloads", "report.txt")
+	listed := false
+	for _, path := range result.Artifacts {
+		listed = listed || path == want
+	}
+	if !listed {
+		return fmt.Errorf("artifacts %v do not list %s", result.Artifacts, want)
+	}
+	raw, err := os.ReadFile(want)
+	if err != nil {
+		return fmt.Errorf("report not saved: %w", err)
+	}
+	if string(raw) != selftestReport {
+		return fmt.Errorf("saved report is %q, want %q", raw, selftestReport)
+	}
+	return nil
+}
+
+// selftestFixture is the site the scenarios run against
+func selftestFixture() http.Handler {
+	mux := http.NewServeMux()
+	page := func(w http.ResponseWriter, title, body string) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!doctype html><html><head><title>%s</title></head><body>%s</body></html>", title, body)
+	}
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.FormValue("email") == selftestEmail && r.FormValue("password") == selftestPassword {
+				http.SetCookie(w, &http.Cookie{Name: "selftest_session", Value: "ok", Path: "/"})
+				http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+				return
+			}
+			page(w, "Sign in", `<p role="alert">Wrong email or password</p>`+loginForm)
+			return
+		}
+		page(w, "Sign in", loginForm)
+	})
+	mux.HandleFunc("/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("selftest_session"); err != nil || c.Value != "ok" {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		page(w, "Dashboard", `<h1>Dashboard</h1>
+<table><caption>Orders</caption>
+<thead><tr><th>Order</th><th>Status</th><th>Total</th></tr></thead>
+<tbody><tr><td>1042</td><td>Shipped</td><td>$18.50</td></tr>
+<tr><td>1043</td><td>Processing</td><td>$7.00</td></tr>
+<tr><td>1044</td><td>Delivered</td><td>$42.10</td></tr></tbody></table>`)
+	})
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		page(w, "Catalog", `<h1>Catalog</h1><ul id="list" style="list-style:none"></ul><p id="end">Loading...</p>
+<script>
+let n = 0;
+const list = document.getElementById('list');
+function more() {
+	for (let i = 0; i < 20 && n < 100; i++) {
+		n++;
+		const li = document.createElement('li');
+		li.style.height = '60px';
+		li.innerHTML = '<a href="/item/' + n + '">Item ' + n + '</a>';
+		list.appendChild(li);
+	}
+	if (n >= 100) document.getElementById('end').textContent = 'End of list';
+}
+more();
+window.addEventListener('scroll', () => {
+	if (window.innerHeight + window.scrollY >= document.body.scrollHeight - 200) more();
+});
+</script>`)
+	})
+	mux.HandleFunc("/item/", func(w http.ResponseWriter, r *http.Request) {
+		n := strings.TrimPrefix(r.URL.Path, "/item/")
+		page(w, "Item "+n, "<h1>Item "+n+"</h1>")
+	})
+	mux.HandleFunc("/frame", func(w http.ResponseWriter, r *http.Request) {
+		page(w, "Widget host", `<h1>Widget host</h1><p id="status">Waiting</p>
+<iframe src="/widget" title="Widget" style="width:400px;height:150px"></iframe>`)
+	})
+	mux.HandleFunc("/toolbar", func(w http.ResponseWriter, r *http.Request) {
+		// Icon-only buttons named only by a tooltip or a hidden description
+		icon := `<svg width="16" height="16" viewBox="0 0 16 16"><path d="M2 2h12v12H2z"/></svg>`
+		page(w, "Inbox", `<h1>Inbox</h1><p id="status"></p><div role="toolbar">
+<button onclick="document.getElementById('status').textContent += ' deleted'" aria-describedby="delete-tip">`+icon+`</button>
+<span id="delete-tip" hidden>Delete message</span>
+<button onclick="document.getElementById('status').textContent += ' archived'" title="Archive">`+icon+`</button>
+<button onclick="document.getElementById('status').textContent += ' starred'" title="Star">`+icon+`</button>
+</div>`)
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		href := "data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte(selftestReport))
+		page(w, "Report", `<h1>Report</h1><a href="`+href+`" download="report.txt">Download report</a>`)
+	})
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		// An empty shell the client renders late, like an SPA waiting for its bundle and data
+		page(w, "App", `<div id="root"></div>
+<script>
+setTimeout(() => {
+	const items = ['Orders', 'Customers', 'Products', 'Reports', 'Settings'];
+	document.getElementById('root').innerHTML = '<h1>Store admin</h1><p id="status"></p><nav>' +
+		items.map(i => '<button onclick="document.getElementById(\'status\').textContent = \'' + i + ' open\'">Open ' + i.toLowerCase() + '</button>').join(' ') +
+		'</nav><p>Welcome back. Pick a section to manage the store: orders waiting for shipment, customer accounts, the product catalog, sales reports and store settings.</p>';
+}, 2500);
+</script>`)
+	})
+	mux.HandleFunc("/widget", func(w http.ResponseWriter, r *http.Request) {
+		page(w, "Widget", `<button onclick="parent.document.getElementById('status').textContent = 'Details: 42'">Show details</button>`)
+	})
+	return mux
+}
+
+const loginForm = `<h1>Sign in</h1>
+<form method="post" action="/login" aria-label="Sign in">
+<label for="email">Email</label> <input id="email" name="email" type="email" required>
+<label for="password">Password</label> <input id="password" name="password" type="password" required>
+<button id="login" type="submit">Sign in</button>
+</form>`
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/server"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// errNoUser answers ask_user and confirmation prompts in serve mode
+var errNoUser = errors.New("no interactive user in serve mode")
+
+// runServe takes tasks over HTTP (-serve) until ctx ends. Every task runs in its own
+// browser context of the shared launcher, with the same guards as a single run.
+func runServe(ctx context.Context, opts cliOptions, llmClient llm.Client, launcher *browser.Launcher, headers browser.OriginHeaders, ocr snapshot.OCR) error {
+	cfg := server.Config{
+		Workers:      opts.serveJobs,
+		QueueSize:    opts.serveQueue,
+		Storage:      opts.storage,
+		AbandonAfter: opts.abandon,
+		MaxJSHeap:    int64(opts.maxHeapMB) << 20,
+	}
+	if opts.llmPing > 0 {
+		cfg.PingInterval = opts.llmPing
+		cfg.Ping = func(ctx context.Context) error {
+			_, err := llmClient.Generate(ctx, llm.Request{Messages: []llm.Message{{Role: "user", Content: "ping"}}, MaxTokens: 1})
+			return err
+		}
+	}
+	run := func(ctx context.Context, ctrl browser.Controller, t *server.Task) (agent.RunResult, error) {
+		if err := guardController(ctrl, opts, headers); err != nil {
+			return agent.RunResult{}, err
+		}
+		taskOpts := serveTaskOptions(opts, t.Slug)
+		lang := agent.DetectLanguage(t.Text)
+		noUser := func(context.Context, string) (string, error) { return "", errNoUser }
+		orch, _ := newOrchestrator(taskOpts, llmClient, ctrl, noUser, ocr, lang, nil, io.Discard,
+			log.With().Str("comp", "orch").Str("task", t.ID).Logger())
+		t.SetPauser(orch)
+		collector := snapshot.NewCollector(snapshotOptions(taskOpts, ocr, lang))
+		return orch.Run(ctx, agent.Task{Description: t.Text, Slug: t.Slug}, func(c context.Context) (snapshot.Summary, error) {
+			return collector.Collect(c, ctrl)
+		})
+	}
+	srv := server.New(cfg, launcher, run, log.With().Str("comp", "server").Logger())
+	srv.Start(ctx)
+
+	httpServer := &http.Server{Addr: opts.serve, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	log.Info().Str("addr", opts.serve).Int("workers", opts.serveJobs).Msg("serving tasks")
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("listen %s: %w", opts.serve, err)
+	}
+	return nil
+}
+
+// serveTaskOptions names the artifacts of one served task after its slug. Nobody answers
+// prompts in serve mode, so actions that would ask for confirmation are denied instead.
+func serveTaskOptions(opts cliOptions, slug string) cliOptions {
+	opts.shotDir = agent.ExpandSlug(opts.shotDir, slug)
+	opts.historyPath = agent.ExpandSlug(opts.historyPath, slug)
+	opts.trajectory = agent.ExpandSlug(opts.trajectory, slug)
+	if opts.confirm == agent.ConfirmPrompt {
+		opts.confirm = agent.ConfirmDeny
+	}
+	if opts.confirmGen == agent.ConfirmPrompt {
+		opts.confirmGen = agent.ConfirmDeny
+	}
+	return opts
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+)
+
+func TestServeTaskOptions(t *testing.T) {
+	opts := cliOptions{shotDir: "shots/{slug}", trajectory: "runs/{slug}.jsonl", confirm: agent.ConfirmPrompt, confirmGen: agent.ConfirmAutoApprove}
+	got := serveTaskOptions(opts, "find-weather-1a2b3c")
+	if got.shotDir != "shots/find-weather-1a2b3c" || got.trajectory != "runs/find-weather-1a2b3c.jsonl" || got.historyPath != "" {
+		t.Fatalf("artifact paths = %q %q %q", got.shotDir, got.trajectory, got.historyPath)
+	}
+	if got.confirm != agent.ConfirmDeny {
+		t.Fatalf("confirm = %q: nobody can answer a prompt in serve mode", got.confirm)
+	}
+	if got.confirmGen != agent.ConfirmAutoApprove {
+		t.Fatalf("confirm-generic = %q, an explicit mode must be kept", got.confirmGen)
+	}
+	if opts.shotDir != "shots/{slug}" {
+		t.Fatal("serveTaskOptions changed the shared options")
+	}
+}
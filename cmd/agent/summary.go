@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+)
+
+const summaryTaskMaxRunes = 120
+
+// runSummary is the effective configuration echoed once at run start
+type runSummary struct {
+	task          string
+	provider      string
+	model         string
+	headless      bool
+	storage       string
+	storageLoaded bool
+	maxSteps      int
+	temperature   float64
+	workdir       string
+	features      []string // Enabled non-default features
+	tools         int
+}
+
+func newRunSummary(opts cliOptions, provider, model string, headless, ocr bool, toolCount int) runSummary {
+	workdir, _ := os.Getwd()
+	storageLoaded := false
+	if opts.storage != "" {
+		if _, err := os.Stat(opts.storage); err == nil {
+			storageLoaded = true
+		}
+	}
+	features := make([]string, 0, 6)
+	if opts.vision {
+		features = append(features, "vision")
+	}
+	if opts.batchItem != "" {
+		features = append(features, "batch")
+	}
+	if len(opts.allowHosts) > 0 || len(opts.blockHosts) > 0 {
+		features = append(features, "domain-policy")
+	}
+	if len(opts.ssoDomains) > 0 {
+		features = append(features, "sso-domains")
+	}
+	if opts.retention.enabled() {
+		features = append(features, "retention")
+	}
+	if opts.uploadDir != "" {
+		features = append(features, "upload-dir")
+	}
+	if opts.prompts != "" {
+		features = append(features, "prompt-overrides")
+	}
+	if opts.confirm != agent.ConfirmPrompt || opts.confirmGen != "" {
+		features = append(features, "confirm="+opts.confirm)
+	}
+	if opts.maxTime > 0 || opts.stepTime > 0 {
+		features = append(features, "timeouts")
+	}
+	if opts.compact {
+		features = append(features, "compact-history")
+	}
+	if opts.llmTitle {
+		features = append(features, "llm-title")
+	}
+	if opts.localize {
+		features = append(features, "localize-urls")
+	}
+	if opts.verify {
+		features = append(features, "verify-finish")
+	}
+	if opts.deliver {
+		features = append(features, "deliverables")
+	}
+	if opts.viewport {
+		features = append(features, "viewport-only")
+	}
+	if opts.snapMax > 0 || opts.snapChars > 0 {
+		features = append(features, "snapshot-limits")
+	}
+	if opts.riskCheck {
+		features = append(features, "risk-check")
+	}
+	if opts.safeForms {
+		features = append(features, "safe-forms")
+	}
+	if opts.supervised {
+		features = append(features, "supervised")
+	}
+	if opts.jsonSchema {
+		features = append(features, "json-schema")
+	}
+	if opts.autoConsent {
+		features = append(features, "auto-consent")
+	}
+	if opts.shotDir != "" {
+		features = append(features, "screenshots")
+	}
+	if opts.finishTmpl != "" {
+		features = append(features, "finish-template")
+	}
+	if opts.trajectory != "" {
+		features = append(features, "trajectory")
+	}
+	if opts.saveState != "" {
+		features = append(features, "save-state")
+	}
+	if ocr {
+		features = append(features, "ocr")
+	}
+	return runSummary{
+		task:          truncateRunes(opts.task, summaryTaskMaxRunes),
+		provider:      provider,
+		model:         model,
+		headless:      headless,
+		storage:       opts.storage,
+		storageLoaded: storageLoaded,
+		maxSteps:      opts.maxSteps,
+		temperature:   opts.temperature,
+		workdir:       workdir,
+		features:      features,
+		tools:         toolCount,
+	}
+}
+
+// log writes the summary as one structured event
+func (s runSummary) log(logger zerolog.Logger) {
+	logger.Info().
+		Str("task", s.task).
+		Str("provider", s.provider).
+		Str("model", s.model).
+		Bool("headless", s.headless).
+		Str("storage", s.storage).
+		Bool("storage_loaded", s.storageLoaded).
+		Int("max_steps", s.maxSteps).
+		Float64("temperature", s.temperature).
+		Str("workdir", s.workdir).
+		Strs("features", s.features).
+		Int("tools", s.tools).
+		Msg("run config")
+}
+
+func truncateRunes(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Привет, мир", 11, "Привет, мир"},
+		{"Привет, мир", 10, "Привет, ми..."},
+		{"Привет, мир", 0, "..."},
+		{"  🍕🍕🍕  ", 3, "🍕🍕🍕"}, // Trimmed before counting
+		{"🍕🍕🍕🍕", 2, "🍕🍕..."},
+		{"a👍🏽b", 2, "a👍..."}, // The skin tone modifier is a rune of its own
+		{"", 5, ""},
+	}
+	for _, tt := range tests {
+		got := truncateRunes(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if strings.HasSuffix(got, "...") && utf8.RuneCountInString(got) != tt.n+3 {
+			t.Errorf("truncateRunes(%q, %d) = %q has %d runes", tt.s, tt.n, got, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+func TestRunSummaryLogsEveryField(t *testing.T) {
+	storage := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(storage, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := cliOptions{
+		task:        "  " + strings.Repeat("Найди билеты ", 20),
+		storage:     storage,
+		maxSteps:    30,
+		temperature: 0.3,
+		confirm:     agent.ConfirmPrompt,
+		vision:      true,
+		supervised:  true,
+		trajectory:  "runs/{slug}.jsonl",
+	}
+	var buf bytes.Buffer
+	newRunSummary(opts, "anthropic", "claude-sonnet-4", true, true, 37).log(zerolog.New(&buf))
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("not one JSON event: %q", buf.String())
+	}
+	wd, _ := os.Getwd()
+	want := map[string]any{
+		"message":        "run config",
+		"provider":       "anthropic",
+		"model":          "claude-sonnet-4",
+		"headless":       true,
+		"storage":        storage,
+		"storage_loaded": true,
+		"max_steps":      float64(30),
+		"temperature":    0.3,
+		"workdir":        wd,
+		"tools":          float64(37),
+	}
+	for key, value := range want {
+		if event[key] != value {
+			t.Errorf("%s = %v, want %v", key, event[key], value)
+		}
+	}
+	task, _ := event["task"].(string)
+	if !strings.HasPrefix(task, "Найди билеты") || utf8.RuneCountInString(task) != summaryTaskMaxRunes+3 {
+		t.Errorf("task = %q, want it trimmed and cut to %d runes", task, summaryTaskMaxRunes)
+	}
+	features, _ := event["features"].([]any)
+	got := fmt.Sprint(features)
+	if got != "[vision supervised trajectory ocr]" {
+		t.Errorf("features = %s", got)
+	}
+}
+
+func TestRunSummaryDefaults(t *testing.T) {
+	s := newRunSummary(cliOptions{task: "hi", confirm: agent.ConfirmPrompt, storage: filepath.Join(t.TempDir(), "missing.json")}, "openai", "gpt-4o-mini", false, false, 10)
+	if len(s.features) != 0 {
+		t.Errorf("features = %v, want none for defaults", s.features)
+	}
+	if s.storageLoaded {
+		t.Error("missing storage file reported as loaded")
+	}
+}
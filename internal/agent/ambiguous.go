@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// resolveAmbiguousLocator handles strict mode violations: when every match has the same text
+// the elements are interchangeable and the first one is used, otherwise the candidates are
+// reported back to the planner so it can pick a more specific selector or an index
+func (o *Orchestrator) resolveAmbiguousLocator(ctx context.Context, dec Decision) (string, tools.Result, bool) {
+	sel, _ := dec.ActionInput["selector"].(string)
+	if sel == "" {
+		return "", tools.Result{}, false
+	}
+	texts, err := o.tools.SelectorMatches(ctx, sel)
+	if err != nil || len(texts) < 2 {
+		return "", tools.Result{}, false
+	}
+	if !sameTexts(texts) {
+		quoted := make([]string, 0, len(texts))
+		for _, t := range texts {
+			if t == "" {
+				t = "<no text>"
+			}
+			quoted = append(quoted, "'"+truncateText(t, 40)+"'")
+		}
+		o.recoveryNote = fmt.Sprintf("selector matched %d elements: %s — be more specific or use an index", len(texts), strings.Join(quoted, ", "))
+		return "", tools.Result{}, false
+	}
+
+	input := make(map[string]any, len(dec.ActionInput))
+	for k, v := range dec.ActionInput {
+		input[k] = v
+	}
+	input["selector"] = sel + " >> nth=0"
+	o.logger.Info().Str("strategy", "first_of_identical").Str("selector", sel).Int("matches", len(texts)).Msg("ambiguous selector with identical matches - using the first")
+	res, err := o.tools.Invoke(ctx, dec.ActionName, input)
+	if err != nil {
+		return "", tools.Result{}, false
+	}
+	return dec.ActionName, res, true
+}
+
+// sameTexts reports whether all texts are equal (case/space-insensitive) and not empty
+func sameTexts(texts []string) bool {
+	first := strings.ToLower(strings.Join(strings.Fields(texts[0]), " "))
+	if first == "" {
+		return false
+	}
+	for _, t := range texts[1:] {
+		if strings.ToLower(strings.Join(strings.Fields(t), " ")) != first {
+			return false
+		}
+	}
+	return true
+}
+
+// takeRecoveryNote returns and clears the note left by a failed recovery strategy
+func (o *Orchestrator) takeRecoveryNote() string {
+	note := o.recoveryNote
+	o.recoveryNote = ""
+	return note
+}
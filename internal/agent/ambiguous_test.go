@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// mailPage mirrors internal/browser/testdata/ambiguous_delete.html: three different .delete
+// buttons and two identical .remove ones
+var mailPage = browser.FakePage{
+	URL: "https://mail.example.com/inbox",
+	Elements: []browser.FakeElement{
+		{Selector: ".delete", Role: "button", Text: "Удалить"},
+		{Selector: ".delete", Role: "button", Text: "Удалить всё"},
+		{Selector: ".delete", Role: "button", Text: "Удалить навсегда"},
+		{Selector: ".remove", Role: "button", Text: "Remove"},
+		{Selector: ".remove", Role: "button", Text: "Remove", Navigate: "https://mail.example.com/removed"},
+	},
+}
+
+func TestAmbiguousLocatorRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  string
+		wantClick []string // Click selectors in order
+		wantURL   string
+		wantStep  string // Part of the step's history result
+	}{
+		{
+			name:      "different texts are listed for the planner",
+			selector:  ".delete",
+			wantClick: []string{".delete"},
+			wantURL:   mailPage.URL,
+			wantStep:  "selector matched 3 elements: 'Удалить', 'Удалить всё', 'Удалить навсегда' — be more specific or use an index",
+		},
+		{
+			name:      "identical texts click the first match",
+			selector:  ".remove",
+			wantClick: []string{".remove", ".remove >> nth=0"},
+			wantURL:   mailPage.URL, // The first .remove does not navigate, the second would
+			wantStep:  "recovered via ambiguous_locator: clicked",
+		},
+	}
+	summary := snapshot.Summary{URL: mailPage.URL, Elements: []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Удалить", Sel: ".delete"},
+		{Index: 2, Role: "button", Text: "Remove", Sel: ".remove"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(mailPage, browser.FakePage{URL: "https://mail.example.com/removed"})
+			ctrl.FailNext("Click", fmt.Errorf("strict mode violation: locator(%q) resolved to several elements", tt.selector))
+			// Deleting needs approval; the test is about the locator, not the confirmation
+			cfg := Config{ConfirmationPolicy: ConfirmationPolicy{Mode: ConfirmAutoApprove}}
+			result, err := runWithController(t, cfg, ctrl, summary,
+				decision("click_selector", map[string]any{"selector": tt.selector}),
+				finishDecision("done", true),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var clicks []string
+			for _, c := range ctrl.CallsTo("Click") {
+				clicks = append(clicks, c.Args[0].(string))
+			}
+			if fmt.Sprint(clicks) != fmt.Sprint(tt.wantClick) {
+				t.Errorf("clicks %q, want %q", clicks, tt.wantClick)
+			}
+			if n := len(ctrl.CallsTo("ClickText")) + len(ctrl.CallsTo("ClickRole")) + len(ctrl.CallsTo("ClickByTextFuzzy")); n != 0 {
+				t.Errorf("calls = %v, want no other click strategies after an ambiguous locator", ctrl.Calls())
+			}
+			if ctrl.Current().URL != tt.wantURL {
+				t.Errorf("page = %s, want %s", ctrl.Current().URL, tt.wantURL)
+			}
+			if len(result.History) == 0 || !strings.Contains(result.History[0].Result, tt.wantStep) {
+				t.Errorf("history = %+v, want the step to report %q", result.History, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestSameTexts(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  bool
+	}{
+		{[]string{"Remove", " remove "}, true},
+		{[]string{"Удалить", "Удалить всё"}, false},
+		{[]string{"", ""}, false},
+	}
+	for _, tt := range tests {
+		if got := sameTexts(tt.texts); got != tt.want {
+			t.Errorf("sameTexts(%q) = %v, want %v", tt.texts, got, tt.want)
+		}
+	}
+}
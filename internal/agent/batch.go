@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultBatchMaxItems     = 20
+	defaultBatchStepsPerItem = 10
+	batchItemPlaceholder     = "{item}"
+	batchNotesKept           = 3 // Outcomes of previous items passed to the next one
+)
+
+// BatchSpec turns a task into two phases: the task itself collects a list of items,
+// then every item is processed by a bounded sub-run with its own history (shared browser).
+type BatchSpec struct {
+	ItemTask     string // Task for one item, "{item}" is replaced with the item
+	MaxItems     int    // 0 = defaultBatchMaxItems
+	StepsPerItem int    // 0 = defaultBatchStepsPerItem
+}
+
+// BatchItemResult is one element of the RunResult.Output JSON array of a batch run
+type BatchItemResult struct {
+	Item    string `json:"item"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+const batchCollectInstruction = `
+
+BATCH MODE - PHASE 1: only collect the list of items to process (use collect_texts, attribute "href" for links). Do NOT process the items yourself.
+When the list is complete, finish with message = a JSON array of strings, one per item, e.g. ["https://example.com/a", "https://example.com/b"].`
+
+// runBatch collects the item list, then runs a sub-run per item. Item failures are recorded
+// in the output and never abort the batch.
+func (o *Orchestrator) runBatch(ctx context.Context, task Task, snap summaryFunc) (RunResult, error) {
+	spec := *task.Batch
+	if spec.MaxItems <= 0 {
+		spec.MaxItems = defaultBatchMaxItems
+	}
+	if spec.StepsPerItem <= 0 {
+		spec.StepsPerItem = defaultBatchStepsPerItem
+	}
+	if !strings.Contains(spec.ItemTask, batchItemPlaceholder) {
+		return o.newRunResult(), fmt.Errorf("batch item task must contain %s", batchItemPlaceholder)
+	}
+
+	collected, err := o.run(ctx, Task{Description: task.Description + batchCollectInstruction}, snap)
+	if err != nil {
+		return collected, fmt.Errorf("batch collect phase: %w", err)
+	}
+	items, err := parseBatchItems(collected.FinalMessage)
+	if err != nil {
+		err = fmt.Errorf("batch collect phase: %w", err)
+		collected.Success = false
+		collected.fail(FailureInvalidDecision, err, "finish", "")
+		return collected, err
+	}
+	if len(items) > spec.MaxItems {
+		o.logger.Warn().Int("items", len(items)).Int("max", spec.MaxItems).Msg("batch list truncated")
+		items = items[:spec.MaxItems]
+	}
+	o.logger.Info().Int("items", len(items)).Msg("batch list collected")
+
+	result := collected
+	result.Output = ""
+	outcomes := make([]BatchItemResult, 0, len(items))
+
+	// Sub-runs get their own step budget; checkpoints and resume apply to the batch as a whole
+	saved := o.cfg
+	o.cfg.MaxSteps = spec.StepsPerItem
+	o.cfg.HistoryPath = ""
+	o.cfg.Resume = nil
+	defer func() { o.cfg = saved }()
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			result.fail(FailureUserCancel, err, "", "")
+			result.Output = marshalBatch(outcomes)
+			return result, err
+		}
+		o.usage.stepBase = result.Steps
+		desc := strings.ReplaceAll(spec.ItemTask, batchItemPlaceholder, item) + batchNotes(outcomes)
+		o.logger.Info().Int("item", i+1).Int("of", len(items)).Str("value", item).Msg("batch item")
+
+		sub, err := o.run(ctx, Task{Description: desc}, snap)
+		outcome := BatchItemResult{Item: item, Success: err == nil && sub.Success, Message: sub.FinalMessage, Output: sub.Output}
+		if err != nil {
+			outcome.Error = err.Error()
+			if sub.FailureReason != nil {
+				outcome.Error = sub.FailureReason.Kind + ": " + outcome.Error
+			}
+		}
+		outcomes = append(outcomes, outcome)
+		result.Steps += sub.Steps
+		result.History = append(result.History, sub.History...)
+		result.Artifacts = append(result.Artifacts, sub.Artifacts...)
+	}
+
+	succeeded := 0
+	for _, out := range outcomes {
+		if out.Success {
+			succeeded++
+		}
+	}
+	result.Success = true
+	result.FinalMessage = fmt.Sprintf("Batch done: %d of %d items succeeded", succeeded, len(outcomes))
+	result.Output = marshalBatch(outcomes)
+	return result, nil
+}
+
+// parseBatchItems reads the JSON array of items from the collect phase finish message
+func parseBatchItems(message string) ([]string, error) {
+	start, end := strings.Index(message, "["), strings.LastIndex(message, "]")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("finish message has no JSON array of items: %q", truncateText(message, 200))
+	}
+	var raw []any
+	if err := json.Unmarshal([]byte(message[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("parse item list: %w", err)
+	}
+	items := make([]string, 0, len(raw))
+	for _, v := range raw {
+		item := strings.TrimSpace(fmt.Sprint(v))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item list is empty")
+	}
+	return items, nil
+}
+
+// batchNotes passes the outcome of the last items on, so sub-runs share what was learned
+func batchNotes(outcomes []BatchItemResult) string {
+	if len(outcomes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nNotes from previous items:")
+	for _, out := range outcomes[max(0, len(outcomes)-batchNotesKept):] {
+		status := "ok"
+		if !out.Success {
+			status = "failed: " + truncateText(out.Error, 120)
+		}
+		fmt.Fprintf(&b, "\n- %s (%s) %s", truncateText(out.Item, 80), status, truncateText(out.Message, 120))
+	}
+	return b.String()
+}
+
+func marshalBatch(outcomes []BatchItemResult) string {
+	data, err := json.Marshal(outcomes)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
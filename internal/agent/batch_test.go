@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// catalogSummary is the 5-item fixture list the collect phase reads
+var catalogSummary = func() snapshot.Summary {
+	s := snapshot.Summary{URL: "https://shop.example.com/catalog", Title: "Catalog"}
+	for i := 1; i <= 5; i++ {
+		s.Elements = append(s.Elements, snapshot.Element{Index: i, Role: "link", Text: fmt.Sprintf("Product %d", i), Sel: fmt.Sprintf("#p-%d", i)})
+	}
+	return s
+}()
+
+func productURL(i int) string { return fmt.Sprintf("https://shop.example.com/p/%d", i) }
+
+func runBatchScript(t *testing.T, spec BatchSpec, responses ...string) (RunResult, *llm.ScriptedClient, *browser.FakeController, error) {
+	t.Helper()
+	client := llm.NewScriptedClient(responses)
+	ctrl := browser.NewFakeController(browser.FakePage{URL: catalogSummary.URL})
+	orch := NewOrchestrator(Config{MaxSteps: 5, Quiet: true}, NewPlanner(client), tools.New(ctrl, nil), zerolog.Nop())
+	task := Task{Description: "get the price of every product in the catalog", Batch: &spec}
+	result, err := orch.Run(context.Background(), task, func(context.Context) (snapshot.Summary, error) {
+		return catalogSummary, nil
+	})
+	return result, client, ctrl, err
+}
+
+func TestBatchOverFiveItems(t *testing.T) {
+	var urls []string
+	for i := 1; i <= 5; i++ {
+		urls = append(urls, productURL(i))
+	}
+	list, _ := json.Marshal(urls)
+	open := func(i int) string { return decision("navigate", map[string]any{"url": productURL(i)}) }
+	script := []string{
+		decision("list_elements", map[string]any{"role": "link"}),
+		finishDecision("Collected: "+string(list), true),
+		open(1), finishDecision("price 10", true),
+		open(2), finishDecision("price 20", true),
+		open(3), open(3), // Item 3 runs out of steps
+		open(4), finishDecision("price 40", true),
+		open(5), finishDecision("price 50", true),
+	}
+	result, client, ctrl, err := runBatchScript(t, BatchSpec{ItemTask: "open {item} and read the price", StepsPerItem: 2}, script...)
+	if err != nil {
+		t.Fatalf("a failed item aborted the batch: %v", err)
+	}
+	if !result.Success || result.FinalMessage != "Batch done: 4 of 5 items succeeded" {
+		t.Fatalf("result = %+v", result)
+	}
+	var outcomes []BatchItemResult
+	if err := json.Unmarshal([]byte(result.Output), &outcomes); err != nil {
+		t.Fatalf("output %q is not a JSON array: %v", result.Output, err)
+	}
+	if len(outcomes) != 5 {
+		t.Fatalf("%d outcomes, want 5", len(outcomes))
+	}
+	for i, out := range outcomes {
+		if out.Item != productURL(i+1) {
+			t.Errorf("outcome %d is for %q", i, out.Item)
+		}
+		if i == 2 {
+			if out.Success || !strings.HasPrefix(out.Error, FailureStepLimit+": ") {
+				t.Errorf("failed item = %+v, want a step_limit error", out)
+			}
+			continue
+		}
+		if !out.Success || out.Message != fmt.Sprintf("price %d0", i+1) || out.Error != "" {
+			t.Errorf("item %d = %+v", i+1, out)
+		}
+	}
+	if result.Steps != len(script) {
+		t.Errorf("steps = %d, want %d across the phases", result.Steps, len(script))
+	}
+	if n := len(ctrl.CallsTo("Navigate")); n != 6 {
+		t.Errorf("%d navigations, want 6", n)
+	}
+
+	reqs := client.Requests()
+	if len(reqs) != len(script) {
+		t.Fatalf("%d planner calls, want %d", len(reqs), len(script))
+	}
+	if msg := reqs[0].Messages[0].Content; !strings.Contains(msg, "BATCH MODE - PHASE 1") {
+		t.Error("collect phase prompt misses the batch instruction")
+	}
+	if msg := reqs[2].Messages[0].Content; !strings.Contains(msg, "open "+productURL(1)+" and read the price") || strings.Contains(msg, "Notes from previous items") {
+		t.Error("first item prompt must name the item and carry no notes")
+	}
+	// Item 4 starts with fresh history but with the notes of items 1-3
+	msg := reqs[8].Messages[0].Content
+	if !strings.Contains(msg, "open "+productURL(4)) || !strings.Contains(msg, productURL(3)+" (failed: "+FailureStepLimit) || strings.Contains(msg, "<step_1>") {
+		t.Errorf("item 4 prompt misses the notes or repeats old history:\n%s", msg)
+	}
+}
+
+func TestBatchLimitsItems(t *testing.T) {
+	result, _, _, err := runBatchScript(t, BatchSpec{ItemTask: "open {item}", MaxItems: 2},
+		finishDecision(`["a", "b", "c", "d", "e"]`, true),
+		finishDecision("done a", true),
+		finishDecision("done b", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outcomes []BatchItemResult
+	if err := json.Unmarshal([]byte(result.Output), &outcomes); err != nil || len(outcomes) != 2 {
+		t.Fatalf("output = %s, want the first 2 items", result.Output)
+	}
+}
+
+func TestBatchCollectPhaseFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   BatchSpec
+		script []string
+		want   string
+	}{
+		{name: "no placeholder", spec: BatchSpec{ItemTask: "open it"}, want: "must contain {item}"},
+		{name: "no list", spec: BatchSpec{ItemTask: "open {item}"}, script: []string{finishDecision("nothing found", true)}, want: "no JSON array"},
+		{name: "empty list", spec: BatchSpec{ItemTask: "open {item}"}, script: []string{finishDecision(`[" ", ""]`, true)}, want: "item list is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, _, err := runBatchScript(t, tt.spec, tt.script...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if result.Success {
+				t.Fatal("failed batch reported success")
+			}
+		})
+	}
+}
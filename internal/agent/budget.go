@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+const (
+	// promptContextShare of the context left after the output reserve goes to the prompt;
+	// the rest absorbs the token estimate error and tool definitions
+	promptContextShare = 0.8
+	minPromptTokens    = 2000
+	runesPerToken      = 4 // Rough average across English and Cyrillic text
+)
+
+// promptBudget derives the prompt token limit from the model capacity
+func promptBudget(info llm.ModelInfo, outputTokens int) int {
+	budget := int(float64(info.ContextTokens-outputTokens) * promptContextShare)
+	if budget < minPromptTokens {
+		budget = minPromptTokens
+	}
+	return budget
+}
+
+func estimateTokens(s string) int {
+	return utf8.RuneCountInString(s)/runesPerToken + 1
+}
+
+// fitPrompt trims the prompt parts to opts.MaxPromptTokens: oldest history steps go first,
+// then the tail of the element list. Returns the guidance and formatted history to render.
+func (p *fastPlanner) fitPrompt(system, guidance string, history []HistoryItem, render func(guidance, history string) string) (string, string) {
+	budget := p.opts.MaxPromptTokens
+	over := func(g, h string) int {
+		return estimateTokens(system) + estimateTokens(render(g, h)) - budget
+	}
+	formatted := formatHistory(history, 0)
+	if budget <= 0 || over(guidance, formatted) <= 0 {
+		return guidance, formatted
+	}
+	// Recent steps carry the memory and the latest results - keep at least the last one
+	for skip := 1; skip < len(history); skip++ {
+		formatted = fmt.Sprintf("(%d earlier steps omitted)\n\n", skip) + formatHistory(history[skip:], skip)
+		if over(guidance, formatted) <= 0 {
+			return guidance, formatted
+		}
+	}
+	keep := utf8.RuneCountInString(guidance) - over(guidance, formatted)*runesPerToken
+	return cutLines(guidance, keep) + "... (element list cut to fit the model context)\n", formatted
+}
+
+// cutLines cuts s to maxRunes at the last complete line
+func cutLines(s string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	cut := cutRunes(s, maxRunes)
+	if len(cut) == len(s) {
+		return s
+	}
+	if i := strings.LastIndex(cut, "\n"); i >= 0 {
+		return cut[:i+1]
+	}
+	return ""
+}
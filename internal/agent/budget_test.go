@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestPromptBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   llm.ModelInfo
+		output int
+		want   int
+	}{
+		{name: "large window", info: llm.ModelInfo{ContextTokens: 200000}, output: 2000, want: 158400},
+		{name: "gpt-4o-mini", info: llm.ModelInfo{ContextTokens: 128000}, output: 2000, want: 100800},
+		{name: "small local model", info: llm.ModelInfo{ContextTokens: 8192}, output: 2048, want: 4915},
+		{name: "just above the floor", info: llm.ModelInfo{ContextTokens: 4552}, output: 2048, want: 2003},
+		{name: "exactly the floor", info: llm.ModelInfo{ContextTokens: 4548}, output: 2048, want: minPromptTokens},
+		{name: "below the floor", info: llm.ModelInfo{ContextTokens: 4096}, output: 2048, want: minPromptTokens},
+		{name: "output larger than the window", info: llm.ModelInfo{ContextTokens: 1000}, output: 2000, want: minPromptTokens},
+		{name: "no window", output: 2000, want: minPromptTokens},
+	}
+	for _, tt := range tests {
+		if got := promptBudget(tt.info, tt.output); got != tt.want {
+			t.Errorf("%s: promptBudget = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sizedClient is a scripted model with a chosen capacity
+type sizedClient struct {
+	*llm.ScriptedClient
+	info llm.ModelInfo
+}
+
+func (c sizedClient) ModelInfo() llm.ModelInfo { return c.info }
+
+func TestPlannerDerivesItsBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       llm.ModelInfo
+		opts       PlannerOptions
+		wantOutput int
+		wantPrompt int
+	}{
+		{name: "defaults", info: llm.ModelInfo{ContextTokens: 128000, MaxOutputTokens: 16384}, wantOutput: defaultPlannerMaxTokens, wantPrompt: 100800},
+		{name: "output capped by the model", info: llm.ModelInfo{ContextTokens: 8192, MaxOutputTokens: 1024}, opts: PlannerOptions{MaxTokens: 4000}, wantOutput: 1024, wantPrompt: 5734},
+		{name: "unknown output limit", info: llm.ModelInfo{ContextTokens: 8192}, opts: PlannerOptions{MaxTokens: 4000}, wantOutput: 4000, wantPrompt: 3353},
+		{name: "explicit prompt limit wins", info: llm.ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}, opts: PlannerOptions{MaxPromptTokens: 3000}, wantOutput: defaultPlannerMaxTokens, wantPrompt: 3000},
+		{name: "negative values mean default", info: llm.ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}, opts: PlannerOptions{MaxTokens: -1, MaxPromptTokens: -1}, wantOutput: defaultPlannerMaxTokens, wantPrompt: 158400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPlannerWithOptions(sizedClient{llm.NewScriptedClient(nil), tt.info}, tt.opts).(*fastPlanner)
+			if p.opts.MaxTokens != tt.wantOutput || p.opts.MaxPromptTokens != tt.wantPrompt {
+				t.Fatalf("max tokens %d, prompt tokens %d; want %d, %d", p.opts.MaxTokens, p.opts.MaxPromptTokens, tt.wantOutput, tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestPlannerTrimsOldestHistoryFirst(t *testing.T) {
+	var history []HistoryItem
+	for i := 1; i <= 40; i++ {
+		history = append(history, HistoryItem{Action: "navigate", Result: fmt.Sprintf("step %d: %s", i, strings.Repeat("результат ", 40)), URL: "https://example.com"})
+	}
+	client := sizedClient{llm.NewScriptedClient([]string{finishDecision("done", true)}), llm.ModelInfo{ContextTokens: 8192, MaxOutputTokens: 2048}}
+	state := State{Task: "read the results", Summary: snapshot.Summary{URL: "https://example.com"}, History: history}
+	if _, err := NewPlannerWithOptions(client, PlannerOptions{}).Next(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	req := client.Requests()[0]
+	msg := req.Messages[0].Content
+	if !strings.Contains(msg, "earlier steps omitted") || !strings.Contains(msg, "step 40:") || strings.Contains(msg, "step 1:") {
+		t.Fatal("history was not trimmed from the oldest step")
+	}
+	if tokens := estimateTokens(req.System) + estimateTokens(msg); tokens > promptBudget(client.info, defaultPlannerMaxTokens) {
+		t.Fatalf("prompt is ~%d tokens, budget %d", tokens, promptBudget(client.info, defaultPlannerMaxTokens))
+	}
+}
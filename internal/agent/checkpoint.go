@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// checkpointVersion is bumped on incompatible changes of the checkpoint format
+const checkpointVersion = 1
+
+// Checkpoint is the persisted run state used to resume a run that died midway
+type Checkpoint struct {
+	Version int           `json:"version"`
+	Task    string        `json:"task"`
+	Step    int           `json:"step"` // Completed steps
+	URL     string        `json:"url"`  // Page to return to
+	History []HistoryItem `json:"history"`
+	Memory  TaskMemory    `json:"memory"`
+	SavedAt time.Time     `json:"saved_at"`
+}
+
+// LoadCheckpoint reads a checkpoint written with Config.HistoryPath
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s is corrupted: %w", path, err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s has version %d, this build reads version %d - start the task anew", path, cp.Version, checkpointVersion)
+	}
+	if cp.Step < 0 || strings.TrimSpace(cp.Task) == "" {
+		return nil, fmt.Errorf("checkpoint %s is incomplete (no task or negative step)", path)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint writes the run state to Config.HistoryPath; failures are logged, never fatal
+func (o *Orchestrator) saveCheckpoint(task Task, step int, url string, history []HistoryItem) {
+	if o.cfg.HistoryPath == "" {
+		return
+	}
+	cp := Checkpoint{
+		Version: checkpointVersion,
+		Task:    task.Description,
+		Step:    step,
+		URL:     url,
+		History: history,
+		SavedAt: time.Now(),
+	}
+	if o.memory != nil {
+		cp.Memory = *o.memory
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		o.logger.Warn().Err(err).Msg("marshal checkpoint")
+		return
+	}
+	// A crash mid-write must not destroy the previous checkpoint
+	if err := browser.WriteFileAtomic(o.cfg.HistoryPath, data, 0o600); err != nil {
+		o.logger.Warn().Err(err).Str("path", o.cfg.HistoryPath).Msg("write checkpoint")
+	}
+}
+
+// resumeAt returns the browser to the checkpoint page
+func (o *Orchestrator) resumeAt(ctx context.Context, cp *Checkpoint) error {
+	o.logger.Info().
+		Int("step", cp.Step).
+		Int("history", len(cp.History)).
+		Str("url", cp.URL).
+		Msg("resuming from checkpoint")
+	if !strings.HasPrefix(cp.URL, "http://") && !strings.HasPrefix(cp.URL, "https://") {
+		return nil
+	}
+	_, err := o.tools.Invoke(ctx, "navigate", map[string]any{"url": cp.URL})
+	return err
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func TestCheckpointWrittenAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.json")
+	// A stale world-readable checkpoint from an older build is replaced, not rewritten in place
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	_, _, err := scriptedRun(t, Config{HistoryPath: path}, page, loginSummary, nil,
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Step == 0 || len(cp.History) == 0 {
+		t.Errorf("checkpoint = %+v, want the clicked step recorded", cp)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("checkpoint mode %o, want 600", perm)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only the checkpoint without temp files", names)
+	}
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// now reads Config.Now
+func (o *Orchestrator) now() time.Time {
+	if o.cfg.Now != nil {
+		return o.cfg.Now()
+	}
+	return time.Now()
+}
+
+// dateVarPattern matches the date variables of a task: {today}, {tomorrow}, {yesterday},
+// {today+N} / {today-N} (YYYY-MM-DD, the set_date format) and {now} (YYYY-MM-DD HH:MM)
+var dateVarPattern = regexp.MustCompile(`\{(today|tomorrow|yesterday|now)([+-]\d{1,3})?\}`)
+
+// expandDateVars resolves date variables against now, which is already in the browser
+// timezone; offsets are accepted on {today} only, other unknown forms are left as is
+func expandDateVars(text string, now time.Time) string {
+	return dateVarPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := dateVarPattern.FindStringSubmatch(match)
+		days := 0
+		if m[2] != "" {
+			if m[1] != "today" {
+				return match
+			}
+			days, _ = strconv.Atoi(m[2])
+		}
+		switch m[1] {
+		case "now":
+			return now.Format("2006-01-02 15:04")
+		case "tomorrow":
+			days = 1
+		case "yesterday":
+			days = -1
+		}
+		return now.AddDate(0, 0, days).Format("2006-01-02")
+	})
+}
+
+// browserLocation is the zone of the page clock: the IANA zone when this machine knows it,
+// otherwise a fixed zone with the page's offset; the machine zone when the page reported nothing
+func browserLocation(clock *snapshot.PageClock, machine *time.Location) *time.Location {
+	if clock == nil {
+		return machine
+	}
+	if clock.Timezone != "" {
+		if loc, err := time.LoadLocation(clock.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(utcOffset(clock.Offset*60), clock.Offset*60)
+}
+
+// timeContext is the Time line of <agent_state>: the current time in the browser timezone,
+// plus the agent machine's time (the zone of now) when its zone differs; "" when now is zero
+func timeContext(now time.Time, clock *snapshot.PageClock) string {
+	if now.IsZero() {
+		return ""
+	}
+	page := now.In(browserLocation(clock, now.Location()))
+	zone := utcOffset(offsetSeconds(page))
+	if clock != nil && clock.Timezone != "" {
+		zone = clock.Timezone + " " + zone
+	}
+	line := fmt.Sprintf("Time: %s (%s), browser timezone %s - dates and times on the site are in this zone",
+		page.Format("2006-01-02 15:04"), page.Weekday(), zone)
+	if offsetSeconds(now) != offsetSeconds(page) {
+		line += fmt.Sprintf("; agent machine: %s %s", now.Format("2006-01-02 15:04"), utcOffset(offsetSeconds(now)))
+	}
+	return line
+}
+
+func offsetSeconds(t time.Time) int {
+	_, offset := t.Zone()
+	return offset
+}
+
+// utcOffset formats an offset in seconds as UTC+03:00
+func utcOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
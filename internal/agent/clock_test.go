@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// lateEvening is 22:30 UTC on March 31: already April 1 in Moscow, still March 31 in New York
+var lateEvening = time.Date(2026, 3, 31, 22, 30, 0, 0, time.UTC)
+
+var moscowClock = &snapshot.PageClock{Timezone: "Europe/Moscow", Offset: 180}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("no tz data for %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestExpandDateVars(t *testing.T) {
+	now := lateEvening.In(mustLoad(t, "Europe/Moscow"))
+	got := expandDateVars("Book {tomorrow} at 10:00, not {yesterday}; {today}, {today+30}, {today-1}, {now}, {tomorrow+1}, {someday}", now)
+	want := "Book 2026-04-02 at 10:00, not 2026-03-31; 2026-04-01, 2026-05-01, 2026-03-31, 2026-04-01 01:30, {tomorrow+1}, {someday}"
+	if got != want {
+		t.Fatalf("expandDateVars =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTimeContext(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+	newYork := mustLoad(t, "America/New_York")
+	const site = " - dates and times on the site are in this zone"
+	tests := []struct {
+		name  string
+		now   time.Time
+		clock *snapshot.PageClock
+		want  string
+	}{
+		{
+			name:  "browser zone differs from the machine",
+			now:   lateEvening,
+			clock: moscowClock,
+			want:  "Time: 2026-04-01 01:30 (Wednesday), browser timezone Europe/Moscow UTC+03:00" + site + "; agent machine: 2026-03-31 22:30 UTC+00:00",
+		},
+		{
+			name:  "same zone",
+			now:   lateEvening.In(moscow),
+			clock: moscowClock,
+			want:  "Time: 2026-04-01 01:30 (Wednesday), browser timezone Europe/Moscow UTC+03:00" + site,
+		},
+		{
+			name:  "zone name unknown to this machine",
+			now:   lateEvening,
+			clock: &snapshot.PageClock{Timezone: "Mars/Olympus_Mons", Offset: -150},
+			want:  "Time: 2026-03-31 20:00 (Tuesday), browser timezone Mars/Olympus_Mons UTC-02:30" + site + "; agent machine: 2026-03-31 22:30 UTC+00:00",
+		},
+		{
+			name:  "offset only",
+			now:   lateEvening,
+			clock: &snapshot.PageClock{Offset: 330},
+			want:  "Time: 2026-04-01 04:00 (Wednesday), browser timezone UTC+05:30" + site + "; agent machine: 2026-03-31 22:30 UTC+00:00",
+		},
+		{
+			name: "page reported nothing",
+			now:  lateEvening.In(newYork),
+			want: "Time: 2026-03-31 18:30 (Tuesday), browser timezone UTC-04:00" + site,
+		},
+		{name: "no clock", clock: moscowClock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeContext(tt.now, tt.clock); got != tt.want {
+				t.Fatalf("timeContext =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigNowReachesThePlanner(t *testing.T) {
+	mustLoad(t, "Europe/Moscow")
+	tests := []struct {
+		name     string
+		clock    *snapshot.PageClock
+		wantTask string
+		wantTime string
+	}{
+		{name: "browser zone", clock: moscowClock, wantTask: "Book a slot for 2026-04-02 at 10:00", wantTime: "Time: 2026-04-01 01:30 (Wednesday), browser timezone Europe/Moscow"},
+		{name: "machine zone", wantTask: "Book a slot for 2026-04-01 at 10:00", wantTime: "Time: 2026-03-31 22:30 (Tuesday), browser timezone UTC+00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := llm.NewScriptedClient([]string{finishDecision("booked", true)})
+			cfg := Config{MaxSteps: 2, Quiet: true, Now: func() time.Time { return lateEvening }}
+			orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(browser.NewFakeController(loginPage), nil), zerolog.Nop())
+			summary := snapshot.Summary{URL: "https://clinic.example/slots", Clock: tt.clock}
+			if _, err := orch.Run(context.Background(), Task{Description: "Book a slot for {tomorrow} at 10:00"}, func(context.Context) (snapshot.Summary, error) {
+				return summary, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			prompt := client.Requests()[0].Messages[0].Content
+			if !strings.Contains(prompt, tt.wantTask) || !strings.Contains(prompt, tt.wantTime) {
+				t.Fatalf("prompt misses %q or %q:\n%s", tt.wantTask, tt.wantTime, prompt)
+			}
+		})
+	}
+}
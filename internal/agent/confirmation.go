@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Confirmation modes for destructive actions (ConfirmationPolicy.Mode and overrides)
+const (
+	ConfirmPrompt      = "prompt"       // Ask the user (terminal or ConfirmationPolicy.Callback)
+	ConfirmAutoApprove = "auto-approve" // Execute without asking (unattended runs)
+	ConfirmDeny        = "deny"         // Refuse; the planner sees the refusal in history
+)
+
+// Severity tiers of the confirmation keywords
+const (
+	SeverityFinancial = "financial" // Payments and purchases
+	SeverityGeneric   = "generic"   // Deleting, submitting, cancelling
+)
+
+// defaultConfirmationKeywords match the action target text (case-insensitive substring;
+// whole words for the label of a filled field)
+var defaultConfirmationKeywords = map[string][]string{
+	SeverityFinancial: {"payment", "оплатить", "купить", "buy", "purchase", "checkout", "pay"},
+	SeverityGeneric: {
+		"delete", "удалить",
+		"remove", "очистить", "clear",
+		"submit", "отправить", "подтвердить",
+		"confirm",
+		"cancel", "отменить",
+		"archive", "архив",
+		"unsubscribe", "отписаться",
+	},
+}
+
+// ConfirmFunc decides on a destructive action instead of the terminal prompt
+// (e.g. a server routing confirmations to its own UI)
+type ConfirmFunc func(ctx context.Context, action string, input map[string]any) (bool, error)
+
+// ConfirmationPolicy decides what happens to actions matching a confirmation keyword.
+// The mode is taken from Overrides[keyword], then SeverityModes[severity], then Mode.
+type ConfirmationPolicy struct {
+	Mode          string              // Default ConfirmPrompt
+	SeverityModes map[string]string   // Per tier, e.g. {"generic": "auto-approve"}
+	Overrides     map[string]string   // Per keyword, e.g. {"submit": "auto-approve"}
+	Keywords      map[string][]string // Keywords per tier, nil = defaultConfirmationKeywords
+	Callback      ConfirmFunc         // Replaces the terminal prompt in ConfirmPrompt mode
+}
+
+// ValidConfirmMode reports whether mode is one of the Confirm* modes
+func ValidConfirmMode(mode string) bool {
+	switch mode {
+	case ConfirmPrompt, ConfirmAutoApprove, ConfirmDeny:
+		return true
+	}
+	return false
+}
+
+// fillActions type text into a field; their "text" is the typed value, not the target
+var fillActions = map[string]bool{"fill": true, "fill_by_index": true, "fill_and_submit": true}
+
+// match returns the first keyword (financial tier first) found in the action target.
+// Fills are judged by the field's label (see confirmationInput), never by the typed value,
+// and only whole words count there: "Display name" is no payment field.
+func (p ConfirmationPolicy) match(action string, input map[string]any) (keyword, severity string) {
+	switch action {
+	case "click_selector", "click_role", "click_text", "click_by_index":
+	case "fill", "fill_by_index", "fill_and_submit":
+		input = map[string]any{"selector": input["selector"], "label": input["label"]}
+	default:
+		return "", ""
+	}
+	found := strings.Contains
+	if fillActions[action] {
+		found = containsWord
+	}
+
+	// Most specific field wins: label > text > name > role > selector
+	var target string
+	if selector, ok := input["selector"].(string); ok {
+		target = selector
+	}
+	if role, ok := input["role"].(string); ok {
+		target = role
+	}
+	if name, ok := input["name"].(string); ok && name != "" {
+		target = name
+	}
+	if text, ok := input["text"].(string); ok {
+		target = text
+	}
+	if label, ok := input["label"].(string); ok {
+		target = label
+	}
+	target = strings.ToLower(target)
+
+	keywords := p.Keywords
+	if keywords == nil {
+		keywords = defaultConfirmationKeywords
+	}
+	for _, tier := range []string{SeverityFinancial, SeverityGeneric} {
+		for _, kw := range keywords[tier] {
+			if kw != "" && found(target, strings.ToLower(kw)) {
+				return kw, tier
+			}
+		}
+	}
+	// Custom tiers beyond the two defaults
+	for tier, list := range keywords {
+		if tier == SeverityFinancial || tier == SeverityGeneric {
+			continue
+		}
+		for _, kw := range list {
+			if kw != "" && found(target, strings.ToLower(kw)) {
+				return kw, tier
+			}
+		}
+	}
+	return "", ""
+}
+
+// containsWord reports whether word occurs in s between non-letter, non-digit runes
+func containsWord(s, word string) bool {
+	for from := 0; from <= len(s)-len(word); {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := from+i, from+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		from = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// confirmedAnswer accepts only an explicit yes to a confirmation prompt
+func confirmedAnswer(answer string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(answer)), ".!") {
+	case "yes", "y", "да", "д":
+		return true
+	}
+	return false
+}
+
+func (p ConfirmationPolicy) modeFor(keyword, severity string) string {
+	if mode, ok := p.Overrides[keyword]; ok && ValidConfirmMode(mode) {
+		return mode
+	}
+	if mode, ok := p.SeverityModes[severity]; ok && ValidConfirmMode(mode) {
+		return mode
+	}
+	if ValidConfirmMode(p.Mode) {
+		return p.Mode
+	}
+	return ConfirmPrompt
+}
+
+// confirmAction applies the policy to an action matching keyword. refusal describes
+// who refused, for the history entry.
+func (o *Orchestrator) confirmAction(ctx context.Context, keyword, severity, action string, input map[string]any, pageURL string) (ok bool, refusal string, err error) {
+	mode := o.cfg.ConfirmationPolicy.modeFor(keyword, severity)
+	o.logger.Info().Str("action", action).Str("keyword", keyword).Str("severity", severity).Str("mode", mode).Msg("confirmation required")
+	switch mode {
+	case ConfirmAutoApprove:
+		return true, "", nil
+	case ConfirmDeny:
+		return false, fmt.Sprintf("denied by confirmation policy (deny, %s keyword %q)", severity, keyword), nil
+	}
+	if cb := o.cfg.ConfirmationPolicy.Callback; cb != nil {
+		ok, err := cb(ctx, action, input)
+		return ok, fmt.Sprintf("rejected by confirmation callback (%s keyword %q)", severity, keyword), err
+	}
+	ok, err = o.requestConfirmation(ctx, action, input, pageURL)
+	return ok, fmt.Sprintf("cancelled by user (%s keyword %q)", severity, keyword), err
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestConfirmationMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		input   map[string]any
+		keyword string
+	}{
+		{"click text", "click_text", map[string]any{"text": "Delete account"}, "delete"},
+		{"click substring", "click_by_index", map[string]any{"index": 3, "text": "Checkout now"}, "checkout"},
+		{"click harmless", "click_selector", map[string]any{"selector": "#next"}, ""},
+		{"fill ignores the typed value", "fill", map[string]any{"selector": "#q", "text": "delete old orders", "label": "Search"}, ""},
+		{"fill by field label", "fill_by_index", map[string]any{"index": 2, "text": "x", "label": "Type DELETE to confirm"}, "delete"},
+		{"fill label needs whole words", "fill", map[string]any{"selector": "#name", "text": "Bob", "label": "Display name"}, ""},
+		{"fill label in Russian", "fill_and_submit", map[string]any{"selector": "#reason", "text": "да", "label": "Причина: удалить аккаунт"}, "удалить"},
+		{"fill without label falls back to the selector", "fill_and_submit", map[string]any{"selector": "#payment-card", "text": "4111"}, "payment"},
+		{"other tools", "navigate", map[string]any{"url": "https://example.com/delete"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyword, _ := ConfirmationPolicy{}.match(tt.action, tt.input)
+			if keyword != tt.keyword {
+				t.Errorf("match = %q, want %q", keyword, tt.keyword)
+			}
+		})
+	}
+}
+
+func TestConfirmationInputLabelsFills(t *testing.T) {
+	byIndex := confirmationInput(Decision{ActionName: "fill_by_index", ActionInput: map[string]any{"index": float64(2), "text": testPassword}}, loginSummary)
+	if byIndex["label"] != "Password" || byIndex["text"] != testPassword {
+		t.Errorf("fill_by_index input = %v, want the Password label added", byIndex)
+	}
+	bySelector := confirmationInput(Decision{ActionName: "fill", ActionInput: map[string]any{"selector": "#email", "text": "a@b.c"}}, loginSummary)
+	if bySelector["label"] != "Email" {
+		t.Errorf("fill input = %v, want the Email label added", bySelector)
+	}
+	click := confirmationInput(Decision{ActionName: "click_by_index", ActionInput: map[string]any{"index": float64(3)}}, loginSummary)
+	if click["text"] != "Sign in" {
+		t.Errorf("click_by_index input = %v, want the element text", click)
+	}
+}
+
+func TestConfirmedAnswer(t *testing.T) {
+	for answer, want := range map[string]bool{
+		"yes": true, "Y": true, "да.": true, " Yes! ": true,
+		"no": false, "no thank you": false, "not yet": false, "": false, "yesterday": false,
+	} {
+		if got := confirmedAnswer(answer); got != want {
+			t.Errorf("confirmedAnswer(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+var inboxPage = browser.FakePage{
+	URL: "https://mail.example/inbox",
+	Elements: []browser.FakeElement{
+		{Selector: "#delete-3", Role: "button", Text: "Delete"},
+	},
+}
+
+var inboxSummary = snapshot.Summary{
+	URL:   "https://mail.example/inbox",
+	Title: "Inbox",
+	Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Invoice March", Sel: "#mail-3"},
+		{Index: 2, Role: "button", Text: "Delete", Sel: "#delete-3"},
+	},
+}
+
+func TestConfirmationPromptDescribesDeleteButton(t *testing.T) {
+	for _, answer := range []string{"no thank you", "yes"} {
+		t.Run(answer, func(t *testing.T) {
+			var asked []string
+			prompt := func(_ context.Context, message string) (string, error) {
+				asked = append(asked, message)
+				return answer, nil
+			}
+			result, ctrl, err := scriptedRun(t, Config{}, inboxPage, inboxSummary, prompt,
+				decision("click_by_index", map[string]any{"index": 2}),
+				finishDecision("done", true),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(asked) != 1 {
+				t.Fatalf("asked %d times, want one confirmation", len(asked))
+			}
+			for _, want := range []string{"SECURITY CHECK", "click_by_index", `Element: "Delete"`, "Role: button", "Page: https://mail.example/inbox"} {
+				if !strings.Contains(asked[0], want) {
+					t.Errorf("prompt %q does not mention %q", asked[0], want)
+				}
+			}
+			clicked := len(ctrl.CallsTo("Click")) > 0
+			if clicked != (answer == "yes") {
+				t.Errorf("answer %q: clicked = %v", answer, clicked)
+			}
+			if answer != "yes" && !strings.Contains(result.History[0].Result, "cancelled by user") {
+				t.Errorf("history = %+v, want the cancellation recorded", result.History)
+			}
+		})
+	}
+}
+
+func TestConfirmationPromptHidesTypedText(t *testing.T) {
+	deletePage := browser.FakePage{URL: "https://example.com/account", Elements: []browser.FakeElement{{Selector: "#confirm", Role: "textbox", Text: "Type DELETE to remove the account"}}}
+	deleteSummary := snapshot.Summary{URL: deletePage.URL, Elements: []snapshot.Element{{Index: 1, Role: "textbox", Text: "Type DELETE to remove the account", Sel: "#confirm"}}}
+	var asked []string
+	prompt := func(_ context.Context, message string) (string, error) {
+		asked = append(asked, message)
+		return "no", nil
+	}
+	_, _, err := scriptedRun(t, Config{}, deletePage, deleteSummary, prompt,
+		decision("fill", map[string]any{"selector": "#confirm", "text": testPassword}),
+		finishDecision("done", false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 1 {
+		t.Fatalf("asked %d times, want one confirmation for the DELETE field", len(asked))
+	}
+	if strings.Contains(asked[0], testPassword) {
+		t.Errorf("prompt echoes the typed text: %q", asked[0])
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent/prompts"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+const (
+	// maxDeliverableRejections bounds how often a finish missing deliverables is bounced back
+	maxDeliverableRejections = 2
+	// maxDeliverables caps the checklist; longer lists are noise from over-eager extraction
+	maxDeliverables = 8
+)
+
+// Deliverable is one output the task explicitly asks for ("the price", "the delivery time")
+type Deliverable struct {
+	Item string `json:"item"`
+	Done bool   `json:"done"` // The planner's memory claims it is found
+}
+
+// DeliverableChecker extracts the deliverables of a task and checks a finish message
+// against each of them (Config.Deliverables). Usage is the LLM usage of each call,
+// counted into the run totals (-max-cost).
+type DeliverableChecker interface {
+	// Extract lists the outputs the task requests; a single-output task may return one item or none
+	Extract(ctx context.Context, task string) ([]string, llm.Usage, error)
+	// Covered reports whether the finish message answers the item
+	Covered(ctx context.Context, item, message string) (bool, llm.Usage, error)
+}
+
+type llmDeliverableChecker struct {
+	llm llm.Client
+}
+
+// NewLLMDeliverableChecker asks the model with short dedicated prompts
+func NewLLMDeliverableChecker(client llm.Client) DeliverableChecker {
+	return &llmDeliverableChecker{llm: client}
+}
+
+func (c *llmDeliverableChecker) Extract(ctx context.Context, task string) ([]string, llm.Usage, error) {
+	p := prompts.ExtractionPrompt(languageName(DetectLanguage(task)), task)
+	resp, err := c.llm.Generate(ctx, llm.Request{
+		System:      p.System,
+		Messages:    []llm.Message{{Role: "user", Content: p.User}},
+		Temperature: 0,
+		MaxTokens:   150,
+	})
+	if err != nil {
+		return nil, resp.Usage, err
+	}
+	return parseDeliverables(resp.Text), resp.Usage, nil
+}
+
+func (c *llmDeliverableChecker) Covered(ctx context.Context, item, message string) (bool, llm.Usage, error) {
+	p := prompts.CoveragePrompt(languageName(DetectLanguage(item)), item, message)
+	resp, err := c.llm.Generate(ctx, llm.Request{
+		System:      p.System,
+		Messages:    []llm.Message{{Role: "user", Content: p.User}},
+		Temperature: 0,
+		MaxTokens:   5,
+	})
+	if err != nil {
+		return false, resp.Usage, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(resp.Text))
+	switch {
+	case strings.HasPrefix(answer, "yes"):
+		return true, resp.Usage, nil
+	case strings.HasPrefix(answer, "no"):
+		return false, resp.Usage, nil
+	default:
+		return false, resp.Usage, fmt.Errorf("unexpected deliverable verdict %q", truncateText(resp.Text, 40))
+	}
+}
+
+// parseDeliverables reads one item per line, dropping list markers and duplicates
+func parseDeliverables(text string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		item := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•0123456789.) "))
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, item)
+		if len(items) == maxDeliverables {
+			break
+		}
+	}
+	return items
+}
+
+// extractDeliverables fills TaskMemory.Deliverables at run start; failures leave it empty
+func (o *Orchestrator) extractDeliverables(ctx context.Context, task string) {
+	o.memory.Deliverables = nil
+	items, usage, err := o.cfg.Deliverables.Extract(ctx, task)
+	o.recordUsage(0, usage) // Before the first step - counts into the totals only
+	if err != nil {
+		o.logger.Warn().Err(err).Msg("deliverable extraction failed - finish is not checked against a checklist")
+		return
+	}
+	for _, item := range items {
+		o.memory.Deliverables = append(o.memory.Deliverables, Deliverable{Item: item})
+	}
+	o.logger.Info().Strs("deliverables", items).Msg("task deliverables")
+}
+
+// markDeliverables ticks items whose words all appear in the planner's memory note
+func (o *Orchestrator) markDeliverables(memory string) {
+	memory = strings.ToLower(memory)
+	if memory == "" {
+		return
+	}
+	for i := range o.memory.Deliverables {
+		d := &o.memory.Deliverables[i]
+		if !d.Done && mentionsAll(memory, d.Item) {
+			d.Done = true
+		}
+	}
+}
+
+// mentionsAll reports whether text contains every significant word (4+ letters) of item
+func mentionsAll(text, item string) bool {
+	found := false
+	for _, w := range strings.Fields(strings.ToLower(item)) {
+		w = strings.Trim(w, ".,;:!?\"'()")
+		if len([]rune(w)) < 4 {
+			continue
+		}
+		if !strings.Contains(text, w) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// renderDeliverables is the checklist shown in <agent_state>
+func renderDeliverables(items []Deliverable) string {
+	if len(items) < 2 {
+		// A single deliverable is the task itself - nothing to track
+		return ""
+	}
+	var b strings.Builder
+	for _, d := range items {
+		mark := "[ ]"
+		if d.Done {
+			mark = "[x]"
+		}
+		fmt.Fprintf(&b, "%s %s\n", mark, d.Item)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// unmetDeliverables checks the finish message against every checklist item; checker
+// failures count as covered - they must not block a run
+func (o *Orchestrator) unmetDeliverables(ctx context.Context, step int, message string) []string {
+	if len(o.memory.Deliverables) < 2 {
+		return nil
+	}
+	var unmet []string
+	for _, d := range o.memory.Deliverables {
+		covered, usage, err := o.cfg.Deliverables.Covered(ctx, d.Item, message)
+		o.recordUsage(step, usage)
+		if err != nil {
+			o.logger.Warn().Err(err).Str("item", d.Item).Msg("deliverable check failed - treating as covered")
+			continue
+		}
+		if !covered {
+			unmet = append(unmet, d.Item)
+		}
+	}
+	return unmet
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const twoPartTask = "find the price and the delivery time of the Lumo lamp"
+
+var lampSummary = snapshot.Summary{URL: "https://shop.example.com/lumo", Title: "Lumo lamp"}
+
+// finishWithMemory is finishDecision with the planner's memory note
+func finishWithMemory(message, memory string) string {
+	data, _ := json.Marshal(map[string]any{
+		"thinking": "scripted", "evaluation_previous_goal": "scripted", "memory": memory, "next_goal": "scripted",
+		"action": "finish", "input": map[string]any{"message": message, "success": true},
+	})
+	return string(data)
+}
+
+// runDeliverables runs twoPartTask with a scripted planner and a scripted deliverable checker
+func runDeliverables(t *testing.T, checker []string, planner ...string) (RunResult, *llm.ScriptedClient) {
+	t.Helper()
+	client := llm.NewScriptedClient(planner)
+	cfg := Config{MaxSteps: len(planner) + 1, Quiet: true, Deliverables: NewLLMDeliverableChecker(llm.NewScriptedClient(checker))}
+	orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(browser.NewFakeController(loginPage), nil), zerolog.Nop())
+	result, err := orch.Run(context.Background(), Task{Description: twoPartTask}, func(context.Context) (snapshot.Summary, error) {
+		return lampSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, client
+}
+
+func TestFinishMissingADeliverableIsRejected(t *testing.T) {
+	result, client := runDeliverables(t,
+		[]string{"1. the price\n2. the delivery time", "yes", "no", "yes", "yes"},
+		finishWithMemory("The Lumo lamp costs 2 490 ₽", "price: 2 490 ₽"),
+		finishWithMemory("The Lumo lamp costs 2 490 ₽, delivery takes 2 days", "price: 2 490 ₽, delivery time: 2 days"),
+	)
+	if !result.Success || result.Steps != 2 {
+		t.Fatalf("result = %+v, want success on the second finish", result)
+	}
+	if len(result.History) == 0 || result.History[0].Result != "finish rejected: the message does not give the delivery time - find them, or say explicitly in the message why they are unavailable" {
+		t.Fatalf("history = %+v, want the first finish rejected for the delivery time", result.History)
+	}
+
+	// The second step shows the checklist with the price ticked from the memory note
+	reqs := client.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("%d planner calls, want 2", len(reqs))
+	}
+	prompt := reqs[1].Messages[len(reqs[1].Messages)-1].Content
+	if !strings.Contains(prompt, "[x] the price\n[ ] the delivery time") {
+		t.Errorf("second prompt misses the checklist:\n%s", prompt)
+	}
+}
+
+func TestDeliverableRejectionsAreBounded(t *testing.T) {
+	onlyPrice := finishWithMemory("The Lumo lamp costs 2 490 ₽", "")
+	checker := []string{"- the price\n- the delivery time"}
+	for i := 0; i <= maxDeliverableRejections; i++ {
+		checker = append(checker, "yes", "no")
+	}
+	result, _ := runDeliverables(t, checker, onlyPrice, onlyPrice, onlyPrice)
+	if !result.Success || result.Steps != maxDeliverableRejections+1 {
+		t.Fatalf("result = %+v, want the finish accepted after %d rejections", result, maxDeliverableRejections)
+	}
+	rejected := 0
+	for _, h := range result.History {
+		if strings.HasPrefix(h.Result, "finish rejected: the message does not give the delivery time") {
+			rejected++
+		}
+	}
+	if rejected != maxDeliverableRejections {
+		t.Errorf("%d rejections in history, want %d", rejected, maxDeliverableRejections)
+	}
+}
+
+func TestSingleDeliverableAndCheckerErrorsDoNotBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker []string
+	}{
+		{name: "single deliverable is not tracked", checker: []string{"the price"}},
+		{name: "unclear verdicts count as covered", checker: []string{"the price\nthe delivery time", "maybe", "perhaps"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := runDeliverables(t, tt.checker, finishWithMemory("The Lumo lamp costs 2 490 ₽", ""))
+			if !result.Success || result.Steps != 1 {
+				t.Fatalf("result = %+v, want the first finish accepted", result)
+			}
+		})
+	}
+}
+
+func TestParseDeliverables(t *testing.T) {
+	text := "1. the price\n2) The Price\n- the delivery time\n\n* warranty"
+	for i := 0; i < maxDeliverables; i++ {
+		text += fmt.Sprintf("\nextra %d", i)
+	}
+	got := parseDeliverables(text)
+	if len(got) != maxDeliverables || strings.Join(got[:3], "|") != "the price|the delivery time|warranty" {
+		t.Fatalf("parseDeliverables = %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// duplicateContextRunes caps the ancestor text shown for duplicate-text elements
+const duplicateContextRunes = 40
+
+// duplicateContexts annotates elements sharing role and text with others ("Подробнее" × 20):
+// " (3/20, under 'Ноутбук ASUS …')" - the ordinal within the group and the first line of the
+// nearest ancestor with a different text. Without hierarchy data (JS collector) the nearest
+// preceding element with a unique text stands in for the ancestor (usually the card title).
+// Keyed by element index.
+func duplicateContexts(elements []snapshot.Element) map[int]string {
+	groups := make(map[string][]int) // role|text -> positions in elements
+	for i := range elements {
+		if text := normalizedText(elements[i].Text); text != "" {
+			key := strings.ToLower(elements[i].Role) + "|" + text
+			groups[key] = append(groups[key], i)
+		}
+	}
+	byNode := make(map[string]*snapshot.Element, len(elements))
+	for i := range elements {
+		if elements[i].NodeId != "" {
+			byNode[elements[i].NodeId] = &elements[i]
+		}
+	}
+
+	contexts := make(map[int]string)
+	for _, positions := range groups {
+		if len(positions) < 2 {
+			continue
+		}
+		for n, pos := range positions {
+			el := &elements[pos]
+			note := fmt.Sprintf(" (%d/%d", n+1, len(positions))
+			if under := distinctAncestorText(el, byNode); under != "" {
+				note += fmt.Sprintf(", under '%s'", under)
+			} else if prev := precedingUniqueText(elements, pos, groups); prev != "" {
+				note += fmt.Sprintf(", after '%s'", prev)
+			}
+			contexts[el.Index] = note + ")"
+		}
+	}
+	return contexts
+}
+
+// distinctAncestorText is the first line of the nearest ancestor whose text differs from el's
+func distinctAncestorText(el *snapshot.Element, byNode map[string]*snapshot.Element) string {
+	own := normalizedText(el.Text)
+	parent := byNode[el.ParentId]
+	for depth := 0; parent != nil && depth < 10; depth++ {
+		if text := firstLine(parent.Text); text != "" && normalizedText(text) != own {
+			return truncateText(text, duplicateContextRunes)
+		}
+		parent = byNode[parent.ParentId]
+	}
+	return ""
+}
+
+// precedingUniqueText is the first line of the closest earlier element whose role+text is unique
+func precedingUniqueText(elements []snapshot.Element, pos int, groups map[string][]int) string {
+	for i := pos - 1; i >= 0; i-- {
+		text := normalizedText(elements[i].Text)
+		if text == "" {
+			continue
+		}
+		if len(groups[strings.ToLower(elements[i].Role)+"|"+text]) == 1 {
+			return truncateText(firstLine(elements[i].Text), duplicateContextRunes)
+		}
+	}
+	return ""
+}
+
+func normalizedText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
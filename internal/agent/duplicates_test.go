@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// productCards loads a catalog page of three laptop cards, each with "Подробнее" and "В корзину"
+func productCards(t *testing.T) snapshot.Summary {
+	t.Helper()
+	data, err := os.ReadFile("testdata/product_cards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary snapshot.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	return summary
+}
+
+func TestDuplicateContexts(t *testing.T) {
+	cdp := productCards(t)
+	// The JS collector has no hierarchy and no wrapper elements
+	var js []snapshot.Element
+	for _, el := range cdp.Elements {
+		if el.Role != "generic" {
+			el.NodeId, el.ParentId = "", ""
+			js = append(js, el)
+		}
+	}
+	tests := []struct {
+		name     string
+		elements []snapshot.Element
+		want     map[int]string
+	}{
+		{
+			name:     "cdp hierarchy",
+			elements: cdp.Elements,
+			want: map[int]string{
+				3:  " (1/3, under 'Ноутбук ASUS VivoBook 15 X1504')",
+				4:  " (1/3, under 'Ноутбук ASUS VivoBook 15 X1504')",
+				6:  " (2/3, under 'Ноутбук Lenovo IdeaPad Slim 3')",
+				7:  " (2/3, under 'Ноутбук Lenovo IdeaPad Slim 3')",
+				10: " (3/3, under 'Ноутбук HP 255 G10')", // Past the wrapper with the same text
+				11: " (3/3, under 'Ноутбук HP 255 G10')",
+			},
+		},
+		{
+			name:     "js collector",
+			elements: js,
+			want: map[int]string{
+				3:  " (1/3, after 'Ноутбук ASUS VivoBook 15 X1504')",
+				4:  " (1/3, after 'Ноутбук ASUS VivoBook 15 X1504')",
+				6:  " (2/3, after 'Ноутбук Lenovo IdeaPad Slim 3')",
+				7:  " (2/3, after 'Ноутбук Lenovo IdeaPad Slim 3')",
+				10: " (3/3, after 'Ноутбук HP 255 G10')",
+				11: " (3/3, after 'Ноутбук HP 255 G10')",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := duplicateContexts(tt.elements)
+			if len(got) != len(tt.want) {
+				t.Errorf("annotated %d elements, want %d: %v", len(got), len(tt.want), got)
+			}
+			for index, want := range tt.want {
+				if got[index] != want {
+					t.Errorf("[%d] = %q, want %q", index, got[index], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlannerAnnotatesDuplicateTexts(t *testing.T) {
+	client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+	state := State{Task: "открой карточку ноутбука HP", Summary: productCards(t)}
+	if _, err := NewPlanner(client).Next(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	msg := client.Requests()[0].Messages[0].Content
+	for _, want := range []string{
+		`[3]link:"Подробнее" (1/3, under 'Ноутбук ASUS VivoBook 15 X1504')`,
+		`[10]link:"Подробнее" (3/3, under 'Ноутбук HP 255 G10')`,
+		`[1]link:"Каталог"`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message misses %q", want)
+		}
+	}
+	if strings.Contains(msg, `[1]link:"Каталог" (`) {
+		t.Error("unique element annotated")
+	}
+}
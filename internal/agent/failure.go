@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Failure kinds reported in RunResult.FailureReason
+const (
+	FailureStepLimit       = "step_limit"       // MaxSteps reached without finish
+	FailureRepeatedAction  = "repeated_action"  // Same action repeated over its limit (Action set)
+	FailureLLMQuota        = "llm_quota"        // Provider usage limit / rate limit
+	FailureLLMError        = "llm_error"        // Planner call or decision parsing failed
+	FailureBudget          = "budget"           // Config.MaxCost exceeded
+	FailureBrowserCrash    = "browser_crash"    // Browser or context died
+	FailureUserCancel      = "user_cancel"      // Interrupted (signal) or aborted by the supervisor
+	FailureInvalidDecision = "invalid_decision" // Decision can't be executed (Action set)
+	FailurePrompt          = "prompt_error"     // Asking the human failed
+	FailureTimeout         = "timeout"          // Config.MaxDuration ran out or steps kept timing out
+	FailureNoProgress      = "no_progress"      // Page state stopped changing despite a loop warning (Action set)
+)
+
+// FailureReason answers "what killed this run" for post-mortem tooling
+type FailureReason struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+	Step   int    `json:"step"`
+	Action string `json:"action,omitempty"` // Offending action/tool
+	URL    string `json:"url,omitempty"`    // Page at the failure
+}
+
+// fail records the failure reason; the first recorded reason wins
+func (r *RunResult) fail(kind string, err error, action, url string) {
+	if r.FailureReason != nil {
+		return
+	}
+	r.FailureReason = &FailureReason{Kind: kind, Detail: err.Error(), Step: r.Steps, Action: action, URL: url}
+}
+
+// failureKind classifies errors that reach Run without an explicit reason
+func failureKind(err error) string {
+	var te *RunTimeoutError
+	if errors.As(err, &te) {
+		return FailureTimeout
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return FailureUserCancel
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "usage limit") || strings.Contains(msg, "quota") ||
+		strings.Contains(msg, "rate limit") || strings.Contains(msg, "status 429"):
+		return FailureLLMQuota
+	case strings.Contains(msg, "browser has been closed") || strings.Contains(msg, "context closed") ||
+		strings.Contains(msg, "target closed") || strings.Contains(msg, "browser is not connected"):
+		return FailureBrowserCrash
+	}
+	return FailureLLMError
+}
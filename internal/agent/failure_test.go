@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// failingClient is a planner model whose every call fails with err
+type failingClient struct{ err error }
+
+func (c failingClient) Generate(context.Context, llm.Request) (llm.Response, error) {
+	return llm.Response{}, c.err
+}
+func (c failingClient) Name() string             { return "failing" }
+func (c failingClient) ModelInfo() llm.ModelInfo { return llm.ModelInfo{ContextTokens: 200000} }
+
+// hangingClient is a planner model that answers only when the call is cancelled
+type hangingClient struct{ failingClient }
+
+func (hangingClient) Generate(ctx context.Context, _ llm.Request) (llm.Response, error) {
+	<-ctx.Done()
+	return llm.Response{}, ctx.Err()
+}
+
+// costlyClient reports usage on every scripted answer
+type costlyClient struct {
+	*llm.ScriptedClient
+	usage llm.Usage
+}
+
+func (c costlyClient) Generate(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.ScriptedClient.Generate(ctx, req)
+	resp.Usage = c.usage
+	return resp, err
+}
+
+func TestFailureReasons(t *testing.T) {
+	navigate := func(url string) string { return decision("navigate", map[string]any{"url": url}) }
+	tests := []struct {
+		name   string
+		cfg    Config
+		client llm.Client // Scripted from responses when nil
+		ctx    func() context.Context
+		prompt tools.PromptFunc
+		script []string
+		want   FailureReason // Detail is matched as a substring
+	}{
+		{
+			name:   "step limit",
+			cfg:    Config{MaxSteps: 2},
+			script: []string{navigate("https://example.com/a"), navigate("https://example.com/b")},
+			want:   FailureReason{Kind: FailureStepLimit, Detail: "step limit", Step: 2, URL: loginSummary.URL},
+		},
+		{
+			name:   "llm quota",
+			client: failingClient{errors.New("anthropic: status 429: rate limit exceeded")},
+			want:   FailureReason{Kind: FailureLLMQuota, Detail: "status 429", Step: 1, URL: loginSummary.URL},
+		},
+		{
+			name:   "llm error",
+			client: failingClient{errors.New("anthropic: status 500: overloaded")},
+			want:   FailureReason{Kind: FailureLLMError, Detail: "status 500", Step: 1, URL: loginSummary.URL},
+		},
+		{
+			name:   "browser crash",
+			client: failingClient{errors.New("read page: target closed")},
+			want:   FailureReason{Kind: FailureBrowserCrash, Detail: "target closed", Step: 1, URL: loginSummary.URL},
+		},
+		{
+			name: "user cancel",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			want: FailureReason{Kind: FailureUserCancel, Detail: "canceled", Step: 1},
+		},
+		{
+			name:   "run timeout",
+			cfg:    Config{MaxDuration: 50 * time.Millisecond},
+			client: hangingClient{},
+			want:   FailureReason{Kind: FailureTimeout, Detail: "exceeded max duration", Step: 1, URL: loginSummary.URL},
+		},
+		{
+			name:   "budget",
+			cfg:    Config{Model: "claude-sonnet-4", MaxCost: 0.01},
+			client: costlyClient{llm.NewScriptedClient([]string{navigate("https://example.com/a")}), llm.Usage{PromptTokens: 100000}},
+			want:   FailureReason{Kind: FailureBudget, Detail: "cost budget exceeded", Step: 1, Action: "navigate", URL: loginSummary.URL},
+		},
+		{
+			name:   "supervisor abort",
+			cfg:    Config{Supervised: true},
+			prompt: func(context.Context, string) (string, error) { return "abort", nil },
+			script: []string{navigate("https://example.com/a")},
+			want:   FailureReason{Kind: FailureUserCancel, Detail: "aborted by supervisor", Step: 1, Action: "navigate", URL: loginSummary.URL},
+		},
+		{
+			name:   "supervisor prompt fails",
+			cfg:    Config{Supervised: true},
+			prompt: func(context.Context, string) (string, error) { return "", errors.New("stdin closed") },
+			script: []string{navigate("https://example.com/a")},
+			want:   FailureReason{Kind: FailurePrompt, Detail: "stdin closed", Step: 1, Action: "navigate", URL: loginSummary.URL},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Quiet = true
+			if cfg.MaxSteps == 0 {
+				cfg.MaxSteps = 5
+			}
+			client := tt.client
+			if client == nil {
+				client = llm.NewScriptedClient(tt.script)
+			}
+			prompt := tt.prompt
+			if prompt == nil {
+				prompt = func(context.Context, string) (string, error) { return "", errors.New("unexpected question") }
+			}
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			box := tools.New(browser.NewFakeController(loginPage), prompt)
+			orch := NewOrchestrator(cfg, NewPlanner(client), box, zerolog.Nop())
+			result, err := orch.Run(ctx, Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+				return loginSummary, nil
+			})
+			if err == nil {
+				t.Fatalf("run succeeded: %+v", result)
+			}
+			got := result.FailureReason
+			if got == nil {
+				t.Fatalf("no failure reason for %v", err)
+			}
+			if got.Kind != tt.want.Kind || got.Step != tt.want.Step || got.Action != tt.want.Action || got.URL != tt.want.URL {
+				t.Errorf("failure = %+v, want %+v", *got, tt.want)
+			}
+			if !strings.Contains(got.Detail, tt.want.Detail) || got.Detail != err.Error() {
+				t.Errorf("detail = %q, want %q from the returned error %q", got.Detail, tt.want.Detail, err)
+			}
+		})
+	}
+}
+
+func TestFailureReasonFirstWins(t *testing.T) {
+	var r RunResult
+	r.Steps = 3
+	r.fail(FailureNoProgress, errors.New("no progress"), "scroll", "https://example.com")
+	r.fail(FailureLLMError, errors.New("later"), "", "")
+	if r.FailureReason.Kind != FailureNoProgress || r.FailureReason.Step != 3 || r.FailureReason.Action != "scroll" {
+		t.Fatalf("failure = %+v", *r.FailureReason)
+	}
+}
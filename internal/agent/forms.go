@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+const (
+	// maxRenderedForms bounds the FORM block of the planner guidance (footers with many forms)
+	maxRenderedForms = 5
+	// maxRenderedFields bounds the fields shown per form
+	maxRenderedFields = 12
+)
+
+// formsGuidance renders snapshot forms as compact lines, e.g.
+// FORM 'Sign in': [3] 'Email' (email, required, empty), [4] 'Пароль' (password, empty), submit: [5] 'Войти'
+func formsGuidance(forms []snapshot.Form) string {
+	if len(forms) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for n, form := range forms {
+		if n == maxRenderedForms {
+			fmt.Fprintf(&b, "... %d more forms\n", len(forms)-n)
+			break
+		}
+		fields := make([]string, 0, len(form.Fields))
+		for i, f := range form.Fields {
+			if i == maxRenderedFields {
+				fields = append(fields, fmt.Sprintf("... %d more fields", len(form.Fields)-i))
+				break
+			}
+			fields = append(fields, formFieldText(f))
+		}
+		fmt.Fprintf(&b, "FORM '%s': %s", form.Name, strings.Join(fields, ", "))
+		if len(form.Submits) > 0 {
+			submits := make([]string, 0, len(form.Submits))
+			for _, s := range form.Submits {
+				submits = append(submits, fmt.Sprintf("[%d] '%s'", s.Index, truncateText(s.Label, 30)))
+			}
+			b.WriteString(", submit: " + strings.Join(submits, " / "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func formFieldText(f snapshot.FormField) string {
+	var notes []string
+	if f.Type != "" && f.Type != "text" && f.Type != "textbox" {
+		notes = append(notes, f.Type)
+	}
+	if f.Required {
+		notes = append(notes, "required")
+	}
+	switch {
+	case f.Type == "checkbox" || f.Type == "radio" || f.Type == "switch":
+		if f.Checked {
+			notes = append(notes, "checked")
+		} else {
+			notes = append(notes, "unchecked")
+		}
+	case f.Value == "***":
+		notes = append(notes, "filled")
+	case f.Value != "":
+		notes = append(notes, fmt.Sprintf("= '%s'", truncateText(f.Value, 30)))
+	default:
+		notes = append(notes, "empty")
+	}
+	label := f.Label
+	if label == "" {
+		label = f.Type
+	}
+	return fmt.Sprintf("[%d] '%s' (%s)", f.Index, truncateText(label, 40), strings.Join(notes, ", "))
+}
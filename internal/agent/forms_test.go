@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// twoForms is Summary.Forms of internal/snapshot/testdata/two_forms.html after the user typed the email
+var twoForms = []snapshot.Form{
+	{
+		Name: "Sign in",
+		Fields: []snapshot.FormField{
+			{Index: 3, Label: "Email", Type: "email", Required: true, Value: "ann@example.com"},
+			{Index: 4, Label: "Password", Type: "password"},
+			{Index: 5, Label: "Remember me", Type: "checkbox"},
+		},
+		Submits: []snapshot.FormField{{Index: 7, Label: "Войти", Type: "submit"}},
+	},
+	{
+		Name:    "Newsletter",
+		Fields:  []snapshot.FormField{{Index: 8, Label: "Your email", Type: "email"}},
+		Submits: []snapshot.FormField{{Index: 9, Label: "Subscribe", Type: "button"}},
+	},
+}
+
+func TestFormsGuidance(t *testing.T) {
+	want := "FORM 'Sign in': [3] 'Email' (email, required, = 'ann@example.com'), [4] 'Password' (password, empty), " +
+		"[5] 'Remember me' (checkbox, unchecked), submit: [7] 'Войти'\n" +
+		"FORM 'Newsletter': [8] 'Your email' (email, empty), submit: [9] 'Subscribe'\n"
+	if got := formsGuidance(twoForms); got != want {
+		t.Errorf("formsGuidance:\n%s\nwant:\n%s", got, want)
+	}
+	if got := formsGuidance(nil); got != "" {
+		t.Errorf("formsGuidance(nil) = %q", got)
+	}
+
+	filled := []snapshot.FormField{{Index: 1, Label: "Пароль", Type: "password", Value: "***"}, {Index: 2, Type: "textbox"}}
+	if got := formsGuidance([]snapshot.Form{{Name: "form 1", Fields: filled}}); got != "FORM 'form 1': [1] 'Пароль' (password, filled), [2] 'textbox' (empty)\n" {
+		t.Errorf("masked and unlabeled fields: %q", got)
+	}
+}
+
+func TestPlannerRendersForms(t *testing.T) {
+	summary := snapshot.Summary{URL: "https://example.com/account", Forms: twoForms, Elements: []snapshot.Element{
+		{Index: 1, Role: "searchbox", Text: "Search site", Sel: "#site-search"},
+		{Index: 3, Role: "textbox", Text: "Email", Sel: "#email", Form: "Sign in"},
+		{Index: 7, Role: "button", Text: "Войти", Sel: "#login", Form: "Sign in"},
+	}}
+	client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+	if _, err := NewPlanner(client).Next(context.Background(), State{Task: "sign in to example.com", Summary: summary}); err != nil {
+		t.Fatal(err)
+	}
+	msg := client.Requests()[0].Messages[0].Content
+	for _, want := range []string{"FORM 'Sign in': [3] 'Email'", "submit: [7] 'Войти'", "FORM 'Newsletter'"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("planner message misses %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "FORM 'Search") {
+		t.Errorf("orphan input rendered as a form:\n%s", msg)
+	}
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// hydrationPoll spaces the re-snapshots of a page that is still rendering
+const hydrationPoll = 250 * time.Millisecond
+
+// HydrationLimits configure the extra wait for pages served as an empty shell (SPAs that render
+// client-side); zero fields take defaultHydrationLimits
+type HydrationLimits struct {
+	MinElements int           // A snapshot with fewer elements...
+	MinText     int           // ...and fewer visible text runes is an empty first render
+	MaxWait     time.Duration // Longest wait for the page to fill in
+}
+
+var defaultHydrationLimits = HydrationLimits{MinElements: 5, MinText: 200, MaxWait: 8 * time.Second}
+
+func (l HydrationLimits) withDefaults() HydrationLimits {
+	if l.MinElements <= 0 {
+		l.MinElements = defaultHydrationLimits.MinElements
+	}
+	if l.MinText <= 0 {
+		l.MinText = defaultHydrationLimits.MinText
+	}
+	if l.MaxWait <= 0 {
+		l.MaxWait = defaultHydrationLimits.MaxWait
+	}
+	return l
+}
+
+// empty reports a snapshot too thin to plan on
+func (l HydrationLimits) empty(s snapshot.Summary) bool {
+	return len(s.Elements) < l.MinElements && utf8.RuneCountInString(s.Visible) < l.MinText
+}
+
+// awaitHydration re-collects a nearly empty snapshot while the page is still loading or its app
+// root is unrendered, up to Config.Hydration.MaxWait. Returns the snapshot to plan on and the note
+// for the planner, "" when there was nothing to wait for. Callers run it once per navigation so a
+// genuinely empty page costs one wait, not one per step.
+func (o *Orchestrator) awaitHydration(ctx context.Context, summary snapshot.Summary, snap summaryFunc) (snapshot.Summary, string) {
+	lim := o.cfg.Hydration.withDefaults()
+	if summary.URL == "" || summary.URL == "about:blank" || !lim.empty(summary) {
+		return summary, ""
+	}
+	if act, err := o.tools.PageActivity(ctx); err != nil || !act.Busy() {
+		return summary, ""
+	}
+	start := time.Now()
+	deadline := start.Add(lim.MaxWait)
+	for {
+		o.settle(ctx, time.Until(deadline))
+		ctxSnap, cancel := snapshot.WithDeadline(ctx, 5*time.Second)
+		next, err := snap(ctxSnap)
+		cancel()
+		if err == nil && next.URL != "" {
+			summary = next
+		}
+		if !lim.empty(summary) || ctx.Err() != nil || time.Now().After(deadline) {
+			break
+		}
+		if act, err := o.tools.PageActivity(ctx); err != nil || !act.Busy() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(hydrationPoll):
+		}
+	}
+	waited := time.Since(start).Round(100 * time.Millisecond)
+	o.logger.Info().Str("url", summary.URL).Dur("waited", waited).Int("elements", len(summary.Elements)).Msg("waited for client render")
+	if lim.empty(summary) {
+		return summary, fmt.Sprintf("initial render was empty; waited %s for hydration but the page is still nearly empty - it may need an action (scroll, reload) or really have no content", waited)
+	}
+	return summary, fmt.Sprintf("initial render was empty; waited %s for hydration", waited)
+}
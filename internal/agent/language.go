@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// minPageLetters is how much element text the script heuristic needs to call a page language
+const minPageLetters = 40
+
+// DetectLanguage returns a short language code ("ru" or "en") for the task text.
+// Simple script-based heuristic: Cyrillic letters dominate -> Russian, otherwise English.
+func DetectLanguage(text string) string {
+	cyrillic, latin := scriptCounts(text)
+	if cyrillic > 0 && cyrillic >= latin {
+		return "ru"
+	}
+	return "en"
+}
+
+func scriptCounts(text string) (cyrillic, latin int) {
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	return cyrillic, latin
+}
+
+// PageLanguage returns the page UI language: the declared <html lang> (base code, "en-US" -> "en"),
+// else the dominant script of element texts; "" when there is too little text to tell
+func PageLanguage(s snapshot.Summary) string {
+	if base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s.Lang)), "-"); base != "" {
+		return base
+	}
+	var b strings.Builder
+	for _, el := range s.Elements {
+		b.WriteString(el.Text)
+		b.WriteByte(' ')
+	}
+	cyrillic, latin := scriptCounts(b.String())
+	if cyrillic+latin < minPageLetters {
+		return ""
+	}
+	if cyrillic >= latin {
+		return "ru"
+	}
+	return "en"
+}
+
+// languageNames are used in planner guidance; other codes are shown as is
+var languageNames = map[string]string{"ru": "Russian", "en": "English"}
+
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// languageSwitcherLabels are texts of UI language switchers by the language they select,
+// "" holds generic menu labels
+var languageSwitcherLabels = map[string][]string{
+	"":   {"язык", "language", "lang", "🌐"},
+	"ru": {"русский", "рус", "ru", "russian"},
+	"en": {"english", "eng", "en", "английский"},
+}
+
+// findLanguageSwitcher locates a control that switches the UI language, preferring one
+// that names the wanted language ("English", "RU") over a generic "Language" menu
+func findLanguageSwitcher(elems []snapshot.Element, lang string) *snapshot.Element {
+	var generic *snapshot.Element
+	for i := range elems {
+		el := &elems[i]
+		switch strings.ToLower(el.Role) {
+		case "button", "link", "combobox", "menuitem", "option", "listitem":
+		default:
+			continue
+		}
+		text := strings.ToLower(strings.TrimSpace(el.Text))
+		if text == "" {
+			continue
+		}
+		for _, label := range languageSwitcherLabels[lang] {
+			// Short codes must match whole ("ru"), names may be part of a label ("Русский язык")
+			if text == label || (len([]rune(label)) > 3 && strings.Contains(text, label)) {
+				return el
+			}
+		}
+		if generic == nil {
+			for _, label := range languageSwitcherLabels[""] {
+				if text == label || (len([]rune(label)) > 3 && strings.Contains(text, label)) || strings.HasPrefix(text, label+":") {
+					generic = el
+					break
+				}
+			}
+		}
+	}
+	return generic
+}
+
+// languageGuidance tells the planner the page UI is in another language than the task,
+// so labels from the task ("нажми Корзина") won't match literally; "" when they agree
+func languageGuidance(task string, s snapshot.Summary) string {
+	taskLang, pageLang := DetectLanguage(task), PageLanguage(s)
+	if pageLang == "" || pageLang == taskLang {
+		return ""
+	}
+	msg := fmt.Sprintf("\nLANGUAGE: the task is in %s but the page is in %s - element labels won't match the task words literally. ",
+		languageName(taskLang), languageName(pageLang))
+	if el := findLanguageSwitcher(s.Elements, taskLang); el != nil {
+		msg += fmt.Sprintf("Either switch the site language ([%d]%s:%q) or translate", el.Index, el.Role, truncateText(el.Text, 30))
+	} else {
+		msg += "Translate"
+	}
+	return msg + " what you look for into the page language (e.g. \"Корзина\" / \"Cart\").\n"
+}
+
+// languageParams are query parameters known to select the UI language, by site domain
+var languageParams = map[string]string{
+	"google.com":  "hl",
+	"youtube.com": "hl",
+	"booking.com": "lang",
+}
+
+// localizeURL adds the site's UI language parameter for lang to raw (hl=ru), keeping URLs of
+// unknown sites and ones that already choose a language unchanged
+func localizeURL(raw, lang string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for domain, param := range languageParams {
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		q := u.Query()
+		if q.Has(param) {
+			return raw
+		}
+		q.Set(param, lang)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return raw
+}
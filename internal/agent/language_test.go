@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+var (
+	englishShop = snapshot.Summary{URL: "https://shop.example.com", Lang: "en-US", Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Cart"},
+		{Index: 2, Role: "button", Text: "Menu"},
+		{Index: 3, Role: "link", Text: "Русский"},
+	}}
+	russianShop = snapshot.Summary{URL: "https://shop.example.ru", Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Корзина"},
+		{Index: 2, Role: "link", Text: "Каталог товаров для дома и сада"},
+		{Index: 3, Role: "button", Text: "Оформить заказ"},
+		{Index: 4, Role: "combobox", Text: "Язык: RU"},
+	}}
+)
+
+func TestLanguageGuidance(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    string
+		summary snapshot.Summary
+		want    string // Substring of the hint; "" = no hint
+	}{
+		{
+			name:    "russian task on an english page with a switcher",
+			task:    "Нажми Корзина и оформи заказ",
+			summary: englishShop,
+			want:    `the task is in Russian but the page is in English - element labels won't match the task words literally. Either switch the site language ([3]link:"Русский")`,
+		},
+		{
+			name:    "russian task on an english page without a switcher",
+			task:    "Нажми Корзина",
+			summary: snapshot.Summary{Lang: "en", Elements: englishShop.Elements[:2]},
+			want:    "the page is in English - element labels won't match the task words literally. Translate what you look for",
+		},
+		{
+			name:    "english task on a russian page, generic switcher",
+			task:    "Open the cart and check out",
+			summary: russianShop,
+			want:    `the task is in English but the page is in Russian - element labels won't match the task words literally. Either switch the site language ([4]combobox:"Язык: RU")`,
+		},
+		{
+			name:    "english task on a russian page with an english link",
+			task:    "Open the cart",
+			summary: snapshot.Summary{Lang: "ru", Elements: append([]snapshot.Element{{Index: 9, Role: "link", Text: "English"}}, russianShop.Elements...)},
+			want:    `Either switch the site language ([9]link:"English")`,
+		},
+		{name: "same language", task: "Open the cart", summary: englishShop},
+		{name: "russian task on a russian page", task: "Открой корзину", summary: russianShop},
+		{name: "too little text to tell", task: "Открой корзину", summary: snapshot.Summary{Elements: []snapshot.Element{{Index: 1, Role: "link", Text: "Cart"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := languageGuidance(tt.task, tt.summary)
+			if tt.want == "" {
+				if got != "" {
+					t.Fatalf("unexpected hint %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("hint = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLanguage(t *testing.T) {
+	tests := []struct {
+		summary snapshot.Summary
+		want    string
+	}{
+		{snapshot.Summary{Lang: "en-GB", Elements: russianShop.Elements}, "en"}, // Declared language wins
+		{snapshot.Summary{Lang: " RU "}, "ru"},
+		{russianShop, "ru"},
+		{snapshot.Summary{Elements: []snapshot.Element{{Text: "Free shipping on all orders over fifty dollars today"}}}, "en"},
+		{snapshot.Summary{Elements: []snapshot.Element{{Text: "OK"}}}, ""},
+	}
+	for _, tt := range tests {
+		if got := PageLanguage(tt.summary); got != tt.want {
+			t.Errorf("PageLanguage(lang %q) = %q, want %q", tt.summary.Lang, got, tt.want)
+		}
+	}
+}
+
+func TestPlannerShowsLanguageHint(t *testing.T) {
+	client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+	state := State{Task: "Нажми Корзина", Summary: englishShop}
+	if _, err := NewPlanner(client).Next(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := client.Requests()[0].Messages[0].Content; !strings.Contains(prompt, "LANGUAGE: the task is in Russian but the page is in English") {
+		t.Fatalf("prompt misses the language hint:\n%s", prompt)
+	}
+}
+
+func TestLocalizeURL(t *testing.T) {
+	tests := []struct {
+		raw, lang, want string
+	}{
+		{"https://www.google.com/search?q=погода", "ru", "https://www.google.com/search?hl=ru&q=%D0%BF%D0%BE%D0%B3%D0%BE%D0%B4%D0%B0"},
+		{"https://m.youtube.com/", "en", "https://m.youtube.com/?hl=en"},
+		{"https://www.booking.com/index.html?lang=de", "ru", "https://www.booking.com/index.html?lang=de"}, // Explicit choice kept
+		{"https://notgoogle.com/", "ru", "https://notgoogle.com/"},
+		{"https://example.com/", "ru", "https://example.com/"},
+		{"about:blank", "ru", "about:blank"},
+	}
+	for _, tt := range tests {
+		if got := localizeURL(tt.raw, tt.lang); got != tt.want {
+			t.Errorf("localizeURL(%q, %q) = %q, want %q", tt.raw, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizeURLsOnNavigate(t *testing.T) {
+	for _, localize := range []bool{false, true} {
+		ctrl := browser.NewFakeController(browser.FakePage{URL: "about:blank"})
+		client := llm.NewScriptedClient([]string{
+			decision("navigate", map[string]any{"url": "https://www.google.com/maps"}),
+			finishDecision("opened", true),
+		})
+		cfg := Config{MaxSteps: 3, Quiet: true, LocalizeURLs: localize}
+		orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(ctrl, nil), zerolog.Nop())
+		if _, err := orch.Run(context.Background(), Task{Description: "Найди аптеку рядом"}, func(context.Context) (snapshot.Summary, error) {
+			return snapshot.Summary{URL: ctrl.Current().URL}, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := "https://www.google.com/maps"
+		if localize {
+			want += "?hl=ru"
+		}
+		if calls := ctrl.CallsTo("Navigate"); len(calls) != 1 || calls[0].Args[0] != want {
+			t.Fatalf("LocalizeURLs=%v: navigate calls %+v, want %s", localize, calls, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// LoopLimits configure page-state loop detection; zero fields take defaultLoopLimits
+type LoopLimits struct {
+	Window     int // Recent steps remembered (N)
+	MaxRepeats int // Occurrences of the same action + input on the same page state that trigger guidance
+	MaxStill   int // Consecutive steps on one page state after which the run is stuck (K)
+}
+
+var defaultLoopLimits = LoopLimits{Window: 12, MaxRepeats: 3, MaxStill: 6}
+
+func (l LoopLimits) withDefaults() LoopLimits {
+	if l.Window <= 0 {
+		l.Window = defaultLoopLimits.Window
+	}
+	if l.MaxRepeats <= 0 {
+		l.MaxRepeats = defaultLoopLimits.MaxRepeats
+	}
+	if l.MaxStill <= 0 {
+		l.MaxStill = defaultLoopLimits.MaxStill
+	}
+	return l
+}
+
+// readOnlyActions gather information without changing the page: running one with a new
+// input on an unchanged page is progress, not a loop
+var readOnlyActions = map[string]bool{
+	"read_page": true, "read_page_ocr": true, "collect_texts": true, "screenshot": true,
+	"list_elements": true, "extract_table": true, "page_to_markdown": true, "list_tabs": true,
+}
+
+// maxHistorySteps bounds consecutive go_back/go_forward: more in a row is ping-pong between
+// two pages or a walk through the whole history, not a way back to a list
+const maxHistorySteps = 3
+
+// historySteps counts the go_back/go_forward actions at the end of history (observations skipped)
+func historySteps(history []HistoryItem) int {
+	n := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		switch history[i].Action {
+		case "observation":
+		case "go_back", "go_forward":
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// stepKey identifies "this action with this input on this page state"
+type stepKey struct {
+	state, action, input string
+}
+
+type loopVerdict int
+
+const (
+	loopNone  loopVerdict = iota
+	loopNote              // Tell the planner the page isn't changing, run the action
+	loopWarn              // Tell the planner it's looping, don't run the action
+	loopStuck             // The page state hasn't changed for MaxStill steps - abort
+)
+
+// loopDetector catches loops tooManyRepeats can't see by name alone: alternating clicks on
+// two indices that do nothing, click/scroll cycles on a page that never changes
+type loopDetector struct {
+	limits    LoopLimits
+	recent    []stepKey // Last Window planned steps
+	lastState string
+	still     int // Consecutive steps planned on lastState
+	level     int // Loop warnings given on lastState
+}
+
+func newLoopDetector(limits LoopLimits) *loopDetector {
+	return &loopDetector{limits: limits.withDefaults()}
+}
+
+// check records the planned step and rates it; the message goes to the planner's history
+func (d *loopDetector) check(key stepKey) (loopVerdict, string) {
+	repeats := 1
+	for _, k := range d.recent {
+		if k == key {
+			repeats++
+		}
+	}
+	d.recent = append(d.recent, key)
+	if len(d.recent) > d.limits.Window {
+		d.recent = d.recent[len(d.recent)-d.limits.Window:]
+	}
+
+	if key.state != d.lastState {
+		d.lastState, d.still, d.level = key.state, 1, 0
+	} else if repeats > 1 || !readOnlyActions[key.action] {
+		d.still++
+	}
+
+	switch {
+	case d.still >= d.limits.MaxStill:
+		return loopStuck, fmt.Sprintf("the page has not changed for %d steps", d.still)
+	case repeats >= d.limits.MaxRepeats:
+		d.level++
+		if d.level == 1 {
+			return loopWarn, fmt.Sprintf("LOOP: %s with the same input already ran %d times on this unchanged page and has no effect - "+
+				"it was not run again. Choose a different action", key.action, repeats-1)
+		}
+		return loopWarn, fmt.Sprintf("LOOP (warning %d): you keep choosing %s on a page that does not change. Stop and reflect: "+
+			"in evaluation_previous_goal list what you tried and why it failed, then take a different approach - "+
+			"another element, another page, or finish with a partial result", d.level, key.action)
+	case d.still == (d.limits.MaxStill+1)/2:
+		return loopNote, fmt.Sprintf("NO PROGRESS: the page has not changed for %d steps, the run stops after %d. "+
+			"If scrolling loads nothing, the content may be in an iframe - use collect_texts or read_page", d.still, d.limits.MaxStill)
+	}
+	return loopNone, ""
+}
+
+// pageStateHash fingerprints what the planner sees: URL, title and the element list
+func pageStateHash(s snapshot.Summary) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s\x00", s.URL, s.Title)
+	for _, el := range s.Elements {
+		fmt.Fprintf(h, "%s\x00%s\x00", el.Role, el.Text)
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// inputHash fingerprints an action input; map keys are marshalled in sorted order
+func inputHash(input map[string]any) string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Sprint(input)
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
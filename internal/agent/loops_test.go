@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// verdicts feeds a synthetic step sequence to a fresh detector
+func verdicts(limits LoopLimits, steps []stepKey) ([]loopVerdict, []string) {
+	d := newLoopDetector(limits)
+	var got []loopVerdict
+	var msgs []string
+	for _, k := range steps {
+		v, msg := d.check(k)
+		got = append(got, v)
+		msgs = append(msgs, msg)
+	}
+	return got, msgs
+}
+
+func TestLoopDetectorSyntheticHistories(t *testing.T) {
+	click := func(state, index string) stepKey {
+		return stepKey{state: state, action: "click_by_index", input: index}
+	}
+	tests := []struct {
+		name   string
+		limits LoopLimits
+		steps  []stepKey
+		want   []loopVerdict
+	}{
+		{
+			name:  "same click on the same page",
+			steps: []stepKey{click("a", "3"), click("a", "3"), click("a", "3")},
+			want:  []loopVerdict{loopNone, loopNone, loopWarn},
+		},
+		{
+			name:   "page never changes",
+			limits: LoopLimits{MaxStill: 4, MaxRepeats: 10},
+			steps:  []stepKey{click("a", "1"), click("a", "2"), click("a", "3"), click("a", "4")},
+			want:   []loopVerdict{loopNone, loopNote, loopNone, loopStuck},
+		},
+		{
+			name:  "every click changes the page",
+			steps: []stepKey{click("a", "3"), click("b", "3"), click("c", "3"), click("d", "3"), click("e", "3"), click("f", "3"), click("g", "3")},
+			want:  []loopVerdict{loopNone, loopNone, loopNone, loopNone, loopNone, loopNone, loopNone},
+		},
+		{
+			name:   "window forgets old repeats",
+			limits: LoopLimits{Window: 2, MaxRepeats: 3, MaxStill: 10},
+			steps:  []stepKey{click("a", "3"), click("a", "1"), click("a", "2"), click("a", "3")},
+			want:   []loopVerdict{loopNone, loopNone, loopNone, loopNone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msgs := verdicts(tt.limits, tt.steps)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("step %d: verdict %d (%q), want %d", i+1, got[i], msgs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoopDetectorMessages(t *testing.T) {
+	_, msgs := verdicts(LoopLimits{MaxStill: 4, MaxRepeats: 10}, []stepKey{
+		{state: "a", action: "click_by_index", input: "1"},
+		{state: "a", action: "click_by_index", input: "2"},
+		{state: "a", action: "click_by_index", input: "3"},
+		{state: "a", action: "click_by_index", input: "4"},
+	})
+	if !strings.HasPrefix(msgs[1], "NO PROGRESS: the page has not changed for 2 steps, the run stops after 4") {
+		t.Errorf("note = %q", msgs[1])
+	}
+	if msgs[3] != "the page has not changed for 4 steps" {
+		t.Errorf("stuck reason = %q", msgs[3])
+	}
+}
+
+func TestPageStateHash(t *testing.T) {
+	base := snapshot.Summary{URL: "https://shop.example/list", Title: "List", Elements: []snapshot.Element{{Index: 1, Role: "link", Text: "Item 1", Sel: "#i1"}}}
+	same := base
+	same.Elements = []snapshot.Element{{Index: 7, Role: "link", Text: "Item 1", Sel: "#other"}}
+	if pageStateHash(base) != pageStateHash(same) {
+		t.Error("indices and selectors changed the state hash - the planner sees the same page")
+	}
+	more := base
+	more.Elements = append(append([]snapshot.Element{}, base.Elements...), snapshot.Element{Index: 2, Role: "link", Text: "Item 2"})
+	moved := base
+	moved.URL = "https://shop.example/list?page=2"
+	for _, s := range []snapshot.Summary{more, moved} {
+		if pageStateHash(s) == pageStateHash(base) {
+			t.Errorf("state %+v hashes like the original page", s)
+		}
+	}
+	if inputHash(map[string]any{"a": 1, "b": "x"}) != inputHash(map[string]any{"b": "x", "a": 1}) {
+		t.Error("input hash depends on key order")
+	}
+}
+
+func TestLoopDetectorAlternatingActions(t *testing.T) {
+	// The model alternates click and scroll on a page that never changes: no single action
+	// repeats often enough for tooManyRepeats, the page state gives it away
+	var steps []stepKey
+	for i := 0; i < 6; i++ {
+		if i%2 == 0 {
+			steps = append(steps, stepKey{state: "list", action: "click_by_index", input: "4"})
+		} else {
+			steps = append(steps, stepKey{state: "list", action: "scroll_page", input: "down"})
+		}
+	}
+	got, msgs := verdicts(LoopLimits{}, steps)
+	want := []loopVerdict{loopNone, loopNone, loopNote, loopNone, loopWarn, loopStuck}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("step %d: verdict %d (%q), want %d", i+1, got[i], msgs[i], want[i])
+		}
+	}
+	if !strings.HasPrefix(msgs[4], "LOOP: click_by_index with the same input already ran 2 times") {
+		t.Errorf("first warning = %q", msgs[4])
+	}
+}
+
+func TestLoopDetectorEscalates(t *testing.T) {
+	steps := []stepKey{
+		{state: "a", action: "click_by_index", input: "4"},
+		{state: "a", action: "click_by_index", input: "4"},
+		{state: "a", action: "click_by_index", input: "4"},
+		{state: "a", action: "click_by_index", input: "4"},
+	}
+	got, msgs := verdicts(LoopLimits{MaxStill: 10}, steps)
+	if got[2] != loopWarn || got[3] != loopWarn {
+		t.Fatalf("verdicts = %v, want warnings from the third repeat on", got)
+	}
+	if !strings.HasPrefix(msgs[3], "LOOP (warning 2)") || !strings.Contains(msgs[3], "Stop and reflect") {
+		t.Errorf("second warning = %q, want the reflection prompt", msgs[3])
+	}
+}
+
+func TestLoopDetectorProgressingList(t *testing.T) {
+	// Paging through a long list repeats the same scroll and the same "open next item" click,
+	// but every step lands on a new page state - that is work, not a loop
+	var steps []stepKey
+	for i := 0; i < 30; i++ {
+		state := string(rune('a'+i%26)) + strings.Repeat("+", i/26)
+		action, input := "scroll_page", "down"
+		if i%3 == 2 {
+			action, input = "click_by_index", "1"
+		}
+		steps = append(steps, stepKey{state: state, action: action, input: input})
+	}
+	// Reading the page several ways without changing it is not a loop either
+	steps = append(steps,
+		stepKey{state: "z", action: "read_page", input: "1"},
+		stepKey{state: "z", action: "collect_texts", input: "li"},
+		stepKey{state: "z", action: "extract_table", input: "#prices"},
+		stepKey{state: "z", action: "list_elements", input: ""},
+		stepKey{state: "z", action: "page_to_markdown", input: ""},
+		stepKey{state: "z", action: "read_page", input: "2"},
+	)
+	got, msgs := verdicts(LoopLimits{}, steps)
+	for i, v := range got {
+		if v != loopNone {
+			t.Errorf("step %d (%s): verdict %d %q, want none", i+1, steps[i].action, v, msgs[i])
+		}
+	}
+}
+
+func TestAlternatingLoopStopsRun(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	var script []string
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			script = append(script, decision("click_selector", map[string]any{"selector": "#login"}))
+		} else {
+			script = append(script, decision("scroll_page", map[string]any{"direction": "down"}))
+		}
+	}
+	result, _, err := scriptedRun(t, Config{}, page, loginSummary, nil, script...)
+	if err == nil {
+		t.Fatalf("run alternating on an unchanged page succeeded: %+v", result)
+	}
+	if result.FailureReason == nil || result.FailureReason.Kind != FailureNoProgress {
+		t.Errorf("failure = %+v, want %s", result.FailureReason, FailureNoProgress)
+	}
+	if result.Steps >= len(script) {
+		t.Errorf("ran %d steps, want the loop cut before the script ran out", result.Steps)
+	}
+}
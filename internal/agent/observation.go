@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultObservationCap applies to tools without their own cap (runes)
+	defaultObservationCap = 1000
+	// envObservationCapPrefix + tool name in upper case overrides a cap, e.g. AGENT_OBS_CAP_READ_PAGE=8000;
+	// AGENT_OBS_CAP_DEFAULT overrides the default. 0 disables the cap.
+	envObservationCapPrefix = "AGENT_OBS_CAP_"
+	observationCapDefault   = "default"
+)
+
+// defaultObservationCaps are per-tool limits for HistoryItem.Result (runes).
+// Reading tools carry the data the planner needs, action tools only need a short status.
+var defaultObservationCaps = map[string]int{
+	"read_page":              5000,
+	"read_page_ocr":          5000,
+	"page_to_markdown":       5000,
+	"collect_texts":          2000,
+	"list_elements":          6000,
+	"extract_table":          8000,
+	"request_user_input":     0, // User data is never cut
+	"click_by_index":         300,
+	"click_selector":         300,
+	"click_role":             300,
+	"click_text":             300,
+	"click_text_fuzzy":       300,
+	"click_coordinates":      300,
+	"fill_by_index":          300,
+	"fill":                   300,
+	"fill_and_submit":        300,
+	"set_date":               300,
+	"upload_file":            300,
+	"hover":                  300,
+	"choose_combobox_option": 300,
+	"press_key":              300,
+}
+
+// resolveObservationCaps merges defaults, Config.ObservationCaps and env overrides (in that order)
+func resolveObservationCaps(custom map[string]int) map[string]int {
+	caps := make(map[string]int, len(defaultObservationCaps)+len(custom)+1)
+	caps[observationCapDefault] = defaultObservationCap
+	for tool, limit := range defaultObservationCaps {
+		caps[tool] = limit
+	}
+	for tool, limit := range custom {
+		caps[tool] = limit
+	}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envObservationCapPrefix) {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(kv, envObservationCapPrefix), "=")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			continue
+		}
+		caps[strings.ToLower(name)] = limit
+	}
+	return caps
+}
+
+// capObservation limits a tool observation stored in history; the full text stays in the run log
+func (o *Orchestrator) capObservation(action, observation string) string {
+	limit, ok := o.obsCaps[action]
+	if !ok {
+		limit = o.obsCaps[observationCapDefault]
+	}
+	if limit <= 0 || utf8.RuneCountInString(observation) <= limit {
+		return observation
+	}
+	o.logger.Debug().
+		Str("action", action).
+		Int("limit", limit).
+		Str("observation", observation).
+		Msg("observation capped in history")
+	return cutRunes(observation, limit) + "... [truncated]"
+}
@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// verboseToolbox answers the listed tools with observations of the given size (runes)
+type verboseToolbox struct {
+	tools.Toolbox
+	sizes map[string]int
+}
+
+func (v verboseToolbox) Invoke(ctx context.Context, name string, input map[string]any) (tools.Result, error) {
+	if n, ok := v.sizes[name]; ok {
+		return tools.Result{Observation: strings.Repeat("ж", n)}, nil
+	}
+	return v.Toolbox.Invoke(ctx, name, input)
+}
+
+// runVerbose runs a read-heavy script and returns the result and the planner requests
+func runVerbose(t *testing.T, caps map[string]int) (RunResult, []llm.Request) {
+	t.Helper()
+	box := verboseToolbox{
+		Toolbox: tools.New(browser.NewFakeController(loginPage), nil),
+		sizes:   map[string]int{"read_page": 20000, "collect_texts": 8000, "click_selector": 3000},
+	}
+	client := llm.NewScriptedClient([]string{
+		decision("read_page", map[string]any{}),
+		decision("click_selector", map[string]any{"selector": "#email"}),
+		decision("collect_texts", map[string]any{"selector": ".price"}),
+		decision("read_page", map[string]any{}),
+		finishDecision("read everything", true),
+	})
+	cfg := Config{MaxSteps: 7, Quiet: true, ObservationCaps: caps}
+	orch := NewOrchestrator(cfg, NewPlanner(client), box, zerolog.Nop())
+	result, err := orch.Run(context.Background(), Task{Description: "read the catalog"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, client.Requests()
+}
+
+// toolRunes counts the runes of tool results in history, leaving out the orchestrator's own notes
+func toolRunes(history []HistoryItem) int {
+	n := 0
+	for _, h := range history {
+		if h.Action != "observation" {
+			n += utf8.RuneCountInString(h.Result)
+		}
+	}
+	return n
+}
+
+func promptRunes(req llm.Request) int {
+	n := 0
+	for _, m := range req.Messages {
+		n += utf8.RuneCountInString(m.Content)
+	}
+	return n
+}
+
+func TestObservationCapsBoundHistoryGrowth(t *testing.T) {
+	uncapped := map[string]int{"read_page": 0, "collect_texts": 0, "click_selector": 0}
+	full, fullRequests := runVerbose(t, uncapped)
+	capped, cappedRequests := runVerbose(t, nil)
+
+	if got := toolRunes(full.History); got != 20000+3000+8000+20000 {
+		t.Fatalf("uncapped history holds %d runes, want every observation in full", got)
+	}
+	suffix := utf8.RuneCountInString("... [truncated]")
+	want := map[string]int{"read_page": 5000 + suffix, "collect_texts": 2000 + suffix, "click_selector": 300 + suffix}
+	total := 0
+	for _, h := range capped.History {
+		if h.Action == "observation" {
+			continue
+		}
+		if got := utf8.RuneCountInString(h.Result); got != want[h.Action] {
+			t.Errorf("%s result holds %d runes, want %d", h.Action, got, want[h.Action])
+		}
+		if !utf8.ValidString(h.Result) {
+			t.Errorf("%s result was cut inside a rune", h.Action)
+		}
+		total += want[h.Action]
+	}
+	if got := toolRunes(capped.History); got != total {
+		t.Fatalf("capped history holds %d runes, want %d", got, total)
+	}
+
+	// The prompt grows by at most the cap per step, instead of the whole observation
+	if len(fullRequests) != len(cappedRequests) {
+		t.Fatalf("%d vs %d planner calls", len(fullRequests), len(cappedRequests))
+	}
+	for i := 1; i < len(cappedRequests); i++ {
+		growth := promptRunes(cappedRequests[i]) - promptRunes(cappedRequests[i-1])
+		if growth > 5000+suffix+1000 {
+			t.Errorf("capped prompt grew by %d runes at step %d", growth, i+1)
+		}
+	}
+	last := len(cappedRequests) - 1
+	if promptRunes(cappedRequests[last]) >= promptRunes(fullRequests[last]) {
+		t.Fatalf("capped prompt %d runes, uncapped %d", promptRunes(cappedRequests[last]), promptRunes(fullRequests[last]))
+	}
+}
+
+func TestResolveObservationCaps(t *testing.T) {
+	t.Setenv(envObservationCapPrefix+"READ_PAGE", "8000")
+	t.Setenv(envObservationCapPrefix+"DEFAULT", "50")
+	t.Setenv(envObservationCapPrefix+"CLICK_TEXT", "not a number")
+	caps := resolveObservationCaps(map[string]int{"read_page": 100, "collect_texts": 0, "my_tool": 70})
+	tests := map[string]int{
+		"read_page":           8000, // env beats config
+		"collect_texts":       0,    // config beats defaults
+		"my_tool":             70,
+		"click_text":          300, // invalid env value is ignored
+		"request_user_input":  0,
+		observationCapDefault: 50,
+	}
+	for tool, want := range tests {
+		if caps[tool] != want {
+			t.Errorf("cap[%s] = %d, want %d", tool, caps[tool], want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// Observer receives run progress (UI, metrics). Calls are synchronous, from the run goroutine;
+// a panicking observer is logged and ignored.
+type Observer interface {
+	OnStep(StepEvent)     // After the planner decided
+	OnAction(ActionEvent) // After the action finished (including recovery)
+	OnFinish(FinishEvent) // When Run returns
+}
+
+// StepEvent is the planned step: what the agent saw and decided
+type StepEvent struct {
+	Step         int
+	Summary      snapshot.Summary
+	Decision     Decision
+	Agent        string        // Planner that produced the decision
+	PlanDuration time.Duration // Planner call time
+}
+
+// ActionEvent is the outcome of an executed action
+type ActionEvent struct {
+	Step        int
+	Action      string // Action actually executed (recovery may change it)
+	Input       map[string]any
+	Observation string
+	Err         error // Final error after all recovery attempts
+	Recovered   bool  // Succeeded only through adaptive error handling
+	URL         string
+	Duration    time.Duration
+	// Strategy is the recovery strategy that succeeded (Recovered), RecoveryAttempts all tried ones
+	Strategy         string
+	RecoveryAttempts []RecoveryAttempt
+}
+
+// FinishEvent is the run outcome
+type FinishEvent struct {
+	Result RunResult
+	Err    error
+}
+
+// ConsoleObserver prints the classic "agent[N]: action -> result" progress lines to Out (stdout when nil)
+type ConsoleObserver struct {
+	Out io.Writer
+}
+
+func (ConsoleObserver) OnStep(StepEvent) {}
+
+func (c ConsoleObserver) OnAction(ev ActionEvent) {
+	if ev.Err != nil {
+		return
+	}
+	recovered := ""
+	if ev.Recovered {
+		recovered = " (recovered via " + ev.Strategy + ")"
+	}
+	out := c.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, "agent[%d]: %s%s -> %s\n", ev.Step, ev.Action, recovered, truncate(ev.Action, ev.Observation))
+}
+
+func (ConsoleObserver) OnFinish(FinishEvent) {}
+
+// notify calls the observers (Config.Observer, trajectory recorder), recovering from
+// their panics so the run continues
+func (o *Orchestrator) notify(call func(Observer)) {
+	if o.cfg.Observer != nil {
+		o.notifyOne(o.cfg.Observer, call)
+	}
+	if o.trajectory != nil {
+		o.notifyOne(o.trajectory, call)
+	}
+}
+
+func (o *Orchestrator) notifyOne(obs Observer, call func(Observer)) {
+	defer func() {
+		if r := recover(); r != nil {
+			o.logger.Error().Interface("panic", r).Msg("observer panicked - event dropped")
+		}
+	}()
+	call(obs)
+}
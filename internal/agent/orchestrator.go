@@ -2,22 +2,104 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog"
 
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
 )
 
 type Config struct {
 	MaxSteps int
+	// AutoDismissConsent clicks cookie consent banners automatically (once per domain per run)
+	AutoDismissConsent bool
+	// ScreenshotDir, when set, receives step_NNN.png after every action plus index.json
+	ScreenshotDir string
+	// UseVision attaches a downscaled viewport screenshot to every planner request
+	UseVision bool
+	// Supervised asks the human to approve/edit/skip/abort every action before it runs
+	Supervised bool
+	// Quiet suppresses console progress lines (agent[N]: action -> result) when embedding the orchestrator
+	Quiet bool
+	// Output receives console progress lines; os.Stdout when nil
+	Output io.Writer
+	// HistoryPath, when set, receives a checkpoint (history, memory, URL) after every step
+	HistoryPath string
+	// Resume continues a run from a checkpoint (see LoadCheckpoint)
+	Resume *Checkpoint
+	// AllowedDomains / BlockedDomains restrict navigation ("example.com", "*.example.com");
+	// the browser-level block is installed by the caller (Controller.EnableDomainGuard)
+	AllowedDomains []string
+	BlockedDomains []string
+	// ConfirmationPolicy decides on actions matching destructive keywords (prompt by default)
+	ConfirmationPolicy ConfirmationPolicy
+	// RiskClassifier, when set, rates clicks/fills the keyword check can't decide (generic
+	// keywords, unlabelled buttons); NewLLMRiskClassifier asks the planner's model
+	RiskClassifier RiskClassifier
+	// LocalizeURLs adds the task language parameter (hl=, lang=) to navigate URLs of sites
+	// known to support it, so the UI matches the task wording
+	LocalizeURLs bool
+	// VerifyFinish, when set, checks finish decisions against a fresh snapshot and sends the
+	// planner back to work (at most maxFinishRejections times); NewLLMFinishVerifier asks a model
+	VerifyFinish FinishVerifier
+	// Deliverables, when set, turns the task into a checklist of requested outputs at run start
+	// and rejects finishes whose message misses some (at most maxDeliverableRejections times)
+	Deliverables DeliverableChecker
+	// TrajectoryPath, when set, receives one JSON line per step (snapshot, decision, outcome)
+	TrajectoryPath string
+	// Observer receives step/action/finish events; ConsoleObserver by default unless Quiet
+	Observer Observer
+	// Model is the LLM name (Client.Name) used to price token usage
+	Model string
+	// MaxCost aborts the run once the estimated LLM cost (USD) exceeds it, 0 = no limit
+	MaxCost float64
+	// MaxDuration bounds the whole run (paused time excluded); Run then returns *RunTimeoutError
+	MaxDuration time.Duration
+	// StepTimeout bounds one step (snapshot + plan + act); a timed-out step becomes a history
+	// entry and the run goes on unless it happens maxStepTimeouts times in a row
+	StepTimeout time.Duration
+	// SummarizeHistory keeps a progress summary of steps older than the planner's recent
+	// window (data from the user, action counts, pages) in TaskMemory and the prompt
+	SummarizeHistory bool
+	// LoopLimits tune detection of steps that don't change the page (defaults when zero)
+	LoopLimits LoopLimits
+	// Waits bounds how long the page may settle after each action type (defaults when zero)
+	Waits Waits
+	// Hydration bounds the extra wait for a nearly empty page that is still rendering
+	// client-side, once per navigation (defaults when zero)
+	Hydration HydrationLimits
+	// RecoveryStrategies run in order after a failed action; nil = DefaultRecoveryStrategies(),
+	// an empty slice disables recovery
+	RecoveryStrategies []RecoveryStrategy
+	// ObservationCaps overrides per-tool limits (runes) for observations stored in history, "default" for the rest
+	ObservationCaps map[string]int
+	// TaskTitler, when set, names the task at run start (NewLLMTaskTitler); the first words
+	// of the task are the title otherwise
+	TaskTitler TaskTitler
+	// Now is the clock behind the planner's Time line and task date variables ({today},
+	// {tomorrow}, ...); time.Now when nil. The location of the times it returns is the agent
+	// machine's zone
+	Now func() time.Time
 }
 
 type Task struct {
 	Description string
+	// Slug is a filesystem-safe short name (TaskSlug), Title a human one (Config.TaskTitler);
+	// Run fills whichever is empty
+	Slug  string
+	Title string
+	// Batch runs the task as "collect a list, then process every item" (see BatchSpec)
+	Batch *BatchSpec
 }
 
 type Orchestrator struct {
@@ -25,16 +107,82 @@ type Orchestrator struct {
 	planner Planner
 	tools   tools.Toolbox
 	logger  zerolog.Logger
+	// Specialized planners consulted before the default one
+	subAgents []SubAgent
 	// Error tracking for adaptive handling
 	errorHistory []errorRecord
 	// Persistent memory for tasks
 	memory *TaskMemory
+	// Cookie consent decisions per domain for the current run
+	consent map[string]browser.ConsentResult
+	// Dead browser context was already recreated in this run
+	contextRecreated bool
+	// Resolved per-tool observation caps
+	obsCaps map[string]int
+	// Token usage of the current run
+	usage usageTracker
+	// Explanation from a failed recovery strategy, appended to the history error
+	recoveryNote string
+	// Step recorder for Config.TrajectoryPath, opened on first Run
+	trajectory *trajectoryRecorder
+	// Navigation policy from Config.AllowedDomains / BlockedDomains
+	domains browser.DomainPolicy
+	// Pause/Resume gate checked at the top of each step
+	pause pauseGate
+	// RiskClassifier verdicts per URL + element text
+	riskCache map[string]RiskLevel
+}
+
+// RunResult describes a finished run.
+type RunResult struct {
+	Title        string    `json:"title"`                 // Task.Title
+	Slug         string    `json:"slug"`                  // Task.Slug
+	Success      bool      `json:"success"`               // Planner finished the task and reported it done
+	FinalMessage string    `json:"final_message"`         // Finish message shown to the user
+	Steps        int       `json:"steps"`                 // Steps taken
+	Output       string    `json:"output,omitempty"`      // Last extracted data (read_page/collect_texts result)
+	Artifacts    []string  `json:"artifacts,omitempty"`   // Files produced by the run (saved state, etc.)
+	Version      string    `json:"version"`               // Agent build version
+	PromptHash   string    `json:"prompt_hash"`           // Hash of the effective system prompt
+	Tools        []string  `json:"tools"`                 // Names of tools registered for the run
+	Usage        llm.Usage `json:"usage"`                 // LLM tokens over the run
+	CostUSD      float64   `json:"cost_usd,omitempty"`    // Estimated LLM cost, 0 if the model has no known price
+	StepTokens   []int     `json:"step_tokens,omitempty"` // Tokens per step
+	// FinishRejection is the verifier's reason when the run had to end on a finish it rejected
+	FinishRejection string `json:"finish_rejection,omitempty"`
+	// UnknownToolCalls counts decisions naming a tool that is not registered
+	UnknownToolCalls int `json:"unknown_tool_calls,omitempty"`
+	// FailureReason is set whenever Run returns an error
+	FailureReason *FailureReason `json:"failure_reason,omitempty"`
+	// History is every executed (or cancelled/skipped) action of the run
+	History []HistoryItem `json:"history"`
+	// Recovery summarizes adaptive error recovery, nil when no strategy was tried
+	Recovery *RecoveryStats `json:"recovery,omitempty"`
+	// Duration is the wall time of Run
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// tabActions choose the active tab themselves, a page switch while planning them is no reason to re-plan
+var tabActions = map[string]bool{"list_tabs": true, "switch_tab": true, "close_tab": true}
+
+// outputActions produce data the user asked for; the last observation becomes RunResult.Output
+var outputActions = map[string]bool{
+	"read_page":        true,
+	"read_page_ocr":    true,
+	"page_to_markdown": true,
+	"collect_texts":    true,
+	"extract_table":    true,
 }
 
 type TaskMemory struct {
-	ScrollCount  int
-	LastSnapshot snapshot.Summary
-	LastAction   string
+	ScrollCount  int              `json:"scroll_count"`
+	LastSnapshot snapshot.Summary `json:"-"` // Re-observed after resume
+	LastAction   string           `json:"last_action"`
+	// Progress summarizes history older than the planner's recent window (Config.SummarizeHistory)
+	Progress     string `json:"progress,omitempty"`
+	ProgressUpTo int    `json:"progress_up_to,omitempty"` // History items covered by Progress
+	// Deliverables is the checklist of requested outputs (Config.Deliverables)
+	Deliverables []Deliverable `json:"deliverables,omitempty"`
 }
 
 type errorRecord struct {
@@ -44,28 +192,187 @@ type errorRecord struct {
 	timestamp time.Time
 }
 
-func NewOrchestrator(cfg Config, planner Planner, toolbox tools.Toolbox, logger zerolog.Logger) *Orchestrator {
+func NewOrchestrator(cfg Config, planner Planner, toolbox tools.Toolbox, logger zerolog.Logger, subAgents ...SubAgent) *Orchestrator {
+	if cfg.Observer == nil && !cfg.Quiet {
+		cfg.Observer = ConsoleObserver{Out: cfg.Output}
+	}
 	return &Orchestrator{
-		cfg:     cfg,
-		planner: planner,
-		tools:   toolbox,
-		logger:  logger,
-		memory:  &TaskMemory{},
+		cfg:       cfg,
+		planner:   planner,
+		tools:     toolbox,
+		logger:    logger,
+		subAgents: subAgents,
+		memory:    &TaskMemory{},
+		consent:   make(map[string]browser.ConsentResult),
+		obsCaps:   resolveObservationCaps(cfg.ObservationCaps),
+		domains:   browser.NewDomainPolicy(cfg.AllowedDomains, cfg.BlockedDomains),
+		riskCache: make(map[string]RiskLevel),
 	}
 }
 
-func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) error {
+// Run executes the task and reports token usage of the run in the result.
+func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) (RunResult, error) {
+	o.usage = usageTracker{}
+	if o.cfg.TrajectoryPath != "" && o.trajectory == nil {
+		rec, err := newTrajectoryRecorder(o.cfg.TrajectoryPath)
+		if err != nil {
+			o.logger.Warn().Err(err).Msg("trajectory recording disabled")
+		} else {
+			o.trajectory = rec
+		}
+	}
+	ctx, stopDeadline := o.withRunDeadline(ctx)
+	defer stopDeadline()
+	start := time.Now()
+	o.nameTask(ctx, &task)
+	o.logger.Info().Str("title", task.Title).Str("slug", task.Slug).Msg("task")
+	var (
+		result RunResult
+		err    error
+	)
+	if task.Batch != nil {
+		result, err = o.runBatch(ctx, task, snap)
+	} else {
+		result, err = o.run(ctx, task, snap)
+	}
+	result.Duration = time.Since(start)
+	result.Title, result.Slug = task.Title, task.Slug
+	if err != nil {
+		if te := runTimeout(ctx); te != nil {
+			// The loop saw a plain cancellation - report the deadline instead
+			te.Steps = result.Steps
+			err = te
+			if result.FailureReason != nil {
+				result.FailureReason.Kind, result.FailureReason.Detail = FailureTimeout, err.Error()
+			}
+		}
+		// Sites without an explicit reason are classified from the error
+		result.fail(failureKind(err), err, "", "")
+	}
+	o.finishUsage(&result)
+	o.notify(func(obs Observer) { obs.OnFinish(FinishEvent{Result: result, Err: err}) })
+	return result, err
+}
+
+func (o *Orchestrator) run(ctx context.Context, task Task, snap summaryFunc) (result RunResult, err error) {
+	result = o.newRunResult()
+	o.contextRecreated = false
+	o.logger.Info().
+		Str("version", result.Version).
+		Str("prompt_hash", result.PromptHash).
+		Strs("tools", result.Tools).
+		Msg("run start")
+
+	var archive *stepArchive
+	if o.cfg.ScreenshotDir != "" {
+		a, err := newStepArchive(o.cfg.ScreenshotDir)
+		if err != nil {
+			o.logger.Warn().Err(err).Msg("screenshot archive disabled")
+		} else {
+			archive = a
+			result.Artifacts = append(result.Artifacts, o.cfg.ScreenshotDir)
+		}
+	}
+
 	history := make([]HistoryItem, 0, 8)
-	for step := 1; step <= o.cfg.MaxSteps; step++ {
+	loops := newLoopDetector(o.cfg.LoopLimits)
+	selectors := &selectorUsage{}
+	// Snapshot the previous decision was planned on, for the planner's change summary
+	var planned *snapshot.Summary
+	lastURL := ""
+	hydratedURL := "" // Last URL checked for an empty first render (one wait per navigation)
+	startStep := 1
+	if cp := o.cfg.Resume; cp != nil {
+		history = append(history, cp.History...)
+		memory := cp.Memory
+		o.memory = &memory
+		startStep = cp.Step + 1
+		lastURL = cp.URL
+		if err := o.resumeAt(ctx, cp); err != nil {
+			o.logger.Warn().Err(err).Str("url", cp.URL).Msg("resume: navigate to last URL failed")
+		}
+	} else {
+		// Batch items and repeated runs start without an old progress summary
+		o.memory.Progress, o.memory.ProgressUpTo = "", 0
+		if o.cfg.Deliverables != nil {
+			o.extractDeliverables(ctx, task.Description)
+		}
+	}
+	completed := startStep - 1
+	// History items appended while executing a decision carry its Source
+	source, sourceFrom := "", len(history)
+	stampSource := func() {
+		for i := sourceFrom; i < len(history); i++ {
+			history[i].Source = source
+		}
+		sourceFrom = len(history)
+	}
+	defer func() {
+		stampSource()
+		result.History = history
+		o.saveCheckpoint(task, completed, lastURL, history)
+	}()
+	// The loop body runs under the per-step context; prompts to the human use runCtx
+	runCtx := ctx
+	cancelStep := func() {}
+	defer func() { cancelStep() }()
+	stepTimeouts := 0 // Consecutive
+	finishRejections := 0
+	deliverableRejections := 0
+	// timedOut turns an expired step into a history entry; false once the timeouts repeat
+	timedOut := func(ctx context.Context, phase, url string) bool {
+		if !o.stepTimedOut(ctx, runCtx) {
+			return false
+		}
+		stepTimeouts++
+		o.logger.Warn().Int("step", result.Steps).Str("phase", phase).Dur("timeout", o.cfg.StepTimeout).Msg("step timed out")
+		history = append(history, HistoryItem{
+			Action: "observation",
+			Result: fmt.Sprintf("step timed out after %s while %s - try a simpler or different action", o.cfg.StepTimeout, phase),
+			URL:    url,
+		})
+		return stepTimeouts < maxStepTimeouts
+	}
+	for step := startStep; step <= o.cfg.MaxSteps; step++ {
+		result.Steps = step
+		stampSource()
+		if step > startStep {
+			completed = step - 1
+			o.saveCheckpoint(task, completed, lastURL, history)
+		}
 		if err := ctx.Err(); err != nil {
-			return err
+			result.fail(FailureUserCancel, err, "", lastURL)
+			return result, err
+		}
+		note, err := o.waitIfPaused(ctx, step)
+		if err != nil {
+			result.fail(FailureUserCancel, err, "", lastURL)
+			return result, err
+		}
+		if note != "" {
+			history = append(history, HistoryItem{Action: "observation", Result: note, URL: lastURL})
+		}
+		cancelStep()
+		ctx, cancel := o.stepContext(runCtx)
+		cancelStep = cancel
+
+		if o.cfg.AutoDismissConsent {
+			if item, ok := o.handleConsent(ctx); ok {
+				history = append(history, item)
+			}
 		}
 
-		// Wait for stable DOM after navigation (event-driven, not fixed sleep)
-		if len(history) > 0 && history[len(history)-1].Action == "navigate" {
-			// Use WaitForStableDOM instead of fixed sleep for better performance
-			if err := o.tools.WaitForStableDOM(ctx, 5*time.Second); err != nil {
-				o.logger.Debug().Err(err).Msg("wait for stable DOM after navigate")
+		// A popup that opened or closed itself since the last action (OAuth windows) changes the page
+		if sw, ok := o.tools.FollowPages(ctx); ok {
+			o.logger.Info().Str("event", sw.Reason).Str("popup", sw.URL).Str("active", sw.Tab.URL).Msg("active page changed")
+			history = append(history, HistoryItem{Action: "observation", Result: sw.String(), URL: lastURL})
+		}
+		// Files downloaded since the last action that wait_for_download didn't report
+		for _, d := range o.tools.TakeDownloads() {
+			o.logger.Info().Str("file", d.Filename).Str("path", d.Path).Str("error", d.Error).Msg("download finished")
+			history = append(history, HistoryItem{Action: "observation", Result: d.String(), URL: lastURL})
+			if d.Path != "" {
+				result.Artifacts = append(result.Artifacts, d.Path)
 			}
 		}
 
@@ -75,6 +382,14 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 		ctxSnap, cancel := snapshot.WithDeadline(ctx, 5*time.Second)
 		summary, _ := snap(ctxSnap)
 		cancel()
+		hydration := ""
+		if summary.URL != hydratedURL {
+			hydratedURL = summary.URL
+			summary, hydration = o.awaitHydration(ctx, summary, snap)
+		}
+		if summary.URL != "" {
+			lastURL = summary.URL
+		}
 
 		// Update toolbox with current snapshot so collect_texts can find real indices
 		o.tools.SetSnapshot(&summary)
@@ -110,19 +425,64 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 			Msg("snapshot")
 
 		state := State{
-			Task:    task.Description,
-			Step:    step,
-			History: last(history, 5),
-			Summary: summary,
-			Tools:   o.tools.Describe(),
+			Task:         task.Description,
+			Step:         step,
+			History:      last(history, recentHistoryItems),
+			Summary:      summary,
+			Tools:        o.tools.Describe(),
+			SelectorHint: selectors.guidance(summary),
+			Deliverables: renderDeliverables(o.memory.Deliverables),
+			Now:          o.now(),
+			Hydration:    hydration,
+		}
+		if planned != nil {
+			diff := snapshot.Compare(*planned, summary)
+			state.Changes, state.Diff = diff.String(), &diff
+		}
+		plannedOn := summary // summary is reassigned after the action
+		planned = &plannedOn
+		if o.cfg.SummarizeHistory {
+			o.updateProgress(history)
+			state.Progress = o.memory.Progress
+		}
+		if o.cfg.UseVision {
+			if shot, err := o.captureVision(ctx); err != nil {
+				o.logger.Warn().Err(err).Msg("vision screenshot failed - planning from text snapshot only")
+			} else {
+				state.Screenshot = shot
+			}
 		}
 
-		// Use unified planner with dynamic system prompt (browser-use pattern)
-		// No sub-agents needed - planner adapts to task type automatically
-		dec, err := o.planner.Next(ctx, state)
+		// Sub-agent that can handle the task plans first, unified planner is the fallback
+		planStart := time.Now()
+		dec, agentName, err := o.plan(ctx, task, state)
+		planDuration := time.Since(planStart)
 		if err != nil {
-			return fmt.Errorf("planner: %w", err)
+			if timedOut(ctx, "planning", summary.URL) {
+				continue
+			}
+			err = fmt.Errorf("planner (%s): %w", agentName, err)
+			kind := failureKind(err)
+			if o.stepTimedOut(ctx, runCtx) {
+				err = fmt.Errorf("%d steps in a row timed out: %w", stepTimeouts, err)
+				kind = FailureTimeout
+			}
+			result.fail(kind, err, "", summary.URL)
+			return result, err
 		}
+		if err := o.checkBudget(); err != nil {
+			result.fail(FailureBudget, err, dec.ActionName, summary.URL)
+			return result, err
+		}
+		source, sourceFrom = dec.Source, len(history) // Consent notes above stay unattributed
+		o.logger.Info().
+			Int("step", step).
+			Str("agent", agentName).
+			Str("action", dec.ActionName).
+			Msg("decision")
+		o.notify(func(obs Observer) {
+			obs.OnStep(StepEvent{Step: step, Summary: summary, Decision: dec, Agent: agentName, PlanDuration: planDuration})
+		})
 
 		// Log reasoning if available (for debugging and transparency)
 		if dec.Thinking != "" {
@@ -138,20 +498,68 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 			o.logger.Info().Str("next_goal", dec.NextGoal).Msg("next goal")
 		}
 
+		o.markDeliverables(dec.Memory)
+		if dec.Finish && o.cfg.Deliverables != nil && deliverableRejections < maxDeliverableRejections {
+			if unmet := o.unmetDeliverables(ctx, step, dec.Message); len(unmet) > 0 {
+				deliverableRejections++
+				o.logger.Info().Strs("unmet", unmet).Msg("finish rejected: deliverables missing")
+				history = append(history, HistoryItem{
+					Action: "finish",
+					Result: "finish rejected: the message does not give " + strings.Join(unmet, "; ") + " - find them, or say explicitly in the message why they are unavailable",
+					URL:    summary.URL,
+				})
+				continue
+			}
+		}
+		finishRejected := ""
+		if dec.Finish && o.cfg.VerifyFinish != nil {
+			finishRejected = o.finishRejection(ctx, step, task.Description, dec, snap)
+			if finishRejected != "" && finishRejections < maxFinishRejections {
+				finishRejections++
+				history = append(history, HistoryItem{
+					Action: "finish",
+					Result: "finish rejected: " + finishRejected + " - check the page and complete the task",
+					URL:    summary.URL,
+				})
+				continue
+			}
+		}
 		if dec.Finish {
-			if dec.Message != "" {
-				fmt.Printf("✅ %s\n", dec.Message)
-			} else {
-				// Fallback: use thinking or memory if message is empty
-				if dec.Thinking != "" {
-					fmt.Printf("✅ %s\n", dec.Thinking)
-				} else if dec.Memory != "" {
-					fmt.Printf("✅ Task completed. %s\n", dec.Memory)
-				} else {
-					fmt.Printf("✅ Task completed\n")
-				}
+			// Printing is up to the caller (see --finish-template)
+			result.Success = dec.Success
+			if finishRejected != "" {
+				// Out of rejections: the run ends, but not as a success the verifier disputes
+				result.Success = false
+				result.FinishRejection = finishRejected
 			}
-			return nil
+			switch {
+			case dec.Message != "":
+				result.FinalMessage = dec.Message
+			// Fallback: use thinking or memory if message is empty
+			case dec.Thinking != "":
+				result.FinalMessage = dec.Thinking
+			case dec.Memory != "":
+				result.FinalMessage = "Task completed. " + dec.Memory
+			default:
+				result.FinalMessage = "Task completed"
+			}
+			return result, nil
+		}
+
+		// Invented tool names: map common aliases, otherwise answer with suggestions
+		// instead of spending a tool call and the recovery strategies on it
+		if alias := normalizeToolAlias(dec.ActionName, dec.ActionInput); alias != dec.ActionName {
+			o.logger.Info().Str("action", dec.ActionName).Str("tool", alias).Msg("tool alias normalized")
+			dec.ActionName = alias
+		}
+		if msg := unknownToolMessage(dec.ActionName, state.Tools); msg != "" {
+			result.UnknownToolCalls++
+			o.logger.Warn().Str("action", dec.ActionName).Int("count", result.UnknownToolCalls).Msg("unknown tool")
+			history = append(history, HistoryItem{Action: dec.ActionName, Result: msg, URL: summary.URL})
+			o.notify(func(obs Observer) {
+				obs.OnAction(ActionEvent{Step: step, Action: dec.ActionName, Input: dec.ActionInput, Err: errors.New(msg), URL: summary.URL})
+			})
+			continue
 		}
 
 		limit := 3
@@ -194,29 +602,135 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 			checkInput[k] = v
 		}
 		checkInput["_url"] = summary.URL
+		checkInput["_selector"] = historySelector(dec, summary)
 		if tooManyRepeats(history, dec.ActionName, checkInput, limit) {
-			return fmt.Errorf("too many repeated actions: %s (limit: %d). Try a different action", dec.ActionName, limit)
+			err := fmt.Errorf("too many repeated actions: %s (limit: %d). Try a different action", dec.ActionName, limit)
+			result.fail(FailureRepeatedAction, err, dec.ActionName, summary.URL)
+			return result, err
+		}
+		// Name-based limits miss loops over different inputs - compare page states too
+		if dec.ActionName != "request_user_input" {
+			key := stepKey{state: pageStateHash(summary), action: dec.ActionName, input: inputHash(dec.ActionInput)}
+			switch verdict, msg := loops.check(key); verdict {
+			case loopNote:
+				history = append(history, HistoryItem{Action: "observation", Result: msg, URL: summary.URL})
+			case loopWarn:
+				o.logger.Warn().Int("step", step).Str("action", dec.ActionName).Msg("loop detected - warning planner")
+				history = append(history, HistoryItem{Action: "observation", Result: msg, URL: summary.URL})
+				continue
+			case loopStuck:
+				err := fmt.Errorf("no progress: %s", msg)
+				result.fail(FailureNoProgress, err, dec.ActionName, summary.URL)
+				return result, err
+			}
 		}
 
-		// Security layer: check for destructive actions
-		if requiresConfirmation(dec.ActionName, dec.ActionInput) {
-			confirmed, err := o.requestConfirmation(ctx, dec.ActionName, dec.ActionInput)
+		selectors.record(dec, summary)
+
+		// Security layer: check for destructive actions. Keywords are the fast path,
+		// the risk classifier settles ambiguous cases
+		keyword, severity := o.cfg.ConfirmationPolicy.match(dec.ActionName, confirmationInput(dec, summary))
+		if o.cfg.RiskClassifier != nil {
+			if q, ok := riskQuery(task.Description, dec, summary); ok && riskAmbiguous(severity, q) {
+				switch level, _ := o.classifyRisk(ctx, step, q); level {
+				case RiskSafe, RiskNeedsConfirmation:
+					keyword, severity = applyRiskLevel(level, keyword, severity)
+				case RiskForbidden:
+					history = append(history, HistoryItem{
+						Action: dec.ActionName,
+						Result: fmt.Sprintf("refused: risk classifier rated %s %q as forbidden for this task - find another way or finish", q.Role, q.Text),
+						URL:    summary.URL,
+					})
+					o.printf("⛔ Action refused by risk classifier: %s %q\n", dec.ActionName, q.Text)
+					continue
+				}
+			}
+		}
+		if keyword != "" {
+			confirmed, refusal, err := o.confirmAction(runCtx, keyword, severity, dec.ActionName, dec.ActionInput, summary.URL)
 			if err != nil {
-				return fmt.Errorf("confirmation request failed: %w", err)
+				err = fmt.Errorf("confirmation request failed: %w", err)
+				result.fail(FailurePrompt, err, dec.ActionName, summary.URL)
+				return result, err
 			}
 			if !confirmed {
 				item := HistoryItem{
 					Action: dec.ActionName,
-					Result: "cancelled by user",
+					Result: refusal,
 					URL:    summary.URL,
 				}
-				if dec.ActionName == "click_selector" {
-					if sel, ok := dec.ActionInput["selector"].(string); ok {
-						item.Selector = sel
-					}
-				}
+				item.Selector = historySelector(dec, summary)
 				history = append(history, item)
-				fmt.Printf("⚠️  Action cancelled by user: %s\n", dec.ActionName)
+				o.printf("⚠️  Action not confirmed: %s - %s\n", dec.ActionName, refusal)
+				continue
+			}
+		}
+
+		if o.cfg.Supervised {
+			edited, verdict, err := o.supervise(runCtx, dec, summary.URL)
+			if err != nil {
+				err = fmt.Errorf("supervisor prompt failed: %w", err)
+				result.fail(FailurePrompt, err, dec.ActionName, summary.URL)
+				return result, err
+			}
+			switch verdict {
+			case verdictAbort:
+				err := fmt.Errorf("aborted by supervisor at step %d", step)
+				result.fail(FailureUserCancel, err, dec.ActionName, summary.URL)
+				return result, err
+			case verdictSkip:
+				history = append(history, HistoryItem{
+					Action: dec.ActionName,
+					Result: "skipped by supervisor - choose a different action",
+					URL:    summary.URL,
+				})
+				continue
+			}
+			dec = edited
+		}
+
+		// Refuse navigation outside the domain policy - the planner sees why and adjusts
+		if dec.ActionName == "navigate" {
+			target, _ := dec.ActionInput["url"].(string)
+			if o.cfg.LocalizeURLs {
+				if localized := localizeURL(target, DetectLanguage(task.Description)); localized != target {
+					o.logger.Debug().Str("url", localized).Msg("navigate: UI language parameter added")
+					dec.ActionInput["url"], target = localized, localized
+				}
+			}
+			if ok, reason := o.domains.Allows(target); !ok {
+				o.logger.Warn().Str("url", target).Str("reason", reason).Msg("navigation refused by domain policy")
+				history = append(history, HistoryItem{
+					Action: dec.ActionName,
+					Result: fmt.Sprintf("refused to open %s: %s - stay on the allowed sites", target, reason),
+					URL:    summary.URL,
+				})
+				continue
+			}
+		}
+
+		// Back/forward ping-pong: make the planner navigate or click instead
+		if dec.ActionName == "go_back" || dec.ActionName == "go_forward" {
+			if n := historySteps(history); n >= maxHistorySteps {
+				o.logger.Warn().Str("action", dec.ActionName).Int("in_a_row", n).Msg("history navigation refused")
+				history = append(history, HistoryItem{
+					Action: dec.ActionName,
+					Result: fmt.Sprintf("refused: %d back/forward steps in a row - navigate to the page you need or click a link on this one", n),
+					URL:    summary.URL,
+				})
+				continue
+			}
+		}
+
+		// The decision was planned on a page that is no longer active: re-plan on a fresh snapshot
+		if !tabActions[dec.ActionName] {
+			if sw, ok := o.tools.FollowPages(ctx); ok {
+				o.logger.Info().Str("event", sw.Reason).Str("popup", sw.URL).Str("skipped", dec.ActionName).Msg("active page changed while planning")
+				history = append(history, HistoryItem{
+					Action: "observation",
+					Result: sw.String() + " - " + dec.ActionName + " was not run, it was planned on the previous page",
+					URL:    summary.URL,
+				})
 				continue
 			}
 		}
@@ -234,16 +748,10 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 			// Check if element text contains captcha-related text
 			isCaptchaElement := false
 			if dec.ActionName == "click_by_index" {
-				if index, ok := dec.ActionInput["index"].(float64); ok {
-					indexInt := int(index)
-					for i := range summary.Elements {
-						if summary.Elements[i].Index == indexInt {
-							elText := strings.ToLower(summary.Elements[i].Text)
-							isCaptchaElement = strings.Contains(elText, "робот") || strings.Contains(elText, "robot") ||
-								strings.Contains(elText, "не робот") || strings.Contains(elText, "not a robot")
-							break
-						}
-					}
+				if el := indexedElement(dec, summary); el != nil {
+					elText := strings.ToLower(el.Text)
+					isCaptchaElement = strings.Contains(elText, "робот") || strings.Contains(elText, "robot") ||
+						strings.Contains(elText, "не робот") || strings.Contains(elText, "not a robot")
 				}
 			} else if name, ok := dec.ActionInput["name"].(string); ok {
 				nameLower := strings.ToLower(name)
@@ -268,262 +776,183 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 			}
 		}
 
-		// Handle click_by_index: convert to click_selector using element from snapshot (browser-use pattern)
-		var foundElement *snapshot.Element // Keep reference for bbox fallback
-		if dec.ActionName == "click_by_index" {
-			index, ok := dec.ActionInput["index"].(float64)
-			if !ok {
-				indexInt, okInt := dec.ActionInput["index"].(int)
-				if okInt {
-					index = float64(indexInt)
-				} else {
-					return fmt.Errorf("invalid index type for click_by_index")
-				}
-			}
-			indexInt := int(index)
-
-			// Find element by index in snapshot
-			for i := range summary.Elements {
-				if summary.Elements[i].Index == indexInt {
-					foundElement = &summary.Elements[i]
-					break
-				}
-			}
-
-			if foundElement == nil {
-				// Build list of available indices for better error message
-				availableIndices := make([]int, 0, len(summary.Elements))
-				for _, el := range summary.Elements {
-					availableIndices = append(availableIndices, el.Index)
-				}
-				return fmt.Errorf("element with index %d not found in current snapshot. Available indices: %v. Use an index from the current snapshot", indexInt, availableIndices)
-			}
-
-			// CRITICAL FIX: For CDP elements without bbox, prefer selector if available
-			// CDP sees virtualized elements but they may not have valid selectors or bbox
-			// If selector exists and looks valid, use click_selector
-			// Otherwise, use click_role with name
-			if foundElement.BBox == "" && foundElement.Role != "" && foundElement.Role != "generic" && foundElement.Role != "none" {
-				// Element has no bbox (virtualized) - try selector first, then click_role
-				// Check if selector looks valid (not empty, not just role)
-				hasValidSelector := foundElement.Sel != "" &&
-					!strings.HasPrefix(foundElement.Sel, "[role=\"") && // Not just [role="link"]
-					strings.Contains(foundElement.Sel, "[") // Has some selector structure
-
-				if hasValidSelector {
-					// Use selector - it should work even without bbox
-					o.logger.Debug().
-						Int("index", indexInt).
-						Str("selector", foundElement.Sel).
-						Str("text", truncateTextForDebug(foundElement.Text, 30)).
-						Msg("CDP element without bbox - using selector")
-
-					dec.ActionName = "click_selector"
-					dec.ActionInput = map[string]any{"selector": foundElement.Sel}
-				} else {
-					// Use click_role with name - Playwright Locator API handles virtualized lists
-					// Even for email links, Playwright should find the right element
-					o.logger.Debug().
-						Int("index", indexInt).
-						Str("role", foundElement.Role).
-						Str("text", truncateTextForDebug(foundElement.Text, 30)).
-						Msg("CDP element without bbox - using click_role")
-
-					dec.ActionName = "click_role"
-					dec.ActionInput = map[string]any{
-						"role": foundElement.Role,
-					}
-					if foundElement.Text != "" {
-						dec.ActionInput["name"] = foundElement.Text
-					}
-				}
-			} else {
-				// Element has bbox or generic role - use normal selector conversion
-				o.logger.Debug().
-					Int("index", indexInt).
-					Str("selector", foundElement.Sel).
-					Str("text", truncateTextForDebug(foundElement.Text, 30)).
-					Str("bbox", foundElement.BBox).
-					Msg("converting click_by_index to click_selector")
-
-				dec.ActionName = "click_selector"
-				dec.ActionInput = map[string]any{"selector": foundElement.Sel}
+		actionStart := time.Now()
+		var recovery recoveryOutcome
+		actionEvent := func(action, observation string, err error, recovered bool, url string) {
+			o.notify(func(obs Observer) {
+				obs.OnAction(ActionEvent{
+					Step: step, Action: action, Input: dec.ActionInput, Observation: observation,
+					Err: err, Recovered: recovered, URL: url, Duration: time.Since(actionStart),
+					Strategy: recovery.strategy, RecoveryAttempts: recovery.attempts,
+				})
+			})
+		}
+		actCtx := ctx
+		if dec.ActionName == "request_user_input" {
+			actCtx = runCtx // The human takes as long as they need
+		}
+		toolResult, err := o.tools.Invoke(actCtx, dec.ActionName, dec.ActionInput)
+		if archive != nil {
+			// Capture failures never abort the step
+			if shotErr := archive.capture(ctx, o.tools, step, dec.ActionName, o.tools.Page().URL(), err); shotErr != nil {
+				o.logger.Debug().Err(shotErr).Msg("screenshot archive")
 			}
 		}
-
-		result, err := o.tools.Invoke(ctx, dec.ActionName, dec.ActionInput)
 		if err != nil {
-			// Browser-use pattern: if click_selector fails and we have bbox, try coordinates
-			if dec.ActionName == "click_selector" && foundElement != nil && foundElement.BBox != "" {
-				// Parse bbox: "x,y,width,height" -> center point
-				var x, y, w, h float64
-				if n, _ := fmt.Sscanf(foundElement.BBox, "%f,%f,%f,%f", &x, &y, &w, &h); n == 4 {
-					// Click at center of bbox
-					centerX := x + w/2
-					centerY := y + h/2
-					o.logger.Info().
-						Float64("x", centerX).
-						Float64("y", centerY).
-						Str("bbox", foundElement.BBox).
-						Msg("click_selector failed, trying click_coordinates from bbox")
-
-					coordResult, coordErr := o.tools.Invoke(ctx, "click_coordinates", map[string]any{
-						"x": int(centerX),
-						"y": int(centerY),
-					})
-					if coordErr == nil {
-						// Success with coordinates!
-						result = coordResult
-						err = nil
-						o.logger.Info().Msg("click_coordinates succeeded as fallback")
-					}
+			// Check if error is selector parsing error - skip retry for invalid selectors
+			errorType := o.analyzeError(err)
+			if errorType == "selector_parse_error" {
+				o.logger.Warn().
+					Err(err).
+					Str("action", dec.ActionName).
+					Msg("selector parse error - skipping retry, will try alternative")
+				item := HistoryItem{
+					Action: dec.ActionName,
+					Result: "error: invalid selector",
+					URL:    summary.URL,
 				}
+				item.Selector = historySelector(dec, summary)
+				history = append(history, item)
+				actionEvent(dec.ActionName, "", err, false, summary.URL)
+				// Update snapshot and continue
+				o.settle(ctx, afterErrorWait)
+				ctxSnapErr, cancelErr := snapshot.WithDeadline(ctx, 3*time.Second)
+				summaryErr, _ := snap(ctxSnapErr)
+				cancelErr()
+				summary = summaryErr
+				continue
 			}
 
-			if err != nil {
-				// Check if error is selector parsing error - skip retry for invalid selectors
-				errorType := o.analyzeError(err)
-				if errorType == "selector_parse_error" {
-					o.logger.Warn().
-						Err(err).
-						Str("action", dec.ActionName).
-						Msg("selector parse error - skipping retry, will try alternative")
-					item := HistoryItem{
-						Action: dec.ActionName,
-						Result: "error: invalid selector",
-						URL:    summary.URL,
-					}
-					if dec.ActionName == "click_selector" {
-						if sel, ok := dec.ActionInput["selector"].(string); ok {
-							item.Selector = sel
-						}
-					}
-					history = append(history, item)
-					// Update snapshot and continue
-					time.Sleep(500 * time.Millisecond)
-					ctxSnapErr, cancelErr := snapshot.WithDeadline(ctx, 3*time.Second)
-					summaryErr, _ := snap(ctxSnapErr)
-					cancelErr()
-					summary = summaryErr
-					continue
+			// Recovery can't run on an expired step - the next one starts with a fresh deadline
+			if o.stepTimedOut(ctx, runCtx) {
+				actionEvent(dec.ActionName, "", err, false, summary.URL)
+				if !timedOut(ctx, "running "+dec.ActionName, summary.URL) {
+					err = fmt.Errorf("%d steps in a row timed out: %w", stepTimeouts, err)
+					result.fail(FailureTimeout, err, dec.ActionName, summary.URL)
+					return result, err
 				}
+				continue
+			}
 
-				// Record error for adaptive handling
-				o.errorHistory = append(o.errorHistory, errorRecord{
-					action:    dec.ActionName,
-					errorType: errorType,
-					step:      step,
-					timestamp: time.Now(),
-				})
-				// Keep only last 10 errors
-				if len(o.errorHistory) > 10 {
-					o.errorHistory = o.errorHistory[len(o.errorHistory)-10:]
-				}
+			// Record error for adaptive handling
+			o.errorHistory = append(o.errorHistory, errorRecord{
+				action:    dec.ActionName,
+				errorType: errorType,
+				step:      step,
+				timestamp: time.Now(),
+			})
+			// Keep only last 10 errors
+			if len(o.errorHistory) > 10 {
+				o.errorHistory = o.errorHistory[len(o.errorHistory)-10:]
+			}
 
-				o.logger.Warn().
-					Err(err).
+			o.logger.Warn().
+				Err(err).
+				Str("action", dec.ActionName).
+				Str("error_type", errorType).
+				Msg("tool error")
+
+			// Re-observation: update snapshot before retry
+			o.settle(ctx, afterErrorWait)
+			ctxSnapRetry, cancelRetry := snapshot.WithDeadline(ctx, 3*time.Second)
+			freshSummary, _ := snap(ctxSnapRetry)
+			cancelRetry()
+
+			// CRITICAL: Check if page state changed (user completed action manually)
+			// If URL changed significantly or new elements appeared, user likely completed the action
+			urlChanged := summary.URL != freshSummary.URL
+			elementsChanged := len(freshSummary.Elements) != len(summary.Elements)
+
+			// If timeout occurred but page state changed, assume user completed the action
+			// This is especially important for fill_by_index - user may have filled field manually
+			if errorType == "timeout" && (urlChanged || elementsChanged) {
+				o.logger.Info().
+					Bool("url_changed", urlChanged).
+					Bool("elements_changed", elementsChanged).
+					Str("old_url", summary.URL).
+					Str("new_url", freshSummary.URL).
+					Int("old_elements", len(summary.Elements)).
+					Int("new_elements", len(freshSummary.Elements)).
 					Str("action", dec.ActionName).
-					Str("error_type", errorType).
-					Msg("tool error")
-
-				// Re-observation: update snapshot before retry
-				time.Sleep(500 * time.Millisecond) // Wait for DOM to settle
-				ctxSnapRetry, cancelRetry := snapshot.WithDeadline(ctx, 3*time.Second)
-				freshSummary, _ := snap(ctxSnapRetry)
-				cancelRetry()
-
-				// CRITICAL: Check if page state changed (user completed action manually)
-				// If URL changed significantly or new elements appeared, user likely completed the action
-				urlChanged := summary.URL != freshSummary.URL
-				elementsChanged := len(freshSummary.Elements) != len(summary.Elements)
-
-				// If timeout occurred but page state changed, assume user completed the action
-				// This is especially important for fill_by_index - user may have filled field manually
-				if errorType == "timeout" && (urlChanged || elementsChanged) {
-					o.logger.Info().
-						Bool("url_changed", urlChanged).
-						Bool("elements_changed", elementsChanged).
-						Str("old_url", summary.URL).
-						Str("new_url", freshSummary.URL).
-						Int("old_elements", len(summary.Elements)).
-						Int("new_elements", len(freshSummary.Elements)).
-						Str("action", dec.ActionName).
-						Msg("Page state changed after timeout - assuming user completed action manually")
-
-					// Record success with note that user completed it
-					item := HistoryItem{
-						Action: dec.ActionName,
-						Result: fmt.Sprintf("timeout but page changed - user likely completed action manually (URL changed: %v, elements changed: %v)", urlChanged, elementsChanged),
-						URL:    freshSummary.URL,
-					}
-					history = append(history, item)
-					summary = freshSummary
-					continue // Continue with new state
-				}
+					Msg("Page state changed after timeout - assuming user completed action manually")
 
-				// Adaptive error handling: try multiple strategies with fresh snapshot
-				recoveredAction, recoveredResult, success := o.handleErrorAdaptively(ctx, dec, freshSummary, snap, history, step)
-				if success {
-					// Successfully recovered from error
-					item := HistoryItem{
-						Action: recoveredAction,
-						Result: recoveredResult.Observation,
-						URL:    freshSummary.URL,
-					}
-					if recoveredAction == "click_selector" {
-						// Try to extract selector from original decision
-						if sel, ok := dec.ActionInput["selector"].(string); ok {
-							item.Selector = sel
-						}
-					}
-					history = append(history, item)
-					fmt.Printf("agent[%d]: %s (recovered) -> %s\n", step, recoveredAction, truncate(recoveredAction, recoveredResult.Observation))
-					// Re-observation loop: update snapshot after successful recovery
-					time.Sleep(800 * time.Millisecond)
-					ctxSnapAfter, cancelAfter := snapshot.WithDeadline(ctx, 3*time.Second)
-					summaryAfter, _ := snap(ctxSnapAfter)
-					cancelAfter()
-					summary = summaryAfter // Update summary for next iteration
-					o.updateMemory(recoveredAction, summaryAfter)
-					// Delay after click actions
-					if recoveredAction == "click_role" || recoveredAction == "click_selector" || recoveredAction == "click_text" {
-						time.Sleep(1 * time.Second)
-					}
-					continue
+				// Record success with note that user completed it
+				item := HistoryItem{
+					Action: dec.ActionName,
+					Result: fmt.Sprintf("timeout but page changed - user likely completed action manually (URL changed: %v, elements changed: %v)", urlChanged, elementsChanged),
+					URL:    freshSummary.URL,
 				}
+				history = append(history, item)
+				actionEvent(dec.ActionName, item.Result, nil, false, freshSummary.URL)
+				summary = freshSummary
+				continue // Continue with new state
+			}
 
-				// All recovery strategies failed
+			// Adaptive error handling: try multiple strategies with fresh snapshot
+			recovery = o.handleErrorAdaptively(ctx, recoveryDecision(dec, summary), freshSummary, snap, step)
+			for _, a := range recovery.attempts {
+				result.recordRecovery(a)
+			}
+			if recovery.ok {
+				// Successfully recovered from error
+				recoveredAction, recoveredResult := recovery.action, recovery.result
 				item := HistoryItem{
-					Action: dec.ActionName,
-					Result: "error: " + err.Error(),
-					URL:    summary.URL,
+					Action: recoveredAction,
+					Result: fmt.Sprintf("recovered via %s: %s", recovery.strategy, o.capObservation(recoveredAction, recoveredResult.Observation)),
+					URL:    freshSummary.URL,
 				}
-				if dec.ActionName == "click_selector" {
-					if sel, ok := dec.ActionInput["selector"].(string); ok {
-						item.Selector = sel
-					}
+				if recoveredAction == "click_selector" {
+					// Selector of the original decision
+					item.Selector = historySelector(dec, summary)
 				}
 				history = append(history, item)
-				// Re-observation: update snapshot even after error to see what changed
-				time.Sleep(500 * time.Millisecond)
-				ctxSnapErr, cancelErr := snapshot.WithDeadline(ctx, 3*time.Second)
-				summaryErr, _ := snap(ctxSnapErr)
-				cancelErr()
-				summary = summaryErr // Update summary for next iteration
-				// Don't give up immediately - let planner decide next action with fresh snapshot
+				actionEvent(recoveredAction, recoveredResult.Observation, nil, true, freshSummary.URL)
+				// Re-observation loop: update snapshot after successful recovery
+				o.waitAfterAction(ctx, recoveredAction)
+				ctxSnapAfter, cancelAfter := snapshot.WithDeadline(ctx, 3*time.Second)
+				summaryAfter, _ := snap(ctxSnapAfter)
+				cancelAfter()
+				summary = summaryAfter // Update summary for next iteration
+				o.updateMemory(recoveredAction, summaryAfter)
 				continue
 			}
+
+			// All recovery strategies failed
+			item := HistoryItem{
+				Action: dec.ActionName,
+				Result: "error: " + err.Error(),
+				URL:    summary.URL,
+			}
+			if note := o.takeRecoveryNote(); note != "" {
+				item.Result += " - " + note
+			}
+			item.Selector = historySelector(dec, summary)
+			history = append(history, item)
+			actionEvent(dec.ActionName, "", err, false, summary.URL)
+			// Re-observation: update snapshot even after error to see what changed
+			o.settle(ctx, afterErrorWait)
+			ctxSnapErr, cancelErr := snapshot.WithDeadline(ctx, 3*time.Second)
+			summaryErr, _ := snap(ctxSnapErr)
+			cancelErr()
+			summary = summaryErr // Update summary for next iteration
+			// Don't give up immediately - let planner decide next action with fresh snapshot
+			continue
+		}
+		stepTimeouts = 0
+		actionEvent(dec.ActionName, toolResult.Observation, nil, false, summary.URL)
+		if outputActions[dec.ActionName] {
+			result.Output = toolResult.Observation
 		}
-		fmt.Printf("agent[%d]: %s -> %s\n", step, dec.ActionName, truncate(dec.ActionName, result.Observation))
+		result.Artifacts = append(result.Artifacts, toolResult.Artifacts...)
 
 		// CRITICAL: After request_user_input with "done", check if page changed
 		// If page changed (URL or elements), user completed the action - don't ask again
-		if dec.ActionName == "request_user_input" && strings.Contains(result.Observation, "User confirmed: action completed") {
+		if dec.ActionName == "request_user_input" && strings.Contains(toolResult.Observation, "User confirmed: action completed") {
 			oldURL := summary.URL
 			oldElementCount := len(summary.Elements)
 
 			// Wait a bit for page to settle after user action
-			time.Sleep(1 * time.Second)
+			o.waitAfterAction(ctx, dec.ActionName)
 			ctxSnapAfter, cancelAfter := snapshot.WithDeadline(ctx, 3*time.Second)
 			freshSummaryAfter, _ := snap(ctxSnapAfter)
 			cancelAfter()
@@ -556,78 +985,89 @@ func (o *Orchestrator) Run(ctx context.Context, task Task, snap summaryFunc) err
 		// Create history item with selector, URL context, and reasoning fields (like browser-use-reference)
 		item := HistoryItem{
 			Action:                 dec.ActionName,
-			Result:                 result.Observation,
+			Result:                 o.capObservation(dec.ActionName, toolResult.Observation),
 			URL:                    summary.URL,
 			EvaluationPreviousGoal: dec.EvaluationPreviousGoal,
 			Memory:                 dec.Memory,
 			NextGoal:               dec.NextGoal,
 		}
-		if dec.ActionName == "click_selector" {
-			if sel, ok := dec.ActionInput["selector"].(string); ok {
-				item.Selector = sel
-			}
-		}
+		item.Selector = historySelector(dec, summary)
 		// Enhance history to show data flow without hardcoded hints
 		// For request_user_input: preserve the actual data value in result so agent can see what was received
 		// For fill_by_index: include the text that was filled so agent can match it with previous request_user_input results
 		// This helps agent track data flow: request -> receive -> use, without hardcoded instructions
-		if dec.ActionName == "request_user_input" && !strings.Contains(result.Observation, "User confirmed:") {
+		if dec.ActionName == "request_user_input" && !strings.Contains(toolResult.Observation, "User confirmed:") {
 			// This is data (not confirmation) - make it clear in history
-			item.Result = fmt.Sprintf("Received data from user: %s", result.Observation)
+			item.Result = receivedDataPrefix + toolResult.Observation
 		}
 		if dec.ActionName == "fill_by_index" {
 			if text, ok := dec.ActionInput["text"].(string); ok && text != "" {
 				// Include the filled text in result so agent can see what data was used
-				item.Result = fmt.Sprintf("%s (text: %s)", result.Observation, text)
+				item.Result = fmt.Sprintf("%s (text: %s)", item.Result, text)
 			}
 		}
 		history = append(history, item)
 
-		// Observation Stabilization: wait after scroll, then check if DOM changed
-		if dec.ActionName == "scroll_page" {
-			time.Sleep(1000 * time.Millisecond) // Wait for virtual list to render
-			ctxSnapStable, cancelStable := snapshot.WithDeadline(ctx, 3*time.Second)
-			stableSummary, _ := snap(ctxSnapStable)
-			cancelStable()
-			// Compare with previous snapshot
-			if !o.snapshotChanged(summary, stableSummary) {
-				o.logger.Info().Msg("snapshot unchanged after scroll - stopping scroll loop")
-				history = append(history, HistoryItem{
-					Action: "observation",
-					Result: "no changes after scroll - content may be in iframe, use collect_texts or read_page",
-				})
-				// Update summary to reflect that scroll didn't help
-				summary = stableSummary
-			} else {
-				summary = stableSummary
-			}
-		} else {
-			// Re-observation loop: update snapshot after every action
-			// For fill actions, wait longer to allow form validation and UI updates
-			// Forms may need time to validate input and update UI (enable buttons, show errors, etc.)
-			waitTime := 800 * time.Millisecond
-			if dec.ActionName == "fill_by_index" || dec.ActionName == "fill" {
-				waitTime = 3000 * time.Millisecond // Wait 3 seconds for form fields - gives pages time to update UI after input
+		// Re-observation loop: update snapshot after every action; pages that stop changing
+		// (scrolling an iframe, dead buttons) are caught by the loop detector
+		// Fill budgets are longer - forms validate input and update UI (enable buttons, show errors, etc.)
+		o.waitAfterAction(ctx, dec.ActionName)
+		ctxSnapAfter, cancelAfter := snapshot.WithDeadline(ctx, 3*time.Second)
+		summaryAfter, _ := snap(ctxSnapAfter)
+		cancelAfter()
+		summary = summaryAfter // Update summary for next iteration
+
+		// The action may still have left the allowed sites (JS location change, popup reuse)
+		if ok, reason := o.domains.Allows(summary.URL); !ok && summary.URL != "" {
+			o.logger.Warn().Str("url", summary.URL).Str("reason", reason).Msg("page left the allowed domains - going back")
+			if _, err := o.tools.Invoke(ctx, "go_back", nil); err != nil {
+				o.logger.Debug().Err(err).Msg("go back after domain policy violation")
 			}
-			time.Sleep(waitTime)
-			ctxSnapAfter, cancelAfter := snapshot.WithDeadline(ctx, 3*time.Second)
-			summaryAfter, _ := snap(ctxSnapAfter)
-			cancelAfter()
-
-			summary = summaryAfter // Update summary for next iteration
+			history = append(history, HistoryItem{
+				Action: "observation",
+				Result: fmt.Sprintf("page left the allowed sites (%s) - went back", reason),
+				URL:    summary.URL,
+			})
 		}
 
 		// Update memory after action
 		o.updateMemory(dec.ActionName, summary)
 
 		// No hardcoded auto-actions for specific URL patterns - LLM decides when to read content
+	}
+	err = fmt.Errorf("step limit reached")
+	result.fail(FailureStepLimit, err, "", lastURL)
+	return result, err
+}
 
-		// Delay after click actions to let heavy SPAs update
-		if dec.ActionName == "click_role" || dec.ActionName == "click_selector" || dec.ActionName == "click_text" {
-			time.Sleep(1 * time.Second)
-		}
+// printf writes a console progress line to Config.Output unless Config.Quiet is set
+func (o *Orchestrator) printf(format string, args ...any) {
+	if o.cfg.Quiet {
+		return
+	}
+	out := o.cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, args...)
+}
+
+// newRunResult fills reproducibility info: which build, prompt and toolset produced the run
+func (o *Orchestrator) newRunResult() RunResult {
+	return RunResult{
+		Version:    BuildVersion(),
+		PromptHash: SystemPromptHash(),
+		Tools:      ToolNames(o.tools.Describe()),
+	}
+}
+
+// ToolNames returns names of the given tools in registration order
+func ToolNames(ts []tools.Tool) []string {
+	names := make([]string, 0, len(ts))
+	for _, t := range ts {
+		names = append(names, t.Name)
 	}
-	return fmt.Errorf("step limit reached")
+	return names
 }
 
 type summaryFunc func(ctx context.Context) (snapshot.Summary, error)
@@ -640,20 +1080,14 @@ func last(items []HistoryItem, n int) []HistoryItem {
 }
 
 func truncate(action, s string) string {
-	if action == "read_page" {
-		return "(read_page data omitted)"
+	if action == "read_page" || action == "page_to_markdown" {
+		return "(" + action + " data omitted)"
 	}
-	if len(s) > 160 {
-		return s[:160] + "..."
-	}
-	return s
+	return truncateText(s, 160)
 }
 
 func truncateTextForDebug(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen] + "..."
+	return truncateText(s, maxLen)
 }
 
 func tooManyRepeats(history []HistoryItem, action string, input map[string]any, limit int) bool {
@@ -683,8 +1117,9 @@ func tooManyRepeats(history []HistoryItem, action string, input map[string]any,
 		return count >= limit
 	}
 
-	// For click_by_index, check by action + URL (strict limit to prevent loops)
+	// For click_by_index, check action + clicked element's selector + URL (strict limit to prevent loops)
 	if action == "click_by_index" {
+		selector, _ := input["_selector"].(string)
 		currentURL := ""
 		if url, ok := input["_url"].(string); ok {
 			currentURL = url
@@ -692,8 +1127,8 @@ func tooManyRepeats(history []HistoryItem, action string, input map[string]any,
 
 		count := 0
 		for i := len(history) - 1; i >= 0 && i >= len(history)-limit; i-- {
-			if history[i].Action == action && history[i].URL == currentURL {
-				// Same action on same URL - likely a loop
+			if history[i].Action == action && history[i].Selector == selector && history[i].URL == currentURL {
+				// Same element on same URL - likely a loop
 				count++
 			}
 		}
@@ -709,105 +1144,130 @@ func tooManyRepeats(history []HistoryItem, action string, input map[string]any,
 	return true
 }
 
-// requiresConfirmation checks if an action is destructive and requires user confirmation
-func requiresConfirmation(action string, input map[string]any) bool {
-	// Check action name for destructive keywords
-	destructiveActions := map[string]bool{
-		"click_selector": false, // will check by selector/text
-		"click_role":     false, // will check by role
-		"click_text":     false, // will check by text
-		"fill":           false, // will check by context
-	}
-
-	if !destructiveActions[action] {
-		return false
-	}
-
-	// Check input for destructive keywords
-	var textToCheck string
+// requestConfirmation asks user for confirmation before destructive action.
+// The prompt shows what will actually be clicked: element text, role, its container
+// (e.g. which email row) and the current URL. In headed mode the element is highlighted.
+func (o *Orchestrator) requestConfirmation(ctx context.Context, action string, input map[string]any, pageURL string) (bool, error) {
+	// Build description of the action
+	actionDesc := fmt.Sprintf("Action: %s", action)
 	if selector, ok := input["selector"].(string); ok {
-		textToCheck = selector
+		actionDesc += fmt.Sprintf(" on selector: %s", selector)
 	}
 	if role, ok := input["role"].(string); ok {
-		textToCheck = role
+		actionDesc += fmt.Sprintf(" on role: %s", role)
 	}
 	if text, ok := input["text"].(string); ok {
-		textToCheck = text
-	}
-	if label, ok := input["label"].(string); ok {
-		textToCheck = label
-	}
-
-	// Keywords that indicate destructive actions (case-insensitive)
-	destructiveKeywords := []string{
-		"delete", "удалить",
-		"payment", "оплатить", "купить", "buy", "purchase", "checkout", "pay",
-		"remove", "очистить", "clear",
-		"submit", "отправить", "подтвердить",
-		"confirm", "подтвердить",
-		"cancel", "отменить",
-		"archive", "архив",
-		"unsubscribe", "отписаться",
-	}
-
-	textToCheckLower := strings.ToLower(fmt.Sprintf("%v", textToCheck))
-	for _, keyword := range destructiveKeywords {
-		if contains(textToCheckLower, strings.ToLower(keyword)) {
-			return true
+		if fillActions[action] {
+			// The typed value may be a secret - say how much, not what
+			actionDesc += fmt.Sprintf(", typing %d characters", utf8.RuneCountInString(text))
+		} else {
+			actionDesc += fmt.Sprintf(" on text: %s", text)
 		}
 	}
 
-	return false
-}
-
-// requestConfirmation asks user for confirmation before destructive action
-func (o *Orchestrator) requestConfirmation(ctx context.Context, action string, input map[string]any) (bool, error) {
-	// Build description of the action
-	actionDesc := fmt.Sprintf("Action: %s", action)
-	if selector, ok := input["selector"].(string); ok {
-		actionDesc += fmt.Sprintf(" on selector: %s", selector)
+	// Resolve the target element so the user sees what will be affected
+	if info, err := o.tools.DescribeTarget(ctx, action, input); err == nil {
+		if info.Text != "" {
+			actionDesc += fmt.Sprintf("\nElement: %q", info.Text)
+		}
+		if info.Role != "" {
+			actionDesc += fmt.Sprintf("\nRole: %s", info.Role)
+		}
+		if info.Container != "" {
+			actionDesc += fmt.Sprintf("\nIn: %q", info.Container)
+		}
+	} else {
+		o.logger.Debug().Err(err).Str("action", action).Msg("could not resolve confirmation target")
 	}
-	if role, ok := input["role"].(string); ok {
-		actionDesc += fmt.Sprintf(" on role: %s", role)
+	if pageURL != "" {
+		actionDesc += fmt.Sprintf("\nPage: %s", pageURL)
 	}
-	if text, ok := input["text"].(string); ok {
-		actionDesc += fmt.Sprintf(" on text: %s", text)
+	if err := o.tools.HighlightTarget(ctx, action, input); err != nil {
+		o.logger.Debug().Err(err).Str("action", action).Msg("could not highlight confirmation target")
 	}
 
 	prompt := fmt.Sprintf("⚠️  SECURITY CHECK: This action may be destructive:\n%s\n\nDo you want to proceed? (yes/no): ", actionDesc)
 
-	// Use request_user_input tool to ask user
-	result, err := o.tools.Invoke(ctx, "request_user_input", map[string]any{
-		"prompt": prompt,
-	})
+	// Raw answer: request_user_input rewrites "yes" and would let "no thank you" pass a substring check
+	answer, err := o.tools.Ask(ctx, prompt)
 	if err != nil {
 		return false, err
 	}
+	return confirmedAnswer(answer), nil
+}
 
-	// Parse response
-	response := result.Observation
-	if contains(response, "yes") || contains(response, "да") || contains(response, "y") {
-		return true, nil
+// confirmationInput adds the snapshot element text to index-based clicks, so destructive
+// keyword checks see "Delete" instead of a bare index, and the field label to fills
+// (by index or selector) so they are judged by the field instead of the typed value
+func confirmationInput(dec Decision, summary snapshot.Summary) map[string]any {
+	if dec.ActionName != "click_by_index" && !fillActions[dec.ActionName] {
+		return dec.ActionInput
+	}
+	input := make(map[string]any, len(dec.ActionInput)+1)
+	for k, v := range dec.ActionInput {
+		input[k] = v
+	}
+	el := indexedElement(dec, summary)
+	if sel, _ := dec.ActionInput["selector"].(string); el == nil && sel != "" {
+		for i := range summary.Elements {
+			if summary.Elements[i].Sel == sel {
+				el = &summary.Elements[i]
+				break
+			}
+		}
+	}
+	if el == nil {
+		return input
 	}
-	return false, nil
+	if fillActions[dec.ActionName] {
+		input["label"] = el.Text
+	} else {
+		input["text"] = el.Text
+	}
+	return input
 }
 
-func contains(s, substr string) bool {
-	if len(s) < len(substr) {
-		return false
+// indexedElement returns the snapshot element an index action points at, nil if none
+func indexedElement(dec Decision, summary snapshot.Summary) *snapshot.Element {
+	var index int
+	switch v := dec.ActionInput["index"].(type) {
+	case float64:
+		index = int(v)
+	case int:
+		index = v
+	default:
+		return nil
+	}
+	for i := range summary.Elements {
+		if summary.Elements[i].Index == index {
+			return &summary.Elements[i]
+		}
 	}
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+	return nil
+}
+
+// historySelector is the selector a click acted on, recorded so repeat checks can tell targets apart
+func historySelector(dec Decision, summary snapshot.Summary) string {
+	switch dec.ActionName {
+	case "click_selector":
+		sel, _ := dec.ActionInput["selector"].(string)
+		return sel
+	case "click_by_index":
+		if el := indexedElement(dec, summary); el != nil {
+			return el.Sel
 		}
 	}
-	return false
+	return ""
 }
 
 // analyzeError categorizes error type for adaptive handling
 func (o *Orchestrator) analyzeError(err error) string {
 	errStr := strings.ToLower(err.Error())
 	switch {
+	case strings.Contains(errStr, "context closed") || strings.Contains(errStr, "context or browser has been closed"):
+		return "context_closed"
+	case strings.Contains(errStr, "strict mode violation"):
+		return "ambiguous_locator"
 	case strings.Contains(errStr, "badstring") || strings.Contains(errStr, "unsupported token") || strings.Contains(errStr, "parsing selector"):
 		return "selector_parse_error"
 	case strings.Contains(errStr, "timeout"):
@@ -825,103 +1285,6 @@ func (o *Orchestrator) analyzeError(err error) string {
 	}
 }
 
-// handleErrorAdaptively tries multiple recovery strategies based on error type
-func (o *Orchestrator) handleErrorAdaptively(ctx context.Context, dec Decision, summary snapshot.Summary, snap summaryFunc, history []HistoryItem, step int) (string, tools.Result, bool) {
-	// Don't retry if we've already tried too many times for this action
-	if o.hasRecentRetries(dec.ActionName, 2) {
-		return "", tools.Result{}, false
-	}
-
-	errorType := o.errorHistory[len(o.errorHistory)-1].errorType
-
-	// Strategy 1: Wait and retry (for timeout/stale element)
-	if errorType == "timeout" || errorType == "stale_element" {
-		o.logger.Info().Str("strategy", "wait_retry").Msg("trying wait and retry")
-		time.Sleep(2 * time.Second)
-		// Get fresh snapshot
-		ctxSnap, cancel := snapshot.WithDeadline(ctx, 3*time.Second)
-		freshSummary, _ := snap(ctxSnap)
-		cancel()
-		_ = freshSummary // use freshSummary if needed
-		// Retry original action
-		retryResult, err := o.tools.Invoke(ctx, dec.ActionName, dec.ActionInput)
-		if err == nil {
-			return dec.ActionName, retryResult, true
-		}
-	}
-
-	// Strategy 2: Try alternative action methods (for click actions)
-	if dec.ActionName == "click_selector" || dec.ActionName == "click_role" || dec.ActionName == "click_text" {
-		alternatives := o.generateAlternatives(dec, summary)
-		for _, alt := range alternatives {
-			o.logger.Info().
-				Str("original", dec.ActionName).
-				Str("alternative", alt.action).
-				Msg("trying alternative action")
-			altResult, err := o.tools.Invoke(ctx, alt.action, alt.input)
-			if err == nil {
-				return alt.action, altResult, true
-			}
-		}
-
-		// Strategy 2b: Try fuzzy text matching if we have text
-		if dec.ActionName == "click_selector" {
-			if text := o.extractTextFromSelector(dec, summary); text != "" {
-				o.logger.Info().Str("strategy", "fuzzy_text").Str("text", text).Msg("trying fuzzy text match")
-				fuzzyResult, err := o.tools.Invoke(ctx, "click_text_fuzzy", map[string]any{"text": text})
-				if err == nil {
-					return "click_text_fuzzy", fuzzyResult, true
-				}
-			}
-		}
-
-		// Strategy 2c: Try clicking by coordinates from bbox (last resort)
-		if coords := o.extractCoordinates(dec, summary); coords.x > 0 && coords.y > 0 {
-			o.logger.Info().
-				Float64("x", coords.x).
-				Float64("y", coords.y).
-				Msg("trying click by coordinates")
-			coordResult, err := o.tools.Invoke(ctx, "click_coordinates", map[string]any{
-				"x": int(coords.x),
-				"y": int(coords.y),
-			})
-			if err == nil {
-				return "click_coordinates", coordResult, true
-			}
-		}
-	}
-
-	// Strategy 3: Find similar element in snapshot (for element_not_found)
-	if errorType == "element_not_found" {
-		similar := o.findSimilarElement(dec, summary)
-		if similar.action != "" {
-			o.logger.Info().
-				Str("original", dec.ActionName).
-				Str("similar", similar.action).
-				Msg("trying similar element")
-			similarResult, err := o.tools.Invoke(ctx, similar.action, similar.input)
-			if err == nil {
-				return similar.action, similarResult, true
-			}
-		}
-	}
-
-	// Strategy 4: Scroll to make element visible (for not_interactable)
-	if errorType == "not_interactable" {
-		o.logger.Info().Str("strategy", "scroll_to_element").Msg("trying scroll to element")
-		// Try scrolling to element location if we have bbox info
-		if err := o.scrollToElement(ctx, dec, summary); err == nil {
-			time.Sleep(1 * time.Second)
-			retryResult, err := o.tools.Invoke(ctx, dec.ActionName, dec.ActionInput)
-			if err == nil {
-				return dec.ActionName, retryResult, true
-			}
-		}
-	}
-
-	return "", tools.Result{}, false
-}
-
 type alternativeAction struct {
 	action string
 	input  map[string]any
@@ -942,28 +1305,22 @@ func (o *Orchestrator) generateAlternatives(dec Decision, summary snapshot.Summa
 					input:  map[string]any{"text": text},
 				})
 			}
-			// Try click_role if selector has role
-			if role := o.extractRoleFromSelector(selector, summary); role != "" {
+			// Try click_role if selector has role; without a label it would click any element of the role
+			if role := o.extractRoleFromSelector(selector, summary); role != "" && text != "" {
 				alternatives = append(alternatives, alternativeAction{
 					action: "click_role",
-					input:  map[string]any{"role": role, "label": text},
+					input:  map[string]any{"role": role, "name": text},
 				})
 			}
 		}
 	case "click_role":
-		if role, ok := dec.ActionInput["role"].(string); ok {
-			label, _ := dec.ActionInput["label"].(string)
-			text := label
+		if _, ok := dec.ActionInput["role"].(string); ok {
+			// Only the named element: a bare [role='...'] selector or the text of some other
+			// element of the role would click whatever comes first
+			text, _ := dec.ActionInput["name"].(string)
 			if text == "" {
-				// Try to find text from snapshot
-				text = o.findTextByRole(role, summary)
+				text, _ = dec.ActionInput["label"].(string)
 			}
-			// Try click_selector
-			alternatives = append(alternatives, alternativeAction{
-				action: "click_selector",
-				input:  map[string]any{"selector": fmt.Sprintf("[role='%s']", role)},
-			})
-			// Try click_text if we have text
 			if text != "" {
 				alternatives = append(alternatives, alternativeAction{
 					action: "click_text",
@@ -972,12 +1329,12 @@ func (o *Orchestrator) generateAlternatives(dec Decision, summary snapshot.Summa
 			}
 		}
 	case "click_text":
-		if text, ok := dec.ActionInput["text"].(string); ok {
+		if text, ok := dec.ActionInput["text"].(string); ok && text != "" {
 			// Try click_role with common roles
 			for _, role := range []string{"button", "link", "menuitem"} {
 				alternatives = append(alternatives, alternativeAction{
 					action: "click_role",
-					input:  map[string]any{"role": role, "label": text},
+					input:  map[string]any{"role": role, "name": text},
 				})
 			}
 			// Try click_selector if we can find matching selector
@@ -993,38 +1350,82 @@ func (o *Orchestrator) generateAlternatives(dec Decision, summary snapshot.Summa
 	return alternatives
 }
 
-// findSimilarElement finds a similar element in snapshot when original is not found
-func (o *Orchestrator) findSimilarElement(dec Decision, summary snapshot.Summary) alternativeAction {
-	// Extract search criteria from original action
-	var searchText string
-	if text, ok := dec.ActionInput["text"].(string); ok {
-		searchText = strings.ToLower(text)
+// similarElementThreshold is the minimal normalized text similarity for similar-element recovery
+const similarElementThreshold = 0.75
+
+// findSimilarElement finds a similar element in snapshot when original is not found.
+// Candidate must share the original role and have text similarity above threshold.
+// Returns the recovery action and its similarity score.
+func (o *Orchestrator) findSimilarElement(dec Decision, summary snapshot.Summary) (alternativeAction, float64) {
+	role, text := o.originalTarget(dec, summary)
+	if role == "" || text == "" {
+		// Without role and text we can't prove the candidate has the same intent
+		return alternativeAction{}, 0
+	}
+
+	var best *snapshot.Element
+	bestScore := 0.0
+	for i := range summary.Elements {
+		elem := &summary.Elements[i]
+		if !strings.EqualFold(elem.Role, role) {
+			continue
+		}
+		score := textSimilarity(text, elem.Text)
+		if score >= similarElementThreshold && score > bestScore {
+			best = elem
+			bestScore = score
+		}
 	}
-	if selector, ok := dec.ActionInput["selector"].(string); ok {
-		searchText = strings.ToLower(selector)
+	if best == nil {
+		return alternativeAction{}, 0
 	}
 
-	// Search in snapshot elements for similar text
-	for _, elem := range summary.Elements {
-		elemText := strings.ToLower(elem.Text)
-		if searchText != "" && strings.Contains(elemText, searchText) {
-			// Found similar element, try to click it
-			if elem.Sel != "" {
-				return alternativeAction{
-					action: "click_selector",
-					input:  map[string]any{"selector": elem.Sel},
+	if best.Sel != "" {
+		return alternativeAction{
+			action: "click_selector",
+			input:  map[string]any{"selector": best.Sel},
+		}, bestScore
+	}
+	return alternativeAction{
+		action: "click_role",
+		input:  map[string]any{"role": best.Role, "name": best.Text},
+	}, bestScore
+}
+
+// originalTarget extracts role and text of the element the failed action targeted
+func (o *Orchestrator) originalTarget(dec Decision, summary snapshot.Summary) (string, string) {
+	role, _ := dec.ActionInput["role"].(string)
+	var text string
+	for _, key := range []string{"name", "label", "text"} {
+		if v, ok := dec.ActionInput[key].(string); ok && strings.TrimSpace(v) != "" {
+			text = v
+			break
+		}
+	}
+	if selector, ok := dec.ActionInput["selector"].(string); ok && selector != "" {
+		for _, elem := range summary.Elements {
+			if elem.Sel == selector {
+				if role == "" {
+					role = elem.Role
 				}
-			}
-			if elem.Role != "" {
-				return alternativeAction{
-					action: "click_role",
-					input:  map[string]any{"role": elem.Role, "label": elem.Text},
+				if text == "" {
+					text = elem.Text
 				}
+				break
 			}
 		}
 	}
-
-	return alternativeAction{}
+	// click_text carries no role - infer it from an element with identical text
+	if role == "" && text != "" {
+		normalized := normalizeText(text)
+		for _, elem := range summary.Elements {
+			if normalizeText(elem.Text) == normalized {
+				role = elem.Role
+				break
+			}
+		}
+	}
+	return role, text
 }
 
 // Helper methods for alternative generation
@@ -1056,15 +1457,6 @@ func (o *Orchestrator) findSelectorByText(text string, summary snapshot.Summary)
 	return ""
 }
 
-func (o *Orchestrator) findTextByRole(role string, summary snapshot.Summary) string {
-	for _, elem := range summary.Elements {
-		if elem.Role == role {
-			return elem.Text
-		}
-	}
-	return ""
-}
-
 func (o *Orchestrator) scrollToElement(ctx context.Context, dec Decision, summary snapshot.Summary) error {
 	// Try to find element bbox in snapshot
 	for _, elem := range summary.Elements {
@@ -1144,15 +1536,60 @@ func (o *Orchestrator) extractTextFromSelector(dec Decision, summary snapshot.Su
 		if el.Sel == selector || strings.Contains(el.Sel, selector) {
 			// Use first line of text, limit length
 			text := strings.Split(el.Text, "\n")[0]
-			if len(text) > 50 {
-				text = text[:50]
-			}
+			text = cutRunes(text, 50)
 			return strings.TrimSpace(text)
 		}
 	}
 	return ""
 }
 
+// handleConsent dismisses cookie consent banner once per domain.
+// Returns history item describing the decision when a banner was handled.
+func (o *Orchestrator) handleConsent(ctx context.Context) (HistoryItem, bool) {
+	page := o.tools.Page()
+	if page == nil {
+		return HistoryItem{}, false
+	}
+	pageURL := page.URL()
+	domain := hostOf(pageURL)
+	if domain == "" {
+		return HistoryItem{}, false
+	}
+	if _, handled := o.consent[domain]; handled {
+		return HistoryItem{}, false
+	}
+	res, err := o.tools.DismissConsent(ctx)
+	if err != nil {
+		o.logger.Debug().Err(err).Str("domain", domain).Msg("consent dismiss failed")
+		return HistoryItem{}, false
+	}
+	// Only a handled banner marks the domain: consent walls often render after the first snapshot
+	if !res.Found {
+		return HistoryItem{}, false
+	}
+	o.consent[domain] = res
+	o.logger.Info().
+		Str("domain", domain).
+		Str("strategy", res.Strategy).
+		Str("button", res.Button).
+		Bool("verified", res.Verified).
+		Msg("cookie consent banner handled")
+	result := fmt.Sprintf("cookie consent banner dismissed automatically (%s: %q)", res.Strategy, res.Button)
+	if !res.Verified {
+		result = fmt.Sprintf("cookie consent banner clicked (%s: %q) but it is still visible - close it manually if it blocks the page", res.Strategy, res.Button)
+	}
+	return HistoryItem{Action: "observation", Result: result, URL: pageURL}, true
+}
+
+// hostOf returns lowercased host of URL without port, "" for non-http URLs like about:blank
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
 // updateMemory updates persistent memory about task progress
 func (o *Orchestrator) updateMemory(action string, summary snapshot.Summary) {
 	if o.memory == nil {
@@ -1168,36 +1605,3 @@ func (o *Orchestrator) updateMemory(action string, summary snapshot.Summary) {
 		o.memory.ScrollCount++
 	}
 }
-
-// snapshotChanged compares two snapshots to see if DOM changed significantly
-func (o *Orchestrator) snapshotChanged(old, new snapshot.Summary) bool {
-	// Simple comparison: check if element count changed or URL changed
-	if old.URL != new.URL {
-		return true
-	}
-	if len(old.Elements) != len(new.Elements) {
-		return true
-	}
-	// Check if we have new elements
-	if len(new.Elements) > len(old.Elements) {
-		// More elements = likely new content loaded
-		return true
-	}
-	// Check if element texts changed (simple heuristic)
-	if len(old.Elements) > 0 && len(new.Elements) > 0 {
-		// Compare first few elements
-		maxCompare := 10
-		if len(old.Elements) < maxCompare {
-			maxCompare = len(old.Elements)
-		}
-		if len(new.Elements) < maxCompare {
-			maxCompare = len(new.Elements)
-		}
-		for i := 0; i < maxCompare; i++ {
-			if old.Elements[i].Text != new.Elements[i].Text {
-				return true
-			}
-		}
-	}
-	return false
-}
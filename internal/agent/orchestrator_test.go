@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// decision renders planner output for the scripted model
+func decision(action string, input map[string]any) string {
+	data, _ := json.Marshal(map[string]any{
+		"thinking":                 "scripted",
+		"evaluation_previous_goal": "scripted",
+		"memory":                   "",
+		"next_goal":                "scripted",
+		"action":                   action,
+		"input":                    input,
+	})
+	return string(data)
+}
+
+func finishDecision(message string, success bool) string {
+	return decision("finish", map[string]any{"message": message, "success": success})
+}
+
+// scriptedRun runs the orchestrator with a scripted planner model over a fake browser; the page
+// state the loop observes is always summary
+func scriptedRun(t *testing.T, cfg Config, page browser.FakePage, summary snapshot.Summary, prompt tools.PromptFunc, responses ...string) (RunResult, *browser.FakeController, error) {
+	t.Helper()
+	ctrl := browser.NewFakeController(page)
+	result, err := runWithPrompt(t, cfg, ctrl, summary, prompt, responses...)
+	return result, ctrl, err
+}
+
+// runWithController is scriptedRun over a prepared controller (queued failures, pages)
+func runWithController(t *testing.T, cfg Config, ctrl *browser.FakeController, summary snapshot.Summary, responses ...string) (RunResult, error) {
+	t.Helper()
+	return runWithPrompt(t, cfg, ctrl, summary, nil, responses...)
+}
+
+func runWithPrompt(t *testing.T, cfg Config, ctrl *browser.FakeController, summary snapshot.Summary, prompt tools.PromptFunc, responses ...string) (RunResult, error) {
+	t.Helper()
+	if cfg.MaxSteps == 0 {
+		cfg.MaxSteps = len(responses) + 2
+	}
+	cfg.Quiet = true
+	if prompt == nil {
+		prompt = func(context.Context, string) (string, error) {
+			t.Fatal("unexpected question to the user")
+			return "", nil
+		}
+	}
+	orch := NewOrchestrator(cfg, NewPlanner(llm.NewScriptedClient(responses)), tools.New(ctrl, prompt), zerolog.Nop())
+	return orch.Run(context.Background(), Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+		return summary, nil
+	})
+}
+
+// scriptedVerifier answers finish verifications in order, repeating the last verdict
+type scriptedVerifier struct {
+	verdicts []FinishVerdict
+	calls    int
+}
+
+func (v *scriptedVerifier) Verify(ctx context.Context, task, message string, summary snapshot.Summary) (FinishVerdict, error) {
+	verdict := v.verdicts[min(v.calls, len(v.verdicts)-1)]
+	v.calls++
+	return verdict, nil
+}
+
+func TestFinishPropagatesPlannerSuccess(t *testing.T) {
+	for _, success := range []bool{true, false} {
+		result, _, err := scriptedRun(t, Config{}, loginPage, loginSummary, nil, finishDecision("done", success))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Success != success || result.FinalMessage != "done" {
+			t.Errorf("finish success=%v: got Success=%v message %q", success, result.Success, result.FinalMessage)
+		}
+	}
+}
+
+func TestVerifyFinishRejectsThenContinues(t *testing.T) {
+	verifier := &scriptedVerifier{verdicts: []FinishVerdict{{Done: false, Reason: "error banner visible"}, {Done: true}}}
+	result, _, err := scriptedRun(t, Config{VerifyFinish: verifier}, loginPage, loginSummary, nil,
+		finishDecision("signed in", true),
+		finishDecision("signed in now", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.FinalMessage != "signed in now" || result.FinishRejection != "" {
+		t.Errorf("got Success=%v message %q rejection %q, want the second finish accepted", result.Success, result.FinalMessage, result.FinishRejection)
+	}
+	if len(result.History) == 0 || result.History[0].Result != "finish rejected: error banner visible - check the page and complete the task" {
+		t.Errorf("history does not record the rejection: %+v", result.History)
+	}
+}
+
+func TestVerifyFinishOutOfRejectionsIsNotSuccess(t *testing.T) {
+	verifier := &scriptedVerifier{verdicts: []FinishVerdict{{Done: false, Reason: "error banner visible"}}}
+	result, _, err := scriptedRun(t, Config{VerifyFinish: verifier}, loginPage, loginSummary, nil,
+		finishDecision("signed in", true),
+		finishDecision("signed in", true),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifier.calls != maxFinishRejections+1 {
+		t.Errorf("verifier called %d times, want %d", verifier.calls, maxFinishRejections+1)
+	}
+	if result.Success {
+		t.Error("a finish the verifier kept rejecting was reported as success")
+	}
+	if result.FinishRejection != "error banner visible" {
+		t.Errorf("FinishRejection = %q, want the last verdict", result.FinishRejection)
+	}
+}
+
+func TestFinishOnFirstStep(t *testing.T) {
+	client := llm.NewScriptedClient([]string{finishDecision("nothing to do", true)})
+	orch := NewOrchestrator(Config{MaxSteps: 3, Quiet: true}, NewPlanner(client), tools.New(browser.NewFakeController(loginPage), nil), zerolog.Nop())
+	result, err := orch.Run(context.Background(), Task{Description: "open example.com"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Steps != 1 || len(result.History) != 0 {
+		t.Errorf("result = %+v, want success after one step without actions", result)
+	}
+	requests := client.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("planner asked %d times, want once", len(requests))
+	}
+	if last := requests[0].Messages[len(requests[0].Messages)-1].Content; !strings.Contains(last, "open example.com") || !strings.Contains(last, loginSummary.URL) {
+		t.Errorf("planner prompt lacks the task or the page: %q", last)
+	}
+}
+
+func TestRepeatLimitAborts(t *testing.T) {
+	click := decision("click_by_index", map[string]any{"index": 3})
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	result, ctrl, err := scriptedRun(t, Config{MaxSteps: 10}, page, loginSummary, nil, click, click, click, click, click, click)
+	if err == nil {
+		t.Fatalf("run with a looping planner succeeded: %+v", result)
+	}
+	if result.FailureReason == nil || result.FailureReason.Kind != FailureRepeatedAction || result.FailureReason.Action != "click_by_index" {
+		t.Errorf("failure = %+v, want %s on click_by_index", result.FailureReason, FailureRepeatedAction)
+	}
+	if n := len(ctrl.CallsTo("Click")); n == 0 || n >= 6 {
+		t.Errorf("%d clicks, want the loop cut before the script ran out", n)
+	}
+}
+
+func TestRecoveryRescuesFailedClick(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	ctrl := browser.NewFakeController(page)
+	ctrl.FailNext("Click", errors.New("element is not visible"))
+	client := llm.NewScriptedClient([]string{
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("signed in", true),
+	})
+	orch := NewOrchestrator(Config{MaxSteps: 4, Quiet: true}, NewPlanner(client), tools.New(ctrl, nil), zerolog.Nop())
+	result, err := orch.Run(context.Background(), Task{Description: "sign in"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Errorf("result = %+v, want success", result)
+	}
+	if result.Recovery == nil || result.Recovery.Successes != 1 {
+		t.Fatalf("recovery = %+v, want one successful recovery", result.Recovery)
+	}
+	if len(result.History) != 1 || !strings.HasPrefix(result.History[0].Result, "recovered via ") {
+		t.Errorf("history = %+v, want the recovered click", result.History)
+	}
+	if len(ctrl.CallsTo("ClickText")) != 1 {
+		t.Errorf("calls = %v, want the click retried by its text", ctrl.Calls())
+	}
+}
+
+func TestConfirmationPolicyModes(t *testing.T) {
+	click := decision("click_by_index", map[string]any{"index": 2})
+	tests := []struct {
+		mode       string
+		wantClicks int
+		wantResult string
+	}{
+		{ConfirmAutoApprove, 1, ""},
+		{ConfirmDeny, 0, "denied by confirmation policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := Config{ConfirmationPolicy: ConfirmationPolicy{Mode: tt.mode}}
+			result, ctrl, err := scriptedRun(t, cfg, inboxPage, inboxSummary, nil, click, finishDecision("done", true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(ctrl.CallsTo("Click")); n != tt.wantClicks {
+				t.Errorf("%d clicks, want %d", n, tt.wantClicks)
+			}
+			if tt.wantResult != "" && !strings.Contains(result.History[0].Result, tt.wantResult) {
+				t.Errorf("history = %+v, want %q", result.History, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestConfirmationCallback(t *testing.T) {
+	var got []string
+	cfg := Config{ConfirmationPolicy: ConfirmationPolicy{Callback: func(_ context.Context, action string, input map[string]any) (bool, error) {
+		got = append(got, action)
+		return false, nil
+	}}}
+	result, ctrl, err := scriptedRun(t, cfg, inboxPage, inboxSummary, nil,
+		decision("click_by_index", map[string]any{"index": 2}),
+		finishDecision("left the mail alone", false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "click_by_index" || len(ctrl.CallsTo("Click")) != 0 {
+		t.Errorf("callback saw %v, clicks %d; want one rejected click_by_index", got, len(ctrl.CallsTo("Click")))
+	}
+	if result.Success {
+		t.Error("finish with success=false reported as success")
+	}
+}
+
+func TestConsentHandledOncePerDomainWhenFound(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	ctrl := browser.NewFakeController(page)
+	// Step 1: no banner yet; step 2: it rendered late and gets accepted; after that the domain is done
+	ctrl.Consent(browser.ConsentResult{}, browser.ConsentResult{Found: true, Strategy: "accept", Button: "Accept all", Verified: true})
+	result, err := runWithController(t, Config{AutoDismissConsent: true}, ctrl, loginSummary,
+		decision("scroll_page", map[string]any{"direction": "down"}),
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ctrl.CallsTo("DismissConsent")); n != 2 {
+		t.Errorf("DismissConsent called %d times, want a retry after the empty first check and none after the banner", n)
+	}
+	found := false
+	for _, item := range result.History {
+		if strings.Contains(item.Result, `cookie consent banner dismissed automatically (accept: "Accept all")`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("history = %+v, want the dismissed banner reported", result.History)
+	}
+}
+
+func TestConsoleOutputGoesToConfiguredWriter(t *testing.T) {
+	var out strings.Builder
+	cfg := Config{MaxSteps: 4, Output: &out, ConfirmationPolicy: ConfirmationPolicy{Mode: ConfirmDeny}}
+	client := llm.NewScriptedClient([]string{
+		decision("click_by_index", map[string]any{"index": 2}),
+		decision("scroll_page", map[string]any{"direction": "down"}),
+		finishDecision("done", true),
+	})
+	orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(browser.NewFakeController(inboxPage), nil), zerolog.Nop())
+	if _, err := orch.Run(context.Background(), Task{Description: "clean the inbox"}, func(context.Context) (snapshot.Summary, error) {
+		return inboxSummary, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Action not confirmed: click_by_index", "agent[2]: scroll_page"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("console output %q lacks %q", out.String(), want)
+		}
+	}
+}
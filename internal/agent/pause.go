@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pauseGate holds the run at the top of the next step while paused
+type pauseGate struct {
+	mu       sync.Mutex
+	resumed  chan struct{} // Closed by Resume, nil while running
+	pausedAt time.Time
+	total    time.Duration // Paused time over the orchestrator lifetime
+}
+
+// Pause stops the run before its next step; false if already paused.
+// Safe to call from any goroutine.
+func (o *Orchestrator) Pause() bool {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		return false
+	}
+	g.resumed = make(chan struct{})
+	g.pausedAt = time.Now()
+	return true
+}
+
+// Resume continues a paused run with a fresh snapshot; false if not paused
+func (o *Orchestrator) Resume() bool {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		return false
+	}
+	close(g.resumed)
+	g.resumed = nil
+	g.total += time.Since(g.pausedAt)
+	return true
+}
+
+// Paused reports whether the run is held by Pause
+func (o *Orchestrator) Paused() bool {
+	o.pause.mu.Lock()
+	defer o.pause.mu.Unlock()
+	return o.pause.resumed != nil
+}
+
+// PausedDuration is the total time spent paused; step and run timeouts exclude it
+func (o *Orchestrator) PausedDuration() time.Duration {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	total := g.total
+	if g.resumed != nil {
+		total += time.Since(g.pausedAt)
+	}
+	return total
+}
+
+// waitIfPaused blocks while paused. It returns the history note for the planner
+// (a human may have used the browser meanwhile), or "" if the run was not paused.
+func (o *Orchestrator) waitIfPaused(ctx context.Context, step int) (string, error) {
+	o.pause.mu.Lock()
+	resumed, since := o.pause.resumed, o.pause.pausedAt
+	o.pause.mu.Unlock()
+	if resumed == nil {
+		return "", nil
+	}
+	o.logger.Info().Int("step", step).Msg("run paused")
+	o.printf("⏸  Paused before step %d\n", step)
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	paused := time.Since(since).Round(time.Second)
+	o.logger.Info().Int("step", step).Dur("paused", paused).Msg("run resumed")
+	o.printf("▶️  Resumed\n")
+	return fmt.Sprintf("run was paused for %s for manual intervention - the page may have been changed by a human, re-check the current state before continuing", paused), nil
+}
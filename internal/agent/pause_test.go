@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// pauseAfter pauses the run once the action of step is done, like a user pressing p
+type pauseAfter struct {
+	step   int
+	orch   *Orchestrator
+	paused chan struct{}
+}
+
+func (p *pauseAfter) OnStep(StepEvent) {}
+func (p *pauseAfter) OnAction(ev ActionEvent) {
+	if ev.Step == p.step && p.orch.Pause() {
+		close(p.paused)
+	}
+}
+func (p *pauseAfter) OnFinish(FinishEvent) {}
+
+// pausableRun starts a scripted run that pauses after step 1 and waits for the pause
+func pausableRun(t *testing.T, ctx context.Context, responses ...string) (*Orchestrator, *llm.ScriptedClient, *browser.FakeController, chan runOutcome) {
+	t.Helper()
+	client := llm.NewScriptedClient(responses)
+	ctrl := browser.NewFakeController(loginPage)
+	obs := &pauseAfter{step: 1, paused: make(chan struct{})}
+	orch := NewOrchestrator(Config{MaxSteps: 5, Quiet: true, Observer: obs}, NewPlanner(client), tools.New(ctrl, nil), zerolog.Nop())
+	obs.orch = orch
+	done := make(chan runOutcome, 1)
+	go func() {
+		result, err := orch.Run(ctx, Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+			return loginSummary, nil
+		})
+		done <- runOutcome{result, err}
+	}()
+	select {
+	case <-obs.paused:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run never reached the pause")
+	}
+	return orch, client, ctrl, done
+}
+
+type runOutcome struct {
+	result RunResult
+	err    error
+}
+
+func TestPauseHoldsTheRun(t *testing.T) {
+	orch, client, ctrl, done := pausableRun(t, context.Background(),
+		decision("navigate", map[string]any{"url": "https://example.com/a"}),
+		decision("navigate", map[string]any{"url": "https://example.com/b"}),
+		finishDecision("signed in", true))
+
+	time.Sleep(150 * time.Millisecond)
+	if !orch.Paused() {
+		t.Fatal("run is not paused")
+	}
+	if n := len(client.Requests()); n != 1 {
+		t.Fatalf("%d planner calls while paused after step 1, want 1", n)
+	}
+	if n := len(ctrl.CallsTo("Navigate")); n != 1 {
+		t.Fatalf("%d navigations while paused after step 1, want 1", n)
+	}
+	if orch.Pause() {
+		t.Fatal("second Pause reported a change")
+	}
+	if !orch.Resume() || orch.Resume() {
+		t.Fatal("Resume must succeed once")
+	}
+
+	out := <-done
+	if out.err != nil || !out.result.Success {
+		t.Fatalf("resumed run = %+v, %v", out.result, out.err)
+	}
+	if n := len(ctrl.CallsTo("Navigate")); n != 2 {
+		t.Fatalf("%d navigations after resume, want 2", n)
+	}
+	var note bool
+	for _, h := range out.result.History {
+		note = note || strings.Contains(h.Result, "manual intervention")
+	}
+	if !note {
+		t.Fatalf("history = %+v, want the manual intervention note", out.result.History)
+	}
+	if d := orch.PausedDuration(); d < 150*time.Millisecond {
+		t.Fatalf("paused duration = %s, want at least the 150ms hold", d)
+	}
+}
+
+func TestCancelWhilePaused(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, client, _, done := pausableRun(t, ctx,
+		decision("navigate", map[string]any{"url": "https://example.com/a"}),
+		finishDecision("never planned", true))
+	cancel()
+	out := <-done
+	if !errors.Is(out.err, context.Canceled) {
+		t.Fatalf("err = %v, want cancellation", out.err)
+	}
+	if out.result.FailureReason == nil || out.result.FailureReason.Kind != FailureUserCancel {
+		t.Fatalf("failure = %+v", out.result.FailureReason)
+	}
+	if n := len(client.Requests()); n != 1 {
+		t.Fatalf("%d planner calls, want none after the pause", n)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
@@ -57,6 +59,14 @@ You must ALWAYS respond with valid JSON:
 <browser_rules>
 - Only interact with elements that have an index in the elements list provided
 - Use click_by_index with index from elements list when available
+- To return to a list or search results after opening the wrong item (or after reading one), use go_back instead of navigating to the list again - it keeps filters, pagination and scroll position; go_forward undoes a go_back. At most 3 back/forward steps in a row are allowed
+- Controls that appear only on mouse over (menu items under a top-level nav entry, delete/archive icons on a mail or table row) are missing from the elements list until revealed: use hover on the menu entry or the row first, then click the revealed element by index
+- Icon-only buttons (trash can, star, reply) are listed by their tooltip or accessible name, marked (title), (label), (alt) or (description): the text is not on screen, but it is how to tell such buttons apart. Don't use click_text with it - click them by index
+- Element indices stay the same between steps on the same page; elements marked *new* appeared since the previous step (e.g. a dropdown or a validation message your action revealed)
+- Elements with the same text are numbered within their group with the text they belong to, e.g. [37]link:"Подробнее" (3/20, under 'Ноутбук ASUS ...') - pick the one whose context matches your goal, not simply the first
+- Long pages list only part of their elements: if the element you need is not listed, use list_elements with a text filter (text_contains, optionally role) to get its index instead of scrolling
+- FORM lines group the fields of one form with the button that submits it: fill the fields of the form you need and click ITS submit button, not a similar button of another form
+- Elements marked (iframe) live inside an embedded frame: use click_by_index / fill_by_index / read_by_index for them - selectors and text clicks run against the main page and will not find them
 - CRITICAL: Always use elements from the CURRENT <browser_state> snapshot, NOT from history. If an element is not in the current snapshot, it doesn't exist anymore - the page has changed. Check the current snapshot before every action.
 - CRITICAL: The browser state is automatically updated after each action. You will receive the new page state in the next step. If the page changes after an action, the sequence continues and you get the new state automatically - you do NOT need to use wait or wait_for actions to wait for page changes.
 - CRITICAL: After clicking a button or submitting a form, DO NOT use wait action to check if the page changed. The page state is automatically updated in the next step - just proceed to the next action or check the new snapshot that will be provided.
@@ -70,8 +80,16 @@ You must ALWAYS respond with valid JSON:
 - CRITICAL: Before using fill_by_index or fill, you MUST have the data. If you see a textbox field (role="textbox" in elements list) and you don't have the value to fill it with, you MUST use request_user_input FIRST to ask the user for the data. DO NOT attempt to fill a field without data - this will cause a timeout. DO NOT use placeholder values like "your_password_here", "enter_password", "your_email", etc. - these are NOT real data and will be rejected. The sequence is: (1) See textbox field -> (2) Use request_user_input("Please provide [login/email/password/etc]") -> (3) After receiving the value, use fill_by_index with that EXACT value (not a placeholder) -> (4) Click submit/next button
 - CRITICAL: Before requesting data from user, ALWAYS check your Memory fields in history. If you already requested and received data (e.g., password, login), DO NOT request it again. Use the data you already received from previous request_user_input actions. Check history to see what data you already have.
 - CRITICAL CAPTCHA RULE: If you detect a captcha page (URL contains "captcha" or "showcaptcha", page title contains "робот" or "robot", or you see text like "Я не робот", "I'm not a robot", "Вы не робот?"), you MUST IMMEDIATELY use request_user_input tool to ask the user to solve it. DO NOT click on any captcha elements (checkbox, button, link, or any element on captcha page). DO NOT attempt to solve it automatically. DO NOT use click_role, click_selector, click_by_index, or any click action on captcha pages. The ONLY action allowed on captcha pages is request_user_input. After the user responds with "done" (or "готово", "yes"), check if the page changed (URL changed or new elements appeared) - if yes, continue with your task. The user's response "done" is a confirmation that captcha is solved, NOT data to use in fill tool
+- For search boxes (fill a query, press Enter, wait for results) use fill_and_submit with the field index and the query - one step instead of fill + press_key. Its result tells whether the page navigated or the results updated in place
+- For date fields and calendar pickers use set_date with the field index and an ISO date (YYYY-MM-DD) instead of clicking calendar cells one by one
+- For dropdowns (role="combobox", native selects, react-select style pickers, multi-selects) use choose_combobox_option with the combobox index and the option text instead of clicking it open and hunting for the option; for a multi-select call it once per value. If the result says the combobox reads something else, check the snapshot before moving on
+- To download a file (PDF, export, invoice) click its link or button, then call wait_for_download: it returns where the file was saved. Downloads that finish on their own show up in history as "downloaded ... to <path>" - mention the saved paths in your finish message
+- To attach a file (resume, document, photo) use upload_file with the index of the file input or upload button and the file name; never click "Upload"/"Choose file" buttons - the system file dialog can't be used. Only files from the upload directory are available: if the file is not there, ask the user with request_user_input
 - To find an input field: check snapshot for role="textbox" elements, or use collect_texts with selector "input[type='text'], input[type='email'], input[type='password'], textarea, [role='textbox']" to get the field selector
 - To read content from a page, use read_page tool with appropriate selector
+- For tabular data (price lists, schedules, results tables, data grids) use extract_table instead of read_page: it keeps columns apart so you can compare rows
+- Links and popups that open a new tab or window switch you to it automatically ("popup opened" in the action result); when the popup closes itself (OAuth login, payment) you are back on the page that opened it ("popup closed"). The TABS line lists open tabs when there are several: use switch_tab to go back to an earlier tab and close_tab for tabs you are done with
+- To summarize an article or collect links from a page, use page_to_markdown: unlike read_page it keeps headings, lists and link URLs
 - To find interactive elements not visible in snapshot, use collect_texts tool
 - CRITICAL: If clicking on a link leads to unexpected results (like opening search instead of detail view), use collect_texts to find parent container elements that contain that link, then click on the parent element instead
 - If snapshot shows only links but you need to interact with list items, use collect_texts to explore the page structure and find the actual interactive elements
@@ -134,6 +152,25 @@ type State struct {
 	History []HistoryItem
 	Summary snapshot.Summary
 	Tools   []tools.Tool
+	// Screenshot of the viewport attached to the request (vision mode), nil otherwise
+	Screenshot *llm.Image
+	// Progress summarizes steps older than History (Config.SummarizeHistory), "" otherwise
+	Progress string
+	// SelectorHint corrects a planner that keeps inventing selectors, "" otherwise
+	SelectorHint string
+	// Deliverables is the checklist of requested outputs (Config.Deliverables), "" otherwise
+	Deliverables string
+	// Changes is the rendered snapshot.Diff against the previous step's snapshot, "" on the first step
+	Changes string
+	// Diff is that diff, nil on the first step. On the same page the message lists only the
+	// text (non-interactive) elements it reports as added or changed, not the unchanged ones
+	Diff *snapshot.Diff
+	// Now is the current time (Config.Now): rendered in the browser timezone of Summary.Clock,
+	// it resolves date variables of the task; zero leaves them as written. Its location is the
+	// agent machine's zone
+	Now time.Time
+	// Hydration notes the wait for an empty first render of the page, "" otherwise
+	Hydration string
 }
 
 type HistoryItem struct {
@@ -144,25 +181,83 @@ type HistoryItem struct {
 	EvaluationPreviousGoal string `json:"evaluation_previous_goal,omitempty"` // Analysis of last action
 	Memory                 string `json:"memory,omitempty"`                   // Progress tracking
 	NextGoal               string `json:"next_goal,omitempty"`                // Next immediate goal
+	// Source is the planner or sub-agent whose decision produced the item (empty for orchestrator notes)
+	Source string `json:"source,omitempty"`
 }
 
 type Decision struct {
 	ActionName             string
 	ActionInput            map[string]any
 	Finish                 bool
+	Success                bool // Finish only: input.success, the planner's own verdict (true when omitted)
 	Message                string
 	Thinking               string // Reasoning about current state
 	EvaluationPreviousGoal string // Analysis of last action
 	Memory                 string // Progress tracking
 	NextGoal               string // Next immediate goal
+	// Usage is the tokens spent producing the decision (set even when parsing fails)
+	Usage llm.Usage
+	// Source is the planner or sub-agent Name() that made the decision (set by the orchestrator)
+	Source string
+	// RawJSON is the model output the decision was parsed from (text plus the native tool
+	// call), kept for run logs only - never put back into prompts
+	RawJSON string
 }
 
 type fastPlanner struct {
-	llm llm.Client
+	llm  llm.Client
+	opts PlannerOptions
 }
 
+// PlannerOptions tunes how the planner talks to the LLM
+type PlannerOptions struct {
+	// ForceJSONSchema requests structured outputs constrained to the Decision schema (OpenAI)
+	ForceJSONSchema bool
+	// Temperature for planner requests (0 = deterministic)
+	Temperature float32
+	// MaxTokens caps the decision length, 0 = defaultPlannerMaxTokens (at most the model's output limit)
+	MaxTokens int
+	// MaxPromptTokens caps the estimated prompt size, 0 = derived from the client's ModelInfo
+	MaxPromptTokens int
+}
+
+// defaultPlannerMaxTokens leaves room for detailed reasoning (thinking/evaluation/memory)
+const defaultPlannerMaxTokens = 2000
+
 func NewPlanner(client llm.Client) Planner {
-	return &fastPlanner{llm: client}
+	return NewPlannerWithOptions(client, PlannerOptions{})
+}
+
+// NewPlannerWithOptions creates the unified planner with custom options
+func NewPlannerWithOptions(client llm.Client, opts PlannerOptions) Planner {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = defaultPlannerMaxTokens
+	}
+	info := client.ModelInfo()
+	if info.MaxOutputTokens > 0 && opts.MaxTokens > info.MaxOutputTokens {
+		opts.MaxTokens = info.MaxOutputTokens
+	}
+	if opts.MaxPromptTokens <= 0 {
+		opts.MaxPromptTokens = promptBudget(info, opts.MaxTokens)
+	}
+	return &fastPlanner{llm: client, opts: opts}
+}
+
+// decisionSchemaName and decisionSchema describe the planner output (see parseDecision)
+const decisionSchemaName = "decision"
+
+var decisionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"thinking":                 map[string]any{"type": "string"},
+		"evaluation_previous_goal": map[string]any{"type": "string"},
+		"memory":                   map[string]any{"type": "string"},
+		"next_goal":                map[string]any{"type": "string"},
+		"action":                   map[string]any{"type": "string", "description": "tool name or finish"},
+		"input":                    map[string]any{"type": "object", "description": "tool input; for finish: {\"message\": \"...\", \"success\": true|false}"},
+	},
+	"required":             []string{"thinking", "evaluation_previous_goal", "memory", "next_goal", "action", "input"},
+	"additionalProperties": false,
 }
 
 func (p *fastPlanner) Next(ctx context.Context, state State) (Decision, error) {
@@ -171,6 +266,19 @@ func (p *fastPlanner) Next(ctx context.Context, state State) (Decision, error) {
 
 	// Minimal guidance - just page info, let agent figure out the rest
 	guidance := fmt.Sprintf("URL: %s | Title: %s | Elements: %d\n", state.Summary.URL, state.Summary.Title, len(state.Summary.Elements))
+	if vp := state.Summary.Viewport; vp != nil {
+		guidance += vp.Note() + "\n"
+	}
+	if state.Summary.Partial {
+		guidance += snapshot.PartialNote + "\n"
+	}
+	if state.Hydration != "" {
+		guidance += state.Hydration + "\n"
+	}
+	guidance += tabsGuidance(state.Summary.Tabs)
+	if state.Changes != "" {
+		guidance += "CHANGES SINCE YOUR LAST ACTION:\n" + state.Changes + "\n"
+	}
 
 	if len(state.Summary.Elements) > 0 {
 		// Interactive roles that should be shown (like browser-use-reference shows all interactive elements)
@@ -183,22 +291,48 @@ func (p *fastPlanner) Next(ctx context.Context, state State) (Decision, error) {
 
 		// Show all interactive elements first (like browser-use-reference)
 		// They show ALL interactive elements, not just first 15
+		duplicates := duplicateContexts(state.Summary.Elements)
 		hasTextbox := false
 		hasLoginButton := false
-		for i := range state.Summary.Elements {
-			el := &state.Summary.Elements[i]
+		renderActionable := func(el *snapshot.Element) {
 			roleLower := strings.ToLower(el.Role)
-			if actionableRoles[roleLower] {
-				guidance += fmt.Sprintf("[%d]%s:%q\n", el.Index, el.Role, truncateText(el.Text, 50))
-				if roleLower == "textbox" {
-					hasTextbox = true
-				}
-				// Check for login button/link (universal pattern)
-				textLower := strings.ToLower(el.Text)
-				if (roleLower == "button" || roleLower == "link") && (strings.Contains(textLower, "войти") || strings.Contains(textLower, "login") || strings.Contains(textLower, "sign in") || strings.Contains(textLower, "log in")) {
-					hasLoginButton = true
+			guidance += fmt.Sprintf("[%d]%s:%q%s%s%s\n", el.Index, el.Role, truncateText(el.Text, 50), elementState(el), duplicates[el.Index], newMark(el))
+			if roleLower == "textbox" {
+				hasTextbox = true
+			}
+			// Check for login button/link (universal pattern)
+			textLower := strings.ToLower(el.Text)
+			if (roleLower == "button" || roleLower == "link") && (strings.Contains(textLower, "войти") || strings.Contains(textLower, "login") || strings.Contains(textLower, "sign in") || strings.Contains(textLower, "log in")) {
+				hasLoginButton = true
+			}
+		}
+
+		// Open modal dialog: its elements go first, background elements are demoted
+		hasDialog := false
+		for i := range state.Summary.Elements {
+			if state.Summary.Elements[i].InDialog {
+				hasDialog = true
+				break
+			}
+		}
+		if hasDialog {
+			guidance += "DIALOG IS OPEN - act inside the dialog first (close it to reach the page behind):\n"
+			for i := range state.Summary.Elements {
+				el := &state.Summary.Elements[i]
+				if el.InDialog && actionableRoles[strings.ToLower(el.Role)] {
+					renderActionable(el)
 				}
 			}
+			guidance += "BACKGROUND (behind the dialog - clicks will likely be intercepted):\n"
+		}
+		for i := range state.Summary.Elements {
+			el := &state.Summary.Elements[i]
+			if hasDialog && el.InDialog {
+				continue
+			}
+			if actionableRoles[strings.ToLower(el.Role)] {
+				renderActionable(el)
+			}
 		}
 
 		// Universal rule: if you see a login button/link but no login form (textbox), click the button first
@@ -212,9 +346,14 @@ func (p *fastPlanner) Next(ctx context.Context, state State) (Decision, error) {
 		} else if hasTextbox && (strings.Contains(state.Summary.URL, "auth") || strings.Contains(state.Summary.URL, "login") || strings.Contains(strings.ToLower(state.Summary.Title), "authorization") || strings.Contains(strings.ToLower(state.Summary.Title), "log in")) {
 			guidance += "\nCRITICAL: You see textbox fields on a login/authorization page. If you don't have the login/email/password data, you MUST use request_user_input FIRST to ask the user for it, then use fill_by_index with the received value.\n"
 		}
-
-		// Then show non-interactive elements (up to 50 more to keep context manageable)
-		nonInteractiveCount := 0
+		guidance += formsGuidance(state.Summary.Forms)
+		guidance += languageGuidance(state.Task, state.Summary)
+		guidance += state.SelectorHint
+
+		// Then show non-interactive elements (up to 50 more to keep context manageable);
+		// on the same page only those the diff reports, the rest the planner saw last step
+		changed := state.Diff.ChangedIndices()
+		nonInteractiveCount, unchanged := 0, 0
 		maxNonInteractive := 50
 		for i := range state.Summary.Elements {
 			if nonInteractiveCount >= maxNonInteractive {
@@ -222,23 +361,43 @@ func (p *fastPlanner) Next(ctx context.Context, state State) (Decision, error) {
 			}
 			el := &state.Summary.Elements[i]
 			roleLower := strings.ToLower(el.Role)
-			if !actionableRoles[roleLower] {
-				guidance += fmt.Sprintf("[%d]%s:%q\n", el.Index, el.Role, truncateText(el.Text, 50))
-				nonInteractiveCount++
+			if actionableRoles[roleLower] {
+				continue
+			}
+			if changed != nil && !changed[el.Index] {
+				unchanged++
+				continue
 			}
+			guidance += fmt.Sprintf("[%d]%s:%q%s%s%s\n", el.Index, el.Role, truncateText(el.Text, 50), elementState(el), duplicates[el.Index], newMark(el))
+			nonInteractiveCount++
+		}
+		if unchanged > 0 {
+			guidance += fmt.Sprintf("(%d text elements unchanged since your last step are not repeated - read_page or read_by_index reads them again)\n", unchanged)
 		}
 	}
 
-	// Format history like browser-use-reference: <step_N>:\nEvaluation: ...\nMemory: ...\nNext Goal: ...\nAction Results: ...
-	historyFormatted := formatHistory(state.History)
-
 	// Format message like browser-use-reference: highlight user_request prominently (like browser-use-reference does)
-	msg := fmt.Sprintf(`<user_request>
+	progress := ""
+	if state.Progress != "" {
+		progress = "\nProgress of earlier steps:\n" + state.Progress
+	}
+	if state.Deliverables != "" {
+		progress += "\nDeliverables (the finish message must give each one; note found ones in memory):\n" + state.Deliverables
+	}
+	task := state.Task
+	if !state.Now.IsZero() {
+		task = expandDateVars(task, state.Now.In(browserLocation(state.Summary.Clock, state.Now.Location())))
+		if line := timeContext(state.Now, state.Summary.Clock); line != "" {
+			progress = "\n" + line + progress
+		}
+	}
+	render := func(guidance, historyFormatted string) string {
+		return fmt.Sprintf(`<user_request>
 %s
 </user_request>
 
 <agent_state>
-Step: %d
+Step: %d%s
 </agent_state>
 
 <browser_state>
@@ -262,46 +421,201 @@ OUTPUT FORMAT (strict JSON only, no text outside):
   "input": {}
 }
 
-If you need to finish the task, set "action": "finish" and provide "input": {"message": "Your detailed summary here"}.
+If you need to finish the task, set "action": "finish" and provide "input": {"message": "Your detailed summary here", "success": true}; set "success": false when the task is not fully done.
 The "message" field is REQUIRED when action is "finish" - describe what was accomplished, what steps were taken, and any important results.
 
 IMPORTANT: Use ONE action per step. Do NOT use multi_tool_use.parallel. Execute actions sequentially: first fill the field, then click the button in the next step.`,
-		state.Task,
-		state.Step,
-		state.Summary.URL,
-		state.Summary.Title,
-		len(state.Summary.Elements),
-		guidance,
-		historyFormatted)
+			task,
+			state.Step,
+			progress,
+			state.Summary.URL,
+			state.Summary.Title,
+			len(state.Summary.Elements),
+			guidance,
+			historyFormatted)
+	}
+	// History is formatted like browser-use-reference: <step_N>:\nEvaluation: ...\nMemory: ...\nNext Goal: ...\nAction Results: ...
+	// and trimmed together with the element list to the model's context window
+	guidance, historyFormatted := p.fitPrompt(systemPrompt, guidance, state.History, render)
+	msg := render(guidance, historyFormatted)
+	userMsg := llm.Message{Role: "user", Content: msg}
+	if state.Screenshot != nil {
+		userMsg.Images = []llm.Image{*state.Screenshot}
+		userMsg.Content += "\n\nA screenshot of the current viewport is attached. Use it for visual context (canvas widgets, images with text, layout), but element indices always refer to the numbered elements list above - never guess an index from the picture."
+	}
 	resp, err := p.llm.Generate(ctx, llm.Request{
 		System:      systemPrompt,
-		Messages:    []llm.Message{{Role: "user", Content: msg}},
+		Messages:    []llm.Message{userMsg},
 		Tools:       toLLMTools(state.Tools),
-		Temperature: 0.0,
-		MaxTokens:   2000, // Increased for detailed reasoning (thinking/evaluation/memory)
+		Temperature: p.opts.Temperature,
+		MaxTokens:   p.opts.MaxTokens,
+
+		ForceJSONSchema: p.opts.ForceJSONSchema,
+		SchemaName:      decisionSchemaName,
+		Schema:          decisionSchema,
 	})
 	if err != nil {
 		return Decision{}, err
 	}
-	dec, err := parseDecision(resp.Text)
+	return DecisionFromResponse(resp, p.opts.ForceJSONSchema)
+}
+
+// DecisionFromResponse turns a model answer into a decision, for the planner and sub-agents
+// alike. Truncated answers were already retried with a larger limit by the client
+// (llm.Response.StopReason); a native tool call (stop reason "tool_use") is used directly,
+// text JSON is the fallback for models without tool_use. structured is set when the
+// request forced a JSON schema. Usage is filled on errors too.
+func DecisionFromResponse(resp llm.Response, structured bool) (Decision, error) {
+	var (
+		dec Decision
+		err error
+	)
+	switch {
+	case resp.ToolCall != nil:
+		dec, err = decisionFromToolCall(*resp.ToolCall, resp.Text)
+	case structured:
+		dec, err = parseStructuredDecision(resp.Text)
+	default:
+		dec, err = parseDecision(resp.Text)
+	}
 	if err != nil {
-		return Decision{}, fmt.Errorf("%w: raw=%q", err, resp.Text)
+		if resp.Truncated() {
+			// Still cut after the client's retry with a larger limit - the output cap is too low for this prompt
+			return Decision{Usage: resp.Usage}, fmt.Errorf("%w (output truncated at the token limit, stop reason %s - raise MaxTokens): raw=%q", err, resp.StopReason, resp.Text)
+		}
+		return Decision{Usage: resp.Usage}, fmt.Errorf("%w: raw=%q", err, resp.Text)
 	}
+	dec.Usage = resp.Usage
+	dec.RawJSON = rawOutput(resp)
 	return dec, nil
 }
 
+// rawOutput is the model output as received: text, then the native tool call as JSON
+func rawOutput(resp llm.Response) string {
+	if resp.ToolCall == nil {
+		return resp.Text
+	}
+	call, err := json.Marshal(map[string]any{"tool_call": map[string]any{"name": resp.ToolCall.Name, "input": resp.ToolCall.Input}})
+	if err != nil {
+		return resp.Text
+	}
+	return strings.TrimSpace(resp.Text + "\n" + string(call))
+}
+
+// maxRenderedTabs bounds the TABS line of the planner guidance
+const maxRenderedTabs = 8
+
+// tabsGuidance lists open tabs when there are several, e.g.
+// TABS (2 open, * = active): [0] 'Search results' | [1]* 'Product page'
+func tabsGuidance(tabs []browser.Tab) string {
+	if len(tabs) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(tabs))
+	for i, t := range tabs {
+		if i == maxRenderedTabs {
+			parts = append(parts, fmt.Sprintf("... %d more", len(tabs)-i))
+			break
+		}
+		mark := ""
+		if t.Active {
+			mark = "*"
+		}
+		title := t.Title
+		if title == "" {
+			title = t.URL
+		}
+		parts = append(parts, fmt.Sprintf("[%d]%s '%s'", t.Index, mark, truncateText(title, 40)))
+	}
+	return fmt.Sprintf("TABS (%d open, * = active): %s\n", len(tabs), strings.Join(parts, " | "))
+}
+
+// newMark flags elements that appeared since the previous snapshot (stable indices, see snapshot.Collector)
+func newMark(el *snapshot.Element) string {
+	if el.New {
+		return " *new*"
+	}
+	return ""
+}
+
+// elementState renders the current value and ARIA states of an element compactly
+// (` value="user@x.com" checked focused`), so filled fields and toggles are not redone.
+// Elements of child frames are marked "(iframe)": only index tools reach into their document.
+func elementState(el *snapshot.Element) string {
+	var b strings.Builder
+	if el.TextSource != "" {
+		fmt.Fprintf(&b, " (%s)", el.TextSource) // Not visible text: match the icon, not a caption
+	}
+	if el.FrameURL != "" {
+		b.WriteString(" (iframe)")
+	}
+	if el.Value != "" {
+		fmt.Fprintf(&b, " value=%q", truncateText(el.Value, 40))
+	}
+	switch strings.ToLower(el.Role) {
+	case "checkbox", "radio", "switch", "menuitemcheckbox", "menuitemradio":
+		if el.Checked {
+			b.WriteString(" checked")
+		} else {
+			b.WriteString(" unchecked")
+		}
+	default:
+		if el.Checked {
+			b.WriteString(" pressed")
+		}
+	}
+	if el.Expanded {
+		b.WriteString(" expanded")
+	}
+	if el.Focused {
+		b.WriteString(" focused")
+	}
+	if el.Disabled {
+		b.WriteString(" disabled")
+	}
+	return b.String()
+}
+
+// decisionFromToolCall builds a decision from a structured tool call.
+// Reasoning fields (thinking, memory, ...) are still taken from the text JSON when the model wrote one.
+func decisionFromToolCall(call llm.ToolCall, text string) (Decision, error) {
+	var reasoning reasoningFields
+	if jsonStr, err := extractJSON(text); err == nil {
+		_ = json.Unmarshal([]byte(jsonStr), &reasoning)
+	}
+	input := call.Input
+	if input == nil {
+		input = make(map[string]any)
+	}
+	return buildDecision(call.Name, input, reasoning)
+}
+
+// parseStructuredDecision parses output produced under a JSON schema: the whole text is the JSON object,
+// so no extraction or comment stripping is needed. Falls back to parseDecision if the provider ignored the schema.
+func parseStructuredDecision(text string) (Decision, error) {
+	var parsed struct {
+		reasoningFields
+		Action string         `json:"action"`
+		Input  map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &parsed); err != nil {
+		return parseDecision(text)
+	}
+	if parsed.Input == nil {
+		parsed.Input = make(map[string]any)
+	}
+	return buildDecision(parsed.Action, parsed.Input, parsed.reasoningFields)
+}
+
 func parseDecision(text string) (Decision, error) {
 	jsonStr, err := extractJSON(text)
 	if err != nil {
 		return Decision{}, err
 	}
 	var parsed struct {
-		Thinking               string      `json:"thinking"`
-		EvaluationPreviousGoal string      `json:"evaluation_previous_goal"`
-		Memory                 string      `json:"memory"`
-		NextGoal               string      `json:"next_goal"`
-		Action                 string      `json:"action"`
-		Input                  interface{} `json:"input"` // Can be map or array for multi_tool_use.parallel
+		reasoningFields
+		Action string      `json:"action"`
+		Input  interface{} `json:"input"` // Can be map or array for multi_tool_use.parallel
 	}
 	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
 		return Decision{}, fmt.Errorf("llm json parse: %w", err)
@@ -340,8 +654,21 @@ func parseDecision(text string) (Decision, error) {
 		}
 	}
 
+	return buildDecision(parsed.Action, actionInput, parsed.reasoningFields)
+}
+
+// reasoningFields are the optional reasoning parts of the planner JSON output
+type reasoningFields struct {
+	Thinking               string `json:"thinking"`
+	EvaluationPreviousGoal string `json:"evaluation_previous_goal"`
+	Memory                 string `json:"memory"`
+	NextGoal               string `json:"next_goal"`
+}
+
+// buildDecision normalizes action name and validates finish, shared by text and tool_use parsing
+func buildDecision(action string, actionInput map[string]any, reasoning reasoningFields) (Decision, error) {
 	// Remove "functions." prefix if present (OpenAI sometimes adds this prefix)
-	actionName := strings.TrimSpace(parsed.Action)
+	actionName := strings.TrimSpace(action)
 	if strings.HasPrefix(actionName, "functions.") {
 		actionName = strings.TrimPrefix(actionName, "functions.")
 	}
@@ -349,14 +676,15 @@ func parseDecision(text string) (Decision, error) {
 	dec := Decision{
 		ActionName:             actionName,
 		ActionInput:            actionInput,
-		Thinking:               strings.TrimSpace(parsed.Thinking),
-		EvaluationPreviousGoal: strings.TrimSpace(parsed.EvaluationPreviousGoal),
-		Memory:                 strings.TrimSpace(parsed.Memory),
-		NextGoal:               strings.TrimSpace(parsed.NextGoal),
+		Thinking:               strings.TrimSpace(reasoning.Thinking),
+		EvaluationPreviousGoal: strings.TrimSpace(reasoning.EvaluationPreviousGoal),
+		Memory:                 strings.TrimSpace(reasoning.Memory),
+		NextGoal:               strings.TrimSpace(reasoning.NextGoal),
 	}
 
 	if dec.ActionName == "finish" {
 		dec.Finish = true
+		dec.Success = finishSuccess(actionInput["success"])
 		if msg, ok := actionInput["message"].(string); ok && strings.TrimSpace(msg) != "" {
 			dec.Message = strings.TrimSpace(msg)
 		} else if m, ok := actionInput["result"].(string); ok && strings.TrimSpace(m) != "" {
@@ -372,6 +700,18 @@ func parseDecision(text string) (Decision, error) {
 	return dec, nil
 }
 
+// finishSuccess reads finish input.success; models also send it as a string, and a missing
+// value counts as success so finishes written before the field keep their meaning
+func finishSuccess(v any) bool {
+	switch s := v.(type) {
+	case bool:
+		return s
+	case string:
+		return !strings.EqualFold(strings.TrimSpace(s), "false")
+	}
+	return true
+}
+
 func extractJSON(text string) (string, error) {
 	depth := 0
 	start := -1
@@ -479,11 +819,24 @@ func toLLMTools(ts []tools.Tool) []llm.Tool {
 	return res
 }
 
+// truncateText cuts s to maxLen runes, adding "..." only when something was cut
 func truncateText(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+	if cut := cutRunes(s, maxLen); len(cut) < len(s) {
+		return cut + "..."
 	}
-	return s[:maxLen] + "..."
+	return s
+}
+
+// cutRunes returns at most n runes of s without splitting a multi-byte character
+func cutRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
 }
 
 // formatHistory formats history items like browser-use-reference:
@@ -492,14 +845,15 @@ func truncateText(s string, maxLen int) string {
 // Memory: ...
 // Next Goal: ...
 // Action Results: ...
-func formatHistory(history []HistoryItem) string {
+// first is the number of earlier steps left out, so step numbers stay stable.
+func formatHistory(history []HistoryItem, first int) string {
 	if len(history) == 0 {
 		return ""
 	}
 
 	var parts []string
 	for i, item := range history {
-		stepNum := i + 1
+		stepNum := first + i + 1
 		var content []string
 
 		if item.EvaluationPreviousGoal != "" {
@@ -512,6 +866,11 @@ func formatHistory(history []HistoryItem) string {
 			content = append(content, "Next Goal: "+item.NextGoal)
 		}
 
+		// Steps decided by a specialist sub-agent are marked so the model knows who acted
+		if item.Source != "" && item.Source != defaultPlannerName {
+			content = append(content, "Decided by: "+item.Source)
+		}
+
 		actionResult := fmt.Sprintf("Action Results: %s -> %s", item.Action, item.Result)
 		if item.Selector != "" {
 			actionResult += fmt.Sprintf(" (selector: %s)", item.Selector)
@@ -0,0 +1,294 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestBuildDecisionFinishSuccess(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  bool
+	}{
+		{"explicit true", map[string]any{"message": "done", "success": true}, true},
+		{"explicit false", map[string]any{"message": "gave up", "success": false}, false},
+		{"string false", map[string]any{"message": "gave up", "success": "False"}, false},
+		{"string true", map[string]any{"message": "done", "success": "true"}, true},
+		{"omitted", map[string]any{"message": "done"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := buildDecision("finish", tt.input, reasoningFields{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !dec.Finish || dec.Success != tt.want {
+				t.Errorf("Finish=%v Success=%v, want finish with success %v", dec.Finish, dec.Success, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDecisionFinishNeedsMessage(t *testing.T) {
+	if _, err := buildDecision("finish", map[string]any{"success": true}, reasoningFields{}); err == nil {
+		t.Fatal("finish without a message was accepted")
+	}
+}
+
+func TestPlannerListsOnlyChangedTextOnTheSamePage(t *testing.T) {
+	page := "https://shop.example.com/cart"
+	prev := snapshot.Summary{URL: page, Elements: []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Checkout", Sel: "#checkout"},
+		{Index: 2, Role: "heading", Text: "Your cart", Sel: "h1"},
+		{Index: 3, Role: "text", Text: "Total: 10 €", Sel: "#total"},
+	}}
+	cur := snapshot.Summary{URL: page, Elements: []snapshot.Element{
+		prev.Elements[0], prev.Elements[1],
+		{Index: 3, Role: "text", Text: "Total: 25 €", Sel: "#total"},
+		{Index: 4, Role: "text", Text: "Coupon applied", Sel: "#coupon"},
+	}}
+	diff := snapshot.Compare(prev, cur)
+
+	tests := []struct {
+		name    string
+		state   State
+		listed  []string
+		omitted []string
+	}{
+		{
+			name:   "first step lists everything",
+			state:  State{Summary: cur},
+			listed: []string{`[1]button:"Checkout"`, `[2]heading:"Your cart"`, `[3]text:"Total: 25 €"`, `[4]text:"Coupon applied"`},
+		},
+		{
+			name:    "same page lists actions and changed text only",
+			state:   State{Summary: cur, Diff: &diff, Changes: diff.String()},
+			listed:  []string{`[1]button:"Checkout"`, `[3]text:"Total: 25 €"`, `[4]text:"Coupon applied"`, "(1 text elements unchanged since your last step are not repeated", "CHANGES SINCE YOUR LAST ACTION"},
+			omitted: []string{`[2]heading:"Your cart"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+			tt.state.Task = "apply the coupon"
+			if _, err := NewPlanner(client).Next(context.Background(), tt.state); err != nil {
+				t.Fatal(err)
+			}
+			msg := client.Requests()[0].Messages[0].Content
+			for _, want := range tt.listed {
+				if !strings.Contains(msg, want) {
+					t.Errorf("message misses %q", want)
+				}
+			}
+			for _, unwanted := range tt.omitted {
+				if strings.Contains(msg, unwanted) {
+					t.Errorf("message repeats unchanged %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestPlannerOptionsReachTheClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      PlannerOptions
+		wantTemp  float32
+		wantLimit int
+	}{
+		{name: "defaults", wantTemp: 0, wantLimit: defaultPlannerMaxTokens},
+		{name: "configured", opts: PlannerOptions{Temperature: 0.7, MaxTokens: 500}, wantTemp: 0.7, wantLimit: 500},
+		{name: "capped by the model", opts: PlannerOptions{Temperature: 2, MaxTokens: 100000}, wantTemp: 2, wantLimit: 8192},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+			state := State{Task: "say hi", Summary: snapshot.Summary{URL: "https://example.com"}}
+			if _, err := NewPlannerWithOptions(client, tt.opts).Next(context.Background(), state); err != nil {
+				t.Fatal(err)
+			}
+			req := client.Requests()[0]
+			if req.Temperature != tt.wantTemp || req.MaxTokens != tt.wantLimit {
+				t.Fatalf("request temperature %g, max tokens %d; want %g, %d", req.Temperature, req.MaxTokens, tt.wantTemp, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestDecisionFromResponsePrefersToolUse(t *testing.T) {
+	reasoning := `{"thinking": "needs an account", "evaluation_previous_goal": "Success", "memory": "home page", "next_goal": "open login", "action": "click_text", "input": {"text": "Ignored"}}`
+	tests := []struct {
+		name      string
+		resp      llm.Response
+		wantName  string
+		wantInput string
+		wantGoal  string
+	}{
+		{
+			name:      "tool_use wins over the text action",
+			resp:      llm.Response{Text: "Opening login.\n" + reasoning, ToolCall: &llm.ToolCall{ID: "toolu_1", Name: "click_by_index", Input: map[string]any{"index": float64(12)}}, StopReason: "tool_use"},
+			wantName:  "click_by_index",
+			wantInput: `{"index":12}`,
+			wantGoal:  "open login",
+		},
+		{
+			name:      "tool_use without any text",
+			resp:      llm.Response{ToolCall: &llm.ToolCall{Name: "go_back"}, StopReason: "tool_use"},
+			wantName:  "go_back",
+			wantInput: `{}`,
+		},
+		{
+			name:      "text JSON fallback wrapped in prose",
+			resp:      llm.Response{Text: "Here is my decision:\n```json\n" + reasoning + "\n```", StopReason: "end_turn"},
+			wantName:  "click_text",
+			wantInput: `{"text":"Ignored"}`,
+			wantGoal:  "open login",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := DecisionFromResponse(tt.resp, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			input, _ := json.Marshal(dec.ActionInput)
+			if dec.ActionName != tt.wantName || string(input) != tt.wantInput || dec.NextGoal != tt.wantGoal {
+				t.Fatalf("decision = %s %s goal %q", dec.ActionName, input, dec.NextGoal)
+			}
+			if tt.resp.ToolCall != nil && !strings.Contains(dec.RawJSON, `"tool_call"`) {
+				t.Fatalf("raw output %q misses the tool call", dec.RawJSON)
+			}
+		})
+	}
+}
+
+func TestDecisionFromToolUseFinish(t *testing.T) {
+	dec, err := DecisionFromResponse(llm.Response{ToolCall: &llm.ToolCall{Name: "finish", Input: map[string]any{"message": "done", "success": true}}}, false)
+	if err != nil || !dec.Finish || !dec.Success || dec.Message != "done" {
+		t.Fatalf("decision = %+v, %v", dec, err)
+	}
+}
+
+func TestDecisionWithTrailingProse(t *testing.T) {
+	decision := `{"next_goal": "open cart", "action": "click_by_index", "input": {"index": 7}}`
+	tests := []struct {
+		name       string
+		text       string
+		structured bool
+	}{
+		{name: "trailing prose", text: decision + "\n\nI picked the cart link because the task says {checkout}."},
+		{name: "prose on both sides", text: "Next step:\n" + decision + "\nThat should open the cart."},
+		{name: "comment inside the object", text: `{"action": "click_by_index", // the cart
+"input": {"index": 7}, "next_goal": "open cart"} done.`},
+		{name: "schema mode with strict JSON", text: decision, structured: true},
+		{name: "schema mode falls back on prose", text: decision + "\nHope this helps!", structured: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := DecisionFromResponse(llm.Response{Text: tt.text, StopReason: "stop"}, tt.structured)
+			if err != nil {
+				t.Fatal(err)
+			}
+			input, _ := json.Marshal(dec.ActionInput)
+			if dec.ActionName != "click_by_index" || string(input) != `{"index":7}` || dec.NextGoal != "open cart" {
+				t.Fatalf("decision = %s %s goal %q", dec.ActionName, input, dec.NextGoal)
+			}
+		})
+	}
+}
+
+func TestPlannerRequestsTheDecisionSchema(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+		state := State{Task: "say hi", Summary: snapshot.Summary{URL: "https://example.com"}}
+		if _, err := NewPlannerWithOptions(client, PlannerOptions{ForceJSONSchema: force}).Next(context.Background(), state); err != nil {
+			t.Fatal(err)
+		}
+		req := client.Requests()[0]
+		if req.ForceJSONSchema != force || req.SchemaName != decisionSchemaName || req.Schema == nil {
+			t.Fatalf("ForceJSONSchema=%v: request force %v, schema %q", force, req.ForceJSONSchema, req.SchemaName)
+		}
+	}
+}
+
+func TestDecisionFromResponseByStopReason(t *testing.T) {
+	complete := `{"next_goal": "open cart", "action": "click_by_index", "input": {"index": 7}}`
+	tests := []struct {
+		name       string
+		resp       llm.Response
+		wantAction string
+		wantErr    string // Substring of the error; "" = a decision
+		noTrunc    bool   // The error must not blame the token limit
+	}{
+		{name: "tool_use", resp: llm.Response{ToolCall: &llm.ToolCall{Name: "click_by_index", Input: map[string]any{"index": float64(7)}}, StopReason: "tool_use"}, wantAction: "click_by_index"},
+		{name: "end_turn", resp: llm.Response{Text: complete, StopReason: "end_turn"}, wantAction: "click_by_index"},
+		{name: "stop", resp: llm.Response{Text: complete, StopReason: "stop"}, wantAction: "click_by_index"},
+		{name: "max_tokens after a complete object", resp: llm.Response{Text: complete + "\nReasoning: the cart link is", StopReason: "max_tokens"}, wantAction: "click_by_index"},
+		{name: "max_tokens mid object", resp: llm.Response{Text: `{"thinking": "The catalog lists 48 products`, StopReason: "max_tokens"}, wantErr: "output truncated at the token limit, stop reason max_tokens"},
+		{name: "length mid object", resp: llm.Response{Text: `{"action": "click_by_index", "input": {"ind`, StopReason: "length"}, wantErr: "stop reason length"},
+		{name: "end_turn without JSON", resp: llm.Response{Text: "I cannot continue.", StopReason: "end_turn"}, wantErr: "json not found", noTrunc: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.resp.Usage = llm.Usage{PromptTokens: 10, CompletionTokens: 5}
+			dec, err := DecisionFromResponse(tt.resp, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if tt.noTrunc && strings.Contains(err.Error(), "truncated") {
+					t.Fatalf("err = %v blames the token limit", err)
+				}
+			} else if err != nil || dec.ActionName != tt.wantAction {
+				t.Fatalf("decision = %q, %v; want %q", dec.ActionName, err, tt.wantAction)
+			}
+			if dec.Usage != tt.resp.Usage {
+				t.Fatalf("usage = %+v, want it kept on every outcome", dec.Usage)
+			}
+		})
+	}
+}
+
+func TestPlannerListsDialogElementsFirst(t *testing.T) {
+	// The modal sign-in form of internal/snapshot/testdata/modal_form.html
+	summary := snapshot.Summary{URL: "https://shop.example.com/checkout", Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Home", Sel: "#home"},
+		{Index: 2, Role: "button", Text: "Buy now", Sel: "#buy"},
+		{Index: 3, Role: "textbox", Text: "Email", Sel: "#email", InDialog: true},
+		{Index: 4, Role: "button", Text: "Continue", Sel: "#continue", InDialog: true},
+	}}
+	order := []string{"DIALOG IS OPEN", `[3]textbox:"Email"`, `[4]button:"Continue"`, "BACKGROUND (behind the dialog", `[1]link:"Home"`, `[2]button:"Buy now"`}
+
+	client := llm.NewScriptedClient([]string{finishDecision("done", true)})
+	if _, err := NewPlanner(client).Next(context.Background(), State{Task: "buy the lamp", Summary: summary}); err != nil {
+		t.Fatal(err)
+	}
+	msg := client.Requests()[0].Messages[0].Content
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(msg[pos:], want)
+		if i < 0 {
+			t.Fatalf("message misses %q after position %d:\n%s", want, pos, msg)
+		}
+		pos += i + len(want)
+	}
+
+	// Without a dialog the list keeps page order and has no headers
+	for i := range summary.Elements {
+		summary.Elements[i].InDialog = false
+	}
+	client = llm.NewScriptedClient([]string{finishDecision("done", true)})
+	if _, err := NewPlanner(client).Next(context.Background(), State{Task: "buy the lamp", Summary: summary}); err != nil {
+		t.Fatal(err)
+	}
+	msg = client.Requests()[0].Messages[0].Content
+	if strings.Contains(msg, "DIALOG IS OPEN") || strings.Contains(msg, "BACKGROUND") || strings.Index(msg, `[1]link:"Home"`) > strings.Index(msg, `[3]textbox:"Email"`) {
+		t.Fatalf("message without a dialog:\n%s", msg)
+	}
+}
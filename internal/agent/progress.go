@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// recentHistoryItems are sent to the planner verbatim, older ones only via the progress summary
+	recentHistoryItems = 5
+	// progressMaxURLs caps the "pages visited" line of the progress summary
+	progressMaxURLs = 5
+	// receivedDataPrefix marks request_user_input results that carry data (see the run loop)
+	receivedDataPrefix = "Received data from user: "
+)
+
+// updateProgress refreshes TaskMemory.Progress with a summary of the history items that fell out
+// of the planner's recent window. It only rebuilds when new items dropped out since the last time.
+func (o *Orchestrator) updateProgress(history []HistoryItem) {
+	older := len(history) - recentHistoryItems
+	if older <= 0 || older == o.memory.ProgressUpTo {
+		return
+	}
+	o.memory.Progress = summarizeProgress(history[:older])
+	o.memory.ProgressUpTo = older
+	o.logger.Debug().Int("items", older).Msg("progress summary updated")
+}
+
+// summarizeProgress is a deterministic reducer over old history items: data the user gave,
+// how often each action ran (processed-item counts), pages visited and the planner's latest
+// progress note - what the recent window alone loses on multi-phase tasks
+func summarizeProgress(items []HistoryItem) string {
+	var (
+		received []string
+		note     string
+		urls     []string
+	)
+	counts := make(map[string]int)
+	seenURL := make(map[string]bool)
+	for _, item := range items {
+		if item.Action == "observation" {
+			continue
+		}
+		counts[item.Action]++
+		if item.Action == "request_user_input" && strings.HasPrefix(item.Result, receivedDataPrefix) {
+			received = append(received, strings.TrimPrefix(item.Result, receivedDataPrefix))
+		}
+		if item.Memory != "" {
+			note = item.Memory
+		}
+		if item.URL != "" && !seenURL[item.URL] {
+			seenURL[item.URL] = true
+			urls = append(urls, item.URL)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Earlier steps summarized: %d\n", len(items))
+	if len(received) > 0 {
+		fmt.Fprintf(&b, "Data already received from the user (do not ask again): %s\n", strings.Join(received, "; "))
+	}
+	if len(counts) > 0 {
+		actions := make([]string, 0, len(counts))
+		for action := range counts {
+			actions = append(actions, action)
+		}
+		sort.Slice(actions, func(i, j int) bool {
+			if counts[actions[i]] != counts[actions[j]] {
+				return counts[actions[i]] > counts[actions[j]]
+			}
+			return actions[i] < actions[j]
+		})
+		parts := make([]string, len(actions))
+		for i, action := range actions {
+			parts[i] = fmt.Sprintf("%s x%d", action, counts[action])
+		}
+		fmt.Fprintf(&b, "Actions done: %s\n", strings.Join(parts, ", "))
+	}
+	if len(urls) > progressMaxURLs {
+		urls = append([]string{fmt.Sprintf("(%d more)", len(urls)-progressMaxURLs)}, urls[len(urls)-progressMaxURLs:]...)
+	}
+	if len(urls) > 0 {
+		fmt.Fprintf(&b, "Pages visited: %s\n", strings.Join(urls, ", "))
+	}
+	if note != "" {
+		fmt.Fprintf(&b, "Last progress note: %s\n", note)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
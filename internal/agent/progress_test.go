@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+func clicks(n int) []HistoryItem {
+	items := make([]HistoryItem, n)
+	for i := range items {
+		items[i] = HistoryItem{Action: "click_by_index", Result: fmt.Sprintf("clicked %d", i+1), URL: fmt.Sprintf("https://shop.example.com/item/%d", i+1)}
+	}
+	return items
+}
+
+func TestProgressRebuiltOnlyWhenHistoryGrows(t *testing.T) {
+	o := &Orchestrator{memory: &TaskMemory{}, logger: zerolog.Nop()}
+	history := clicks(recentHistoryItems)
+	o.updateProgress(history)
+	if o.memory.Progress != "" || o.memory.ProgressUpTo != 0 {
+		t.Fatalf("summary %q up to %d while everything fits the recent window", o.memory.Progress, o.memory.ProgressUpTo)
+	}
+
+	history = clicks(recentHistoryItems + 2)
+	o.updateProgress(history)
+	if o.memory.ProgressUpTo != 2 || !strings.HasPrefix(o.memory.Progress, "Earlier steps summarized: 2\n") {
+		t.Fatalf("summary %q up to %d, want the 2 oldest items", o.memory.Progress, o.memory.ProgressUpTo)
+	}
+
+	// Same history: the summary is kept as is, not rebuilt
+	o.memory.Progress = "kept"
+	o.updateProgress(history)
+	if o.memory.Progress != "kept" {
+		t.Fatalf("summary rebuilt without new items: %q", o.memory.Progress)
+	}
+
+	history = clicks(recentHistoryItems + 3)
+	o.updateProgress(history)
+	if o.memory.ProgressUpTo != 3 || !strings.HasPrefix(o.memory.Progress, "Earlier steps summarized: 3\n") {
+		t.Fatalf("summary %q up to %d, want it rebuilt over 3 items", o.memory.Progress, o.memory.ProgressUpTo)
+	}
+}
+
+func TestSummarizeProgress(t *testing.T) {
+	items := append([]HistoryItem{
+		{Action: "request_user_input", Result: receivedDataPrefix + "alice@example.com", URL: "https://shop.example.com/login"},
+		{Action: "request_user_input", Result: "User confirmed: action completed (e.g., captcha solved). Continue with the task."},
+		{Action: "observation", Result: "NO PROGRESS: the page has not changed for 3 steps"},
+	}, clicks(7)...)
+	items[4].Memory = "collected 2 of 10 prices"
+	items[8].Memory = "collected 6 of 10 prices"
+	want := strings.Join([]string{
+		"Earlier steps summarized: 10",
+		"Data already received from the user (do not ask again): alice@example.com",
+		"Actions done: click_by_index x7, request_user_input x2",
+		"Pages visited: (3 more), https://shop.example.com/item/3, https://shop.example.com/item/4, https://shop.example.com/item/5, https://shop.example.com/item/6, https://shop.example.com/item/7",
+		"Last progress note: collected 6 of 10 prices",
+	}, "\n")
+	if got := summarizeProgress(items); got != want {
+		t.Fatalf("summary =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProgressReachesThePlanner(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var (
+			responses []string
+			items     []browser.FakePage
+		)
+		// Visit an item, ask for its quantity, visit the next one...
+		for i := 0; i < recentHistoryItems+2; i++ {
+			if i%2 == 1 {
+				responses = append(responses, decision("request_user_input", map[string]any{"prompt": "Quantity?"}))
+				continue
+			}
+			url := fmt.Sprintf("https://shop.example.com/item/%d", i+1)
+			responses = append(responses, decision("navigate", map[string]any{"url": url}))
+			items = append(items, browser.FakePage{URL: url})
+		}
+		responses = append(responses, finishDecision("visited every item", true))
+		client := llm.NewScriptedClient(responses)
+		ctrl := browser.NewFakeController(browser.FakePage{URL: "https://shop.example.com/"}, items...)
+		cfg := Config{MaxSteps: len(responses), Quiet: true, SummarizeHistory: enabled}
+		answers := 0
+		ask := func(context.Context, string) (string, error) {
+			answers++
+			return fmt.Sprintf("%d pcs", answers), nil
+		}
+		orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(ctrl, ask), zerolog.Nop())
+		result, err := orch.Run(context.Background(), Task{Description: "visit every item"}, func(context.Context) (snapshot.Summary, error) {
+			return snapshot.Summary{URL: ctrl.Current().URL}, nil
+		})
+		if err != nil || !result.Success {
+			t.Fatalf("run = %+v, %v", result, err)
+		}
+		var summarized []string
+		for _, req := range client.Requests() {
+			prompt := req.Messages[0].Content
+			if i := strings.Index(prompt, "Earlier steps summarized: "); i >= 0 {
+				summarized = append(summarized, strings.SplitN(prompt[i:], "\n", 2)[0])
+			}
+		}
+		if !enabled {
+			if len(summarized) != 0 {
+				t.Fatalf("summary shown with SummarizeHistory off: %v", summarized)
+			}
+			continue
+		}
+		want := []string{"Earlier steps summarized: 1", "Earlier steps summarized: 2"}
+		if strings.Join(summarized, ",") != strings.Join(want, ",") {
+			t.Fatalf("summaries per prompt = %v, want %v", summarized, want)
+		}
+		if last := client.Requests()[len(responses)-1].Messages[0].Content; !strings.Contains(last, "Data already received from the user (do not ask again): 1 pcs") {
+			t.Fatalf("the last prompt lost the user's answer:\n%s", last)
+		}
+	}
+}
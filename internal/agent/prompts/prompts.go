@@ -0,0 +1,220 @@
+// Package prompts builds the prompts of the agent's auxiliary model calls - finish validation,
+// deliverable extraction and coverage, risk rating, task titles, progress summaries and
+// reflection on a stuck run - so business logic does not
+// embed prompt text. Each builder takes the task language and returns the system prompt and
+// the user message; operators may replace any system prompt with LoadOverrides.
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the prompts, the keys of an override file
+const (
+	FinishValidation = "finish_validation"
+	Extraction       = "extraction"
+	Coverage         = "coverage"
+	Risk             = "risk"
+	Title            = "title"
+	Summary          = "summary"
+	Reflection       = "reflection"
+)
+
+// maxListItems bounds the steps or failures a Summary or Reflection prompt quotes; older ones are counted
+const maxListItems = 30
+
+// Prompt is a system prompt plus the single user message of an auxiliary call
+type Prompt struct {
+	System string
+	User   string
+}
+
+var defaults = map[string]string{
+	FinishValidation: `You check whether a browser automation agent really completed its task, judging by the current page.
+Answer "yes" or "no" first, then one short sentence with the reason.
+Answer "no" only when the page shows the task is not done: an error or validation message, a form still waiting to be submitted, a login wall, missing results the task asked for.
+If the page is neutral or consistent with the agent's message, answer "yes".`,
+
+	Extraction: `You list what a user expects to get back from a browser task.
+Output one requested deliverable per line, a few words each, no numbering, no commentary.
+Only list information or results the user explicitly asks for ("the price", "the delivery time", "confirmation the order was placed").
+If the task asks for a single thing, output one line. If it asks for nothing to be reported, output nothing.`,
+
+	Coverage: `You check whether a browser agent's final message provides one specific deliverable.
+Answer "yes" if the message states it (a value, a result, or an explicit statement that it does not exist), "no" if the message omits it. Answer with one word.`,
+
+	Risk: `You rate the risk of one action a browser automation agent is about to take.
+Answer with exactly one word:
+- safe: navigation, search, filters, opening items, closing popups, form steps that change nothing yet
+- needs-confirmation: irreversible or costly effects the user should approve: paying, ordering, deleting, sending messages, changing account settings
+- forbidden: irreversible effects clearly outside the task (a purchase or deletion the task does not ask for)`,
+
+	Title: `You name browser automation tasks. Reply with a title of at most 5 words in the language of the task, no quotes, no trailing period.`,
+
+	Summary: `You keep the progress notes of a browser automation agent on a long task.
+Summarize the steps below in at most 6 short lines: what is already done (logged in, filters set, pages visited), how many items were processed out of how many, and every piece of data the user supplied or the agent collected, verbatim.
+Do not invent steps, do not plan ahead, do not repeat failed attempts unless they explain the current state.`,
+
+	Reflection: `You review a browser automation agent that is not making progress.
+From the task and its recent failed or repeated actions, answer in at most 3 short lines: why the attempts fail, and one concrete different approach (another element, another page, scrolling, waiting, asking the user).
+Never suggest repeating an action that already failed the same way.`,
+}
+
+var (
+	mu        sync.RWMutex
+	overrides = map[string]string{}
+)
+
+// LoadOverrides replaces system prompts with the ones of a JSON file mapping prompt names to
+// text ({"risk": "..."}); an empty path keeps the defaults. Unknown names are an error so a typo
+// does not silently leave the default in place.
+func LoadOverrides(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("prompt overrides: %w", err)
+	}
+	var loaded map[string]string
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return fmt.Errorf("prompt overrides %s: %w", path, err)
+	}
+	for name, text := range loaded {
+		if _, ok := defaults[name]; !ok {
+			return fmt.Errorf("prompt overrides %s: unknown prompt %q (known: %s)", path, name, strings.Join(Names(), ", "))
+		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("prompt overrides %s: %q is empty", path, name)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	overrides = loaded
+	return nil
+}
+
+// Names lists the prompts that can be overridden
+func Names() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// system is the override or default system prompt of name, followed by the language note
+func system(name, note string) string {
+	mu.RLock()
+	text, ok := overrides[name]
+	mu.RUnlock()
+	if !ok {
+		text = defaults[name]
+	}
+	if note != "" {
+		text += "\n" + note
+	}
+	return text
+}
+
+// judgeNote tells a yes/no judge the page may be in another language than the task
+func judgeNote(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	return fmt.Sprintf("The task is written in %s; the page may use another language - compare meaning, not words.", lang)
+}
+
+// FinishValidationPrompt asks whether page (a rendered snapshot) confirms the agent's finish message;
+// lang is the task language name ("Russian"), "" when unknown
+func FinishValidationPrompt(lang, task, message, page string) Prompt {
+	return Prompt{
+		System: system(FinishValidation, judgeNote(lang)),
+		User: fmt.Sprintf("Task: %s\nAgent's finish message: %s\n\nCurrent page:\n%s\n\nDoes this page state confirm the task is complete?",
+			task, message, page),
+	}
+}
+
+// ExtractionPrompt asks for the deliverables of a task, one per line, in the task language
+func ExtractionPrompt(lang, task string) Prompt {
+	note := ""
+	if lang != "" {
+		note = fmt.Sprintf("Write the deliverables in %s.", lang)
+	}
+	return Prompt{System: system(Extraction, note), User: "Task: " + task}
+}
+
+// CoveragePrompt asks whether a finish message provides one deliverable
+func CoveragePrompt(lang, item, message string) Prompt {
+	return Prompt{
+		System: system(Coverage, judgeNote(lang)),
+		User:   fmt.Sprintf("Deliverable: %s\n\nFinal message:\n%s", item, message),
+	}
+}
+
+// RiskTarget is the action RiskPrompt asks about
+type RiskTarget struct {
+	Task    string
+	URL     string
+	Action  string
+	Role    string
+	Text    string
+	Context []string // Neighbouring snapshot elements
+}
+
+// RiskPrompt asks for the risk level (safe, needs-confirmation, forbidden) of an action
+func RiskPrompt(lang string, t RiskTarget) Prompt {
+	return Prompt{
+		System: system(Risk, judgeNote(lang)),
+		User: fmt.Sprintf("Task: %s\nPage: %s\nAction: %s on %s %q\nNearby elements:\n%s",
+			t.Task, t.URL, t.Action, t.Role, t.Text, strings.Join(t.Context, "\n")),
+	}
+}
+
+// SummaryPrompt asks for progress notes over the steps of a run ("navigate https://... -> ok"),
+// written in the task language
+func SummaryPrompt(lang, task string, steps []string) Prompt {
+	note := ""
+	if lang != "" {
+		note = fmt.Sprintf("Write the notes in %s.", lang)
+	}
+	return Prompt{System: system(Summary, note), User: fmt.Sprintf("Task: %s\n\nSteps:\n%s", task, numbered(steps))}
+}
+
+// ReflectionPrompt asks why recent actions fail and what to try instead
+func ReflectionPrompt(lang, task string, failures []string) Prompt {
+	note := ""
+	if lang != "" {
+		note = fmt.Sprintf("Answer in %s.", lang)
+	}
+	return Prompt{System: system(Reflection, note), User: fmt.Sprintf("Task: %s\n\nRecent actions that did not help:\n%s", task, numbered(failures))}
+}
+
+// numbered lists the last maxListItems items, noting how many earlier ones were left out
+func numbered(items []string) string {
+	var b strings.Builder
+	first := 0
+	if len(items) > maxListItems {
+		first = len(items) - maxListItems
+		fmt.Fprintf(&b, "(%d earlier omitted)\n", first)
+	}
+	for i := first; i < len(items); i++ {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, items[i])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// TitlePrompt asks for a short title of a task
+func TitlePrompt(lang, task string) Prompt {
+	note := ""
+	if lang != "" {
+		note = fmt.Sprintf("The task is in %s.", lang)
+	}
+	return Prompt{System: system(Title, note), User: task}
+}
@@ -0,0 +1,109 @@
+package prompts
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current builders")
+
+// builders renders every prompt with fixed inputs, in English and in Russian
+func builders() map[string]Prompt {
+	steps := []string{"navigate https://shop.example.com -> ok", "request_user_input -> user gave size 42", "click_by_index 7 -> ok"}
+	failures := []string{"click_text \"Checkout\" -> element not found", "click_text \"Checkout\" -> element not found"}
+	risk := RiskTarget{Task: "order sneakers", URL: "https://shop.example.com/cart", Action: "click_by_index", Role: "button", Text: "Pay now", Context: []string{"[3] link \"Cart\"", "[4] button \"Pay now\""}}
+	return map[string]Prompt{
+		"finish_validation_en": FinishValidationPrompt("English", "order sneakers", "Order placed", "URL: https://shop.example.com/thanks\nThank you for your order"),
+		"extraction_ru":        ExtractionPrompt("Russian", "узнай цену и срок доставки"),
+		"coverage_en":          CoveragePrompt("English", "the price", "The sneakers cost $120."),
+		"risk_en":              RiskPrompt("English", risk),
+		"title_ru":             TitlePrompt("Russian", "найди кроссовки 42 размера и положи в корзину"),
+		"summary_en":           SummaryPrompt("English", "order sneakers in size 42", steps),
+		"summary_ru":           SummaryPrompt("Russian", "закажи кроссовки 42 размера", steps),
+		"reflection_en":        ReflectionPrompt("English", "order sneakers", failures),
+		"reflection_nolang":    ReflectionPrompt("", "order sneakers", failures),
+	}
+}
+
+func TestPromptsGolden(t *testing.T) {
+	for name, p := range builders() {
+		t.Run(name, func(t *testing.T) {
+			got := "SYSTEM:\n" + p.System + "\n\nUSER:\n" + p.User + "\n"
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test ./internal/agent/prompts -update)", err)
+			}
+			if got != string(want) {
+				t.Fatalf("prompt changed, run with -update if intended:\n--- got\n%s\n--- want\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestPromptsSize(t *testing.T) {
+	// System prompts go with every auxiliary call: keep them a few hundred tokens at most
+	const maxSystemBytes = 1500
+	for name, p := range builders() {
+		if len(p.System) > maxSystemBytes {
+			t.Errorf("%s: system prompt is %d bytes, budget %d", name, len(p.System), maxSystemBytes)
+		}
+		if !utf8.ValidString(p.System) || !utf8.ValidString(p.User) {
+			t.Errorf("%s: invalid UTF-8", name)
+		}
+	}
+}
+
+func TestListsKeepTheLastItems(t *testing.T) {
+	steps := make([]string, maxListItems+12)
+	for i := range steps {
+		steps[i] = fmt.Sprintf("step %d", i+1)
+	}
+	p := SummaryPrompt("", "long task", steps)
+	if !strings.Contains(p.User, "(12 earlier omitted)") {
+		t.Fatalf("no omission note:\n%s", p.User)
+	}
+	if strings.Contains(p.User, "step 12\n") || !strings.Contains(p.User, "13. step 13\n") || !strings.HasSuffix(p.User, "42. step 42") {
+		t.Fatalf("wrong items kept:\n%s", p.User)
+	}
+	if n := strings.Count(p.User, "\n"); n > maxListItems+4 {
+		t.Fatalf("%d lines, list is not bounded", n)
+	}
+}
+
+func TestOverridesApplyToNewBuilders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.json")
+	if err := os.WriteFile(path, []byte(`{"summary": "Custom summary persona.", "reflection": "Custom reflection persona."}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadOverrides(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		mu.Lock()
+		overrides = map[string]string{}
+		mu.Unlock()
+	}()
+	if p := SummaryPrompt("Russian", "task", nil); p.System != "Custom summary persona.\nWrite the notes in Russian." {
+		t.Fatalf("summary system = %q", p.System)
+	}
+	if p := ReflectionPrompt("", "task", nil); p.System != "Custom reflection persona." {
+		t.Fatalf("reflection system = %q", p.System)
+	}
+	if p := TitlePrompt("", "task"); p.System != defaults[Title] {
+		t.Fatal("an override of other prompts changed the title prompt")
+	}
+}
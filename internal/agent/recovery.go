@@ -0,0 +1,427 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const (
+	// waitRetryPause lets a slow page settle before wait_retry repeats the action
+	waitRetryPause = 2 * time.Second
+	// scrollSettlePause lets smooth scrolling finish before scroll_into_view retries
+	scrollSettlePause = 1 * time.Second
+)
+
+// RecoveryStrategy is one step of the error-recovery cascade run after a failed action.
+// Strategies are tried in Config.RecoveryStrategies order until one recovers or stops the cascade.
+type RecoveryStrategy interface {
+	// Name identifies the strategy in logs and lets callers filter DefaultRecoveryStrategies
+	Name() string
+	// Applies reports whether the strategy handles this error type (see analyzeError) and action
+	Applies(errorType string, dec Decision) bool
+	// Attempt tries to recover and returns the action actually run with its result;
+	// ok=false moves on to the next strategy unless rc.Stop was called
+	Attempt(ctx context.Context, rc *RecoveryContext) (action string, result tools.Result, ok bool)
+}
+
+// RecoveryContext is what a strategy gets to work with
+type RecoveryContext struct {
+	ErrorType string
+	Decision  Decision
+	// Summary is the snapshot taken right after the failure
+	Summary snapshot.Summary
+	// Snapshot takes a fresh one
+	Snapshot func(ctx context.Context) (snapshot.Summary, error)
+	Tools    tools.Toolbox
+	Logger   zerolog.Logger
+
+	o       *Orchestrator
+	stopped bool
+}
+
+// Stop ends the cascade after the current strategy: later strategies must not run
+// (the action was declined, or the error is fully explained)
+func (rc *RecoveryContext) Stop() {
+	rc.stopped = true
+}
+
+// Note explains to the planner why recovery failed; it is appended to the history error
+func (rc *RecoveryContext) Note(text string) {
+	rc.o.recoveryNote = text
+}
+
+// Confirm runs a recovery action through the confirmation gate of the original one:
+// true when neither action matches ConfirmationPolicy or the human approved it
+func (rc *RecoveryContext) Confirm(ctx context.Context, action string, input map[string]any) bool {
+	o, dec := rc.o, rc.Decision
+	keyword, severity := o.cfg.ConfirmationPolicy.match(dec.ActionName, confirmationInput(dec, rc.Summary))
+	if keyword == "" {
+		keyword, severity = o.cfg.ConfirmationPolicy.match(action, input)
+	}
+	if keyword == "" {
+		return true
+	}
+	confirmed, _, err := o.confirmAction(ctx, keyword, severity, action, input, rc.Summary.URL)
+	if err != nil || !confirmed {
+		rc.Logger.Warn().Err(err).Str("action", action).Msg("recovery action not confirmed")
+		return false
+	}
+	return true
+}
+
+// retry re-runs the original action unchanged
+func (rc *RecoveryContext) retry(ctx context.Context) (string, tools.Result, bool) {
+	res, err := rc.Tools.Invoke(ctx, rc.Decision.ActionName, rc.Decision.ActionInput)
+	if err != nil {
+		return "", tools.Result{}, false
+	}
+	return rc.Decision.ActionName, res, true
+}
+
+// waitOrDone pauses for d; false when ctx ended first and recovery must give up
+func waitOrDone(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// RecoveryAttempt is one strategy tried on a failed action
+type RecoveryAttempt struct {
+	Step      int    `json:"step"`
+	Strategy  string `json:"strategy"`
+	ErrorType string `json:"error_type"` // analyzeError class of the original failure
+	Success   bool   `json:"success"`
+}
+
+// StrategyStats counts attempts and successes of one strategy
+type StrategyStats struct {
+	Attempts  int `json:"attempts"`
+	Successes int `json:"successes"`
+}
+
+// RecoveryStats summarizes adaptive recovery over a run (RunResult.Recovery)
+type RecoveryStats struct {
+	Attempts   int                      `json:"attempts"`
+	Successes  int                      `json:"successes"`
+	ByStrategy map[string]StrategyStats `json:"by_strategy"`
+	// ByErrorType counts attempts per original error type
+	ByErrorType map[string]StrategyStats `json:"by_error_type"`
+}
+
+func (s *RecoveryStats) add(a RecoveryAttempt) {
+	if s.ByStrategy == nil {
+		s.ByStrategy = make(map[string]StrategyStats)
+		s.ByErrorType = make(map[string]StrategyStats)
+	}
+	s.Attempts++
+	bs, be := s.ByStrategy[a.Strategy], s.ByErrorType[a.ErrorType]
+	bs.Attempts++
+	be.Attempts++
+	if a.Success {
+		s.Successes++
+		bs.Successes++
+		be.Successes++
+	}
+	s.ByStrategy[a.Strategy], s.ByErrorType[a.ErrorType] = bs, be
+}
+
+func (r *RunResult) recordRecovery(a RecoveryAttempt) {
+	if r.Recovery == nil {
+		r.Recovery = &RecoveryStats{}
+	}
+	r.Recovery.add(a)
+}
+
+// recoveryOutcome is the result of the cascade for one failed action
+type recoveryOutcome struct {
+	ok       bool
+	action   string // Action actually run by the successful strategy
+	strategy string
+	result   tools.Result
+	attempts []RecoveryAttempt
+}
+
+// DefaultRecoveryStrategies returns the built-in cascade in the order it runs when
+// Config.RecoveryStrategies is nil: recreate_context, ambiguous_locator, wait_retry,
+// alternative_click, fuzzy_text, click_coordinates, similar_element, scroll_into_view
+func DefaultRecoveryStrategies() []RecoveryStrategy {
+	return []RecoveryStrategy{
+		recreateContextStrategy{},
+		ambiguousLocatorStrategy{},
+		waitRetryStrategy{},
+		alternativeClickStrategy{},
+		fuzzyTextStrategy{},
+		coordinatesStrategy{},
+		similarElementStrategy{},
+		scrollIntoViewStrategy{},
+	}
+}
+
+// handleErrorAdaptively runs the recovery strategies applicable to the last error and
+// reports every attempt, so strategies that never work show up in RunResult.Recovery
+func (o *Orchestrator) handleErrorAdaptively(ctx context.Context, dec Decision, summary snapshot.Summary, snap summaryFunc, step int) recoveryOutcome {
+	var out recoveryOutcome
+	// Don't retry if we've already tried too many times for this action
+	if o.hasRecentRetries(dec.ActionName, 2) {
+		return out
+	}
+
+	strategies := o.cfg.RecoveryStrategies
+	if strategies == nil {
+		strategies = DefaultRecoveryStrategies()
+	}
+	rc := &RecoveryContext{
+		ErrorType: o.errorHistory[len(o.errorHistory)-1].errorType,
+		Decision:  dec,
+		Summary:   summary,
+		Snapshot:  snap,
+		Tools:     o.tools,
+		Logger:    o.logger,
+		o:         o,
+	}
+	for _, s := range strategies {
+		if !s.Applies(rc.ErrorType, dec) {
+			continue
+		}
+		action, res, ok := s.Attempt(ctx, rc)
+		out.attempts = append(out.attempts, RecoveryAttempt{Step: step, Strategy: s.Name(), ErrorType: rc.ErrorType, Success: ok})
+		o.logger.Info().
+			Str("strategy", s.Name()).
+			Str("error_type", rc.ErrorType).
+			Str("action", dec.ActionName).
+			Bool("success", ok).
+			Msg("recovery attempt")
+		if ok {
+			out.ok, out.action, out.strategy, out.result = true, action, s.Name(), res
+			return out
+		}
+		if rc.stopped {
+			break
+		}
+	}
+	return out
+}
+
+// recoveryDecision lets click strategies work on a failed click_by_index: it becomes the
+// click the toolbox ran for the element of the pre-action snapshot. Frame elements stay
+// as they are - main-page click strategies would act on the wrong document.
+func recoveryDecision(dec Decision, summary snapshot.Summary) Decision {
+	if dec.ActionName != "click_by_index" {
+		return dec
+	}
+	if el := indexedElement(dec, summary); el != nil && el.FrameURL == "" {
+		dec.ActionName, dec.ActionInput = tools.IndexClick(*el)
+	}
+	return dec
+}
+
+func isClickAction(action string) bool {
+	return action == "click_selector" || action == "click_role" || action == "click_text"
+}
+
+// recreateContextStrategy recreates a dead browser context and retries (at most once per run)
+type recreateContextStrategy struct{}
+
+func (recreateContextStrategy) Name() string { return "recreate_context" }
+
+func (recreateContextStrategy) Applies(errorType string, _ Decision) bool {
+	return errorType == "context_closed"
+}
+
+func (recreateContextStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	// Nothing else can work on a closed context
+	rc.Stop()
+	if rc.o.contextRecreated {
+		return "", tools.Result{}, false
+	}
+	rc.o.contextRecreated = true
+	rc.Logger.Warn().Str("strategy", "recreate_context").Msg("browser context closed - recreating")
+	if err := rc.Tools.RecreateContext(ctx); err != nil {
+		rc.Logger.Error().Err(err).Msg("recreate context failed")
+		return "", tools.Result{}, false
+	}
+	return rc.retry(ctx)
+}
+
+// ambiguousLocatorStrategy picks the first match when a selector matched identical elements,
+// otherwise explains the candidates (see resolveAmbiguousLocator)
+type ambiguousLocatorStrategy struct{}
+
+func (ambiguousLocatorStrategy) Name() string { return "ambiguous_locator" }
+
+func (ambiguousLocatorStrategy) Applies(errorType string, _ Decision) bool {
+	return errorType == "ambiguous_locator"
+}
+
+func (ambiguousLocatorStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	rc.Stop()
+	return rc.o.resolveAmbiguousLocator(ctx, rc.Decision)
+}
+
+// waitRetryStrategy waits for the page to settle and retries (timeout/stale element)
+type waitRetryStrategy struct{}
+
+func (waitRetryStrategy) Name() string { return "wait_retry" }
+
+func (waitRetryStrategy) Applies(errorType string, _ Decision) bool {
+	return errorType == "timeout" || errorType == "stale_element"
+}
+
+func (waitRetryStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	rc.Logger.Info().Str("strategy", "wait_retry").Msg("trying wait and retry")
+	if !waitOrDone(ctx, waitRetryPause) {
+		return "", tools.Result{}, false
+	}
+	// Fresh snapshot refreshes the page state the tools resolve elements against
+	ctxSnap, cancel := snapshot.WithDeadline(ctx, 3*time.Second)
+	_, _ = rc.Snapshot(ctxSnap)
+	cancel()
+	return rc.retry(ctx)
+}
+
+// alternativeClickStrategy retries a click with another click method (selector/role/text)
+type alternativeClickStrategy struct{}
+
+func (alternativeClickStrategy) Name() string { return "alternative_click" }
+
+func (alternativeClickStrategy) Applies(_ string, dec Decision) bool {
+	return isClickAction(dec.ActionName)
+}
+
+func (alternativeClickStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	for _, alt := range rc.o.generateAlternatives(rc.Decision, rc.Summary) {
+		rc.Logger.Info().
+			Str("original", rc.Decision.ActionName).
+			Str("alternative", alt.action).
+			Msg("trying alternative action")
+		// Another click method may resolve to another element than the approved one
+		if !rc.Confirm(ctx, alt.action, alt.input) {
+			rc.Stop()
+			return "", tools.Result{}, false
+		}
+		altResult, err := rc.Tools.Invoke(ctx, alt.action, alt.input)
+		if err == nil {
+			return alt.action, altResult, true
+		}
+	}
+	return "", tools.Result{}, false
+}
+
+// fuzzyTextStrategy clicks by fuzzy text taken from the element a failed selector points at
+type fuzzyTextStrategy struct{}
+
+func (fuzzyTextStrategy) Name() string { return "fuzzy_text" }
+
+func (fuzzyTextStrategy) Applies(_ string, dec Decision) bool {
+	return dec.ActionName == "click_selector"
+}
+
+func (fuzzyTextStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	text := rc.o.extractTextFromSelector(rc.Decision, rc.Summary)
+	if text == "" {
+		return "", tools.Result{}, false
+	}
+	rc.Logger.Info().Str("strategy", "fuzzy_text").Str("text", text).Msg("trying fuzzy text match")
+	// A fuzzy match may land on another element than the one the human approved
+	if !rc.Confirm(ctx, "click_text_fuzzy", map[string]any{"text": text}) {
+		rc.Stop()
+		return "", tools.Result{}, false
+	}
+	fuzzyResult, err := rc.Tools.Invoke(ctx, "click_text_fuzzy", map[string]any{"text": text})
+	if err != nil {
+		return "", tools.Result{}, false
+	}
+	return "click_text_fuzzy", fuzzyResult, true
+}
+
+// coordinatesStrategy clicks the center of the element's bbox (last resort for clicks)
+type coordinatesStrategy struct{}
+
+func (coordinatesStrategy) Name() string { return "click_coordinates" }
+
+func (coordinatesStrategy) Applies(_ string, dec Decision) bool {
+	return isClickAction(dec.ActionName)
+}
+
+func (coordinatesStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	coords := rc.o.extractCoordinates(rc.Decision, rc.Summary)
+	if coords.x <= 0 || coords.y <= 0 {
+		return "", tools.Result{}, false
+	}
+	rc.Logger.Info().
+		Float64("x", coords.x).
+		Float64("y", coords.y).
+		Msg("trying click by coordinates")
+	input := map[string]any{"x": int(coords.x), "y": int(coords.y)}
+	// Whatever is on top at that point gets the click - maybe not the approved element
+	if !rc.Confirm(ctx, "click_coordinates", input) {
+		rc.Stop()
+		return "", tools.Result{}, false
+	}
+	coordResult, err := rc.Tools.Invoke(ctx, "click_coordinates", input)
+	if err != nil {
+		return "", tools.Result{}, false
+	}
+	return "click_coordinates", coordResult, true
+}
+
+// similarElementStrategy acts on an element with the same role and near-identical text.
+// Looser matches don't qualify - clicking "Delete all" instead of a missing "Delete" is not
+// a recovery, it's data loss.
+type similarElementStrategy struct{}
+
+func (similarElementStrategy) Name() string { return "similar_element" }
+
+func (similarElementStrategy) Applies(errorType string, _ Decision) bool {
+	return errorType == "element_not_found"
+}
+
+func (similarElementStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	similar, score := rc.o.findSimilarElement(rc.Decision, rc.Summary)
+	if similar.action == "" {
+		return "", tools.Result{}, false
+	}
+	rc.Logger.Info().
+		Str("original", rc.Decision.ActionName).
+		Str("similar", similar.action).
+		Float64("similarity", score).
+		Msg("trying similar element")
+	// A declined substitute ends recovery: the human already said no to this kind of action
+	if !rc.Confirm(ctx, similar.action, similar.input) {
+		rc.Stop()
+		return "", tools.Result{}, false
+	}
+	similarResult, err := rc.Tools.Invoke(ctx, similar.action, similar.input)
+	if err != nil {
+		return "", tools.Result{}, false
+	}
+	return similar.action, similarResult, true
+}
+
+// scrollIntoViewStrategy scrolls to the element's bbox and retries (not interactable)
+type scrollIntoViewStrategy struct{}
+
+func (scrollIntoViewStrategy) Name() string { return "scroll_into_view" }
+
+func (scrollIntoViewStrategy) Applies(errorType string, _ Decision) bool {
+	return errorType == "not_interactable"
+}
+
+func (scrollIntoViewStrategy) Attempt(ctx context.Context, rc *RecoveryContext) (string, tools.Result, bool) {
+	rc.Logger.Info().Str("strategy", "scroll_to_element").Msg("trying scroll to element")
+	if err := rc.o.scrollToElement(ctx, rc.Decision, rc.Summary); err != nil {
+		return "", tools.Result{}, false
+	}
+	if !waitOrDone(ctx, scrollSettlePause) {
+		return "", tools.Result{}, false
+	}
+	return rc.retry(ctx)
+}
@@ -0,0 +1,378 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+func TestAnalyzeError(t *testing.T) {
+	tests := map[string]string{
+		"click: Target page, context or browser has been closed":   "context_closed",
+		"strict mode violation: locator resolved to 2 elements":    "ambiguous_locator",
+		"Timeout 10000ms exceeded":                                 "timeout",
+		"element not found: #save":                                 "element_not_found",
+		"element is not visible":                                   "element_not_found",
+		"element is not clickable at point (10, 20)":               "not_interactable",
+		"element is detached from the DOM":                         "stale_element",
+		"net::ERR_CONNECTION_RESET":                                "network_error",
+		"unexpected token \"]\" while parsing selector \"a[href\"": "selector_parse_error",
+		"something else":                                           "unknown",
+	}
+	o := &Orchestrator{}
+	for msg, want := range tests {
+		if got := o.analyzeError(errors.New(msg)); got != want {
+			t.Errorf("analyzeError(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestRecoveryRecreatesClosedContext(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	ctrl := browser.NewFakeController(page)
+	closed := errors.New("Target page, context or browser has been closed")
+	ctrl.FailNext("Click", closed)
+	result, err := runWithController(t, Config{}, ctrl, loginSummary,
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ctrl.CallsTo("Recreate")); n != 1 {
+		t.Errorf("Recreate called %d times, want once", n)
+	}
+	if n := len(ctrl.CallsTo("Click")); n != 2 {
+		t.Errorf("%d clicks, want the click retried on the new context", n)
+	}
+	if result.Recovery == nil || result.Recovery.ByStrategy["recreate_context"].Successes != 1 {
+		t.Errorf("recovery = %+v, want recreate_context to succeed", result.Recovery)
+	}
+}
+
+func TestRecoveryRecreatesContextOncePerRun(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	ctrl := browser.NewFakeController(page)
+	closed := errors.New("Target page, context or browser has been closed")
+	ctrl.FailNext("Click", closed, closed, closed)
+	result, err := runWithController(t, Config{}, ctrl, loginSummary,
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("could not sign in", false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ctrl.CallsTo("Recreate")); n != 1 {
+		t.Errorf("Recreate called %d times, want once per run", n)
+	}
+	// A dead context stops the other strategies: nothing but the retry touches the page
+	if n := len(ctrl.CallsTo("ClickText")) + len(ctrl.CallsTo("ClickRole")); n != 0 {
+		t.Errorf("calls = %v, want no alternative clicks on a closed context", ctrl.Calls())
+	}
+	if len(result.History) < 2 || !strings.Contains(result.History[1].Result, "closed") {
+		t.Errorf("history = %+v, want the second failure reported", result.History)
+	}
+}
+
+// fakeToolbox records the actions a recovery strategy runs; actions listed in fail return an error
+type fakeToolbox struct {
+	tools.Toolbox
+	fail      map[string]error
+	matches   []string // SelectorMatches answer
+	invoked   []string // "action input" in call order
+	recreated int
+}
+
+func (f *fakeToolbox) Invoke(_ context.Context, name string, input map[string]any) (tools.Result, error) {
+	f.invoked = append(f.invoked, fmt.Sprintf("%s %v", name, input))
+	if err := f.fail[name]; err != nil {
+		return tools.Result{}, err
+	}
+	return tools.Result{Observation: name + " done"}, nil
+}
+
+func (f *fakeToolbox) RecreateContext(context.Context) error {
+	f.recreated++
+	return nil
+}
+
+func (f *fakeToolbox) SelectorMatches(context.Context, string) ([]string, error) {
+	return f.matches, nil
+}
+
+// attemptStrategy runs one strategy on a prepared failure the way handleErrorAdaptively does
+func attemptStrategy(ctx context.Context, s RecoveryStrategy, cfg Config, tb *fakeToolbox, errorType string, dec Decision, summary snapshot.Summary) (string, bool, *RecoveryContext) {
+	o := &Orchestrator{cfg: cfg, tools: tb, logger: zerolog.Nop()}
+	rc := &RecoveryContext{
+		ErrorType: errorType,
+		Decision:  dec,
+		Summary:   summary,
+		Snapshot:  func(context.Context) (snapshot.Summary, error) { return summary, nil },
+		Tools:     tb,
+		Logger:    zerolog.Nop(),
+		o:         o,
+	}
+	if !s.Applies(errorType, dec) {
+		return "", false, rc
+	}
+	action, _, ok := s.Attempt(ctx, rc)
+	return action, ok, rc
+}
+
+func TestRecoveryStrategies(t *testing.T) {
+	saveSummary := snapshot.Summary{URL: "https://app.example/form", Elements: []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Save", Sel: "#save", BBox: "100,200,80,30"},
+	}}
+	clickSave := Decision{ActionName: "click_selector", ActionInput: map[string]any{"selector": "#save"}}
+	deny := Config{ConfirmationPolicy: ConfirmationPolicy{Mode: ConfirmDeny, Keywords: map[string][]string{SeverityGeneric: {"save"}}}}
+	notFound := errors.New("element not found")
+	tests := []struct {
+		name        string
+		strategy    RecoveryStrategy
+		cfg         Config
+		errorType   string
+		dec         Decision
+		fail        map[string]error
+		matches     []string
+		wantInvoked []string
+		wantAction  string // "" when the attempt must fail
+		wantStop    bool
+	}{
+		{name: "recreate context retries", strategy: recreateContextStrategy{}, errorType: "context_closed", dec: clickSave,
+			wantInvoked: []string{"click_selector map[selector:#save]"}, wantAction: "click_selector", wantStop: true},
+		{name: "identical matches take the first", strategy: ambiguousLocatorStrategy{}, errorType: "ambiguous_locator", dec: clickSave, matches: []string{"Save", "save "},
+			wantInvoked: []string{"click_selector map[selector:#save >> nth=0]"}, wantAction: "click_selector", wantStop: true},
+		{name: "different matches only explain", strategy: ambiguousLocatorStrategy{}, errorType: "ambiguous_locator", dec: clickSave, matches: []string{"Save", "Save as"},
+			wantStop: true},
+		{name: "wait and retry", strategy: waitRetryStrategy{}, errorType: "timeout", dec: clickSave,
+			wantInvoked: []string{"click_selector map[selector:#save]"}, wantAction: "click_selector"},
+		{name: "wait retry skips other errors", strategy: waitRetryStrategy{}, errorType: "element_not_found", dec: clickSave},
+		{name: "alternative click methods in order", strategy: alternativeClickStrategy{}, errorType: "element_not_found", dec: clickSave, fail: map[string]error{"click_text": notFound},
+			wantInvoked: []string{"click_text map[text:Save]", "click_role map[name:Save role:button]"}, wantAction: "click_role"},
+		{name: "declined alternative stops", strategy: alternativeClickStrategy{}, cfg: deny, errorType: "element_not_found", dec: clickSave, wantStop: true},
+		{name: "fuzzy text from the selector's element", strategy: fuzzyTextStrategy{}, errorType: "element_not_found", dec: clickSave,
+			wantInvoked: []string{"click_text_fuzzy map[text:Save]"}, wantAction: "click_text_fuzzy"},
+		{name: "declined fuzzy text stops", strategy: fuzzyTextStrategy{}, cfg: deny, errorType: "element_not_found", dec: clickSave, wantStop: true},
+		{name: "coordinates at the bbox center", strategy: coordinatesStrategy{}, errorType: "not_interactable", dec: clickSave,
+			wantInvoked: []string{"click_coordinates map[x:140 y:215]"}, wantAction: "click_coordinates"},
+		{name: "declined coordinates stop", strategy: coordinatesStrategy{}, cfg: deny, errorType: "not_interactable", dec: clickSave, wantStop: true},
+		{name: "similar element with the same role", strategy: similarElementStrategy{}, errorType: "element_not_found",
+			dec:         Decision{ActionName: "click_role", ActionInput: map[string]any{"role": "button", "name": "Sav"}},
+			wantInvoked: []string{"click_selector map[selector:#save]"}, wantAction: "click_selector"},
+		{name: "scroll into view then retry", strategy: scrollIntoViewStrategy{}, errorType: "not_interactable", dec: clickSave,
+			wantInvoked: []string{"scroll_page map[direction:down distance:300]", "click_selector map[selector:#save]"}, wantAction: "click_selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeToolbox{fail: tt.fail, matches: tt.matches}
+			action, ok, rc := attemptStrategy(context.Background(), tt.strategy, tt.cfg, tb, tt.errorType, tt.dec, saveSummary)
+			if ok != (tt.wantAction != "") || action != tt.wantAction {
+				t.Errorf("attempt = %q ok=%v, want %q", action, ok, tt.wantAction)
+			}
+			if fmt.Sprint(tb.invoked) != fmt.Sprint(tt.wantInvoked) {
+				t.Errorf("invoked %q, want %q", tb.invoked, tt.wantInvoked)
+			}
+			if rc.stopped != tt.wantStop {
+				t.Errorf("stopped = %v, want %v", rc.stopped, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestRecoveryWaitsStopOnCancel(t *testing.T) {
+	summary := snapshot.Summary{Elements: []snapshot.Element{{Index: 1, Role: "button", Text: "Save", Sel: "#save", BBox: "0,0,10,10"}}}
+	clickSave := Decision{ActionName: "click_selector", ActionInput: map[string]any{"selector": "#save"}}
+	for _, tt := range []struct {
+		strategy  RecoveryStrategy
+		errorType string
+	}{
+		{waitRetryStrategy{}, "timeout"},
+		{scrollIntoViewStrategy{}, "not_interactable"},
+	} {
+		t.Run(tt.strategy.Name(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			tb := &fakeToolbox{}
+			start := time.Now()
+			if _, ok, _ := attemptStrategy(ctx, tt.strategy, Config{}, tb, tt.errorType, clickSave, summary); ok {
+				t.Error("cancelled recovery reported success")
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("cancelled recovery took %s", elapsed)
+			}
+			for _, call := range tb.invoked {
+				if strings.HasPrefix(call, "click_selector") {
+					t.Errorf("click retried after cancellation: %q", tb.invoked)
+				}
+			}
+		})
+	}
+}
+
+// actionRecorder keeps every ActionEvent the run reports
+type actionRecorder struct{ actions []ActionEvent }
+
+func (r *actionRecorder) OnStep(StepEvent)        {}
+func (r *actionRecorder) OnAction(ev ActionEvent) { r.actions = append(r.actions, ev) }
+func (r *actionRecorder) OnFinish(ev FinishEvent) {}
+
+func TestRecoveryStrategySequenceIsRecorded(t *testing.T) {
+	summary := snapshot.Summary{URL: loginSummary.URL, Title: loginSummary.Title, Elements: []snapshot.Element{
+		{Index: 1, Role: "textbox", Text: "Email", Sel: "#email"},
+		{Index: 2, Role: "button", Text: "Sign in", Sel: "#login", BBox: "100,200,80,30"},
+	}}
+	notFound := errors.New("element not found: #login")
+	spy := &fakeToolbox{
+		Toolbox: tools.New(browser.NewFakeController(loginPage), nil),
+		fail:    map[string]error{"click_selector": notFound, "click_text": notFound, "click_role": notFound, "click_text_fuzzy": notFound},
+	}
+	client := llm.NewScriptedClient([]string{
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		finishDecision("signed in", true),
+	})
+	var logs strings.Builder
+	rec := &actionRecorder{}
+	orch := NewOrchestrator(Config{MaxSteps: 4, Quiet: true, Observer: rec}, NewPlanner(client), spy, zerolog.New(&logs))
+	result, err := orch.Run(context.Background(), Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+		return summary, nil
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("run = %+v, %v", result, err)
+	}
+
+	wantInvoked := []string{
+		"click_selector map[selector:#login]",
+		"click_text map[text:Sign in]",
+		"click_role map[name:Sign in role:button]",
+		"click_text_fuzzy map[text:Sign in]",
+		"click_coordinates map[x:140 y:215]",
+	}
+	if fmt.Sprint(spy.invoked) != fmt.Sprint(wantInvoked) {
+		t.Errorf("invoked %q, want %q", spy.invoked, wantInvoked)
+	}
+
+	wantAttempts := []RecoveryAttempt{
+		{Step: 1, Strategy: "alternative_click", ErrorType: "element_not_found"},
+		{Step: 1, Strategy: "fuzzy_text", ErrorType: "element_not_found"},
+		{Step: 1, Strategy: "click_coordinates", ErrorType: "element_not_found", Success: true},
+	}
+	if len(rec.actions) != 1 {
+		t.Fatalf("action events = %+v, want one", rec.actions)
+	}
+	ev := rec.actions[0]
+	if !ev.Recovered || ev.Strategy != "click_coordinates" || ev.Action != "click_coordinates" {
+		t.Errorf("action event = %+v, want recovered via click_coordinates", ev)
+	}
+	if fmt.Sprint(ev.RecoveryAttempts) != fmt.Sprint(wantAttempts) {
+		t.Errorf("recovery attempts = %+v, want %+v", ev.RecoveryAttempts, wantAttempts)
+	}
+
+	stats := result.Recovery
+	if stats == nil || stats.Attempts != 3 || stats.Successes != 1 {
+		t.Fatalf("recovery stats = %+v, want 3 attempts, 1 success", stats)
+	}
+	for name, want := range map[string]StrategyStats{
+		"alternative_click": {Attempts: 1},
+		"fuzzy_text":        {Attempts: 1},
+		"click_coordinates": {Attempts: 1, Successes: 1},
+	} {
+		if got := stats.ByStrategy[name]; got != want {
+			t.Errorf("ByStrategy[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+	if got := stats.ByErrorType["element_not_found"]; got != (StrategyStats{Attempts: 3, Successes: 1}) {
+		t.Errorf("ByErrorType[element_not_found] = %+v", got)
+	}
+
+	recovered := false
+	for _, h := range result.History {
+		recovered = recovered || (h.Action == "click_coordinates" && strings.HasPrefix(h.Result, "recovered via click_coordinates: "))
+	}
+	if !recovered {
+		t.Errorf("history = %+v, want the step recovered via click_coordinates", result.History)
+	}
+
+	// The run log names every attempt in order
+	var logged []string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"message":"recovery attempt"`) {
+			var entry struct {
+				Strategy string `json:"strategy"`
+				Success  bool   `json:"success"`
+			}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			logged = append(logged, fmt.Sprintf("%s:%v", entry.Strategy, entry.Success))
+		}
+	}
+	if want := "[alternative_click:false fuzzy_text:false click_coordinates:true]"; fmt.Sprint(logged) != want {
+		t.Errorf("logged attempts %v, want %s", logged, want)
+	}
+}
+
+// contextKiller closes the fake browser context once the planner picked the given step
+type contextKiller struct {
+	ctrl *browser.FakeController
+	step int
+}
+
+func (k contextKiller) OnStep(ev StepEvent) {
+	if ev.Step == k.step {
+		k.ctrl.CloseContext()
+	}
+}
+func (contextKiller) OnAction(ActionEvent) {}
+func (contextKiller) OnFinish(FinishEvent) {}
+
+func TestRecoveryAfterContextClosedMidRun(t *testing.T) {
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	ctrl := browser.NewFakeController(page)
+	result, err := runWithController(t, Config{Observer: contextKiller{ctrl: ctrl, step: 2}}, ctrl, loginSummary,
+		decision("type_text", map[string]any{"selector": "#email", "text": "user@example.com"}),
+		decision("click_selector", map[string]any{"selector": "#login"}),
+		decision("type_text", map[string]any{"selector": "#password", "text": "hunter2"}),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Steps != 4 {
+		t.Fatalf("result = %+v, want the run to go on after the context died", result)
+	}
+	if n := len(ctrl.CallsTo("Recreate")); n != 1 {
+		t.Errorf("Recreate called %d times, want once", n)
+	}
+	// The click failed on the dead context and succeeded on the recreated one
+	var sequence []string
+	for _, c := range ctrl.Calls() {
+		if c.Method == "WaitFor" || c.Method == "Recreate" || strings.HasPrefix(c.Method, "Click") {
+			sequence = append(sequence, c.Method)
+		}
+	}
+	if want := "[WaitFor Recreate WaitFor Click]"; fmt.Sprint(sequence) != want {
+		t.Errorf("calls %v, want %s", sequence, want)
+	}
+	if ctrl.Current().URL != loginPage.URL {
+		t.Errorf("page after recreate = %s, want %s", ctrl.Current().URL, loginPage.URL)
+	}
+	last := result.History[len(result.History)-1]
+	if !strings.HasPrefix(result.History[1].Result, "recovered via recreate_context: ") || last.Result != "filled #password" {
+		t.Errorf("history = %+v, want the click recovered via recreate_context and the next step run", result.History)
+	}
+	if result.Recovery == nil || result.Recovery.ByErrorType["context_closed"] != (StrategyStats{Attempts: 1, Successes: 1}) {
+		t.Errorf("recovery = %+v, want one successful context_closed recovery", result.Recovery)
+	}
+}
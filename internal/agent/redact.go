@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// redactedMark replaces secrets in recorded text
+const redactedMark = "[REDACTED]"
+
+// secretPattern is a credential format the model may echo from pages or earlier steps;
+// replacement may keep context via ${1}
+type secretPattern struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var secretPatterns = []secretPattern{
+	{regexp.MustCompile(`sk-(?:ant-|proj-)?[A-Za-z0-9_-]{16,}`), redactedMark},  // Anthropic / OpenAI keys
+	{regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`), redactedMark},                 // Google API keys
+	{regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/-]{16,}=*`), redactedMark}, // Authorization headers
+	// JSON password fields
+	{regexp.MustCompile(`(?i)("(?:password|passwd|пароль)"\s*:\s*")[^"]+`), "${1}" + redactedMark},
+}
+
+// redactor masks secrets in text written to run logs: known values (typed into password
+// fields during the run) and secretPatterns
+type redactor struct {
+	mu      sync.Mutex
+	secrets []string
+}
+
+// add remembers a secret value; very short values are skipped - masking them would garble text
+func (r *redactor) add(secret string) {
+	secret = strings.TrimSpace(secret)
+	if len([]rune(secret)) < 4 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.secrets {
+		if s == secret {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, secret)
+}
+
+// noteFill remembers the text of a fill into a password field (an index target or a
+// selector naming a password input)
+func (r *redactor) noteFill(dec Decision, target *snapshot.Element) {
+	password := target != nil && isPasswordField(*target)
+	if target == nil {
+		sel, _ := dec.ActionInput["selector"].(string)
+		password = strings.Contains(strings.ToLower(sel), "password")
+	}
+	if !password {
+		return
+	}
+	if text, ok := dec.ActionInput["text"].(string); ok {
+		r.add(text)
+	}
+}
+
+// redactInput copies an action input with every string value redacted; a typed secret
+// becomes exactly redactedMark, which replay asks the user to supply again
+func (r *redactor) redactInput(input map[string]any) map[string]any {
+	if input == nil {
+		return nil
+	}
+	out := make(map[string]any, len(input))
+	for k, v := range input {
+		if s, ok := v.(string); ok {
+			if r.isSecret(s) {
+				v = redactedMark
+			} else {
+				v = r.redact(s)
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// isSecret reports whether text is a remembered secret as a whole
+func (r *redactor) isSecret(text string) bool {
+	text = strings.TrimSpace(text)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.secrets {
+		if s == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) redact(text string) string {
+	if text == "" {
+		return text
+	}
+	r.mu.Lock()
+	for _, s := range r.secrets {
+		text = strings.ReplaceAll(text, s, redactedMark)
+	}
+	r.mu.Unlock()
+	for _, p := range secretPatterns {
+		text = p.re.ReplaceAllString(text, p.replacement)
+	}
+	return text
+}
+
+// isPasswordField reports whether a snapshot element is a password input
+func isPasswordField(el snapshot.Element) bool {
+	if el.Value == "***" || strings.Contains(el.Attr, "type:password") || strings.Contains(el.Sel, `type="password"`) {
+		return true
+	}
+	text := strings.ToLower(el.Text)
+	return strings.EqualFold(el.Role, "textbox") && (strings.Contains(text, "password") || strings.Contains(text, "пароль"))
+}
@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const replaySimilarLimit = 5 // Candidates listed in the diff report
+
+// Replayer re-executes a recorded trajectory (Config.TrajectoryPath) without the LLM
+type Replayer struct {
+	tools  tools.Toolbox
+	logger zerolog.Logger
+}
+
+// ReplayResult describes a finished replay.
+type ReplayResult struct {
+	Steps        int         // Recorded steps in the trajectory
+	Replayed     int         // Actions executed
+	Success      bool        // All actions applied and the recording finished
+	Unfinished   bool        // All actions applied, but the recording ended without finish
+	FinalMessage string      // Finish message of the recording
+	Diff         *ReplayDiff // Why the replay stopped, nil on success
+}
+
+// ReplayDiff reports the recorded step that could not be applied to the live page
+type ReplayDiff struct {
+	Step        int
+	Action      string
+	Reason      string
+	RecordedURL string
+	CurrentURL  string
+	Target      *snapshot.Element  // Recorded element of an index action
+	Similar     []snapshot.Element // Live elements that resemble the target
+	RawDecision string             // Recorded model output of the step (redacted), "" for old recordings
+}
+
+func (d *ReplayDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "replay stopped at step %d (%s): %s\n", d.Step, d.Action, d.Reason)
+	fmt.Fprintf(&b, "  recorded URL: %s\n", d.RecordedURL)
+	fmt.Fprintf(&b, "  current URL:  %s\n", d.CurrentURL)
+	if d.Target != nil {
+		fmt.Fprintf(&b, "  recorded target: [%d]%s:%q (selector: %s)\n", d.Target.Index, d.Target.Role, truncateText(d.Target.Text, 60), d.Target.Sel)
+	}
+	for _, el := range d.Similar {
+		fmt.Fprintf(&b, "  similar now: [%d]%s:%q (selector: %s)\n", el.Index, el.Role, truncateText(el.Text, 60), el.Sel)
+	}
+	if d.RawDecision != "" {
+		fmt.Fprintf(&b, "  recorded model output: %s\n", d.RawDecision)
+	}
+	return b.String()
+}
+
+func NewReplayer(toolbox tools.Toolbox, logger zerolog.Logger) *Replayer {
+	return &Replayer{tools: toolbox, logger: logger}
+}
+
+// LoadTrajectory reads a JSONL trajectory. The recorder appends, so a file may hold
+// several runs - only the last one is returned.
+func LoadTrajectory(path string) ([]TrajectoryStep, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open trajectory: %w", err)
+	}
+	defer f.Close()
+
+	var steps []TrajectoryStep
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var st TrajectoryStep
+		if err := json.Unmarshal(scanner.Bytes(), &st); err != nil {
+			return nil, fmt.Errorf("trajectory %s line %d: %w", path, line, err)
+		}
+		if len(steps) > 0 && st.Step <= steps[len(steps)-1].Step {
+			steps = steps[:0] // Next run starts
+		}
+		steps = append(steps, st)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read trajectory: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("trajectory %s has no steps", path)
+	}
+	return steps, nil
+}
+
+// Replay executes the recorded actions in order against fresh snapshots. Index actions are
+// re-resolved by the recorded element (indices drift between page loads); failed recorded
+// actions are skipped since the agent replanned after them.
+func (r *Replayer) Replay(ctx context.Context, steps []TrajectoryStep, snap summaryFunc) (ReplayResult, error) {
+	result := ReplayResult{Steps: len(steps)}
+	lastAction := ""
+	for _, st := range steps {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if st.Decision.Finish {
+			result.Success = true
+			result.FinalMessage = st.Decision.Message
+			return result, nil
+		}
+		if st.Action == "" || (st.Error != "" && !st.Recovered) {
+			continue
+		}
+
+		if lastAction == "navigate" {
+			if err := r.tools.WaitForStableDOM(ctx, 5*time.Second); err != nil {
+				r.logger.Debug().Err(err).Msg("wait for stable DOM after navigate")
+			}
+		}
+		ctxSnap, cancel := snapshot.WithDeadline(ctx, 5*time.Second)
+		summary, _ := snap(ctxSnap)
+		cancel()
+		r.tools.SetSnapshot(&summary)
+
+		diff := func(reason string) (ReplayResult, error) {
+			result.Diff = &ReplayDiff{
+				Step: st.Step, Action: st.Decision.Action, Reason: reason,
+				RecordedURL: st.URL, CurrentURL: summary.URL, Target: st.Target,
+				RawDecision: st.Decision.Raw,
+			}
+			if st.Target != nil {
+				result.Diff.Similar = similarElements(*st.Target, summary.Elements)
+			}
+			return result, fmt.Errorf("replay diverged at step %d: %s", st.Step, reason)
+		}
+
+		action, input := st.Action, st.Input
+		if st.Target != nil {
+			el, ambiguous := resolveRecordedElement(*st.Target, summary.Elements)
+			if ambiguous {
+				return diff(fmt.Sprintf("recorded element %s:%q is ambiguous - several elements on the page match it", st.Target.Role, truncateText(st.Target.Text, 60)))
+			}
+			if el == nil {
+				return diff(fmt.Sprintf("recorded element %s:%q not found on the page", st.Target.Role, truncateText(st.Target.Text, 60)))
+			}
+			action, input = replayIndexAction(st.Decision, el)
+		}
+		input, err := r.restoreSecrets(ctx, st.Step, action, input)
+		if err != nil {
+			return diff(err.Error())
+		}
+		r.logger.Info().Int("step", st.Step).Str("action", action).Str("url", summary.URL).Msg("replay")
+		if _, err := r.tools.Invoke(ctx, action, input); err != nil {
+			return diff(err.Error())
+		}
+		result.Replayed++
+		lastAction = action
+	}
+	// Recording ended without finish (step limit, error): the actions applied, but the
+	// recorded run itself never succeeded
+	result.Unfinished = true
+	return result, nil
+}
+
+// restoreSecrets asks the user for the values the recording redacted (passwords typed during
+// the run); a value redacted only in part can't be rebuilt and stops the replay
+func (r *Replayer) restoreSecrets(ctx context.Context, step int, action string, input map[string]any) (map[string]any, error) {
+	var out map[string]any
+	for k, v := range input {
+		s, ok := v.(string)
+		if !ok || !strings.Contains(s, redactedMark) {
+			continue
+		}
+		if s != redactedMark {
+			return nil, fmt.Errorf("recorded %s of %s is partly redacted and can't be replayed", k, action)
+		}
+		answer, err := r.tools.Ask(ctx, fmt.Sprintf("Replay step %d (%s) types a secret that was not recorded. Enter the %s to type:", step, action, k))
+		if err != nil {
+			return nil, fmt.Errorf("recorded %s of %s is redacted and no value was given: %w", k, action, err)
+		}
+		if strings.TrimSpace(answer) == "" {
+			return nil, fmt.Errorf("recorded %s of %s is redacted and no value was given", k, action)
+		}
+		if out == nil {
+			out = make(map[string]any, len(input))
+			for key, val := range input {
+				out[key] = val
+			}
+		}
+		out[k] = answer
+	}
+	if out == nil {
+		return input, nil
+	}
+	return out, nil
+}
+
+// resolveRecordedElement finds the live element matching a recorded one: same role and text,
+// ties broken by a unique selector match; a unique selector match is also the fallback.
+// ambiguous reports several candidates left - guessing would replay onto the wrong element.
+func resolveRecordedElement(target snapshot.Element, elements []snapshot.Element) (el *snapshot.Element, ambiguous bool) {
+	var matches []*snapshot.Element
+	for i := range elements {
+		el := &elements[i]
+		if strings.EqualFold(el.Role, target.Role) && strings.TrimSpace(el.Text) == strings.TrimSpace(target.Text) {
+			matches = append(matches, el)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if target.Sel == "" {
+			return nil, false
+		}
+		var bySel *snapshot.Element
+		for i := range elements {
+			if elements[i].Sel == target.Sel {
+				if bySel != nil {
+					return nil, true
+				}
+				bySel = &elements[i]
+			}
+		}
+		return bySel, false
+	case 1:
+		return matches[0], false
+	}
+	var bySel *snapshot.Element
+	for _, el := range matches {
+		if target.Sel != "" && el.Sel == target.Sel {
+			if bySel != nil {
+				return nil, true
+			}
+			bySel = el
+		}
+	}
+	return bySel, bySel == nil
+}
+
+// replayIndexAction rebuilds an index action for the resolved live element
+func replayIndexAction(dec TrajectoryDecision, el *snapshot.Element) (string, map[string]any) {
+	input := make(map[string]any, len(dec.Input))
+	for k, v := range dec.Input {
+		input[k] = v
+	}
+	input["index"] = el.Index
+	return dec.Action, input
+}
+
+// similarElements lists live elements of the same role whose text overlaps the target
+func similarElements(target snapshot.Element, elements []snapshot.Element) []snapshot.Element {
+	text := strings.ToLower(strings.TrimSpace(target.Text))
+	var similar []snapshot.Element
+	for _, el := range elements {
+		if len(similar) == replaySimilarLimit {
+			break
+		}
+		if !strings.EqualFold(el.Role, target.Role) {
+			continue
+		}
+		elText := strings.ToLower(strings.TrimSpace(el.Text))
+		if text == "" || elText == "" || strings.Contains(elText, text) || strings.Contains(text, elText) || el.Sel == target.Sel {
+			similar = append(similar, el)
+		}
+	}
+	return similar
+}
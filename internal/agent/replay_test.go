@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+func TestResolveRecordedElement(t *testing.T) {
+	target := snapshot.Element{Index: 4, Role: "button", Text: "Delete", Sel: "#delete-2"}
+	tests := []struct {
+		name      string
+		elements  []snapshot.Element
+		wantSel   string
+		ambiguous bool
+	}{
+		{"unique text", []snapshot.Element{{Index: 1, Role: "button", Text: "Delete", Sel: "#other"}}, "#other", false},
+		{"tie broken by selector", []snapshot.Element{
+			{Index: 1, Role: "button", Text: "Delete", Sel: "#delete-1"},
+			{Index: 2, Role: "button", Text: "Delete", Sel: "#delete-2"},
+		}, "#delete-2", false},
+		{"same text, no selector to tell apart", []snapshot.Element{
+			{Index: 4, Role: "button", Text: "Delete", Sel: "#delete-1"},
+			{Index: 5, Role: "button", Text: "Delete", Sel: "#delete-3"},
+		}, "", true},
+		{"selector fallback", []snapshot.Element{{Index: 7, Role: "button", Text: "Remove", Sel: "#delete-2"}}, "#delete-2", false},
+		{"duplicate selector", []snapshot.Element{
+			{Index: 1, Role: "link", Text: "a", Sel: "#delete-2"},
+			{Index: 2, Role: "link", Text: "b", Sel: "#delete-2"},
+		}, "", true},
+		{"gone", []snapshot.Element{{Index: 1, Role: "button", Text: "Archive", Sel: "#archive"}}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			el, ambiguous := resolveRecordedElement(target, tt.elements)
+			if ambiguous != tt.ambiguous {
+				t.Errorf("ambiguous = %v, want %v", ambiguous, tt.ambiguous)
+			}
+			sel := ""
+			if el != nil {
+				sel = el.Sel
+			}
+			if sel != tt.wantSel {
+				t.Errorf("resolved %q, want %q", sel, tt.wantSel)
+			}
+		})
+	}
+}
+
+// clickStep records a click_by_index on the inbox Delete button
+var clickStep = TrajectoryStep{
+	Step:     1,
+	URL:      inboxSummary.URL,
+	Target:   &inboxSummary.Elements[1],
+	Decision: TrajectoryDecision{Action: "click_by_index", Input: map[string]any{"index": float64(2)}},
+	Action:   "click_by_index",
+	Input:    map[string]any{"index": float64(2)},
+}
+
+func replay(t *testing.T, summary snapshot.Summary, steps ...TrajectoryStep) (ReplayResult, *browser.FakeController, error) {
+	t.Helper()
+	fake := browser.NewFakeController(inboxPage)
+	snap := func(context.Context) (snapshot.Summary, error) { return summary, nil }
+	res, err := NewReplayer(tools.New(fake, nil), zerolog.Nop()).Replay(context.Background(), steps, snap)
+	return res, fake, err
+}
+
+func TestReplayFinishedRecording(t *testing.T) {
+	finish := TrajectoryStep{Step: 2, Decision: TrajectoryDecision{Action: "finish", Finish: true, Message: "deleted"}}
+	res, fake, err := replay(t, inboxSummary, clickStep, finish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Unfinished || res.Replayed != 1 || res.FinalMessage != "deleted" {
+		t.Errorf("result = %+v, want a successful replay of one action", res)
+	}
+	if len(fake.CallsTo("Click")) != 1 {
+		t.Errorf("clicks = %v, want one", fake.CallsTo("Click"))
+	}
+}
+
+func TestReplayUnfinishedRecordingIsNotSuccess(t *testing.T) {
+	res, _, err := replay(t, inboxSummary, clickStep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || !res.Unfinished || res.Replayed != 1 {
+		t.Errorf("result = %+v, want the action replayed but no success", res)
+	}
+}
+
+func TestReplayStopsOnAmbiguousElement(t *testing.T) {
+	twoButtons := inboxSummary
+	twoButtons.Elements = []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Delete", Sel: "#delete-1"},
+		{Index: 2, Role: "button", Text: "Delete", Sel: "#delete-2"},
+	}
+	res, fake, err := replay(t, twoButtons, clickStep)
+	if err == nil || res.Diff == nil {
+		t.Fatalf("replay onto two matching buttons succeeded: %+v", res)
+	}
+	if !strings.Contains(res.Diff.Reason, "ambiguous") {
+		t.Errorf("diff reason %q, want it marked ambiguous", res.Diff.Reason)
+	}
+	if len(res.Diff.Similar) != 2 {
+		t.Errorf("diff lists %d similar elements, want both candidates", len(res.Diff.Similar))
+	}
+	if n := len(fake.CallsTo("Click")); n != 0 {
+		t.Errorf("%d clicks on an ambiguous target, want none", n)
+	}
+}
@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent/prompts"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// RiskLevel is the verdict of a RiskClassifier
+type RiskLevel string
+
+const (
+	RiskSafe              RiskLevel = "safe"
+	RiskNeedsConfirmation RiskLevel = "needs-confirmation"
+	RiskForbidden         RiskLevel = "forbidden"
+)
+
+// SeverityClassified is the confirmation tier of actions flagged by the classifier without a keyword
+const SeverityClassified = "classified"
+
+// riskDowngradable are the generic keywords a "safe" verdict may lift: they over-trigger on
+// harmless form steps ("Подтвердить email"). Every other keyword match stands whatever the
+// classifier says - its answer can be steered by page text.
+var riskDowngradable = map[string]bool{"submit": true, "подтвердить": true, "confirm": true}
+
+// riskContextElements is how many snapshot neighbours on each side describe the target
+const riskContextElements = 3
+
+// RiskQuery describes an action target for classification
+type RiskQuery struct {
+	Task    string
+	URL     string
+	Action  string
+	Role    string
+	Text    string
+	Context []string // Neighbouring snapshot elements ("[12]button:\"Оплатить\"")
+}
+
+// RiskClassifier rates actions whose keyword check is ambiguous (Config.RiskClassifier).
+// Usage is the LLM usage of the call, counted into the run totals (-max-cost).
+type RiskClassifier interface {
+	Classify(ctx context.Context, q RiskQuery) (RiskLevel, llm.Usage, error)
+}
+
+type llmRiskClassifier struct {
+	llm llm.Client
+}
+
+// NewLLMRiskClassifier asks the model with a short dedicated prompt
+func NewLLMRiskClassifier(client llm.Client) RiskClassifier {
+	return &llmRiskClassifier{llm: client}
+}
+
+func (c *llmRiskClassifier) Classify(ctx context.Context, q RiskQuery) (RiskLevel, llm.Usage, error) {
+	p := prompts.RiskPrompt(languageName(DetectLanguage(q.Task)), prompts.RiskTarget(q))
+	resp, err := c.llm.Generate(ctx, llm.Request{
+		System:      p.System,
+		Messages:    []llm.Message{{Role: "user", Content: p.User}},
+		Temperature: 0,
+		MaxTokens:   10,
+	})
+	if err != nil {
+		return "", resp.Usage, err
+	}
+	level, err := parseRiskLevel(resp.Text)
+	return level, resp.Usage, err
+}
+
+// parseRiskLevel accepts exactly one of the verdict words ("unsafe" is not "safe")
+func parseRiskLevel(text string) (RiskLevel, error) {
+	word := strings.ToLower(strings.Trim(firstLine(text), " \t\"'`.,:;!*"))
+	switch level := RiskLevel(word); level {
+	case RiskSafe, RiskNeedsConfirmation, RiskForbidden:
+		return level, nil
+	}
+	return "", fmt.Errorf("unexpected risk verdict %q", truncateText(text, 40))
+}
+
+// applyRiskLevel combines the keyword match with a classifier verdict. The classifier only
+// escalates: needs-confirmation adds a confirmation to an unmatched action, safe lifts nothing
+// but the riskDowngradable keywords.
+func applyRiskLevel(level RiskLevel, keyword, severity string) (string, string) {
+	switch level {
+	case RiskSafe:
+		if riskDowngradable[keyword] {
+			return "", ""
+		}
+	case RiskNeedsConfirmation, RiskForbidden:
+		if keyword == "" {
+			return "risk:" + string(level), SeverityClassified
+		}
+	}
+	return keyword, severity
+}
+
+// riskQuery resolves the action target in the snapshot. ok is false for actions the
+// classifier does not rate (only clicks and fills have a target worth rating).
+func riskQuery(task string, dec Decision, summary snapshot.Summary) (RiskQuery, bool) {
+	q := RiskQuery{Task: task, URL: summary.URL, Action: dec.ActionName}
+	pos := -1
+	switch dec.ActionName {
+	case "click_by_index", "fill_by_index", "fill_and_submit":
+		index := optionalIndex(dec.ActionInput)
+		for i := range summary.Elements {
+			if summary.Elements[i].Index == index {
+				pos = i
+				break
+			}
+		}
+	case "click_selector", "fill":
+		sel, _ := dec.ActionInput["selector"].(string)
+		for i := range summary.Elements {
+			if sel != "" && summary.Elements[i].Sel == sel {
+				pos = i
+				break
+			}
+		}
+	case "click_text":
+		q.Text, _ = dec.ActionInput["text"].(string)
+	case "click_role":
+		q.Role, _ = dec.ActionInput["role"].(string)
+		q.Text, _ = dec.ActionInput["name"].(string)
+	default:
+		return RiskQuery{}, false
+	}
+	if pos >= 0 {
+		el := summary.Elements[pos]
+		q.Role, q.Text = el.Role, el.Text
+		for i := pos - riskContextElements; i <= pos+riskContextElements; i++ {
+			if i < 0 || i >= len(summary.Elements) || i == pos {
+				continue
+			}
+			n := summary.Elements[i]
+			q.Context = append(q.Context, fmt.Sprintf("[%d]%s:%q", n.Index, n.Role, truncateText(n.Text, 60)))
+		}
+	}
+	return q, q.Text != "" || q.Role != ""
+}
+
+// riskAmbiguous reports whether the keyword check alone can't be trusted: a generic-tier
+// keyword (over-triggers on "подтвердить") or a button click without any keyword
+// (purchases labelled differently). Financial keywords stay on the fast path.
+func riskAmbiguous(severity string, q RiskQuery) bool {
+	switch severity {
+	case SeverityGeneric:
+		return true
+	case "":
+		return strings.HasPrefix(q.Action, "click") && strings.EqualFold(q.Role, "button")
+	}
+	return false
+}
+
+// classifyRisk consults Config.RiskClassifier, caching verdicts per (URL, element text).
+// ok is false when no verdict is available and the keyword result applies.
+func (o *Orchestrator) classifyRisk(ctx context.Context, step int, q RiskQuery) (RiskLevel, bool) {
+	key := q.URL + "\x00" + q.Text
+	if level, cached := o.riskCache[key]; cached {
+		return level, true
+	}
+	level, usage, err := o.cfg.RiskClassifier.Classify(ctx, q)
+	o.recordUsage(step, usage)
+	if err != nil {
+		o.logger.Warn().Err(err).Str("text", truncateText(q.Text, 40)).Msg("risk classifier failed - using keyword check")
+		return "", false
+	}
+	o.logger.Info().Str("text", truncateText(q.Text, 40)).Str("risk", string(level)).Msg("risk classified")
+	o.riskCache[key] = level
+	return level, true
+}
+
+func optionalIndex(input map[string]any) int {
+	switch v := input["index"].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package agent
+
+import "testing"
+
+func TestParseRiskLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RiskLevel
+		wantErr bool
+	}{
+		{in: "safe", want: RiskSafe},
+		{in: " Safe.\n", want: RiskSafe},
+		{in: "needs-confirmation", want: RiskNeedsConfirmation},
+		{in: "FORBIDDEN", want: RiskForbidden},
+		{in: "`forbidden`\nbecause the task does not ask for it", want: RiskForbidden},
+		{in: "unsafe", wantErr: true},
+		{in: "not safe", wantErr: true},
+		{in: "safe enough", wantErr: true},
+		{in: "confirm", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRiskLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseRiskLevel(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyRiskLevelOnlyEscalates(t *testing.T) {
+	tests := []struct {
+		name              string
+		level             RiskLevel
+		keyword, severity string
+		wantKeyword       string
+		wantSeverity      string
+	}{
+		{"safe keeps delete", RiskSafe, "delete", SeverityGeneric, "delete", SeverityGeneric},
+		{"safe keeps удалить", RiskSafe, "удалить", SeverityGeneric, "удалить", SeverityGeneric},
+		{"safe keeps unsubscribe", RiskSafe, "unsubscribe", SeverityGeneric, "unsubscribe", SeverityGeneric},
+		{"safe keeps cancel", RiskSafe, "cancel", SeverityGeneric, "cancel", SeverityGeneric},
+		{"safe keeps financial", RiskSafe, "pay", SeverityFinancial, "pay", SeverityFinancial},
+		{"safe lifts submit", RiskSafe, "submit", SeverityGeneric, "", ""},
+		{"safe lifts подтвердить", RiskSafe, "подтвердить", SeverityGeneric, "", ""},
+		{"safe lifts confirm", RiskSafe, "confirm", SeverityGeneric, "", ""},
+		{"safe on unmatched", RiskSafe, "", "", "", ""},
+		{"needs-confirmation adds", RiskNeedsConfirmation, "", "", "risk:needs-confirmation", SeverityClassified},
+		{"needs-confirmation keeps keyword", RiskNeedsConfirmation, "submit", SeverityGeneric, "submit", SeverityGeneric},
+	}
+	for _, tt := range tests {
+		keyword, severity := applyRiskLevel(tt.level, tt.keyword, tt.severity)
+		if keyword != tt.wantKeyword || severity != tt.wantSeverity {
+			t.Errorf("%s: applyRiskLevel(%s, %q) = %q/%q, want %q/%q", tt.name, tt.level, tt.keyword, keyword, severity, tt.wantKeyword, tt.wantSeverity)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const screenshotIndexFile = "index.json"
+
+// stepShot is one entry of the screenshot archive index
+type stepShot struct {
+	Step   int    `json:"step"`
+	Action string `json:"action"`
+	URL    string `json:"url"`
+	File   string `json:"file,omitempty"`
+	Error  string `json:"error,omitempty"` // Action error or capture error
+}
+
+// stepArchive saves a screenshot after every action into Config.ScreenshotDir
+// and keeps index.json in sync, so a failed run can be replayed visually
+type stepArchive struct {
+	dir     string
+	entries []stepShot
+}
+
+func newStepArchive(dir string) (*stepArchive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create screenshot dir: %w", err)
+	}
+	return &stepArchive{dir: dir}, nil
+}
+
+// capture takes the step screenshot; failures are recorded in the index, never returned
+func (a *stepArchive) capture(ctx context.Context, toolbox tools.Toolbox, step int, action, url string, actionErr error) error {
+	entry := stepShot{Step: step, Action: action, URL: url}
+	if actionErr != nil {
+		entry.Error = actionErr.Error()
+	}
+	file := fmt.Sprintf("step_%03d.png", step)
+	if _, err := toolbox.Invoke(ctx, "screenshot", map[string]any{"path": filepath.Join(a.dir, file)}); err != nil {
+		entry.Error = joinErrors(entry.Error, "screenshot: "+err.Error())
+	} else {
+		entry.File = file
+	}
+	a.entries = append(a.entries, entry)
+
+	data, err := json.MarshalIndent(a.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal screenshot index: %w", err)
+	}
+	return os.WriteFile(filepath.Join(a.dir, screenshotIndexFile), data, 0o644)
+}
+
+func joinErrors(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "; " + b
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const (
+	// inventedSelectorThreshold invented selectors per run before the planner gets corrective guidance
+	inventedSelectorThreshold = 3
+	// selectorHintMatches caps the elements suggested in place of an invented selector
+	selectorHintMatches = 3
+)
+
+// selectorUsage counts selector- vs index-based actions of a run. Some models keep
+// emitting click_selector with invented selectors despite the prompt - the top source
+// of selector_parse_error.
+type selectorUsage struct {
+	selector int
+	index    int
+	invented int
+	// Last selector not found among snapshot elements
+	lastInvented string
+}
+
+// record counts an action about to run against the snapshot it was planned on
+func (u *selectorUsage) record(dec Decision, summary snapshot.Summary) {
+	switch dec.ActionName {
+	case "click_by_index", "fill_by_index", "read_by_index":
+		u.index++
+	case "fill_and_submit", "set_date":
+		if _, ok := dec.ActionInput["index"]; ok {
+			u.index++
+		}
+	case "click_selector", "fill":
+		u.selector++
+		sel, _ := dec.ActionInput["selector"].(string)
+		if sel != "" && !tools.SelectorInSnapshot(sel, summary.Elements) {
+			u.invented++
+			u.lastInvented = sel
+		}
+	}
+}
+
+// guidance is the corrective block for the planner once invented selectors pass the threshold
+// and outnumber index actions; it lists indices of the elements the last selector most
+// likely meant. "" while selectors are fine.
+func (u *selectorUsage) guidance(summary snapshot.Summary) string {
+	if u.invented < inventedSelectorThreshold || u.invented <= u.index {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nSELECTORS: %d of your %d selectors were not in the snapshot (index actions: %d). Stop inventing CSS selectors - use click_by_index / fill_by_index with indices from the elements list.\n",
+		u.invented, u.selector, u.index)
+	if matches := tools.MatchSelector(u.lastInvented, summary.Elements, selectorHintMatches); len(matches) > 0 {
+		fmt.Fprintf(&b, "Elements matching your last selector %q:\n", truncateText(u.lastInvented, 80))
+		for _, el := range matches {
+			b.WriteString(tools.DescribeMatch(el) + "\n")
+		}
+	}
+	return b.String()
+}
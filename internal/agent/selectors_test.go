@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestSelectorGuidance(t *testing.T) {
+	summary := snapshot.Summary{URL: "https://shop.example.com/", Elements: []snapshot.Element{
+		{Index: 4, Role: "link", Text: "Корзина", Sel: "a.header-cart"},
+		{Index: 5, Role: "button", Text: "Войти", Sel: "button.login-btn"},
+	}}
+	click := func(sel string) Decision {
+		return Decision{ActionName: "click_selector", ActionInput: map[string]any{"selector": sel}}
+	}
+	byIndex := Decision{ActionName: "click_by_index", ActionInput: map[string]any{"index": 4}}
+
+	var u selectorUsage
+	u.record(click("a.header-cart"), summary) // From the snapshot
+	u.record(click(`a:has-text("Корзина")`), summary)
+	u.record(click(`button:contains('Войти')`), summary)
+	if g := u.guidance(summary); g != "" {
+		t.Fatalf("guidance below the threshold: %q", g)
+	}
+	u.record(click(`//button[text()='Войти']`), summary)
+	g := u.guidance(summary)
+	for _, want := range []string{
+		"SELECTORS: 3 of your 4 selectors were not in the snapshot (index actions: 0)",
+		`Elements matching your last selector "//button[text()='Войти']":` + "\n" + `[5] button "Войти"`,
+	} {
+		if !strings.Contains(g, want) {
+			t.Fatalf("guidance = %q, missing %q", g, want)
+		}
+	}
+
+	// Index actions outnumbering invented selectors mean the planner already adapted
+	for i := 0; i < 3; i++ {
+		u.record(byIndex, summary)
+	}
+	if g := u.guidance(summary); g != "" {
+		t.Fatalf("guidance after the planner switched to indices: %q", g)
+	}
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// textSimilarity returns normalized similarity of two UI texts in [0, 1].
+// Uses the best of edit-distance similarity and token overlap, so both typos
+// ("Удалть" vs "Удалить") and reordered words score high, while
+// "Delete" vs "Delete all" stays well below the recovery threshold. A word negated by a
+// prefix ("Archive" vs "Unarchive") scores 0: it is one edit pair away and means the opposite.
+func textSimilarity(a, b string) float64 {
+	na, nb := normalizeText(a), normalizeText(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	if negatedPair(strings.Fields(na), strings.Fields(nb)) {
+		return 0
+	}
+	ra, rb := []rune(na), []rune(nb)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	editScore := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	tokenScore := tokenOverlap(strings.Fields(na), strings.Fields(nb))
+	if tokenScore > editScore {
+		return tokenScore
+	}
+	return editScore
+}
+
+// normalizeText lowercases text, drops punctuation and collapses whitespace
+func normalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// negationPrefixes turn a UI verb or state into its opposite
+var negationPrefixes = []string{"un", "dis", "de", "non", "не", "раз", "от"}
+
+// negatedPair reports whether a word of one text is a word of the other with a negation prefix
+func negatedPair(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			for _, p := range negationPrefixes {
+				if x == p+y || y == p+x {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// tokenOverlap is Jaccard similarity of two token sets
+func tokenOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	setA := make(map[string]bool, len(a))
+	for _, t := range a {
+		setA[t] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, t := range b {
+		setB[t] = true
+	}
+	common := 0
+	for t := range setA {
+		if setB[t] {
+			common++
+		}
+	}
+	union := len(setA) + len(setB) - common
+	return float64(common) / float64(union)
+}
+
+// levenshtein computes edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		close bool
+	}{
+		{"Delete", "delete", true},
+		{"Удалть", "Удалить", true},
+		{"Save draft", "Draft save", true},
+		{"Sign in", "Sign  in!", true},
+		{"Delete", "Delete all", false},
+		{"Delete", "Delete account", false},
+		{"Remove item", "Remove all items", false},
+		{"Pay", "Pay later", false},
+		{"Archive", "Unarchive", false},
+		{"Отправить", "Отправить всем", false},
+		{"Save", "", false},
+	}
+	for _, tt := range tests {
+		score := textSimilarity(tt.a, tt.b)
+		if (score >= similarElementThreshold) != tt.close {
+			t.Errorf("textSimilarity(%q, %q) = %.2f, want close=%v (threshold %.2f)", tt.a, tt.b, score, tt.close, similarElementThreshold)
+		}
+	}
+}
+
+func TestFindSimilarElementRejectsNearMisses(t *testing.T) {
+	// The failed click targeted a "Delete" button that is gone from the live page
+	failed := Decision{ActionName: "click_role", ActionInput: map[string]any{"role": "button", "name": "Delete"}}
+	tests := []struct {
+		name      string
+		candidate snapshot.Element
+		wantSel   string
+	}{
+		{"same role, same text", snapshot.Element{Index: 2, Role: "button", Text: "Delete", Sel: "#delete-new"}, "#delete-new"},
+		{"typo", snapshot.Element{Index: 2, Role: "button", Text: "Delet", Sel: "#delete-new"}, "#delete-new"},
+		{"broader action", snapshot.Element{Index: 2, Role: "button", Text: "Delete all", Sel: "#delete-all"}, ""},
+		{"different target", snapshot.Element{Index: 2, Role: "button", Text: "Delete account", Sel: "#delete-account"}, ""},
+		{"other role", snapshot.Element{Index: 2, Role: "link", Text: "Delete", Sel: "#delete-link"}, ""},
+		{"unrelated", snapshot.Element{Index: 2, Role: "button", Text: "Cancel", Sel: "#cancel"}, ""},
+	}
+	o := &Orchestrator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alt, score := o.findSimilarElement(failed, snapshot.Summary{Elements: []snapshot.Element{tt.candidate}})
+			got, _ := alt.input["selector"].(string)
+			if got != tt.wantSel {
+				t.Errorf("recovered onto %q (score %.2f), want %q", got, score, tt.wantSel)
+			}
+		})
+	}
+}
+
+func TestRecoveryGoesThroughConfirmation(t *testing.T) {
+	placed := snapshot.Summary{URL: inboxSummary.URL, Title: inboxSummary.Title, Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Invoice March", Sel: "#mail-3", BBox: "10,10,200,20"},
+		{Index: 2, Role: "button", Text: "Delete", Sel: "#delete-3", BBox: "300,10,60,20"},
+	}}
+	byIndex := decision("click_by_index", map[string]any{"index": 2})
+	// click_by_index falls back to the bbox itself; the strategy is reached from a selector click
+	bySelector := decision("click_selector", map[string]any{"selector": "#delete-3"})
+	tests := []struct {
+		strategy RecoveryStrategy
+		action   string
+		summary  snapshot.Summary
+		click    string
+	}{
+		{similarElementStrategy{}, "click_selector", inboxSummary, byIndex},
+		{fuzzyTextStrategy{}, "click_text_fuzzy", inboxSummary, byIndex},
+		{alternativeClickStrategy{}, "click_text", inboxSummary, byIndex},
+		{coordinatesStrategy{}, "click_coordinates", placed, bySelector},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.Name(), func(t *testing.T) {
+			var asked []string
+			cfg := Config{
+				MaxSteps:           3,
+				RecoveryStrategies: []RecoveryStrategy{tt.strategy},
+				ConfirmationPolicy: ConfirmationPolicy{Callback: func(_ context.Context, action string, _ map[string]any) (bool, error) {
+					asked = append(asked, action)
+					return len(asked) == 1, nil // The planner's click is approved, nothing after it
+				}},
+			}
+			ctrl := browser.NewFakeController(inboxPage)
+			ctrl.FailNext("Click", errors.New("element not found"))
+			result, err := runWithController(t, cfg, ctrl, tt.summary,
+				tt.click,
+				finishDecision("could not delete", false),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(asked) != 2 || asked[1] != tt.action {
+				t.Fatalf("confirmation asked for %v, want the recovery %s confirmed too", asked, tt.action)
+			}
+			n := 0
+			for _, method := range []string{"Click", "ClickText", "ClickRole", "ClickByTextFuzzy", "ClickByCoordinates"} {
+				n += len(ctrl.CallsTo(method))
+			}
+			if n != 1 {
+				t.Errorf("%d clicks, want only the failed original one: %v", n, ctrl.Calls())
+			}
+			if result.Recovery == nil || result.Recovery.Successes != 0 {
+				t.Errorf("recovery = %+v, want one declined attempt", result.Recovery)
+			}
+		})
+	}
+}
+
+func TestAlternativesNeverClickUnnamedRole(t *testing.T) {
+	summary := snapshot.Summary{Elements: []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Archive", Sel: "#archive"},
+		{Index: 2, Role: "button", Text: "Delete", Sel: "#delete"},
+	}}
+	tests := []struct {
+		name string
+		dec  Decision
+		want []string // "action input" of every alternative, in order
+	}{
+		{"named role", Decision{ActionName: "click_role", ActionInput: map[string]any{"role": "button", "name": "Delete"}}, []string{`click_text map[text:Delete]`}},
+		{"legacy label", Decision{ActionName: "click_role", ActionInput: map[string]any{"role": "button", "label": "Delete"}}, []string{`click_text map[text:Delete]`}},
+		{"unnamed role", Decision{ActionName: "click_role", ActionInput: map[string]any{"role": "button"}}, nil},
+		{"selector with text", Decision{ActionName: "click_selector", ActionInput: map[string]any{"selector": "#delete"}}, []string{`click_text map[text:Delete]`, `click_role map[name:Delete role:button]`}},
+		{"unknown selector", Decision{ActionName: "click_selector", ActionInput: map[string]any{"selector": "[role='button']"}}, nil},
+		{"empty text", Decision{ActionName: "click_text", ActionInput: map[string]any{"text": ""}}, nil},
+	}
+	o := &Orchestrator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, alt := range o.generateAlternatives(tt.dec, summary) {
+				got = append(got, fmt.Sprintf("%s %v", alt.action, alt.input))
+				if alt.action == "click_role" && alt.input["name"] == nil {
+					t.Errorf("unnamed click_role alternative %v", alt.input)
+				}
+				if sel, _ := alt.input["selector"].(string); strings.HasPrefix(sel, "[role=") {
+					t.Errorf("bare role selector alternative %q", sel)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("alternatives = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
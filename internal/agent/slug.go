@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent/prompts"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+const (
+	// slugWords is how many significant words of the task go into its slug and fallback title
+	slugWords = 6
+	// maxSlugLen keeps slugs usable as directory and file names
+	maxSlugLen = 60
+	// maxTitleRunes bounds titles produced by a TaskTitler
+	maxTitleRunes = 80
+	// slugPlaceholder in artifact paths is replaced by the task slug (ExpandSlug)
+	slugPlaceholder = "{slug}"
+)
+
+// slugStopWords are skipped when picking the significant words of a task
+var slugStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "and": true, "or": true, "in": true,
+	"on": true, "at": true, "for": true, "with": true, "from": true, "by": true, "please": true,
+	"и": true, "в": true, "во": true, "на": true, "с": true, "со": true, "к": true, "по": true,
+	"из": true, "за": true, "для": true, "о": true, "об": true, "а": true, "но": true, "или": true,
+	"мне": true, "пожалуйста": true,
+}
+
+// translit maps Cyrillic letters to Latin (Russian passport style, ъ/ь dropped)
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g", // Ukrainian
+}
+
+// TaskTitler writes a short human title for a task (artifact listings, logs); usage is
+// counted into the run totals (-max-cost)
+type TaskTitler interface {
+	Title(ctx context.Context, task string) (string, llm.Usage, error)
+}
+
+type llmTaskTitler struct {
+	llm llm.Client
+}
+
+// NewLLMTaskTitler asks the model for a title of at most five words (one short call per run)
+func NewLLMTaskTitler(client llm.Client) TaskTitler {
+	return &llmTaskTitler{llm: client}
+}
+
+func (t *llmTaskTitler) Title(ctx context.Context, task string) (string, llm.Usage, error) {
+	p := prompts.TitlePrompt(languageName(DetectLanguage(task)), cutRunes(task, 2000))
+	resp, err := t.llm.Generate(ctx, llm.Request{
+		System:      p.System,
+		Messages:    []llm.Message{{Role: "user", Content: p.User}},
+		Temperature: 0,
+		MaxTokens:   30,
+	})
+	if err != nil {
+		return "", resp.Usage, err
+	}
+	title := strings.Trim(firstLine(resp.Text), " \"'«»`.")
+	return truncateText(title, maxTitleRunes), resp.Usage, nil
+}
+
+// TaskSlug is a deterministic filesystem-safe name for a task: its first significant words,
+// transliterated and kebab-cased ("Найди билеты в Казань на 5 мая" -> "naidi-bilety-kazan-5-maia");
+// "task" when nothing usable remains (emoji, punctuation)
+func TaskSlug(description string) string {
+	var parts []string
+	for _, word := range significantWords(description) {
+		if part := slugPart(word); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	slug := ""
+	for _, part := range parts {
+		next := part
+		if slug != "" {
+			next = slug + "-" + part
+		}
+		if len(next) > maxSlugLen {
+			if slug == "" {
+				slug = strings.TrimRight(part[:maxSlugLen], "-")
+			}
+			break
+		}
+		slug = next
+	}
+	if slug == "" {
+		return "task"
+	}
+	return slug
+}
+
+// fallbackTitle is the first significant words of the task in its own script
+func fallbackTitle(description string) string {
+	title := strings.Join(significantWords(description), " ")
+	if title == "" {
+		return truncateText(firstLine(description), maxTitleRunes)
+	}
+	return truncateText(title, maxTitleRunes)
+}
+
+// significantWords are the first slugWords words of the task without stop words and
+// surrounding punctuation; URLs are reduced to their host
+func significantWords(description string) []string {
+	var picked []string
+	for _, w := range strings.Fields(description) {
+		if u, err := url.Parse(w); err == nil && u.Host != "" {
+			w = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+		w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if w == "" || slugStopWords[strings.ToLower(w)] {
+			continue
+		}
+		picked = append(picked, w)
+		if len(picked) == slugWords {
+			break
+		}
+	}
+	return picked
+}
+
+// slugPart lower-cases and transliterates a word; other characters become dashes
+// ("example.com" -> "example-com"), so the result is only [a-z0-9-]
+func slugPart(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if latin, ok := translit[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(collapseDashes(b.String()), "-")
+}
+
+func collapseDashes(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return s
+}
+
+// ExpandSlug replaces {slug} in an artifact path with the task slug
+func ExpandSlug(path, slug string) string {
+	return strings.ReplaceAll(path, slugPlaceholder, slug)
+}
+
+// nameTask fills Task.Slug and Task.Title when the caller left them empty; the titler is
+// best effort - its failure falls back to the first words of the task
+func (o *Orchestrator) nameTask(ctx context.Context, task *Task) {
+	if task.Slug == "" {
+		task.Slug = TaskSlug(task.Description)
+	}
+	if task.Title != "" {
+		return
+	}
+	if o.cfg.TaskTitler != nil {
+		title, usage, err := o.cfg.TaskTitler.Title(ctx, task.Description)
+		o.recordUsage(0, usage)
+		if err != nil {
+			o.logger.Warn().Err(err).Msg("task title failed, using the first words of the task")
+		} else if title != "" {
+			task.Title = title
+			return
+		}
+	}
+	task.Title = fallbackTitle(task.Description)
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func TestTaskSlug(t *testing.T) {
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"Найди билеты в Казань на 5 мая", "naidi-bilety-kazan-5-maia"},
+		{"Щёлкни «Подъезд» и съешь жёлтую вишню", "shchelkni-podezd-sesh-zheltuiu-vishniu"},
+		{"Знайди їжу та ґанок", "znaidi-izhu-ta-ganok"},
+		{"Please open https://www.example.com/login and sign in", "open-example-com-sign"}, // URLs are reduced to their host
+		{"Купи iPhone 15 Pro на ozon.ru!!!", "kupi-iphone-15-pro-ozon-ru"},
+		{"🚀🔥 закажи пиццу 🍕 с доставкой", "zakazhi-pitstsu-dostavkoi"},
+		{"order 🍕pizza🍕 now", "order-pizza-now"},
+		{"🚀🔥🍕", "task"},
+		{"!!! ??? ...", "task"},
+		{"", "task"},
+		{"   \n\t ", "task"},
+		{"и в на с по", "task"},
+		{"東京の天気", "task"},
+		{"C++ & Go: compare (2024)", "c-go-compare-2024"},
+		{strings.Repeat("а", 100), strings.Repeat("a", maxSlugLen)},
+		{"one two three four five six seven eight", "one-two-three-four-five-six"},
+	}
+	for _, tt := range tests {
+		got := TaskSlug(tt.task)
+		if got != tt.want {
+			t.Errorf("TaskSlug(%q) = %q, want %q", tt.task, got, tt.want)
+		}
+		if !slugPattern.MatchString(got) || len(got) > maxSlugLen {
+			t.Errorf("TaskSlug(%q) = %q is not a safe file name", tt.task, got)
+		}
+	}
+}
+
+func TestTaskSlugStopsAtTheLengthLimit(t *testing.T) {
+	long := "Сравни характеристики холодильников производителей Либхерр Бош Самсунг"
+	got := TaskSlug(long)
+	if len(got) > maxSlugLen || strings.HasSuffix(got, "-") || !strings.HasPrefix(got, "sravni-kharakteristiki-") {
+		t.Fatalf("TaskSlug(%q) = %q (%d bytes)", long, got, len(got))
+	}
+}
+
+func TestTranslitCoversRussianAlphabet(t *testing.T) {
+	for _, r := range "абвгдеёжзийклмнопрстуфхцчшщъыьэюя" {
+		latin, ok := translit[r]
+		if !ok {
+			t.Errorf("no transliteration for %q", r)
+		}
+		if !regexp.MustCompile(`^[a-z]*$`).MatchString(latin) {
+			t.Errorf("%q -> %q is not lowercase Latin", r, latin)
+		}
+	}
+}
+
+func TestFallbackTitle(t *testing.T) {
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"Найди, пожалуйста, билеты в Казань на 5 мая", "Найди билеты Казань 5 мая"},
+		{"🚀🔥🍕", "🚀🔥🍕"},
+		{"🚀🔥\nsecond line", "second line"},
+	}
+	for _, tt := range tests {
+		if got := fallbackTitle(tt.task); got != tt.want {
+			t.Errorf("fallbackTitle(%q) = %q, want %q", tt.task, got, tt.want)
+		}
+	}
+	long := fallbackTitle(strings.Repeat("🍕", 200))
+	if !utf8.ValidString(long) || utf8.RuneCountInString(long) != maxTitleRunes+len("...") {
+		t.Errorf("emoji title = %q (%d runes)", long, utf8.RuneCountInString(long))
+	}
+}
+
+func TestLLMTaskTitlerCleansTheAnswer(t *testing.T) {
+	client := llm.NewScriptedClient([]string{"«Билеты в Казань».\nexplanation follows"})
+	title, _, err := NewLLMTaskTitler(client).Title(context.Background(), "Найди билеты в Казань на 5 мая")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Билеты в Казань" {
+		t.Fatalf("title = %q", title)
+	}
+}
+
+func TestExpandSlug(t *testing.T) {
+	if got := ExpandSlug("runs/{slug}/steps-{slug}.jsonl", "naidi-bilety"); got != "runs/naidi-bilety/steps-naidi-bilety.jsonl" {
+		t.Fatalf("ExpandSlug = %q", got)
+	}
+	if got := ExpandSlug("runs/fixed.jsonl", "x"); got != "runs/fixed.jsonl" {
+		t.Fatalf("ExpandSlug without placeholder = %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultPlannerName identifies the unified planner in logs
+const defaultPlannerName = "planner"
+
+// SubAgent is a specialized planner for a class of tasks (e.g. email handling).
+// The orchestrator consults CanHandle before each planning call and falls back
+// to the default planner when the sub-agent fails. Implementations parse their model answers
+// with DecisionFromResponse, so short output budgets get the same truncation retry and native
+// tool call handling as the planner.
+type SubAgent interface {
+	Planner
+	Name() string
+	CanHandle(task string) bool
+}
+
+// plan asks the first matching sub-agent for the next decision, falling back to the default planner.
+// Returns the decision and the name of the agent that produced it.
+func (o *Orchestrator) plan(ctx context.Context, task Task, state State) (Decision, string, error) {
+	for _, sub := range o.subAgents {
+		if !sub.CanHandle(task.Description) {
+			continue
+		}
+		dec, err := sub.Next(ctx, state)
+		o.recordUsage(state.Step, dec.Usage)
+		if err == nil {
+			err = validateDecision(dec)
+		}
+		if err == nil {
+			dec.Source = sub.Name()
+			return dec, sub.Name(), nil
+		}
+		if ctx.Err() != nil {
+			return Decision{}, sub.Name(), err
+		}
+		o.logger.Warn().
+			Err(err).
+			Str("agent", sub.Name()).
+			Int("step", state.Step).
+			Msg("sub-agent failed, falling back to default planner")
+		break
+	}
+	dec, err := o.planner.Next(ctx, state)
+	o.recordUsage(state.Step, dec.Usage)
+	dec.Source = defaultPlannerName
+	return dec, defaultPlannerName, err
+}
+
+// validateDecision rejects decisions that can't be executed
+func validateDecision(dec Decision) error {
+	if !dec.Finish && dec.ActionName == "" {
+		return fmt.Errorf("decision has no action")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// fakeSubAgent handles tasks mentioning keyword with scripted answers, one per call
+type fakeSubAgent struct {
+	keyword string
+	answers []subAnswer
+	calls   int
+}
+
+type subAnswer struct {
+	dec Decision
+	err error
+}
+
+func (f *fakeSubAgent) Name() string { return "mail" }
+
+func (f *fakeSubAgent) CanHandle(task string) bool { return strings.Contains(task, f.keyword) }
+
+func (f *fakeSubAgent) Next(ctx context.Context, state State) (Decision, error) {
+	if f.calls >= len(f.answers) {
+		return Decision{}, errors.New("fake sub-agent: no more answers")
+	}
+	answer := f.answers[f.calls]
+	f.calls++
+	return answer.dec, answer.err
+}
+
+// agentRecorder keeps the planner name of every step
+type agentRecorder struct{ agents []string }
+
+func (r *agentRecorder) OnStep(ev StepEvent)     { r.agents = append(r.agents, ev.Agent) }
+func (r *agentRecorder) OnAction(ActionEvent)    {}
+func (r *agentRecorder) OnFinish(ev FinishEvent) {}
+
+func TestSubAgentRouting(t *testing.T) {
+	open := Decision{ActionName: "navigate", ActionInput: map[string]any{"url": "https://mail.example.com/inbox"}}
+	finish := Decision{Finish: true, Success: true, Message: "sub-agent done"}
+
+	cases := []struct {
+		name    string
+		task    string
+		answers []subAnswer
+		planner []string // Scripted default planner answers
+		want    []string // Agent of each step
+		message string
+	}{
+		{
+			name:    "matching task goes to the sub-agent",
+			task:    "reply to the latest mail",
+			answers: []subAnswer{{dec: open}, {dec: finish}},
+			want:    []string{"mail", "mail"},
+			message: "sub-agent done",
+		},
+		{
+			name:    "other tasks stay with the planner",
+			task:    "find the weather",
+			answers: []subAnswer{{dec: finish}},
+			planner: []string{finishDecision("planner done", true)},
+			want:    []string{defaultPlannerName},
+			message: "planner done",
+		},
+		{
+			name:    "sub-agent error falls back for that step",
+			task:    "reply to the latest mail",
+			answers: []subAnswer{{err: errors.New("model overloaded")}, {dec: finish}},
+			planner: []string{decision("navigate", map[string]any{"url": "https://mail.example.com/inbox"})},
+			want:    []string{defaultPlannerName, "mail"},
+			message: "sub-agent done",
+		},
+		{
+			name:    "decision without an action falls back",
+			task:    "reply to the latest mail",
+			answers: []subAnswer{{dec: Decision{Thinking: "unparsable"}}, {dec: finish}},
+			planner: []string{decision("navigate", map[string]any{"url": "https://mail.example.com/inbox"})},
+			want:    []string{defaultPlannerName, "mail"},
+			message: "sub-agent done",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := &fakeSubAgent{keyword: "mail", answers: tc.answers}
+			rec := &agentRecorder{}
+			ctrl := browser.NewFakeController(browser.FakePage{URL: "https://mail.example.com/", Text: "Inbox"})
+			orch := NewOrchestrator(Config{MaxSteps: 5, Quiet: true, Observer: rec},
+				NewPlanner(llm.NewScriptedClient(tc.planner)), tools.New(ctrl, nil), zerolog.Nop(), sub)
+			result, err := orch.Run(context.Background(), Task{Description: tc.task}, func(context.Context) (snapshot.Summary, error) {
+				return snapshot.Summary{URL: "https://mail.example.com/", Title: "Inbox"}, nil
+			})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if result.FinalMessage != tc.message || !result.Success {
+				t.Fatalf("result = %q success=%v, want %q", result.FinalMessage, result.Success, tc.message)
+			}
+			if strings.Join(rec.agents, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("agents per step = %v, want %v", rec.agents, tc.want)
+			}
+			if !strings.Contains(tc.task, "mail") && sub.calls != 0 {
+				t.Fatalf("sub-agent was asked %d times for a task it can't handle", sub.calls)
+			}
+		})
+	}
+}
+
+// sourceRecorder keeps the decision source of every step
+type sourceRecorder struct{ sources []string }
+
+func (r *sourceRecorder) OnStep(ev StepEvent)  { r.sources = append(r.sources, ev.Decision.Source) }
+func (r *sourceRecorder) OnAction(ActionEvent) {}
+func (r *sourceRecorder) OnFinish(FinishEvent) {}
+
+func TestDecisionSourcePropagation(t *testing.T) {
+	inbox := Decision{ActionName: "navigate", ActionInput: map[string]any{"url": "https://mail.example.com/inbox"}}
+	finish := Decision{Finish: true, Success: true, Message: "replied"}
+	sub := &fakeSubAgent{keyword: "mail", answers: []subAnswer{{dec: inbox}, {err: errors.New("model overloaded")}, {dec: finish}}}
+	client := llm.NewScriptedClient([]string{decision("navigate", map[string]any{"url": "https://mail.example.com/sent"})})
+	dir := t.TempDir()
+	checkpoint, trajectory := dir+"/checkpoint.json", dir+"/trajectory.jsonl"
+	var logs strings.Builder
+	rec := &sourceRecorder{}
+	ctrl := browser.NewFakeController(browser.FakePage{URL: "https://mail.example.com/", Text: "Inbox"})
+	cfg := Config{MaxSteps: 5, Quiet: true, Observer: rec, HistoryPath: checkpoint, TrajectoryPath: trajectory}
+	orch := NewOrchestrator(cfg, NewPlanner(client), tools.New(ctrl, nil), zerolog.New(&logs), sub)
+	result, err := orch.Run(context.Background(), Task{Description: "reply to the latest mail"}, func(context.Context) (snapshot.Summary, error) {
+		return snapshot.Summary{URL: "https://mail.example.com/", Title: "Inbox"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"mail", defaultPlannerName, "mail"}
+	if strings.Join(rec.sources, ",") != strings.Join(want, ",") {
+		t.Fatalf("decision sources = %v, want %v", rec.sources, want)
+	}
+	var history []string
+	for _, h := range result.History {
+		history = append(history, h.Action+"="+h.Source)
+	}
+	if got := strings.Join(history, ","); got != "navigate=mail,navigate="+defaultPlannerName {
+		t.Fatalf("history sources = %s", got)
+	}
+	// The planner is told which steps a specialist took
+	if msg := client.Requests()[0].Messages[0].Content; !strings.Contains(msg, "Decided by: mail") {
+		t.Fatal("planner prompt does not attribute the sub-agent step")
+	}
+
+	cp, err := LoadCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.History) != 2 || cp.History[0].Source != "mail" || cp.History[1].Source != defaultPlannerName {
+		t.Fatalf("checkpoint history = %+v", cp.History)
+	}
+	steps, err := LoadTrajectory(trajectory)
+	if err != nil || len(steps) != len(want) {
+		t.Fatalf("trajectory = %d steps, %v; want %d", len(steps), err, len(want))
+	}
+	for i, step := range steps {
+		if step.Agent != want[i] {
+			t.Errorf("trajectory step %d agent = %q, want %q", step.Step, step.Agent, want[i])
+		}
+	}
+	for _, w := range want {
+		if !strings.Contains(logs.String(), `"agent":"`+w+`"`) {
+			t.Errorf("no step log line with agent %q", w)
+		}
+	}
+}
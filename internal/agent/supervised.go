@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// supervisorVerdict is the human decision for a pending action in supervised mode
+type supervisorVerdict int
+
+const (
+	verdictApprove supervisorVerdict = iota
+	verdictSkip
+	verdictAbort
+)
+
+// supervise presents the pending action to the human (Config.Supervised) and returns
+// the possibly edited decision with the verdict. Unknown and empty answers repeat the question:
+// a stray Enter must not approve an action.
+func (o *Orchestrator) supervise(ctx context.Context, dec Decision, pageURL string) (Decision, supervisorVerdict, error) {
+	for {
+		answer, err := o.tools.Ask(ctx, o.describePending(ctx, dec, pageURL)+"\n[a]pprove / [e]dit input / [s]kip / a[b]ort: ")
+		if err != nil {
+			return dec, verdictAbort, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "approve", "y", "yes", "да":
+			return dec, verdictApprove, nil
+		case "s", "skip":
+			return dec, verdictSkip, nil
+		case "b", "abort", "q", "quit":
+			return dec, verdictAbort, nil
+		case "e", "edit":
+			input, err := o.askInput(ctx)
+			if err != nil {
+				return dec, verdictAbort, err
+			}
+			if input != nil {
+				dec.ActionInput = input
+			}
+		}
+	}
+}
+
+// askInput asks for replacement action input until it parses as a JSON object;
+// nil means keep the current input
+func (o *Orchestrator) askInput(ctx context.Context) (map[string]any, error) {
+	const question = "Replacement input as JSON object (empty to keep current): "
+	prompt := question
+	for {
+		raw, err := o.tools.Ask(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(raw) == "" {
+			return nil, nil
+		}
+		var input map[string]any
+		if err := json.Unmarshal([]byte(raw), &input); err != nil || input == nil {
+			if err == nil {
+				err = fmt.Errorf("not an object")
+			}
+			prompt = fmt.Sprintf("⚠️  Invalid JSON: %v\n%s", err, question)
+			continue
+		}
+		return input, nil
+	}
+}
+
+// describePending renders action, input, resolved element and URL for the supervisor
+func (o *Orchestrator) describePending(ctx context.Context, dec Decision, pageURL string) string {
+	input, _ := json.Marshal(dec.ActionInput)
+	desc := fmt.Sprintf("👀 SUPERVISED: next action %s %s", dec.ActionName, input)
+	if info, err := o.tools.DescribeTarget(ctx, dec.ActionName, dec.ActionInput); err == nil {
+		if info.Text != "" {
+			desc += fmt.Sprintf("\nElement: %q (%s)", info.Text, info.Role)
+		}
+		if info.Container != "" {
+			desc += fmt.Sprintf("\nIn: %q", info.Container)
+		}
+	}
+	if pageURL != "" {
+		desc += "\nPage: " + pageURL
+	}
+	return desc
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func TestSupervisedEmptyAnswerAndBadJSONAskAgain(t *testing.T) {
+	answers := []string{"", "e", "{bad", `{"selector": "#login"}`, "a"}
+	var asked []string
+	prompt := func(_ context.Context, message string) (string, error) {
+		asked = append(asked, message)
+		if len(asked) > len(answers) {
+			return "a", nil
+		}
+		return answers[len(asked)-1], nil
+	}
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	_, ctrl, err := scriptedRun(t, Config{Supervised: true}, page, loginSummary, prompt,
+		decision("click_selector", map[string]any{"selector": "#missing"}),
+		finishDecision("signed in", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) < len(answers) {
+		t.Fatalf("asked %d questions, want at least %d: %q", len(asked), len(answers), asked)
+	}
+	if !strings.Contains(asked[1], "#missing") {
+		t.Errorf("empty answer did not repeat the question: %q", asked[1])
+	}
+	if !strings.HasPrefix(asked[3], "⚠️  Invalid JSON:") || !strings.Contains(asked[3], "Replacement input") {
+		t.Errorf("invalid JSON not reported through the prompt: %q", asked[3])
+	}
+	if !strings.Contains(asked[4], "#login") {
+		t.Errorf("edited input not shown for approval: %q", asked[4])
+	}
+	clicks := ctrl.CallsTo("Click")
+	if len(clicks) != 1 || clicks[0].Args[0] != "#login" {
+		t.Errorf("clicks = %v, want only the edited #login approved", clicks)
+	}
+}
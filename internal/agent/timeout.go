@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// maxStepTimeouts consecutive step timeouts abort the run
+	maxStepTimeouts = 2
+	// runDeadlineTick is how often the run deadline re-checks the clock (pauses extend it)
+	runDeadlineTick = 200 * time.Millisecond
+)
+
+// RunTimeoutError is returned by Run when Config.MaxDuration ran out, so callers can tell
+// "ran out of time" from "failed"
+type RunTimeoutError struct {
+	Limit time.Duration
+	Steps int // Steps started before the deadline
+}
+
+func (e *RunTimeoutError) Error() string {
+	return fmt.Sprintf("run exceeded max duration %s after %d steps", e.Limit, e.Steps)
+}
+
+// withRunDeadline cancels ctx with a *RunTimeoutError once the run used Config.MaxDuration.
+// A plain context deadline would count paused time, so the clock is polled instead.
+func (o *Orchestrator) withRunDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	limit := o.cfg.MaxDuration
+	if limit <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	start, pausedBefore := time.Now(), o.PausedDuration()
+	go func() {
+		ticker := time.NewTicker(runDeadlineTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if time.Since(start)-(o.PausedDuration()-pausedBefore) >= limit {
+					cancel(&RunTimeoutError{Limit: limit})
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// runTimeout returns the error ctx was cancelled with by withRunDeadline, if any
+func runTimeout(ctx context.Context) *RunTimeoutError {
+	var te *RunTimeoutError
+	if errors.As(context.Cause(ctx), &te) {
+		return te
+	}
+	return nil
+}
+
+// stepContext bounds one step (snapshot + plan + act) by Config.StepTimeout
+func (o *Orchestrator) stepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.cfg.StepTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.cfg.StepTimeout)
+}
+
+// stepTimedOut reports whether the step deadline expired while the run itself is still alive
+func (o *Orchestrator) stepTimedOut(stepCtx, runCtx context.Context) bool {
+	return o.cfg.StepTimeout > 0 && errors.Is(stepCtx.Err(), context.DeadlineExceeded) && runCtx.Err() == nil
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// sleepingToolbox hangs in the slow action until its context ends, like a WaitFor on an
+// element that never appears; everything else goes to the fake browser
+type sleepingToolbox struct {
+	tools.Toolbox
+	slow string
+}
+
+func (s sleepingToolbox) Invoke(ctx context.Context, name string, input map[string]any) (tools.Result, error) {
+	if name != s.slow {
+		return s.Toolbox.Invoke(ctx, name, input)
+	}
+	<-ctx.Done()
+	return tools.Result{}, ctx.Err()
+}
+
+func runSleeping(t *testing.T, cfg Config, responses ...string) (RunResult, error, time.Duration) {
+	t.Helper()
+	cfg.Quiet = true
+	if cfg.MaxSteps == 0 {
+		cfg.MaxSteps = len(responses) + 2
+	}
+	box := sleepingToolbox{Toolbox: tools.New(browser.NewFakeController(loginPage), nil), slow: "wait_for"}
+	orch := NewOrchestrator(cfg, NewPlanner(llm.NewScriptedClient(responses)), box, zerolog.Nop())
+	start := time.Now()
+	result, err := orch.Run(context.Background(), Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	return result, err, time.Since(start)
+}
+
+func TestStepTimeoutBecomesHistory(t *testing.T) {
+	hang := decision("wait_for", map[string]any{"selector": "#welcome"})
+	result, err, _ := runSleeping(t, Config{StepTimeout: 100 * time.Millisecond}, hang, finishDecision("gave up waiting", true))
+	if err != nil {
+		t.Fatalf("one timed-out step ended the run: %v", err)
+	}
+	if !result.Success || result.Steps != 2 {
+		t.Fatalf("result = %+v, want success at step 2", result)
+	}
+	found := false
+	for _, h := range result.History {
+		found = found || strings.Contains(h.Result, "step timed out after 100ms while running wait_for")
+	}
+	if !found {
+		t.Fatalf("history = %+v, want the step timeout recorded", result.History)
+	}
+}
+
+func TestRepeatedStepTimeoutsEndTheRun(t *testing.T) {
+	hang := decision("wait_for", map[string]any{"selector": "#welcome"})
+	hangAgain := decision("wait_for", map[string]any{"selector": "#dashboard"})
+	result, err, _ := runSleeping(t, Config{StepTimeout: 50 * time.Millisecond}, hang, hangAgain, finishDecision("never reached", true))
+	if err == nil || !strings.Contains(err.Error(), "2 steps in a row timed out") {
+		t.Fatalf("err = %v, want consecutive step timeouts", err)
+	}
+	var te *RunTimeoutError
+	if errors.As(err, &te) {
+		t.Fatal("step timeouts reported as the run deadline")
+	}
+	if fr := result.FailureReason; fr == nil || fr.Kind != FailureTimeout || fr.Action != "wait_for" || fr.Step != 2 {
+		t.Fatalf("failure = %+v", result.FailureReason)
+	}
+}
+
+func TestMaxDurationStopsAHungAction(t *testing.T) {
+	hang := decision("wait_for", map[string]any{"selector": "#welcome"})
+	result, err, elapsed := runSleeping(t, Config{MaxDuration: 200 * time.Millisecond}, hang)
+	var te *RunTimeoutError
+	if !errors.As(err, &te) || te.Limit != 200*time.Millisecond || te.Steps != result.Steps {
+		t.Fatalf("err = %v, want *RunTimeoutError after %d steps", err, result.Steps)
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("run took %s with a 200ms limit", elapsed)
+	}
+	if result.FailureReason == nil || result.FailureReason.Kind != FailureTimeout {
+		t.Fatalf("failure = %+v, want %s", result.FailureReason, FailureTimeout)
+	}
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// toolAliases maps tool names models commonly invent to registered tools.
+// Entries with "" are resolved by normalizeToolAlias from the input fields.
+var toolAliases = map[string]string{
+	"click":         "",
+	"click_element": "",
+	"type":          "",
+	"type_text":     "",
+	"input_text":    "",
+	"goto":          "navigate",
+	"go_to_url":     "navigate",
+	"open_url":      "navigate",
+	"open":          "navigate",
+	"back":          "go_back",
+	"forward":       "go_forward",
+	"scroll":        "scroll_page",
+	"press":         "press_key",
+	"send_keys":     "press_key",
+	"extract":       "read_page",
+	"get_text":      "read_page",
+}
+
+// normalizeToolAlias returns the registered tool for a known alias, or action unchanged
+func normalizeToolAlias(action string, input map[string]any) string {
+	target, ok := toolAliases[strings.ToLower(action)]
+	if !ok {
+		return action
+	}
+	if target != "" {
+		return target
+	}
+	_, hasIndex := input["index"]
+	_, hasSelector := input["selector"]
+	switch strings.ToLower(action) {
+	case "type", "type_text", "input_text":
+		if hasIndex {
+			return "fill_by_index"
+		}
+		return "fill"
+	default: // click aliases
+		switch {
+		case hasIndex:
+			return "click_by_index"
+		case hasSelector:
+			return "click_selector"
+		default:
+			return "click_text"
+		}
+	}
+}
+
+// unknownToolMessage explains an unregistered tool name, or returns "" if the tool exists
+func unknownToolMessage(action string, registered []tools.Tool) string {
+	names := ToolNames(registered)
+	for _, name := range names {
+		if name == action {
+			return ""
+		}
+	}
+	msg := fmt.Sprintf("unknown tool '%s'", action)
+	if best := closestToolName(action, names); best != "" {
+		msg += fmt.Sprintf(" - did you mean '%s'?", best)
+	}
+	return msg + " Available: " + strings.Join(names, ", ")
+}
+
+// closestToolName picks the name with the smallest edit distance, "" if none is close
+func closestToolName(action string, names []string) string {
+	action = strings.ToLower(action)
+	best, bestDist := "", -1
+	for _, name := range names {
+		d := levenshtein([]rune(action), []rune(name))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	// More than half of the name rewritten is a different tool, not a typo
+	if bestDist < 0 || bestDist > (len([]rune(action))+1)/2 {
+		return ""
+	}
+	return best
+}
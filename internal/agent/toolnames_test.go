@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+func TestNormalizeToolAlias(t *testing.T) {
+	tests := []struct {
+		action string
+		input  map[string]any
+		want   string
+	}{
+		{"click", map[string]any{"index": 3}, "click_by_index"},
+		{"click_element", map[string]any{"selector": "#go"}, "click_selector"},
+		{"Click", map[string]any{"text": "Sign in"}, "click_text"},
+		{"type_text", map[string]any{"index": 2, "text": "x"}, "fill_by_index"},
+		{"input_text", map[string]any{"selector": "#q", "text": "x"}, "fill"},
+		{"goto", map[string]any{"url": "https://example.com"}, "navigate"},
+		{"send_keys", map[string]any{"key": "Enter"}, "press_key"},
+		{"fill", map[string]any{"selector": "#q"}, "fill"},
+		{"click_button", nil, "click_button"},
+	}
+	for _, tt := range tests {
+		if got := normalizeToolAlias(tt.action, tt.input); got != tt.want {
+			t.Errorf("normalizeToolAlias(%q, %v) = %q, want %q", tt.action, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownToolMessage(t *testing.T) {
+	registered := []tools.Tool{{Name: "fill"}, {Name: "click_by_index"}, {Name: "navigate"}, {Name: "read_page"}}
+	tests := []struct {
+		action     string
+		suggestion string // "" - known tool, "-" - unknown without a suggestion
+	}{
+		{"fill", ""},
+		{"navigate", ""},
+		{"fil", "fill"},
+		{"click_by_indx", "click_by_index"},
+		{"Navigate", "navigate"},
+		{"read_pages", "read_page"},
+		{"solve_captcha", "-"},
+		{"x", "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			msg := unknownToolMessage(tt.action, registered)
+			switch tt.suggestion {
+			case "":
+				if msg != "" {
+					t.Errorf("registered tool reported as unknown: %q", msg)
+				}
+				return
+			case "-":
+				if strings.Contains(msg, "did you mean") {
+					t.Errorf("message %q suggests a tool for an unrelated name", msg)
+				}
+			default:
+				if !strings.Contains(msg, "did you mean '"+tt.suggestion+"'?") {
+					t.Errorf("message %q, want %q suggested", msg, tt.suggestion)
+				}
+			}
+			if !strings.HasPrefix(msg, "unknown tool '"+tt.action+"'") || !strings.HasSuffix(msg, "Available: fill, click_by_index, navigate, read_page") {
+				t.Errorf("message %q does not name the tool and list the available ones", msg)
+			}
+		})
+	}
+}
+
+func TestUnknownToolSkipsInvocation(t *testing.T) {
+	page := browser.FakePage{URL: inboxPage.URL, Elements: append([]browser.FakeElement{{Selector: "#mail-3", Role: "link", Text: "Invoice March"}}, inboxPage.Elements...)}
+	result, ctrl, err := scriptedRun(t, Config{}, page, inboxSummary, nil,
+		decision("delete_email", map[string]any{"index": 2}),
+		decision("click", map[string]any{"text": "Invoice March"}),
+		finishDecision("opened the invoice", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.UnknownToolCalls != 1 {
+		t.Errorf("UnknownToolCalls = %d, want 1", result.UnknownToolCalls)
+	}
+	if len(result.History) != 2 || !strings.HasPrefix(result.History[0].Result, "unknown tool 'delete_email'") {
+		t.Fatalf("history = %+v, want the unknown tool answered", result.History)
+	}
+	if result.History[1].Action != "click_text" {
+		t.Errorf("alias ran as %q, want click_text", result.History[1].Action)
+	}
+	if result.Recovery != nil && result.Recovery.Attempts > 0 {
+		t.Errorf("recovery = %+v, want none for an unknown tool", result.Recovery)
+	}
+	if calls := ctrl.CallsTo("ClickText"); len(calls) != 1 {
+		t.Errorf("calls = %v, want only the aliased click", ctrl.Calls())
+	}
+}
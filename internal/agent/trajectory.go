@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+const (
+	trajectoryElements       = 20   // Elements of the snapshot kept per step
+	trajectoryObservationCap = 2000 // Runes of tool observation kept per step
+)
+
+// TrajectoryStep is one JSONL line of a recorded run (Config.TrajectoryPath)
+type TrajectoryStep struct {
+	Step         int                `json:"step"`
+	Time         time.Time          `json:"time"`
+	PromptHash   string             `json:"prompt_hash"`
+	URL          string             `json:"url"`
+	Title        string             `json:"title"`
+	ElementCount int                `json:"element_count"`
+	Elements     []snapshot.Element `json:"elements"`         // First trajectoryElements elements
+	Target       *snapshot.Element  `json:"target,omitempty"` // Element of an index action, to re-resolve on replay
+	Agent        string             `json:"agent"`
+	Decision     TrajectoryDecision `json:"decision"`
+	PlanMs       int64              `json:"plan_ms"`
+
+	// Outcome, empty when the step ended without an action (finish, skip, cancel)
+	Action      string         `json:"action,omitempty"` // Executed action (differs from the decision after recovery)
+	Input       map[string]any `json:"input,omitempty"`  // Typed secrets are redactedMark, replay asks for them
+	Observation string         `json:"observation,omitempty"`
+	Truncated   bool           `json:"truncated,omitempty"` // Observation was cut to trajectoryObservationCap
+	Error       string         `json:"error,omitempty"`
+	Recovered   bool           `json:"recovered,omitempty"`
+	ActionMs    int64          `json:"action_ms,omitempty"`
+}
+
+// TrajectoryDecision is the planner decision as recorded
+type TrajectoryDecision struct {
+	Action     string         `json:"action,omitempty"`
+	Input      map[string]any `json:"input,omitempty"`
+	Finish     bool           `json:"finish,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Thinking   string         `json:"thinking,omitempty"`
+	Evaluation string         `json:"evaluation_previous_goal,omitempty"`
+	Memory     string         `json:"memory,omitempty"`
+	NextGoal   string         `json:"next_goal,omitempty"`
+	// Raw is the model output the decision was parsed from, secrets redacted
+	Raw string `json:"raw,omitempty"`
+}
+
+// trajectoryRecorder is an Observer appending one line per step; every line is synced
+// so a crash loses at most the step in progress
+type trajectoryRecorder struct {
+	mu       sync.Mutex
+	file     *os.File
+	pending  *TrajectoryStep // Planned step waiting for its action outcome
+	redactor redactor
+}
+
+func newTrajectoryRecorder(path string) (*trajectoryRecorder, error) {
+	// Owner-only like storage state and checkpoints: inputs typed during the run are recorded
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open trajectory: %w", err)
+	}
+	if err := f.Chmod(0o600); err != nil { // A file from an earlier run keeps its mode otherwise
+		f.Close()
+		return nil, fmt.Errorf("open trajectory: %w", err)
+	}
+	return &trajectoryRecorder{file: f}, nil
+}
+
+func (r *trajectoryRecorder) OnStep(ev StepEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush() // Previous step had no action
+
+	elems := ev.Summary.Elements
+	if len(elems) > trajectoryElements {
+		elems = elems[:trajectoryElements]
+	}
+	dec := ev.Decision
+	target := indexTarget(dec, ev.Summary)
+	r.redactor.noteFill(dec, target) // Before redacting: the model may echo the password it types
+	line := &TrajectoryStep{
+		Step:         ev.Step,
+		Time:         time.Now(),
+		PromptHash:   SystemPromptHash(),
+		URL:          ev.Summary.URL,
+		Title:        ev.Summary.Title,
+		ElementCount: len(ev.Summary.Elements),
+		Elements:     elems,
+		Target:       target,
+		Agent:        ev.Agent,
+		PlanMs:       ev.PlanDuration.Milliseconds(),
+		Decision: TrajectoryDecision{
+			Action:     dec.ActionName,
+			Input:      r.redactor.redactInput(dec.ActionInput),
+			Finish:     dec.Finish,
+			Message:    r.redactor.redact(dec.Message),
+			Thinking:   r.redactor.redact(dec.Thinking),
+			Evaluation: r.redactor.redact(dec.EvaluationPreviousGoal),
+			Memory:     r.redactor.redact(dec.Memory),
+			NextGoal:   r.redactor.redact(dec.NextGoal),
+			Raw:        r.redactor.redact(dec.RawJSON),
+		},
+	}
+	r.pending = line
+}
+
+func (r *trajectoryRecorder) OnAction(ev ActionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil || r.pending.Step != ev.Step {
+		return
+	}
+	r.pending.Action = ev.Action
+	r.pending.Input = r.redactor.redactInput(ev.Input)
+	observation := r.redactor.redact(ev.Observation)
+	r.pending.Observation = cutRunes(observation, trajectoryObservationCap)
+	r.pending.Truncated = len(r.pending.Observation) < len(observation)
+	if ev.Err != nil {
+		r.pending.Error = r.redactor.redact(ev.Err.Error())
+	}
+	r.pending.Recovered = ev.Recovered
+	r.pending.ActionMs = ev.Duration.Milliseconds()
+	r.flush()
+}
+
+func (r *trajectoryRecorder) OnFinish(FinishEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush()
+}
+
+// flush writes the pending step; errors are dropped - recording must not break the run
+func (r *trajectoryRecorder) flush() {
+	if r.pending == nil {
+		return
+	}
+	data, err := json.Marshal(r.pending)
+	r.pending = nil
+	if err != nil {
+		return
+	}
+	_, _ = r.file.Write(append(data, '\n'))
+	_ = r.file.Sync()
+}
+
+// indexTarget returns the snapshot element an index action refers to
+func indexTarget(dec Decision, summary snapshot.Summary) *snapshot.Element {
+	switch dec.ActionName {
+	case "click_by_index", "fill_by_index", "fill_and_submit", "set_date":
+	default:
+		return nil
+	}
+	var index int
+	switch v := dec.ActionInput["index"].(type) {
+	case float64:
+		index = int(v)
+	case int:
+		index = v
+	default:
+		return nil
+	}
+	for i := range summary.Elements {
+		if summary.Elements[i].Index == index {
+			el := summary.Elements[i]
+			return &el
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+const testPassword = "hunter2-secret"
+
+var loginPage = browser.FakePage{
+	URL: "https://example.com/login",
+	Elements: []browser.FakeElement{
+		{Selector: "#email", Role: "textbox", Text: "Email"},
+		{Selector: "#password", Role: "textbox", Text: "Password"},
+	},
+}
+
+var loginSummary = snapshot.Summary{
+	URL:   "https://example.com/login",
+	Title: "Sign in",
+	Elements: []snapshot.Element{
+		{Index: 1, Role: "textbox", Text: "Email", Sel: "#email"},
+		{Index: 2, Role: "textbox", Text: "Password", Sel: "#password", Attr: "type:password"},
+		{Index: 3, Role: "button", Text: "Sign in", Sel: "#login"},
+	},
+}
+
+// recordPasswordFill writes a trajectory of one action typing the password whose model output,
+// reasoning and observation all echo it
+func recordPasswordFill(t *testing.T, action string, input map[string]any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trajectory.jsonl")
+	rec, err := newTrajectoryRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.file.Close()
+	rec.OnStep(StepEvent{
+		Step:    1,
+		Summary: loginSummary,
+		Decision: Decision{
+			ActionName:  action,
+			ActionInput: input,
+			Thinking:    "type the password " + testPassword,
+			Memory:      "password is " + testPassword,
+			NextGoal:    "fill " + testPassword,
+			RawJSON:     `{"action":"` + action + `","input":{"text":"` + testPassword + `"}}`,
+		},
+	})
+	rec.OnAction(ActionEvent{Step: 1, Action: action, Input: input, Observation: "filled Password with " + testPassword})
+	rec.OnFinish(FinishEvent{})
+	return path
+}
+
+func TestTrajectoryRedactsTypedPasswords(t *testing.T) {
+	path := recordPasswordFill(t, "fill_by_index", map[string]any{"index": 2, "text": testPassword})
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), testPassword) {
+		t.Fatalf("trajectory contains the typed password:\n%s", raw)
+	}
+	steps, err := LoadTrajectory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := steps[0]
+	if st.Decision.Input["text"] != redactedMark || st.Input["text"] != redactedMark {
+		t.Errorf("typed password recorded as %q / %q, want %q", st.Decision.Input["text"], st.Input["text"], redactedMark)
+	}
+	if st.Decision.Input["index"] != float64(2) {
+		t.Errorf("index recorded as %v, want 2", st.Decision.Input["index"])
+	}
+}
+
+func TestTrajectoryIsOwnerOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trajectory.jsonl")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := newTrajectoryRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.file.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("trajectory mode = %o, want 600", mode)
+	}
+}
+
+func TestTrajectoryRedactsSelectorPasswordFills(t *testing.T) {
+	var r redactor
+	dec := Decision{ActionName: "fill", ActionInput: map[string]any{"selector": "input[type=password]", "text": testPassword}}
+	r.noteFill(dec, nil)
+	if got := r.redactInput(dec.ActionInput)["text"]; got != redactedMark {
+		t.Errorf("selector fill recorded as %q, want %q", got, redactedMark)
+	}
+	if got := r.redactInput(map[string]any{"selector": "#q", "text": "weather"})["text"]; got != "weather" {
+		t.Errorf("plain fill recorded as %q, want it unchanged", got)
+	}
+}
+
+func TestReplayAsksForRedactedValues(t *testing.T) {
+	steps, err := LoadTrajectory(recordPasswordFill(t, "fill", map[string]any{"selector": "#password", "text": testPassword}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := func(context.Context) (snapshot.Summary, error) { return loginSummary, nil }
+
+	t.Run("answered", func(t *testing.T) {
+		fake := browser.NewFakeController(loginPage)
+		var asked []string
+		ask := func(_ context.Context, msg string) (string, error) {
+			asked = append(asked, msg)
+			return testPassword, nil
+		}
+		res, err := NewReplayer(tools.New(fake, ask), zerolog.Nop()).Replay(context.Background(), steps, snap)
+		if err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		if len(asked) != 1 || res.Replayed != 1 {
+			t.Fatalf("asked %d times, replayed %d actions; want 1 and 1", len(asked), res.Replayed)
+		}
+		fills := fake.CallsTo("Fill")
+		if len(fills) != 1 || fills[0].Args[1] != testPassword {
+			t.Errorf("fills = %v, want the password typed once", fills)
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		fake := browser.NewFakeController(loginPage)
+		ask := func(context.Context, string) (string, error) { return "", nil }
+		res, err := NewReplayer(tools.New(fake, ask), zerolog.Nop()).Replay(context.Background(), steps, snap)
+		if err == nil || res.Diff == nil {
+			t.Fatalf("replay without the secret succeeded: %+v", res)
+		}
+		if n := len(fake.CallsTo("Fill")); n != 0 {
+			t.Errorf("%d fills without the secret, want none", n)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// cutSamples are multi-byte strings: Cyrillic (2 bytes per rune), emoji (4 bytes), an emoji
+// with a skin tone modifier (2 runes) and a mix
+var cutSamples = []string{"Привет, мир", "🍕🍕🍕🍕🍕", "a👍🏽b👍🏽c", "Ж🍕ab ё", ""}
+
+// boundaries are the cut lengths around the rune count of s
+func boundaries(s string) []int {
+	n := utf8.RuneCountInString(s)
+	return []int{0, 1, n - 1, n, n + 1}
+}
+
+func TestCutRunes(t *testing.T) {
+	for _, s := range cutSamples {
+		for _, n := range boundaries(s) {
+			got := cutRunes(s, n)
+			want := min(max(n, 0), utf8.RuneCountInString(s))
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) != want || !strings.HasPrefix(s, got) {
+				t.Errorf("cutRunes(%q, %d) = %q, want the first %d runes", s, n, got, want)
+			}
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	for _, s := range cutSamples {
+		count := utf8.RuneCountInString(s)
+		for _, n := range boundaries(s) {
+			if n < 0 {
+				continue
+			}
+			got := truncateText(s, n)
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateText(%q, %d) = %q is not valid UTF-8", s, n, got)
+			}
+			if count <= n {
+				if got != s {
+					t.Errorf("truncateText(%q, %d) = %q, want it unchanged", s, n, got)
+				}
+				continue
+			}
+			if got != cutRunes(s, n)+"..." || utf8.RuneCountInString(got) != n+3 {
+				t.Errorf("truncateText(%q, %d) = %q, want %d runes and an ellipsis", s, n, got, n)
+			}
+		}
+	}
+}
+
+func TestTruncateHistoryResult(t *testing.T) {
+	long := strings.Repeat("Ж🍕", 100)
+	for _, cut := range []string{truncate("click_text", long), truncateTextForDebug(long, 160)} {
+		if !utf8.ValidString(cut) || utf8.RuneCountInString(cut) != 163 || !strings.HasSuffix(cut, "...") {
+			t.Errorf("cut = %q (%d runes)", cut, utf8.RuneCountInString(cut))
+		}
+	}
+	if got := truncate("read_page", long); got != "(read_page data omitted)" {
+		t.Errorf("read_page result = %q", got)
+	}
+	if got := truncate("click_text", "ок 👍"); got != "ок 👍" {
+		t.Errorf("short result = %q, want it unchanged", got)
+	}
+}
+
+func TestCutLines(t *testing.T) {
+	s := "первая строка\n🍕🍕🍕\nтретья"
+	tests := []struct {
+		maxRunes int
+		want     string
+	}{
+		{0, ""},
+		{5, ""}, // Not even the first line fits
+		{14, "первая строка\n"},
+		{16, "первая строка\n"}, // Cuts inside the emoji line, keeps complete lines only
+		{18, "первая строка\n🍕🍕🍕\n"},
+		{utf8.RuneCountInString(s), s},
+		{100, s},
+	}
+	for _, tt := range tests {
+		got := cutLines(s, tt.maxRunes)
+		if got != tt.want || !utf8.ValidString(got) || utf8.RuneCountInString(got) > max(tt.maxRunes, 0) {
+			t.Errorf("cutLines(%d) = %q, want %q", tt.maxRunes, got, tt.want)
+		}
+	}
+}
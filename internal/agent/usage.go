@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+// usageTracker accumulates LLM token usage over a run
+type usageTracker struct {
+	total    llm.Usage
+	perStep  []int // Tokens by step (index = step-1)
+	stepBase int   // Steps taken by earlier sub-runs of a batch
+}
+
+// recordUsage adds LLM usage (planner and auxiliary checks) to the run totals; step < 1 counts only into the totals
+func (o *Orchestrator) recordUsage(step int, u llm.Usage) {
+	o.usage.total.Add(u)
+	if step < 1 {
+		return
+	}
+	step += o.usage.stepBase
+	for len(o.usage.perStep) < step {
+		o.usage.perStep = append(o.usage.perStep, 0)
+	}
+	o.usage.perStep[step-1] += u.Total()
+}
+
+// checkBudget fails once the estimated cost exceeds Config.MaxCost
+func (o *Orchestrator) checkBudget() error {
+	if o.cfg.MaxCost <= 0 {
+		return nil
+	}
+	cost, ok := llm.EstimateCost(o.cfg.Model, o.usage.total)
+	if !ok || cost <= o.cfg.MaxCost {
+		return nil
+	}
+	return fmt.Errorf("cost budget exceeded: $%.4f > $%.4f (%d tokens)", cost, o.cfg.MaxCost, o.usage.total.Total())
+}
+
+// finishUsage copies usage into the result and logs the totals
+func (o *Orchestrator) finishUsage(result *RunResult) {
+	result.Usage = o.usage.total
+	result.StepTokens = append([]int(nil), o.usage.perStep...)
+	ev := o.logger.Info().
+		Int("prompt_tokens", result.Usage.PromptTokens).
+		Int("completion_tokens", result.Usage.CompletionTokens).
+		Int("total_tokens", result.Usage.Total()).
+		Ints("step_tokens", result.StepTokens)
+	if cost, ok := llm.EstimateCost(o.cfg.Model, result.Usage); ok {
+		result.CostUSD = cost
+		ev = ev.Float64("cost_usd", cost)
+	}
+	ev.Msg("run usage")
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+// meteredClient reports a fixed usage for every answer of the wrapped client
+type meteredClient struct {
+	llm.Client
+	usage llm.Usage
+}
+
+func (c meteredClient) Generate(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.Client.Generate(ctx, req)
+	resp.Usage = c.usage
+	return resp, err
+}
+
+func metered(prompt, completion int, responses ...string) llm.Client {
+	return meteredClient{Client: llm.NewScriptedClient(responses), usage: llm.Usage{PromptTokens: prompt, CompletionTokens: completion}}
+}
+
+func TestAuxiliaryCallsCountTowardUsage(t *testing.T) {
+	cfg := Config{
+		MaxSteps:       4,
+		Quiet:          true,
+		TaskTitler:     NewLLMTaskTitler(metered(20, 2, "Sign in")),
+		Deliverables:   NewLLMDeliverableChecker(metered(30, 3, "- account name\n- plan", "yes", "yes")),
+		VerifyFinish:   NewLLMFinishVerifier(metered(50, 5, "yes")),
+		RiskClassifier: NewLLMRiskClassifier(metered(40, 4, "safe")),
+	}
+	planner := NewPlanner(metered(100, 10,
+		decision("click_by_index", map[string]any{"index": 3}),
+		finishDecision("signed in as Bob, plan Pro", true),
+	))
+	page := browser.FakePage{URL: loginPage.URL, Elements: append(loginPage.Elements, browser.FakeElement{Selector: "#login", Role: "button", Text: "Sign in"})}
+	orch := NewOrchestrator(cfg, planner, tools.New(browser.NewFakeController(page), nil), zerolog.Nop())
+	result, err := orch.Run(context.Background(), Task{Description: "sign in to example.com and tell me the account name and plan"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("result = %+v, want success", result)
+	}
+	// Run start: title 22 + extraction 33; step 1: planner 110 + risk 44; step 2: planner 110 + two coverage checks 66 + verification 55
+	if got := result.Usage.Total(); got != 22+33+110+44+110+66+55 {
+		t.Errorf("total tokens %d, want every LLM call counted (%+v)", got, result.Usage)
+	}
+	if len(result.StepTokens) != 2 || result.StepTokens[0] != 110+44 || result.StepTokens[1] != 110+66+55 {
+		t.Errorf("step tokens %v, want the auxiliary calls on their steps", result.StepTokens)
+	}
+}
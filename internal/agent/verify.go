@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent/prompts"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+const (
+	// maxFinishRejections bounds how often a finish is bounced back, so planner and verifier can't ping-pong
+	maxFinishRejections = 2
+	// finishVerifyPageRunes caps the page state sent to the verifier
+	finishVerifyPageRunes = 4000
+)
+
+// FinishVerdict is the answer of a FinishVerifier
+type FinishVerdict struct {
+	Done   bool
+	Reason string
+	Usage  llm.Usage // LLM usage of the check, counted into the run totals (-max-cost)
+}
+
+// FinishVerifier double-checks a finish decision against a fresh page state (Config.VerifyFinish)
+type FinishVerifier interface {
+	Verify(ctx context.Context, task, message string, summary snapshot.Summary) (FinishVerdict, error)
+}
+
+type llmFinishVerifier struct {
+	llm llm.Client
+}
+
+// NewLLMFinishVerifier asks the model with a short dedicated prompt
+func NewLLMFinishVerifier(client llm.Client) FinishVerifier {
+	return &llmFinishVerifier{llm: client}
+}
+
+func (v *llmFinishVerifier) Verify(ctx context.Context, task, message string, summary snapshot.Summary) (FinishVerdict, error) {
+	p := prompts.FinishValidationPrompt(languageName(DetectLanguage(task)), task, message, cutRunes(summary.String(), finishVerifyPageRunes))
+	resp, err := v.llm.Generate(ctx, llm.Request{
+		System:      p.System,
+		Messages:    []llm.Message{{Role: "user", Content: p.User}},
+		Temperature: 0,
+		MaxTokens:   80,
+	})
+	if err != nil {
+		return FinishVerdict{Usage: resp.Usage}, err
+	}
+	verdict, err := parseFinishVerdict(resp.Text)
+	verdict.Usage = resp.Usage
+	return verdict, err
+}
+
+// parseFinishVerdict reads "yes/no" plus the reason that follows it
+func parseFinishVerdict(text string) (FinishVerdict, error) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+	var done bool
+	switch {
+	case strings.HasPrefix(lower, "yes"):
+		done = true
+		text = text[len("yes"):]
+	case strings.HasPrefix(lower, "no"):
+		text = text[len("no"):]
+	default:
+		return FinishVerdict{}, fmt.Errorf("unexpected finish verdict %q", truncateText(text, 40))
+	}
+	reason := strings.TrimSpace(strings.TrimLeft(text, " .,:;-\n"))
+	return FinishVerdict{Done: done, Reason: reason}, nil
+}
+
+// finishRejection verifies a finish decision on a fresh snapshot and returns the reason to
+// reject it, "" to accept. Verifier failures accept the finish - they must not block a run.
+func (o *Orchestrator) finishRejection(ctx context.Context, step int, task string, dec Decision, snap summaryFunc) string {
+	ctxSnap, cancel := snapshot.WithDeadline(ctx, 5*time.Second)
+	summary, _ := snap(ctxSnap)
+	cancel()
+	verdict, err := o.cfg.VerifyFinish.Verify(ctx, task, dec.Message, summary)
+	o.recordUsage(step, verdict.Usage)
+	if err != nil {
+		o.logger.Warn().Err(err).Msg("finish verification failed - accepting finish")
+		return ""
+	}
+	if verdict.Done {
+		return ""
+	}
+	if verdict.Reason == "" {
+		verdict.Reason = "the page does not show the task as done"
+	}
+	o.logger.Info().Str("reason", verdict.Reason).Msg("finish rejected by verifier")
+	return verdict.Reason
+}
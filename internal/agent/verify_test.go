@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+func TestLLMFinishVerifier(t *testing.T) {
+	tests := []struct {
+		answer  string
+		want    FinishVerdict
+		wantErr bool
+	}{
+		{"yes", FinishVerdict{Done: true}, false},
+		{"Yes - the dashboard greets the user", FinishVerdict{Done: true, Reason: "the dashboard greets the user"}, false},
+		{"no: the form shows \"Invalid password\"", FinishVerdict{Reason: "the form shows \"Invalid password\""}, false},
+		{"maybe", FinishVerdict{}, true},
+	}
+	for _, tt := range tests {
+		verifier := NewLLMFinishVerifier(llm.NewScriptedClient([]string{tt.answer}))
+		got, err := verifier.Verify(context.Background(), "sign in", "signed in", loginSummary)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.answer, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.answer, got, tt.want)
+		}
+	}
+}
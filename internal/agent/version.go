@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime/debug"
+)
+
+// Version is set at build time:
+// go build -ldflags "-X github.com/polzovatel/ai-agent-for-browser-fast/internal/agent.Version=v1.2.3"
+var Version string
+
+// BuildVersion returns the version identifier of this binary.
+// Prefers -ldflags value, then module version and VCS revision from build info.
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		version += "+" + revision
+		if modified {
+			version += "-dirty"
+		}
+	}
+	return version
+}
+
+// SystemPromptHash returns short hash of the effective planner system prompt.
+// Runs produced by different prompts have different hashes.
+func SystemPromptHash() string {
+	sum := sha256.Sum256([]byte(buildSystemPrompt("")))
+	return hex.EncodeToString(sum[:])[:12]
+}
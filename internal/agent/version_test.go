@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/tools"
+)
+
+func TestBuildVersionPrefersLdflags(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+	Version = ""
+	if got := BuildVersion(); got == "" {
+		t.Fatal("BuildVersion() without ldflags is empty")
+	}
+	Version = "v1.2.3"
+	if got := BuildVersion(); got != "v1.2.3" {
+		t.Fatalf("BuildVersion() = %q, want the ldflags value", got)
+	}
+}
+
+func TestSystemPromptHash(t *testing.T) {
+	hash := SystemPromptHash()
+	if !regexp.MustCompile(`^[0-9a-f]{12}$`).MatchString(hash) {
+		t.Fatalf("SystemPromptHash() = %q, want 12 hex digits", hash)
+	}
+	if SystemPromptHash() != hash {
+		t.Fatal("SystemPromptHash() is not stable")
+	}
+}
+
+func TestRunRecordsBuildPromptAndTools(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+	Version = "v1.2.3"
+	trajectory := filepath.Join(t.TempDir(), "trajectory.jsonl")
+	var logs strings.Builder
+	box := tools.New(browser.NewFakeController(loginPage), nil)
+	client := llm.NewScriptedClient([]string{
+		decision("type_text", map[string]any{"selector": "#email", "text": "user@example.com"}),
+		finishDecision("signed in", true),
+	})
+	orch := NewOrchestrator(Config{MaxSteps: 4, Quiet: true, TrajectoryPath: trajectory}, NewPlanner(client), box, zerolog.New(&logs))
+	result, err := orch.Run(context.Background(), Task{Description: "sign in to example.com"}, func(context.Context) (snapshot.Summary, error) {
+		return loginSummary, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTools := ToolNames(box.Describe())
+	if result.Version != "v1.2.3" || result.PromptHash != SystemPromptHash() || fmt.Sprint(result.Tools) != fmt.Sprint(wantTools) {
+		t.Fatalf("result version=%q prompt_hash=%q tools=%v, want v1.2.3 %q %v", result.Version, result.PromptHash, result.Tools, SystemPromptHash(), wantTools)
+	}
+	if len(wantTools) == 0 || wantTools[0] == "" {
+		t.Fatalf("tool names = %v", wantTools)
+	}
+
+	var header struct {
+		Version    string   `json:"version"`
+		PromptHash string   `json:"prompt_hash"`
+		Tools      []string `json:"tools"`
+	}
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"message":"run start"`) {
+			if err := json.Unmarshal([]byte(line), &header); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if header.Version != result.Version || header.PromptHash != result.PromptHash || fmt.Sprint(header.Tools) != fmt.Sprint(result.Tools) {
+		t.Errorf("run start header = %+v, want the RunResult values", header)
+	}
+
+	steps, err := LoadTrajectory(trajectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range steps {
+		if s.PromptHash != result.PromptHash {
+			t.Errorf("trajectory step %d prompt_hash = %q, want %q", s.Step, s.PromptHash, result.PromptHash)
+		}
+	}
+}
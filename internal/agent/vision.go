@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Screenshots are PNG
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/llm"
+)
+
+const (
+	visionMaxWidth    = 1024 // Screenshots wider than this are downscaled before sending
+	visionJPEGQuality = 80
+)
+
+// captureVision takes a viewport screenshot for the planner (vision mode).
+// The image is downscaled to visionMaxWidth and re-encoded as JPEG to keep requests small.
+func (o *Orchestrator) captureVision(ctx context.Context) (*llm.Image, error) {
+	data, err := o.tools.CaptureScreenshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot: %w", err)
+	}
+	img = downscale(img, visionMaxWidth)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: visionJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("encode screenshot: %w", err)
+	}
+	return &llm.Image{MediaType: "image/jpeg", Data: buf.Bytes()}, nil
+}
+
+// downscale resizes img to maxWidth keeping aspect ratio (box filter); smaller images are returned as is
+func downscale(img image.Image, maxWidth int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxWidth || w == 0 {
+		return img
+	}
+	nw := maxWidth
+	nh := h * nw / w
+	if nh == 0 {
+		nh = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		sy0 := b.Min.Y + y*h/nh
+		sy1 := b.Min.Y + (y+1)*h/nh
+		for x := 0; x < nw; x++ {
+			sx0 := b.Min.X + x*w/nw
+			sx1 := b.Min.X + (x+1)*w/nw
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+cr, g+cg, bl+cb, a+ca
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
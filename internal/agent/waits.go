@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// otherActionWait caps the settle after actions without their own budget (read, keys...)
+	otherActionWait = 800 * time.Millisecond
+	// afterErrorWait caps the settle before re-observing a page after a failed action
+	afterErrorWait = 500 * time.Millisecond
+	// minSettle is slept instead when the DOM-stability wait itself fails
+	minSettle = 300 * time.Millisecond
+)
+
+// Waits are budgets for the page to settle after an action. WaitForStableDOM returns as soon
+// as the page is quiet, so they only bound slow pages; zero fields take the defaults.
+type Waits struct {
+	AfterClick    time.Duration // Clicks and request_user_input (default 1.8s)
+	AfterFill     time.Duration // Typing, form validation re-renders (default 3s)
+	AfterScroll   time.Duration // Virtual lists rendering new rows (default 1s)
+	AfterNavigate time.Duration // navigate, go_back, go_forward (default 5s)
+}
+
+// defaultWaits match the fixed sleeps they replaced
+var defaultWaits = Waits{
+	AfterClick:    1800 * time.Millisecond,
+	AfterFill:     3 * time.Second,
+	AfterScroll:   time.Second,
+	AfterNavigate: 5 * time.Second,
+}
+
+// budget returns the settle budget for an action
+func (w Waits) budget(action string) time.Duration {
+	pick := func(v, def time.Duration) time.Duration {
+		if v > 0 {
+			return v
+		}
+		return def
+	}
+	switch action {
+	case "click_by_index", "click_text", "click_role", "click_selector", "click_text_fuzzy",
+		"click_coordinates", "fill_and_submit", "request_user_input":
+		return pick(w.AfterClick, defaultWaits.AfterClick)
+	case "fill", "fill_by_index", "set_date":
+		return pick(w.AfterFill, defaultWaits.AfterFill)
+	case "scroll_page", "scroll_to_element":
+		return pick(w.AfterScroll, defaultWaits.AfterScroll)
+	case "navigate", "go_back", "go_forward":
+		return pick(w.AfterNavigate, defaultWaits.AfterNavigate)
+	}
+	return otherActionWait
+}
+
+// waitAfterAction lets the page settle after action within its Config.Waits budget
+func (o *Orchestrator) waitAfterAction(ctx context.Context, action string) {
+	o.settle(ctx, o.cfg.Waits.budget(action))
+}
+
+// settle waits for a stable DOM up to budget, sleeping only briefly if that wait fails
+func (o *Orchestrator) settle(ctx context.Context, budget time.Duration) {
+	err := o.tools.WaitForStableDOM(ctx, budget)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	o.logger.Debug().Err(err).Msg("wait for stable DOM")
+	pause := minSettle
+	if budget < pause {
+		pause = budget
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(pause):
+	}
+}
@@ -0,0 +1,42 @@
+package browser
+
+import "context"
+
+// PageActivity tells whether the page is still working on its first render: an SPA served as an
+// empty shell fills itself in only after its scripts ran and its data arrived
+type PageActivity struct {
+	Loading   bool `json:"loading"`    // document.readyState is not complete yet
+	Requests  int  `json:"requests"`   // Resources in flight or finished within the last second
+	EmptyRoot bool `json:"empty_root"` // An app mount point (#root, #app, #__next...) is empty while scripts exist
+}
+
+// Busy reports whether the page may still render content
+func (a PageActivity) Busy() bool {
+	return a.Loading || a.Requests > 0 || a.EmptyRoot
+}
+
+// activityScript reads PageActivity from the main document
+const activityScript = `() => {
+	const now = performance.now();
+	const requests = performance.getEntriesByType('resource').filter(e => e.responseEnd === 0 || now - e.responseEnd < 1000).length;
+	const root = document.querySelector('#root, #app, #__next, #__nuxt, #svelte, [data-reactroot], app-root');
+	return {
+		loading: document.readyState !== 'complete',
+		requests,
+		empty_root: !!root && document.scripts.length > 0 && !(root.innerText || '').trim() && root.querySelectorAll('input, button, a, img').length === 0,
+	};
+}`
+
+// Activity reports whether the page is still loading or rendering (see PageActivity)
+func (c *controller) Activity(ctx context.Context) (PageActivity, error) {
+	if err := ctx.Err(); err != nil {
+		return PageActivity{}, err
+	}
+	val, err := c.page.Evaluate(activityScript)
+	if err != nil {
+		return PageActivity{}, wrap(err)
+	}
+	var a PageActivity
+	err = decodeScriptResult(val, &a)
+	return a, err
+}
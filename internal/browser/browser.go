@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
@@ -15,7 +16,9 @@ const (
 	defaultNavTimeout   = 30 * time.Second
 	defaultActionTime   = 10 * time.Second
 	headlessEnv         = "AGENT_HEADLESS"
+	timezoneEnv         = "AGENT_TIMEZONE" // IANA zone emulated by browser contexts, e.g. Europe/Berlin
 	defaultScrollAmount = 600
+	screenshotTimeout   = 3 * time.Second // Short timeout - a hung page must not stall the run
 )
 
 // Controller exposes minimal browser actions to the agent.
@@ -23,20 +26,71 @@ type Controller interface {
 	Close(ctx context.Context) error
 	Navigate(ctx context.Context, url string) error
 	GoBack(ctx context.Context) error
+	GoForward(ctx context.Context) error
 	ClickText(ctx context.Context, text string, exact bool) error
 	ClickRole(ctx context.Context, role, name string, exact bool) error
 	Click(ctx context.Context, selector string) error
 	ClickByCoordinates(ctx context.Context, x, y float64) error
 	ClickByTextFuzzy(ctx context.Context, text string) error
 	Fill(ctx context.Context, selector, text string) error
+	PressKey(ctx context.Context, selector, key string) error // Press key on selector (or focused element if empty)
+	// FillAndSubmit fills, presses Enter on the same element and reports navigation or DOM change
+	FillAndSubmit(ctx context.Context, selector, text string) (SubmitResult, error)
+	// SetDate fills a date input or picker; returns the strategy used and the value read back
+	SetDate(ctx context.Context, selector string, date time.Time) (strategy, value string, err error)
+	// ChooseComboboxOption opens an ARIA combobox (or native <select>) and picks the option by text
+	ChooseComboboxOption(ctx context.Context, selector, option string) (ComboboxResult, error)
+	// UploadFile attaches a local file to a file input or the chooser a button opens; returns the strategy
+	UploadFile(ctx context.Context, selector, path string) (strategy string, err error)
 	Read(ctx context.Context, selector string) (string, error)
 	Scroll(ctx context.Context, direction string, distance int) (int, error)
 	ScrollToElement(ctx context.Context, selector string) error
 	WaitFor(ctx context.Context, selector string, timeout time.Duration) error
+	WaitForEnabled(ctx context.Context, selector string, timeout time.Duration) (bool, error) // Wait for disabled element to become enabled
 	WaitForLazyListItems(ctx context.Context, timeout time.Duration) error
 	WaitForStableDOM(ctx context.Context, timeout time.Duration) error
-	SaveState(ctx context.Context, path string) error
-	Hover(ctx context.Context, selector string) error // Hover over element to reveal hidden elements
+	// Activity reports whether the page is still loading or rendering its first content
+	Activity(ctx context.Context) (PageActivity, error)
+	// SaveState returns the path actually written (may be a workdir fallback)
+	SaveState(ctx context.Context, path string) (string, error)
+	// HoverReveal hovers and waits for the page to react; reports visible interactive elements before and after
+	HoverReveal(ctx context.Context, selector string) (HoverResult, error)
+	Hover(ctx context.Context, selector string) error          // Hover over element to reveal hidden elements
+	DismissConsent(ctx context.Context) (ConsentResult, error) // Dismiss cookie consent banner if present
+	DescribeElement(ctx context.Context, target ElementTarget) (ElementInfo, error)
+	Highlight(ctx context.Context, target ElementTarget) error // Highlight element in headed mode
+	BringToFront(ctx context.Context) error                    // Raise the browser window in headed mode (captcha, 2FA)
+	// ExtractTable reads a table or ARIA grid by selector, or the largest one when selector is ""
+	ExtractTable(ctx context.Context, selector string, maxRows int) (Table, error)
+	// ReadMarkdown renders the page or the element matching selector as Markdown, cut to maxChars
+	ReadMarkdown(ctx context.Context, selector string, maxChars int) (string, error)
+	// MatchTexts lists texts of all elements matching selector (ambiguous locator diagnostics)
+	MatchTexts(ctx context.Context, selector string, limit int) ([]string, error)
+	// Screenshot captures PNG, written to path if not empty
+	Screenshot(ctx context.Context, path string, fullPage bool) ([]byte, error)
+	Recreate(ctx context.Context) error       // Replace a dead context (same options, cookies, last URL)
+	EnableFormGuard(allowlist []string) error // Block cross-site form POSTs unless allowlisted
+	TakeBlockedSubmissions() []string         // Drain notes about blocked submissions
+	TakeNotes() []string                      // Drain notes about what the browser did on its own (closed popups, lost cookies)
+	// EnableDomainGuard aborts navigations (and redirects) to hosts the policy refuses
+	EnableDomainGuard(policy DomainPolicy) error
+	// EnableOriginHeaders adds extra request headers only for their origins (staging tokens)
+	EnableOriginHeaders(headers OriginHeaders) error
+	// EnableDownloads saves downloaded files into dir ("" leaves downloads disabled)
+	EnableDownloads(dir string) error
+	// WaitForDownload returns the next finished download, waiting up to timeout for one
+	WaitForDownload(ctx context.Context, timeout time.Duration) (Download, error)
+	TakeDownloads() []Download // Drain finished downloads nobody waited for
+	// LimitPages closes pages opened beyond max in this and recreated contexts, 0 = no limit
+	LimitPages(max int)
+	// ResourceUsage reports open pages and used JS heap of the context
+	ResourceUsage(ctx context.Context) (ResourceUsage, error)
+	// ListTabs returns the open pages in opening order; SwitchTab and CloseTab take their indices
+	ListTabs(ctx context.Context) ([]Tab, error)
+	SwitchTab(ctx context.Context, index int) (Tab, error)
+	CloseTab(ctx context.Context, index int) (Tab, error) // Returns the tab active afterwards
+	// AdoptOpenedTab follows a tab the active page opened (target=_blank) or a self-closed page
+	AdoptOpenedTab(ctx context.Context) (TabSwitch, bool)
 	Page() playwright.Page
 }
 
@@ -56,6 +110,15 @@ func NewLauncher(ctx context.Context) (*Launcher, error) {
 		return nil, fmt.Errorf("start playwright: %w", err)
 	}
 	headless := parseBoolEnv(headlessEnv, false)
+	browser, err := launchChromium(pw, headless)
+	if err != nil {
+		_ = pw.Stop()
+		return nil, err
+	}
+	return &Launcher{pw: pw, browser: browser, headless: headless}, nil
+}
+
+func launchChromium(pw *playwright.Playwright, headless bool) (playwright.Browser, error) {
 	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
 		Headless: playwright.Bool(headless),
 		Args: []string{
@@ -64,15 +127,19 @@ func NewLauncher(ctx context.Context) (*Launcher, error) {
 		},
 	})
 	if err != nil {
-		_ = pw.Stop()
 		return nil, fmt.Errorf("launch chromium: %w", err)
 	}
-	return &Launcher{pw: pw, browser: browser, headless: headless}, nil
+	return browser, nil
 }
 
 func (l *Launcher) NewController(ctx context.Context, storagePath string) (Controller, error) {
 	opts := playwright.BrowserNewContextOptions{
 		IgnoreHttpsErrors: playwright.Bool(true),
+		AcceptDownloads:   playwright.Bool(true),
+	}
+	// Sites render dates in the browser timezone - pin it when the machine's zone differs from the user's
+	if tz := strings.TrimSpace(os.Getenv(timezoneEnv)); tz != "" {
+		opts.TimezoneId = playwright.String(tz)
 	}
 	hasStorageState := false
 	if strings.TrimSpace(storagePath) != "" {
@@ -95,10 +162,24 @@ func (l *Launcher) NewController(ctx context.Context, storagePath string) (Contr
 
 	// If storage state was loaded, page might be on about:blank
 	// This is normal - agent will navigate to the site and cookies will be applied
-	ctrl := &controller{context: context, page: page, hasStorageState: hasStorageState}
+	ctrl := &controller{
+		browser:         l.browser,
+		contextOpts:     opts,
+		context:         context,
+		page:            page,
+		hasStorageState: hasStorageState,
+		headless:        l.headless,
+	}
+	ctrl.watchTabs(context)
+	ctrl.applyWindowBounds()
 	return ctrl, nil
 }
 
+// Headless reports whether the browser was launched headless (AGENT_HEADLESS)
+func (l *Launcher) Headless() bool {
+	return l.headless
+}
+
 func (l *Launcher) Close() error {
 	if l.browser != nil {
 		_ = l.browser.Close()
@@ -110,9 +191,25 @@ func (l *Launcher) Close() error {
 }
 
 type controller struct {
+	browser         playwright.Browser
+	contextOpts     playwright.BrowserNewContextOptions // Kept to recreate a dead context with the same options
 	context         playwright.BrowserContext
 	page            playwright.Page
 	hasStorageState bool // Track if storage state was loaded
+	headless        bool
+	formGuard       *formGuard // Cross-site form submission block, nil when disabled
+	// Navigation block outside the domain policy, nil when disabled
+	domainGuard *domainGuard
+	// Open page cap per context (LimitPages), 0 = no limit
+	maxPages int
+	// Per-origin extra headers, nil when disabled
+	headerInjector *headerInjector
+	tabsMu         sync.Mutex                          // Guards page switches against the OnPage handler (watchTabs)
+	openedTab      playwright.Page                     // Last page opened by the active page, taken by AdoptOpenedTab
+	openers        map[playwright.Page]playwright.Page // Popup -> the page that opened it
+	downloads      *downloads                          // Saved downloads, nil when disabled
+	notesMu        sync.Mutex
+	notes          []string // Pending browser notes, drained by TakeNotes
 }
 
 func (c *controller) Page() playwright.Page {
@@ -151,6 +248,14 @@ func (c *controller) GoBack(ctx context.Context) error {
 	return wrap(err)
 }
 
+func (c *controller) GoForward(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := c.page.GoForward()
+	return wrap(err)
+}
+
 func (c *controller) ClickText(ctx context.Context, text string, exact bool) error {
 	if err := ctx.Err(); err != nil {
 		return err
@@ -256,6 +361,44 @@ func (c *controller) Hover(ctx context.Context, selector string) error {
 	return wrap(first.Hover())
 }
 
+// hoverSettle bounds the wait for menus and row actions a hover reveals
+const hoverSettle = time.Second
+
+// HoverResult counts the visible interactive elements around a hover
+type HoverResult struct {
+	Before, After int
+}
+
+// countInteractiveScript counts the visible interactive elements of the main document
+const countInteractiveScript = `() => {
+	const sel = 'a[href], button, input, select, textarea, [role=button], [role=link], [role=menuitem], [role=option], [role=tab], [tabindex]';
+	let n = 0;
+	for (const el of document.querySelectorAll(sel)) {
+		const r = el.getBoundingClientRect();
+		if (r.width > 0 && r.height > 0 && getComputedStyle(el).visibility !== 'hidden') n++;
+	}
+	return n;
+}`
+
+// HoverReveal hovers over selector, lets the page settle (menus, Gmail-style row actions) and
+// counts the visible interactive elements before and after
+func (c *controller) HoverReveal(ctx context.Context, selector string) (HoverResult, error) {
+	var res HoverResult
+	if n, err := c.page.Evaluate(countInteractiveScript); err == nil {
+		res.Before, _ = n.(int)
+	}
+	if err := c.Hover(ctx, selector); err != nil {
+		return HoverResult{}, err
+	}
+	if err := c.WaitForStableDOM(ctx, hoverSettle); err != nil && ctx.Err() != nil {
+		return HoverResult{}, ctx.Err()
+	}
+	if n, err := c.page.Evaluate(countInteractiveScript); err == nil {
+		res.After, _ = n.(int)
+	}
+	return res, nil
+}
+
 // WaitForLazyListItems waits for lazy-loaded list items to appear (universal solution)
 func (c *controller) WaitForLazyListItems(ctx context.Context, timeout time.Duration) error {
 	if err := ctx.Err(); err != nil {
@@ -311,13 +454,14 @@ func (c *controller) WaitForLazyListItems(ctx context.Context, timeout time.Dura
 	// Fallback: search by text content for list-like structures
 	fallbackScript := `(limit) => {
 		const out = [];
+		const cut = (s, n) => s.length <= n ? s : Array.from(s).slice(0, n).join(""); // By code points
 		function scan(root) {
 			const nodes = root.querySelectorAll("div, li, span, a, [role='option'], [role='listitem'], [role='row']");
 			for (const n of nodes) {
 				try {
 					const t = (n.innerText || n.textContent || "").trim();
 					if (t && t.length > 10 && t.length < 500) {
-						out.push(t.slice(0,200));
+						out.push(cut(t, 200));
 						if (out.length >= limit) return;
 					}
 				} catch(e){}
@@ -554,6 +698,38 @@ func (c *controller) WaitFor(ctx context.Context, selector string, timeout time.
 	}))
 }
 
+// WaitForEnabled polls element until it is enabled (no disabled / aria-disabled) or timeout expires.
+// Returns false without error if element stayed disabled - usually a sign of a form validation error.
+func (c *controller) WaitForEnabled(ctx context.Context, selector string, timeout time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	first := c.page.Locator(selector).First()
+	predicate := `(el) => !el.disabled && el.getAttribute("aria-disabled") !== "true"`
+	deadline := time.Now().Add(timeout)
+	for {
+		val, err := first.Evaluate(predicate, nil, playwright.LocatorEvaluateOptions{
+			Timeout: playwright.Float(1000),
+		})
+		if err == nil {
+			if enabled, ok := val.(bool); ok && enabled {
+				return true, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 // WaitForStableDOM waits for DOM to stabilize (no mutations for a period)
 // This replaces fixed sleep() calls with event-driven waiting
 func (c *controller) WaitForStableDOM(ctx context.Context, timeout time.Duration) error {
@@ -577,14 +753,21 @@ func (c *controller) WaitForStableDOM(ctx context.Context, timeout time.Duration
 		})
 	}
 
-	// Additional: wait for no DOM mutations for 300ms (like MutationObserver)
+	// Additional: wait for no DOM mutations for 300ms (like MutationObserver),
+	// capped by the timeout so constantly animating pages don't block
 	script := `
-		() => {
+		(maxMs) => {
 			return new Promise((resolve) => {
 				let timeoutId;
+				const cap = setTimeout(() => {
+					clearTimeout(timeoutId);
+					observer.disconnect();
+					resolve();
+				}, maxMs);
 				const observer = new MutationObserver(() => {
 					clearTimeout(timeoutId);
 					timeoutId = setTimeout(() => {
+						clearTimeout(cap);
 						observer.disconnect();
 						resolve();
 					}, 300);
@@ -596,29 +779,51 @@ func (c *controller) WaitForStableDOM(ctx context.Context, timeout time.Duration
 					attributeOldValue: false
 				});
 				timeoutId = setTimeout(() => {
+					clearTimeout(cap);
 					observer.disconnect();
 					resolve();
 				}, 300);
 			});
 		}
 	`
-	_, err := c.page.Evaluate(script)
+	_, err := c.page.Evaluate(script, timeout.Milliseconds())
 	return wrap(err)
 }
 
-func (c *controller) SaveState(ctx context.Context, path string) error {
+// Screenshot captures the page as PNG; playwright writes it to path when path is not empty
+func (c *controller) Screenshot(ctx context.Context, path string, fullPage bool) ([]byte, error) {
 	if err := ctx.Err(); err != nil {
-		return err
+		return nil, err
+	}
+	opts := playwright.PageScreenshotOptions{
+		FullPage: playwright.Bool(fullPage),
+		Timeout:  playwright.Float(float64(screenshotTimeout.Milliseconds())),
+	}
+	if path != "" {
+		opts.Path = playwright.String(path)
+	}
+	data, err := c.page.Screenshot(opts)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	return data, nil
+}
+
+// SaveState writes storage state atomically and returns the path actually used.
+// If the requested path fails (read-only fs, long path), it retries once in the workdir.
+func (c *controller) SaveState(ctx context.Context, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
 	state, err := c.context.StorageState()
 	if err != nil {
-		return wrap(err)
+		return "", wrap(err)
 	}
 	data, err := json.Marshal(state)
 	if err != nil {
-		return fmt.Errorf("marshal storage: %w", err)
+		return "", fmt.Errorf("marshal storage: %w", err)
 	}
-	return os.WriteFile(path, data, 0o600)
+	return saveStateFile(path, data)
 }
 
 func wrap(err error) error {
@@ -0,0 +1,44 @@
+// Package browsertest runs tests against a real Chromium over static HTML fixtures.
+// Such tests need the Playwright browsers installed and are skipped unless AGENT_BROWSER_TESTS=1.
+package browsertest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// Env enables the browser tests
+const Env = "AGENT_BROWSER_TESTS"
+
+// Open serves dir (usually "testdata") over HTTP and opens page, a path inside it, in a fresh
+// headless browser. It returns the controller and the server URL; both close with the test.
+func Open(t testing.TB, dir, page string) (browser.Controller, string) {
+	t.Helper()
+	if os.Getenv(Env) != "1" {
+		t.Skipf("set %s=1 to run tests against a real browser", Env)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+	t.Setenv("AGENT_HEADLESS", "true")
+
+	ctx := context.Background()
+	launcher, err := browser.NewLauncher(ctx)
+	if err != nil {
+		t.Fatalf("launch browser: %v", err)
+	}
+	t.Cleanup(func() { _ = launcher.Close() })
+	ctrl, err := launcher.NewController(ctx, "")
+	if err != nil {
+		t.Fatalf("browser controller: %v", err)
+	}
+	t.Cleanup(func() { _ = ctrl.Close(ctx) })
+	if err := ctrl.Navigate(ctx, server.URL+"/"+page); err != nil {
+		t.Fatalf("open %s: %v", page, err)
+	}
+	return ctrl, server.URL
+}
@@ -0,0 +1,272 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ChooseComboboxOption strategies reported to the planner
+const (
+	ComboByTyping = "typing" // Option text typed to filter the list, matching option clicked
+	ComboByArrows = "arrows" // ArrowDown through the list until the option was highlighted, then Enter
+	ComboByNative = "native" // Native <select>: option selected by script with input/change events
+)
+
+const (
+	// comboboxWait bounds the wait for the listbox to render the matching option
+	comboboxWait = 3 * time.Second
+	// maxComboboxArrows bounds ArrowDown presses of the keyboard fallback
+	maxComboboxArrows = 60
+)
+
+// ComboboxResult is the outcome of ChooseComboboxOption
+type ComboboxResult struct {
+	Option   string // Text of the option picked
+	Strategy string
+	Value    string // What the combobox shows afterwards: input value, single value or chips
+	Verified bool   // Value contains the option text
+}
+
+// comboboxInputScript prepares a combobox: selects the option of a native <select> by text
+// (returns {native, option}), otherwise marks the element it can type into - the element itself
+// or an input inside a container (react-select, downshift) - with data-agent-combo-input
+const comboboxInputScript = `(el, want) => {
+	const norm = (s) => (s || '').replace(/\s+/g, ' ').trim().toLowerCase();
+	if (el.localName === 'select') {
+		const w = norm(want);
+		const opts = Array.from(el.options).filter(o => !o.disabled);
+		const o = opts.find(o => norm(o.text) === w) || opts.find(o => norm(o.text).startsWith(w)) || opts.find(o => norm(o.text).includes(w));
+		if (!o) return {native: true, option: '', options: opts.slice(0, 10).map(o => o.text.trim())};
+		o.selected = true;
+		el.dispatchEvent(new Event('input', {bubbles: true}));
+		el.dispatchEvent(new Event('change', {bubbles: true}));
+		return {native: true, option: o.text.trim()};
+	}
+	document.querySelectorAll('[data-agent-combo-input]').forEach(e => e.removeAttribute('data-agent-combo-input'));
+	const typable = (e) => !!e && (e.isContentEditable || (e.localName === 'input' && !e.readOnly && !e.disabled &&
+		!['checkbox', 'radio', 'button', 'submit', 'file', 'hidden'].includes(e.type)));
+	let input = typable(el) ? el : el.querySelector('input:not([type=hidden]), [contenteditable=true]');
+	if (!typable(input)) input = null;
+	if (input) input.setAttribute('data-agent-combo-input', '1');
+	return {native: false, typable: !!input};
+}`
+
+// comboboxOptionsScript reads the open listbox of the combobox: the one its aria-controls /
+// aria-owns point to, otherwise any visible role=option (menus are often portals at the end of
+// body). Marks the best match for want (exact, prefix, then substring) with data-agent-option.
+const comboboxOptionsScript = `(el, want) => {
+	document.querySelectorAll('[data-agent-option]').forEach(e => e.removeAttribute('data-agent-option'));
+	const norm = (s) => (s || '').replace(/\s+/g, ' ').trim().toLowerCase();
+	const text = (e) => (e.innerText || e.textContent || '').replace(/\s+/g, ' ').trim();
+	const visible = (e) => { const r = e.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const owners = [el, ...el.querySelectorAll('[aria-controls], [aria-owns], [aria-activedescendant]')];
+	const ids = owners.flatMap(e => ((e.getAttribute('aria-controls') || '') + ' ' + (e.getAttribute('aria-owns') || '')).split(/\s+/)).filter(Boolean);
+	let scopes = ids.map(id => document.getElementById(id)).filter(Boolean);
+	if (!scopes.some(s => s.querySelector('[role=option]'))) scopes = [document];
+	const options = scopes.flatMap(s => Array.from(s.querySelectorAll('[role=option]')))
+		.filter(o => visible(o) && o.getAttribute('aria-disabled') !== 'true');
+	const w = norm(want);
+	let best = null, rank = 0;
+	for (const o of options) {
+		const t = norm(text(o));
+		const r = t === w ? 3 : t.startsWith(w) ? 2 : t.includes(w) ? 1 : 0;
+		if (r > rank) { best = o; rank = r; }
+	}
+	if (best) best.setAttribute('data-agent-option', '1');
+	// The option highlighted by keyboard navigation
+	let active = null;
+	for (const e of owners) {
+		const id = e.getAttribute('aria-activedescendant');
+		if (id && document.getElementById(id)) { active = document.getElementById(id); break; }
+	}
+	if (!active) active = options.find(o => /(^|[-_\s])(focused|highlighted|active)/i.test(o.className));
+	return {
+		count: options.length,
+		match: best ? text(best) : '',
+		active: active ? text(active) : '',
+		options: options.slice(0, 10).map(text),
+	};
+}`
+
+// comboboxValueScript reads what the combobox shows: its own value, the typing input's value
+// and the text of the nearest ancestor that has any (single value, multi-select chips)
+const comboboxValueScript = `(el) => {
+	const parts = [];
+	if (el.value) parts.push(el.value);
+	const input = document.querySelector('[data-agent-combo-input]');
+	if (input && input !== el && input.value) parts.push(input.value);
+	let node = el;
+	for (let i = 0; i < 4 && node; i++, node = node.parentElement) {
+		const t = (node.innerText || '').replace(/\s+/g, ' ').trim();
+		if (t) { parts.push(t); break; }
+	}
+	return parts.join(' | ');
+}`
+
+// comboboxOptions is the decoded comboboxOptionsScript result
+type comboboxOptions struct {
+	Count   int      `json:"count"`
+	Match   string   `json:"match"`
+	Active  string   `json:"active"`
+	Options []string `json:"options"`
+}
+
+// ChooseComboboxOption picks option in an ARIA combobox (react-select style) whose options exist
+// only while it is open: opens it, types the option text to filter, clicks the matching
+// role=option (Enter when the click fails) and falls back to ArrowDown navigation when typing
+// doesn't filter. Native <select> elements are set directly. For multi-selects call it once
+// per value. The value shown afterwards is read back into the result; the error of a missing
+// option lists the first options the list shows.
+func (c *controller) ChooseComboboxOption(ctx context.Context, selector, option string) (ComboboxResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ComboboxResult{}, err
+	}
+	first := c.page.Locator(selector).First()
+	if err := first.WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateVisible}); err != nil {
+		return ComboboxResult{}, wrap(err)
+	}
+	val, err := first.Evaluate(comboboxInputScript, option)
+	if err != nil {
+		return ComboboxResult{}, wrap(err)
+	}
+	var prep struct {
+		Native  bool     `json:"native"`
+		Option  string   `json:"option"`
+		Options []string `json:"options"`
+		Typable bool     `json:"typable"`
+	}
+	if err := decodeScriptResult(val, &prep); err != nil {
+		return ComboboxResult{}, err
+	}
+	if prep.Native {
+		if prep.Option == "" {
+			return ComboboxResult{}, fmt.Errorf("option %q not found in the <select> (options: %s)", option, strings.Join(prep.Options, "; "))
+		}
+		return ComboboxResult{Option: prep.Option, Strategy: ComboByNative, Value: prep.Option, Verified: true}, nil
+	}
+
+	if err := first.Click(); err != nil {
+		return ComboboxResult{}, wrap(err)
+	}
+	input := first
+	if prep.Typable {
+		input = c.page.Locator(`[data-agent-combo-input="1"]`).First()
+		_ = input.Fill("")
+		if err := input.PressSequentially(option, playwright.LocatorPressSequentiallyOptions{Delay: playwright.Float(20)}); err != nil {
+			return ComboboxResult{}, wrap(err)
+		}
+	}
+
+	// Strategy 1: click the option the filtered list shows
+	opts, err := c.waitComboboxOption(ctx, first, option)
+	if err != nil {
+		return ComboboxResult{}, err
+	}
+	if opts.Match != "" {
+		if err := c.page.Locator(`[data-agent-option="1"]`).First().Click(); err != nil {
+			if err := input.Press("Enter"); err != nil {
+				return ComboboxResult{}, wrap(err)
+			}
+		}
+		return c.comboboxResult(first, opts.Match, ComboByTyping), nil
+	}
+
+	// Strategy 2: the list doesn't filter by typing - walk it with the arrow keys
+	if prep.Typable {
+		_ = input.Fill("")
+	}
+	want := normalizeOption(option)
+	prev := ""
+	for i := 0; i < maxComboboxArrows; i++ {
+		if err := ctx.Err(); err != nil {
+			return ComboboxResult{}, err
+		}
+		if err := input.Press("ArrowDown"); err != nil {
+			return ComboboxResult{}, wrap(err)
+		}
+		opts, err = c.comboboxOptions(first, option)
+		if err != nil {
+			return ComboboxResult{}, err
+		}
+		active := normalizeOption(opts.Active)
+		if active != "" && strings.Contains(active, want) {
+			if err := input.Press("Enter"); err != nil {
+				return ComboboxResult{}, wrap(err)
+			}
+			return c.comboboxResult(first, opts.Active, ComboByArrows), nil
+		}
+		if i > 0 && active == prev {
+			break // Last option reached (or nothing is highlighted)
+		}
+		prev = active
+	}
+	_ = input.Press("Escape")
+	if opts.Count == 0 {
+		return ComboboxResult{}, fmt.Errorf("combobox %s opened no options with role=option - click it and pick the option from the snapshot", selector)
+	}
+	return ComboboxResult{}, fmt.Errorf("option %q not found among %d options (first: %s)", option, opts.Count, strings.Join(opts.Options, "; "))
+}
+
+// waitComboboxOption polls the listbox until an option matches want or comboboxWait passes
+func (c *controller) waitComboboxOption(ctx context.Context, combo playwright.Locator, want string) (comboboxOptions, error) {
+	deadline := time.Now().Add(comboboxWait)
+	for {
+		opts, err := c.comboboxOptions(combo, want)
+		if err != nil || opts.Match != "" || time.Now().After(deadline) {
+			return opts, err
+		}
+		select {
+		case <-ctx.Done():
+			return comboboxOptions{}, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (c *controller) comboboxOptions(combo playwright.Locator, want string) (comboboxOptions, error) {
+	val, err := combo.Evaluate(comboboxOptionsScript, want)
+	if err != nil {
+		return comboboxOptions{}, wrap(err)
+	}
+	var opts comboboxOptions
+	err = decodeScriptResult(val, &opts)
+	return opts, err
+}
+
+// comboboxResult reads the combobox back once the widget re-rendered the chosen value
+func (c *controller) comboboxResult(combo playwright.Locator, option, strategy string) ComboboxResult {
+	res := ComboboxResult{Option: option, Strategy: strategy}
+	deadline := time.Now().Add(comboboxWait)
+	for {
+		if val, err := combo.Evaluate(comboboxValueScript, nil); err == nil {
+			res.Value = fmt.Sprint(val)
+		}
+		res.Verified = strings.Contains(normalizeOption(res.Value), normalizeOption(option))
+		if res.Verified || time.Now().After(deadline) {
+			return res
+		}
+		time.Sleep(150 * time.Millisecond)
+	}
+}
+
+// normalizeOption lowercases and collapses whitespace like the option scripts do
+func normalizeOption(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// decodeScriptResult converts an Evaluate result (maps and slices) into out
+func decodeScriptResult(val interface{}, out interface{}) error {
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode script result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package browser
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ConsentResult describes how a cookie consent banner was handled
+type ConsentResult struct {
+	Found    bool   // Banner with a known button was found
+	Strategy string // "accept" or "reject" - strategy that was used last
+	Button   string // Text of the clicked button
+	Verified bool   // Banner disappeared after the click
+}
+
+// consentScript finds a visible cookie/consent banner and clicks accept/reject button.
+// A banner is an element named cookie/consent/gdpr that is also built like one: a dialog
+// (role, <dialog>, aria-modal) or a fixed/sticky overlay, mentioning cookies or consent and
+// holding buttons. A "cookie" class on a recipe card or a footer link is not a banner.
+// Mode "check" only reports whether a banner is visible.
+const consentScript = `(mode) => {
+	const acceptWords = ["accept all", "accept cookies", "accept", "allow all", "allow", "i agree", "agree", "got it", "ok",
+		"принять все", "принять", "согласен", "согласна", "разрешить", "понятно", "хорошо"];
+	const rejectWords = ["reject all", "reject", "decline", "deny", "only necessary", "necessary only",
+		"отклонить", "отказаться", "только необходимые"];
+	const containerSel = "[id*='cookie' i],[class*='cookie' i],[id*='consent' i],[class*='consent' i],[id*='gdpr' i],[class*='gdpr' i],[aria-label*='cookie' i]";
+	const visible = (el) => {
+		const r = el.getBoundingClientRect();
+		const s = window.getComputedStyle(el);
+		return r.width > 0 && r.height > 0 && s.visibility !== "hidden" && s.display !== "none";
+	};
+	const buttonSel = "button,a,[role='button'],input[type='button'],input[type='submit']";
+	const consentText = /cookie|consent|gdpr|куки|персональн/i;
+	const overlay = (el) => {
+		for (let e = el; e && e !== document.body; e = e.parentElement) {
+			const role = (e.getAttribute("role") || "").toLowerCase();
+			if (role === "dialog" || role === "alertdialog" || e.tagName === "DIALOG" || e.getAttribute("aria-modal") === "true") return true;
+			const pos = window.getComputedStyle(e).position;
+			if (pos === "fixed" || pos === "sticky") return true;
+		}
+		return false;
+	};
+	const isBanner = (el) => visible(el) && overlay(el) && consentText.test(el.innerText || "") &&
+		Array.from(el.querySelectorAll(buttonSel)).some(visible);
+	// Outermost matches only - a banner's inner "cookie-text" div is the same banner
+	const banners = Array.from(document.querySelectorAll(containerSel)).filter(isBanner)
+		.filter((el, _, all) => !all.some(other => other !== el && other.contains(el)));
+	if (mode === "check") return banners.length > 0 ? "visible" : "";
+	const words = mode === "reject" ? rejectWords : acceptWords;
+	const label = (b) => (b.innerText || b.value || b.getAttribute("aria-label") || "").trim().toLowerCase();
+	for (const banner of banners) {
+		const buttons = Array.from(banner.querySelectorAll(buttonSel)).filter(visible);
+		for (const w of words) {
+			const btn = buttons.find(b => label(b) === w) || buttons.find(b => label(b).startsWith(w));
+			if (btn) {
+				const text = (btn.innerText || btn.value || btn.getAttribute("aria-label") || w).trim();
+				btn.click();
+				return text;
+			}
+		}
+	}
+	return "";
+}`
+
+// DismissConsent clicks "accept" on a cookie consent banner and verifies after 1s that it disappeared.
+// Some banners re-render when the click happens before their JS stored the choice - then it retries once with "reject".
+func (c *controller) DismissConsent(ctx context.Context) (ConsentResult, error) {
+	var res ConsentResult
+	for _, strategy := range []string{"accept", "reject"} {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		val, err := c.page.Evaluate(consentScript, strategy)
+		if err != nil {
+			return res, wrap(err)
+		}
+		button, _ := val.(string)
+		if strings.TrimSpace(button) == "" {
+			// No banner or no button for this strategy
+			if !res.Found {
+				continue
+			}
+			return res, nil
+		}
+		res.Found = true
+		res.Strategy = strategy
+		res.Button = button
+
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
+		check, err := c.page.Evaluate(consentScript, "check")
+		if err != nil {
+			return res, wrap(err)
+		}
+		if visible, _ := check.(string); visible == "" {
+			res.Verified = true
+			return res, nil
+		}
+	}
+	return res, nil
+}
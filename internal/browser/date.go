@@ -0,0 +1,207 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// SetDate strategies reported to the planner
+const (
+	DateByScript   = "script"   // Value set directly with input/change events
+	DateByCalendar = "calendar" // Day clicked in the visible calendar widget
+)
+
+// maxCalendarMonths bounds month navigation in the calendar widget
+const maxCalendarMonths = 36
+
+// setDateScript sets the value through the native setter (React/Vue see the change),
+// preferring the library API when the input is a flatpickr instance.
+// The value format follows the input type or its current value/placeholder.
+const setDateScript = `(el, iso) => {
+	const [y, m, d] = iso.split('-');
+	if (el._flatpickr) {
+		el._flatpickr.setDate(iso, true);
+		return el.value;
+	}
+	let value = iso;
+	if (el.type !== 'date') {
+		const hint = (el.value || el.placeholder || '').toLowerCase();
+		if (/^\d{1,2}\.\d{1,2}\.\d{4}$/.test(el.value) || /^(дд|dd)\.(мм|mm)\./.test(hint)) value = d + '.' + m + '.' + y;
+		else if (/^\d{1,2}\/\d{1,2}\/\d{4}$/.test(el.value) || /^mm\/dd\//.test(hint)) value = m + '/' + d + '/' + y;
+		else if (/^(dd|дд)\/(mm|мм)\//.test(hint)) value = d + '/' + m + '/' + y;
+	}
+	el.removeAttribute('readonly');
+	const setter = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value').set;
+	setter.call(el, value);
+	el.dispatchEvent(new Event('input', {bubbles: true}));
+	el.dispatchEvent(new Event('change', {bubbles: true}));
+	el.dispatchEvent(new Event('blur', {bubbles: true}));
+	return el.value;
+}`
+
+// calendarHeadersScript returns texts of visible calendar headers, including values of
+// month/year selects and inputs (flatpickr keeps the year in an <input>)
+const calendarHeadersScript = `() => {
+	const visible = (el) => { const r = el.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const sel = '[class*="month"], [class*="caption"], [class*="header"], [class*="title"], [role="heading"], [aria-live]';
+	const out = [];
+	for (const el of document.querySelectorAll(sel)) {
+		if (!visible(el) || !el.closest('[role="dialog"], [role="grid"], [class*="calendar"], [class*="picker"], [class*="datepicker"], table') && !el.querySelector('select, input')) continue;
+		let text = el.innerText || '';
+		for (const f of el.querySelectorAll('select, input')) {
+			text += ' ' + (f.tagName === 'SELECT' ? (f.options[f.selectedIndex] || {}).text || '' : f.value);
+		}
+		text = text.replace(/\s+/g, ' ').trim();
+		if (text && text.length < 60) out.push(text);
+	}
+	return out;
+}`
+
+// calendarNavScript clicks the visible next/prev month control
+const calendarNavScript = `(dir) => {
+	const re = dir === 'next' ? /next|след|вперед|вперёд|forward|›|»|→/i : /prev|пред|назад|back|‹|«|←/i;
+	const visible = (el) => { const r = el.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const cands = document.querySelectorAll('button, [role="button"], a, span[class*="month"], [class*="nav"], [class*="arrow"]');
+	for (const el of cands) {
+		if (!visible(el)) continue;
+		const label = [el.getAttribute('aria-label'), el.getAttribute('title'), el.className && el.className.baseVal === undefined ? el.className : '', (el.innerText || '').trim()].join(' ');
+		if (re.test(label)) { el.click(); return true; }
+	}
+	return false;
+}`
+
+// calendarDayScript marks the visible, enabled day cell of the shown month
+const calendarDayScript = `(day) => {
+	const visible = (el) => { const r = el.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const skip = /disabled|outside|other|prev|next|adjacent|not-current/i;
+	document.querySelectorAll('[data-agent-day]').forEach((el) => el.removeAttribute('data-agent-day'));
+	const cands = document.querySelectorAll('[role="gridcell"], td, [class*="day"]');
+	for (const el of cands) {
+		if (!visible(el) || (el.innerText || '').trim() !== String(day)) continue;
+		if (el.getAttribute('aria-disabled') === 'true' || el.disabled || skip.test(typeof el.className === 'string' ? el.className : '')) continue;
+		const target = el.querySelector('button') || el;
+		target.setAttribute('data-agent-day', '1');
+		return true;
+	}
+	return false;
+}`
+
+// SetDate fills a date input (also readonly JS pickers) and reports the strategy that worked
+func (c *controller) SetDate(ctx context.Context, selector string, date time.Time) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	first := c.page.Locator(selector).First()
+	if err := first.WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateAttached}); err != nil {
+		return "", "", wrap(err)
+	}
+	iso := date.Format("2006-01-02")
+
+	// Strategy 1: set the value directly
+	if v, err := first.Evaluate(setDateScript, iso); err == nil {
+		if value := fmt.Sprint(v); dateValueMatches(value, date) {
+			return DateByScript, value, nil
+		}
+	}
+
+	// Strategy 2: open the calendar and click the day
+	if err := first.Click(); err != nil {
+		return "", "", fmt.Errorf("set date: script value did not stick and the calendar did not open: %w", wrap(err))
+	}
+	for i := 0; i < maxCalendarMonths; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		shown, ok := c.shownMonth()
+		if !ok {
+			return "", "", fmt.Errorf("set date: calendar header with month and year not found")
+		}
+		target := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if shown.Equal(target) {
+			found, err := c.page.Evaluate(calendarDayScript, date.Day())
+			if err != nil || found != true {
+				return "", "", fmt.Errorf("set date: day %d not found in the calendar", date.Day())
+			}
+			if err := c.page.Locator(`[data-agent-day="1"]`).First().Click(); err != nil {
+				return "", "", wrap(err)
+			}
+			value, _ := first.InputValue()
+			return DateByCalendar, value, nil
+		}
+		dir := "next"
+		if shown.After(target) {
+			dir = "prev"
+		}
+		if moved, err := c.page.Evaluate(calendarNavScript, dir); err != nil || moved != true {
+			return "", "", fmt.Errorf("set date: %s month control not found", dir)
+		}
+		time.Sleep(150 * time.Millisecond) // Let the grid re-render
+	}
+	return "", "", fmt.Errorf("set date: %s is more than %d months from the shown month", iso, maxCalendarMonths)
+}
+
+// shownMonth parses the first calendar header naming a month and a year
+func (c *controller) shownMonth() (time.Time, bool) {
+	raw, err := c.page.Evaluate(calendarHeadersScript)
+	if err != nil {
+		return time.Time{}, false
+	}
+	list, _ := raw.([]interface{})
+	for _, item := range list {
+		if t, ok := parseMonthYear(fmt.Sprint(item)); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// monthPrefixes covers ru (nominative and genitive) and en month names by unambiguous prefix
+var monthPrefixes = []struct {
+	prefix string
+	month  time.Month
+}{
+	{"jan", time.January}, {"feb", time.February}, {"mar", time.March}, {"apr", time.April},
+	{"may", time.May}, {"jun", time.June}, {"jul", time.July}, {"aug", time.August},
+	{"sep", time.September}, {"oct", time.October}, {"nov", time.November}, {"dec", time.December},
+	{"янв", time.January}, {"фев", time.February}, {"мар", time.March}, {"апр", time.April},
+	{"май", time.May}, {"мая", time.May}, {"июн", time.June}, {"июл", time.July}, {"авг", time.August},
+	{"сен", time.September}, {"окт", time.October}, {"ноя", time.November}, {"дек", time.December},
+}
+
+// parseMonthYear reads "March 2025", "март 2025 г.", "мая 2025" as the first day of the month
+func parseMonthYear(text string) (time.Time, bool) {
+	text = strings.ToLower(text)
+	year := yearPattern.FindString(text)
+	if year == "" {
+		return time.Time{}, false
+	}
+	y, _ := strconv.Atoi(year)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '.' || r == '\u00a0'
+	}) {
+		for _, m := range monthPrefixes {
+			if strings.HasPrefix(word, m.prefix) {
+				return time.Date(y, m.month, 1, 0, 0, 0, 0, time.UTC), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateValueMatches checks the value read back contains the date in a common format
+func dateValueMatches(value string, date time.Time) bool {
+	for _, layout := range []string{"2006-01-02", "02.01.2006", "01/02/2006", "02/01/2006", "2.1.2006", "1/2/2006"} {
+		if strings.Contains(value, date.Format(layout)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMonthYear(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Time
+		ok   bool
+	}{
+		{"March 2025", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), true},
+		{"‹ Март 2025 ›", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), true},
+		{"14 мая 2025 г.", time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), true},
+		{"Sep, 1999", time.Date(1999, time.September, 1, 0, 0, 0, 0, time.UTC), true},
+		{"декабрь 2030", time.Date(2030, time.December, 1, 0, 0, 0, 0, time.UTC), true},
+		{"March", time.Time{}, false},
+		{"Week 12 2025", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMonthYear(tt.text)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseMonthYear(%q) = %s %v, want %s %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDateValueMatches(t *testing.T) {
+	date := time.Date(2025, time.May, 4, 0, 0, 0, 0, time.UTC)
+	for value, want := range map[string]bool{
+		"2025-05-04": true,
+		"04.05.2025": true,
+		"05/04/2025": true,
+		"4.5.2025":   true,
+		"Sun 4 May":  false,
+		"":           false,
+	} {
+		if got := dateValueMatches(value, date); got != want {
+			t.Errorf("dateValueMatches(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
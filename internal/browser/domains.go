@@ -0,0 +1,135 @@
+package browser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// DomainPolicy restricts which hosts the agent may open. Patterns are exact hosts
+// ("example.com") or wildcards ("*.example.com" - the domain and all its subdomains).
+// The blocklist wins; an empty allowlist allows every host that is not blocked.
+type DomainPolicy struct {
+	Allowed []string
+	Blocked []string
+}
+
+// NewDomainPolicy normalizes the patterns (case, surrounding dots and spaces)
+func NewDomainPolicy(allowed, blocked []string) DomainPolicy {
+	return DomainPolicy{Allowed: normalizePatterns(allowed), Blocked: normalizePatterns(blocked)}
+}
+
+func normalizePatterns(patterns []string) []string {
+	var out []string
+	for _, p := range patterns {
+		p = strings.ToLower(strings.Trim(strings.TrimSpace(p), "."))
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Active reports whether the policy restricts anything
+func (p DomainPolicy) Active() bool {
+	return len(p.Allowed) > 0 || len(p.Blocked) > 0
+}
+
+// Allows checks the host of raw (a scheme-less "example.com:8080/path" is read as https).
+// An active policy passes only http(s) URLs with a host, plus about:blank and data: pages:
+// file:, javascript: and every other scheme would step around the host check. reason explains a refusal.
+func (p DomainPolicy) Allows(raw string) (ok bool, reason string) {
+	if !p.Active() {
+		return true, ""
+	}
+	raw = strings.TrimSpace(raw)
+	if lower := strings.ToLower(raw); lower == "about:blank" || strings.HasPrefix(lower, "data:") {
+		return true, ""
+	}
+	schemeless := !strings.Contains(raw, "://")
+	if schemeless {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false, "not a valid URL"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false, fmt.Sprintf("scheme %s: is not allowed, only http and https", u.Scheme)
+	}
+	// "mailto:a@example.com" turns into user "mailto:a" at example.com once prefixed
+	if u.Hostname() == "" || (schemeless && u.User != nil) {
+		return false, "not a web URL with a host"
+	}
+	host := strings.ToLower(u.Hostname())
+	if pattern, hit := matchDomain(host, p.Blocked); hit {
+		return false, fmt.Sprintf("host %s is blocked (%s)", host, pattern)
+	}
+	if len(p.Allowed) == 0 {
+		return true, ""
+	}
+	if _, hit := matchDomain(host, p.Allowed); hit {
+		return true, ""
+	}
+	return false, fmt.Sprintf("host %s is not in the allowed domains (%s)", host, strings.Join(p.Allowed, ", "))
+}
+
+func matchDomain(host string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		if base, wildcard := strings.CutPrefix(pattern, "*."); wildcard {
+			if host == base || strings.HasSuffix(host, "."+base) {
+				return pattern, true
+			}
+		} else if host == pattern {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// domainGuard aborts top-level and frame navigations (including redirects) to hosts
+// the policy refuses, so clicks and server redirects cannot leave the allowed sites
+type domainGuard struct {
+	policy  DomainPolicy
+	mu      sync.Mutex
+	blocked []string // Pending notes, drained by TakeBlockedSubmissions
+}
+
+// EnableDomainGuard installs the navigation block for the current and any recreated context
+func (c *controller) EnableDomainGuard(policy DomainPolicy) error {
+	if !policy.Active() {
+		return nil
+	}
+	guard := &domainGuard{policy: policy}
+	c.domainGuard = guard
+	return c.installRoutes(c.context)
+}
+
+func (g *domainGuard) handle(route playwright.Route) {
+	req := route.Request()
+	if !req.IsNavigationRequest() {
+		_ = route.Fallback()
+		return
+	}
+	ok, reason := g.policy.Allows(req.URL())
+	if ok {
+		_ = route.Fallback()
+		return
+	}
+	note := fmt.Sprintf("blocked navigation to %s: %s", originOf(req.URL()), reason)
+	g.mu.Lock()
+	g.blocked = append(g.blocked, note)
+	g.mu.Unlock()
+	_ = route.Abort("blockedbyclient")
+}
+
+func (g *domainGuard) take() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	notes := g.blocked
+	g.blocked = nil
+	return notes
+}
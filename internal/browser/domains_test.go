@@ -0,0 +1,73 @@
+package browser
+
+import "testing"
+
+func TestDomainPolicyAllows(t *testing.T) {
+	policy := NewDomainPolicy([]string{"*.example.com", "intranet.local"}, []string{"ads.example.com"})
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/login", true},
+		{"https://shop.example.com/cart", true},
+		{"http://intranet.local/", true},
+		{"example.com/path", true},
+		{"intranet.local:8080/admin", true},
+		{"about:blank", true},
+		{"data:text/html,<p>hi</p>", true},
+		{"https://ads.example.com/track", false},
+		{"https://evil.com/", false},
+		{"evil.com", false},
+		{"evil.com:8080", false},
+		{"file:///etc/passwd", false},
+		{"javascript:alert(1)", false},
+		{"JavaScript://%0aalert(1)", false},
+		{"mailto:admin@example.com", false},
+		{"chrome://settings", false},
+		{"ftp://example.com/file", false},
+		{"https:///path", false},
+		{"//evil.com/", false},
+		{"about:config", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		ok, reason := policy.Allows(tt.url)
+		if ok != tt.want {
+			t.Errorf("Allows(%q) = %v (%s), want %v", tt.url, ok, reason, tt.want)
+		}
+		if !ok && reason == "" {
+			t.Errorf("Allows(%q) refused without a reason", tt.url)
+		}
+	}
+}
+
+func TestDomainPolicyInactiveAllowsAll(t *testing.T) {
+	for _, raw := range []string{"file:///etc/passwd", "https://evil.com", ""} {
+		if ok, _ := (DomainPolicy{}).Allows(raw); !ok {
+			t.Errorf("inactive policy refused %q", raw)
+		}
+	}
+}
+
+func TestDomainPolicyBlocklistOnlyRefusesOtherSchemes(t *testing.T) {
+	policy := NewDomainPolicy(nil, []string{"evil.com"})
+	for raw, want := range map[string]bool{"https://docs.example.org": true, "file:///etc/passwd": false, "javascript:void(0)": false} {
+		if ok, _ := policy.Allows(raw); ok != want {
+			t.Errorf("Allows(%q) = %v, want %v", raw, ok, want)
+		}
+	}
+}
+
+func TestDomainGuardAbortsNonWebNavigations(t *testing.T) {
+	guard := &domainGuard{policy: NewDomainPolicy([]string{"example.com"}, nil)}
+	for raw, allowed := range map[string]bool{"https://example.com/next": true, "file:///etc/passwd": false, "https://evil.com/": false} {
+		route := &formRoute{req: formRequest{url: raw}}
+		guard.handle(route)
+		if route.aborted == allowed || (route.fallbacks == 1) != allowed {
+			t.Errorf("%s: aborted=%v fallbacks=%d, want allowed=%v", raw, route.aborted, route.fallbacks, allowed)
+		}
+	}
+	if notes := guard.take(); len(notes) != 2 {
+		t.Errorf("notes = %q, want the two refused navigations", notes)
+	}
+}
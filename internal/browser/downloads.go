@@ -0,0 +1,183 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Download is a file a page downloaded (EnableDownloads)
+type Download struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"` // Name suggested by the site
+	Path     string `json:"path"`     // Saved file, "" when the download failed
+	Size     int64  `json:"size"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (d Download) String() string {
+	if d.Error != "" {
+		return fmt.Sprintf("download of %s failed: %s", d.Filename, d.Error)
+	}
+	return fmt.Sprintf("downloaded %s (%d bytes) to %s", d.Filename, d.Size, d.Path)
+}
+
+// downloads saves the files pages download into dir and queues them for WaitForDownload and
+// TakeDownloads; each download is handed out once
+type downloads struct {
+	dir     string
+	mu      sync.Mutex
+	running int           // Started, not saved yet
+	queue   []Download    // Finished, not handed out yet
+	changed chan struct{} // Closed and replaced when a download starts or finishes
+}
+
+// EnableDownloads saves files downloaded by any page of this and recreated contexts into dir
+// (created on the first download); without it downloads are dropped when the context closes
+func (c *controller) EnableDownloads(dir string) error {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("download dir: %w", err)
+	}
+	c.downloads = &downloads{dir: abs, changed: make(chan struct{})}
+	c.watchDownloads(c.context)
+	return nil
+}
+
+func (c *controller) watchDownloads(bctx playwright.BrowserContext) {
+	watch := func(page playwright.Page) {
+		page.OnDownload(func(d playwright.Download) {
+			c.downloads.started()
+			go c.downloads.save(d) // SaveAs blocks until the transfer ends
+		})
+	}
+	for _, page := range bctx.Pages() {
+		watch(page)
+	}
+	bctx.OnPage(watch)
+}
+
+// WaitForDownload returns the oldest download not handed out yet, waiting up to timeout for
+// one to start and finish
+func (c *controller) WaitForDownload(ctx context.Context, timeout time.Duration) (Download, error) {
+	if err := ctx.Err(); err != nil {
+		return Download{}, err
+	}
+	d := c.downloads
+	if d == nil {
+		return Download{}, fmt.Errorf("downloads are disabled - start the agent with -download-dir")
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		d.mu.Lock()
+		if len(d.queue) > 0 {
+			next := d.queue[0]
+			d.queue = d.queue[1:]
+			d.mu.Unlock()
+			return next, nil
+		}
+		running, changed := d.running, d.changed
+		d.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return Download{}, ctx.Err()
+		case <-deadline.C:
+			if running > 0 {
+				return Download{}, fmt.Errorf("download still in progress after %s - wait again", timeout)
+			}
+			return Download{}, fmt.Errorf("no download started within %s - click the download link or button first", timeout)
+		case <-changed:
+		}
+	}
+}
+
+// TakeDownloads returns and clears the finished downloads not handed out yet
+func (c *controller) TakeDownloads() []Download {
+	d := c.downloads
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := d.queue
+	d.queue = nil
+	return out
+}
+
+func (d *downloads) started() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running++
+	d.notify()
+}
+
+// save writes the download under its suggested name, adding " (N)" when the name is taken
+func (d *downloads) save(dl playwright.Download) {
+	res := Download{URL: dl.URL(), Filename: filepath.Base(dl.SuggestedFilename())}
+	if strings.HasPrefix(res.URL, "data:") {
+		res.URL = "data: URL" // Inline payloads would flood the history
+	}
+	if res.Filename == "" || res.Filename == "." || res.Filename == string(filepath.Separator) {
+		res.Filename = "download"
+	}
+	var err error
+	if err = os.MkdirAll(d.dir, 0o755); err == nil {
+		path := d.reserve(res.Filename)
+		if err = dl.SaveAs(path); err == nil {
+			res.Path = path
+			if info, statErr := os.Stat(path); statErr == nil {
+				res.Size = info.Size()
+			}
+		} else {
+			_ = os.Remove(path)
+		}
+	}
+	if err != nil {
+		if failure := dl.Failure(); failure != nil {
+			err = failure
+		}
+		res.Error = err.Error()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running--
+	d.queue = append(d.queue, res)
+	d.notify()
+}
+
+// reserve returns a free path for name in dir and creates the file, so concurrent downloads
+// of the same name don't overwrite each other
+func (d *downloads) reserve(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	path := filepath.Join(d.dir, name)
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return path
+		}
+		if !os.IsExist(err) || i > 1000 {
+			return path
+		}
+		path = filepath.Join(d.dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+}
+
+// notify wakes WaitForDownload; callers hold mu
+func (d *downloads) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
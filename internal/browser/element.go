@@ -0,0 +1,126 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ElementTarget identifies an element by one of: CSS selector, ARIA role (+name) or visible text
+type ElementTarget struct {
+	Selector string
+	Role     string
+	Name     string
+	Text     string
+}
+
+// ElementInfo is a human-readable description of a resolved element
+type ElementInfo struct {
+	Text      string `json:"text"`      // Visible text (or value/aria-label)
+	Role      string `json:"role"`      // ARIA role or tag name
+	Container string `json:"container"` // First line of the surrounding container (e.g. which email row)
+}
+
+// describeElementScript collects element text, role and the first distinct line of its container
+const describeElementScript = `(el) => {
+	const clean = (s) => (s || "").replace(/\s+/g, " ").trim();
+	const cut = (s, n) => s.length <= n ? s : Array.from(s).slice(0, n).join(""); // By code points
+	const text = cut(clean(el.innerText || el.value || el.getAttribute("aria-label") || el.getAttribute("title")), 120);
+	const role = el.getAttribute("role") || el.tagName.toLowerCase();
+	const firstLine = (node) => {
+		const lines = (node.innerText || "").split("\n").map(clean).filter(l => l && l !== text);
+		return lines.length > 0 ? cut(lines[0], 120) : "";
+	};
+	let container = "";
+	const semantic = el.parentElement && el.parentElement.closest("li,tr,article,[role='row'],[role='listitem'],[role='article'],[role='dialog'],form");
+	if (semantic) container = firstLine(semantic);
+	let p = el.parentElement;
+	while (!container && p && p !== document.body) {
+		container = firstLine(p);
+		p = p.parentElement;
+	}
+	return {text, role, container};
+}`
+
+func (c *controller) locate(target ElementTarget) (playwright.Locator, error) {
+	switch {
+	case strings.TrimSpace(target.Selector) != "":
+		return c.page.Locator(target.Selector).First(), nil
+	case strings.TrimSpace(target.Role) != "":
+		opts := playwright.PageGetByRoleOptions{}
+		if target.Name != "" {
+			opts.Name = target.Name
+		}
+		return c.page.GetByRole(playwright.AriaRole(strings.ToLower(target.Role)), opts).First(), nil
+	case strings.TrimSpace(target.Text) != "":
+		return c.page.GetByText(target.Text).First(), nil
+	default:
+		return nil, fmt.Errorf("element target is empty")
+	}
+}
+
+// DescribeElement resolves the target and returns its text, role and surrounding container text
+func (c *controller) DescribeElement(ctx context.Context, target ElementTarget) (ElementInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return ElementInfo{}, err
+	}
+	loc, err := c.locate(target)
+	if err != nil {
+		return ElementInfo{}, err
+	}
+	val, err := loc.Evaluate(describeElementScript, nil, playwright.LocatorEvaluateOptions{
+		Timeout: playwright.Float(2000),
+	})
+	if err != nil {
+		return ElementInfo{}, wrap(err)
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return ElementInfo{}, fmt.Errorf("unexpected element description: %T", val)
+	}
+	info := ElementInfo{}
+	info.Text, _ = m["text"].(string)
+	info.Role, _ = m["role"].(string)
+	info.Container, _ = m["container"].(string)
+	return info, nil
+}
+
+// Highlight visually marks the target element in headed mode (no-op in headless mode)
+func (c *controller) Highlight(ctx context.Context, target ElementTarget) error {
+	if c.headless {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	loc, err := c.locate(target)
+	if err != nil {
+		return err
+	}
+	return wrap(loc.Highlight())
+}
+
+// MatchTexts returns the first text line of every element matching selector (at most limit),
+// to explain or resolve strict mode violations ("locator resolved to 3 elements")
+func (c *controller) MatchTexts(ctx context.Context, selector string, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	texts, err := c.page.Locator(selector).AllInnerTexts()
+	if err != nil {
+		return nil, wrap(err)
+	}
+	if limit > 0 && len(texts) > limit {
+		texts = texts[:limit]
+	}
+	for i, t := range texts {
+		t = strings.TrimSpace(t)
+		if nl := strings.IndexByte(t, '\n'); nl >= 0 {
+			t = strings.TrimSpace(t[:nl])
+		}
+		texts[i] = t
+	}
+	return texts, nil
+}
@@ -0,0 +1,741 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// FakeElement is one element of a FakePage
+type FakeElement struct {
+	Selector string
+	Role     string
+	Text     string
+	Value    string
+	Disabled bool
+	// Navigate is the URL a click (or submit, for fields) opens, "" = no navigation
+	Navigate string
+}
+
+// FakePage is a declarative page model served by FakeController
+type FakePage struct {
+	URL      string
+	Title    string
+	Text     string // Body text returned by Read("body")
+	Markdown string // ReadMarkdown result for the whole page, Text when empty
+	Elements []FakeElement
+	// Tables are ExtractTable results by selector, "" for auto-detection
+	Tables map[string]Table
+}
+
+// FakeCall is one recorded Controller invocation
+type FakeCall struct {
+	Method string
+	Args   []any
+}
+
+// FakeController is an in-memory Controller for tests: pages come from a model, every call is
+// recorded, and errors can be queued per method. Page() returns a stub that answers URL, Title,
+// InnerText, IsClosed and Close; other playwright.Page methods panic.
+type FakeController struct {
+	mu      sync.Mutex
+	pages   map[string]FakePage
+	current FakePage
+	back    []FakePage
+	forward []FakePage
+	errs    map[string][]error
+	calls   []FakeCall
+	closed  bool
+	// contextClosed fails every call like a dead BrowserContext until Recreate
+	contextClosed bool
+	blocked       []string
+	notes         []string
+	consent       []ConsentResult
+	// Finished downloads queued by Download, handed out by WaitForDownload and TakeDownloads
+	downloaded []Download
+}
+
+var _ Controller = (*FakeController)(nil)
+
+// NewFakeController starts on the first page; Navigate can reach any of the given pages by URL
+func NewFakeController(start FakePage, more ...FakePage) *FakeController {
+	f := &FakeController{
+		pages:   map[string]FakePage{start.URL: start},
+		current: start,
+		errs:    make(map[string][]error),
+	}
+	for _, p := range more {
+		f.pages[p.URL] = p
+	}
+	return f
+}
+
+// FailNext queues errors returned by the next calls of method (e.g. "Click"), one per call
+func (f *FakeController) FailNext(method string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = append(f.errs[method], errs...)
+}
+
+// BlockSubmission queues a note returned by TakeBlockedSubmissions
+func (f *FakeController) BlockSubmission(note string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocked = append(f.blocked, note)
+}
+
+// Note queues a note returned by TakeNotes
+func (f *FakeController) Note(note string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notes = append(f.notes, note)
+}
+
+// Consent queues results of the next DismissConsent calls, as if banners were on the page
+func (f *FakeController) Consent(results ...ConsentResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.consent = append(f.consent, results...)
+}
+
+// Download queues a finished download, as if the page downloaded a file
+func (f *FakeController) Download(d Download) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.downloaded = append(f.downloaded, d)
+}
+
+// Calls returns all recorded invocations in order
+func (f *FakeController) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// CallsTo returns the recorded invocations of one method
+func (f *FakeController) CallsTo(method string) []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []FakeCall
+	for _, c := range f.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Current returns the page model shown now, with filled values
+func (f *FakeController) Current() FakePage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current
+}
+
+// call records the invocation and pops a queued error; callers hold no lock
+func (f *FakeController) call(ctx context.Context, method string, args ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Method: method, Args: args})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.closed && method != "Close" {
+		return fmt.Errorf("fake: %s: target closed", method)
+	}
+	if f.contextClosed && method != "Recreate" {
+		return fmt.Errorf("fake: %s: Target page, context or browser has been closed", method)
+	}
+	if queued := f.errs[method]; len(queued) > 0 {
+		f.errs[method] = queued[1:]
+		return queued[0]
+	}
+	return nil
+}
+
+// find returns the index of the first element of the current page matching match; caller holds mu
+func (f *FakeController) find(match func(el FakeElement) bool) (int, bool) {
+	for i, el := range f.current.Elements {
+		if match(el) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// bySelector matches elements by exact selector; a Playwright " >> nth=N" suffix picks the
+// N-th of the elements with the base selector
+func bySelector(selector string) func(FakeElement) bool {
+	base, nth, ok := strings.Cut(selector, " >> nth=")
+	n, err := strconv.Atoi(nth)
+	if !ok || err != nil {
+		return func(el FakeElement) bool { return el.Selector == selector }
+	}
+	seen := -1
+	return func(el FakeElement) bool {
+		if el.Selector != base {
+			return false
+		}
+		seen++
+		return seen == n
+	}
+}
+
+// open switches to the page at url; caller holds mu
+func (f *FakeController) open(url string) error {
+	page, ok := f.pages[url]
+	if !ok {
+		return fmt.Errorf("fake: navigate %s: net::ERR_NAME_NOT_RESOLVED", url)
+	}
+	f.back = append(f.back, f.current)
+	f.forward = nil
+	f.current = page
+	return nil
+}
+
+// click clicks the matched element, following its Navigate URL
+func (f *FakeController) click(what string, match func(FakeElement) bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.find(match)
+	if !ok {
+		return fmt.Errorf("fake: %s: element not found (timeout)", what)
+	}
+	el := f.current.Elements[i]
+	if el.Disabled {
+		return fmt.Errorf("fake: %s: element is not enabled", what)
+	}
+	if el.Navigate != "" {
+		return f.open(el.Navigate)
+	}
+	return nil
+}
+
+func (f *FakeController) Close(ctx context.Context) error {
+	if err := f.call(ctx, "Close"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *FakeController) Navigate(ctx context.Context, url string) error {
+	if err := f.call(ctx, "Navigate", url); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.open(url)
+}
+
+func (f *FakeController) GoBack(ctx context.Context) error {
+	if err := f.call(ctx, "GoBack"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.back) == 0 {
+		return fmt.Errorf("fake: no previous page")
+	}
+	f.forward = append(f.forward, f.current)
+	f.current = f.back[len(f.back)-1]
+	f.back = f.back[:len(f.back)-1]
+	return nil
+}
+
+func (f *FakeController) GoForward(ctx context.Context) error {
+	if err := f.call(ctx, "GoForward"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.forward) == 0 {
+		return fmt.Errorf("fake: no next page")
+	}
+	f.back = append(f.back, f.current)
+	f.current = f.forward[len(f.forward)-1]
+	f.forward = f.forward[:len(f.forward)-1]
+	return nil
+}
+
+func (f *FakeController) ClickText(ctx context.Context, text string, exact bool) error {
+	if err := f.call(ctx, "ClickText", text, exact); err != nil {
+		return err
+	}
+	return f.click("text "+text, func(el FakeElement) bool { return textMatches(el.Text, text, exact) })
+}
+
+func (f *FakeController) ClickRole(ctx context.Context, role, name string, exact bool) error {
+	if err := f.call(ctx, "ClickRole", role, name, exact); err != nil {
+		return err
+	}
+	return f.click("role "+role+" "+name, func(el FakeElement) bool {
+		return el.Role == role && textMatches(el.Text, name, exact)
+	})
+}
+
+func (f *FakeController) Click(ctx context.Context, selector string) error {
+	if err := f.call(ctx, "Click", selector); err != nil {
+		return err
+	}
+	return f.click(selector, bySelector(selector))
+}
+
+func (f *FakeController) ClickByCoordinates(ctx context.Context, x, y float64) error {
+	return f.call(ctx, "ClickByCoordinates", x, y)
+}
+
+func (f *FakeController) ClickByTextFuzzy(ctx context.Context, text string) error {
+	if err := f.call(ctx, "ClickByTextFuzzy", text); err != nil {
+		return err
+	}
+	return f.click("fuzzy "+text, func(el FakeElement) bool { return textMatches(el.Text, text, false) })
+}
+
+func textMatches(have, want string, exact bool) bool {
+	if exact {
+		return strings.TrimSpace(have) == want
+	}
+	return strings.Contains(strings.ToLower(have), strings.ToLower(want))
+}
+
+func (f *FakeController) Fill(ctx context.Context, selector, text string) error {
+	if err := f.call(ctx, "Fill", selector, text); err != nil {
+		return err
+	}
+	_, err := f.fill(selector, text)
+	return err
+}
+
+// fill sets the value of the selector's element and returns it
+func (f *FakeController) fill(selector, text string) (FakeElement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.find(bySelector(selector))
+	if !ok {
+		return FakeElement{}, fmt.Errorf("fake: fill %s: element not found (timeout)", selector)
+	}
+	if f.current.Elements[i].Disabled {
+		return FakeElement{}, fmt.Errorf("fake: fill %s: element is not enabled", selector)
+	}
+	// Elements are shared with the page model - copy before writing
+	f.current.Elements = append([]FakeElement(nil), f.current.Elements...)
+	f.current.Elements[i].Value = text
+	return f.current.Elements[i], nil
+}
+
+func (f *FakeController) PressKey(ctx context.Context, selector, key string) error {
+	return f.call(ctx, "PressKey", selector, key)
+}
+
+// FillAndSubmit fills the field and follows its Navigate URL as the submit outcome
+func (f *FakeController) FillAndSubmit(ctx context.Context, selector, text string) (SubmitResult, error) {
+	if err := f.call(ctx, "FillAndSubmit", selector, text); err != nil {
+		return SubmitResult{}, err
+	}
+	el, err := f.fill(selector, text)
+	if err != nil {
+		return SubmitResult{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := SubmitResult{Value: el.Value, Outcome: SubmitNoChange, URL: f.current.URL}
+	if el.Navigate != "" {
+		if err := f.open(el.Navigate); err != nil {
+			return res, err
+		}
+		res.Outcome, res.URL = SubmitNavigation, f.current.URL
+	}
+	return res, nil
+}
+
+func (f *FakeController) SetDate(ctx context.Context, selector string, date time.Time) (string, string, error) {
+	if err := f.call(ctx, "SetDate", selector, date); err != nil {
+		return "", "", err
+	}
+	el, err := f.fill(selector, date.Format("2006-01-02"))
+	if err != nil {
+		return "", "", err
+	}
+	return DateByScript, el.Value, nil
+}
+
+// ChooseComboboxOption fills the element with the option text, as if typing filtered the list
+func (f *FakeController) ChooseComboboxOption(ctx context.Context, selector, option string) (ComboboxResult, error) {
+	if err := f.call(ctx, "ChooseComboboxOption", selector, option); err != nil {
+		return ComboboxResult{}, err
+	}
+	el, err := f.fill(selector, option)
+	if err != nil {
+		return ComboboxResult{}, err
+	}
+	return ComboboxResult{Option: option, Strategy: ComboByTyping, Value: el.Value, Verified: true}, nil
+}
+
+// UploadFile records path as the Value of the element, like a file input reports its file
+func (f *FakeController) UploadFile(ctx context.Context, selector, path string) (string, error) {
+	if err := f.call(ctx, "UploadFile", selector, path); err != nil {
+		return "", err
+	}
+	if _, err := f.fill(selector, path); err != nil {
+		return "", err
+	}
+	return UploadByInput, nil
+}
+
+// Read returns the page Text for "" and "body", otherwise the element text
+func (f *FakeController) Read(ctx context.Context, selector string) (string, error) {
+	if err := f.call(ctx, "Read", selector); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if selector == "" || selector == "body" {
+		return f.current.Text, nil
+	}
+	i, ok := f.find(bySelector(selector))
+	if !ok {
+		return "", fmt.Errorf("fake: read %s: element not found (timeout)", selector)
+	}
+	return f.current.Elements[i].Text, nil
+}
+
+func (f *FakeController) Scroll(ctx context.Context, direction string, distance int) (int, error) {
+	if err := f.call(ctx, "Scroll", direction, distance); err != nil {
+		return 0, err
+	}
+	return distance, nil
+}
+
+func (f *FakeController) ScrollToElement(ctx context.Context, selector string) error {
+	return f.waitFor(ctx, "ScrollToElement", selector)
+}
+
+func (f *FakeController) WaitFor(ctx context.Context, selector string, timeout time.Duration) error {
+	return f.waitFor(ctx, "WaitFor", selector)
+}
+
+func (f *FakeController) waitFor(ctx context.Context, method, selector string) error {
+	if err := f.call(ctx, method, selector); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.find(bySelector(selector)); !ok {
+		return fmt.Errorf("fake: %s %s: timeout", method, selector)
+	}
+	return nil
+}
+
+func (f *FakeController) WaitForEnabled(ctx context.Context, selector string, timeout time.Duration) (bool, error) {
+	if err := f.call(ctx, "WaitForEnabled", selector, timeout); err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.find(bySelector(selector))
+	return ok && !f.current.Elements[i].Disabled, nil
+}
+
+func (f *FakeController) WaitForLazyListItems(ctx context.Context, timeout time.Duration) error {
+	return f.call(ctx, "WaitForLazyListItems", timeout)
+}
+
+func (f *FakeController) WaitForStableDOM(ctx context.Context, timeout time.Duration) error {
+	return f.call(ctx, "WaitForStableDOM", timeout)
+}
+
+// Activity reports an idle page: fake pages render completely at once
+func (f *FakeController) Activity(ctx context.Context) (PageActivity, error) {
+	return PageActivity{}, f.call(ctx, "Activity")
+}
+
+// SaveState records the call and reports path as written; nothing touches the disk.
+// A queued *StateFallbackError reports its Used path like the real controller.
+func (f *FakeController) SaveState(ctx context.Context, path string) (string, error) {
+	if err := f.call(ctx, "SaveState", path); err != nil {
+		var fallback *StateFallbackError
+		if errors.As(err, &fallback) {
+			return fallback.Used, err
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+func (f *FakeController) Hover(ctx context.Context, selector string) error {
+	return f.waitFor(ctx, "Hover", selector)
+}
+
+// HoverReveal reveals nothing: the element count stays the same
+func (f *FakeController) HoverReveal(ctx context.Context, selector string) (HoverResult, error) {
+	if err := f.waitFor(ctx, "HoverReveal", selector); err != nil {
+		return HoverResult{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := len(f.current.Elements)
+	return HoverResult{Before: n, After: n}, nil
+}
+
+func (f *FakeController) DismissConsent(ctx context.Context) (ConsentResult, error) {
+	if err := f.call(ctx, "DismissConsent"); err != nil {
+		return ConsentResult{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.consent) == 0 {
+		return ConsentResult{}, nil
+	}
+	res := f.consent[0]
+	f.consent = f.consent[1:]
+	return res, nil
+}
+
+func (f *FakeController) DescribeElement(ctx context.Context, target ElementTarget) (ElementInfo, error) {
+	if err := f.call(ctx, "DescribeElement", target); err != nil {
+		return ElementInfo{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.find(func(el FakeElement) bool {
+		switch {
+		case target.Selector != "":
+			return el.Selector == target.Selector
+		case target.Role != "":
+			return el.Role == target.Role && textMatches(el.Text, target.Name, false)
+		}
+		return textMatches(el.Text, target.Text, false)
+	})
+	if !ok {
+		return ElementInfo{}, fmt.Errorf("fake: describe %+v: element not found", target)
+	}
+	el := f.current.Elements[i]
+	return ElementInfo{Text: el.Text, Role: el.Role}, nil
+}
+
+func (f *FakeController) Highlight(ctx context.Context, target ElementTarget) error {
+	return f.call(ctx, "Highlight", target)
+}
+
+func (f *FakeController) BringToFront(ctx context.Context) error {
+	return f.call(ctx, "BringToFront")
+}
+
+// ExtractTable returns the page table registered for the selector, cut to maxRows
+func (f *FakeController) ExtractTable(ctx context.Context, selector string, maxRows int) (Table, error) {
+	if err := f.call(ctx, "ExtractTable", selector, maxRows); err != nil {
+		return Table{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.current.Tables[selector]
+	if !ok {
+		return Table{}, fmt.Errorf("fake: no table for %q", selector)
+	}
+	if maxRows > 0 && len(t.Rows) > maxRows {
+		t.Rows = t.Rows[:maxRows]
+	}
+	return t, nil
+}
+
+// ReadMarkdown returns the page Markdown (or Text) for "" and "body", otherwise the element text
+func (f *FakeController) ReadMarkdown(ctx context.Context, selector string, maxChars int) (string, error) {
+	if err := f.call(ctx, "ReadMarkdown", selector, maxChars); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	md := f.current.Markdown
+	switch {
+	case selector != "" && selector != "body":
+		i, ok := f.find(bySelector(selector))
+		if !ok {
+			return "", fmt.Errorf("fake: no element matches %s", selector)
+		}
+		md = f.current.Elements[i].Text
+	case md == "":
+		md = f.current.Text
+	}
+	return cutMarkdown(md, maxChars), nil
+}
+
+// ListTabs reports the current page as the only tab
+func (f *FakeController) ListTabs(ctx context.Context) ([]Tab, error) {
+	if err := f.call(ctx, "ListTabs"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return []Tab{{Index: 0, URL: f.current.URL, Title: f.current.Title, Active: true}}, nil
+}
+
+// SwitchTab accepts only tab 0, the current page
+func (f *FakeController) SwitchTab(ctx context.Context, index int) (Tab, error) {
+	if err := f.call(ctx, "SwitchTab", index); err != nil {
+		return Tab{}, err
+	}
+	if index != 0 {
+		return Tab{}, fmt.Errorf("fake: no tab %d (1 open)", index)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Tab{Index: 0, URL: f.current.URL, Title: f.current.Title, Active: true}, nil
+}
+
+// CloseTab fails: the fake has a single tab
+func (f *FakeController) CloseTab(ctx context.Context, index int) (Tab, error) {
+	if err := f.call(ctx, "CloseTab", index); err != nil {
+		return Tab{}, err
+	}
+	return Tab{}, fmt.Errorf("fake: tab %d is the only open tab", index)
+}
+
+// AdoptOpenedTab never switches: fake pages open no tabs
+func (f *FakeController) AdoptOpenedTab(ctx context.Context) (TabSwitch, bool) {
+	_ = f.call(ctx, "AdoptOpenedTab")
+	return TabSwitch{}, false
+}
+
+func (f *FakeController) MatchTexts(ctx context.Context, selector string, limit int) ([]string, error) {
+	if err := f.call(ctx, "MatchTexts", selector, limit); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, el := range f.current.Elements {
+		if el.Selector == selector && len(texts) < limit {
+			texts = append(texts, el.Text)
+		}
+	}
+	return texts, nil
+}
+
+// Screenshot returns an empty image; path is not written
+func (f *FakeController) Screenshot(ctx context.Context, path string, fullPage bool) ([]byte, error) {
+	return nil, f.call(ctx, "Screenshot", path, fullPage)
+}
+
+// CloseContext kills the fake's BrowserContext while the browser stays up: calls fail
+// with Playwright's "context closed" error until Recreate
+func (f *FakeController) CloseContext() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.contextClosed = true
+}
+
+// Recreate revives a closed fake on the same page
+func (f *FakeController) Recreate(ctx context.Context) error {
+	f.mu.Lock()
+	f.closed, f.contextClosed = false, false
+	f.mu.Unlock()
+	return f.call(ctx, "Recreate")
+}
+
+func (f *FakeController) EnableFormGuard(allowlist []string) error {
+	return f.call(context.Background(), "EnableFormGuard", allowlist)
+}
+
+func (f *FakeController) TakeBlockedSubmissions() []string {
+	_ = f.call(context.Background(), "TakeBlockedSubmissions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	notes := f.blocked
+	f.blocked = nil
+	return notes
+}
+
+func (f *FakeController) TakeNotes() []string {
+	_ = f.call(context.Background(), "TakeNotes")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	notes := f.notes
+	f.notes = nil
+	return notes
+}
+
+func (f *FakeController) EnableDownloads(dir string) error {
+	return f.call(context.Background(), "EnableDownloads", dir)
+}
+
+// WaitForDownload returns the oldest queued download without waiting
+func (f *FakeController) WaitForDownload(ctx context.Context, timeout time.Duration) (Download, error) {
+	if err := f.call(ctx, "WaitForDownload", timeout); err != nil {
+		return Download{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.downloaded) == 0 {
+		return Download{}, fmt.Errorf("no download started within %s - click the download link or button first", timeout)
+	}
+	next := f.downloaded[0]
+	f.downloaded = f.downloaded[1:]
+	return next, nil
+}
+
+func (f *FakeController) TakeDownloads() []Download {
+	_ = f.call(context.Background(), "TakeDownloads")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := f.downloaded
+	f.downloaded = nil
+	return out
+}
+
+func (f *FakeController) EnableDomainGuard(policy DomainPolicy) error {
+	return f.call(context.Background(), "EnableDomainGuard", policy)
+}
+
+func (f *FakeController) EnableOriginHeaders(headers OriginHeaders) error {
+	return f.call(context.Background(), "EnableOriginHeaders", headers)
+}
+
+func (f *FakeController) LimitPages(max int) {
+	_ = f.call(context.Background(), "LimitPages", max)
+}
+
+func (f *FakeController) ResourceUsage(ctx context.Context) (ResourceUsage, error) {
+	return ResourceUsage{Pages: 1}, f.call(ctx, "ResourceUsage")
+}
+
+func (f *FakeController) Page() playwright.Page {
+	return fakePage{f: f}
+}
+
+// fakePage answers the page queries the agent makes outside Controller; the embedded nil
+// interface makes every other method panic, which points tests at the missing stub
+type fakePage struct {
+	playwright.Page
+	f *FakeController
+}
+
+func (p fakePage) URL() string {
+	return p.f.Current().URL
+}
+
+func (p fakePage) Title() (string, error) {
+	return p.f.Current().Title, nil
+}
+
+func (p fakePage) InnerText(selector string, options ...playwright.PageInnerTextOptions) (string, error) {
+	return p.f.Read(context.Background(), selector)
+}
+
+func (p fakePage) IsClosed() bool {
+	p.f.mu.Lock()
+	defer p.f.mu.Unlock()
+	return p.f.closed
+}
+
+func (p fakePage) Close(options ...playwright.PageCloseOptions) error {
+	return p.f.Close(context.Background())
+}
@@ -0,0 +1,201 @@
+package browser_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+func TestWaitForEnabledFixture(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "disabled_submit.html")
+	ctx := context.Background()
+
+	if err := ctrl.Fill(ctx, "#email", "anna@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	enabled, err := ctrl.WaitForEnabled(ctx, "#submit", 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Fatal("submit stayed disabled after validation passed")
+	}
+	if waited := time.Since(started); waited < 300*time.Millisecond {
+		t.Errorf("enabled after %s, want the wait to cover the validation delay", waited)
+	}
+
+	// aria-disabled counts as disabled too, and the wait gives up at its timeout
+	started = time.Now()
+	enabled, err = ctrl.WaitForEnabled(ctx, "#never", 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled {
+		t.Error("aria-disabled button reported as enabled")
+	}
+	if waited := time.Since(started); waited > 2*time.Second {
+		t.Errorf("gave up after %s, want about the 500ms timeout", waited)
+	}
+}
+
+func TestMatchTextsFixture(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "ambiguous_delete.html")
+	ctx := context.Background()
+
+	texts, err := ctrl.MatchTexts(ctx, ".delete", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[Удалить Удалить всё Удалить навсегда]"; fmt.Sprint(texts) != want {
+		t.Errorf("MatchTexts(.delete) = %q, want the first trimmed line of each: %s", texts, want)
+	}
+	if texts, err = ctrl.MatchTexts(ctx, ".delete", 2); err != nil || len(texts) != 2 {
+		t.Errorf("MatchTexts with limit 2 = %q, %v", texts, err)
+	}
+
+	// Identical matches are resolved by clicking the first one
+	texts, err = ctrl.MatchTexts(ctx, ".remove", 5)
+	if err != nil || fmt.Sprint(texts) != "[Remove Remove]" {
+		t.Fatalf("MatchTexts(.remove) = %q, %v", texts, err)
+	}
+	if err := ctrl.Click(ctx, ".remove >> nth=0"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := ctrl.Read(ctx, "#status"); err != nil || status != "removed" {
+		t.Errorf("status = %q, %v after clicking the first match", status, err)
+	}
+}
+
+func TestSetDateFixtures(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "date_pickers.html")
+	ctx := context.Background()
+	tests := []struct {
+		selector     string
+		date         time.Time
+		wantStrategy string
+		wantValue    string
+	}{
+		{"#native", time.Date(2025, time.May, 14, 0, 0, 0, 0, time.UTC), browser.DateByScript, "2025-05-14"},
+		{"#masked", time.Date(1990, time.February, 3, 0, 0, 0, 0, time.UTC), browser.DateByScript, "03.02.1990"},
+		{"#fp", time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), browser.DateByScript, "31/12/2025"},
+		// The calendar opens on March 2025: forward two months, then back four
+		{"#visit", time.Date(2025, time.May, 14, 0, 0, 0, 0, time.UTC), browser.DateByCalendar, "14.05.2025"},
+		{"#visit", time.Date(2025, time.January, 20, 0, 0, 0, 0, time.UTC), browser.DateByCalendar, "20.01.2025"},
+	}
+	for _, tt := range tests {
+		strategy, value, err := ctrl.SetDate(ctx, tt.selector, tt.date)
+		if err != nil {
+			t.Errorf("SetDate(%s, %s): %v", tt.selector, tt.date.Format("2006-01-02"), err)
+			continue
+		}
+		if strategy != tt.wantStrategy || value != tt.wantValue {
+			t.Errorf("SetDate(%s, %s) = %s %q, want %s %q", tt.selector, tt.date.Format("2006-01-02"), strategy, value, tt.wantStrategy, tt.wantValue)
+		}
+	}
+}
+
+func TestExtractTableFixture(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "tables.html")
+	ctx := context.Background()
+	tests := []struct {
+		selector    string
+		maxRows     int
+		wantCaption string
+		wantHeaders string
+		wantRows    string
+		wantTotal   int
+		wantVirtual bool
+	}{
+		// Auto-detection picks the table with the most cells; grouped headers are joined,
+		// the colspan data cell is padded
+		{"", 50, "Flights to Berlin", "[Flight Price / Economy Price / Business Stops]",
+			"[[SU 100 12 000 ₽ 40 000 ₽ 0] [LH 200 Sold out  1] [TK 300 9 500 ₽ 31 000 ₽ 1]]", 3, false},
+		{"#flights", 2, "Flights to Berlin", "[Flight Price / Economy Price / Business Stops]",
+			"[[SU 100 12 000 ₽ 40 000 ₽ 0] [LH 200 Sold out  1]]", 3, false},
+		{"#details", 50, "", "[]", "[[Passenger Anna] [Seat 14C]]", 2, false},
+		// A cell selector resolves to its table
+		{"#details td", 50, "", "[]", "[[Passenger Anna] [Seat 14C]]", 2, false},
+		{"#quotes", 50, "Quotes", "[Ticker Price]", "[[AAPL 189.10] [MSFT 411.25] [NVDA 875.40]]", 3, true},
+	}
+	for _, tt := range tests {
+		table, err := ctrl.ExtractTable(ctx, tt.selector, tt.maxRows)
+		if err != nil {
+			t.Errorf("ExtractTable(%q): %v", tt.selector, err)
+			continue
+		}
+		if table.Caption != tt.wantCaption || fmt.Sprint(table.Headers) != tt.wantHeaders || fmt.Sprint(table.Rows) != tt.wantRows {
+			t.Errorf("ExtractTable(%q) = %q %q %q, want %q %s %s", tt.selector, table.Caption, table.Headers, table.Rows, tt.wantCaption, tt.wantHeaders, tt.wantRows)
+		}
+		if table.TotalRows != tt.wantTotal || table.Virtualized() != tt.wantVirtual || table.Frame != "" {
+			t.Errorf("ExtractTable(%q): total %d, virtualized %v, frame %q; want %d, %v, main frame", tt.selector, table.TotalRows, table.Virtualized(), table.Frame, tt.wantTotal, tt.wantVirtual)
+		}
+	}
+	if table, _ := ctrl.ExtractTable(ctx, "#quotes", 50); table.RowCount != 1000 {
+		t.Errorf("virtualized grid RowCount = %d, want the 1000 declared data rows", table.RowCount)
+	}
+	if _, err := ctrl.ExtractTable(ctx, "#missing", 50); err == nil {
+		t.Error("ExtractTable(#missing) succeeded")
+	}
+}
+
+func TestExtractTableInFrameFixture(t *testing.T) {
+	ctrl, server := browsertest.Open(t, "testdata", "table_frame.html")
+	ctx := context.Background()
+	for _, selector := range []string{"", "#prices"} {
+		table, err := ctrl.ExtractTable(ctx, selector, 50)
+		if err != nil {
+			t.Errorf("ExtractTable(%q): %v", selector, err)
+			continue
+		}
+		if fmt.Sprint(table.Headers) != "[Product Price]" || fmt.Sprint(table.Rows) != "[[Lamp 1 990 ₽] [Desk 14 500 ₽]]" {
+			t.Errorf("ExtractTable(%q) = %q %q", selector, table.Headers, table.Rows)
+		}
+		if want := server + "/table_frame_inner.html"; table.Frame != want {
+			t.Errorf("ExtractTable(%q) frame = %q, want %q", selector, table.Frame, want)
+		}
+	}
+}
+
+func TestChooseComboboxOptionFixture(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "comboboxes.html")
+	ctx := context.Background()
+	tests := []struct {
+		selector     string
+		option       string
+		wantOption   string
+		wantStrategy string
+		wantValue    string
+	}{
+		// The container is given: the inner input is typed into, the async menu waited for
+		{"#city", "новосиб", "Новосибирск", browser.ComboByTyping, "Новосибирск"},
+		{"#city-input", "Нижний Новгород", "Нижний Новгород", browser.ComboByTyping, "Нижний Новгород"},
+		// Rendered option clicked; September is only rendered once the arrows reach it
+		{"#month", "April", "April", browser.ComboByTyping, "April"},
+		{"#month", "September", "September", browser.ComboByArrows, "September"},
+		{"#country", "kaz", "Kazakhstan", browser.ComboByNative, "Kazakhstan"},
+	}
+	for _, tt := range tests {
+		res, err := ctrl.ChooseComboboxOption(ctx, tt.selector, tt.option)
+		if err != nil {
+			t.Errorf("ChooseComboboxOption(%s, %q): %v", tt.selector, tt.option, err)
+			continue
+		}
+		if res.Option != tt.wantOption || res.Strategy != tt.wantStrategy || !res.Verified || !strings.Contains(res.Value, tt.wantValue) {
+			t.Errorf("ChooseComboboxOption(%s, %q) = %+v, want %q via %s showing %q", tt.selector, tt.option, res, tt.wantOption, tt.wantStrategy, tt.wantValue)
+		}
+	}
+
+	// A missing option lists what the menu offers; disabled <select> options cannot be picked
+	if _, err := ctrl.ChooseComboboxOption(ctx, "#city", "Казань"); err == nil || !strings.Contains(err.Error(), "not found among 4 options (first: Москва;") {
+		t.Errorf("missing city: err = %v", err)
+	}
+	if _, err := ctrl.ChooseComboboxOption(ctx, "#country", "Armenia"); err == nil || !strings.Contains(err.Error(), "options: -; Russia; Kazakhstan") {
+		t.Errorf("disabled option: err = %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package browser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// formGuard blocks document POSTs (form submissions) whose target origin differs
+// from the submitting page, unless the target host is allowlisted
+type formGuard struct {
+	allow   []string
+	mu      sync.Mutex
+	blocked []string // Pending notes, drained by TakeBlockedSubmissions
+}
+
+// EnableFormGuard turns on the cross-origin form submission block for the current
+// and any recreated context. Allowlist entries match the host and its subdomains.
+func (c *controller) EnableFormGuard(allowlist []string) error {
+	guard := &formGuard{}
+	for _, host := range allowlist {
+		host = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(host), "."))
+		if host != "" {
+			guard.allow = append(guard.allow, host)
+		}
+	}
+	c.formGuard = guard
+	return c.installRoutes(c.context)
+}
+
+// TakeBlockedSubmissions returns and clears notes about blocked form submissions
+// and navigations (EnableDomainGuard)
+func (c *controller) TakeBlockedSubmissions() []string {
+	var notes []string
+	if c.formGuard != nil {
+		c.formGuard.mu.Lock()
+		notes = append(notes, c.formGuard.blocked...)
+		c.formGuard.blocked = nil
+		c.formGuard.mu.Unlock()
+	}
+	if c.domainGuard != nil {
+		notes = append(notes, c.domainGuard.take()...)
+	}
+	return notes
+}
+
+// TakeNotes returns and clears notes about what the browser did on its own
+func (c *controller) TakeNotes() []string {
+	c.notesMu.Lock()
+	defer c.notesMu.Unlock()
+	notes := c.notes
+	c.notes = nil
+	return notes
+}
+
+func (c *controller) note(format string, args ...any) {
+	c.notesMu.Lock()
+	c.notes = append(c.notes, fmt.Sprintf(format, args...))
+	c.notesMu.Unlock()
+}
+
+func (g *formGuard) handle(route playwright.Route) {
+	req := route.Request()
+	if req.Method() != "POST" || req.ResourceType() != "document" || !req.IsNavigationRequest() {
+		_ = route.Fallback()
+		return
+	}
+	frame := req.Frame()
+	if frame == nil {
+		_ = route.Fallback()
+		return
+	}
+	pageURL := frame.URL()
+	if sameOrigin(pageURL, req.URL()) || g.allowed(req.URL()) {
+		_ = route.Fallback()
+		return
+	}
+	note := fmt.Sprintf("blocked cross-origin form submission from %s to %s (not allowlisted)", originOf(pageURL), originOf(req.URL()))
+	g.mu.Lock()
+	g.blocked = append(g.blocked, note)
+	g.mu.Unlock()
+	_ = route.Abort("blockedbyclient")
+}
+
+func (g *formGuard) allowed(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, a := range g.allow {
+		if host == a || strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether a form on page may post to target: same host, port and scheme,
+// or the same host upgraded from http to https (default ports count as equal).
+// A downgrade to http, another host or port, and pages without a real origin (about:blank, data:) never pass.
+func sameOrigin(page, target string) bool {
+	up, err := url.Parse(page)
+	if err != nil {
+		return false
+	}
+	ut, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	if up.Hostname() == "" || !strings.EqualFold(up.Hostname(), ut.Hostname()) {
+		return false
+	}
+	switch {
+	case up.Scheme == ut.Scheme:
+	case up.Scheme == "http" && ut.Scheme == "https":
+		// Upgrade: the page's own port says nothing about the https one, only explicit ones must match
+		return up.Port() == "" || ut.Port() == "" || up.Port() == ut.Port()
+	default:
+		return false
+	}
+	return effectivePort(up) == effectivePort(ut)
+}
+
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// RegistrableDomain approximates eTLD+1 without a public suffix list:
+// the last two labels, or three for ccTLD second levels like co.uk / com.au
+func RegistrableDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if strings.Trim(host, "0123456789.") == "" || strings.Contains(host, ":") {
+		return host // IPv4/IPv6 literal
+	}
+	labels := strings.Split(host, ".")
+	n := 2
+	if len(labels) >= 3 && len(labels[len(labels)-1]) == 2 {
+		switch labels[len(labels)-2] {
+		case "co", "com", "net", "org", "gov", "edu", "ac", "msk", "spb":
+			n = 3
+		}
+	}
+	if len(labels) <= n {
+		return host
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+func originOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,152 @@
+package browser
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// formRoute is a form submission as the browser routes it: a POST document navigation from a frame
+type formRoute struct {
+	playwright.Route
+	req       formRequest
+	fallbacks int
+	aborted   bool
+}
+
+type formRequest struct {
+	playwright.Request
+	url, frameURL string
+}
+
+type fakeFrame struct {
+	playwright.Frame
+	url string
+}
+
+func (r formRequest) URL() string                { return r.url }
+func (r formRequest) Method() string             { return "POST" }
+func (r formRequest) ResourceType() string       { return "document" }
+func (r formRequest) IsNavigationRequest() bool  { return true }
+func (r formRequest) Frame() playwright.Frame    { return fakeFrame{url: r.frameURL} }
+func (f fakeFrame) URL() string                  { return f.url }
+func (r *formRoute) Request() playwright.Request { return r.req }
+
+func (r *formRoute) Fallback(...playwright.RouteFallbackOptions) error {
+	r.fallbacks++
+	return nil
+}
+
+func (r *formRoute) Abort(...string) error {
+	r.aborted = true
+	return nil
+}
+
+var formAction = regexp.MustCompile(`<form method="post" action="([^"]+)">`)
+
+// submit submits the fixture form on pageURL through the guard, posting it for real when the guard lets it go
+func submit(t *testing.T, g *formGuard, pageURL string) *formRoute {
+	t.Helper()
+	resp, err := http.Get(pageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := formAction.FindStringSubmatch(string(page))
+	if m == nil {
+		t.Fatalf("no form on %s", pageURL)
+	}
+	base, _ := url.Parse(pageURL)
+	action, _ := base.Parse(m[1])
+	route := &formRoute{req: formRequest{url: action.String(), frameURL: pageURL}}
+	g.handle(route)
+	if route.fallbacks > 0 {
+		post, err := http.PostForm(action.String(), url.Values{"card": {"4111"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		post.Body.Close()
+	}
+	return route
+}
+
+func TestFormGuardBlocksThirdPartyPost(t *testing.T) {
+	var thirdPartyPosts, firstPartyPosts atomic.Int32
+	thirdParty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thirdPartyPosts.Add(1)
+	}))
+	defer thirdParty.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><form method="post" action="%s/collect"><input name="card"></form></body></html>`, thirdParty.URL)
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><form method="post" action="/profile/save"><input name="card"></form></body></html>`)
+	})
+	mux.HandleFunc("/profile/save", func(w http.ResponseWriter, r *http.Request) {
+		firstPartyPosts.Add(1)
+	})
+	site := httptest.NewServer(mux)
+	defer site.Close()
+
+	g := &formGuard{}
+	if route := submit(t, g, site.URL+"/checkout"); !route.aborted || route.fallbacks != 0 {
+		t.Errorf("third-party form: aborted=%v fallbacks=%d, want it aborted", route.aborted, route.fallbacks)
+	}
+	if n := thirdPartyPosts.Load(); n != 0 {
+		t.Errorf("third party received %d posts", n)
+	}
+	if len(g.blocked) != 1 || !strings.Contains(g.blocked[0], thirdParty.URL) {
+		t.Errorf("notes = %v, want the blocked target named", g.blocked)
+	}
+
+	if route := submit(t, g, site.URL+"/profile"); route.aborted || route.fallbacks != 1 {
+		t.Errorf("same-origin form: aborted=%v fallbacks=%d, want it let through", route.aborted, route.fallbacks)
+	}
+	if n := firstPartyPosts.Load(); n != 1 {
+		t.Errorf("site received %d posts, want 1", n)
+	}
+
+	// The allowlist opens the third party up
+	allowed := &formGuard{allow: []string{"127.0.0.1"}}
+	if route := submit(t, allowed, site.URL+"/checkout"); route.aborted {
+		t.Error("allowlisted target was blocked")
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		page, target string
+		want         bool
+	}{
+		{"https://shop.example/cart", "https://shop.example/pay", true},
+		{"http://shop.example/cart", "https://shop.example/pay", true},
+		{"http://shop.example:8080/cart", "https://shop.example/pay", true},
+		{"https://shop.example/cart", "http://shop.example/pay", false},
+		{"https://shop.example/cart", "https://shop.example:443/pay", true},
+		{"https://shop.example/cart", "https://shop.example:8443/pay", false},
+		{"http://shop.example:8080/cart", "https://shop.example:8443/pay", false},
+		{"https://SHOP.example/cart", "https://shop.example/pay", true},
+		{"https://shop.example/cart", "https://pay.shop.example/", false},
+		{"https://login.example.com/", "https://example.com/", false},
+		{"https://shop.example/cart", "https://evil.example/collect", false},
+		{"about:blank", "https://shop.example/pay", false},
+	}
+	for _, tt := range tests {
+		if got := sameOrigin(tt.page, tt.target); got != tt.want {
+			t.Errorf("sameOrigin(%q, %q) = %v, want %v", tt.page, tt.target, got, tt.want)
+		}
+	}
+}
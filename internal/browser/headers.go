@@ -0,0 +1,123 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	// originHeadersEnv holds the OriginHeaders JSON inline, originHeadersFileEnv a path to it
+	originHeadersEnv     = "AGENT_EXTRA_HEADERS"
+	originHeadersFileEnv = "AGENT_EXTRA_HEADERS_FILE"
+)
+
+// OriginHeaders maps an origin ("https://staging.example.com", port included when not default)
+// to extra request headers sent only to that origin
+type OriginHeaders map[string]map[string]string
+
+// LoadOriginHeaders reads OriginHeaders JSON from a file
+func LoadOriginHeaders(path string) (OriginHeaders, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read extra headers: %w", err)
+	}
+	return parseOriginHeaders(data)
+}
+
+// OriginHeadersFromEnv reads AGENT_EXTRA_HEADERS (inline JSON) or AGENT_EXTRA_HEADERS_FILE; nil when unset
+func OriginHeadersFromEnv() (OriginHeaders, error) {
+	if raw := strings.TrimSpace(os.Getenv(originHeadersEnv)); raw != "" {
+		return parseOriginHeaders([]byte(raw))
+	}
+	if path := strings.TrimSpace(os.Getenv(originHeadersFileEnv)); path != "" {
+		return LoadOriginHeaders(path)
+	}
+	return nil, nil
+}
+
+func parseOriginHeaders(data []byte) (OriginHeaders, error) {
+	var raw OriginHeaders
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse extra headers: %w", err)
+	}
+	headers := make(OriginHeaders, len(raw))
+	for origin, h := range raw {
+		key := normalizeOrigin(origin)
+		if key == "" {
+			return nil, fmt.Errorf("extra headers: %q is not an origin (want scheme://host[:port])", origin)
+		}
+		headers[key] = h
+	}
+	return headers, nil
+}
+
+// normalizeOrigin returns scheme://host[:port] in lower case without default ports, "" if raw has no host
+func normalizeOrigin(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	scheme, host, port := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host
+}
+
+// headerInjector adds per-origin headers through routing: SetExtraHTTPHeaders is context-wide
+// and would leak tokens to third-party origins
+type headerInjector struct {
+	headers OriginHeaders
+}
+
+// EnableOriginHeaders installs the header injection for the current and any recreated context
+func (c *controller) EnableOriginHeaders(headers OriginHeaders) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	c.headerInjector = &headerInjector{headers: headers}
+	return c.installRoutes(c.context)
+}
+
+// handle fetches requests to a configured origin with its headers. Header overrides passed to
+// Fallback/Continue also apply to the redirects Chromium follows, so a first-party 30x to a third
+// party would carry the token along; fetching without redirects and fulfilling the 30x makes the
+// browser issue the next hop as a new request, which comes back here and is judged by its own origin.
+func (h *headerInjector) handle(route playwright.Route) {
+	req := route.Request()
+	headers, ok := h.requestHeaders(req.URL(), req.Headers())
+	if !ok {
+		_ = route.Fallback()
+		return
+	}
+	resp, err := route.Fetch(playwright.RouteFetchOptions{Headers: headers, MaxRedirects: playwright.Int(0)})
+	if err != nil {
+		_ = route.Abort()
+		return
+	}
+	_ = route.Fulfill(playwright.RouteFulfillOptions{Response: resp})
+}
+
+// requestHeaders merges the extra headers of rawURL's origin into base; false when the origin has none
+func (h *headerInjector) requestHeaders(rawURL string, base map[string]string) (map[string]string, bool) {
+	extra, ok := h.headers[normalizeOrigin(rawURL)]
+	if !ok {
+		return nil, false
+	}
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[strings.ToLower(k)] = v
+	}
+	return merged, true
+}
@@ -0,0 +1,246 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fakeRoute serves one routed request over real HTTP the way Playwright does: Fallback sends it
+// with the browser's headers, Fetch honours MaxRedirects and Fulfill hands the response back
+type fakeRoute struct {
+	playwright.Route
+	req       fakeRequest
+	fulfilled *http.Response
+	aborted   bool
+}
+
+type fakeRequest struct {
+	playwright.Request
+	url string
+}
+
+func (r fakeRequest) URL() string                { return r.url }
+func (r fakeRequest) Headers() map[string]string { return map[string]string{"accept": "*/*"} }
+
+type fakeAPIResponse struct {
+	playwright.APIResponse
+	resp *http.Response
+}
+
+func (r *fakeRoute) Request() playwright.Request { return r.req }
+
+func (r *fakeRoute) Fallback(options ...playwright.RouteFallbackOptions) error {
+	var headers map[string]string
+	if len(options) > 0 {
+		headers = options[0].Headers
+	}
+	resp, err := r.send(headers, 20)
+	r.fulfilled = resp
+	return err
+}
+
+func (r *fakeRoute) Fetch(options ...playwright.RouteFetchOptions) (playwright.APIResponse, error) {
+	var opts playwright.RouteFetchOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	maxRedirects := 20
+	if opts.MaxRedirects != nil {
+		maxRedirects = *opts.MaxRedirects
+	}
+	resp, err := r.send(opts.Headers, maxRedirects)
+	if err != nil {
+		return nil, err
+	}
+	return fakeAPIResponse{resp: resp}, nil
+}
+
+func (r *fakeRoute) Fulfill(options ...playwright.RouteFulfillOptions) error {
+	r.fulfilled = options[0].Response.(fakeAPIResponse).resp
+	return nil
+}
+
+func (r *fakeRoute) Abort(errorCode ...string) error {
+	r.aborted = true
+	return nil
+}
+
+// send issues the request with headers on every hop - Chromium keeps header overrides across
+// the redirects it follows
+func (r *fakeRoute) send(headers map[string]string, maxRedirects int) (*http.Response, error) {
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return nil
+	}}
+	req, err := http.NewRequest(http.MethodGet, r.req.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// browse routes rawURL through handle and follows fulfilled redirects as new requests, like the browser
+func browse(t *testing.T, handle func(playwright.Route), rawURL string) *http.Response {
+	t.Helper()
+	for hops := 0; hops < 5; hops++ {
+		route := &fakeRoute{req: fakeRequest{url: rawURL}}
+		handle(route)
+		if route.aborted || route.fulfilled == nil {
+			t.Fatalf("request to %s was not served", rawURL)
+		}
+		location := route.fulfilled.Header.Get("Location")
+		if location == "" {
+			return route.fulfilled
+		}
+		next, err := route.fulfilled.Request.URL.Parse(location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawURL = next.String()
+	}
+	t.Fatalf("too many redirects from %s", rawURL)
+	return nil
+}
+
+// headerLog records the X-Preview-Token values a server received
+type headerLog struct {
+	mu     sync.Mutex
+	tokens []string
+}
+
+func (l *headerLog) record(r *http.Request) {
+	l.mu.Lock()
+	l.tokens = append(l.tokens, r.Header.Get("X-Preview-Token"))
+	l.mu.Unlock()
+}
+
+func TestOriginHeadersStayFirstParty(t *testing.T) {
+	var thirdLog, firstLog headerLog
+	third := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thirdLog.record(r)
+		_, _ = io.WriteString(w, "third party")
+	}))
+	defer third.Close()
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		firstLog.record(r)
+		switch r.URL.Path {
+		case "/out":
+			http.Redirect(w, r, third.URL+"/landing", http.StatusFound)
+		default:
+			_, _ = io.WriteString(w, "first party")
+		}
+	}))
+	defer first.Close()
+
+	injector := &headerInjector{headers: OriginHeaders{
+		normalizeOrigin(first.URL): {"X-Preview-Token": "secret"},
+	}}
+
+	tests := []struct {
+		name       string
+		url        string
+		firstWant  []string
+		thirdWant  []string
+		wantStatus int
+	}{
+		{"first party gets the header", first.URL + "/home", []string{"secret"}, nil, http.StatusOK},
+		{"third party never gets it", third.URL + "/landing", nil, []string{""}, http.StatusOK},
+		{"redirect to a third party drops it", first.URL + "/out", []string{"secret"}, []string{""}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstLog.tokens, thirdLog.tokens = nil, nil
+			resp := browse(t, injector.handle, tt.url)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if !equalStrings(firstLog.tokens, tt.firstWant) {
+				t.Errorf("first party saw tokens %q, want %q", firstLog.tokens, tt.firstWant)
+			}
+			if !equalStrings(thirdLog.tokens, tt.thirdWant) {
+				t.Errorf("third party saw tokens %q, want %q", thirdLog.tokens, tt.thirdWant)
+			}
+		})
+	}
+}
+
+// TestFallbackLeaksOnRedirect pins down why handle fetches instead of overriding headers in Fallback
+func TestFallbackLeaksOnRedirect(t *testing.T) {
+	var thirdLog headerLog
+	third := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thirdLog.record(r)
+	}))
+	defer third.Close()
+	first := httptest.NewServer(http.RedirectHandler(third.URL, http.StatusFound))
+	defer first.Close()
+
+	injector := &headerInjector{headers: OriginHeaders{
+		normalizeOrigin(first.URL): {"X-Preview-Token": "secret"},
+	}}
+	fallback := func(route playwright.Route) {
+		headers, _ := injector.requestHeaders(route.Request().URL(), route.Request().Headers())
+		_ = route.Fallback(playwright.RouteFallbackOptions{Headers: headers})
+	}
+	browse(t, fallback, first.URL)
+	if !equalStrings(thirdLog.tokens, []string{"secret"}) {
+		t.Fatalf("fake route does not model header overrides on redirects: third party saw %q", thirdLog.tokens)
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	injector := &headerInjector{headers: OriginHeaders{
+		"https://staging.example.com": {"X-Preview-Token": "secret"},
+	}}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://staging.example.com/path?q=1", true},
+		{"https://STAGING.example.com:443/", true},
+		{"http://staging.example.com/", false},
+		{"https://staging.example.com:8443/", false},
+		{"https://cdn.example.com/app.js", false},
+		{"https://staging.example.com.evil.test/", false},
+	}
+	for _, tt := range tests {
+		headers, ok := injector.requestHeaders(tt.url, map[string]string{"accept": "*/*"})
+		if ok != tt.want {
+			t.Errorf("requestHeaders(%q) ok = %v, want %v", tt.url, ok, tt.want)
+			continue
+		}
+		if ok && (headers["x-preview-token"] != "secret" || headers["accept"] != "*/*") {
+			t.Errorf("requestHeaders(%q) = %v, want the token merged into the request headers", tt.url, headers)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
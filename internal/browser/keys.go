@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// namedKeys are key names accepted by Playwright keyboard API (besides single characters)
+var namedKeys = map[string]bool{
+	"Enter": true, "Escape": true, "Tab": true, "Backspace": true, "Delete": true, "Insert": true, "Space": true,
+	"ArrowUp": true, "ArrowDown": true, "ArrowLeft": true, "ArrowRight": true,
+	"Home": true, "End": true, "PageUp": true, "PageDown": true,
+	"Shift": true, "Control": true, "Alt": true, "Meta": true, "ControlOrMeta": true,
+	"ShiftLeft": true, "ShiftRight": true, "ControlLeft": true, "ControlRight": true,
+	"AltLeft": true, "AltRight": true, "MetaLeft": true, "MetaRight": true,
+	"CapsLock": true, "ContextMenu": true,
+	"Backquote": true, "Minus": true, "Equal": true, "Backslash": true,
+	"BracketLeft": true, "BracketRight": true, "Semicolon": true, "Quote": true,
+	"Comma": true, "Period": true, "Slash": true,
+}
+
+// ValidateKey checks a key or key combination ("Enter", "Control+A", "Shift+Tab")
+// against the names Playwright accepts
+func ValidateKey(key string) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("key is empty")
+	}
+	parts := []string{key}
+	if key != "+" && strings.Contains(key, "+") {
+		parts = strings.Split(key, "+")
+	}
+	for _, part := range parts {
+		if !validKeyName(part) {
+			return fmt.Errorf("unknown key %q (use e.g. Enter, Escape, Tab, ArrowDown, Backspace, PageDown, F1-F12, KeyA-KeyZ, Digit0-Digit9, a single character, or a combination like Control+A)", part)
+		}
+	}
+	return nil
+}
+
+func validKeyName(name string) bool {
+	if utf8.RuneCountInString(name) == 1 {
+		return true
+	}
+	if namedKeys[name] {
+		return true
+	}
+	// F1-F12
+	if len(name) >= 2 && len(name) <= 3 && name[0] == 'F' {
+		var n int
+		if _, err := fmt.Sscanf(name[1:], "%d", &n); err == nil && n >= 1 && n <= 12 {
+			return true
+		}
+	}
+	// KeyA-KeyZ
+	if len(name) == 4 && strings.HasPrefix(name, "Key") && name[3] >= 'A' && name[3] <= 'Z' {
+		return true
+	}
+	// Digit0-Digit9
+	if len(name) == 6 && strings.HasPrefix(name, "Digit") && name[5] >= '0' && name[5] <= '9' {
+		return true
+	}
+	return false
+}
+
+// PressKey presses a key (or combination) on the focused element, focusing selector first if given
+func (c *controller) PressKey(ctx context.Context, selector, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	if strings.TrimSpace(selector) != "" {
+		first := c.page.Locator(selector).First()
+		if err := first.WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateVisible}); err != nil {
+			return wrap(err)
+		}
+		return wrap(first.Press(key))
+	}
+	return wrap(c.page.Keyboard().Press(key))
+}
@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+)
+
+// Alive reports whether Chromium is still connected (it can be OOM-killed under a long-running process).
+// Cheap: the connection state is tracked locally, no round trip to the browser.
+func (l *Launcher) Alive() bool {
+	return l.browser != nil && l.browser.IsConnected()
+}
+
+// Relaunch starts a fresh Chromium with the same settings after the old one died.
+// Controllers created from the old browser are unusable afterwards - create new ones.
+func (l *Launcher) Relaunch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.pw == nil {
+		return fmt.Errorf("playwright is not running")
+	}
+	if l.browser != nil {
+		_ = l.browser.Close()
+	}
+	browser, err := launchChromium(l.pw, l.headless)
+	if err != nil {
+		l.browser = nil
+		return err
+	}
+	l.browser = browser
+	return nil
+}
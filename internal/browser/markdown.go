@@ -0,0 +1,205 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// markdownScript renders the element matching arg.selector (body when empty) as Markdown:
+// headings, paragraphs, nested lists, links with absolute hrefs, tables, emphasis, code and
+// quotes; hidden elements and form controls are skipped, same-origin iframes are inlined.
+// Returns null when the selector matches nothing. Whitespace is tidied in Go (tidyMarkdown).
+const markdownScript = `(arg) => {
+	const root = arg.selector ? document.querySelector(arg.selector) : document.body;
+	if (!root) return null;
+	const skip = new Set(['script', 'style', 'noscript', 'template', 'svg', 'canvas', 'head', 'input', 'select', 'textarea', 'option']);
+	const blocks = new Set(['p', 'div', 'section', 'article', 'main', 'header', 'footer', 'nav', 'aside', 'form',
+		'fieldset', 'figure', 'figcaption', 'dl', 'dt', 'dd', 'details', 'summary', 'address', 'caption']);
+	let skippedFrames = 0;
+	const hidden = (el) => {
+		if (el.getAttribute('aria-hidden') === 'true') return true;
+		const cs = el.ownerDocument.defaultView.getComputedStyle(el);
+		return cs.display === 'none' || cs.visibility === 'hidden';
+	};
+	const block = (s) => { s = s.trim(); return s ? '\n\n' + s + '\n\n' : ''; };
+	const oneLine = (s) => s.replace(/\s+/g, ' ').trim();
+	const wrap = (mark, s) => {
+		const t = s.trim();
+		if (!t) return s;
+		// Keep the surrounding spaces outside the markers: "a **b** c", not "a** b **c"
+		return (/^\s/.test(s) ? ' ' : '') + mark + t + mark + (/\s$/.test(s) ? ' ' : '');
+	};
+	const children = (node, depth) => {
+		let out = '';
+		for (const c of node.childNodes) out += walk(c, depth);
+		return out;
+	};
+	const list = (el, depth) => {
+		const ordered = el.localName === 'ol';
+		let n = parseInt(el.getAttribute('start') || '1', 10) || 1;
+		const lines = [];
+		for (const li of el.children) {
+			if (li.localName !== 'li' || hidden(li)) continue;
+			let text = '';
+			const nested = [];
+			for (const c of li.childNodes) {
+				if (c.nodeType === 1 && (c.localName === 'ul' || c.localName === 'ol')) {
+					if (!hidden(c)) nested.push(list(c, depth + 1));
+				} else {
+					text += walk(c, depth);
+				}
+			}
+			text = oneLine(text);
+			if (text || nested.length) lines.push('  '.repeat(depth) + (ordered ? (n++) + '. ' : '- ') + text);
+			for (const sub of nested) if (sub) lines.push(sub);
+		}
+		return lines.join('\n');
+	};
+	const table = (el) => {
+		const rows = [];
+		for (const row of el.rows) {
+			if (hidden(row)) continue;
+			const cells = Array.from(row.cells, c => oneLine(children(c, 0)).replace(/\|/g, '\\|'));
+			if (cells.some(c => c)) rows.push(cells);
+		}
+		if (!rows.length) return '';
+		const width = Math.max(...rows.map(r => r.length));
+		const line = (r) => '| ' + Array.from({length: width}, (_, i) => r[i] || '').join(' | ') + ' |';
+		const out = [line(rows[0]), '|' + ' --- |'.repeat(width), ...rows.slice(1).map(line)];
+		const caption = el.caption ? oneLine(el.caption.innerText) : '';
+		return block((caption ? '**' + caption + '**\n\n' : '') + out.join('\n'));
+	};
+	const walk = (node, depth) => {
+		if (node.nodeType === 3) return node.nodeValue.replace(/\s+/g, ' ');
+		if (node.nodeType !== 1) return '';
+		const tag = node.localName;
+		if (skip.has(tag) || hidden(node)) return '';
+		const heading = /^h([1-6])$/.exec(tag);
+		if (heading) return block('#'.repeat(+heading[1]) + ' ' + oneLine(children(node, depth)));
+		switch (tag) {
+		case 'br': return '\n';
+		case 'hr': return block('---');
+		case 'strong': case 'b': return wrap('**', children(node, depth));
+		case 'em': case 'i': return wrap('*', children(node, depth));
+		case 'del': case 's': return wrap('~~', children(node, depth));
+		case 'code': return node.closest('pre') ? node.textContent : wrap('` + "`" + `', node.textContent);
+		case 'pre': return block('` + "```" + `\n' + node.textContent.replace(/\n+$/, '') + '\n` + "```" + `');
+		case 'ul': case 'ol': return depth === 0 ? block(list(node, 0)) : list(node, depth);
+		case 'table': return table(node);
+		case 'blockquote': return block(children(node, depth).trim().split('\n').map(l => '> ' + l.trim()).join('\n'));
+		case 'img': {
+			const alt = oneLine(node.getAttribute('alt') || '');
+			return alt ? '![' + alt + ']' : '';
+		}
+		case 'a': {
+			// Card links often wrap a heading: keep its text, not the # marks
+			const text = oneLine(children(node, depth)).replace(/^#+ /, '');
+			const href = node.getAttribute('href') || '';
+			if (!text || !href || href.startsWith('#') || /^javascript:/i.test(href)) return text ? ' ' + text + ' ' : '';
+			return ' [' + text + '](' + node.href + ') ';
+		}
+		case 'iframe': {
+			let doc = null;
+			try { doc = node.contentDocument; } catch (e) {}
+			if (!doc || !doc.body) { skippedFrames++; return ''; }
+			return block(children(doc.body, 0));
+		}
+		}
+		const inner = children(node, depth);
+		return blocks.has(tag) ? block(inner) : inner;
+	};
+	return {text: walk(root, 0), skipped_frames: skippedFrames};
+}`
+
+var (
+	markdownBlankLines = regexp.MustCompile(`\n{3,}`)
+	markdownSpaces     = regexp.MustCompile(`[ \t]{2,}`)
+	markdownListItem   = regexp.MustCompile(`^ *(?:[-*]|\d+\.) `)
+)
+
+// ReadMarkdown renders the page, or the element matching selector, as Markdown cut to
+// maxChars runes (0 = no cap) at a block boundary; cross-origin iframes are left out with a note
+func (c *controller) ReadMarkdown(ctx context.Context, selector string, maxChars int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := c.page.Evaluate(markdownScript, map[string]interface{}{"selector": selector})
+	if err != nil {
+		return "", wrap(err)
+	}
+	if val == nil {
+		return "", fmt.Errorf("no element matches %s", selector)
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Text          string `json:"text"`
+		SkippedFrames int    `json:"skipped_frames"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("decode markdown: %w", err)
+	}
+	md := tidyMarkdown(out.Text)
+	if out.SkippedFrames > 0 {
+		md += fmt.Sprintf("\n\n(%d cross-origin iframes not included - use read_page for their text)", out.SkippedFrames)
+	}
+	return cutMarkdown(md, maxChars), nil
+}
+
+// tidyMarkdown trims lines and collapses the whitespace left by inline elements, keeping
+// list indentation and fenced code as is, with at most one blank line between blocks
+func tidyMarkdown(raw string) string {
+	lines := strings.Split(raw, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			lines[i] = strings.TrimSpace(line)
+			continue
+		}
+		if inCode {
+			lines[i] = strings.TrimRight(line, " \t")
+			continue
+		}
+		indent := ""
+		if m := markdownListItem.FindString(line); m != "" {
+			indent = m[:len(m)-len(strings.TrimLeft(m, " "))]
+		}
+		lines[i] = indent + markdownSpaces.ReplaceAllString(strings.TrimSpace(line), " ")
+	}
+	return strings.TrimSpace(markdownBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// cutMarkdown keeps the first maxChars runes of md, ending at the last block (blank line) or
+// at least the last line that fits, and says how much was left out
+func cutMarkdown(md string, maxChars int) string {
+	total := utf8.RuneCountInString(md)
+	if maxChars <= 0 || total <= maxChars {
+		return md
+	}
+	note := fmt.Sprintf("\n\n... (cut at %d of %d chars - use selector to read a part of the page)", maxChars, total)
+	keep := maxChars - utf8.RuneCountInString(note)
+	if keep < 0 {
+		keep = 0
+	}
+	cut := md
+	if runes := []rune(md); len(runes) > keep {
+		cut = string(runes[:keep])
+	}
+	if i := strings.LastIndex(cut, "\n\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	} else if i := strings.LastIndexByte(cut, '\n'); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	cut = strings.TrimRight(cut, " \n")
+	if strings.Count(cut, "```")%2 == 1 {
+		cut += "\n```" // Close a code block cut in the middle
+	}
+	return cut + note
+}
@@ -0,0 +1,68 @@
+package browser_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/markdown/*.md from the browser output")
+
+// fixtureOrigin replaces the test server URL in golden files: link hrefs are absolute
+const fixtureOrigin = "http://fixture"
+
+func TestReadMarkdownGolden(t *testing.T) {
+	tests := []struct {
+		page     string
+		selector string
+		golden   string
+	}{
+		{"article.html", "", "article.md"},
+		{"article.html", "article ul", "article_list.md"},
+		{"table.html", "", "table.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			ctrl, server := browsertest.Open(t, "testdata/markdown", tt.page)
+			md, err := ctrl.ReadMarkdown(context.Background(), tt.selector, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.ReplaceAll(md, server, fixtureOrigin) + "\n"
+			path := filepath.Join("testdata", "markdown", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("Markdown changed, run with -update if intended:\n--- got\n%s\n--- want\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestReadMarkdownFixtureLimits(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata/markdown", "article.html")
+	ctx := context.Background()
+	md, err := ctrl.ReadMarkdown(ctx, "", 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utf8.RuneCountInString(md) > 120 || !strings.HasPrefix(md, "[Home](") || !strings.Contains(md, "... (cut at 120 of ") {
+		t.Errorf("ReadMarkdown with max_chars 120 = %q", md)
+	}
+	if _, err := ctrl.ReadMarkdown(ctx, "#missing", 0); err == nil {
+		t.Error("ReadMarkdown(#missing) succeeded")
+	}
+}
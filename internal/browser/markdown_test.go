@@ -0,0 +1,63 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCutMarkdown(t *testing.T) {
+	paragraph := strings.Repeat("Съешь же ещё этих мягких французских булок 🥐 ", 4)
+	md := strings.Repeat("## Раздел 📦\n\n"+paragraph+"\n\n", 20)
+	total := utf8.RuneCountInString(md)
+	for _, maxChars := range []int{150, 200, 333, 1000, total - 1} {
+		got := cutMarkdown(md, maxChars)
+		if !utf8.ValidString(got) {
+			t.Fatalf("cutMarkdown(%d) is not valid UTF-8", maxChars)
+		}
+		if n := utf8.RuneCountInString(got); n > maxChars {
+			t.Errorf("cutMarkdown(%d) has %d runes", maxChars, n)
+		}
+		if !strings.Contains(got, fmt.Sprintf("of %d chars", total)) {
+			t.Errorf("cutMarkdown(%d) = %q, missing the cut note", maxChars, got)
+		}
+		if !strings.HasPrefix(md, strings.SplitN(got, "\n\n... (cut at", 2)[0]) {
+			t.Errorf("cutMarkdown(%d) kept text that is not a prefix", maxChars)
+		}
+	}
+	for _, maxChars := range []int{0, total, total + 1} {
+		if got := cutMarkdown(md, maxChars); got != md {
+			t.Errorf("cutMarkdown(%d) changed the page", maxChars)
+		}
+	}
+}
+
+func TestCutMarkdownClosesCodeBlock(t *testing.T) {
+	md := "Пример:\n\n```\n" + strings.Repeat("строка 🍕\n", 100) + "```\n"
+	got := cutMarkdown(md, 200)
+	if !utf8.ValidString(got) || strings.Count(got, "```")%2 != 0 {
+		t.Fatalf("cutMarkdown = %q", got)
+	}
+}
+
+func TestTidyMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"trims lines and blank runs", "\n\n  # Заголовок  \n\n \n\n\nТекст   с    пробелами \n\n", "# Заголовок\n\nТекст с пробелами"},
+		{"keeps list indentation", "- один\n  - два   пункта\n    1. три", "- один\n  - два пункта\n    1. три"},
+		{"indented text is not a list", "   просто   текст", "просто текст"},
+		{"keeps code as is", "Код:\n\n  ```  \n  go   test  ./...   \n\tкоманда\n```\n", "Код:\n\n```\n  go   test  ./...\n\tкоманда\n```"},
+		{"table rows", "|  Зона | Цена  |\n| --- | --- |", "| Зона | Цена |\n| --- | --- |"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tidyMarkdown(tt.raw); got != tt.want {
+				t.Errorf("tidyMarkdown(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
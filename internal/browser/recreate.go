@@ -0,0 +1,148 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Recreate replaces a dead BrowserContext (calls fail with "context closed" while Chromium is fine).
+// Cookies are carried over from the old context if it still answers, otherwise from the browser-level
+// CDP session; the new context uses the same options and storage, and the page returns to the last URL.
+func (c *controller) Recreate(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.browser == nil || !c.browser.IsConnected() {
+		return fmt.Errorf("browser is not connected - context cannot be recreated")
+	}
+	lastURL := c.page.URL()
+	cookies := c.snapshotCookies(lastURL)
+
+	_ = c.context.Close()
+
+	newCtx, err := c.browser.NewContext(c.contextOpts)
+	if err != nil {
+		return fmt.Errorf("recreate context: %w", err)
+	}
+	if len(cookies) > 0 {
+		if err := newCtx.AddCookies(cookies); err != nil {
+			c.note("recreated browser context without cookies (restore failed: %v) - the session may be logged out", err)
+		}
+	}
+	if err := c.installRoutes(newCtx); err != nil {
+		_ = newCtx.Close()
+		return fmt.Errorf("reinstall routes: %w", err)
+	}
+	if c.maxPages > 0 {
+		c.watchPages(newCtx)
+	}
+	c.watchTabs(newCtx)
+	if c.downloads != nil {
+		c.watchDownloads(newCtx)
+	}
+	page, err := newCtx.NewPage()
+	if err != nil {
+		_ = newCtx.Close()
+		return fmt.Errorf("recreate page: %w", err)
+	}
+	page.SetDefaultTimeout(float64(defaultNavTimeout.Milliseconds()))
+	c.tabsMu.Lock()
+	c.context = newCtx
+	c.page = page
+	c.openedTab = nil
+	c.openers = nil
+	c.tabsMu.Unlock()
+	c.applyWindowBounds() // The new context opens a new window
+
+	if strings.HasPrefix(lastURL, "http://") || strings.HasPrefix(lastURL, "https://") {
+		if err := c.Navigate(ctx, lastURL); err != nil {
+			return fmt.Errorf("navigate back to %s: %w", lastURL, err)
+		}
+	}
+	return nil
+}
+
+// installRoutes (re)registers the enabled route handlers on ctx in a fixed order. Playwright runs
+// the last registered handler first, so the guards come after the header injector: they see every
+// request before it, and the injector ends the chain by fetching the request itself.
+func (c *controller) installRoutes(ctx playwright.BrowserContext) error {
+	if err := ctx.Unroute("**/*"); err != nil {
+		return fmt.Errorf("reset routes: %w", err)
+	}
+	if c.headerInjector != nil {
+		if err := ctx.Route("**/*", c.headerInjector.handle); err != nil {
+			return fmt.Errorf("install origin headers: %w", err)
+		}
+	}
+	if c.formGuard != nil {
+		if err := ctx.Route("**/*", c.formGuard.handle); err != nil {
+			return fmt.Errorf("install form guard: %w", err)
+		}
+	}
+	if c.domainGuard != nil {
+		if err := ctx.Route("**/*", c.domainGuard.handle); err != nil {
+			return fmt.Errorf("install domain guard: %w", err)
+		}
+	}
+	return nil
+}
+
+// snapshotCookies reads cookies of the current context, preferring the context API
+// and falling back to the browser-level CDP session
+func (c *controller) snapshotCookies(pageURL string) []playwright.OptionalCookie {
+	if state, err := c.context.StorageState(); err == nil && state != nil {
+		cookies := make([]playwright.OptionalCookie, 0, len(state.Cookies))
+		for _, ck := range state.Cookies {
+			cookies = append(cookies, ck.ToOptionalCookie())
+		}
+		return cookies
+	}
+
+	session, err := c.browser.NewBrowserCDPSession()
+	if err != nil {
+		return nil
+	}
+	defer session.Detach()
+
+	// Find our browser context id by the page URL - the default context has different cookies
+	params := map[string]interface{}{}
+	if targets, err := session.Send("Target.getTargets", map[string]interface{}{}); err == nil {
+		if m, ok := targets.(map[string]interface{}); ok {
+			infos, _ := m["targetInfos"].([]interface{})
+			for _, raw := range infos {
+				info, _ := raw.(map[string]interface{})
+				if info["type"] == "page" && info["url"] == pageURL {
+					if id, ok := info["browserContextId"].(string); ok {
+						params["browserContextId"] = id
+					}
+					break
+				}
+			}
+		}
+	}
+	if _, ok := params["browserContextId"]; !ok {
+		return nil
+	}
+	res, err := session.Send("Storage.getCookies", params)
+	if err != nil {
+		return nil
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	// CDP cookie fields (name, value, domain, path, expires, httpOnly, secure, sameSite) match playwright's
+	data, err := json.Marshal(m["cookies"])
+	if err != nil {
+		return nil
+	}
+	var cookies []playwright.OptionalCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil
+	}
+	return cookies
+}
@@ -0,0 +1,76 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ResourceUsage is what the browser context holds right now
+type ResourceUsage struct {
+	Pages       int   `json:"pages"`
+	JSHeapBytes int64 `json:"js_heap_bytes"` // Used JS heap summed over pages (CDP Performance metrics)
+}
+
+// LimitPages closes pages opened beyond max (popups, target=_blank) in the current and any
+// recreated context, so one task can't pile up tabs; 0 = no limit
+func (c *controller) LimitPages(max int) {
+	c.maxPages = max
+	if max > 0 {
+		c.watchPages(c.context)
+	}
+}
+
+func (c *controller) watchPages(bctx playwright.BrowserContext) {
+	max := c.maxPages
+	bctx.OnPage(func(page playwright.Page) {
+		if open := len(bctx.Pages()); open > max {
+			c.note("closed a new page: %d open, limit %d", open, max)
+			_ = page.Close()
+		}
+	})
+}
+
+// ResourceUsage reports open pages and used JS heap of the current context.
+// Heap comes from CDP, so it is Chromium-only; pages whose metrics fail count as 0.
+func (c *controller) ResourceUsage(ctx context.Context) (ResourceUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return ResourceUsage{}, err
+	}
+	pages := c.context.Pages()
+	usage := ResourceUsage{Pages: len(pages)}
+	for _, page := range pages {
+		heap, err := c.jsHeapUsed(page)
+		if err != nil {
+			continue
+		}
+		usage.JSHeapBytes += heap
+	}
+	return usage, nil
+}
+
+func (c *controller) jsHeapUsed(page playwright.Page) (int64, error) {
+	session, err := c.context.NewCDPSession(page)
+	if err != nil {
+		return 0, wrap(err)
+	}
+	defer session.Detach()
+	if _, err := session.Send("Performance.enable", map[string]interface{}{}); err != nil {
+		return 0, wrap(err)
+	}
+	res, err := session.Send("Performance.getMetrics", map[string]interface{}{})
+	if err != nil {
+		return 0, wrap(err)
+	}
+	m, _ := res.(map[string]interface{})
+	metrics, _ := m["metrics"].([]interface{})
+	for _, raw := range metrics {
+		metric, _ := raw.(map[string]interface{})
+		if metric["name"] == "JSHeapUsedSize" {
+			value, _ := metric["value"].(float64)
+			return int64(value), nil
+		}
+	}
+	return 0, fmt.Errorf("JSHeapUsedSize metric missing")
+}
@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const stateFallbackDir = ".agent-state"
+
+// StateFallbackError reports that storage state was saved, but not where requested
+type StateFallbackError struct {
+	Requested string
+	Used      string
+	Err       error // Why the requested path failed
+}
+
+func (e *StateFallbackError) Error() string {
+	return fmt.Sprintf("save state to %s failed (%v), saved to %s instead", e.Requested, e.Err, e.Used)
+}
+
+func (e *StateFallbackError) Unwrap() error {
+	return e.Err
+}
+
+// saveStateFile writes data to path, or once more to the workdir fallback when path fails;
+// the error is a *StateFallbackError when only the fallback worked
+func saveStateFile(path string, data []byte) (string, error) {
+	err := WriteFileAtomic(path, data, 0o600)
+	if err == nil {
+		return path, nil
+	}
+	fallback := stateFallbackPath(path)
+	if fallback == "" {
+		return "", fmt.Errorf("save state to %s: %w", path, err)
+	}
+	if ferr := WriteFileAtomic(fallback, data, 0o600); ferr != nil {
+		return "", fmt.Errorf("save state to %s: %v; fallback %s: %w", path, err, fallback, ferr)
+	}
+	return fallback, &StateFallbackError{Requested: path, Used: fallback, Err: err}
+}
+
+// stateFallbackPath picks a location inside the workdir with the same file name,
+// or "" when it would be the requested path again
+func stateFallbackPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	name := filepath.Base(path)
+	if name == "." || name == string(filepath.Separator) {
+		name = "storage_state.json"
+	}
+	fallback := filepath.Join(wd, stateFallbackDir, name)
+	if abs, err := filepath.Abs(path); err == nil && abs == fallback {
+		return ""
+	}
+	if err := os.MkdirAll(filepath.Dir(fallback), 0o700); err != nil {
+		return ""
+	}
+	return fallback
+}
+
+// WriteFileAtomic writes to a uniquely named temp file in the same directory, fsyncs it and
+// renames over path, so a crash mid-write never leaves a truncated file behind (storage
+// state, run checkpoints)
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	// Persist the rename itself; not supported everywhere, so best effort
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inTempWorkdir runs the test from an empty workdir, where the fallback directory goes
+func inTempWorkdir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(prev) })
+	return dir
+}
+
+func TestSaveStateFile(t *testing.T) {
+	wd := inTempWorkdir(t)
+	path := filepath.Join(wd, "state.json")
+	used, err := saveStateFile(path, []byte(`{"cookies":[]}`))
+	if err != nil || used != path {
+		t.Fatalf("saveStateFile = %q, %v", used, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("state file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(filepath.Join(wd, stateFallbackDir)); !os.IsNotExist(err) {
+		t.Fatal("fallback directory created without a failure")
+	}
+}
+
+func TestSaveStateFileFallsBackOnPermissionError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	wd := inTempWorkdir(t)
+	readOnly := filepath.Join(wd, "readonly")
+	if err := os.Mkdir(readOnly, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(readOnly, 0o700) })
+
+	used, err := saveStateFile(filepath.Join(readOnly, "state.json"), []byte(`{}`))
+	var fallback *StateFallbackError
+	if !errors.As(err, &fallback) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("err = %v, want a fallback after a permission error", err)
+	}
+	if want := filepath.Join(wd, stateFallbackDir, "state.json"); used != want || fallback.Used != want {
+		t.Fatalf("used = %q, want %q", used, want)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(readOnly, "state.json")) || !strings.Contains(err.Error(), used) {
+		t.Fatalf("error %q must name both attempts", err)
+	}
+}
+
+func TestSaveStateFileFallsBackOnUnwritablePath(t *testing.T) {
+	wd := inTempWorkdir(t)
+	// A file where a directory should be fails even for root
+	if err := os.WriteFile(filepath.Join(wd, "blocker"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	requested := filepath.Join(wd, "blocker", "state.json")
+	used, err := saveStateFile(requested, []byte(`{"origins":[]}`))
+	var fallback *StateFallbackError
+	if !errors.As(err, &fallback) || fallback.Requested != requested {
+		t.Fatalf("err = %v, want a fallback for %s", err, requested)
+	}
+	data, rerr := os.ReadFile(used)
+	if rerr != nil || string(data) != `{"origins":[]}` {
+		t.Fatalf("fallback file = %q, %v", data, rerr)
+	}
+}
+
+func TestSaveStateFileReportsBothFailures(t *testing.T) {
+	wd := inTempWorkdir(t)
+	// The fallback directory is a file too, so the retry fails as well
+	if err := os.WriteFile(filepath.Join(wd, stateFallbackDir), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wd, "blocker"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	used, err := saveStateFile(filepath.Join(wd, "blocker", "state.json"), []byte(`{}`))
+	var fallback *StateFallbackError
+	if err == nil || used != "" || errors.As(err, &fallback) {
+		t.Fatalf("saveStateFile = %q, %v; want a plain error", used, err)
+	}
+}
+
+func TestWriteFileAtomicKeepsTheOldFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := WriteFileAtomic(path, []byte("good"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("better"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "better" {
+		t.Fatalf("content = %q after replacing", data)
+	}
+
+	// The rename fails onto a non-empty directory: the target stays intact and no temp file is left
+	target := filepath.Join(dir, "taken")
+	if err := os.MkdirAll(filepath.Join(target, "child"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(target, []byte("x"), 0o600); err == nil {
+		t.Fatal("write over a directory succeeded")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Fatalf("temp file %s left behind", e.Name())
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "better" {
+		t.Fatalf("unrelated state file changed to %q", data)
+	}
+}
@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Submit outcomes reported by FillAndSubmit
+const (
+	SubmitNavigation = "navigation" // URL changed after Enter
+	SubmitDOMChange  = "dom_change" // Same URL, page content updated (XHR results)
+	SubmitNoChange   = "none"       // Nothing observable within the timeout
+)
+
+const submitTimeout = 5 * time.Second
+
+// SubmitResult describes a FillAndSubmit call
+type SubmitResult struct {
+	Value   string // Field value read back after filling
+	Outcome string // SubmitNavigation, SubmitDOMChange or SubmitNoChange
+	URL     string // Page URL after the submit
+}
+
+// submitWatchScript counts DOM mutations from now on; the counter dies with the document on navigation
+const submitWatchScript = `() => {
+	window.__agentSubmitMutations = 0;
+	if (window.__agentSubmitObserver) window.__agentSubmitObserver.disconnect();
+	window.__agentSubmitObserver = new MutationObserver((records) => { window.__agentSubmitMutations += records.length; });
+	window.__agentSubmitObserver.observe(document.body, {childList: true, subtree: true, characterData: true});
+}`
+
+const submitMutationsScript = `() => window.__agentSubmitMutations === undefined ? -1 : window.__agentSubmitMutations`
+
+// FillAndSubmit fills selector, presses Enter on it and waits for a navigation or a DOM change
+func (c *controller) FillAndSubmit(ctx context.Context, selector, text string) (SubmitResult, error) {
+	if err := c.Fill(ctx, selector, text); err != nil {
+		return SubmitResult{}, err
+	}
+	first := c.page.Locator(selector).First()
+	value, err := first.InputValue(playwright.LocatorInputValueOptions{Timeout: playwright.Float(2000)})
+	if err != nil {
+		value = text // contenteditable and custom widgets have no input value
+	}
+
+	before := c.page.URL()
+	if _, err := c.page.Evaluate(submitWatchScript); err != nil {
+		return SubmitResult{}, wrap(err)
+	}
+	if err := first.Press("Enter"); err != nil {
+		return SubmitResult{}, wrap(err)
+	}
+
+	res := SubmitResult{Value: value, Outcome: SubmitNoChange}
+	deadline := time.Now().Add(submitTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return SubmitResult{}, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+		if c.page.URL() != before {
+			res.Outcome = SubmitNavigation
+			break
+		}
+		n, err := c.page.Evaluate(submitMutationsScript)
+		if err != nil || fmt.Sprint(n) == "-1" {
+			res.Outcome = SubmitNavigation // Execution context destroyed - a new document is loading
+			break
+		}
+		if fmt.Sprint(n) != "0" {
+			res.Outcome = SubmitDOMChange
+			break
+		}
+	}
+	if res.Outcome != SubmitNoChange {
+		// Let results finish rendering before the next snapshot
+		if err := c.WaitForStableDOM(ctx, submitTimeout); err != nil && ctx.Err() != nil {
+			return SubmitResult{}, ctx.Err()
+		}
+	}
+	res.URL = c.page.URL()
+	return res, nil
+}
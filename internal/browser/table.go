@@ -0,0 +1,162 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// maxTableFrames bounds how many frames ExtractTable searches (ads, trackers)
+const maxTableFrames = 10
+
+// Table is the content of an HTML table or ARIA grid
+type Table struct {
+	Caption string     `json:"caption,omitempty"`
+	Headers []string   `json:"headers"` // Empty for header-less tables; grouped header rows joined with " / "
+	Rows    [][]string `json:"rows"`    // Data rows, colspan cells padded with "" to keep columns aligned
+	// TotalRows is the number of rendered data rows (Rows holds at most maxRows of them)
+	TotalRows int `json:"total_rows"`
+	// RowCount is aria-rowcount when it exceeds the rendered rows: a virtualized grid
+	// renders only the rows near the viewport
+	RowCount int    `json:"row_count,omitempty"`
+	Frame    string `json:"frame,omitempty"` // URL of the child frame the table is in, "" for the main frame
+	Cells    int    `json:"cells,omitempty"` // Cell count, picks the largest table in auto-detection
+}
+
+// Virtualized reports whether the grid declares more rows than it rendered
+func (t Table) Virtualized() bool {
+	return t.RowCount > t.TotalRows
+}
+
+// extractTableScript reads the table matched by arg.selector (or the table/grid inside it),
+// or the table with the most cells when no selector is given; null when there is none
+const extractTableScript = `(arg) => {
+	const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+	const gridSel = 'table, [role="grid"], [role="table"], [role="treegrid"]';
+	let table = null;
+	if (arg.selector) {
+		table = document.querySelector(arg.selector);
+		if (table && !table.matches(gridSel)) table = table.querySelector(gridSel) || table.closest(gridSel);
+	} else {
+		let best = 0;
+		for (const el of document.querySelectorAll(gridSel)) {
+			const r = el.getBoundingClientRect();
+			if (r.width === 0 && r.height === 0) continue;
+			const n = el.querySelectorAll('td, th, [role="gridcell"], [role="cell"]').length;
+			if (n > best) { best = n; table = el; }
+		}
+	}
+	if (!table) return null;
+	const native = table.tagName === 'TABLE';
+	const rows = native ? Array.from(table.rows)
+		: Array.from(table.querySelectorAll('[role="row"]')).filter(r => r.closest('[role="grid"], [role="table"], [role="treegrid"]') === table);
+	const cellsOf = (row) => native ? Array.from(row.cells)
+		: Array.from(row.querySelectorAll('[role="gridcell"], [role="cell"], [role="columnheader"], [role="rowheader"]')).filter(c => c.closest('[role="row"]') === row);
+	const isHeader = (c) => c.tagName === 'TH' || c.getAttribute('role') === 'columnheader';
+	// Header cells repeat their text over the spanned columns, data cells are padded with ""
+	const expand = (row, header) => {
+		const out = [];
+		for (const c of cellsOf(row)) {
+			const span = Math.min(parseInt(c.getAttribute('colspan') || c.getAttribute('aria-colspan') || '1', 10) || 1, 50);
+			const text = clean(c.innerText || c.textContent);
+			for (let i = 0; i < span; i++) out.push(i === 0 || header ? text : '');
+		}
+		return out;
+	};
+	// Leading rows made of header cells only (thead, role=columnheader) form the header
+	let h = 0;
+	while (h < rows.length) {
+		const cells = cellsOf(rows[h]);
+		const inHead = rows[h].parentElement && rows[h].parentElement.tagName === 'THEAD';
+		if (!cells.length || !(inHead || cells.every(isHeader))) break;
+		h++;
+	}
+	let headers = [];
+	for (const row of rows.slice(0, h)) {
+		expand(row, true).forEach((text, i) => {
+			if (!text) return;
+			headers[i] = headers[i] && headers[i] !== text ? headers[i] + ' / ' + text : text;
+		});
+	}
+	headers = Array.from(headers, t => t || '');
+	const data = [];
+	for (const row of rows.slice(h)) {
+		const cells = expand(row, false);
+		if (cells.some(t => t)) data.push(cells);
+	}
+	const rowCount = parseInt(table.getAttribute('aria-rowcount') || '0', 10) || 0;
+	return {
+		caption: native && table.caption ? clean(table.caption.innerText) : clean(table.getAttribute('aria-label')),
+		headers,
+		rows: data.slice(0, arg.maxRows),
+		total_rows: data.length,
+		// aria-rowcount counts header rows too
+		row_count: rowCount > rows.length ? rowCount - h : 0,
+		cells: table.querySelectorAll('td, th, [role="gridcell"], [role="cell"]').length,
+	};
+}`
+
+// ExtractTable reads headers and up to maxRows rows of the table matching selector, or of
+// the largest visible table/grid when selector is empty, searching the main frame first and
+// then child frames
+func (c *controller) ExtractTable(ctx context.Context, selector string, maxRows int) (Table, error) {
+	if err := ctx.Err(); err != nil {
+		return Table{}, err
+	}
+	arg := map[string]interface{}{"selector": selector, "maxRows": maxRows}
+	var (
+		best     *Table
+		firstErr error
+	)
+	main := c.page.MainFrame()
+	for i, frame := range c.page.Frames() {
+		if i == maxTableFrames || ctx.Err() != nil {
+			break
+		}
+		val, err := frame.Evaluate(extractTableScript, arg)
+		if err != nil {
+			if frame == main {
+				firstErr = wrap(err) // Invalid selector or a broken page; child frame errors are noise
+			}
+			continue
+		}
+		if val == nil {
+			continue
+		}
+		t, err := decodeTable(val)
+		if err != nil {
+			return Table{}, err
+		}
+		if frame != main {
+			t.Frame = frame.URL()
+		}
+		if selector != "" {
+			return t, nil // First match wins, main frame first
+		}
+		if best == nil || t.Cells > best.Cells {
+			best = &t
+		}
+	}
+	if best != nil {
+		return *best, nil
+	}
+	if firstErr != nil {
+		return Table{}, firstErr
+	}
+	if selector != "" {
+		return Table{}, fmt.Errorf("no table matches %s", selector)
+	}
+	return Table{}, fmt.Errorf("no table or grid on the page")
+}
+
+func decodeTable(val interface{}) (Table, error) {
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return Table{}, err
+	}
+	var t Table
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return Table{}, fmt.Errorf("decode table: %w", err)
+	}
+	return t, nil
+}
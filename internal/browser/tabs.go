@@ -0,0 +1,190 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// newTabLoadTimeout bounds the wait for an adopted tab to load its document
+const newTabLoadTimeout = 5000 // ms
+
+// Tab is an open page of the browser context
+type Tab struct {
+	Index  int    `json:"index"` // Position in opening order, what SwitchTab/CloseTab take
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Active bool   `json:"active"` // The page the agent acts on
+}
+
+// TabSwitch is an automatic change of the active tab (AdoptOpenedTab)
+type TabSwitch struct {
+	Tab Tab // Active tab after the switch
+	// Reason is "opened" for a popup or tab opened by the active page (window.open,
+	// target=_blank), "closed" when the popup closed itself (OAuth, payment windows)
+	Reason string
+	URL    string // URL of the popup
+}
+
+func (s TabSwitch) String() string {
+	if s.Reason == "closed" {
+		return fmt.Sprintf("popup closed: %s - back on tab [%d] %s", s.URL, s.Tab.Index, s.Tab.URL)
+	}
+	return fmt.Sprintf("popup opened: %s - now the active tab [%d]", s.URL, s.Tab.Index)
+}
+
+// watchTabs remembers the last page opened by the active page (popups, target=_blank links)
+// and the opener of every popup, for AdoptOpenedTab; pages the agent opens have no opener
+func (c *controller) watchTabs(bctx playwright.BrowserContext) {
+	bctx.OnPage(func(page playwright.Page) {
+		opener, err := page.Opener()
+		if err != nil || opener == nil {
+			return
+		}
+		c.tabsMu.Lock()
+		defer c.tabsMu.Unlock()
+		if c.openers == nil {
+			c.openers = make(map[playwright.Page]playwright.Page)
+		}
+		c.openers[page] = opener
+		if opener == c.page {
+			c.openedTab = page
+		}
+	})
+}
+
+// ListTabs returns the open pages of the context in opening order
+func (c *controller) ListTabs(ctx context.Context) ([]Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pages := c.context.Pages()
+	tabs := make([]Tab, 0, len(pages))
+	for i, page := range pages {
+		tabs = append(tabs, c.tabOf(i, page))
+	}
+	return tabs, nil
+}
+
+func (c *controller) tabOf(index int, page playwright.Page) Tab {
+	title, _ := page.Title()
+	return Tab{Index: index, URL: page.URL(), Title: title, Active: page == c.page}
+}
+
+// SwitchTab makes the tab at index the active page and brings it to front
+func (c *controller) SwitchTab(ctx context.Context, index int) (Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return Tab{}, err
+	}
+	pages := c.context.Pages()
+	if index < 0 || index >= len(pages) {
+		return Tab{}, fmt.Errorf("no tab %d (%d open, indices 0-%d)", index, len(pages), len(pages)-1)
+	}
+	c.activate(pages[index])
+	return c.tabOf(index, pages[index]), nil
+}
+
+// CloseTab closes the tab at index; closing the active tab activates the tab that opened it,
+// or the last remaining one. The only open tab can't be closed. Returns the active tab.
+func (c *controller) CloseTab(ctx context.Context, index int) (Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return Tab{}, err
+	}
+	pages := c.context.Pages()
+	if index < 0 || index >= len(pages) {
+		return Tab{}, fmt.Errorf("no tab %d (%d open, indices 0-%d)", index, len(pages), len(pages)-1)
+	}
+	if len(pages) == 1 {
+		return Tab{}, fmt.Errorf("tab %d is the only open tab - navigate instead of closing it", index)
+	}
+	target := pages[index]
+	if target == c.page {
+		next := pages[len(pages)-1]
+		if next == target {
+			next = pages[len(pages)-2]
+		}
+		if opener := c.openerOf(target); opener != nil {
+			next = opener
+		}
+		c.activate(next)
+	}
+	if err := target.Close(); err != nil {
+		return Tab{}, wrap(err)
+	}
+	return c.activeTab(), nil
+}
+
+// AdoptOpenedTab makes a popup or tab opened by the active page (window.open, target=_blank)
+// the active page, and returns to the opener (or the last open tab) when the active popup
+// closed itself; false when nothing changed. Called after every action and before every
+// snapshot, so the agent follows the page the site switched to.
+func (c *controller) AdoptOpenedTab(ctx context.Context) (TabSwitch, bool) {
+	if ctx.Err() != nil {
+		return TabSwitch{}, false
+	}
+	c.tabsMu.Lock()
+	opened := c.openedTab
+	c.openedTab = nil
+	c.tabsMu.Unlock()
+
+	if opened != nil && !opened.IsClosed() {
+		_ = opened.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+			State:   playwright.LoadStateDomcontentloaded,
+			Timeout: playwright.Float(newTabLoadTimeout),
+		})
+		c.activate(opened)
+		return TabSwitch{Tab: c.activeTab(), Reason: "opened", URL: opened.URL()}, true
+	}
+	if opened != nil {
+		// Opened and closed before anyone looked (instant OAuth handshakes): the opener stays active
+		return TabSwitch{Tab: c.activeTab(), Reason: "closed", URL: opened.URL()}, true
+	}
+	if !c.page.IsClosed() {
+		return TabSwitch{}, false
+	}
+	closed := c.page
+	next := c.openerOf(closed)
+	if next == nil {
+		pages := c.context.Pages()
+		if len(pages) == 0 {
+			return TabSwitch{}, false
+		}
+		next = pages[len(pages)-1]
+	}
+	c.activate(next)
+	c.tabsMu.Lock()
+	delete(c.openers, closed)
+	c.tabsMu.Unlock()
+	return TabSwitch{Tab: c.activeTab(), Reason: "closed", URL: closed.URL()}, true
+}
+
+// openerOf is the still open page that opened page, nil for pages the agent opened
+func (c *controller) openerOf(page playwright.Page) playwright.Page {
+	c.tabsMu.Lock()
+	defer c.tabsMu.Unlock()
+	if opener := c.openers[page]; opener != nil && !opener.IsClosed() {
+		return opener
+	}
+	return nil
+}
+
+// activate makes page the one every Controller method acts on
+func (c *controller) activate(page playwright.Page) {
+	c.tabsMu.Lock()
+	c.page = page
+	c.tabsMu.Unlock()
+	page.SetDefaultTimeout(float64(defaultNavTimeout.Milliseconds()))
+	if !c.headless {
+		_ = page.BringToFront()
+	}
+}
+
+func (c *controller) activeTab() Tab {
+	for i, page := range c.context.Pages() {
+		if page == c.page {
+			return c.tabOf(i, page)
+		}
+	}
+	return Tab{Index: -1, URL: c.page.URL(), Active: true}
+}
@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// UploadFile strategies reported to the planner
+const (
+	UploadByInput   = "input"        // Files set on the <input type=file> itself
+	UploadByChooser = "file chooser" // Element clicked, the file chooser it opened answered
+)
+
+// uploadChooserTimeout bounds the wait for a file chooser after clicking a custom upload button
+const uploadChooserTimeout = 5000 // ms
+
+// fileInputScript finds the file input behind the element: the element itself, the input its
+// <label for> points to, or one nested inside it (styled label/dropzone wrappers). Marks it with
+// data-agent-upload so Go can locate it; false when the element has no file input.
+const fileInputScript = `(el) => {
+	document.querySelectorAll('[data-agent-upload]').forEach(e => e.removeAttribute('data-agent-upload'));
+	const isFile = (e) => e && e.localName === 'input' && e.type === 'file';
+	let input = null;
+	if (isFile(el)) input = el;
+	else if (el.localName === 'label' && isFile(el.control)) input = el.control;
+	else input = el.querySelector('input[type=file]');
+	if (!input || input.disabled) return false;
+	input.setAttribute('data-agent-upload', '1');
+	return true;
+}`
+
+// UploadFile attaches the file at path to the element matching selector: directly when it is
+// (or wraps) an <input type=file>, even a hidden one, otherwise by clicking it and answering
+// the file chooser it opens. Returns the strategy used.
+func (c *controller) UploadFile(ctx context.Context, selector, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	first := c.page.Locator(selector).First()
+	// File inputs are usually hidden behind a styled button: wait for attached, not visible
+	if err := first.WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateAttached}); err != nil {
+		return "", wrap(err)
+	}
+
+	// Strategy 1: set the files on the input
+	if found, err := first.Evaluate(fileInputScript, nil); err == nil && found == true {
+		input := c.page.Locator(`[data-agent-upload="1"]`).First()
+		if err := input.SetInputFiles(path); err != nil {
+			return "", fmt.Errorf("upload: set files on the input: %w", wrap(err))
+		}
+		_, _ = input.Evaluate(`(el) => el.removeAttribute('data-agent-upload')`, nil)
+		return UploadByInput, nil
+	}
+
+	// Strategy 2: the element opens the chooser from script (custom upload buttons)
+	chooser, err := c.page.ExpectFileChooser(func() error {
+		return first.Click()
+	}, playwright.PageExpectFileChooserOptions{Timeout: playwright.Float(uploadChooserTimeout)})
+	if err != nil {
+		return "", fmt.Errorf("upload: element is not a file input and clicking it opened no file chooser: %w", wrap(err))
+	}
+	if err := chooser.SetFiles(path); err != nil {
+		return "", fmt.Errorf("upload: set files on the file chooser: %w", wrap(err))
+	}
+	return UploadByChooser, nil
+}
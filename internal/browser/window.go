@@ -0,0 +1,100 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// windowEnv positions the browser window in headed mode: WIDTHxHEIGHT[+LEFT+TOP], e.g. 1280x900+100+100
+const windowEnv = "AGENT_WINDOW"
+
+// WindowBounds is the position and size of the browser window in screen pixels
+type WindowBounds struct {
+	Left, Top     int
+	Width, Height int
+}
+
+// ParseWindowBounds parses WIDTHxHEIGHT or WIDTHxHEIGHT+LEFT+TOP
+func ParseWindowBounds(spec string) (WindowBounds, error) {
+	var b WindowBounds
+	spec = strings.TrimSpace(spec)
+	n, _ := fmt.Sscanf(spec, "%dx%d+%d+%d", &b.Width, &b.Height, &b.Left, &b.Top)
+	if n != 2 && n != 4 {
+		return WindowBounds{}, fmt.Errorf("invalid window bounds %q: want WIDTHxHEIGHT or WIDTHxHEIGHT+LEFT+TOP", spec)
+	}
+	if n == 2 && strings.Contains(spec, "+") {
+		return WindowBounds{}, fmt.Errorf("invalid window bounds %q: want WIDTHxHEIGHT or WIDTHxHEIGHT+LEFT+TOP", spec)
+	}
+	if b.Width <= 0 || b.Height <= 0 {
+		return WindowBounds{}, fmt.Errorf("invalid window bounds %q: size must be positive", spec)
+	}
+	return b, nil
+}
+
+// BringToFront raises the page's window and tab so the user finds it (captcha, 2FA);
+// a no-op in headless mode
+func (c *controller) BringToFront(ctx context.Context) error {
+	if c.headless {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return wrap(c.page.BringToFront())
+}
+
+// applyWindowBounds positions the window of the current page from AGENT_WINDOW via CDP
+// Browser.setWindowBounds; headless mode and an unset variable are no-ops, errors become notes (TakeNotes)
+func (c *controller) applyWindowBounds() {
+	spec := os.Getenv(windowEnv)
+	if c.headless || strings.TrimSpace(spec) == "" {
+		return
+	}
+	bounds, err := ParseWindowBounds(spec)
+	if err != nil {
+		c.note("%s ignored: %v", windowEnv, err)
+		return
+	}
+	if err := c.setWindowBounds(bounds); err != nil {
+		c.note("set window bounds failed: %v", err)
+	}
+}
+
+func (c *controller) setWindowBounds(b WindowBounds) error {
+	session, err := c.context.NewCDPSession(c.page)
+	if err != nil {
+		return err
+	}
+	defer session.Detach()
+	res, err := session.Send("Browser.getWindowForTarget", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	window, ok := res.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected Browser.getWindowForTarget result %T", res)
+	}
+	windowID, ok := window["windowId"].(float64)
+	if !ok {
+		return fmt.Errorf("Browser.getWindowForTarget returned no windowId")
+	}
+	// A maximized window ignores position and size - restore it first
+	if _, err := session.Send("Browser.setWindowBounds", map[string]interface{}{
+		"windowId": int(windowID),
+		"bounds":   map[string]interface{}{"windowState": "normal"},
+	}); err != nil {
+		return err
+	}
+	_, err = session.Send("Browser.setWindowBounds", map[string]interface{}{
+		"windowId": int(windowID),
+		"bounds": map[string]interface{}{
+			"left":   b.Left,
+			"top":    b.Top,
+			"width":  b.Width,
+			"height": b.Height,
+		},
+	})
+	return err
+}
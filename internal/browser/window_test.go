@@ -0,0 +1,25 @@
+package browser
+
+import "testing"
+
+func TestParseWindowBounds(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    WindowBounds
+		wantErr bool
+	}{
+		{spec: "1280x900+100+100", want: WindowBounds{Left: 100, Top: 100, Width: 1280, Height: 900}},
+		{spec: " 1024x768 ", want: WindowBounds{Width: 1024, Height: 768}},
+		{spec: "1280x900+0+0", want: WindowBounds{Width: 1280, Height: 900}},
+		{spec: "1280x900+100", wantErr: true},
+		{spec: "1280", wantErr: true},
+		{spec: "0x900", wantErr: true},
+		{spec: "big", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWindowBounds(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseWindowBounds(%q) = %+v, %v", tt.spec, got, err)
+		}
+	}
+}
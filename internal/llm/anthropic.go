@@ -3,6 +3,7 @@ package llm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -34,6 +35,8 @@ const (
 type Client interface {
 	Generate(ctx context.Context, req Request) (Response, error)
 	Name() string
+	// ModelInfo reports the context window and output limit of the active model
+	ModelInfo() ModelInfo
 }
 
 type Request struct {
@@ -42,11 +45,23 @@ type Request struct {
 	Tools       []Tool
 	Temperature float32
 	MaxTokens   int
+	// ForceJSONSchema asks the provider to constrain the text output to Schema
+	// (OpenAI response_format json_schema; ignored by providers without structured outputs)
+	ForceJSONSchema bool
+	SchemaName      string
+	Schema          map[string]any
 }
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string  `json:"role"`
+	Content string  `json:"content"`
+	Images  []Image `json:"images,omitempty"` // Optional image parts sent before the text (vision mode)
+}
+
+// Image is an inline image content part
+type Image struct {
+	MediaType string `json:"media_type"` // e.g. "image/jpeg", "image/png"
+	Data      []byte `json:"data"`       // Raw bytes, base64-encoded by the provider client
 }
 
 type Tool struct {
@@ -57,6 +72,20 @@ type Tool struct {
 
 type Response struct {
 	Text string
+	// ToolCall is the first native tool call of the response (Anthropic tool_use block), nil if none
+	ToolCall *ToolCall
+	// Usage is the token count reported by the provider (zero if not reported)
+	Usage Usage
+	// StopReason is the provider's finish/stop reason as reported ("end_turn", "length",
+	// "max_tokens", ...); see Truncated
+	StopReason string
+}
+
+// ToolCall is a structured tool invocation returned by the model
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input map[string]any
 }
 
 type anthropicClient struct {
@@ -69,19 +98,21 @@ type anthropicClient struct {
 func NewAnthropicFromEnv() (Client, error) {
 	key := strings.TrimSpace(os.Getenv(envAPIKey))
 	if key == "" {
-		return nil, fmt.Errorf("missing %s", envAPIKey)
+		return nil, fmt.Errorf("missing %s - export it or add it to .env (keys: https://console.anthropic.com/settings/keys)", envAPIKey)
 	}
 	model := strings.TrimSpace(os.Getenv(envModel))
 	if model == "" {
 		model = defaultModel
 	}
 	model = strings.Trim(model, "\"'")
+	httpClient, err := newHTTPClient(timeoutSecs * time.Second)
+	if err != nil {
+		return nil, err
+	}
 	return &anthropicClient{
 		apiKey: key,
 		model:  model,
-		http: &http.Client{
-			Timeout: timeoutSecs * time.Second,
-		},
+		http:   httpClient,
 		logger: zerolog.Nop(), // Will be set by caller if needed
 	}, nil
 }
@@ -100,24 +131,31 @@ func NewAnthropicWithLogger(logger zerolog.Logger) (Client, error) {
 
 func (c *anthropicClient) Name() string { return c.model }
 
+func (c *anthropicClient) ModelInfo() ModelInfo { return LookupModelInfo(c.model) }
+
 func (c *anthropicClient) Generate(ctx context.Context, req Request) (Response, error) {
+	return generateChecked(ctx, req, maxTokens, c.ModelInfo(), c.logger, c.generate)
+}
+
+func (c *anthropicClient) generate(ctx context.Context, req Request) (Response, error) {
 	// Validate input
 	if len(req.Messages) == 0 {
 		return Response{}, errors.New("no messages")
 	}
 
 	// Validate and sanitize message content
+	sanitizeRequest(&req, c.logger)
 	for i, m := range req.Messages {
 		if len(m.Content) > maxRequestSize {
 			c.logger.Warn().Int("message_idx", i).Int("size", len(m.Content)).Msg("message too large, truncating")
-			req.Messages[i].Content = m.Content[:maxRequestSize] + "... [truncated]"
+			req.Messages[i].Content = cutBytes(m.Content, maxRequestSize) + "... [truncated]"
 		}
 	}
 
 	// Validate system prompt size
 	if len(req.System) > maxRequestSize {
 		c.logger.Warn().Int("size", len(req.System)).Msg("system prompt too large, truncating")
-		req.System = req.System[:maxRequestSize] + "... [truncated]"
+		req.System = cutBytes(req.System, maxRequestSize) + "... [truncated]"
 	}
 
 	var lastErr error
@@ -145,9 +183,21 @@ func (c *anthropicClient) Generate(ctx context.Context, req Request) (Response,
 			payload.System = req.System
 		}
 		for _, m := range req.Messages {
+			content := make([]anthropicContent, 0, len(m.Images)+1)
+			for _, img := range m.Images {
+				content = append(content, anthropicContent{
+					Type: "image",
+					Source: &anthropicImageSource{
+						Type:      "base64",
+						MediaType: img.MediaType,
+						Data:      base64.StdEncoding.EncodeToString(img.Data),
+					},
+				})
+			}
+			content = append(content, anthropicContent{Type: "text", Text: m.Content})
 			payload.Messages = append(payload.Messages, anthropicMessage{
 				Role:    m.Role,
-				Content: []anthropicContent{{Type: "text", Text: m.Content}},
+				Content: content,
 			})
 		}
 		for _, t := range req.Tools {
@@ -211,17 +261,13 @@ func (c *anthropicClient) Generate(ctx context.Context, req Request) (Response,
 			if err := json.Unmarshal(data, &apiErr); err != nil {
 				// If we can't parse error, return raw response
 				errorMsg := rawError
-				if len(errorMsg) > 500 {
-					errorMsg = errorMsg[:500] + "..."
-				}
+				errorMsg = truncateString(errorMsg, 500)
 				lastErr = fmt.Errorf("anthropic %d: %s (raw, parse err: %v)", resp.StatusCode, errorMsg, err)
 			} else {
 				errorMsg := apiErr.Error()
 				if errorMsg == "" {
 					errorMsg = rawError
-					if len(errorMsg) > 500 {
-						errorMsg = errorMsg[:500] + "..."
-					}
+					errorMsg = truncateString(errorMsg, 500)
 				}
 				lastErr = fmt.Errorf("anthropic %d: %s (type: %s)", resp.StatusCode, errorMsg, apiErr.Type)
 			}
@@ -266,17 +312,35 @@ func (c *anthropicClient) Generate(ctx context.Context, req Request) (Response,
 		}
 
 		var buf bytes.Buffer
+		var call *ToolCall
 		for _, content := range ar.Content {
-			if content.Type == "text" {
+			switch content.Type {
+			case "text":
 				buf.WriteString(content.Text)
+			case "tool_use":
+				// One action per step - keep the first tool call
+				if call == nil {
+					input := content.Input
+					if input == nil {
+						input = map[string]any{}
+					}
+					call = &ToolCall{ID: content.ID, Name: content.Name, Input: input}
+				}
 			}
 		}
 
-		c.logger.Debug().
+		logEvent := c.logger.Debug().
 			Int("response_length", buf.Len()).
-			Msg("Anthropic API success")
+			Int("input_tokens", ar.Usage.InputTokens).
+			Int("output_tokens", ar.Usage.OutputTokens).
+			Str("stop_reason", ar.StopReason)
+		if call != nil {
+			logEvent = logEvent.Str("tool_use", call.Name)
+		}
+		logEvent.Msg("Anthropic API success")
 
-		return Response{Text: buf.String()}, nil
+		usage := Usage{PromptTokens: ar.Usage.InputTokens, CompletionTokens: ar.Usage.OutputTokens}
+		return Response{Text: buf.String(), ToolCall: call, Usage: usage, StopReason: ar.StopReason}, nil
 	}
 
 	return Response{}, fmt.Errorf("max retries exceeded: %w", lastErr)
@@ -297,8 +361,19 @@ type anthropicMessage struct {
 }
 
 type anthropicContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+	// tool_use blocks (response only)
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Input map[string]any `json:"input,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"` // "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
 type anthropicTool struct {
@@ -308,7 +383,12 @@ type anthropicTool struct {
 }
 
 type anthropicResponse struct {
-	Content []anthropicContent `json:"content"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 type anthropicError struct {
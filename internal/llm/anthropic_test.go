@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// fixtureTransport answers every request with a recorded response body and keeps the request payload
+type fixtureTransport struct {
+	body    []byte
+	payload anthropicPayload
+}
+
+func (f *fixtureTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	_ = json.NewDecoder(r.Body).Decode(&f.payload)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(f.body)),
+		Request:    r,
+	}, nil
+}
+
+func fixtureClient(t *testing.T, name string) (*anthropicClient, *fixtureTransport) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &fixtureTransport{body: body}
+	return &anthropicClient{apiKey: "test-key", model: "claude-sonnet-4-20250514", http: &http.Client{Transport: transport}, logger: zerolog.Nop()}, transport
+}
+
+func TestAnthropicResponseShapes(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		wantCall *ToolCall
+		wantText string
+		wantStop string
+		wantUse  Usage
+	}{
+		{
+			fixture:  "anthropic_tool_use.json",
+			wantCall: &ToolCall{ID: "toolu_01A09q90qw90lq917835lq9", Name: "click_by_index", Input: map[string]any{"index": float64(12)}}, // First tool_use only
+			wantText: "I'll open the login page first.\n{\"thinking\": \"The task needs an account\", \"evaluation_previous_goal\": \"Success - search loaded\", \"memory\": \"on the home page\", \"next_goal\": \"open the login page\"}",
+			wantStop: "tool_use",
+			wantUse:  Usage{PromptTokens: 2095, CompletionTokens: 93},
+		},
+		{
+			fixture:  "anthropic_text_json.json",
+			wantText: "Here is my decision:\n```json\n{\n  \"thinking\": \"The form is filled\",\n  \"evaluation_previous_goal\": \"Success\",\n  \"memory\": \"email entered\",\n  \"next_goal\": \"submit\",\n  \"action\": \"click_text\",\n  \"input\": {\"text\": \"Sign in\"}\n}\n```",
+			wantStop: "end_turn",
+			wantUse:  Usage{PromptTokens: 1800, CompletionTokens: 71},
+		},
+		{
+			fixture:  "anthropic_tool_use_no_input.json",
+			wantCall: &ToolCall{ID: "toolu_01C", Name: "go_back", Input: map[string]any{}},
+			wantStop: "tool_use",
+			wantUse:  Usage{PromptTokens: 1500, CompletionTokens: 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			client, _ := fixtureClient(t, tt.fixture)
+			resp, err := client.Generate(context.Background(), Request{Messages: []Message{{Role: "user", Content: "next step"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != tt.wantText || resp.StopReason != tt.wantStop || resp.Usage != tt.wantUse {
+				t.Errorf("response = %+v", resp)
+			}
+			if (resp.ToolCall == nil) != (tt.wantCall == nil) {
+				t.Fatalf("tool call = %+v, want %+v", resp.ToolCall, tt.wantCall)
+			}
+			if tt.wantCall != nil {
+				got, _ := json.Marshal(resp.ToolCall)
+				want, _ := json.Marshal(tt.wantCall)
+				if !bytes.Equal(got, want) {
+					t.Errorf("tool call = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnthropicSendsTools(t *testing.T) {
+	client, transport := fixtureClient(t, "anthropic_tool_use.json")
+	tools := []Tool{{Name: "click_by_index", Description: "Click an element", InputSchema: map[string]any{"type": "object", "properties": map[string]any{"index": map[string]any{"type": "integer"}}}}}
+	if _, err := client.Generate(context.Background(), Request{System: "sys", Messages: []Message{{Role: "user", Content: "go"}}, Tools: tools}); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.payload.Tools) != 1 || transport.payload.System != "sys" {
+		t.Fatalf("payload = %+v, want the tool and the system prompt", transport.payload)
+	}
+}
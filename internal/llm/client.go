@@ -9,40 +9,105 @@ import (
 )
 
 const (
-	envProvider = "LLM_PROVIDER" // "anthropic" or "openai"
+	envProvider = "LLM_PROVIDER" // "anthropic", "openai", "ollama" or "gemini"
 )
 
-// NewClientFromEnv creates a client based on LLM_PROVIDER env var
-// Defaults to Anthropic if not specified
-func NewClientFromEnv() (Client, error) {
+// ProviderFromEnv returns the provider selected by LLM_PROVIDER env var (anthropic by default)
+func ProviderFromEnv() string {
 	provider := strings.ToLower(strings.TrimSpace(os.Getenv(envProvider)))
 	if provider == "" {
 		provider = "anthropic" // Default
 	}
+	return provider
+}
+
+// NewClientFromEnv creates a client based on LLM_PROVIDER env var
+// Defaults to Anthropic if not specified
+func NewClientFromEnv() (Client, error) {
+	provider := ProviderFromEnv()
 
 	switch provider {
 	case "openai":
 		return NewOpenAIFromEnv()
 	case "anthropic":
 		return NewAnthropicFromEnv()
+	case "ollama":
+		return NewOllamaFromEnv()
+	case "gemini":
+		return NewGeminiFromEnv()
 	default:
-		return nil, fmt.Errorf("unknown LLM provider: %s (use 'anthropic' or 'openai')", provider)
+		return nil, fmt.Errorf("unknown LLM provider: %s (use 'anthropic', 'openai', 'ollama' or 'gemini')", provider)
 	}
 }
 
 // NewClientWithLogger creates a client with logger based on LLM_PROVIDER env var
 func NewClientWithLogger(logger zerolog.Logger) (Client, error) {
-	provider := strings.ToLower(strings.TrimSpace(os.Getenv(envProvider)))
-	if provider == "" {
-		provider = "anthropic" // Default
+	return NewClientWithOptions(logger, ClientOptions{})
+}
+
+// ClientOptions override the env configuration; empty fields keep env values
+type ClientOptions struct {
+	Provider string // Overrides LLM_PROVIDER
+	Model    string // Overrides the provider's *_MODEL variable
+}
+
+// ResolveProvider returns the override if set, otherwise the LLM_PROVIDER provider
+func ResolveProvider(override string) string {
+	if provider := strings.ToLower(strings.TrimSpace(override)); provider != "" {
+		return provider
 	}
+	return ProviderFromEnv()
+}
+
+// NewClientWithOptions creates a client for the resolved provider and model
+func NewClientWithOptions(logger zerolog.Logger, opts ClientOptions) (Client, error) {
+	provider := ResolveProvider(opts.Provider)
 
+	var (
+		client Client
+		err    error
+	)
 	switch provider {
 	case "openai":
-		return NewOpenAIWithLogger(logger)
+		client, err = NewOpenAIWithLogger(logger)
 	case "anthropic":
-		return NewAnthropicWithLogger(logger)
+		client, err = NewAnthropicWithLogger(logger)
+	case "ollama":
+		client, err = NewOllamaWithLogger(logger)
+	case "gemini":
+		client, err = NewGeminiWithLogger(logger)
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s (use 'anthropic', 'openai', 'ollama' or 'gemini')", provider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+	if model := strings.Trim(strings.TrimSpace(opts.Model), "\"'"); model != "" {
+		if err := setModel(client, model); err != nil {
+			return nil, fmt.Errorf("%s: %w", provider, err)
+		}
+	}
+	return client, nil
+}
+
+// setModel replaces the env-selected model of a freshly created client
+func setModel(client Client, model string) error {
+	switch c := client.(type) {
+	case *anthropicClient:
+		c.model = model
+	case *openAIClient:
+		// Azure deployment defaults to the model name - rebuild the endpoint
+		endpoint, azure, err := openAIEndpoint(model)
+		if err != nil {
+			return err
+		}
+		c.model, c.endpoint, c.azure = model, endpoint, azure
+	case *ollamaClient:
+		c.model = model
+	case *geminiClient:
+		c.model = model
 	default:
-		return nil, fmt.Errorf("unknown LLM provider: %s (use 'anthropic' or 'openai')", provider)
+		return fmt.Errorf("model override is not supported for %T", client)
 	}
+	return nil
 }
@@ -0,0 +1,353 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	envGeminiAPIKey    = "GEMINI_API_KEY"
+	envGeminiModel     = "GEMINI_MODEL"
+	defaultGeminiModel = "gemini-1.5-flash"
+
+	geminiAPIBase     = "https://generativelanguage.googleapis.com/v1beta/models/"
+	geminiMaxTokens   = 900
+	geminiTimeoutSecs = 60
+
+	geminiMaxRetries     = 3
+	geminiRetryBaseDelay = 500 * time.Millisecond
+	geminiMaxRequestSize = 200000 // ~200KB
+)
+
+type geminiClient struct {
+	apiKey string
+	model  string
+	http   *http.Client
+	logger zerolog.Logger
+}
+
+type geminiPayload struct {
+	SystemInstruction *geminiContent         `json:"system_instruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"` // "user" or "model"
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text         string              `json:"text,omitempty"`
+	InlineData   *geminiInlineData   `json:"inline_data,omitempty"`
+	FunctionCall *geminiFunctionCall `json:"functionCall,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"` // base64
+}
+
+type geminiFunctionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"function_declarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+func NewGeminiFromEnv() (Client, error) {
+	key := strings.TrimSpace(os.Getenv(envGeminiAPIKey))
+	if key == "" {
+		return nil, fmt.Errorf("missing %s - export it or add it to .env (keys: https://aistudio.google.com/app/apikey)", envGeminiAPIKey)
+	}
+	model := strings.TrimSpace(os.Getenv(envGeminiModel))
+	if model == "" {
+		model = defaultGeminiModel
+	}
+	model = strings.Trim(model, "\"'")
+	httpClient, err := newHTTPClient(geminiTimeoutSecs * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &geminiClient{
+		apiKey: key,
+		model:  model,
+		http:   httpClient,
+		logger: zerolog.Nop(),
+	}, nil
+}
+
+func NewGeminiWithLogger(logger zerolog.Logger) (Client, error) {
+	client, err := NewGeminiFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if gc, ok := client.(*geminiClient); ok {
+		gc.logger = logger
+	}
+	return client, nil
+}
+
+func (c *geminiClient) Name() string {
+	return c.model
+}
+
+func (c *geminiClient) ModelInfo() ModelInfo {
+	return LookupModelInfo(c.model)
+}
+
+// Generate calls generateContent. Structured outputs (ForceJSONSchema) are not requested:
+// Gemini rejects JSON response mime type together with function calling.
+func (c *geminiClient) Generate(ctx context.Context, req Request) (Response, error) {
+	return generateChecked(ctx, req, geminiMaxTokens, c.ModelInfo(), c.logger, c.generate)
+}
+
+func (c *geminiClient) generate(ctx context.Context, req Request) (Response, error) {
+	// Validate input
+	if len(req.Messages) == 0 {
+		return Response{}, errors.New("no messages")
+	}
+
+	// Validate and sanitize message content
+	sanitizeRequest(&req, c.logger)
+	for i, m := range req.Messages {
+		if len(m.Content) > geminiMaxRequestSize {
+			c.logger.Warn().Int("message_idx", i).Int("size", len(m.Content)).Msg("message too large, truncating")
+			req.Messages[i].Content = cutBytes(m.Content, geminiMaxRequestSize) + "... [truncated]"
+		}
+	}
+
+	// Validate system prompt size
+	if len(req.System) > geminiMaxRequestSize {
+		c.logger.Warn().Int("size", len(req.System)).Msg("system prompt too large, truncating")
+		req.System = cutBytes(req.System, geminiMaxRequestSize) + "... [truncated]"
+	}
+
+	payload := geminiPayload{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     float64(req.Temperature),
+			MaxOutputTokens: max(req.MaxTokens, geminiMaxTokens),
+		},
+	}
+	if req.System != "" {
+		payload.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: req.System}}}
+	}
+	for _, m := range req.Messages {
+		role := m.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		parts := make([]geminiPart, 0, len(m.Images)+1)
+		for _, img := range m.Images {
+			parts = append(parts, geminiPart{InlineData: &geminiInlineData{
+				MimeType: img.MediaType,
+				Data:     base64.StdEncoding.EncodeToString(img.Data),
+			}})
+		}
+		parts = append(parts, geminiPart{Text: m.Content})
+		payload.Contents = append(payload.Contents, geminiContent{Role: role, Parts: parts})
+	}
+	if len(req.Tools) > 0 {
+		decls := make([]geminiFunctionDeclaration, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			decls = append(decls, geminiFunctionDeclaration{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  geminiParameters(t.InputSchema),
+			})
+		}
+		payload.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal payload: %w", err)
+	}
+	endpoint := geminiAPIBase + url.PathEscape(c.model) + ":generateContent"
+
+	var lastErr error
+	for attempt := 0; attempt <= geminiMaxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff
+			delay := geminiRetryBaseDelay * time.Duration(1<<uint(attempt-1))
+			c.logger.Info().
+				Int("attempt", attempt).
+				Dur("delay", delay).
+				Msg("retrying Gemini API call")
+			select {
+			case <-ctx.Done():
+				return Response{}, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		c.logger.Debug().
+			Str("model", c.model).
+			Int("contents", len(payload.Contents)).
+			Int("tools", len(req.Tools)).
+			Int("payload_size", len(body)).
+			Msg("Gemini API request")
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return Response{}, fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("x-goog-api-key", c.apiKey)
+
+		resp, err := c.http.Do(httpReq)
+		if err != nil {
+			lastErr = fmt.Errorf("http request: %w", err)
+			if attempt < geminiMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read response: %w", err)
+			if attempt < geminiMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		c.logger.Debug().
+			Int("status", resp.StatusCode).
+			Int("response_size", len(data)).
+			Msg("Gemini API response")
+
+		if resp.StatusCode >= 400 {
+			var apiResp geminiResponse
+			errorMsg := string(data)
+			status := ""
+			if err := json.Unmarshal(data, &apiResp); err == nil && apiResp.Error != nil {
+				errorMsg = apiResp.Error.Message
+				status = apiResp.Error.Status
+			}
+			errorMsg = truncateString(errorMsg, 500)
+			lastErr = fmt.Errorf("gemini %d: %s (status: %s)", resp.StatusCode, errorMsg, status)
+			c.logger.Error().
+				Int("status", resp.StatusCode).
+				Str("error_status", status).
+				Str("error_msg", errorMsg).
+				Int("attempt", attempt).
+				Msg("Gemini API error")
+			// Retry on 429 (rate limit) and 5xx errors
+			if (resp.StatusCode == 429 || resp.StatusCode >= 500) && attempt < geminiMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		var apiResp geminiResponse
+		if err := json.Unmarshal(data, &apiResp); err != nil {
+			return Response{}, fmt.Errorf("parse response: %w (raw: %s)", err, truncateString(string(data), 500))
+		}
+		if len(apiResp.Candidates) == 0 {
+			return Response{}, fmt.Errorf("no candidates in response")
+		}
+		candidate := apiResp.Candidates[0]
+		usage := Usage{PromptTokens: apiResp.UsageMetadata.PromptTokenCount, CompletionTokens: apiResp.UsageMetadata.CandidatesTokenCount}
+
+		var text strings.Builder
+		for _, part := range candidate.Content.Parts {
+			if part.FunctionCall != nil {
+				// Return function call as JSON in format: {"action": "tool_name", "input": {...}}
+				c.logger.Debug().
+					Str("tool_name", part.FunctionCall.Name).
+					Msg("Gemini function call")
+				args := part.FunctionCall.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				jsonBytes, err := json.Marshal(map[string]any{
+					"action": part.FunctionCall.Name,
+					"input":  args,
+				})
+				if err != nil {
+					return Response{}, fmt.Errorf("marshal function call: %w", err)
+				}
+				return Response{Text: string(jsonBytes), Usage: usage, StopReason: candidate.FinishReason}, nil
+			}
+			text.WriteString(part.Text)
+		}
+		// Empty content (e.g. finish reason SAFETY) is retried by generateChecked
+		c.logger.Debug().
+			Str("finish_reason", candidate.FinishReason).
+			Int("prompt_tokens", apiResp.UsageMetadata.PromptTokenCount).
+			Int("completion_tokens", apiResp.UsageMetadata.CandidatesTokenCount).
+			Str("response_preview", truncateString(text.String(), 200)).
+			Msg("Gemini API success")
+
+		return Response{Text: text.String(), Usage: usage, StopReason: candidate.FinishReason}, nil
+	}
+
+	return Response{}, fmt.Errorf("max retries exceeded: %w", lastErr)
+}
+
+// geminiParameters converts a tool input schema to Gemini's OpenAPI subset:
+// objects without properties must omit parameters entirely
+func geminiParameters(schema map[string]any) map[string]any {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var params map[string]any
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil
+	}
+	props, _ := params["properties"].(map[string]any)
+	if len(props) == 0 {
+		return nil
+	}
+	if req, ok := params["required"].([]any); ok && len(req) == 0 {
+		delete(params, "required")
+	}
+	return params
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	envContextTokens   = "LLM_CONTEXT_TOKENS"    // Context window override for models missing from modelInfos
+	envMaxOutputTokens = "LLM_MAX_OUTPUT_TOKENS" // Output limit override
+)
+
+// ModelInfo is the capacity of a model in tokens
+type ModelInfo struct {
+	ContextTokens   int
+	MaxOutputTokens int
+	// Fallback is set when the model is unknown and no env override was given
+	Fallback bool
+}
+
+// defaultModelInfo is the conservative fallback for unknown models (small local models)
+var defaultModelInfo = ModelInfo{ContextTokens: 8192, MaxOutputTokens: 2048, Fallback: true}
+
+type modelCapacity struct {
+	prefix string
+	info   ModelInfo
+}
+
+// modelInfos is matched by prefix in order - more specific names go first
+var modelInfos = []modelCapacity{
+	{"claude-opus-4", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 32000}},
+	{"claude-sonnet-4", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}},
+	{"claude-haiku-4", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}},
+	{"claude-3-7-sonnet", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}},
+	{"claude-3-5", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 8192}},
+	{"claude-3", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 4096}},
+	{"gpt-4o", ModelInfo{ContextTokens: 128000, MaxOutputTokens: 16384}},
+	{"gpt-4.1", ModelInfo{ContextTokens: 1047576, MaxOutputTokens: 32768}},
+	{"gpt-4-turbo", ModelInfo{ContextTokens: 128000, MaxOutputTokens: 4096}},
+	{"gpt-3.5-turbo", ModelInfo{ContextTokens: 16385, MaxOutputTokens: 4096}},
+	{"gemini-2.0-flash", ModelInfo{ContextTokens: 1048576, MaxOutputTokens: 8192}},
+	{"gemini-1.5-flash", ModelInfo{ContextTokens: 1048576, MaxOutputTokens: 8192}},
+	{"gemini-1.5-pro", ModelInfo{ContextTokens: 2097152, MaxOutputTokens: 8192}},
+	{"llama3.1", ModelInfo{ContextTokens: 8192, MaxOutputTokens: 2048}}, // Ollama default num_ctx, not the model maximum
+}
+
+// LookupModelInfo returns the capacity of model (as returned by Client.Name).
+// LLM_CONTEXT_TOKENS / LLM_MAX_OUTPUT_TOKENS override the table.
+func LookupModelInfo(model string) ModelInfo {
+	info := defaultModelInfo
+	name := normalizeModelName(model)
+	for _, m := range modelInfos {
+		if strings.HasPrefix(name, m.prefix) {
+			info = m.info
+			break
+		}
+	}
+	if n := envTokens(envContextTokens); n > 0 {
+		info.ContextTokens = n
+		info.Fallback = false
+	}
+	if n := envTokens(envMaxOutputTokens); n > 0 {
+		info.MaxOutputTokens = n
+	}
+	return info
+}
+
+func envTokens(key string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// normalizeModelName strips provider decorations from a client name:
+// "azure:gpt-4o@host", "gpt-4o@openrouter.ai", "openai/gpt-4o"
+func normalizeModelName(model string) string {
+	model = strings.TrimPrefix(strings.ToLower(model), "azure:")
+	if i := strings.Index(model, "@"); i >= 0 {
+		model = model[:i]
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	return model
+}
@@ -0,0 +1,51 @@
+package llm
+
+import "testing"
+
+func TestLookupModelInfo(t *testing.T) {
+	tests := []struct {
+		model string
+		want  ModelInfo
+	}{
+		{"claude-sonnet-4-20250514", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 64000}},
+		{"claude-3-5-haiku-latest", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 8192}},
+		{"claude-3-haiku-20240307", ModelInfo{ContextTokens: 200000, MaxOutputTokens: 4096}},
+		{"gpt-4o-mini", ModelInfo{ContextTokens: 128000, MaxOutputTokens: 16384}},
+		{"azure:GPT-4o@mycompany.openai.azure.com", ModelInfo{ContextTokens: 128000, MaxOutputTokens: 16384}},
+		{"openai/gpt-4.1@openrouter.ai", ModelInfo{ContextTokens: 1047576, MaxOutputTokens: 32768}},
+		{"llama3.1:8b", ModelInfo{ContextTokens: 8192, MaxOutputTokens: 2048}},
+		{"qwen2.5:7b", defaultModelInfo},
+		{"", defaultModelInfo},
+	}
+	for _, tt := range tests {
+		if got := LookupModelInfo(tt.model); got != tt.want {
+			t.Errorf("LookupModelInfo(%q) = %+v, want %+v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestLookupModelInfoEnvOverride(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		context, limit string
+		want           ModelInfo
+	}{
+		{name: "unknown model gets a window", model: "qwen2.5:7b", context: "32768", want: ModelInfo{ContextTokens: 32768, MaxOutputTokens: 2048}},
+		{name: "known model overridden", model: "gpt-4o", context: "64000", limit: "4096", want: ModelInfo{ContextTokens: 64000, MaxOutputTokens: 4096}},
+		{name: "output only keeps the fallback flag", model: "qwen2.5:7b", limit: "1024", want: ModelInfo{ContextTokens: 8192, MaxOutputTokens: 1024, Fallback: true}},
+		{name: "spaces are trimmed", model: "qwen2.5:7b", context: " 16384 ", want: ModelInfo{ContextTokens: 16384, MaxOutputTokens: 2048}},
+		{name: "zero is ignored", model: "gpt-4o", context: "0", limit: "0", want: ModelInfo{ContextTokens: 128000, MaxOutputTokens: 16384}},
+		{name: "negative is ignored", model: "qwen2.5:7b", context: "-1", want: defaultModelInfo},
+		{name: "garbage is ignored", model: "qwen2.5:7b", context: "32k", limit: "lots", want: defaultModelInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envContextTokens, tt.context)
+			t.Setenv(envMaxOutputTokens, tt.limit)
+			if got := LookupModelInfo(tt.model); got != tt.want {
+				t.Fatalf("LookupModelInfo(%q) = %+v, want %+v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	envOllamaBaseURL     = "OLLAMA_BASE_URL"
+	envOllamaModel       = "OLLAMA_MODEL"
+	defaultOllamaBaseURL = "http://localhost:11434"
+	defaultOllamaModel   = "llama3.1"
+
+	ollamaChatPath    = "/api/chat"
+	ollamaMaxTokens   = 900
+	ollamaTimeoutSecs = 300 // Local models on CPU are slow
+
+	ollamaMaxRetries     = 3
+	ollamaRetryBaseDelay = 500 * time.Millisecond
+	ollamaMaxRequestSize = 200000 // ~200KB
+)
+
+// ollamaClient talks to a local Ollama server.
+// Tools are not sent: most local models don't support tool calling, so the planner's
+// JSON-in-text protocol (tools are described in the prompt) is always used.
+type ollamaClient struct {
+	baseURL string
+	model   string
+	http    *http.Client
+	logger  zerolog.Logger
+}
+
+type ollamaPayload struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   any             `json:"format,omitempty"` // "json" or JSON schema
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // base64, for multimodal models (llava, qwen-vl)
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict"`
+}
+
+type ollamaResponse struct {
+	Model   string        `json:"model"`
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	// DoneReason is "stop", or "length" when num_predict was reached
+	DoneReason string `json:"done_reason"`
+	Error      string `json:"error,omitempty"`
+	// Token counts (absent when the prompt was served from cache)
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func NewOllamaFromEnv() (Client, error) {
+	baseURL := strings.TrimSpace(os.Getenv(envOllamaBaseURL))
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	baseURL = strings.TrimRight(strings.Trim(baseURL, "\"'"), "/")
+	model := strings.TrimSpace(os.Getenv(envOllamaModel))
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	model = strings.Trim(model, "\"'")
+	httpClient, err := newHTTPClient(ollamaTimeoutSecs * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaClient{
+		baseURL: baseURL,
+		model:   model,
+		http:    httpClient,
+		logger:  zerolog.Nop(),
+	}, nil
+}
+
+func NewOllamaWithLogger(logger zerolog.Logger) (Client, error) {
+	client, err := NewOllamaFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if oc, ok := client.(*ollamaClient); ok {
+		oc.logger = logger
+	}
+	return client, nil
+}
+
+func (c *ollamaClient) Name() string {
+	return c.model
+}
+
+func (c *ollamaClient) ModelInfo() ModelInfo {
+	return LookupModelInfo(c.model)
+}
+
+func (c *ollamaClient) Generate(ctx context.Context, req Request) (Response, error) {
+	return generateChecked(ctx, req, ollamaMaxTokens, c.ModelInfo(), c.logger, c.generate)
+}
+
+func (c *ollamaClient) generate(ctx context.Context, req Request) (Response, error) {
+	// Validate input
+	if len(req.Messages) == 0 {
+		return Response{}, errors.New("no messages")
+	}
+
+	// Validate and sanitize message content
+	sanitizeRequest(&req, c.logger)
+	for i, m := range req.Messages {
+		if len(m.Content) > ollamaMaxRequestSize {
+			c.logger.Warn().Int("message_idx", i).Int("size", len(m.Content)).Msg("message too large, truncating")
+			req.Messages[i].Content = cutBytes(m.Content, ollamaMaxRequestSize) + "... [truncated]"
+		}
+	}
+
+	// Validate system prompt size
+	if len(req.System) > ollamaMaxRequestSize {
+		c.logger.Warn().Int("size", len(req.System)).Msg("system prompt too large, truncating")
+		req.System = cutBytes(req.System, ollamaMaxRequestSize) + "... [truncated]"
+	}
+
+	// No native tool calling - describe tools in the system prompt instead
+	system := req.System
+	if len(req.Tools) > 0 {
+		system += toolsPrompt(req.Tools)
+	}
+
+	messages := make([]ollamaMessage, 0, len(req.Messages)+1)
+	if system != "" {
+		messages = append(messages, ollamaMessage{Role: "system", Content: system})
+	}
+	for _, m := range req.Messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, img := range m.Images {
+			msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(img.Data))
+		}
+		messages = append(messages, msg)
+	}
+
+	payload := ollamaPayload{
+		Model:    c.model,
+		Messages: messages,
+		Stream:   false,
+		Options: ollamaOptions{
+			Temperature: float64(req.Temperature),
+			NumPredict:  max(req.MaxTokens, ollamaMaxTokens),
+		},
+	}
+	if req.ForceJSONSchema && req.Schema != nil {
+		payload.Format = req.Schema
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal payload: %w", err)
+	}
+	url := c.baseURL + ollamaChatPath
+
+	var lastErr error
+	for attempt := 0; attempt <= ollamaMaxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff
+			delay := ollamaRetryBaseDelay * time.Duration(1<<uint(attempt-1))
+			c.logger.Info().
+				Int("attempt", attempt).
+				Dur("delay", delay).
+				Msg("retrying Ollama API call")
+			select {
+			case <-ctx.Done():
+				return Response{}, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		c.logger.Debug().
+			Str("model", c.model).
+			Str("url", url).
+			Int("messages", len(messages)).
+			Int("payload_size", len(body)).
+			Msg("Ollama API request")
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return Response{}, fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.http.Do(httpReq)
+		if err != nil {
+			lastErr = fmt.Errorf("http request: %w", err)
+			if attempt < ollamaMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read response: %w", err)
+			if attempt < ollamaMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		if resp.StatusCode >= 400 {
+			var apiResp ollamaResponse
+			errorMsg := string(data)
+			if err := json.Unmarshal(data, &apiResp); err == nil && apiResp.Error != "" {
+				errorMsg = apiResp.Error
+			}
+			errorMsg = truncateString(errorMsg, 500)
+			lastErr = fmt.Errorf("ollama %d: %s", resp.StatusCode, errorMsg)
+			c.logger.Error().
+				Int("status", resp.StatusCode).
+				Str("error_msg", errorMsg).
+				Int("attempt", attempt).
+				Msg("Ollama API error")
+			// Retry on 429 and 5xx (model loading, OOM), not on 4xx like unknown model
+			if (resp.StatusCode == 429 || resp.StatusCode >= 500) && attempt < ollamaMaxRetries {
+				continue
+			}
+			return Response{}, lastErr
+		}
+
+		var apiResp ollamaResponse
+		if err := json.Unmarshal(data, &apiResp); err != nil {
+			return Response{}, fmt.Errorf("parse response: %w (raw: %s)", err, truncateString(string(data), 500))
+		}
+		// Empty content is retried by generateChecked
+		text := apiResp.Message.Content
+
+		c.logger.Debug().
+			Str("done_reason", apiResp.DoneReason).
+			Str("response_preview", truncateString(text, 200)).
+			Msg("Ollama API success")
+
+		usage := Usage{PromptTokens: apiResp.PromptEvalCount, CompletionTokens: apiResp.EvalCount}
+		return Response{Text: text, Usage: usage, StopReason: apiResp.DoneReason}, nil
+	}
+
+	return Response{}, fmt.Errorf("max retries exceeded: %w", lastErr)
+}
+
+// toolsPrompt renders tool names, descriptions and input fields for models without tool calling
+func toolsPrompt(tools []Tool) string {
+	var b strings.Builder
+	b.WriteString("\n\nAVAILABLE TOOLS (put the tool name into \"action\" and its arguments into \"input\"):\n")
+	for _, t := range tools {
+		fmt.Fprintf(&b, "- %s: %s", t.Name, t.Description)
+		// Properties may be a named map type - decode via JSON instead of type assertions
+		var props map[string]struct {
+			Type string `json:"type"`
+		}
+		if raw, err := json.Marshal(t.InputSchema["properties"]); err == nil {
+			_ = json.Unmarshal(raw, &props)
+		}
+		if len(props) > 0 {
+			fields := make([]string, 0, len(props))
+			for name, prop := range props {
+				fields = append(fields, name+" ("+prop.Type+")")
+			}
+			sort.Strings(fields)
+			fmt.Fprintf(&b, " | input: %s", strings.Join(fields, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
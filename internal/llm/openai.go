@@ -3,11 +3,13 @@ package llm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -16,13 +18,19 @@ import (
 )
 
 const (
-	envOpenAIAPIKey    = "OPENAI_API_KEY"
-	envOpenAIModel     = "OPENAI_MODEL"
-	defaultOpenAIModel = "gpt-4o-mini"
-
-	openAIAPIURL      = "https://api.openai.com/v1/chat/completions"
-	openAIMaxTokens   = 900
-	openAITimeoutSecs = 60
+	envOpenAIAPIKey     = "OPENAI_API_KEY"
+	envOpenAIModel      = "OPENAI_MODEL"
+	envOpenAIBaseURL    = "OPENAI_BASE_URL"    // OpenAI-compatible gateway (OpenRouter, Together, proxy)
+	envOpenAIAPIType    = "OPENAI_API_TYPE"    // "openai" (default) or "azure"
+	envOpenAIAPIVersion = "OPENAI_API_VERSION" // Azure api-version
+	envOpenAIDeployment = "OPENAI_DEPLOYMENT"  // Azure deployment name (defaults to OPENAI_MODEL)
+	defaultOpenAIModel  = "gpt-4o-mini"
+
+	defaultOpenAIBaseURL   = "https://api.openai.com/v1"
+	defaultAzureAPIVersion = "2024-06-01"
+	openAIChatPath         = "/chat/completions"
+	openAIMaxTokens        = 900
+	openAITimeoutSecs      = 60
 
 	openAIMaxRetries     = 3
 	openAIRetryBaseDelay = 500 * time.Millisecond
@@ -30,10 +38,12 @@ const (
 )
 
 type openAIClient struct {
-	apiKey string
-	model  string
-	http   *http.Client
-	logger zerolog.Logger
+	apiKey   string
+	model    string
+	endpoint string // Full chat completions URL
+	azure    bool   // Azure mode: api-key header, deployment in path
+	http     *http.Client
+	logger   zerolog.Logger
 }
 
 type openAIPayload struct {
@@ -43,11 +53,35 @@ type openAIPayload struct {
 	ToolChoice  string          `json:"tool_choice,omitempty"`
 	Temperature float64         `json:"temperature"`
 	MaxTokens   int             `json:"max_tokens"`
+	// Structured outputs: {"type": "json_schema", "json_schema": {...}}
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	// Strict mode is off: free-form objects (like tool input) are not allowed in strict schemas
+	Strict bool `json:"strict"`
 }
 
 type openAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"` // string, or []openAIPart for multi-part (vision) messages
+}
+
+type openAIPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"` // data:<media type>;base64,<data>
 }
 
 type openAITool struct {
@@ -97,23 +131,63 @@ type openAIResponse struct {
 func NewOpenAIFromEnv() (Client, error) {
 	key := strings.TrimSpace(os.Getenv(envOpenAIAPIKey))
 	if key == "" {
-		return nil, fmt.Errorf("missing %s", envOpenAIAPIKey)
+		return nil, fmt.Errorf("missing %s - export it or add it to .env (keys: https://platform.openai.com/api-keys)", envOpenAIAPIKey)
 	}
 	model := strings.TrimSpace(os.Getenv(envOpenAIModel))
 	if model == "" {
 		model = defaultOpenAIModel
 	}
 	model = strings.Trim(model, "\"'")
+	endpoint, azure, err := openAIEndpoint(model)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := newHTTPClient(openAITimeoutSecs * time.Second)
+	if err != nil {
+		return nil, err
+	}
 	return &openAIClient{
-		apiKey: key,
-		model:  model,
-		http: &http.Client{
-			Timeout: openAITimeoutSecs * time.Second,
-		},
-		logger: zerolog.Nop(),
+		apiKey:   key,
+		model:    model,
+		endpoint: endpoint,
+		azure:    azure,
+		http:     httpClient,
+		logger:   zerolog.Nop(),
 	}, nil
 }
 
+// openAIEndpoint builds the chat completions URL from env:
+// standard mode - OPENAI_BASE_URL + /chat/completions;
+// Azure mode - OPENAI_BASE_URL + /openai/deployments/<deployment>/chat/completions?api-version=...
+func openAIEndpoint(model string) (string, bool, error) {
+	base := strings.TrimRight(strings.Trim(strings.TrimSpace(os.Getenv(envOpenAIBaseURL)), "\"'"), "/")
+	apiType := strings.ToLower(strings.TrimSpace(os.Getenv(envOpenAIAPIType)))
+	switch apiType {
+	case "", "openai":
+		if base == "" {
+			base = defaultOpenAIBaseURL
+		}
+		return base + openAIChatPath, false, nil
+	case "azure":
+		if base == "" {
+			return "", false, fmt.Errorf("%s=azure requires %s (https://<resource>.openai.azure.com)", envOpenAIAPIType, envOpenAIBaseURL)
+		}
+		deployment := strings.TrimSpace(os.Getenv(envOpenAIDeployment))
+		if deployment == "" {
+			deployment = model
+		}
+		version := strings.TrimSpace(os.Getenv(envOpenAIAPIVersion))
+		if version == "" {
+			version = defaultAzureAPIVersion
+		}
+		endpoint := base + "/openai/deployments/" + url.PathEscape(deployment) + openAIChatPath +
+			"?api-version=" + url.QueryEscape(version)
+		return endpoint, true, nil
+	default:
+		return "", false, fmt.Errorf("unknown %s: %s (use 'openai' or 'azure')", envOpenAIAPIType, apiType)
+	}
+}
+
 func NewOpenAIWithLogger(logger zerolog.Logger) (Client, error) {
 	client, err := NewOpenAIFromEnv()
 	if err != nil {
@@ -125,28 +199,45 @@ func NewOpenAIWithLogger(logger zerolog.Logger) (Client, error) {
 	return client, nil
 }
 
+func (c *openAIClient) ModelInfo() ModelInfo {
+	return LookupModelInfo(c.model)
+}
+
+// Name is the model for api.openai.com, otherwise model@host so logs show which gateway was used
 func (c *openAIClient) Name() string {
-	return c.model
+	u, err := url.Parse(c.endpoint)
+	if err != nil || u.Host == "api.openai.com" {
+		return c.model
+	}
+	if c.azure {
+		return "azure:" + c.model + "@" + u.Host
+	}
+	return c.model + "@" + u.Host
 }
 
 func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, error) {
+	return generateChecked(ctx, req, openAIMaxTokens, c.ModelInfo(), c.logger, c.generate)
+}
+
+func (c *openAIClient) generate(ctx context.Context, req Request) (Response, error) {
 	// Validate input
 	if len(req.Messages) == 0 {
 		return Response{}, errors.New("no messages")
 	}
 
 	// Validate and sanitize message content
+	sanitizeRequest(&req, c.logger)
 	for i, m := range req.Messages {
 		if len(m.Content) > openAIMaxRequestSize {
 			c.logger.Warn().Int("message_idx", i).Int("size", len(m.Content)).Msg("message too large, truncating")
-			req.Messages[i].Content = m.Content[:openAIMaxRequestSize] + "... [truncated]"
+			req.Messages[i].Content = cutBytes(m.Content, openAIMaxRequestSize) + "... [truncated]"
 		}
 	}
 
 	// Validate system prompt size
 	if len(req.System) > openAIMaxRequestSize {
 		c.logger.Warn().Int("size", len(req.System)).Msg("system prompt too large, truncating")
-		req.System = req.System[:openAIMaxRequestSize] + "... [truncated]"
+		req.System = cutBytes(req.System, openAIMaxRequestSize) + "... [truncated]"
 	}
 
 	var lastErr error
@@ -176,7 +267,7 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 		for _, m := range req.Messages {
 			messages = append(messages, openAIMessage{
 				Role:    m.Role,
-				Content: m.Content,
+				Content: openAIContent(m),
 			})
 		}
 
@@ -203,6 +294,16 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 			payload.Tools = tools
 			payload.ToolChoice = "auto" // Let model decide when to use tools
 		}
+		if req.ForceJSONSchema && req.Schema != nil {
+			name := req.SchemaName
+			if name == "" {
+				name = "response"
+			}
+			payload.ResponseFormat = &openAIResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &openAIJSONSchema{Name: name, Schema: req.Schema},
+			}
+		}
 
 		body, err := json.Marshal(payload)
 		if err != nil {
@@ -218,12 +319,16 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 			Int("max_tokens", payload.MaxTokens).
 			Msg("OpenAI API request")
 
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, openAIAPIURL, bytes.NewReader(body))
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
 		if err != nil {
 			return Response{}, fmt.Errorf("create request: %w", err)
 		}
 		httpReq.Header.Set("Content-Type", "application/json")
-		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		if c.azure {
+			httpReq.Header.Set("api-key", c.apiKey)
+		} else {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
 
 		resp, err := c.http.Do(httpReq)
 		if err != nil {
@@ -256,17 +361,13 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 			if err := json.Unmarshal(data, &apiResp); err != nil || apiResp.Error == nil {
 				// If we can't parse error, return raw response
 				errorMsg := rawError
-				if len(errorMsg) > 500 {
-					errorMsg = errorMsg[:500] + "..."
-				}
+				errorMsg = truncateString(errorMsg, 500)
 				lastErr = fmt.Errorf("openai %d: %s (raw, parse err: %v)", resp.StatusCode, errorMsg, err)
 			} else {
 				errorMsg := apiResp.Error.Message
 				if errorMsg == "" {
 					errorMsg = rawError
-					if len(errorMsg) > 500 {
-						errorMsg = errorMsg[:500] + "..."
-					}
+					errorMsg = truncateString(errorMsg, 500)
 				}
 				lastErr = fmt.Errorf("openai %d: %s (type: %s, code: %s)", resp.StatusCode, errorMsg, apiResp.Error.Type, apiResp.Error.Code)
 			}
@@ -297,6 +398,7 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 		}
 
 		choice := apiResp.Choices[0]
+		usage := Usage{PromptTokens: apiResp.Usage.PromptTokens, CompletionTokens: apiResp.Usage.CompletionTokens}
 
 		// Handle tool calls - OpenAI returns tool calls in message, we need to extract them
 		if len(choice.Message.ToolCalls) > 0 {
@@ -323,14 +425,11 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 			if err != nil {
 				return Response{}, fmt.Errorf("marshal tool call: %w", err)
 			}
-			return Response{Text: string(jsonBytes)}, nil
+			return Response{Text: string(jsonBytes), Usage: usage, StopReason: choice.FinishReason}, nil
 		}
 
-		// Regular text response
+		// Regular text response (empty content is retried by generateChecked)
 		text := choice.Message.Content
-		if text == "" {
-			return Response{}, fmt.Errorf("empty response content")
-		}
 
 		c.logger.Debug().
 			Str("finish_reason", choice.FinishReason).
@@ -340,15 +439,38 @@ func (c *openAIClient) Generate(ctx context.Context, req Request) (Response, err
 			Str("response_preview", truncateString(text, 200)).
 			Msg("OpenAI API success")
 
-		return Response{Text: text}, nil
+		return Response{Text: text, Usage: usage, StopReason: choice.FinishReason}, nil
 	}
 
 	return Response{}, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
+// openAIContent keeps plain string content for text-only messages and switches to parts when images are attached
+func openAIContent(m Message) interface{} {
+	if len(m.Images) == 0 {
+		return m.Content
+	}
+	parts := make([]openAIPart, 0, len(m.Images)+1)
+	for _, img := range m.Images {
+		parts = append(parts, openAIPart{
+			Type: "image_url",
+			ImageURL: &openAIImageURL{
+				URL: "data:" + img.MediaType + ";base64," + base64.StdEncoding.EncodeToString(img.Data),
+			},
+		})
+	}
+	parts = append(parts, openAIPart{Type: "text", Text: m.Content})
+	return parts
+}
+
+// truncateString cuts s to maxLen runes, adding "..." only when something was cut
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+	count := 0
+	for i := range s {
+		if count == maxLen {
+			return s[:i] + "..."
+		}
+		count++
 	}
-	return s[:maxLen] + "..."
+	return s
 }
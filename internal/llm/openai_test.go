@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// capturedRequest is what the fake OpenAI endpoint received
+type capturedRequest struct {
+	Path          string
+	Query         url.Values
+	Authorization string
+	APIKey        string
+	Model         string
+	Format        *openAIResponseFormat
+}
+
+func fakeOpenAI(t *testing.T) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	got := &capturedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model          string                `json:"model"`
+			ResponseFormat *openAIResponseFormat `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*got = capturedRequest{
+			Path:          r.URL.Path,
+			Query:         r.URL.Query(),
+			Authorization: r.Header.Get("Authorization"),
+			APIKey:        r.Header.Get("api-key"),
+			Model:         body.Model,
+			Format:        body.ResponseFormat,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestOpenAIRequestShape(t *testing.T) {
+	srv, got := fakeOpenAI(t)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantPath   string
+		wantQuery  string // api-version, "" = no query
+		wantBearer bool
+		wantModel  string
+		wantName   string
+	}{
+		{
+			name:       "standard gateway",
+			env:        map[string]string{envOpenAIBaseURL: srv.URL + "/v1/"},
+			wantPath:   "/v1/chat/completions",
+			wantBearer: true,
+			wantModel:  "gpt-4o-mini",
+			wantName:   "gpt-4o-mini@" + host,
+		},
+		{
+			name:       "explicit openai type",
+			env:        map[string]string{envOpenAIBaseURL: "'" + srv.URL + "/api'", envOpenAIAPIType: "OpenAI"},
+			wantPath:   "/api/chat/completions",
+			wantBearer: true,
+			wantModel:  "gpt-4o-mini",
+			wantName:   "gpt-4o-mini@" + host,
+		},
+		{
+			name:      "azure with deployment",
+			env:       map[string]string{envOpenAIBaseURL: srv.URL, envOpenAIAPIType: "azure", envOpenAIDeployment: "prod gpt", envOpenAIAPIVersion: "2024-10-21"},
+			wantPath:  "/openai/deployments/prod gpt/chat/completions",
+			wantQuery: "2024-10-21",
+			wantModel: "gpt-4o-mini",
+			wantName:  "azure:gpt-4o-mini@" + host,
+		},
+		{
+			name:      "azure deployment defaults to the model",
+			env:       map[string]string{envOpenAIBaseURL: srv.URL, envOpenAIAPIType: "azure"},
+			wantPath:  "/openai/deployments/gpt-4o-mini/chat/completions",
+			wantQuery: defaultAzureAPIVersion,
+			wantModel: "gpt-4o-mini",
+			wantName:  "azure:gpt-4o-mini@" + host,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{envOpenAIBaseURL, envOpenAIAPIType, envOpenAIDeployment, envOpenAIAPIVersion} {
+				t.Setenv(key, tt.env[key])
+			}
+			t.Setenv(envOpenAIAPIKey, "sk-test")
+			t.Setenv(envOpenAIModel, "gpt-4o-mini")
+
+			client, err := NewOpenAIFromEnv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", client.Name(), tt.wantName)
+			}
+			resp, err := client.Generate(context.Background(), Request{Messages: []Message{{Role: "user", Content: "ping"}}, MaxTokens: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "pong" {
+				t.Errorf("text = %q", resp.Text)
+			}
+
+			if got.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", got.Path, tt.wantPath)
+			}
+			if v := got.Query.Get("api-version"); v != tt.wantQuery {
+				t.Errorf("api-version = %q, want %q", v, tt.wantQuery)
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("body model = %q, want %q", got.Model, tt.wantModel)
+			}
+			if tt.wantBearer {
+				if got.Authorization != "Bearer sk-test" || got.APIKey != "" {
+					t.Errorf("auth = %q, api-key = %q: want only the Bearer header", got.Authorization, got.APIKey)
+				}
+			} else if got.APIKey != "sk-test" || got.Authorization != "" {
+				t.Errorf("auth = %q, api-key = %q: Azure wants only the api-key header", got.Authorization, got.APIKey)
+			}
+		})
+	}
+}
+
+func TestOpenAIEndpointErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiType string
+		base    string
+		want    string
+	}{
+		{name: "azure without base URL", apiType: "azure", want: envOpenAIBaseURL},
+		{name: "unknown type", apiType: "bedrock", base: "https://gw.example.com", want: "unknown " + envOpenAIAPIType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envOpenAIAPIType, tt.apiType)
+			t.Setenv(envOpenAIBaseURL, tt.base)
+			if _, _, err := openAIEndpoint("gpt-4o-mini"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIDefaultEndpointName(t *testing.T) {
+	t.Setenv(envOpenAIAPIKey, "sk-test")
+	t.Setenv(envOpenAIModel, "gpt-4o")
+	t.Setenv(envOpenAIBaseURL, "")
+	t.Setenv(envOpenAIAPIType, "")
+	client, err := NewOpenAIFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Name() != "gpt-4o" {
+		t.Fatalf("Name() = %q: api.openai.com needs no host suffix", client.Name())
+	}
+	if oc := client.(*openAIClient); oc.endpoint != defaultOpenAIBaseURL+openAIChatPath {
+		t.Fatalf("endpoint = %q", oc.endpoint)
+	}
+}
+
+func TestOpenAIStructuredOutputs(t *testing.T) {
+	srv, got := fakeOpenAI(t)
+	t.Setenv(envOpenAIBaseURL, srv.URL)
+	t.Setenv(envOpenAIAPIType, "")
+	t.Setenv(envOpenAIAPIKey, "sk-test")
+	t.Setenv(envOpenAIModel, "gpt-4o-mini")
+	client, err := NewOpenAIFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string]any{"type": "object", "required": []any{"action"}}
+	tests := []struct {
+		name     string
+		req      Request
+		wantName string // "" = no response_format
+	}{
+		{name: "schema mode off", req: Request{SchemaName: "decision", Schema: schema}},
+		{name: "schema mode on", req: Request{ForceJSONSchema: true, SchemaName: "decision", Schema: schema}, wantName: "decision"},
+		{name: "default schema name", req: Request{ForceJSONSchema: true, Schema: schema}, wantName: "response"},
+		{name: "no schema to force", req: Request{ForceJSONSchema: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Messages = []Message{{Role: "user", Content: "ping"}}
+			if _, err := client.Generate(context.Background(), tt.req); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantName == "" {
+				if got.Format != nil {
+					t.Fatalf("response_format = %+v, want none", got.Format)
+				}
+				return
+			}
+			if got.Format == nil || got.Format.Type != "json_schema" || got.Format.JSONSchema == nil {
+				t.Fatalf("response_format = %+v", got.Format)
+			}
+			if js := got.Format.JSONSchema; js.Name != tt.wantName || js.Schema["type"] != "object" || js.Strict {
+				t.Fatalf("json_schema = %+v", js)
+			}
+		})
+	}
+}
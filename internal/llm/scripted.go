@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ScriptRule answers requests accepted by Match with Response; nil Match is a catch-all
+type ScriptRule struct {
+	Match    func(req Request) bool
+	Response string
+}
+
+// ScriptedClient is a deterministic Client returning canned responses (decision JSON)
+// without network calls, for tests and offline runs of the orchestrator loop.
+// Every request is recorded for assertions.
+type ScriptedClient struct {
+	mu        sync.Mutex
+	responses []string     // Returned in order (NewScriptedClient)
+	rules     []ScriptRule // First match wins, rules are reusable (NewScriptedClientWithRules)
+	next      int
+	requests  []Request
+}
+
+// NewScriptedClient returns responses in order; a request past the end is an error
+func NewScriptedClient(responses []string) *ScriptedClient {
+	return &ScriptedClient{responses: append([]string(nil), responses...)}
+}
+
+// NewScriptedClientWithRules answers each request with the first matching rule
+func NewScriptedClientWithRules(rules []ScriptRule) *ScriptedClient {
+	return &ScriptedClient{rules: append([]ScriptRule(nil), rules...)}
+}
+
+// MatchContent matches requests whose last message contains substr
+func MatchContent(substr string) func(Request) bool {
+	return func(req Request) bool {
+		if len(req.Messages) == 0 {
+			return false
+		}
+		return strings.Contains(req.Messages[len(req.Messages)-1].Content, substr)
+	}
+}
+
+func (c *ScriptedClient) Name() string { return "scripted" }
+
+func (c *ScriptedClient) ModelInfo() ModelInfo {
+	return ModelInfo{ContextTokens: 200000, MaxOutputTokens: 8192}
+}
+
+func (c *ScriptedClient) Generate(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	n := len(c.requests)
+
+	if c.rules != nil {
+		for _, rule := range c.rules {
+			if rule.Match == nil || rule.Match(req) {
+				return Response{Text: rule.Response}, nil
+			}
+		}
+		return Response{}, fmt.Errorf("scripted client: no rule matches request %d", n)
+	}
+	if c.next >= len(c.responses) {
+		return Response{}, fmt.Errorf("scripted client: no response left for request %d (%d scripted)", n, len(c.responses))
+	}
+	text := c.responses[c.next]
+	c.next++
+	return Response{Text: text}, nil
+}
+
+// Requests returns the requests received so far
+func (c *ScriptedClient) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func ask(content string) Request {
+	return Request{Messages: []Message{{Role: "user", Content: content}}}
+}
+
+func TestScriptedClientInOrder(t *testing.T) {
+	c := NewScriptedClient([]string{"first", "second"})
+	for _, want := range []string{"first", "second"} {
+		resp, err := c.Generate(context.Background(), ask("next"))
+		if err != nil || resp.Text != want {
+			t.Fatalf("Generate = %q, %v; want %q", resp.Text, err, want)
+		}
+	}
+	if _, err := c.Generate(context.Background(), ask("next")); err == nil {
+		t.Error("request past the script succeeded")
+	}
+	if n := len(c.Requests()); n != 3 {
+		t.Errorf("recorded %d requests, want 3", n)
+	}
+}
+
+func TestScriptedClientRules(t *testing.T) {
+	c := NewScriptedClientWithRules([]ScriptRule{
+		{Match: MatchContent("risk"), Response: "safe"},
+		{Response: "fallback"},
+	})
+	for content, want := range map[string]string{"rate the risk": "safe", "plan": "fallback", "risk again": "safe"} {
+		resp, err := c.Generate(context.Background(), ask(content))
+		if err != nil || resp.Text != want {
+			t.Errorf("%q: Generate = %q, %v; want %q", content, resp.Text, err, want)
+		}
+	}
+
+	strict := NewScriptedClientWithRules([]ScriptRule{{Match: MatchContent("risk"), Response: "safe"}})
+	if _, err := strict.Generate(context.Background(), ask("plan")); err == nil {
+		t.Error("request without a matching rule succeeded")
+	}
+}
+
+func TestScriptedClientHonoursCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewScriptedClient([]string{"x"}).Generate(ctx, ask("next")); err == nil {
+		t.Error("cancelled request succeeded")
+	}
+}
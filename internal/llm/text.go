@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// cutBytes returns a prefix of s of at most max bytes that does not split a rune
+func cutBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// sanitizeRequest replaces invalid UTF-8 in prompt text - it would otherwise be
+// mangled by json.Marshal and rendered as garbage in the prompt
+func sanitizeRequest(req *Request, logger zerolog.Logger) {
+	if !utf8.ValidString(req.System) {
+		logger.Warn().Msg("system prompt is not valid UTF-8, sanitizing")
+		req.System = strings.ToValidUTF8(req.System, "�")
+	}
+	for i, m := range req.Messages {
+		if !utf8.ValidString(m.Content) {
+			logger.Warn().Int("message_idx", i).Msg("message is not valid UTF-8, sanitizing")
+			req.Messages[i].Content = strings.ToValidUTF8(m.Content, "�")
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+var cutSamples = []string{"Привет, мир", "🍕🍕🍕🍕🍕", "a👍🏽b👍🏽c", "Ж🍕ab ё", ""}
+
+func TestCutBytes(t *testing.T) {
+	for _, s := range cutSamples {
+		for limit := 0; limit <= len(s)+1; limit++ {
+			got := cutBytes(s, limit)
+			if !utf8.ValidString(got) || len(got) > limit || !strings.HasPrefix(s, got) {
+				t.Fatalf("cutBytes(%q, %d) = %q", s, limit, got)
+			}
+			// The longest valid prefix: the next rune would not fit
+			if rest := s[len(got):]; rest != "" {
+				_, size := utf8.DecodeRuneInString(rest)
+				if len(got)+size <= limit {
+					t.Fatalf("cutBytes(%q, %d) = %q dropped a rune that fits", s, limit, got)
+				}
+			}
+		}
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	for _, s := range cutSamples {
+		count := utf8.RuneCountInString(s)
+		for _, n := range []int{0, 1, count - 1, count, count + 1} {
+			if n < 0 {
+				continue
+			}
+			got := truncateString(s, n)
+			if !utf8.ValidString(got) {
+				t.Fatalf("truncateString(%q, %d) = %q is not valid UTF-8", s, n, got)
+			}
+			if count <= n && got != s {
+				t.Errorf("truncateString(%q, %d) = %q, want it unchanged", s, n, got)
+			}
+			if count > n && (!strings.HasSuffix(got, "...") || utf8.RuneCountInString(got) != n+3) {
+				t.Errorf("truncateString(%q, %d) = %q, want %d runes and an ellipsis", s, n, got, n)
+			}
+		}
+	}
+}
+
+func TestSanitizeRequest(t *testing.T) {
+	broken := "Привет"[:3] // Cut inside "р"
+	req := Request{System: "sys " + broken, Messages: []Message{{Role: "user", Content: "🍕"[:2] + " ok"}, {Role: "user", Content: "цел 🍕"}}}
+	sanitizeRequest(&req, zerolog.Nop())
+	if !utf8.ValidString(req.System) || !strings.HasPrefix(req.System, "sys П") {
+		t.Errorf("system = %q", req.System)
+	}
+	if req.Messages[0].Content != "� ok" {
+		t.Errorf("message = %q", req.Messages[0].Content)
+	}
+	if req.Messages[1].Content != "цел 🍕" {
+		t.Errorf("valid message changed to %q", req.Messages[1].Content)
+	}
+}
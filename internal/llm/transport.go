@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	envCACertFile    = "LLM_CA_CERT_FILE"           // PEM bundle trusted in addition to the system roots
+	envInsecureTLS   = "LLM_UNSAFE_SKIP_TLS_VERIFY" // "true" disables certificate checks (lab setups only)
+	idleConnsPerHost = 4
+)
+
+// newHTTPClient builds the HTTP client of a provider with an explicit transport:
+// HTTPS_PROXY/HTTP_PROXY/NO_PROXY are honored (credentials in the proxy URL are sent with
+// CONNECT), LLM_CA_CERT_FILE adds a corporate root CA, LLM_UNSAFE_SKIP_TLS_VERIFY=true
+// turns verification off
+func newHTTPClient(timeout time.Duration) (*http.Client, error) {
+	tlsConfig, err := tlsConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConnsPerHost = idleConnsPerHost
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+func tlsConfigFromEnv() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if path := strings.TrimSpace(os.Getenv(envCACertFile)); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", envCACertFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificates in %s", envCACertFile, path)
+		}
+		cfg.RootCAs = pool
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(envInsecureTLS))) {
+	case "1", "true", "yes":
+		fmt.Fprintf(os.Stderr, "WARNING: %s is set - TLS certificates of LLM endpoints are not verified\n", envInsecureTLS)
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
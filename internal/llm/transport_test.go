@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCA stores the test server's certificate as a PEM bundle
+func writeCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHTTPClientTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	ca := writeCA(t, srv)
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		caFile    string
+		insecure  string
+		buildErr  string // newHTTPClient must fail with this
+		verifyErr bool   // the request must fail certificate verification
+	}{
+		{name: "system roots only", verifyErr: true},
+		{name: "custom CA", caFile: ca},
+		{name: "insecure", insecure: "true"},
+		{name: "insecure off", insecure: "false", verifyErr: true},
+		{name: "missing CA file", caFile: filepath.Join(t.TempDir(), "missing.pem"), buildErr: envCACertFile},
+		{name: "CA file without PEM", caFile: notPEM, buildErr: "no PEM certificates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envCACertFile, tt.caFile)
+			t.Setenv(envInsecureTLS, tt.insecure)
+			client, err := newHTTPClient(5 * time.Second)
+			if tt.buildErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.buildErr) {
+					t.Fatalf("err = %v, want %q", err, tt.buildErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Get(srv.URL)
+			if tt.verifyErr {
+				if err == nil || !strings.Contains(err.Error(), "certificate") {
+					t.Fatalf("err = %v, want a certificate verification failure", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNoContent {
+				t.Errorf("status %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHTTPClientTransport(t *testing.T) {
+	t.Setenv(envCACertFile, "")
+	t.Setenv(envInsecureTLS, "")
+	client, err := newHTTPClient(7 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport %T, want *http.Transport", client.Transport)
+	}
+	if client.Timeout != 7*time.Second || transport.Proxy == nil || transport.MaxIdleConnsPerHost != idleConnsPerHost {
+		t.Errorf("client timeout %v, proxy set %v, idle conns %d", client.Timeout, transport.Proxy != nil, transport.MaxIdleConnsPerHost)
+	}
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("certificate verification is off by default")
+	}
+}
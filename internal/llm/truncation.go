@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// terseInstruction is appended to the system prompt when an answer was cut at the token limit
+const terseInstruction = "\n\nYour previous answer was cut off at the output token limit. Answer again, tersely: output only what is required, keep free-text fields to one short sentence."
+
+// truncationReasons are the provider stop reasons meaning the output hit the token limit:
+// OpenAI/Ollama finish_reason "length", Anthropic stop_reason "max_tokens", Gemini "MAX_TOKENS"
+var truncationReasons = map[string]bool{"length": true, "max_tokens": true}
+
+// Truncated reports whether the output was cut at the token limit
+func (r Response) Truncated() bool {
+	return truncationReasons[strings.ToLower(r.StopReason)]
+}
+
+// empty reports whether the response carries neither text nor a tool call
+func (r Response) empty() bool {
+	return strings.TrimSpace(r.Text) == "" && r.ToolCall == nil
+}
+
+// generateChecked runs one provider call (transport retries are the provider's) and retries
+// once more when the answer is unusable: a truncated answer with a larger token limit
+// (bounded by the model's output limit, starting from the provider default) and a terser
+// instruction, an empty one as-is. Usage of both calls is summed. A still-truncated answer
+// is returned with its StopReason; a still-empty one is an error.
+func generateChecked(ctx context.Context, req Request, defaultMax int, info ModelInfo, logger zerolog.Logger, call func(context.Context, Request) (Response, error)) (Response, error) {
+	resp, err := call(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	var retry Request
+	switch {
+	case resp.Truncated():
+		retry = req
+		current := max(req.MaxTokens, defaultMax)
+		retry.MaxTokens = current * 2
+		if info.MaxOutputTokens > 0 && retry.MaxTokens > info.MaxOutputTokens {
+			retry.MaxTokens = max(info.MaxOutputTokens, current)
+		}
+		retry.System += terseInstruction
+		logger.Warn().
+			Str("stop_reason", resp.StopReason).
+			Int("max_tokens", current).
+			Int("retry_max_tokens", retry.MaxTokens).
+			Msg("LLM output truncated - retrying with a larger limit")
+	case resp.empty():
+		retry = req
+		logger.Warn().Str("stop_reason", resp.StopReason).Msg("LLM returned empty content - retrying")
+	default:
+		return resp, nil
+	}
+
+	second, err := call(ctx, retry)
+	second.Usage.Add(resp.Usage)
+	if err != nil {
+		if resp.empty() {
+			return Response{Usage: second.Usage}, err
+		}
+		// The truncated answer is still more useful to the caller than a transport error
+		resp.Usage = second.Usage
+		return resp, nil
+	}
+	if second.empty() {
+		return Response{Usage: second.Usage, StopReason: second.StopReason}, fmt.Errorf("empty response content (stop reason: %s)", second.StopReason)
+	}
+	return second, nil
+}
@@ -0,0 +1,225 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// sequenceTransport answers requests with recorded response bodies in order and keeps each request body
+type sequenceTransport struct {
+	t        *testing.T
+	fixtures []string
+	bodies   [][]byte
+}
+
+func (s *sequenceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, body)
+	if len(s.bodies) > len(s.fixtures) {
+		s.t.Errorf("unexpected request #%d", len(s.bodies))
+		return nil, io.ErrUnexpectedEOF
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", s.fixtures[len(s.bodies)-1]))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    r,
+	}, nil
+}
+
+// sequenceClient is an OpenAI (gpt-4o) or Anthropic (claude-sonnet-4) client answered by the fixtures in order
+func sequenceClient(t *testing.T, provider string, fixtures ...string) (Client, *sequenceTransport) {
+	t.Helper()
+	transport := &sequenceTransport{t: t, fixtures: fixtures}
+	httpClient := &http.Client{Transport: transport}
+	if provider == "openai" {
+		return &openAIClient{apiKey: "sk-test", model: "gpt-4o", endpoint: defaultOpenAIBaseURL + openAIChatPath, http: httpClient, logger: zerolog.Nop()}, transport
+	}
+	return &anthropicClient{apiKey: "test-key", model: "claude-sonnet-4-20250514", http: httpClient, logger: zerolog.Nop()}, transport
+}
+
+// maxTokens is the max_tokens field of each recorded request
+func (s *sequenceTransport) maxTokens() []int {
+	out := make([]int, 0, len(s.bodies))
+	for _, body := range s.bodies {
+		var payload struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.Unmarshal(body, &payload)
+		out = append(out, payload.MaxTokens)
+	}
+	return out
+}
+
+func TestTruncatedAndEmptyResponsesAreRetried(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		fixtures      []string
+		maxTokens     int
+		wantMaxTokens []int
+		wantTerse     bool // The retry asks for a terser answer
+		wantStop      string
+		wantUsage     Usage
+		wantErr       string
+	}{
+		{
+			name:          "openai length then complete",
+			provider:      "openai",
+			fixtures:      []string{"openai_length.json", "openai_stop.json"},
+			maxTokens:     2000,
+			wantMaxTokens: []int{2000, 4000},
+			wantTerse:     true,
+			wantStop:      "stop",
+			wantUsage:     Usage{PromptTokens: 4230, CompletionTokens: 940},
+		},
+		{
+			name:          "openai retry bounded by the model output limit",
+			provider:      "openai",
+			fixtures:      []string{"openai_length.json", "openai_length.json"},
+			maxTokens:     10000,
+			wantMaxTokens: []int{10000, 16384},
+			wantTerse:     true,
+			wantStop:      "length", // Still cut: returned as is for the caller to report
+			wantUsage:     Usage{PromptTokens: 4200, CompletionTokens: 1800},
+		},
+		{
+			name:          "openai empty then complete",
+			provider:      "openai",
+			fixtures:      []string{"openai_empty.json", "openai_stop.json"},
+			wantMaxTokens: []int{openAIMaxTokens, openAIMaxTokens},
+			wantStop:      "stop",
+			wantUsage:     Usage{PromptTokens: 4230, CompletionTokens: 40},
+		},
+		{
+			name:          "openai empty twice",
+			provider:      "openai",
+			fixtures:      []string{"openai_empty.json", "openai_empty.json"},
+			wantMaxTokens: []int{openAIMaxTokens, openAIMaxTokens},
+			wantErr:       "empty response content",
+		},
+		{
+			name:          "anthropic max_tokens then complete",
+			provider:      "anthropic",
+			fixtures:      []string{"anthropic_max_tokens.json", "anthropic_text_json.json"},
+			wantMaxTokens: []int{maxTokens, 2 * maxTokens},
+			wantTerse:     true,
+			wantStop:      "end_turn",
+			wantUsage:     Usage{PromptTokens: 3600, CompletionTokens: 971},
+		},
+		{
+			name:          "anthropic retry bounded by the model output limit",
+			provider:      "anthropic",
+			fixtures:      []string{"anthropic_max_tokens.json", "anthropic_text_json.json"},
+			maxTokens:     40000,
+			wantMaxTokens: []int{40000, 64000},
+			wantTerse:     true,
+			wantStop:      "end_turn",
+			wantUsage:     Usage{PromptTokens: 3600, CompletionTokens: 971},
+		},
+		{
+			name:          "anthropic empty then complete",
+			provider:      "anthropic",
+			fixtures:      []string{"anthropic_empty.json", "anthropic_text_json.json"},
+			wantMaxTokens: []int{maxTokens, maxTokens},
+			wantStop:      "end_turn",
+			wantUsage:     Usage{PromptTokens: 3600, CompletionTokens: 71},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, transport := sequenceClient(t, tt.provider, tt.fixtures...)
+			req := Request{System: "You are a browser agent.", Messages: []Message{{Role: "user", Content: "next step"}}, MaxTokens: tt.maxTokens}
+			resp, err := client.Generate(context.Background(), req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if got := transport.maxTokens(); len(got) != len(tt.wantMaxTokens) || got[0] != tt.wantMaxTokens[0] || got[1] != tt.wantMaxTokens[1] {
+				t.Fatalf("max_tokens per request = %v, want %v", got, tt.wantMaxTokens)
+			}
+			if strings.Contains(string(transport.bodies[0]), "cut off") {
+				t.Fatal("the first request already asks for a terse answer")
+			}
+			if terse := strings.Contains(string(transport.bodies[1]), "cut off at the output token limit"); terse != tt.wantTerse {
+				t.Fatalf("terse instruction in the retry = %v, want %v", terse, tt.wantTerse)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if resp.StopReason != tt.wantStop || resp.Usage != tt.wantUsage {
+				t.Fatalf("stop reason %q, usage %+v; want %q, %+v", resp.StopReason, resp.Usage, tt.wantStop, tt.wantUsage)
+			}
+			if resp.Truncated() != (tt.wantStop == "length") {
+				t.Fatalf("Truncated() = %v for stop reason %q", resp.Truncated(), resp.StopReason)
+			}
+		})
+	}
+}
+
+func TestStopReasonMapping(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		fixtures      []string
+		wantStop      string
+		wantTruncated bool
+		wantToolCall  bool
+	}{
+		{name: "anthropic tool_use", provider: "anthropic", fixtures: []string{"anthropic_tool_use.json"}, wantStop: "tool_use", wantToolCall: true},
+		{name: "anthropic end_turn", provider: "anthropic", fixtures: []string{"anthropic_text_json.json"}, wantStop: "end_turn"},
+		{name: "anthropic max_tokens", provider: "anthropic", fixtures: []string{"anthropic_max_tokens.json", "anthropic_max_tokens.json"}, wantStop: "max_tokens", wantTruncated: true},
+		{name: "openai stop", provider: "openai", fixtures: []string{"openai_stop.json"}, wantStop: "stop"},
+		{name: "openai length", provider: "openai", fixtures: []string{"openai_length.json", "openai_length.json"}, wantStop: "length", wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, transport := sequenceClient(t, tt.provider, tt.fixtures...)
+			resp, err := client.Generate(context.Background(), Request{Messages: []Message{{Role: "user", Content: "next step"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StopReason != tt.wantStop || resp.Truncated() != tt.wantTruncated || (resp.ToolCall != nil) != tt.wantToolCall {
+				t.Fatalf("stop reason %q, truncated %v, tool call %+v", resp.StopReason, resp.Truncated(), resp.ToolCall)
+			}
+			if len(transport.bodies) != len(tt.fixtures) {
+				t.Fatalf("%d requests, want %d", len(transport.bodies), len(tt.fixtures))
+			}
+		})
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	tests := map[string]bool{
+		"length":     true, // OpenAI, Ollama
+		"max_tokens": true, // Anthropic
+		"MAX_TOKENS": true, // Gemini
+		"stop":       false,
+		"end_turn":   false,
+		"tool_use":   false,
+		"tool_calls": false,
+		"SAFETY":     false,
+		"":           false,
+	}
+	for reason, want := range tests {
+		if got := (Response{StopReason: reason}).Truncated(); got != want {
+			t.Errorf("Truncated() for %q = %v, want %v", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package llm
+
+import "strings"
+
+// Usage is the token count reported by the provider for one request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Total returns prompt + completion tokens
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add accumulates other into u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+}
+
+// modelPrice is USD per 1M tokens
+type modelPrice struct {
+	prefix string
+	input  float64
+	output float64
+}
+
+// modelPrices is matched by prefix in order - more specific names go first
+var modelPrices = []modelPrice{
+	{"claude-opus-4", 15, 75},
+	{"claude-3-opus", 15, 75},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-haiku-4", 1, 5},
+	{"claude-3-5-haiku", 0.8, 4},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"gpt-4o-mini", 0.15, 0.6},
+	{"gpt-4o", 2.5, 10},
+	{"gpt-4.1-nano", 0.1, 0.4},
+	{"gpt-4.1-mini", 0.4, 1.6},
+	{"gpt-4.1", 2, 8},
+	{"gpt-4-turbo", 10, 30},
+	{"gpt-3.5-turbo", 0.5, 1.5},
+	{"gemini-2.0-flash", 0.1, 0.4},
+	{"gemini-1.5-flash", 0.075, 0.3},
+	{"gemini-1.5-pro", 1.25, 5},
+}
+
+// EstimateCost returns the USD cost of usage for model (as returned by Client.Name).
+// ok is false when the model is not in the price table (local models, new releases).
+func EstimateCost(model string, usage Usage) (cost float64, ok bool) {
+	model = normalizeModelName(model)
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return (float64(usage.PromptTokens)*p.input + float64(usage.CompletionTokens)*p.output) / 1e6, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxTaskRunes matches the limit of the interactive task prompt
+	maxTaskRunes = 2000
+	// maxBodyBytes bounds a submission body
+	maxBodyBytes = 64 << 10
+)
+
+// LLMHealth is the cached result of the last LLM connectivity check
+type LLMHealth struct {
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Health is the body of /healthz and /readyz
+type Health struct {
+	Status       string     `json:"status"`  // ok, not_ready or unhealthy
+	Browser      bool       `json:"browser"` // Chromium is connected
+	BrowserError string     `json:"browser_error,omitempty"`
+	ActiveTasks  int        `json:"active_tasks"`
+	QueueDepth   int        `json:"queue_depth"`
+	QueueSize    int        `json:"queue_size"`
+	LLM          *LLMHealth `json:"llm,omitempty"` // nil when the check is disabled
+}
+
+// Health reports the server state. Live is false only when the browser is gone for good
+// (relaunch failed, or it died under running tasks) - a restart is the fix. Ready is false
+// also while new tasks would wait or fail: browser down, queue full, LLM unreachable.
+func (s *Server) Health() (h Health, live, ready bool) {
+	s.browserMu.Lock()
+	alive := s.browser.Alive()
+	s.browserMu.Unlock()
+
+	s.mu.Lock()
+	h = Health{Browser: alive, ActiveTasks: s.active, QueueDepth: len(s.queue), QueueSize: cap(s.queue), LLM: s.llm}
+	if s.browserErr != nil {
+		h.BrowserError = s.browserErr.Error()
+	}
+	live = s.browserErr == nil
+	s.mu.Unlock()
+
+	ready = live && alive && h.QueueDepth < h.QueueSize && (h.LLM == nil || h.LLM.OK)
+	switch {
+	case !live:
+		h.Status = "unhealthy"
+	case !ready:
+		h.Status = "not_ready"
+	default:
+		h.Status = "ok"
+	}
+	return h, live, ready
+}
+
+// Handler serves the API:
+//
+//	GET  /healthz     liveness (503 when the server needs a restart)
+//	GET  /readyz      readiness (503 while it can't take tasks)
+//	POST /tasks       {"task": "..."} queues a task, 202 with its status
+//	GET  /tasks       statuses of all tasks
+//	GET  /tasks/{id}  status with resource usage and, once finished, the RunResult
+//	POST /tasks/{id}/pause   holds a running task before its next step
+//	POST /tasks/{id}/resume  continues it with a fresh snapshot
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h, live, _ := s.Health()
+		writeJSON(w, statusCode(live), h)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		h, _, ready := s.Health()
+		writeJSON(w, statusCode(ready), h)
+	})
+	mux.HandleFunc("/tasks", s.handleTasks)
+	mux.HandleFunc("/tasks/", s.handleTask)
+	return mux
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tasks := s.Tasks()
+		out := make([]TaskStatus, 0, len(tasks))
+		for _, t := range tasks {
+			st := t.Status()
+			st.Result = nil // The list stays small; results are per task
+			out = append(out, st)
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		var req struct {
+			Task string `json:"task"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "body must be JSON {\"task\": \"...\"}")
+			return
+		}
+		text := strings.TrimSpace(req.Task)
+		if text == "" {
+			writeError(w, http.StatusBadRequest, "task is empty")
+			return
+		}
+		if utf8.RuneCountInString(text) > maxTaskRunes {
+			writeError(w, http.StatusBadRequest, "task is too long")
+			return
+		}
+		t, err := s.Submit(text)
+		if errors.Is(err, errQueueFull) {
+			w.Header().Set("Retry-After", "30")
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusAccepted, t.Status())
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "use GET or POST")
+	}
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	id, control, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+	t, ok := s.Task(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such task")
+		return
+	}
+	t.touch() // The client is still interested (Config.AbandonAfter)
+	if control != "" {
+		s.handleControl(w, r, t, control)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, t.Status())
+}
+
+// handleControl pauses or resumes a running task. Repeating a request is not an error:
+// the response is the task status either way.
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request, t *Task, control string) {
+	var toggle func() (bool, error)
+	switch control {
+	case "pause":
+		toggle = t.Pause
+	case "resume":
+		toggle = t.Resume
+	default:
+		writeError(w, http.StatusNotFound, "unknown task control "+control)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	changed, err := toggle()
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if changed {
+		s.logger.Info().Str("task", t.ID).Str("control", control).Msg("task " + control + "d")
+	}
+	writeJSON(w, http.StatusOK, t.Status())
+}
+
+func statusCode(ok bool) int {
+	if ok {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,331 @@
+// Package server runs agent tasks submitted over HTTP (agent -serve) on one shared Chromium.
+// A watchdog relaunches the browser when it dies between tasks; /healthz and /readyz report
+// liveness and readiness for process supervisors (Kubernetes probes). A task monitor samples
+// the browser context of every running task and stops tasks over their limits or abandoned
+// by their clients.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+const (
+	defaultQueueSize       = 16
+	defaultWatchInterval   = 10 * time.Second
+	defaultPingInterval    = 5 * time.Minute
+	defaultMonitorInterval = 5 * time.Second
+	// pingTimeout bounds one LLM connectivity check
+	pingTimeout = 30 * time.Second
+)
+
+// Browser is the shared Chromium tasks get their contexts from (browser.Launcher)
+type Browser interface {
+	Alive() bool
+	Relaunch(ctx context.Context) error
+	NewController(ctx context.Context, storagePath string) (browser.Controller, error)
+}
+
+// Runner runs one task on its own browser context; the server closes ctrl afterwards
+type Runner func(ctx context.Context, ctrl browser.Controller, task *Task) (agent.RunResult, error)
+
+// Config tunes the server; zero values pick the defaults
+type Config struct {
+	// Workers is how many tasks run at once (default 1)
+	Workers int
+	// QueueSize is how many tasks may wait for a worker (default 16); submissions beyond it get 503
+	QueueSize int
+	// Storage is the storage state every task context starts with
+	Storage string
+	// WatchInterval spaces the browser liveness checks of the watchdog (default 10s)
+	WatchInterval time.Duration
+	// Ping checks LLM connectivity with a minimal request; nil disables the check
+	Ping func(ctx context.Context) error
+	// PingInterval spaces the LLM checks (default 5m); readiness uses the cached result
+	PingInterval time.Duration
+	// AbandonAfter stops queued and running tasks nobody asked about (GET /tasks/{id} or a
+	// control request) for this long, tearing down their browser context; 0 keeps them
+	AbandonAfter time.Duration
+	// MaxJSHeap stops a running task whose browser context uses more JS heap (bytes); 0 = no limit
+	MaxJSHeap int64
+	// MonitorInterval spaces the resource samples and abandonment checks (default 5s)
+	MonitorInterval time.Duration
+}
+
+// Server queues tasks, runs them on the shared browser and answers the HTTP API (see Handler)
+type Server struct {
+	cfg     Config
+	browser Browser
+	run     Runner
+	logger  zerolog.Logger
+	queue   chan *Task
+
+	browserMu sync.Mutex // Serializes Relaunch and NewController
+
+	mu         sync.Mutex
+	tasks      map[string]*Task
+	order      []string // Task IDs in submission order
+	active     int
+	browserErr error // Why the browser is unusable; nil when healthy
+	llm        *LLMHealth
+}
+
+// New creates a server; call Start to run workers and the watchdog
+func New(cfg Config, b Browser, run Runner, logger zerolog.Logger) *Server {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.WatchInterval <= 0 {
+		cfg.WatchInterval = defaultWatchInterval
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaultPingInterval
+	}
+	if cfg.MonitorInterval <= 0 {
+		cfg.MonitorInterval = defaultMonitorInterval
+	}
+	return &Server{
+		cfg:     cfg,
+		browser: b,
+		run:     run,
+		logger:  logger,
+		queue:   make(chan *Task, cfg.QueueSize),
+		tasks:   make(map[string]*Task),
+	}
+}
+
+// Start runs the workers, the browser watchdog, the task monitor and the LLM check until ctx ends
+func (s *Server) Start(ctx context.Context) {
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work(ctx)
+	}
+	go s.every(ctx, s.cfg.WatchInterval, s.checkBrowser)
+	go s.every(ctx, s.cfg.MonitorInterval, s.monitorTasks)
+	if s.cfg.Ping != nil {
+		s.checkLLM(ctx)
+		go s.every(ctx, s.cfg.PingInterval, s.checkLLM)
+	}
+}
+
+func (s *Server) every(ctx context.Context, interval time.Duration, check func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check(ctx)
+		}
+	}
+}
+
+var (
+	// errQueueFull rejects a submission while every queue slot is taken
+	errQueueFull = errors.New("task queue is full")
+	// errAbandoned and errHeapLimit are why the task monitor stops a task
+	errAbandoned = errors.New("abandoned")
+	errHeapLimit = errors.New("over the JS heap limit")
+)
+
+// Submit queues a task
+func (s *Server) Submit(text string) (*Task, error) {
+	id := newTaskID()
+	// The ID suffix keeps artifacts of repeated tasks ({slug} paths) apart
+	now := time.Now()
+	t := &Task{ID: id, Text: text, Slug: agent.TaskSlug(text) + "-" + id[:6], status: StatusQueued, created: now, lastSeen: now}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.queue <- t:
+	default:
+		return nil, errQueueFull
+	}
+	s.tasks[t.ID] = t
+	s.order = append(s.order, t.ID)
+	return t, nil
+}
+
+// Task returns a submitted task by ID
+func (s *Server) Task(id string) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	return t, ok
+}
+
+// Tasks lists the submitted tasks, oldest first
+func (s *Server) Tasks() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Task, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, s.tasks[id])
+	}
+	return out
+}
+
+func (s *Server) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-s.queue:
+			s.runTask(ctx, t)
+		}
+	}
+}
+
+// runTask runs t and records its outcome only once the task no longer counts as active,
+// so a finished task never holds back the watchdog. A task stopped while queued is skipped.
+func (s *Server) runTask(ctx context.Context, t *Task) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if !t.start(cancel) {
+		return
+	}
+	s.addActive(1)
+	result, err := s.execute(ctx, t)
+	s.addActive(-1)
+	t.finish(result, err)
+}
+
+func (s *Server) execute(ctx context.Context, t *Task) (*agent.RunResult, error) {
+	logger := s.logger.With().Str("task", t.ID).Str("slug", t.Slug).Logger()
+	ctrl, err := s.controller(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("no browser context for task")
+		return nil, fmt.Errorf("browser: %w", err)
+	}
+	defer ctrl.Close(context.Background())
+	t.attach(ctrl)
+	result, err := s.run(ctx, ctrl, t)
+	if err != nil {
+		logger.Warn().Err(err).Msg("task failed")
+	} else {
+		logger.Info().Bool("success", result.Success).Int("steps", result.Steps).Msg("task finished")
+	}
+	return &result, err
+}
+
+// controller creates a context for a task, relaunching a browser that died since the last check
+func (s *Server) controller(ctx context.Context) (browser.Controller, error) {
+	s.browserMu.Lock()
+	defer s.browserMu.Unlock()
+	if !s.browser.Alive() {
+		s.logger.Warn().Msg("browser is not connected - relaunching before the task")
+		if err := s.browser.Relaunch(ctx); err != nil {
+			s.setBrowserErr(fmt.Errorf("relaunch failed: %w", err))
+			return nil, err
+		}
+		s.setBrowserErr(nil)
+	}
+	return s.browser.NewController(ctx, s.cfg.Storage)
+}
+
+// checkBrowser is the watchdog: a dead browser is relaunched while no task runs on it,
+// otherwise the server reports itself unhealthy until the tasks are gone
+func (s *Server) checkBrowser(ctx context.Context) {
+	s.browserMu.Lock()
+	defer s.browserMu.Unlock()
+	if s.browser.Alive() {
+		s.setBrowserErr(nil)
+		return
+	}
+	if n := s.activeTasks(); n > 0 {
+		s.logger.Error().Int("active_tasks", n).Msg("browser died under running tasks")
+		s.setBrowserErr(fmt.Errorf("browser died under %d running task(s)", n))
+		return
+	}
+	s.logger.Warn().Msg("browser is not connected - relaunching")
+	if err := s.browser.Relaunch(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("browser relaunch failed")
+		s.setBrowserErr(fmt.Errorf("relaunch failed: %w", err))
+		return
+	}
+	s.setBrowserErr(nil)
+}
+
+// monitorTasks samples the browser context of every running task and stops tasks over the JS
+// heap limit or abandoned by their clients. Stopping cancels the run, which closes its context;
+// with a {slug} -history path the checkpoint of the last step stays for -resume.
+func (s *Server) monitorTasks(ctx context.Context) {
+	now := time.Now()
+	for _, t := range s.Tasks() {
+		if idle := t.idle(now); s.cfg.AbandonAfter > 0 && idle > s.cfg.AbandonAfter {
+			if t.stop(fmt.Errorf("%w: no status request for %s", errAbandoned, idle.Round(time.Second))) {
+				s.logger.Warn().Str("task", t.ID).Dur("idle", idle).Msg("task abandoned - stopping it")
+			}
+			continue
+		}
+		ctrl := t.controller()
+		if ctrl == nil {
+			continue
+		}
+		usage, err := ctrl.ResourceUsage(ctx)
+		if err != nil {
+			s.logger.Debug().Err(err).Str("task", t.ID).Msg("resource sample failed")
+			continue
+		}
+		t.sample(usage, now)
+		if s.cfg.MaxJSHeap > 0 && usage.JSHeapBytes > s.cfg.MaxJSHeap {
+			if t.stop(fmt.Errorf("%w: %d MB used, limit %d MB", errHeapLimit, usage.JSHeapBytes>>20, s.cfg.MaxJSHeap>>20)) {
+				s.logger.Warn().Str("task", t.ID).Int64("js_heap_bytes", usage.JSHeapBytes).Msg("task over the JS heap limit - stopping it")
+			}
+		}
+	}
+}
+
+func (s *Server) checkLLM(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	err := s.cfg.Ping(pingCtx)
+	cancel()
+	health := &LLMHealth{OK: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		health.Error = err.Error()
+		s.logger.Warn().Err(err).Msg("LLM connectivity check failed")
+	}
+	s.mu.Lock()
+	s.llm = health
+	s.mu.Unlock()
+}
+
+func (s *Server) setBrowserErr(err error) {
+	s.mu.Lock()
+	s.browserErr = err
+	s.mu.Unlock()
+}
+
+func (s *Server) addActive(n int) {
+	s.mu.Lock()
+	s.active += n
+	s.mu.Unlock()
+}
+
+func (s *Server) activeTasks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// newTaskID returns an unguessable task ID: status and results are readable by whoever knows it
+func newTaskID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("t%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
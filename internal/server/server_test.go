@@ -0,0 +1,528 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// fakeBrowser stands in for the Launcher: Kill makes it report a dead Chromium
+type fakeBrowser struct {
+	mu          sync.Mutex
+	dead        bool
+	relaunches  int
+	relaunchErr error
+	heap        int64                     // JS heap every context reports (SetHeap)
+	ctrls       []*browser.FakeController // Contexts handed out, oldest first
+}
+
+func (b *fakeBrowser) Alive() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.dead
+}
+
+func (b *fakeBrowser) Relaunch(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.relaunches++
+	if b.relaunchErr != nil {
+		return b.relaunchErr
+	}
+	b.dead = false
+	return nil
+}
+
+func (b *fakeBrowser) NewController(ctx context.Context, storagePath string) (browser.Controller, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.dead {
+		return nil, errors.New("browser is closed")
+	}
+	ctrl := browser.NewFakeController(browser.FakePage{URL: "about:blank"})
+	b.ctrls = append(b.ctrls, ctrl)
+	return sizedController{FakeController: ctrl, b: b}, nil
+}
+
+func (b *fakeBrowser) SetHeap(bytes int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.heap = bytes
+}
+
+// Closed reports whether the i-th context handed out was closed
+func (b *fakeBrowser) Closed(i int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return i < len(b.ctrls) && len(b.ctrls[i].CallsTo("Close")) > 0
+}
+
+// sizedController reports the fake browser's JS heap to the task monitor
+type sizedController struct {
+	*browser.FakeController
+	b *fakeBrowser
+}
+
+func (c sizedController) ResourceUsage(ctx context.Context) (browser.ResourceUsage, error) {
+	usage, err := c.FakeController.ResourceUsage(ctx)
+	c.b.mu.Lock()
+	usage.JSHeapBytes = c.b.heap
+	c.b.mu.Unlock()
+	return usage, err
+}
+
+func (b *fakeBrowser) Kill() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dead = true
+}
+
+func (b *fakeBrowser) Relaunches() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.relaunches
+}
+
+func finished(ctx context.Context, ctrl browser.Controller, t *Task) (agent.RunResult, error) {
+	return agent.RunResult{Slug: t.Slug, Success: true, FinalMessage: "done: " + t.Text, Steps: 1}, nil
+}
+
+// newTestServer starts a server whose watchdog and task monitor never fire on their own:
+// tests call checkBrowser and monitorTasks
+func newTestServer(t *testing.T, cfg Config, b Browser, run Runner) (*Server, *httptest.Server) {
+	t.Helper()
+	cfg.WatchInterval = time.Hour
+	cfg.MonitorInterval = time.Hour
+	s := New(cfg, b, run, zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+	})
+	return s, ts
+}
+
+func get(t *testing.T, url string, v any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func submit(t *testing.T, ts *httptest.Server, text string) (int, TaskStatus) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"task": text})
+	resp, err := http.Post(ts.URL+"/tasks", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st TaskStatus
+	_ = json.NewDecoder(resp.Body).Decode(&st)
+	return resp.StatusCode, st
+}
+
+func waitStatus(t *testing.T, ts *httptest.Server, id, want string) TaskStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var st TaskStatus
+		if code := get(t, ts.URL+"/tasks/"+id, &st); code != http.StatusOK {
+			t.Fatalf("GET /tasks/%s = %d", id, code)
+		}
+		if st.Status == want {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s is %q, want %q", id, st.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubmittedTaskRunsToDone(t *testing.T) {
+	_, ts := newTestServer(t, Config{}, &fakeBrowser{}, finished)
+
+	code, st := submit(t, ts, "find the weather")
+	if code != http.StatusAccepted || st.ID == "" || st.Status != StatusQueued {
+		t.Fatalf("POST /tasks = %d %+v", code, st)
+	}
+	done := waitStatus(t, ts, st.ID, StatusDone)
+	if done.Result == nil || done.Result.FinalMessage != "done: find the weather" || done.Finished == nil {
+		t.Fatalf("finished task = %+v", done)
+	}
+
+	var list []TaskStatus
+	if code := get(t, ts.URL+"/tasks", &list); code != http.StatusOK || len(list) != 1 || list[0].Result != nil {
+		t.Fatalf("GET /tasks = %d %+v", code, list)
+	}
+	if code := get(t, ts.URL+"/tasks/nope", nil); code != http.StatusNotFound {
+		t.Fatalf("unknown task = %d, want 404", code)
+	}
+}
+
+func TestSubmitRejectsBadBodies(t *testing.T) {
+	_, ts := newTestServer(t, Config{}, &fakeBrowser{}, finished)
+	for name, body := range map[string]string{
+		"not json": "find the weather",
+		"empty":    `{"task": "  "}`,
+		"too long": `{"task": "` + strings.Repeat("я", maxTaskRunes+1) + `"}`,
+	} {
+		resp, err := http.Post(ts.URL+"/tasks", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestWatchdogRelaunchesIdleBrowser(t *testing.T) {
+	b := &fakeBrowser{}
+	s, ts := newTestServer(t, Config{}, b, finished)
+
+	b.Kill()
+	var h Health
+	if code := get(t, ts.URL+"/readyz", &h); code != http.StatusServiceUnavailable || h.Browser {
+		t.Fatalf("readyz with a dead browser = %d %+v", code, h)
+	}
+	if code := get(t, ts.URL+"/healthz", nil); code != http.StatusOK {
+		t.Fatalf("healthz = %d: an idle dead browser is recoverable", code)
+	}
+
+	s.checkBrowser(context.Background())
+	if b.Relaunches() != 1 {
+		t.Fatalf("relaunches = %d, want 1", b.Relaunches())
+	}
+	if code := get(t, ts.URL+"/readyz", &h); code != http.StatusOK || h.Status != "ok" {
+		t.Fatalf("readyz after relaunch = %d %+v", code, h)
+	}
+}
+
+func TestWatchdogReportsFailedRelaunch(t *testing.T) {
+	b := &fakeBrowser{relaunchErr: errors.New("chromium not found")}
+	s, ts := newTestServer(t, Config{}, b, finished)
+
+	b.Kill()
+	s.checkBrowser(context.Background())
+	var h Health
+	if code := get(t, ts.URL+"/healthz", &h); code != http.StatusServiceUnavailable || !strings.Contains(h.BrowserError, "chromium not found") {
+		t.Fatalf("healthz after a failed relaunch = %d %+v", code, h)
+	}
+}
+
+func TestBrowserKilledUnderRunningTask(t *testing.T) {
+	b := &fakeBrowser{}
+	started, release := make(chan struct{}), make(chan struct{})
+	blocking := func(ctx context.Context, ctrl browser.Controller, t *Task) (agent.RunResult, error) {
+		close(started)
+		<-release
+		return agent.RunResult{}, errors.New("target closed")
+	}
+	s, ts := newTestServer(t, Config{}, b, blocking)
+
+	_, st := submit(t, ts, "long task")
+	<-started
+	b.Kill()
+	s.checkBrowser(context.Background())
+
+	var h Health
+	if code := get(t, ts.URL+"/healthz", &h); code != http.StatusServiceUnavailable || h.ActiveTasks != 1 || h.Status != "unhealthy" {
+		t.Fatalf("healthz with a task on a dead browser = %d %+v", code, h)
+	}
+	if b.Relaunches() != 0 {
+		t.Fatal("watchdog relaunched the browser under a running task")
+	}
+
+	close(release)
+	failed := waitStatus(t, ts, st.ID, StatusFailed)
+	if !strings.Contains(failed.Error, "target closed") {
+		t.Fatalf("task error = %q", failed.Error)
+	}
+	s.checkBrowser(context.Background())
+	if b.Relaunches() != 1 {
+		t.Fatalf("relaunches after the task ended = %d, want 1", b.Relaunches())
+	}
+	if code := get(t, ts.URL+"/healthz", nil); code != http.StatusOK {
+		t.Fatalf("healthz after recovery = %d", code)
+	}
+}
+
+func TestTaskRelaunchesDeadBrowserFirst(t *testing.T) {
+	b := &fakeBrowser{}
+	_, ts := newTestServer(t, Config{}, b, finished)
+
+	b.Kill()
+	_, st := submit(t, ts, "after a crash")
+	waitStatus(t, ts, st.ID, StatusDone)
+	if b.Relaunches() != 1 {
+		t.Fatalf("relaunches = %d, want 1", b.Relaunches())
+	}
+}
+
+func TestFailedLLMPingOnlyAffectsReadiness(t *testing.T) {
+	ping := func(ctx context.Context) error { return errors.New("401 unauthorized") }
+	_, ts := newTestServer(t, Config{Ping: ping}, &fakeBrowser{}, finished)
+
+	var h Health
+	if code := get(t, ts.URL+"/readyz", &h); code != http.StatusServiceUnavailable || h.LLM == nil || h.LLM.OK || h.LLM.Error != "401 unauthorized" {
+		t.Fatalf("readyz with a failing LLM = %d %+v", code, h)
+	}
+	if code := get(t, ts.URL+"/healthz", nil); code != http.StatusOK {
+		t.Fatalf("healthz with a failing LLM = %d, want 200", code)
+	}
+}
+
+func TestFullQueueRejectsSubmissions(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	blocking := func(ctx context.Context, ctrl browser.Controller, t *Task) (agent.RunResult, error) {
+		started <- struct{}{}
+		<-release
+		return agent.RunResult{}, nil
+	}
+	_, ts := newTestServer(t, Config{QueueSize: 1}, &fakeBrowser{}, blocking)
+	defer close(release)
+
+	submit(t, ts, "running")
+	<-started
+	if code, _ := submit(t, ts, "queued"); code != http.StatusAccepted {
+		t.Fatalf("second task = %d, want 202", code)
+	}
+	if code := get(t, ts.URL+"/readyz", nil); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz with a full queue = %d, want 503", code)
+	}
+	resp, err := http.Post(ts.URL+"/tasks", "application/json", strings.NewReader(`{"task": "one too many"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("third task = %d, want 503 with Retry-After", resp.StatusCode)
+	}
+}
+
+// gate stands in for the orchestrator's pause gate
+type gate struct {
+	mu     sync.Mutex
+	paused bool
+}
+
+func (g *gate) Pause() bool  { return g.set(true) }
+func (g *gate) Resume() bool { return g.set(false) }
+
+func (g *gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+func (g *gate) set(paused bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	changed := g.paused != paused
+	g.paused = paused
+	return changed
+}
+
+func post(t *testing.T, url string) (int, TaskStatus) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st TaskStatus
+	_ = json.NewDecoder(resp.Body).Decode(&st)
+	return resp.StatusCode, st
+}
+
+func TestPauseAndResumeRunningTask(t *testing.T) {
+	g := &gate{}
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	pausable := func(ctx context.Context, ctrl browser.Controller, t *Task) (agent.RunResult, error) {
+		t.SetPauser(g)
+		started <- struct{}{}
+		<-release
+		return agent.RunResult{Success: true}, nil
+	}
+	_, ts := newTestServer(t, Config{QueueSize: 2}, &fakeBrowser{}, pausable)
+
+	_, running := submit(t, ts, "long task")
+	<-started
+	_, queued := submit(t, ts, "waits for the worker")
+	if code, _ := post(t, ts.URL+"/tasks/"+queued.ID+"/pause"); code != http.StatusConflict {
+		t.Fatalf("pause of a queued task = %d, want 409", code)
+	}
+
+	base := ts.URL + "/tasks/" + running.ID
+	for _, step := range []struct {
+		control string
+		paused  bool
+	}{{"pause", true}, {"pause", true}, {"resume", false}, {"resume", false}, {"pause", true}} {
+		code, st := post(t, base+"/"+step.control)
+		if code != http.StatusOK || st.Paused != step.paused || g.Paused() != step.paused {
+			t.Fatalf("POST %s = %d paused=%v, gate paused=%v; want paused=%v", step.control, code, st.Paused, g.Paused(), step.paused)
+		}
+	}
+	var st TaskStatus
+	if get(t, base, &st); !st.Paused || st.Status != StatusRunning {
+		t.Fatalf("status = %+v, want running and paused", st)
+	}
+	if code := get(t, base+"/pause", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET pause = %d, want 405", code)
+	}
+	if code, _ := post(t, base+"/stop"); code != http.StatusNotFound {
+		t.Fatalf("unknown control = %d, want 404", code)
+	}
+
+	close(release)
+	done := waitStatus(t, ts, running.ID, StatusDone)
+	if done.Paused {
+		t.Fatal("finished task reported as paused")
+	}
+	if code, _ := post(t, base+"/resume"); code != http.StatusConflict {
+		t.Fatalf("resume of a finished task = %d, want 409", code)
+	}
+}
+
+// untilStopped is a Runner that holds the task until the server cancels it
+func untilStopped(started chan<- string) Runner {
+	return func(ctx context.Context, ctrl browser.Controller, t *Task) (agent.RunResult, error) {
+		started <- t.ID
+		<-ctx.Done()
+		return agent.RunResult{Steps: 3}, ctx.Err()
+	}
+}
+
+// waitTask waits for a task status without a request, which would count as client interest
+func waitTask(t *testing.T, s *Server, id, want string) TaskStatus {
+	t.Helper()
+	task, _ := s.Task(id)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := task.Status()
+		if st.Status == want {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s is %q, want %q", id, st.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAbandonedTasksAreStopped(t *testing.T) {
+	b := &fakeBrowser{}
+	started := make(chan string, 2)
+	s, ts := newTestServer(t, Config{AbandonAfter: 50 * time.Millisecond}, b, untilStopped(started))
+
+	_, running := submit(t, ts, "nobody waits for this")
+	<-started
+	_, queued := submit(t, ts, "nor for this")
+	time.Sleep(60 * time.Millisecond)
+	s.monitorTasks(context.Background())
+
+	stopped := waitTask(t, s, running.ID, StatusStopped)
+	if !strings.HasPrefix(stopped.Error, "abandoned: no status request for") || stopped.Result == nil || stopped.Result.Steps != 3 {
+		t.Fatalf("abandoned running task = %+v", stopped)
+	}
+	if !b.Closed(0) {
+		t.Fatal("the browser context of the abandoned task is still open")
+	}
+	never := waitTask(t, s, queued.ID, StatusStopped)
+	if never.Started != nil || !strings.HasPrefix(never.Error, "abandoned") {
+		t.Fatalf("abandoned queued task = %+v", never)
+	}
+	select {
+	case id := <-started:
+		t.Fatalf("task %s started after it was abandoned", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Stopping is reported once; the ended tasks are left alone
+	s.monitorTasks(context.Background())
+	var st TaskStatus
+	if get(t, ts.URL+"/tasks/"+running.ID, &st); st.Status != StatusStopped || st.Error != stopped.Error {
+		t.Fatalf("status after another check = %+v", st)
+	}
+}
+
+func TestPolledTaskIsNotAbandoned(t *testing.T) {
+	started := make(chan string, 1)
+	s, ts := newTestServer(t, Config{AbandonAfter: 50 * time.Millisecond}, &fakeBrowser{}, untilStopped(started))
+
+	_, st := submit(t, ts, "the client keeps polling")
+	<-started
+	for i := 0; i < 6; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if i%2 == 0 {
+			get(t, ts.URL+"/tasks/"+st.ID, nil)
+		} else {
+			post(t, ts.URL+"/tasks/"+st.ID+"/pause") // Refused without a pauser, still a sign of interest
+		}
+		s.monitorTasks(context.Background())
+	}
+	if got := waitTask(t, s, st.ID, StatusRunning); got.Error != "" {
+		t.Fatalf("polled task = %+v", got)
+	}
+}
+
+func TestTaskResourcesAndHeapLimit(t *testing.T) {
+	b := &fakeBrowser{}
+	started := make(chan string, 1)
+	s, ts := newTestServer(t, Config{MaxJSHeap: 512 << 20}, b, untilStopped(started))
+
+	_, submitted := submit(t, ts, "heavy dashboard")
+	<-started
+	base := ts.URL + "/tasks/" + submitted.ID
+	for _, step := range []struct {
+		heap, peak int64
+	}{{100 << 20, 100 << 20}, {50 << 20, 100 << 20}} {
+		b.SetHeap(step.heap)
+		s.monitorTasks(context.Background())
+		var st TaskStatus
+		get(t, base, &st)
+		if st.Status != StatusRunning || st.Resources == nil {
+			t.Fatalf("status = %+v, want running with resources", st)
+		}
+		if r := st.Resources; r.Pages != 1 || r.JSHeapBytes != step.heap || r.PeakJSHeapBytes != step.peak || r.SampledAt.IsZero() {
+			t.Fatalf("resources = %+v, want heap %d, peak %d", r, step.heap, step.peak)
+		}
+	}
+
+	b.SetHeap(600 << 20)
+	s.monitorTasks(context.Background())
+	st := waitStatus(t, ts, submitted.ID, StatusStopped)
+	if st.Error != "over the JS heap limit: 600 MB used, limit 512 MB" {
+		t.Fatalf("error = %q", st.Error)
+	}
+	if st.Resources == nil || st.Resources.PeakJSHeapBytes != 600<<20 {
+		t.Fatalf("resources of the stopped task = %+v", st.Resources)
+	}
+	if !b.Closed(0) {
+		t.Fatal("the browser context over the limit is still open")
+	}
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/agent"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// Task states reported by the status endpoint
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"    // Run returned without error (see Result.Success)
+	StatusFailed  = "failed"  // Run or the browser failed, Error says why
+	StatusStopped = "stopped" // The server stopped it: abandoned or over a resource limit, Error says why
+)
+
+// Pauser holds and continues a running task between steps (agent.Orchestrator)
+type Pauser interface {
+	Pause() bool
+	Resume() bool
+	Paused() bool
+}
+
+// errNotRunning rejects pause and resume of a task that is not running
+var errNotRunning = errors.New("task is not running")
+
+// Task is one submitted task and its outcome
+type Task struct {
+	ID   string
+	Text string
+	Slug string
+
+	mu       sync.Mutex
+	status   string
+	created  time.Time
+	started  time.Time
+	finished time.Time
+	result   *agent.RunResult
+	err      error
+	pauser   Pauser // Set by the Runner while the task runs
+
+	lastSeen  time.Time               // Last status or control request for this task
+	ctrl      browser.Controller      // Browser context of the running task
+	cancel    context.CancelCauseFunc // Stops the running task
+	stopped   error                   // Why the server stopped the task, nil otherwise
+	resources *TaskResources          // Last sample of ctrl
+}
+
+// TaskResources is the last resource sample of a running task's browser context
+type TaskResources struct {
+	browser.ResourceUsage
+	PeakJSHeapBytes int64     `json:"peak_js_heap_bytes"`
+	SampledAt       time.Time `json:"sampled_at"`
+}
+
+// TaskStatus is the JSON view of a task
+type TaskStatus struct {
+	ID       string     `json:"id"`
+	Slug     string     `json:"slug"`
+	Task     string     `json:"task"`
+	Status   string     `json:"status"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Paused   bool       `json:"paused,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	// Resources is the browser context usage: current while running, the last sample after
+	Resources *TaskResources   `json:"resources,omitempty"`
+	Result    *agent.RunResult `json:"result,omitempty"`
+}
+
+// Status returns a consistent copy of the task state
+func (t *Task) Status() TaskStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := TaskStatus{ID: t.ID, Slug: t.Slug, Task: t.Text, Status: t.status, Created: t.created, Result: t.result}
+	if !t.started.IsZero() {
+		started := t.started
+		st.Started = &started
+	}
+	if !t.finished.IsZero() {
+		finished := t.finished
+		st.Finished = &finished
+	}
+	if t.err != nil {
+		st.Error = t.err.Error()
+	}
+	if t.pauser != nil {
+		st.Paused = t.pauser.Paused()
+	}
+	if t.resources != nil {
+		resources := *t.resources
+		st.Resources = &resources
+	}
+	return st
+}
+
+// SetPauser makes the running task pausable; the Runner calls it once the run exists
+func (t *Task) SetPauser(p Pauser) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status == StatusRunning {
+		t.pauser = p
+	}
+}
+
+// Pause holds the task before its next step; false if it was paused already
+func (t *Task) Pause() (bool, error) {
+	p, err := t.runningPauser()
+	if err != nil {
+		return false, err
+	}
+	return p.Pause(), nil
+}
+
+// Resume continues a paused task with a fresh snapshot; false if it was not paused
+func (t *Task) Resume() (bool, error) {
+	p, err := t.runningPauser()
+	if err != nil {
+		return false, err
+	}
+	return p.Resume(), nil
+}
+
+func (t *Task) runningPauser() (Pauser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pauser == nil {
+		return nil, errNotRunning
+	}
+	return t.pauser, nil
+}
+
+// touch records a request for the task: clients that keep asking have not abandoned it
+func (t *Task) touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastSeen = time.Now()
+}
+
+// idle is how long nobody asked about a queued or running task, 0 once it ended
+func (t *Task) idle(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status != StatusQueued && t.status != StatusRunning {
+		return 0
+	}
+	return now.Sub(t.lastSeen)
+}
+
+// start marks the task running with cancel stopping it; false when it was stopped while queued
+func (t *Task) start(cancel context.CancelCauseFunc) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status != StatusQueued {
+		return false
+	}
+	t.status = StatusRunning
+	t.started = time.Now()
+	t.cancel = cancel
+	return true
+}
+
+// attach makes the browser context of the running task visible to the resource monitor
+func (t *Task) attach(ctrl browser.Controller) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ctrl = ctrl
+}
+
+func (t *Task) controller() browser.Controller {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ctrl
+}
+
+// sample records a resource sample of the running task
+func (t *Task) sample(usage browser.ResourceUsage, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	peak := usage.JSHeapBytes
+	if t.resources != nil {
+		peak = max(peak, t.resources.PeakJSHeapBytes)
+	}
+	t.resources = &TaskResources{ResourceUsage: usage, PeakJSHeapBytes: peak, SampledAt: at}
+}
+
+// stop ends a queued task at once and cancels a running one with cause; false if it already ended
+func (t *Task) stop(cause error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.stopped != nil:
+		return false
+	case t.status == StatusQueued:
+		t.stopped, t.err = cause, cause
+		t.status = StatusStopped
+		t.finished = time.Now()
+		return true
+	case t.status == StatusRunning:
+		t.stopped = cause
+		t.cancel(cause)
+		return true
+	}
+	return false
+}
+
+func (t *Task) finish(result *agent.RunResult, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.result, t.err = result, err
+	t.pauser, t.ctrl, t.cancel = nil, nil, nil
+	t.finished = time.Now()
+	switch {
+	case t.stopped != nil:
+		// The run ends with the context error; the reason is why the server cancelled it
+		t.status, t.err = StatusStopped, t.stopped
+	case err != nil:
+		t.status = StatusFailed
+	default:
+		t.status = StatusDone
+	}
+}
@@ -0,0 +1,115 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// syntheticAXTree builds a getFullAXTree payload of about n nodes in document order, shaped like
+// a long catalog: list items holding a heading, a text run, a link and a button each. The result
+// is decoded from JSON like the CDP answer (maps, float64 numbers).
+func syntheticAXTree(tb testing.TB, n int) interface{} {
+	tb.Helper()
+	type axValue struct {
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	}
+	type axNode struct {
+		NodeID      string   `json:"nodeId"`
+		Ignored     bool     `json:"ignored"`
+		Role        axValue  `json:"role"`
+		Name        *axValue `json:"name,omitempty"`
+		ChildIDs    []string `json:"childIds"`
+		BackendNode int      `json:"backendDOMNodeId"`
+	}
+	nodes := []axNode{
+		{NodeID: "1", Role: axValue{"internalRole", "RootWebArea"}, Name: &axValue{"computedString", "Catalog"}},
+		{NodeID: "2", Role: axValue{"role", "list"}},
+	}
+	nodes[0].ChildIDs = []string{"2"}
+	id := 2
+	next := func() string { id++; return fmt.Sprint(id) }
+	for item := 0; len(nodes) < n; item++ {
+		li := axNode{NodeID: next(), Role: axValue{"role", "listitem"}}
+		children := []axNode{
+			{NodeID: next(), Role: axValue{"role", "heading"}, Name: &axValue{"computedString", fmt.Sprintf("Product %d", item)}},
+			{NodeID: next(), Role: axValue{"role", "StaticText"}, Name: &axValue{"computedString", fmt.Sprintf("%d.99 €", item)}},
+			{NodeID: next(), Role: axValue{"role", "link"}, Name: &axValue{"computedString", "Details"}},
+			{NodeID: next(), Role: axValue{"role", "button"}, Name: &axValue{"computedString", "Add to cart"}},
+		}
+		for _, c := range children {
+			li.ChildIDs = append(li.ChildIDs, c.NodeID)
+		}
+		nodes[1].ChildIDs = append(nodes[1].ChildIDs, li.NodeID)
+		nodes = append(nodes, li)
+		nodes = append(nodes, children...)
+	}
+	data, err := json.Marshal(map[string]any{"nodes": nodes})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		tb.Fatal(err)
+	}
+	return tree
+}
+
+func TestParseLargeTreeStopsAtTheLimit(t *testing.T) {
+	tree := syntheticAXTree(t, 50000)
+	start := time.Now()
+	elems, partial, err := parseAccessibilityTree(context.Background(), tree, 200, Options{}.actionableRoles(), nil, zerolog.Nop())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 200 || partial {
+		t.Fatalf("%d elements, partial=%v - want 200, complete", len(elems), partial)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("parsing 200 of 50k nodes took %s", elapsed)
+	}
+	// Hierarchy is fixed up for the emitted elements: list > listitem > link
+	for _, el := range elems {
+		if el.Role == "link" {
+			if el.Depth != 3 || el.ParentId == "" {
+				t.Fatalf("link %+v, want depth 3 under its list item", el)
+			}
+			break
+		}
+	}
+}
+
+func TestParseKeepsPartialResultOnDeadline(t *testing.T) {
+	tree := syntheticAXTree(t, 5000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	elems, partial, err := parseAccessibilityTree(ctx, tree, 10000, Options{}.actionableRoles(), nil, zerolog.Nop())
+	if err != nil || !partial {
+		t.Fatalf("partial=%v err=%v, want a partial result", partial, err)
+	}
+	full, _, _ := parseAccessibilityTree(context.Background(), tree, 10000, Options{}.actionableRoles(), nil, zerolog.Nop())
+	if len(elems) >= len(full) {
+		t.Fatalf("%d elements after the deadline, %d without it", len(elems), len(full))
+	}
+}
+
+func BenchmarkParseAccessibilityTree(b *testing.B) {
+	tree := syntheticAXTree(b, 50000)
+	roles := Options{}.actionableRoles()
+	for _, limit := range []int{200, 50000} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := parseAccessibilityTree(context.Background(), tree, limit, roles, nil, zerolog.Nop()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
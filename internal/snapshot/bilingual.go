@@ -0,0 +1,91 @@
+package snapshot
+
+import "unicode"
+
+// dropHiddenTranslations removes duplicated i18n copies of elements: sites render Russian and
+// English variants at the same place and hide one (SEO, hydration). Conservative - only
+// elements confirmed not rendered (Hidden) are dropped, and only when they sit at the same
+// bbox as an element written in another script:
+//   - a hidden copy with a visible counterpart is dropped;
+//   - when every copy is hidden, copies not in the task language are dropped.
+func dropHiddenTranslations(elems []Element, lang string) []Element {
+	groups := make(map[string][]int)
+	for i, el := range elems {
+		if el.BBox == "" || el.BBox == "0,0,0,0" || el.Text == "" {
+			continue
+		}
+		key := el.Role + "|" + el.BBox
+		groups[key] = append(groups[key], i)
+	}
+
+	drop := make(map[int]bool)
+	for _, idx := range groups {
+		if len(idx) < 2 || !mixedScripts(elems, idx) {
+			continue
+		}
+		anyVisible := false
+		for _, i := range idx {
+			if !elems[i].Hidden {
+				anyVisible = true
+				break
+			}
+		}
+		for _, i := range idx {
+			if !elems[i].Hidden {
+				continue
+			}
+			if anyVisible || (lang != "" && textScript(elems[i].Text) != lang) {
+				drop[i] = true
+			}
+		}
+	}
+	if len(drop) == 0 {
+		return elems
+	}
+
+	kept := make([]Element, 0, len(elems)-len(drop))
+	for i, el := range elems {
+		if !drop[i] {
+			kept = append(kept, el)
+		}
+	}
+	return kept
+}
+
+// mixedScripts reports whether the elements' texts are in different scripts
+func mixedScripts(elems []Element, idx []int) bool {
+	first := ""
+	for _, i := range idx {
+		script := textScript(elems[i].Text)
+		if script == "" {
+			continue
+		}
+		if first == "" {
+			first = script
+		} else if script != first {
+			return true
+		}
+	}
+	return false
+}
+
+// textScript returns "ru" for Cyrillic-dominant text, "en" for Latin-dominant, "" for neither
+func textScript(text string) string {
+	cyrillic, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	switch {
+	case cyrillic == 0 && latin == 0:
+		return ""
+	case cyrillic >= latin:
+		return "ru"
+	default:
+		return "en"
+	}
+}
@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+func TestDropHiddenTranslations(t *testing.T) {
+	slot := func(n int) string { return fmt.Sprintf("10,%d,200,40", n*60) }
+	elems := []Element{
+		{Index: 1, Role: "button", Text: "В корзину", BBox: slot(1)},
+		{Index: 2, Role: "button", Text: "Add to cart", BBox: slot(1), Hidden: true},
+		{Index: 3, Role: "button", Text: "Войти", BBox: slot(2), Hidden: true},
+		{Index: 4, Role: "button", Text: "Sign in", BBox: slot(2), Hidden: true},
+		{Index: 5, Role: "link", Text: "Помощь", BBox: slot(3)},
+		{Index: 6, Role: "link", Text: "Help", BBox: slot(3)},
+		{Index: 7, Role: "button", Text: "Special offer", BBox: slot(4), Hidden: true},
+		{Index: 8, Role: "link", Text: "Add to cart", BBox: slot(1), Hidden: true}, // Another role: not a copy
+		{Index: 9, Role: "button", Text: "Buy", BBox: slot(5), Hidden: true},
+		{Index: 10, Role: "button", Text: "Buy now", BBox: slot(5)}, // Same script: not a translation
+	}
+	tests := []struct {
+		lang string
+		want []int
+	}{
+		{lang: "ru", want: []int{1, 3, 5, 6, 7, 8, 9, 10}},
+		{lang: "en", want: []int{1, 4, 5, 6, 7, 8, 9, 10}},
+		{lang: "", want: []int{1, 3, 4, 5, 6, 7, 8, 9, 10}}, // Unknown language keeps every all-hidden copy
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, el := range dropHiddenTranslations(elems, tt.lang) {
+			got = append(got, el.Index)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("lang %q: kept %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestBilingualFixture(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "bilingual_i18n.html")
+	summary, err := CollectWithOptions(context.Background(), ctrl, Options{Language: "ru", DisableCDP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, el := range summary.Elements {
+		if el.Role == "button" || el.Role == "link" {
+			texts = append(texts, el.Text)
+		}
+	}
+	sort.Strings(texts)
+	want := []string{"Help", "Special offer", "В корзину", "Войти", "Помощь"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Fatalf("kept %q, want %q", texts, want)
+	}
+}
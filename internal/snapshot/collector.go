@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// fingerprintParents is how many ancestors go into an element fingerprint
+const fingerprintParents = 3
+
+// Collector takes consecutive snapshots of one page and keeps element indices stable:
+// an element seen in the previous snapshot of the same URL keeps its index, only new
+// elements get fresh ones (and Element.New). Without it indices are reassigned 1..N and
+// "index 14" from two steps ago may point at a different element after a small DOM shift.
+type Collector struct {
+	opts Options
+
+	mu      sync.Mutex
+	url     string         // URL of the previous snapshot
+	indices map[string]int // Fingerprint -> index in the previous snapshot
+	next    int            // Next fresh index
+}
+
+// NewCollector creates a collector; use one per browser session
+func NewCollector(opts Options) *Collector {
+	return &Collector{opts: opts}
+}
+
+// Collect takes a snapshot and renumbers its elements against the previous one
+func (c *Collector) Collect(ctx context.Context, ctrl browser.Controller) (Summary, error) {
+	summary, err := collect(ctx, ctrl, c.opts)
+	if err != nil {
+		return summary, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.renumber(&summary)
+	summary.Forms = groupForms(summary.Elements)
+	return summary, nil
+}
+
+// renumber reuses indices of fingerprints seen in the previous snapshot of the same URL.
+// A different URL is a different page: numbering starts over.
+func (c *Collector) renumber(summary *Summary) {
+	prints := fingerprints(summary.Elements)
+	if summary.URL != c.url || c.indices == nil {
+		c.url = summary.URL
+		c.indices = make(map[string]int, len(prints))
+		for i := range summary.Elements {
+			c.indices[prints[i]] = summary.Elements[i].Index
+		}
+		c.next = len(summary.Elements) + 1
+		return
+	}
+	used := make(map[int]bool, len(prints))
+	matched := make([]bool, len(prints))
+	for i, fp := range prints {
+		if idx, ok := c.indices[fp]; ok && !used[idx] {
+			summary.Elements[i].Index = idx
+			used[idx] = true
+			matched[i] = true
+		}
+	}
+	indices := make(map[string]int, len(prints))
+	for i := range summary.Elements {
+		el := &summary.Elements[i]
+		if !matched[i] {
+			el.Index = c.next
+			el.New = true
+			c.next++
+		}
+		indices[prints[i]] = el.Index
+	}
+	c.indices = indices
+}
+
+// fingerprints hashes role + normalized text + selector + frame + parent chain of every
+// element; repeats within one snapshot (identical list rows) get an ordinal suffix
+func fingerprints(elems []Element) []string {
+	byNode := make(map[string]*Element, len(elems))
+	for i := range elems {
+		if elems[i].NodeId != "" {
+			byNode[elems[i].NodeId] = &elems[i]
+		}
+	}
+	prints := make([]string, len(elems))
+	seen := make(map[string]int, len(elems))
+	for i := range elems {
+		h := fnv.New64a()
+		el := &elems[i]
+		fmt.Fprintf(h, "%s|%s|%s|%s", strings.ToLower(el.Role), normalizeText(el.Text), stableSelector(el.Sel), el.FrameURL)
+		parent := byNode[el.ParentId]
+		for depth := 0; parent != nil && depth < fingerprintParents; depth++ {
+			fmt.Fprintf(h, "<%s|%s", strings.ToLower(parent.Role), normalizeText(parent.Text))
+			parent = byNode[parent.ParentId]
+		}
+		fp := fmt.Sprintf("%x", h.Sum64())
+		seen[fp]++
+		if n := seen[fp]; n > 1 {
+			fp = fmt.Sprintf("%s#%d", fp, n)
+		}
+		prints[i] = fp
+	}
+	return prints
+}
+
+// stableSelector drops positional CSS paths from fingerprints: an inserted sibling shifts
+// nth-of-type without the element changing (the parent chain still tells them apart)
+func stableSelector(sel string) string {
+	if strings.Contains(sel, ":nth-of-type(") {
+		return ""
+	}
+	return sel
+}
+
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+// modalTexts is what the modal fixtures must flag: true for elements inside the dialog
+var modalTexts = map[string]bool{"Home": false, "Buy now": false, "Email": true, "Continue": true, "Close": true}
+
+func TestAccessibilityTreeFlagsDialogElements(t *testing.T) {
+	data, err := os.ReadFile("testdata/modal_axtree.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatal(err)
+	}
+	elems, _, err := parseAccessibilityTree(context.Background(), tree, 100, Options{}.actionableRoles(), nil, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDialogFlags(t, elems)
+}
+
+func TestCollectorsFlagDialogElements(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		disableCDP bool
+	}{
+		{"cdp", false},
+		{"js collector", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _ := browsertest.Open(t, "testdata", "modal_form.html")
+			summary, err := CollectWithOptions(context.Background(), ctrl, Options{DisableCDP: tt.disableCDP})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDialogFlags(t, summary.Elements)
+		})
+	}
+}
+
+func checkDialogFlags(t *testing.T, elems []Element) {
+	t.Helper()
+	seen := make(map[string]bool)
+	for _, el := range elems {
+		want, ok := modalTexts[el.Text]
+		if !ok || el.Role == "dialog" {
+			continue
+		}
+		seen[el.Text] = true
+		if el.InDialog != want {
+			t.Errorf("%s %q: InDialog = %v, want %v", el.Role, el.Text, el.InDialog, want)
+		}
+	}
+	for text := range modalTexts {
+		if !seen[text] {
+			t.Errorf("element %q not collected", text)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffListLimit caps elements listed per group in Diff.String
+const diffListLimit = 5
+
+// Diff is what changed between two snapshots of a page
+type Diff struct {
+	OldURL, NewURL     string // Set only when the URL changed
+	OldTitle, NewTitle string // Set only when the title changed
+	Added              []Element
+	Removed            []Element
+	TextChanged        []TextChange
+}
+
+// TextChange is an element that stayed (same role and selector) but whose text changed
+type TextChange struct {
+	Element Element // As in the new snapshot
+	OldText string
+}
+
+// Compare diffs two summaries (a Diff function would clash with the type). Elements are
+// matched by fingerprint first (role, text, selector, frame, ancestors - reordering and
+// stable-index renumbering don't matter), then leftovers by role + selector, which catches
+// text updates (counters, status labels).
+func Compare(prev, cur Summary) Diff {
+	var d Diff
+	if prev.URL != cur.URL {
+		d.OldURL, d.NewURL = prev.URL, cur.URL
+	}
+	if prev.Title != cur.Title {
+		d.OldTitle, d.NewTitle = prev.Title, cur.Title
+	}
+
+	oldPrints := make(map[string][]int)
+	for i, fp := range fingerprints(prev.Elements) {
+		oldPrints[fp] = append(oldPrints[fp], i)
+	}
+	matchedOld := make([]bool, len(prev.Elements))
+	var unmatched []int
+	for i, fp := range fingerprints(cur.Elements) {
+		if idx := oldPrints[fp]; len(idx) > 0 {
+			matchedOld[idx[0]] = true
+			oldPrints[fp] = idx[1:]
+			continue
+		}
+		unmatched = append(unmatched, i)
+	}
+
+	// Role + selector of prev elements left over, for text changes
+	bySel := make(map[string][]int)
+	for i, el := range prev.Elements {
+		if !matchedOld[i] && el.Sel != "" {
+			key := selKey(el)
+			bySel[key] = append(bySel[key], i)
+		}
+	}
+	for _, i := range unmatched {
+		el := cur.Elements[i]
+		if el.Sel != "" {
+			key := selKey(el)
+			if idx := bySel[key]; len(idx) > 0 {
+				matchedOld[idx[0]] = true
+				bySel[key] = idx[1:]
+				d.TextChanged = append(d.TextChanged, TextChange{Element: el, OldText: prev.Elements[idx[0]].Text})
+				continue
+			}
+		}
+		d.Added = append(d.Added, el)
+	}
+	for i, el := range prev.Elements {
+		if !matchedOld[i] {
+			d.Removed = append(d.Removed, el)
+		}
+	}
+	return d
+}
+
+func selKey(el Element) string {
+	return strings.ToLower(el.Role) + "|" + el.FrameURL + "|" + el.Sel
+}
+
+// ChangedIndices are the indices of added and text-changed elements of the new snapshot.
+// Nil for a nil diff or another page (URL changed), where every element counts as new.
+func (d *Diff) ChangedIndices() map[int]bool {
+	if d == nil || d.NewURL != "" {
+		return nil
+	}
+	changed := make(map[int]bool, len(d.Added)+len(d.TextChanged))
+	for _, el := range d.Added {
+		changed[el.Index] = true
+	}
+	for _, tc := range d.TextChanged {
+		changed[tc.Element.Index] = true
+	}
+	return changed
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return d.NewURL == "" && d.NewTitle == "" && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.TextChanged) == 0
+}
+
+// String renders the diff compactly for the planner: navigation, new elements grouped by
+// role with their indices ("3 new button: [12] 'Оплатить', ..."), removed ones, text changes
+func (d Diff) String() string {
+	if d.Empty() {
+		return "no visible changes"
+	}
+	var lines []string
+	if d.NewURL != "" {
+		// Another page: element lists are not comparable, the full list follows anyway
+		return fmt.Sprintf("navigated from %s to %s", d.OldURL, d.NewURL)
+	}
+	if d.NewTitle != "" {
+		lines = append(lines, fmt.Sprintf("title changed: %q -> %q", d.OldTitle, d.NewTitle))
+	}
+	for _, group := range groupByRole(d.Added) {
+		parts := make([]string, 0, diffListLimit)
+		for i, el := range group {
+			if i == diffListLimit {
+				parts = append(parts, fmt.Sprintf("+%d more", len(group)-diffListLimit))
+				break
+			}
+			parts = append(parts, fmt.Sprintf("[%d] '%s'", el.Index, truncateRunes(el.Text, 40)))
+		}
+		lines = append(lines, fmt.Sprintf("%d new %s: %s", len(group), group[0].Role, strings.Join(parts, ", ")))
+	}
+	if len(d.Removed) > 0 {
+		parts := make([]string, 0, diffListLimit)
+		for i, el := range d.Removed {
+			if i == diffListLimit {
+				parts = append(parts, fmt.Sprintf("+%d more", len(d.Removed)-diffListLimit))
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s '%s'", el.Role, truncateRunes(el.Text, 40)))
+		}
+		lines = append(lines, fmt.Sprintf("%d gone: %s", len(d.Removed), strings.Join(parts, ", ")))
+	}
+	for i, tc := range d.TextChanged {
+		if i == diffListLimit {
+			lines = append(lines, fmt.Sprintf("+%d more text changes", len(d.TextChanged)-diffListLimit))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("[%d] %s text: '%s' -> '%s'", tc.Element.Index, tc.Element.Role,
+			truncateRunes(tc.OldText, 40), truncateRunes(tc.Element.Text, 40)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// groupByRole groups elements by role, largest group first
+func groupByRole(elems []Element) [][]Element {
+	byRole := make(map[string][]Element)
+	var roles []string
+	for _, el := range elems {
+		role := strings.ToLower(el.Role)
+		if _, ok := byRole[role]; !ok {
+			roles = append(roles, role)
+		}
+		byRole[role] = append(byRole[role], el)
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return len(byRole[roles[i]]) > len(byRole[roles[j]]) })
+	groups := make([][]Element, len(roles))
+	for i, role := range roles {
+		groups[i] = byRole[role]
+	}
+	return groups
+}
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func el(index int, role, text, sel string) Element {
+	return Element{Index: index, Role: role, Text: text, Sel: sel}
+}
+
+// rows builds a virtualized list window: rows first..last, indices starting at base
+func rows(first, last, base int) []Element {
+	var out []Element
+	for i := first; i <= last; i++ {
+		out = append(out, el(base+i-first, "row", fmt.Sprintf("Order #%d", i), fmt.Sprintf("#row-%d", i)))
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	page := "https://shop.example.com/cart"
+	base := []Element{el(1, "link", "Home", "#home"), el(2, "button", "Checkout", "#checkout"), el(3, "text", "Total: 10 €", "#total")}
+
+	tests := []struct {
+		name        string
+		prev, cur   Summary
+		added       []string // Texts
+		removed     []string
+		textChanged []string // "old -> new"
+		render      []string // Substrings of String()
+	}{
+		{
+			name:   "identical",
+			prev:   Summary{URL: page, Elements: base},
+			cur:    Summary{URL: page, Elements: base},
+			render: []string{"no visible changes"},
+		},
+		{
+			name:   "reordered and renumbered",
+			prev:   Summary{URL: page, Elements: base},
+			cur:    Summary{URL: page, Elements: []Element{el(7, "text", "Total: 10 €", "#total"), el(8, "link", "Home", "#home"), el(9, "button", "Checkout", "#checkout")}},
+			render: []string{"no visible changes"},
+		},
+		{
+			name:   "buttons appear",
+			prev:   Summary{URL: page, Elements: base},
+			cur:    Summary{URL: page, Elements: append(append([]Element(nil), base...), el(12, "button", "Оплатить", "#pay"), el(13, "button", "Отмена", "#cancel"))},
+			added:  []string{"Оплатить", "Отмена"},
+			render: []string{"2 new button: [12] 'Оплатить', [13] 'Отмена'"},
+		},
+		{
+			name:    "dialog closed",
+			prev:    Summary{URL: page, Elements: append(append([]Element(nil), base...), el(4, "button", "Accept cookies", "#accept"))},
+			cur:     Summary{URL: page, Elements: base},
+			removed: []string{"Accept cookies"},
+			render:  []string{"1 gone: button 'Accept cookies'"},
+		},
+		{
+			name:        "counter updated in place",
+			prev:        Summary{URL: page, Elements: base},
+			cur:         Summary{URL: page, Elements: []Element{base[0], base[1], el(3, "text", "Total: 25 €", "#total")}},
+			textChanged: []string{"Total: 10 € -> Total: 25 €"},
+			render:      []string{"[3] text text: 'Total: 10 €' -> 'Total: 25 €'"},
+		},
+		{
+			name:    "virtualized list scrolled",
+			prev:    Summary{URL: page, Elements: rows(1, 10, 1)},
+			cur:     Summary{URL: page, Elements: rows(6, 15, 6)},
+			added:   []string{"Order #11", "Order #12", "Order #13", "Order #14", "Order #15"},
+			removed: []string{"Order #1", "Order #2", "Order #3", "Order #4", "Order #5"},
+			render:  []string{"5 new row: [11] 'Order #11'", "5 gone: row 'Order #1'"},
+		},
+		{
+			name:   "identical rows keep their count",
+			prev:   Summary{URL: page, Elements: []Element{el(1, "button", "Add", ""), el(2, "button", "Add", "")}},
+			cur:    Summary{URL: page, Elements: []Element{el(1, "button", "Add", ""), el(2, "button", "Add", ""), el(3, "button", "Add", "")}},
+			added:  []string{"Add"},
+			render: []string{"1 new button: [3] 'Add'"},
+		},
+		{
+			name:   "title changed",
+			prev:   Summary{URL: page, Title: "Cart (1)", Elements: base},
+			cur:    Summary{URL: page, Title: "Cart (2)", Elements: base},
+			render: []string{`title changed: "Cart (1)" -> "Cart (2)"`},
+		},
+		{
+			name:    "navigation",
+			prev:    Summary{URL: page, Elements: base},
+			cur:     Summary{URL: "https://shop.example.com/pay", Elements: []Element{el(1, "button", "Pay", "#pay")}},
+			added:   []string{"Pay"},
+			removed: []string{"Home", "Checkout", "Total: 10 €"},
+			render:  []string{"navigated from https://shop.example.com/cart to https://shop.example.com/pay"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Compare(tt.prev, tt.cur)
+			if got := texts(d.Added); got != strings.Join(tt.added, ",") {
+				t.Errorf("added = %q, want %q", got, strings.Join(tt.added, ","))
+			}
+			if got := texts(d.Removed); got != strings.Join(tt.removed, ",") {
+				t.Errorf("removed = %q, want %q", got, strings.Join(tt.removed, ","))
+			}
+			var changes []string
+			for _, tc := range d.TextChanged {
+				changes = append(changes, tc.OldText+" -> "+tc.Element.Text)
+			}
+			if got := strings.Join(changes, ","); got != strings.Join(tt.textChanged, ",") {
+				t.Errorf("text changes = %q, want %q", got, strings.Join(tt.textChanged, ","))
+			}
+			rendered := d.String()
+			for _, want := range tt.render {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("String() = %q, missing %q", rendered, want)
+				}
+			}
+		})
+	}
+}
+
+func texts(elems []Element) string {
+	var out []string
+	for _, e := range elems {
+		out = append(out, e.Text)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestDiffStringLimitsLists(t *testing.T) {
+	cur := Summary{URL: "https://example.com", Elements: rows(1, 12, 1)}
+	rendered := Compare(Summary{URL: "https://example.com"}, cur).String()
+	if !strings.Contains(rendered, "12 new row:") || !strings.Contains(rendered, "+7 more") {
+		t.Fatalf("String() = %q", rendered)
+	}
+	if strings.Contains(rendered, "Order #6") {
+		t.Fatalf("more than %d elements listed: %q", diffListLimit, rendered)
+	}
+}
+
+func TestChangedIndices(t *testing.T) {
+	page := "https://example.com"
+	prev := Summary{URL: page, Elements: []Element{el(1, "text", "a", "#a"), el(2, "text", "b", "#b")}}
+	cur := Summary{URL: page, Elements: []Element{el(1, "text", "a", "#a"), el(2, "text", "b2", "#b"), el(3, "text", "c", "#c")}}
+	d := Compare(prev, cur)
+	got := d.ChangedIndices()
+	if len(got) != 2 || !got[2] || !got[3] || got[1] {
+		t.Fatalf("ChangedIndices() = %v, want {2, 3}", got)
+	}
+
+	moved := Compare(prev, Summary{URL: page + "/next", Elements: cur.Elements})
+	if moved.ChangedIndices() != nil {
+		t.Fatal("another page must list every element again")
+	}
+	var none *Diff
+	if none.ChangedIndices() != nil {
+		t.Fatal("nil diff (first step) must list every element")
+	}
+}
@@ -0,0 +1,198 @@
+package snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// maxSelectorDepth bounds CSS paths built for AX nodes; deeper paths are too fragile to be worth it
+const maxSelectorDepth = 8
+
+// cssIdent matches ids usable as #id without escaping
+var cssIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// stableAttrs identify an element on their own when unique in the document, in order of preference
+var stableAttrs = []string{"data-testid", "data-test", "data-qa", "data-cy"}
+
+// domIndex is the main frame DOM fetched once per snapshot (DOM.getDocument) to turn AX nodes
+// into real selectors via backendDOMNodeId - one round trip instead of DOM.describeNode per node
+type domIndex struct {
+	nodes  map[int]*domNode // By backendNodeId
+	counts map[string]int   // "attr=value" and "tag|name=value" occurrences, for uniqueness
+}
+
+type domNode struct {
+	tag      string // Lower-case local name
+	attrs    map[string]string
+	parent   *domNode
+	children []*domNode // Element children
+}
+
+// fetchDOMIndex reads the main document tree (not piercing shadow roots and iframes:
+// CSS paths can't cross them, those elements keep role-based selectors)
+func fetchDOMIndex(session playwright.CDPSession) (*domIndex, error) {
+	res, err := session.Send("DOM.getDocument", map[string]interface{}{"depth": -1, "pierce": false})
+	if err != nil {
+		return nil, err
+	}
+	resMap, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid DOM.getDocument result")
+	}
+	root, ok := resMap["root"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no root in DOM.getDocument result")
+	}
+	d := &domIndex{nodes: make(map[int]*domNode), counts: make(map[string]int)}
+
+	type item struct {
+		raw    map[string]interface{}
+		parent *domNode
+	}
+	stack := []item{{raw: root}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		parent := it.parent
+		if nodeType, _ := it.raw["nodeType"].(float64); nodeType == 1 {
+			n := &domNode{tag: strings.ToLower(stringField(it.raw, "localName")), attrs: domAttributes(it.raw), parent: it.parent}
+			if id, ok := it.raw["backendNodeId"].(float64); ok {
+				d.nodes[int(id)] = n
+			}
+			if it.parent != nil {
+				it.parent.children = append(it.parent.children, n)
+			}
+			d.count(n)
+			parent = n
+		}
+		children, _ := it.raw["children"].([]interface{})
+		// Reverse push keeps document order of children
+		for i := len(children) - 1; i >= 0; i-- {
+			if child, ok := children[i].(map[string]interface{}); ok {
+				stack = append(stack, item{raw: child, parent: parent})
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d *domIndex) count(n *domNode) {
+	if id := n.attrs["id"]; id != "" {
+		d.counts["id="+id]++
+	}
+	for _, attr := range stableAttrs {
+		if v := n.attrs[attr]; v != "" {
+			d.counts[attr+"="+v]++
+		}
+	}
+	if name := n.attrs["name"]; name != "" {
+		d.counts[n.tag+"|name="+name]++
+	}
+}
+
+// selector builds a CSS selector for the DOM node behind an AX node: a unique id or test
+// attribute of the node, otherwise a child path (tag:nth-of-type) anchored at the nearest
+// ancestor that has one. "" when the node is unknown or the path would be too deep.
+func (d *domIndex) selector(backendID int) string {
+	n := d.nodes[backendID]
+	if n == nil || n.tag == "" {
+		return ""
+	}
+	if sel := d.uniqueSelector(n); sel != "" {
+		return sel
+	}
+	var parts []string
+	for cur := n; cur != nil; cur = cur.parent {
+		if cur != n {
+			if sel := d.uniqueSelector(cur); sel != "" {
+				parts = append(parts, sel)
+				break
+			}
+		}
+		if cur.tag == "body" || cur.tag == "html" || cur.parent == nil {
+			parts = append(parts, cur.tag)
+			break
+		}
+		if len(parts) == maxSelectorDepth {
+			return ""
+		}
+		parts = append(parts, cur.tag+nthOfType(cur))
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+// uniqueSelector returns #id, [data-testid="..."] or tag[name="..."] when unique in the document
+func (d *domIndex) uniqueSelector(n *domNode) string {
+	if id := n.attrs["id"]; id != "" && d.counts["id="+id] == 1 {
+		if cssIdent.MatchString(id) {
+			return "#" + id
+		}
+		if quotable(id) {
+			return fmt.Sprintf(`[id="%s"]`, id)
+		}
+	}
+	for _, attr := range stableAttrs {
+		if v := n.attrs[attr]; v != "" && d.counts[attr+"="+v] == 1 && quotable(v) {
+			return fmt.Sprintf(`[%s="%s"]`, attr, v)
+		}
+	}
+	if name := n.attrs["name"]; name != "" && d.counts[n.tag+"|name="+name] == 1 && quotable(name) {
+		return fmt.Sprintf(`%s[name="%s"]`, n.tag, name)
+	}
+	return ""
+}
+
+// nthOfType is ":nth-of-type(k)" when the parent has several children with the node's tag
+func nthOfType(n *domNode) string {
+	if n.parent == nil {
+		return ""
+	}
+	k, total := 0, 0
+	for _, sib := range n.parent.children {
+		if sib.tag != n.tag {
+			continue
+		}
+		total++
+		if sib == n {
+			k = total
+		}
+	}
+	if total <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(":nth-of-type(%d)", k)
+}
+
+// quotable reports whether v can go into a double-quoted attribute selector as is
+func quotable(v string) bool {
+	return !strings.ContainsAny(v, "\"\\\n\r")
+}
+
+// domAttributes reads the flat [name, value, name, value, ...] attribute list of a DOM node
+func domAttributes(raw map[string]interface{}) map[string]string {
+	list, _ := raw["attributes"].([]interface{})
+	attrs := make(map[string]string, len(list)/2)
+	for i := 0; i+1 < len(list); i += 2 {
+		name, _ := list[i].(string)
+		value, _ := list[i+1].(string)
+		attrs[name] = value
+	}
+	return attrs
+}
+
+func stringField(raw map[string]interface{}, key string) string {
+	s, _ := raw[key].(string)
+	return s
+}
+
+// axBackendNodeID reads backendDOMNodeId of an AX node, 0 if absent
+func axBackendNodeID(node map[string]interface{}) int {
+	id, _ := node["backendDOMNodeId"].(float64)
+	return int(id)
+}
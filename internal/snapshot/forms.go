@@ -0,0 +1,150 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Form is one <form> of the page: its fields and the buttons that submit it
+type Form struct {
+	Name    string      // Element.Form, with " (iframe)" for forms of child frames
+	Fields  []FormField // In document order
+	Submits []FormField // Submit buttons; plain buttons of the form when it has none
+}
+
+// FormField is a form element of the snapshot
+type FormField struct {
+	Index    int
+	Label    string // Element text: aria-label, aria-labelledby, <label for> or placeholder
+	Type     string // InputType, or the role when the element is not a native control
+	Required bool
+	Value    string // Current value ("***" for passwords)
+	Checked  bool
+}
+
+// fieldRoles are the roles of form fields that are not native inputs
+var fieldRoles = map[string]bool{
+	"textbox": true, "searchbox": true, "combobox": true, "checkbox": true, "radio": true,
+	"spinbutton": true, "slider": true, "listbox": true, "switch": true,
+}
+
+// groupForms builds Summary.Forms from Element.Form; forms without fields are left out
+func groupForms(elements []Element) []Form {
+	var forms []Form
+	var buttons [][]FormField // Buttons without a type, per form - submits of forms that have none
+	pos := make(map[string]int)
+	for _, el := range elements {
+		if el.Form == "" {
+			continue
+		}
+		key := el.FrameURL + "\x00" + el.Form
+		i, ok := pos[key]
+		if !ok {
+			name := el.Form
+			if el.FrameURL != "" {
+				name += " (iframe)"
+			}
+			forms = append(forms, Form{Name: name})
+			buttons = append(buttons, nil)
+			i = len(forms) - 1
+			pos[key] = i
+		}
+		field := FormField{Index: el.Index, Label: firstTextLine(el.Text), Type: el.InputType, Required: el.Required, Value: el.Value, Checked: el.Checked}
+		if field.Type == "" {
+			field.Type = strings.ToLower(el.Role)
+		}
+		switch {
+		case el.InputType == "submit" || el.InputType == "image":
+			forms[i].Submits = append(forms[i].Submits, field)
+		case el.InputType == "button" || el.InputType == "reset":
+		case strings.EqualFold(el.Role, "button"):
+			buttons[i] = append(buttons[i], field)
+		case el.InputType != "" || fieldRoles[strings.ToLower(el.Role)]:
+			forms[i].Fields = append(forms[i].Fields, field)
+		}
+	}
+	kept := forms[:0]
+	for i, f := range forms {
+		if len(f.Fields) == 0 {
+			continue
+		}
+		if len(f.Submits) == 0 {
+			f.Submits = buttons[i]
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// formLabeler gives the forms of one CDP snapshot unique labels (see collectScript formLabel)
+type formLabeler struct {
+	labels map[*domNode]string
+	used   map[string]bool
+}
+
+func (l *formLabeler) label(form *domNode, axName string) string {
+	if form == nil {
+		return ""
+	}
+	if label, ok := l.labels[form]; ok {
+		return label
+	}
+	if l.labels == nil {
+		l.labels = make(map[*domNode]string)
+		l.used = make(map[string]bool)
+	}
+	name := strings.TrimSpace(axName)
+	for _, attr := range []string{"aria-label", "name", "id"} {
+		if name == "" {
+			name = strings.TrimSpace(form.attrs[attr])
+		}
+	}
+	name = truncateRunes(firstTextLine(name), 40)
+	if name == "" {
+		name = fmt.Sprintf("form %d", len(l.labels)+1)
+	}
+	label := name
+	for n := 2; l.used[label]; n++ {
+		label = fmt.Sprintf("%s #%d", name, n)
+	}
+	l.used[label] = true
+	l.labels[form] = label
+	return label
+}
+
+// form is the nearest <form> or role=form ancestor of the node
+func (n *domNode) form() *domNode {
+	for cur := n.parent; cur != nil; cur = cur.parent {
+		if cur.tag == "form" || cur.attrs["role"] == "form" {
+			return cur
+		}
+	}
+	return nil
+}
+
+// inputType mirrors Element.InputType for a DOM node: input and button types with their defaults
+func (n *domNode) inputType() string {
+	switch n.tag {
+	case "input":
+		if t := strings.ToLower(n.attrs["type"]); t != "" {
+			return t
+		}
+		return "text"
+	case "button":
+		if t := strings.ToLower(n.attrs["type"]); t != "" {
+			return t
+		}
+		return "submit"
+	case "select", "textarea":
+		return n.tag
+	}
+	return ""
+}
+
+func firstTextLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+// twoForms is Summary.Forms of testdata/two_forms.html: label and type of each field,
+// labels of the submits. The orphan inputs and the field-less logout form are left out.
+var twoForms = []struct {
+	name    string
+	fields  [][2]string
+	submits []string
+}{
+	{"Sign in", [][2]string{{"Email", "email"}, {"Password", "password"}, {"Remember me", "checkbox"}}, []string{"Войти"}},
+	{"Newsletter", [][2]string{{"Your email", "email"}}, []string{"Subscribe"}},
+}
+
+func TestGroupForms(t *testing.T) {
+	data, err := os.ReadFile("testdata/two_forms_elements.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var elems []Element
+	if err := json.Unmarshal(data, &elems); err != nil {
+		t.Fatal(err)
+	}
+	forms := groupForms(elems)
+	checkTwoForms(t, forms)
+	if email := forms[0].Fields[0]; email.Index != 3 || !email.Required {
+		t.Errorf("Email field = %+v, want index 3, required", email)
+	}
+	if submit := forms[1].Submits[0]; submit.Index != 9 || submit.Type != "button" {
+		t.Errorf("Newsletter submit = %+v, want the role=button fallback [9]", submit)
+	}
+
+	// The same form name in a child frame is a different form
+	frame := append([]Element(nil), elems[2:5]...)
+	for i := range frame {
+		frame[i].Index += 20
+		frame[i].FrameURL = "https://accounts.example.com/embed"
+	}
+	forms = groupForms(append(elems, frame...))
+	if len(forms) != 3 || forms[2].Name != "Sign in (iframe)" || len(forms[2].Fields) != 3 || forms[2].Submits != nil {
+		t.Errorf("forms with a child frame = %+v", forms)
+	}
+}
+
+func TestCollectorsGroupTwoForms(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		disableCDP bool
+	}{
+		{"cdp", false},
+		{"js collector", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _ := browsertest.Open(t, "testdata", "two_forms.html")
+			summary, err := NewCollector(Options{DisableCDP: tt.disableCDP}).Collect(context.Background(), ctrl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTwoForms(t, summary.Forms)
+			for _, el := range summary.Elements {
+				if (el.Text == "Search site" || el.Text == "Coupon") && el.Form != "" {
+					t.Errorf("orphan input %q has Form %q", el.Text, el.Form)
+				}
+			}
+		})
+	}
+}
+
+func checkTwoForms(t *testing.T, forms []Form) {
+	t.Helper()
+	if len(forms) != len(twoForms) {
+		t.Fatalf("got %d forms, want %d: %+v", len(forms), len(twoForms), forms)
+	}
+	for i, want := range twoForms {
+		got := forms[i]
+		if got.Name != want.name {
+			t.Errorf("form %d: name %q, want %q", i, got.Name, want.name)
+		}
+		if len(got.Fields) != len(want.fields) {
+			t.Errorf("form %q: fields %+v, want %v", want.name, got.Fields, want.fields)
+		} else {
+			for j, f := range want.fields {
+				if got.Fields[j].Label != f[0] || got.Fields[j].Type != f[1] {
+					t.Errorf("form %q field %d: %q (%s), want %q (%s)", want.name, j, got.Fields[j].Label, got.Fields[j].Type, f[0], f[1])
+				}
+			}
+		}
+		var submits []string
+		for _, s := range got.Submits {
+			submits = append(submits, s.Label)
+		}
+		if len(submits) != len(want.submits) || (len(submits) > 0 && submits[0] != want.submits[0]) {
+			t.Errorf("form %q: submits %q, want %q", want.name, submits, want.submits)
+		}
+	}
+}
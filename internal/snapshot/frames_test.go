@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+func TestMergeElements(t *testing.T) {
+	main := []Element{
+		{Index: 1, Role: "link", Text: "Inbox", Sel: "#inbox"},
+		{Index: 2, Role: "button", Text: "Compose", Sel: "#compose"},
+		{Index: 3, Role: "img", Sel: "#logo"},
+	}
+	frames := []Element{
+		{Role: "link", Text: "Your order  has shipped", Sel: "a:nth-of-type(1)", FrameURL: "https://mail.example.com/list"},
+		{Role: "Button", Text: "compose", Sel: "button", FrameURL: "https://mail.example.com/list"}, // Seen in the main frame
+		{Role: "link", Text: "your order has shipped", Sel: "a:nth-of-type(2)", FrameURL: "https://mail.example.com/list"},
+		{Role: "img", Sel: "#avatar", FrameURL: "https://mail.example.com/list"}, // No text: the selector tells it apart
+	}
+	var got []string
+	for _, el := range mergeElements(main, frames) {
+		got = append(got, el.Role+":"+el.Sel)
+	}
+	want := []string{"link:#inbox", "button:#compose", "img:#logo", "link:a:nth-of-type(1)", "img:#avatar"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("merged %v, want %v", got, want)
+	}
+}
+
+func TestChildFrameCoverage(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "frame_inbox.html")
+	ctx := context.Background()
+	if err := ctrl.WaitFor(ctx, "iframe#messages", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	page := ctrl.Page()
+	rows := []string{"Invoice for May from Lumo Shop", "Your order has shipped", "Team meeting moved to Friday"}
+
+	// Before: the CDP tree of the main frame alone misses the message list
+	opts := Options{}.withDefaults()
+	mainOnly, _, err := collectMainFrame(ctx, page, opts.MaxElements, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countTexts(mainOnly, rows); n != 0 {
+		t.Fatalf("main frame pass has %d message rows, want none:\n%s", n, elementList(mainOnly))
+	}
+
+	// After: the child frame is collected with the JS collector and merged
+	summary, err := CollectWithOptions(ctx, ctrl, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countTexts(summary.Elements, rows); n != len(rows) {
+		t.Fatalf("snapshot has %d of %d message rows:\n%s", n, len(rows), elementList(summary.Elements))
+	}
+	compose := 0
+	for _, el := range summary.Elements {
+		if el.Role == "button" && el.Text == "Compose" {
+			compose++
+		}
+		if strings.Contains(el.Text, "order has shipped") && !strings.HasSuffix(el.FrameURL, "/frame_inbox_list.html") {
+			t.Errorf("row %q has FrameURL %q, want the iframe", el.Text, el.FrameURL)
+		}
+	}
+	if compose != 1 {
+		t.Errorf("%d Compose buttons, want the iframe copy merged away:\n%s", compose, elementList(summary.Elements))
+	}
+}
+
+func countTexts(elems []Element, texts []string) int {
+	n := 0
+	for _, want := range texts {
+		for _, el := range elems {
+			if el.Text == want {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+func elementList(elems []Element) string {
+	var b strings.Builder
+	for _, el := range elems {
+		fmt.Fprintf(&b, "%s %q frame=%s\n", el.Role, el.Text, el.FrameURL)
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+const (
+	// OCRMarker prefixes text that was recognized from a screenshot instead of read from the DOM
+	OCRMarker = "(OCR)"
+
+	ocrMaxElements    = 5    // Run OCR only when the DOM yields fewer elements than this
+	ocrScreenshotTime = 5000 // ms
+)
+
+// OCR recognizes text on a rendered page screenshot (PNG).
+// Used as a fallback for canvas dashboards and PDF viewers where the DOM has no readable text.
+type OCR interface {
+	Recognize(ctx context.Context, image []byte, lang string) (string, error)
+}
+
+type tesseractOCR struct {
+	bin string
+}
+
+// NewTesseractOCR returns OCR backed by the tesseract binary.
+// Returns nil if tesseract is not installed - OCR fallback is disabled then.
+func NewTesseractOCR() OCR {
+	bin, err := exec.LookPath("tesseract")
+	if err != nil {
+		return nil
+	}
+	return &tesseractOCR{bin: bin}
+}
+
+func (t *tesseractOCR) Recognize(ctx context.Context, image []byte, lang string) (string, error) {
+	tmp, err := os.CreateTemp("", "agent-ocr-*.png")
+	if err != nil {
+		return "", fmt.Errorf("ocr temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(image); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("ocr write image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("ocr write image: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.bin, tmp.Name(), "stdout", "-l", tesseractLang(lang))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// tesseractLang maps task language hint to tesseract traineddata names
+// English is always added because UI chrome is often English even on localized pages
+func tesseractLang(lang string) string {
+	switch strings.ToLower(strings.TrimSpace(lang)) {
+	case "ru":
+		return "rus+eng"
+	default:
+		return "eng"
+	}
+}
+
+// RecognizeViewport captures a screenshot of the current viewport and runs it through OCR
+func RecognizeViewport(ctx context.Context, ctrl browser.Controller, engine OCR, lang string) (string, error) {
+	if engine == nil {
+		return "", fmt.Errorf("ocr is not available (tesseract not installed)")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	image, err := ctrl.Page().Screenshot(playwright.PageScreenshotOptions{
+		Timeout: playwright.Float(ocrScreenshotTime),
+	})
+	if err != nil {
+		return "", fmt.Errorf("screenshot for ocr: %w", err)
+	}
+	return engine.Recognize(ctx, image, lang)
+}
+
+// needsOCR reports whether the DOM gave the agent nothing to read
+func needsOCR(visible string, elems []Element) bool {
+	return strings.TrimSpace(visible) == "" && len(elems) < ocrMaxElements
+}
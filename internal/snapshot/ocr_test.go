@@ -0,0 +1,87 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+// stubOCR returns a fixed text and remembers the screenshots it was given
+type stubOCR struct {
+	text   string
+	images [][]byte
+	langs  []string
+}
+
+func (s *stubOCR) Recognize(_ context.Context, image []byte, lang string) (string, error) {
+	s.images = append(s.images, image)
+	s.langs = append(s.langs, lang)
+	return s.text, nil
+}
+
+func TestNeedsOCR(t *testing.T) {
+	few := []Element{{Index: 1, Role: "button", Text: "Export"}}
+	many := make([]Element, ocrMaxElements)
+	tests := []struct {
+		visible string
+		elems   []Element
+		want    bool
+	}{
+		{"", nil, true},
+		{" \n\t", few, true},
+		{"", many, false},
+		{"Revenue 1284000", few, false},
+	}
+	for _, tt := range tests {
+		if got := needsOCR(tt.visible, tt.elems); got != tt.want {
+			t.Errorf("needsOCR(%q, %d elements) = %v, want %v", tt.visible, len(tt.elems), got, tt.want)
+		}
+	}
+}
+
+func TestTesseractLang(t *testing.T) {
+	for lang, want := range map[string]string{"ru": "rus+eng", " RU ": "rus+eng", "en": "eng", "": "eng"} {
+		if got := tesseractLang(lang); got != want {
+			t.Errorf("tesseractLang(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+// TestTesseractOCRFixture recognizes a rendered dashboard image; it needs the tesseract binary
+func TestTesseractOCRFixture(t *testing.T) {
+	engine := NewTesseractOCR()
+	if engine == nil {
+		t.Skip("tesseract is not installed")
+	}
+	image, err := os.ReadFile("testdata/ocr_dashboard.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := engine.Recognize(context.Background(), image, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Sales Dashboard", "Revenue", "1284000", "Orders", "3412"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("recognized %q, missing %q", text, want)
+		}
+	}
+}
+
+func TestCanvasPageFallsBackToOCR(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "canvas_dashboard.html")
+	engine := &stubOCR{text: "Sales Dashboard\nRevenue 1284000"}
+	summary, err := CollectWithOptions(context.Background(), ctrl, Options{OCR: engine, Language: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(engine.images) != 1 || len(engine.images[0]) == 0 || engine.langs[0] != "en" {
+		t.Fatalf("OCR calls: %d images, langs %q - want one viewport screenshot", len(engine.images), engine.langs)
+	}
+	if summary.Visible != OCRMarker+" Sales Dashboard\nRevenue 1284000" {
+		t.Errorf("Visible = %q, want the marked OCR text", summary.Visible)
+	}
+}
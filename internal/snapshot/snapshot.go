@@ -8,15 +8,45 @@ import (
 	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog"
 
 	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
 )
 
+// maxCollectFrames bounds how many child frames the JS collector visits per snapshot (ads, trackers)
+const maxCollectFrames = 10
+
+const (
+	// parseBatchSize is how many AX nodes are parsed between checks of the snapshot deadline
+	parseBatchSize = 1000
+	// maxAXDepth bounds parent walks of the AX tree (guards against malformed cyclic trees)
+	maxAXDepth = 1000
+)
+
+// Collection defaults, used for zero Options fields
+const (
+	defaultMaxElements       = 200 // Reduced from 500 to 200 for speed
+	defaultMaxVisibleChars   = 1200
+	defaultMaxNonInteractive = 50
+)
+
+// defaultActionableRoles are always listed; other roles compete for MaxNonInteractive slots
+var defaultActionableRoles = []string{
+	"button", "link", "textbox", "checkbox",
+	"radio", "radiogroup", "combobox", "listitem", "menuitem",
+	"tab", "option", "article", "row",
+	"list", "listbox", "treeitem", "cell",
+}
+
 // Element describes minimal info about interactive node.
 type Element struct {
-	Index      int    `json:"index"`                 // Interactive index (1-based, like browser-use)
-	Role       string `json:"role"`                  // Role from CDP (link, button, etc.)
-	Text       string `json:"text"`                  // Text content
+	Index int    `json:"index"` // Interactive index (1-based, like browser-use)
+	Role  string `json:"role"`  // Role from CDP (link, button, etc.)
+	Text  string `json:"text"`  // Text content
+	// TextSource says where Text came from when it is not the visible text of the element
+	// (icon-only buttons): "label" (aria-label/aria-labelledby), "title", "alt" or "description"
+	// (aria-describedby); "" for visible text and field labels
+	TextSource string `json:"text_source,omitempty"`
 	Attr       string `json:"attr"`                  // Attributes
 	BBox       string `json:"bbox"`                  // Bounding box
 	Sel        string `json:"selector"`              // CSS selector
@@ -24,17 +54,70 @@ type Element struct {
 	Depth      int    `json:"depth"`                 // Depth in hierarchy (0 = root, for indentation)
 	NodeId     string `json:"node_id"`               // CDP node ID (for building hierarchy)
 	ParentId   string `json:"parent_id"`             // Parent node ID (for building hierarchy)
+	Disabled   bool   `json:"disabled,omitempty"`    // Element is disabled (native disabled or aria-disabled)
+	Value      string `json:"value,omitempty"`       // Current value of a form field ("***" for passwords)
+	Checked    bool   `json:"checked,omitempty"`     // Checkbox, radio or switch is checked (native or aria-checked)
+	Expanded   bool   `json:"expanded,omitempty"`    // aria-expanded="true" (open menu, combobox, accordion)
+	Focused    bool   `json:"focused,omitempty"`     // Element has keyboard focus
+	InDialog   bool   `json:"in_dialog,omitempty"`   // Element is inside an open dialog/alertdialog/aria-modal container
+	Hidden     bool   `json:"hidden,omitempty"`      // Has a box but is not rendered (visibility/opacity), JS collector only
+	// FrameURL is the URL of the child frame the element was collected from, "" for the main frame.
+	// Sel and BBox are relative to that frame's document and viewport.
+	FrameURL string `json:"frame_url,omitempty"`
+	// New marks elements that were not in the previous snapshot of the page (Collector)
+	New bool `json:"new,omitempty"`
+	// Form is the label of the <form> the element belongs to (its name, heading or "form N"),
+	// unique within the collected document; "" outside forms. See Summary.Forms.
+	Form      string `json:"form,omitempty"`
+	InputType string `json:"input_type,omitempty"` // Type of <input>/<button> ("email", "submit"), "select" or "textarea"
+	Required  bool   `json:"required,omitempty"`   // required or aria-required="true"
 }
 
 // Summary is a compact view of current page.
 type Summary struct {
 	URL       string
 	Title     string
+	Lang      string // Declared <html lang>, "" if the page doesn't set it
 	Visible   string
 	Elements  []Element
 	PageStats PageStatistics // Page statistics like browser-use
+	// Viewport is set for Options.ViewportOnly snapshots: what was left out below the fold
+	Viewport *ViewportInfo
+	// Partial means the snapshot deadline cut the accessibility tree parse short:
+	// Elements holds what was parsed, more exist further down the page
+	Partial bool
+	// Forms groups form fields and their submit buttons by Element.Form (Collector)
+	Forms []Form
+	// Tabs lists the open tabs when there are several (Controller.ListTabs), nil for one
+	Tabs []browser.Tab
+	// Clock is the time zone the page runs in, nil when the page didn't answer
+	Clock *PageClock
+}
+
+// PageClock is the time zone of the page's scripts: the browser context timezone
+// (AGENT_TIMEZONE), which is what the site renders dates and times in
+type PageClock struct {
+	Timezone string // IANA name reported by Intl, "" if unknown
+	Offset   int    // UTC offset in minutes, east positive (-Date.getTimezoneOffset())
 }
 
+// ViewportInfo describes a viewport-only snapshot
+type ViewportInfo struct {
+	BelowFold  int     // Interactive elements below the visible area (not in Elements)
+	PagesBelow float64 // Scrollable content below the viewport, in viewport heights
+}
+
+// Note tells the planner the snapshot is partial and scrolling reveals more
+func (v ViewportInfo) Note() string {
+	if v.BelowFold == 0 {
+		return "viewport-only snapshot; nothing interactive below the fold"
+	}
+	return fmt.Sprintf("viewport-only snapshot; %d elements exist below the fold (%.1f pages) - scroll to see them", v.BelowFold, v.PagesBelow)
+}
+
+// PartialNote tells the planner a Partial snapshot lists only the start of the page
+const PartialNote = "partial snapshot: the page is too large to parse in time, only its first elements are listed"
+
 // PageStatistics contains page-level statistics
 type PageStatistics struct {
 	Links            int
@@ -49,6 +132,8 @@ func (s Summary) ToMap() map[string]any {
 	return map[string]any{
 		"url":        s.URL,
 		"title":      s.Title,
+		"lang":       s.Lang,
+		"clock":      s.Clock,
 		"visible":    s.Visible,
 		"elements":   s.Elements,
 		"page_stats": s.PageStats,
@@ -88,30 +173,97 @@ func calculatePageStatistics(elems []Element) PageStatistics {
 	return stats
 }
 
+// Options tunes snapshot collection.
+type Options struct {
+	OCR      OCR    // Optional OCR fallback for pages without readable DOM text (nil = disabled)
+	Language string // Language hint for OCR and bilingual dedup ("ru", "en"), usually detected from the task
+	// ViewportOnly collects only elements intersecting the viewport with the JS collector
+	// (no CDP tree): cheaper per step, the planner scrolls deliberately for the rest
+	ViewportOnly bool
+
+	// Zero values below keep the defaults
+	MaxElements       int      // Elements collected from the page (200)
+	MaxVisibleChars   int      // Visible page text, in runes (1200)
+	MaxNonInteractive int      // Non-actionable elements kept after ranking (50)
+	ExtraRoles        []string // Roles treated as actionable in addition to the defaults (e.g. "gridcell", "switch")
+	DisableCDP        bool     // Collect the main frame with querySelectorAll only, skipping the CDP accessibility tree
+
+	// Logger receives collection diagnostics (CDP fallbacks, parse statistics) at debug level;
+	// the zero value discards them
+	Logger zerolog.Logger
+}
+
+// withDefaults fills zero limits
+func (o Options) withDefaults() Options {
+	if o.MaxElements <= 0 {
+		o.MaxElements = defaultMaxElements
+	}
+	if o.MaxVisibleChars <= 0 {
+		o.MaxVisibleChars = defaultMaxVisibleChars
+	}
+	if o.MaxNonInteractive <= 0 {
+		o.MaxNonInteractive = defaultMaxNonInteractive
+	}
+	return o
+}
+
+// actionableRoles is the default set plus ExtraRoles, lower-cased
+func (o Options) actionableRoles() map[string]bool {
+	roles := make(map[string]bool, len(defaultActionableRoles)+len(o.ExtraRoles))
+	for _, role := range defaultActionableRoles {
+		roles[role] = true
+	}
+	for _, role := range o.ExtraRoles {
+		if role = strings.ToLower(strings.TrimSpace(role)); role != "" {
+			roles[role] = true
+		}
+	}
+	return roles
+}
+
+// Collect takes a one-off snapshot; consecutive snapshots should go through a Collector
 func Collect(ctx context.Context, ctrl browser.Controller) (Summary, error) {
+	return CollectWithOptions(ctx, ctrl, Options{})
+}
+
+// CollectWithOptions collects page summary with custom options (indices 1..N, see Collector).
+func CollectWithOptions(ctx context.Context, ctrl browser.Controller, opts Options) (Summary, error) {
+	return NewCollector(opts).Collect(ctx, ctrl)
+}
+
+func collect(ctx context.Context, ctrl browser.Controller, opts Options) (Summary, error) {
+	opts = opts.withDefaults()
 	page := ctrl.Page()
 	title, _ := page.Title()
 	url := page.URL()
+	lang, clock := pageLocale(page)
 
 	text, _ := page.InnerText("body")
-	if len(text) > 1200 {
-		text = text[:1200]
+	text = truncateRunes(text, opts.MaxVisibleChars)
+	var tabs []browser.Tab
+	if open, err := ctrl.ListTabs(ctx); err == nil && len(open) > 1 {
+		tabs = open
 	}
 
 	// Use shorter timeout for snapshot collection to avoid hanging
 	snapshotCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	elems, _ := collectInteractive(snapshotCtx, page, 200) // Reduced from 500 to 200 for speed
+	var (
+		elems    []Element
+		viewport *ViewportInfo
+		partial  bool
+	)
+	if opts.ViewportOnly {
+		elems, viewport = collectViewport(snapshotCtx, page, opts.MaxElements)
+	} else {
+		elems, partial, _ = collectInteractive(snapshotCtx, page, opts)
+	}
+	elems = dropHiddenTranslations(elems, opts.Language)
 
 	// Like browser-use-reference: show ALL interactive elements, don't filter by relevance
 	// Filter only non-interactive elements, keep all interactive ones
-	actionableRoles := map[string]bool{
-		"button": true, "link": true, "textbox": true, "checkbox": true,
-		"radio": true, "radiogroup": true, "combobox": true, "listitem": true, "menuitem": true,
-		"tab": true, "option": true, "article": true, "row": true,
-		"list": true, "listbox": true, "treeitem": true, "cell": true,
-	}
+	actionableRoles := opts.actionableRoles()
 
 	// Separate interactive and non-interactive elements
 	interactiveElems := make([]Element, 0)
@@ -126,8 +278,8 @@ func Collect(ctx context.Context, ctrl browser.Controller) (Summary, error) {
 		}
 	}
 
-	// Filter only non-interactive elements (limit to 50 for context)
-	filteredNonInteractive := filterAndRankElements(nonInteractiveElems, 50)
+	// Filter only non-interactive elements (limited for context)
+	filteredNonInteractive := filterAndRankElements(nonInteractiveElems, opts.MaxNonInteractive)
 
 	// Combine: ALL interactive + filtered non-interactive
 	filteredElems := make([]Element, 0, len(interactiveElems)+len(filteredNonInteractive))
@@ -142,27 +294,105 @@ func Collect(ctx context.Context, ctrl browser.Controller) (Summary, error) {
 	// Calculate page statistics
 	stats := calculatePageStatistics(filteredElems)
 
+	visible := strings.TrimSpace(text)
+	// Canvas-rendered dashboards and PDF viewers have no readable DOM - fall back to OCR of the viewport
+	if opts.OCR != nil && needsOCR(visible, filteredElems) {
+		ocrText, err := RecognizeViewport(ctx, ctrl, opts.OCR, opts.Language)
+		if err != nil {
+			opts.Logger.Debug().Err(err).Msg("OCR fallback failed")
+		} else if ocrText != "" {
+			ocrText = truncateRunes(ocrText, opts.MaxVisibleChars)
+			visible = OCRMarker + " " + ocrText
+		}
+	}
+
 	return Summary{
 		URL:       url,
 		Title:     title,
-		Visible:   strings.TrimSpace(text),
+		Lang:      lang,
+		Visible:   visible,
 		Elements:  filteredElems,
 		PageStats: stats,
+		Viewport:  viewport,
+		Partial:   partial,
+		Tabs:      tabs,
+		Clock:     clock,
 	}, nil
 }
 
+// pageLocale reads the declared <html lang> and the time zone of the page in one call
+func pageLocale(page playwright.Page) (string, *PageClock) {
+	v, err := page.Evaluate(`() => ({
+		lang: document.documentElement.lang || "",
+		tz: (Intl.DateTimeFormat().resolvedOptions().timeZone) || "",
+		offset: -new Date().getTimezoneOffset(),
+	})`)
+	m, ok := v.(map[string]interface{})
+	if err != nil || !ok {
+		return "", nil
+	}
+	lang, _ := m["lang"].(string)
+	clock := &PageClock{}
+	clock.Timezone, _ = m["tz"].(string)
+	switch offset := m["offset"].(type) {
+	case int:
+		clock.Offset = offset
+	case float64:
+		clock.Offset = int(offset)
+	}
+	return lang, clock
+}
+
 func (s Summary) String() string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "URL: %s\nTITLE: %s\nTEXT: %s\nELEMENTS:\n", s.URL, s.Title, s.Visible)
-	for i, el := range s.Elements {
-		fmt.Fprintf(&b, "%d) role=%s text=%s attr=%s bbox=%s\n", i+1, el.Role, el.Text, el.Attr, el.BBox)
+	for _, el := range s.Elements {
+		fmt.Fprintf(&b, "[%d] role=%s text=%s attr=%s bbox=%s\n", el.Index, el.Role, el.Text, el.Attr, el.BBox)
+	}
+	if s.Viewport != nil {
+		b.WriteString(s.Viewport.Note() + "\n")
+	}
+	if s.Partial {
+		b.WriteString(PartialNote + "\n")
+	}
+	for _, t := range s.Tabs {
+		fmt.Fprintf(&b, "TAB [%d] active=%t %s %s\n", t.Index, t.Active, t.Title, t.URL)
 	}
 	return b.String()
 }
 
-func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([]Element, error) {
+// collectInteractive collects elements of the main and child frames; partial is true when
+// the main frame accessibility tree was cut short by ctx
+func collectInteractive(ctx context.Context, page playwright.Page, opts Options) (elems []Element, partial bool, err error) {
+	limit := opts.MaxElements
+	// Child frames are always collected with the JS collector: the CDP tree of the main frame
+	// misses out-of-process iframes (e.g. mail clients rendering the message list in an iframe).
+	// Collect frames first so main frame elements can't use up the whole budget.
+	frameElems := collectFrames(ctx, page, limit/2, opts.Logger)
+
+	elems, partial, err = collectMainFrame(ctx, page, limit-len(frameElems), opts)
+	if err != nil && len(frameElems) == 0 {
+		return nil, false, err
+	}
+	elems = mergeElements(elems, frameElems)
+
+	// Limit final result
+	if len(elems) > limit {
+		elems = elems[:limit]
+	}
+	return elems, partial, nil
+}
+
+// collectMainFrame collects elements of the main frame: CDP accessibility tree first
+// (unless Options.DisableCDP), querySelectorAll as a fallback
+func collectMainFrame(ctx context.Context, page playwright.Page, limit int, opts Options) ([]Element, bool, error) {
+	if opts.DisableCDP {
+		elems, err := evaluateCollector(page, limit)
+		return elems, false, err
+	}
+
 	// Try to use CDP Accessibility.getFullAXTree (like browser-use-reference)
-	// This sees elements in virtualized lists and iframes without scrolling
+	// This sees elements in virtualized lists without scrolling
 	// Fallback to querySelectorAll if CDP fails or is not available
 
 	// Get CDP session for the page (like browser-use-reference)
@@ -175,41 +405,160 @@ func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([
 		result, cdpErr := cdpSession.Send("Accessibility.getFullAXTree", map[string]interface{}{})
 		if cdpErr == nil && result != nil {
 			// Parse accessibility tree and convert to Elements
-			elems, parseErr := parseAccessibilityTree(result, limit)
+			// One DOM.getDocument per snapshot resolves backendDOMNodeIds to real selectors
+			dom, domErr := fetchDOMIndex(cdpSession)
+			if domErr != nil {
+				opts.Logger.Debug().Err(domErr).Msg("CDP DOM.getDocument failed, using role selectors")
+			}
+			elems, partial, parseErr := parseAccessibilityTree(ctx, result, limit, opts.actionableRoles(), dom, opts.Logger)
 			if parseErr == nil && len(elems) > 0 {
 				// CDP worked, return elements
-				// Log CDP success for debugging
-				if resultMap, ok := result.(map[string]interface{}); ok {
-					if nodes, ok := resultMap["nodes"].([]interface{}); ok {
-						// Log CDP stats
-						fmt.Printf("[CDP] Successfully parsed %d elements from %d CDP nodes\n", len(elems), len(nodes))
-					}
-				}
-				return elems, nil
+				return elems, partial, nil
 			}
 			// If parsing failed, log and fall through to querySelectorAll
 			if parseErr != nil {
-				fmt.Printf("[CDP] Parse error: %v\n", parseErr)
+				opts.Logger.Debug().Err(parseErr).Msg("CDP accessibility tree parse failed, falling back to querySelectorAll")
 			} else {
-				fmt.Printf("[CDP] Parsed 0 elements (falling back to querySelectorAll)\n")
+				opts.Logger.Debug().Msg("CDP accessibility tree has no elements, falling back to querySelectorAll")
 			}
 		} else {
 			// CDP failed
-			if cdpErr != nil {
-				fmt.Printf("[CDP] Error: %v\n", cdpErr)
-			} else {
-				fmt.Printf("[CDP] No result returned\n")
-			}
+			opts.Logger.Debug().Err(cdpErr).Bool("empty", result == nil).Msg("CDP Accessibility.getFullAXTree failed, falling back to querySelectorAll")
 		}
-	} else {
+	} else if err != nil {
 		// CDP session creation failed
+		opts.Logger.Debug().Err(err).Msg("CDP session failed, falling back to querySelectorAll")
+	}
+
+	// Fallback: Use querySelectorAll (fast but doesn't see virtualized lists without scrolling)
+	elems, err := evaluateCollector(page, limit)
+	return elems, false, err
+}
+
+// evaluateCollector runs the JS collector (querySelectorAll) in the main frame
+func evaluateCollector(page playwright.Page, limit int) ([]Element, error) {
+	val, err := page.Evaluate(collectScript, limit)
+	if err != nil {
+		return nil, err
+	}
+	return decodeElements(val)
+}
+
+// collectFrames runs the JS collector in every non-main frame (bounded by maxCollectFrames and limit)
+func collectFrames(ctx context.Context, page playwright.Page, limit int, logger zerolog.Logger) []Element {
+	var elems []Element
+	visited := 0
+	for _, frame := range page.Frames() {
+		if len(elems) >= limit || visited >= maxCollectFrames || ctx.Err() != nil {
+			break
+		}
+		// Skip main frame (collected via CDP)
+		if frame == page.MainFrame() {
+			continue
+		}
+		visited++
+		frameVal, err := frame.Evaluate(collectScript, limit-len(elems))
+		if err != nil {
+			// Detached frame or error, skip
+			continue
+		}
+		frameElems, err := decodeElements(frameVal)
 		if err != nil {
-			fmt.Printf("[CDP] Failed to create session: %v (falling back to querySelectorAll)\n", err)
+			continue
+		}
+		for i := range frameElems {
+			frameElems[i].FrameURL = frame.URL()
 		}
+		elems = append(elems, frameElems...)
 	}
+	if len(elems) > 0 {
+		logger.Debug().Int("elements", len(elems)).Int("frames", visited).Msg("collected child frames")
+	}
+	if len(elems) > limit {
+		elems = elems[:limit]
+	}
+	return elems
+}
 
-	// Fallback: Use querySelectorAll (fast but doesn't see virtualized lists without scrolling)
-	script := `(limit) => {
+// mergeElements appends frame elements that the main frame pass hasn't already seen
+// (the JS fallback also walks same-origin iframes, CDP may include them too)
+func mergeElements(main, frames []Element) []Element {
+	seen := make(map[string]bool, len(main))
+	for _, el := range main {
+		seen[elementKey(el)] = true
+	}
+	merged := main
+	for _, el := range frames {
+		key := elementKey(el)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, el)
+	}
+	return merged
+}
+
+// elementKey identifies an element across collectors: role + normalized text (selector when no text)
+func elementKey(el Element) string {
+	text := strings.ToLower(strings.Join(strings.Fields(el.Text), " "))
+	if text == "" {
+		return strings.ToLower(el.Role) + "|sel:" + el.Sel
+	}
+	return strings.ToLower(el.Role) + "|" + text
+}
+
+func decodeElements(val interface{}) ([]Element, error) {
+	bytes, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	var elems []Element
+	if err := json.Unmarshal(bytes, &elems); err != nil {
+		return nil, err
+	}
+	return elems, nil
+}
+
+// collectScript collects interactive elements of a document (including open shadow roots
+// and same-origin iframes) via querySelectorAll
+const collectScript = `(arg) => {
+		// arg is the element limit, or {limit, viewportOnly} for viewport-only snapshots
+		const limit = typeof arg === "number" ? arg : arg.limit;
+		const viewportOnly = typeof arg === "object" && !!arg.viewportOnly;
+		let belowFold = 0;
+		// Cut by code points, not UTF-16 units - slice() can split an emoji surrogate pair
+		const cut = (s, n) => s.length <= n ? s : Array.from(s).slice(0, n).join("");
+		// Text of the elements referenced by aria-labelledby (resolved in the element's own root)
+		function labelledBy(el) {
+			return idrefText(el, "aria-labelledby");
+		}
+		function idrefText(el, attr) {
+			const ids = (el.getAttribute(attr) || "").trim();
+			if (!ids) return "";
+			const root = el.getRootNode();
+			return ids.split(/\s+/).map(id => {
+				const ref = root.getElementById ? root.getElementById(id) : null;
+				return ref ? (ref.innerText || ref.textContent || "") : "";
+			}).join(" ").trim();
+		}
+		// Forms get a label unique within this run: name, heading/legend or "form N"
+		const formLabels = new Map();
+		const usedFormLabels = new Set();
+		function formLabel(el) {
+			const form = el.form || (el.parentElement ? el.parentElement.closest("form,[role='form']") : null);
+			if (!form) return "";
+			if (formLabels.has(form)) return formLabels.get(form);
+			const heading = form.querySelector("legend,h1,h2,h3,h4");
+			let name = (form.getAttribute("aria-label") || labelledBy(form) || (heading ? heading.innerText : "") ||
+				form.getAttribute("name") || form.id || "").trim().split("\n")[0];
+			name = cut(name.trim(), 40) || "form " + (formLabels.size + 1);
+			let label = name;
+			for (let n = 2; usedFormLabels.has(label); n++) label = name + " #" + n;
+			usedFormLabels.add(label);
+			formLabels.set(form, label);
+			return label;
+		}
 		// Helper to check if element is scrollable (from browser-use pattern)
 		function isScrollable(el) {
 			if (!el) return false;
@@ -264,13 +613,53 @@ func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([
 					const isInteractive = el.tagName === "A" || el.tagName === "BUTTON" || el.tagName === "INPUT" || 
 					                      el.tagName === "SELECT" || el.tagName === "TEXTAREA" || hasRole || hasTabIndex;
 					if (!isInteractive && !isScrollableEl) continue;
+					if (viewportOnly && (rect.bottom < 0 || rect.top > window.innerHeight || rect.right < 0 || rect.left > window.innerWidth)) {
+						if (rect.top > window.innerHeight) belowFold++;
+						continue;
+					}
 					
 					const bbox = [Math.round(rect.x), Math.round(rect.y), Math.round(rect.width), Math.round(rect.height)].join(",");
 					const role = el.getAttribute("role") || el.tagName.toLowerCase();
-					const attrs = ["name","aria-label","placeholder","type","value","role","tabindex","data-testid","data-qa","data-qa-type","title"].map(a => (a + ":" + (el.getAttribute(a) || ""))).join("|");
+					const isPassword = el.tagName === "INPUT" && (el.type || "").toLowerCase() === "password";
+					const attrs = ["name","aria-label","placeholder","type","value","role","tabindex","data-testid","data-qa","data-qa-type","title"].map(a => {
+						const v = el.getAttribute(a) || "";
+						return a + ":" + (a === "value" && isPassword && v ? "***" : v);
+					}).join("|");
+					// Form fields: the label is the text, the current value goes to value
+					// (typing must not change the text - it identifies the element across snapshots)
+					const isField = el.tagName === "TEXTAREA" || el.tagName === "SELECT" ||
+						(el.tagName === "INPUT" && !["submit", "button", "reset", "image"].includes((el.type || "").toLowerCase()));
+					let value = "";
+					if (isField) {
+						if (el.tagName === "SELECT") {
+							value = el.selectedIndex >= 0 && el.options[el.selectedIndex] ? el.options[el.selectedIndex].text : "";
+						} else if (!["checkbox", "radio"].includes((el.type || "").toLowerCase())) {
+							value = el.value || "";
+						}
+						value = isPassword && value ? "***" : cut(value.trim(), 100);
+					}
 					// Get text content
-					let text = (el.innerText || el.textContent || el.value || "").trim();
-					text = text.slice(0, 120);
+					let text = isField
+						? (el.getAttribute("aria-label") || labelledBy(el) || (el.labels && el.labels[0] ? el.labels[0].innerText : "") || el.getAttribute("placeholder") || el.getAttribute("title") || "").trim()
+						: (el.innerText || el.textContent || el.value || "").trim();
+					// Icon-only controls: the accessible name, the tooltip, then the description
+					let textSource = "";
+					if (!text && !isField) {
+						const img = el.querySelector("img[alt]");
+						const svgTitle = el.querySelector("svg title");
+						const named = [
+							["label", el.getAttribute("aria-label") || labelledBy(el)],
+							["title", el.getAttribute("title")],
+							["alt", img ? img.getAttribute("alt") : ""],
+							["title", svgTitle ? svgTitle.textContent : ""],
+							["description", idrefText(el, "aria-describedby")],
+						].find(([, v]) => v && v.trim());
+						if (named) {
+							textSource = named[0];
+							text = named[1].trim();
+						}
+					}
+					text = cut(text, 120);
 					
 					// For scrollable containers, add scroll info to text
 					if (isScrollableEl && !text) {
@@ -290,10 +679,10 @@ func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([
 						const label = el.getAttribute("aria-label") || "";
 						const name = el.getAttribute("name") || "";
 						// Use only first line of text, remove newlines
-						const textPart = text.split("\n")[0].slice(0,30).trim();
+						const textPart = cut(text.split("\n")[0], 30).trim();
 						// Sanitize: remove newlines, quotes, brackets, limit length
 						let safe = (label || name || textPart).replace(/"/g, "").replace(/\[/g, "").replace(/\]/g, "").replace(/\n/g, " ").replace(/\r/g, " ").trim();
-						if (safe.length > 40) safe = safe.slice(0, 40);
+						safe = cut(safe, 40);
 						
 						if (testId && safe) {
 							sel = "[data-testid=\"" + testId + "\"][aria-label*=\"" + safe + "\"]";
@@ -318,7 +707,20 @@ func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([
 							if (idx > 0) sel = tag + ":nth-of-type(" + idx + ")";
 						}
 					}
-					pick.push({role, text, attr: attrs, bbox, selector: sel, scrollInfo: scrollInfo});
+					const disabled = el.disabled === true || el.getAttribute("aria-disabled") === "true";
+					const inDialog = !!el.closest("[role='dialog'],[role='alertdialog'],[aria-modal='true'],dialog[open]");
+					// Hidden i18n/hydration copies keep their box but are not rendered
+					const hidden = typeof el.checkVisibility === "function"
+						? !el.checkVisibility({checkOpacity: true, checkVisibilityCSS: true})
+						: window.getComputedStyle(el).visibility === "hidden";
+					const checked = el.checked === true || el.getAttribute("aria-checked") === "true";
+					const expanded = el.getAttribute("aria-expanded") === "true" || (el.tagName === "DETAILS" && el.open);
+					const focused = el === (root.activeElement || document.activeElement);
+					const inputType = el.tagName === "INPUT" || el.tagName === "BUTTON" ? (el.type || "").toLowerCase()
+						: el.tagName === "SELECT" ? "select" : el.tagName === "TEXTAREA" ? "textarea" : "";
+					const required = el.required === true || el.getAttribute("aria-required") === "true";
+					pick.push({role, text, text_source: textSource, attr: attrs, bbox, selector: sel, scrollInfo: scrollInfo, disabled, in_dialog: inDialog, hidden, value, checked, expanded, focused,
+						form: formLabel(el), input_type: inputType, required});
 					
 					// Recurse into shadow DOM
 					if (el.shadowRoot) {
@@ -353,155 +755,30 @@ func collectInteractive(ctx context.Context, page playwright.Page, limit int) ([
 			}
 		}
 		
+		if (viewportOnly) {
+			const doc = document.scrollingElement || document.documentElement;
+			const below = Math.max(0, doc.scrollHeight - window.scrollY - window.innerHeight);
+			return {elements: pick, belowFold, pagesBelow: window.innerHeight > 0 ? below / window.innerHeight : 0};
+		}
 		return pick;
 	}`
-	// Collect from main frame
-	val, err := page.Evaluate(script, limit)
-	if err != nil {
-		return nil, err
-	}
-	bytes, err := json.Marshal(val)
-	if err != nil {
-		return nil, err
-	}
-	var elems []Element
-	if err := json.Unmarshal(bytes, &elems); err != nil {
-		return nil, err
-	}
-
-	// Also collect from all iframes using Playwright API (more aggressive)
-	frames := page.Frames()
-	for _, frame := range frames {
-		if len(elems) >= limit {
-			break
-		}
-		// Skip main frame (already collected)
-		if frame == page.MainFrame() {
-			continue
-		}
-		// Try to collect from iframe
-		iframeVal, iframeErr := frame.Evaluate(script, limit-len(elems))
-		if iframeErr != nil {
-			// Cross-origin iframe or error, skip
-			continue
-		}
-		iframeBytes, err := json.Marshal(iframeVal)
-		if err != nil {
-			continue
-		}
-		var iframeElems []Element
-		if err := json.Unmarshal(iframeBytes, &iframeElems); err != nil {
-			continue
-		}
-		elems = append(elems, iframeElems...)
-	}
-
-	// Limit final result
-	if len(elems) > limit {
-		elems = elems[:limit]
-	}
-
-	return elems, nil
-}
 
 // parseAccessibilityTree parses CDP Accessibility.getFullAXTree response and converts to Elements
-// This is like browser-use-reference approach - sees elements in virtualized lists and iframes
-func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error) {
+// This is like browser-use-reference approach - sees elements in virtualized lists and iframes.
+// partial is true when ctx expired mid-parse: the elements read so far are returned.
+func parseAccessibilityTree(ctx context.Context, cdpResult interface{}, limit int, actionableRoles map[string]bool, dom *domIndex, logger zerolog.Logger) (elems []Element, partial bool, err error) {
 	// CDP returns accessibility tree with nodes
 	// Each node has: role, name, value, description, boundingBox, etc.
 	// We need to extract actionable elements (buttons, links, inputs, etc.)
 
 	resultMap, ok := cdpResult.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid CDP result format")
+		return nil, false, fmt.Errorf("invalid CDP result format")
 	}
 
 	nodes, ok := resultMap["nodes"].([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("no nodes in accessibility tree")
-	}
-
-	// Debug: log total nodes from CDP
-	fmt.Printf("[CDP] Processing %d nodes from accessibility tree\n", len(nodes))
-
-	// Step 1: Build node map and parent-child relationships
-	// Map: nodeId -> node data
-	nodeMap := make(map[string]map[string]interface{})
-	// Map: nodeId -> parentId
-	parentMap := make(map[string]string)
-	// Map: nodeId -> childIds
-	childMap := make(map[string][]string)
-
-	// First pass: build node map and extract relationships
-	for _, nodeInterface := range nodes {
-		node, ok := nodeInterface.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Get nodeId
-		var nodeId string
-		if id, ok := node["nodeId"]; ok {
-			if idStr, ok := id.(string); ok {
-				nodeId = idStr
-			} else if idFloat, ok := id.(float64); ok {
-				nodeId = fmt.Sprintf("%.0f", idFloat)
-			}
-		}
-		if nodeId == "" {
-			continue
-		}
-
-		nodeMap[nodeId] = node
-
-		// Get childIds
-		if childIds, ok := node["childIds"]; ok {
-			if childIdsArr, ok := childIds.([]interface{}); ok {
-				childIdStrs := make([]string, 0, len(childIdsArr))
-				for _, childId := range childIdsArr {
-					if childIdStr, ok := childId.(string); ok {
-						childIdStrs = append(childIdStrs, childIdStr)
-						parentMap[childIdStr] = nodeId
-					} else if childIdFloat, ok := childId.(float64); ok {
-						childIdStr := fmt.Sprintf("%.0f", childIdFloat)
-						childIdStrs = append(childIdStrs, childIdStr)
-						parentMap[childIdStr] = nodeId
-					}
-				}
-				childMap[nodeId] = childIdStrs
-			}
-		}
-	}
-
-	// Step 2: Calculate depth for each node (BFS from root)
-	depthMap := make(map[string]int)
-	// Find root nodes (nodes without parents)
-	var queue []string
-	for nodeId := range nodeMap {
-		if _, hasParent := parentMap[nodeId]; !hasParent {
-			depthMap[nodeId] = 0
-			queue = append(queue, nodeId)
-		}
-	}
-	// BFS to calculate depths
-	for len(queue) > 0 {
-		currentId := queue[0]
-		queue = queue[1:]
-		currentDepth := depthMap[currentId]
-		for _, childId := range childMap[currentId] {
-			if _, visited := depthMap[childId]; !visited {
-				depthMap[childId] = currentDepth + 1
-				queue = append(queue, childId)
-			}
-		}
-	}
-
-	var elems []Element
-	actionableRoles := map[string]bool{
-		"button": true, "link": true, "textbox": true, "checkbox": true,
-		"radio": true, "radiogroup": true, "combobox": true, "listitem": true, "menuitem": true,
-		"tab": true, "option": true, "article": true, "row": true,
-		"list": true, "listbox": true, "treeitem": true, "cell": true,
+		return nil, false, fmt.Errorf("no nodes in accessibility tree")
 	}
 
 	// Roles to skip (not actionable)
@@ -510,17 +787,29 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 		"lineBreak": true, "paragraph": true,
 	}
 
+	// Single pass in document order (getFullAXTree lists nodes depth-first): elements are
+	// emitted as soon as their node is read and reading stops at the limit, so a 50k-node
+	// tree costs only what is kept. Hierarchy is resolved afterwards for emitted elements only.
+	parentMap := make(map[string]string)   // nodeId -> parentId, from childIds of the nodes read
+	dialogNodes := make(map[string]bool)   // dialog/alertdialog/aria-modal nodes read
+	formNames := make(map[*domNode]string) // AX names of form nodes read (aria-labelledby resolved)
+	var forms formLabeler
 	processedCount := 0
 	skippedCount := 0
 	actionableCount := 0
 	noBboxCount := 0
 	noTextCount := 0
+	domSelectors := 0
 
-	// Step 3: Process nodes and build elements with hierarchy info
-	for _, nodeInterface := range nodes {
+	for i, nodeInterface := range nodes {
 		if len(elems) >= limit {
 			break
 		}
+		// The snapshot deadline keeps what was parsed so far instead of discarding it
+		if i%parseBatchSize == 0 && ctx.Err() != nil {
+			partial = true
+			break
+		}
 
 		node, ok := nodeInterface.(map[string]interface{})
 		if !ok {
@@ -529,6 +818,20 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 
 		processedCount++
 
+		nodeId := axNodeID(node["nodeId"])
+		if nodeId != "" {
+			if childIds, ok := node["childIds"].([]interface{}); ok {
+				for _, childId := range childIds {
+					if id := axNodeID(childId); id != "" {
+						parentMap[id] = nodeId
+					}
+				}
+			}
+			if isDialogNode(node) {
+				dialogNodes[nodeId] = true
+			}
+		}
+
 		// Get role - CDP structure: role is an object with "type" field
 		roleValue, ok := node["role"]
 		if !ok {
@@ -564,12 +867,15 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 		}
 
 		// Get name - CDP structure: name is an object with "value" field
-		nameValue := ""
+		nameValue, textSource := "", ""
 		if name, ok := node["name"]; ok {
 			if nameMap, ok := name.(map[string]interface{}); ok {
 				if nv, ok := nameMap["value"].(string); ok {
 					nameValue = nv
 				}
+				if !valueRoles[roleType] {
+					textSource = axNameSource(nameMap)
+				}
 			} else if nv, ok := name.(string); ok {
 				// Fallback: sometimes name might be string directly
 				nameValue = nv
@@ -602,13 +908,23 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 			valueStr = vv
 		}
 
+		if valueStr != "" && isPasswordValue(node, valueStr) {
+			valueStr = "***"
+		}
+
 		text := nameValue
 		if valueStr != "" && text == "" {
 			text = valueStr
 		}
-		if len(text) > 120 {
-			text = text[:120]
+		// Unnamed icon buttons are often described instead (aria-describedby tooltips)
+		if text == "" {
+			if desc, ok := node["description"].(map[string]interface{}); ok {
+				if dv, ok := desc["value"].(string); ok && strings.TrimSpace(dv) != "" {
+					text, textSource = strings.TrimSpace(dv), "description"
+				}
+			}
 		}
+		text = truncateRunes(text, 120)
 
 		// Build attributes
 		attrs := []string{}
@@ -618,6 +934,11 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 		if valueStr != "" {
 			attrs = append(attrs, "value:"+valueStr)
 		}
+		// Value of form fields only - for links, headings etc. CDP "value" is the URL or level
+		fieldValue := ""
+		if valueRoles[roleType] {
+			fieldValue = truncateRunes(valueStr, 100)
+		}
 		attrStr := strings.Join(attrs, "|")
 
 		// Build selector - need to match elements by role and text/name
@@ -646,9 +967,7 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 				if nameValue != "" && len(nameValue) < 50 {
 					safeName := strings.ReplaceAll(nameValue, "\"", "'")
 					safeName = strings.ReplaceAll(safeName, "\n", " ")
-					if len(safeName) > 40 {
-						safeName = safeName[:40]
-					}
+					safeName = truncateRunes(safeName, 40)
 					sel = fmt.Sprintf("input[type=\"%s\"][aria-label*=\"%s\"], [role=\"textbox\"][aria-label*=\"%s\"]", inputType, safeName, safeName)
 				} else {
 					sel = fmt.Sprintf("input[type=\"%s\"], [role=\"textbox\"]", inputType)
@@ -657,14 +976,41 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 				// Use role with aria-label matching name
 				safeName := strings.ReplaceAll(nameValue, "\"", "'")
 				safeName = strings.ReplaceAll(safeName, "\n", " ")
-				if len(safeName) > 40 {
-					safeName = safeName[:40]
-				}
+				safeName = truncateRunes(safeName, 40)
 				sel = fmt.Sprintf("[role=\"%s\"][aria-label*=\"%s\"]", roleType, safeName)
 			} else {
 				sel = fmt.Sprintf("[role=\"%s\"]", roleType)
 			}
 		}
+		// Real DOM selector when the AX node maps to an element of the main document;
+		// the role-based selector above is the fallback (shadow DOM, iframes, unresolved nodes)
+		if dom != nil {
+			if domSel := dom.selector(axBackendNodeID(node)); domSel != "" {
+				sel = domSel
+				domSelectors++
+			}
+		}
+
+		// Form membership and control type from the DOM node behind the AX node
+		formLabel, inputType := "", ""
+		required := axBoolProperty(node, "required")
+		if dom != nil {
+			if n := dom.nodes[axBackendNodeID(node)]; n != nil {
+				if roleType == "form" {
+					formNames[n] = nameValue
+				}
+				_, requiredAttr := n.attrs["required"]
+				required = required || requiredAttr || n.attrs["aria-required"] == "true"
+				inputType = n.inputType()
+				form := n.form()
+				formLabel = forms.label(form, formNames[form])
+			}
+		}
+
+		disabled := axBoolProperty(node, "disabled")
+		checked := axBoolProperty(node, "checked") || axBoolProperty(node, "pressed")
+		expanded := axBoolProperty(node, "expanded")
+		focused := axBoolProperty(node, "focused")
 
 		// Track statistics
 		if bboxStr == "" {
@@ -674,26 +1020,6 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 			noTextCount++
 		}
 
-		// Get nodeId and parentId for hierarchy
-		var nodeId string
-		var parentId string
-		var depth int
-		if id, ok := node["nodeId"]; ok {
-			if idStr, ok := id.(string); ok {
-				nodeId = idStr
-			} else if idFloat, ok := id.(float64); ok {
-				nodeId = fmt.Sprintf("%.0f", idFloat)
-			}
-		}
-		if nodeId != "" {
-			if pid, ok := parentMap[nodeId]; ok {
-				parentId = pid
-			}
-			if d, ok := depthMap[nodeId]; ok {
-				depth = d
-			}
-		}
-
 		// Check if this is an actionable role
 		isActionableRole := actionableRoles[roleType]
 		hasText := text != ""
@@ -705,26 +1031,40 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 		if isActionableRole {
 			// Always include actionable roles - CDP sees virtualized content
 			elems = append(elems, Element{
-				Role:     roleType,
-				Text:     text,
-				Attr:     attrStr,
-				BBox:     bboxStr,
-				Sel:      sel,
-				Depth:    depth,
-				NodeId:   nodeId,
-				ParentId: parentId,
+				Role:       roleType,
+				Text:       text,
+				TextSource: textSource,
+				Attr:       attrStr,
+				BBox:       bboxStr,
+				Sel:        sel,
+				NodeId:     nodeId,
+				Disabled:   disabled,
+				Value:      fieldValue,
+				Checked:    checked,
+				Expanded:   expanded,
+				Focused:    focused,
+				Form:       formLabel,
+				InputType:  inputType,
+				Required:   required,
 			})
 		} else if hasText || hasBbox {
 			// Include non-actionable elements only if they have text or bbox
 			elems = append(elems, Element{
-				Role:     roleType,
-				Text:     text,
-				Attr:     attrStr,
-				BBox:     bboxStr,
-				Sel:      sel,
-				Depth:    depth,
-				NodeId:   nodeId,
-				ParentId: parentId,
+				Role:       roleType,
+				Text:       text,
+				TextSource: textSource,
+				Attr:       attrStr,
+				BBox:       bboxStr,
+				Sel:        sel,
+				NodeId:     nodeId,
+				Disabled:   disabled,
+				Value:      fieldValue,
+				Checked:    checked,
+				Expanded:   expanded,
+				Focused:    focused,
+				Form:       formLabel,
+				InputType:  inputType,
+				Required:   required,
 			})
 		} else {
 			// Skip elements with no actionable role, no text, and no bbox
@@ -732,11 +1072,167 @@ func parseAccessibilityTree(cdpResult interface{}, limit int) ([]Element, error)
 		}
 	}
 
+	// Hierarchy of the emitted elements; parents come before children in document order,
+	// so their childIds have already been read
+	depthMemo := make(map[string]int)
+	dialogMemo := make(map[string]bool)
+	for i := range elems {
+		elems[i].ParentId = parentMap[elems[i].NodeId]
+		elems[i].Depth = axDepth(elems[i].NodeId, parentMap, depthMemo)
+		elems[i].InDialog = inDialog(elems[i].NodeId, dialogNodes, parentMap, dialogMemo)
+	}
+
 	// Debug: log parsing stats
-	fmt.Printf("[CDP] Parsed %d actionable elements (processed: %d, skipped: %d, actionable roles: %d, noBbox: %d, noText: %d)\n",
-		len(elems), processedCount, skippedCount, actionableCount, noBboxCount, noTextCount)
+	logger.Debug().
+		Int("ax_nodes", len(nodes)).
+		Bool("partial", partial).
+		Int("elements", len(elems)).
+		Int("processed", processedCount).
+		Int("skipped", skippedCount).
+		Int("actionable", actionableCount).
+		Int("no_bbox", noBboxCount).
+		Int("no_text", noTextCount).
+		Int("dom_selectors", domSelectors).
+		Msg("CDP accessibility tree parsed")
+
+	return elems, partial, nil
+}
 
-	return elems, nil
+// inDialog reports whether a node or any of its ancestors is a dialog/alertdialog or aria-modal container
+func inDialog(nodeId string, dialogNodes map[string]bool, parentMap map[string]string, memo map[string]bool) bool {
+	if nodeId == "" {
+		return false
+	}
+	if v, ok := memo[nodeId]; ok {
+		return v
+	}
+	memo[nodeId] = false // Guard against cycles
+	result := dialogNodes[nodeId]
+	if !result {
+		if parentId, ok := parentMap[nodeId]; ok {
+			result = inDialog(parentId, dialogNodes, parentMap, memo)
+		}
+	}
+	memo[nodeId] = result
+	return result
+}
+
+// isDialogNode reports whether an AX node is a dialog/alertdialog or aria-modal container
+func isDialogNode(node map[string]interface{}) bool {
+	role := ""
+	if roleMap, ok := node["role"].(map[string]interface{}); ok {
+		role, _ = roleMap["value"].(string)
+	}
+	return role == "dialog" || role == "alertdialog" || axBoolProperty(node, "modal")
+}
+
+// axDepth is the number of ancestors of a node along parentMap (0 for roots)
+func axDepth(nodeId string, parentMap map[string]string, memo map[string]int) int {
+	if nodeId == "" {
+		return 0
+	}
+	var chain []string // The node and its ancestors up to a memoized one or a root
+	base := -1         // Depth of the memoized ancestor, -1 when the chain reaches a root
+	for cur := nodeId; cur != "" && len(chain) <= maxAXDepth; cur = parentMap[cur] {
+		if d, ok := memo[cur]; ok {
+			base = d
+			break
+		}
+		chain = append(chain, cur)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		base++
+		memo[chain[i]] = base
+	}
+	return memo[nodeId]
+}
+
+// axNodeID reads a CDP AX node id, which is a string but may come as a number
+func axNodeID(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return fmt.Sprintf("%.0f", id)
+	}
+	return ""
+}
+
+// valueRoles are the AX roles whose CDP value is user input (Element.Value)
+var valueRoles = map[string]bool{
+	"textbox": true, "searchbox": true, "combobox": true, "spinbutton": true, "slider": true,
+}
+
+// isPasswordValue reports whether an AX value belongs to a password field: Chrome exposes
+// password values as bullets, some builds report the input type as a property
+func isPasswordValue(node map[string]interface{}, value string) bool {
+	if strings.Trim(value, "•*●") == "" {
+		return true
+	}
+	props, _ := node["properties"].([]interface{})
+	for _, prop := range props {
+		propMap, ok := prop.(map[string]interface{})
+		if !ok || propMap["name"] != "inputType" {
+			continue
+		}
+		if propValue, ok := propMap["value"].(map[string]interface{}); ok && propValue["value"] == "password" {
+			return true
+		}
+	}
+	return false
+}
+
+// axNameSource reads which source gave CDP the accessible name (the first one with a value that
+// is not superseded): "label", "title" or "alt" for attributes, "" for the element's contents
+// and label elements
+func axNameSource(name map[string]interface{}) string {
+	sources, _ := name["sources"].([]interface{})
+	for _, s := range sources {
+		src, ok := s.(map[string]interface{})
+		if !ok || src["superseded"] == true {
+			continue
+		}
+		value, ok := src["value"].(map[string]interface{})
+		if !ok || strings.TrimSpace(fmt.Sprint(value["value"])) == "" {
+			continue
+		}
+		switch src["attribute"] {
+		case "aria-label", "aria-labelledby":
+			return "label"
+		case "title":
+			return "title"
+		case "alt":
+			return "alt"
+		}
+		return ""
+	}
+	return ""
+}
+
+// axBoolProperty reads boolean AX property (e.g. "disabled") from CDP node properties list
+func axBoolProperty(node map[string]interface{}, name string) bool {
+	props, ok := node["properties"].([]interface{})
+	if !ok {
+		return false
+	}
+	for _, prop := range props {
+		propMap, ok := prop.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if propName, ok := propMap["name"].(string); !ok || propName != name {
+			continue
+		}
+		if propValue, ok := propMap["value"].(map[string]interface{}); ok {
+			switch v := propValue["value"].(type) {
+			case bool:
+				return v
+			case string:
+				return v == "true"
+			}
+		}
+	}
+	return false
 }
 
 // WithDeadline shortens context to avoid long snapshot waits.
@@ -828,3 +1324,15 @@ func scoreElement(el Element) int {
 
 	return score
 }
+
+// truncateRunes returns at most n runes of s without splitting a multi-byte character
+func truncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
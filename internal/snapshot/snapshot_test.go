@@ -0,0 +1,20 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	for _, s := range []string{"Привет, мир", "🍕🍕🍕🍕🍕", "a👍🏽b👍🏽c", ""} {
+		count := utf8.RuneCountInString(s)
+		for _, n := range []int{0, 1, count - 1, count, count + 1} {
+			got := truncateRunes(s, n)
+			want := min(max(n, 0), count)
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) != want || !strings.HasPrefix(s, got) {
+				t.Errorf("truncateRunes(%q, %d) = %q, want the first %d runes", s, n, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser/browsertest"
+)
+
+// elementStates is the state each collector must report for the form_states fixtures
+var elementStates = map[string]Element{
+	"Email":        {Value: "user@example.com"},
+	"Password":     {Value: "***"},
+	"Display name": {Focused: true},
+	"Country":      {Value: "Serbia"},
+	"Newsletter":   {Checked: true},
+	"SMS alerts":   {},
+	"Dark mode":    {Checked: true},
+	"More options": {Expanded: true},
+	"Save":         {Disabled: true},
+}
+
+func TestAccessibilityTreeStates(t *testing.T) {
+	data, err := os.ReadFile("testdata/form_states_axtree.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatal(err)
+	}
+	elems, _, err := parseAccessibilityTree(context.Background(), tree, 100, Options{}.actionableRoles(), nil, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStates(t, elems)
+	for _, el := range elems {
+		if el.Text == "PIN" && el.Value != "***" {
+			t.Errorf("PIN value = %q, want the password input type masked", el.Value)
+		}
+	}
+	checkNoSecret(t, elems, "4821")
+}
+
+func TestJSCollectorStates(t *testing.T) {
+	ctrl, _ := browsertest.Open(t, "testdata", "form_states.html")
+	summary, err := CollectWithOptions(context.Background(), ctrl, Options{DisableCDP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStates(t, summary.Elements)
+	checkNoSecret(t, summary.Elements, "hunter2")
+}
+
+func checkStates(t *testing.T, elems []Element) {
+	t.Helper()
+	seen := make(map[string]bool)
+	for _, el := range elems {
+		want, ok := elementStates[el.Text]
+		if !ok || seen[el.Text] {
+			continue
+		}
+		seen[el.Text] = true
+		if el.Value != want.Value || el.Checked != want.Checked || el.Expanded != want.Expanded || el.Focused != want.Focused || el.Disabled != want.Disabled {
+			t.Errorf("%s %q: value=%q checked=%v expanded=%v focused=%v disabled=%v, want %+v",
+				el.Role, el.Text, el.Value, el.Checked, el.Expanded, el.Focused, el.Disabled, want)
+		}
+	}
+	for text := range elementStates {
+		if !seen[text] {
+			t.Errorf("element %q not collected", text)
+		}
+	}
+}
+
+func checkNoSecret(t *testing.T, elems []Element, secret string) {
+	t.Helper()
+	data, _ := json.Marshal(elems)
+	if strings.Contains(string(data), secret) {
+		t.Errorf("password %q leaked into the elements", secret)
+	}
+}
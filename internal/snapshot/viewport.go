@@ -0,0 +1,65 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// viewportResult is what collectScript returns in viewport-only mode
+type viewportResult struct {
+	Elements   []Element `json:"elements"`
+	BelowFold  int       `json:"belowFold"`
+	PagesBelow float64   `json:"pagesBelow"`
+}
+
+// collectViewport runs the JS collector restricted to the viewport in the main frame and
+// child frames; the CDP tree is skipped - it is the expensive part of a full snapshot.
+// Below-the-fold counts come from the main frame.
+func collectViewport(ctx context.Context, page playwright.Page, limit int) ([]Element, *ViewportInfo) {
+	main, err := evaluateViewport(page.MainFrame(), limit)
+	if err != nil {
+		return nil, &ViewportInfo{}
+	}
+	info := &ViewportInfo{BelowFold: main.BelowFold, PagesBelow: main.PagesBelow}
+	elems := main.Elements
+	visited := 0
+	for _, frame := range page.Frames() {
+		if len(elems) >= limit || visited >= maxCollectFrames || ctx.Err() != nil {
+			break
+		}
+		if frame == page.MainFrame() {
+			continue
+		}
+		visited++
+		res, err := evaluateViewport(frame, limit-len(elems))
+		if err != nil {
+			continue
+		}
+		for i := range res.Elements {
+			res.Elements[i].FrameURL = frame.URL()
+		}
+		elems = mergeElements(elems, res.Elements)
+	}
+	if len(elems) > limit {
+		elems = elems[:limit]
+	}
+	return elems, info
+}
+
+func evaluateViewport(frame playwright.Frame, limit int) (viewportResult, error) {
+	val, err := frame.Evaluate(collectScript, map[string]interface{}{"limit": limit, "viewportOnly": true})
+	if err != nil {
+		return viewportResult{}, err
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return viewportResult{}, err
+	}
+	var res viewportResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		return viewportResult{}, err
+	}
+	return res, nil
+}
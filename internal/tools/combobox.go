@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// comboboxValueChars caps the combobox value echoed in the observation
+const comboboxValueChars = 120
+
+// chooseComboboxOption picks an option of a custom dropdown (ARIA combobox) or native <select>
+func (s *standard) chooseComboboxOption(ctx context.Context, input map[string]any) (Result, error) {
+	option, err := requiredString(input, "option")
+	if err != nil {
+		return Result{}, err
+	}
+	sel := optionalString(input, "selector")
+	if _, ok := input["index"]; ok {
+		el, err := s.elementByIndex(optionalInt(input, "index"))
+		if err != nil {
+			return Result{}, err
+		}
+		if el.Sel == "" {
+			return Result{}, fmt.Errorf("element [%d] has no selector - use collect_texts to find the combobox", el.Index)
+		}
+		if el.FrameURL != "" {
+			return Result{}, fmt.Errorf("element [%d] is inside an iframe - choose_combobox_option works on the main page only, click the combobox and its option instead", el.Index)
+		}
+		sel = el.Sel
+	}
+	if sel == "" {
+		return Result{}, fmt.Errorf("choose_combobox_option needs index or selector")
+	}
+	res, err := s.ctrl.ChooseComboboxOption(ctx, sel, option)
+	if err != nil {
+		return Result{}, err
+	}
+	obs := fmt.Sprintf("chose %q in %s via %s", res.Option, sel, res.Strategy)
+	if res.Verified {
+		obs += fmt.Sprintf(" (combobox reads %q)", ellipsize(res.Value, comboboxValueChars))
+	} else {
+		obs += fmt.Sprintf("; but the combobox reads %q - check the snapshot before continuing", ellipsize(res.Value, comboboxValueChars))
+	}
+	return Result{Observation: obs}, nil
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestChooseComboboxOption(t *testing.T) {
+	// The city combobox of internal/browser/testdata/comboboxes.html
+	page := browser.FakePage{URL: "https://shop.example.com/delivery", Elements: []browser.FakeElement{
+		{Selector: "#city-input", Role: "combobox", Text: "City"},
+	}}
+	summary := &snapshot.Summary{URL: page.URL, Elements: []snapshot.Element{
+		{Index: 1, Role: "combobox", Text: "City", Sel: "#city-input"},
+		{Index: 2, Role: "combobox", Text: "Month"},
+		{Index: 3, Role: "combobox", Text: "Size", Sel: "#size", FrameURL: "https://widgets.example.com/size"},
+	}}
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    string
+		wantErr string
+	}{
+		{"by index", map[string]any{"index": 1, "option": "Новосибирск"}, `chose "Новосибирск" in #city-input via typing (combobox reads "Новосибирск")`, ""},
+		{"by selector", map[string]any{"selector": "#city-input", "option": "Москва"}, `chose "Москва" in #city-input via typing`, ""},
+		{"index without a selector", map[string]any{"index": 2, "option": "May"}, "", "has no selector"},
+		{"index inside an iframe", map[string]any{"index": 3, "option": "42"}, "", "inside an iframe"},
+		{"no target", map[string]any{"option": "May"}, "", "needs index or selector"},
+		{"no option", map[string]any{"index": 1}, "", "option"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(page)
+			box := New(ctrl, noPrompt)
+			box.SetSnapshot(summary)
+			res, err := box.Invoke(context.Background(), "choose_combobox_option", tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if calls := ctrl.CallsTo("ChooseComboboxOption"); len(calls) != 0 {
+					t.Errorf("controller called on a rejected input: %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(res.Observation, tt.want) {
+				t.Errorf("observation = %q, want %q", res.Observation, tt.want)
+			}
+		})
+	}
+}
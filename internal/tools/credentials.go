@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// credentialPromptWords mark request_user_input prompts asking for account data or secrets
+var credentialPromptWords = []string{
+	"password", "пароль", "passcode", "login", "логин", "username", "имя пользователя",
+	"email", "e-mail", "почт", "token", "токен", "2fa", "one-time", "одноразов",
+	"verification code", "код подтверждения",
+}
+
+// credential is a value the user supplied for account data, tagged with the site that asked for it
+type credential struct {
+	value string
+	site  string // Registrable domain of the page when the value was requested
+}
+
+// noteCredential tags the answer to a credential prompt with the current site
+func (s *standard) noteCredential(prompt, answer string) {
+	value := strings.TrimSpace(answer)
+	if value == "" || !isCredentialPrompt(prompt) {
+		return
+	}
+	site := siteOf(s.ctrl.Page().URL())
+	if site == "" {
+		return
+	}
+	for i := range s.credentials {
+		if s.credentials[i].value == value {
+			s.credentials[i].site = site
+			return
+		}
+	}
+	s.credentials = append(s.credentials, credential{value: value, site: site})
+}
+
+// credentialMismatch returns a refusal note when text is a credential requested on another
+// site and the two sites are not related through Options.CredentialDomains; "" allows the fill
+func (s *standard) credentialMismatch(text, targetURL string) string {
+	value := strings.TrimSpace(text)
+	target := siteOf(targetURL)
+	if value == "" || target == "" {
+		return ""
+	}
+	for _, c := range s.credentials {
+		if c.value != value || c.site == target || s.relatedSites(c.site, target) {
+			continue
+		}
+		return fmt.Sprintf("⛔ NOT FILLED: this value was provided by the user for %s, but the field is on %s. "+
+			"Credentials are not reused across sites - use request_user_input to ask for the %s value", c.site, target, target)
+	}
+	return ""
+}
+
+// fillTargetURL is the URL of the document a snapshot element lives in
+func (s *standard) fillTargetURL(frameURL string) string {
+	if frameURL != "" {
+		return frameURL
+	}
+	return s.ctrl.Page().URL()
+}
+
+// relatedSites reports whether both sites are in the SSO allowlist (Options.CredentialDomains)
+func (s *standard) relatedSites(a, b string) bool {
+	inList := func(site string) bool {
+		for _, d := range s.opts.CredentialDomains {
+			if browser.RegistrableDomain(strings.TrimPrefix(strings.TrimSpace(d), ".")) == site {
+				return true
+			}
+		}
+		return false
+	}
+	return inList(a) && inList(b)
+}
+
+func isCredentialPrompt(prompt string) bool {
+	p := strings.ToLower(prompt)
+	for _, w := range credentialPromptWords {
+		if strings.Contains(p, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteOf is the registrable domain of a page URL, "" for pages without a host (about:blank)
+func siteOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	return browser.RegistrableDomain(u.Hostname())
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+const userSecret = "hunter2-Sekret"
+
+func loginPageAt(url string) browser.FakePage {
+	return browser.FakePage{URL: url, Elements: []browser.FakeElement{{Selector: "#password", Role: "textbox", Text: "Password"}}}
+}
+
+func TestCredentialsStayOnTheirSite(t *testing.T) {
+	tests := []struct {
+		name      string
+		asked, on string
+		domains   []string
+		tool      string
+		wantFill  bool
+	}{
+		{name: "same site", asked: "https://shop.example/login", on: "https://shop.example/checkout", tool: "fill", wantFill: true},
+		{name: "subdomain of the same site", asked: "https://shop.example/login", on: "https://accounts.shop.example/", tool: "fill", wantFill: true},
+		{name: "other site", asked: "https://shop.example/login", on: "https://evil.example/login", tool: "fill"},
+		{name: "other site, fill_and_submit", asked: "https://shop.example/login", on: "https://evil.example/login", tool: "fill_and_submit"},
+		{name: "SSO allowlist", asked: "https://corp.example/", on: "https://login.corp-sso.example/", domains: []string{"corp.example", ".corp-sso.example"}, tool: "fill", wantFill: true},
+		{name: "allowlist needs both sites", asked: "https://corp.example/", on: "https://evil.example/", domains: []string{"corp.example", "corp-sso.example"}, tool: "fill"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(loginPageAt(tt.asked), loginPageAt(tt.on))
+			prompt := func(context.Context, string) (string, error) { return userSecret, nil }
+			box := NewWithOptions(ctrl, prompt, Options{CredentialDomains: tt.domains})
+			ctx := context.Background()
+			if _, err := box.Invoke(ctx, "request_user_input", map[string]any{"prompt": "Enter your password"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := box.Invoke(ctx, "navigate", map[string]any{"url": tt.on}); err != nil {
+				t.Fatal(err)
+			}
+			res, err := box.Invoke(ctx, tt.tool, map[string]any{"selector": "#password", "text": userSecret})
+			if err != nil {
+				t.Fatal(err)
+			}
+			filled := len(ctrl.CallsTo("Fill"))+len(ctrl.CallsTo("FillAndSubmit")) > 0
+			if filled != tt.wantFill {
+				t.Fatalf("filled = %v, want %v (observation %q)", filled, tt.wantFill, res.Observation)
+			}
+			if !tt.wantFill && (!strings.HasPrefix(res.Observation, "⛔ NOT FILLED") || !strings.Contains(res.Observation, "request_user_input")) {
+				t.Errorf("observation %q, want the refusal pointing at request_user_input", res.Observation)
+			}
+			if strings.Contains(res.Observation, userSecret) {
+				t.Errorf("observation echoes the secret: %q", res.Observation)
+			}
+		})
+	}
+}
+
+func TestCredentialNotedOnlyForCredentialPrompts(t *testing.T) {
+	ctrl := browser.NewFakeController(loginPageAt("https://shop.example/"), loginPageAt("https://other.example/"))
+	prompt := func(context.Context, string) (string, error) { return "blue", nil }
+	box := New(ctrl, prompt)
+	ctx := context.Background()
+	if _, err := box.Invoke(ctx, "request_user_input", map[string]any{"prompt": "Which color should the lamp be?"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := box.Invoke(ctx, "navigate", map[string]any{"url": "https://other.example/"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := box.Invoke(ctx, "fill", map[string]any{"selector": "#password", "text": "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ctrl.CallsTo("Fill")) != 1 {
+		t.Errorf("an ordinary answer was refused on another site: %v", ctrl.Calls())
+	}
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+const (
+	// downloadWait is the default timeout_ms of wait_for_download
+	downloadWait = 15 * time.Second
+	// downloadMaxWait caps timeout_ms so a stuck download doesn't eat the step
+	downloadMaxWait = 2 * time.Minute
+)
+
+// waitForDownload reports the next file a page downloaded, waiting for it to finish
+func (s *standard) waitForDownload(ctx context.Context, input map[string]any) (Result, error) {
+	timeout := time.Duration(optionalInt(input, "timeout_ms")) * time.Millisecond
+	if timeout <= 0 {
+		timeout = downloadWait
+	}
+	if timeout > downloadMaxWait {
+		timeout = downloadMaxWait
+	}
+	d, err := s.ctrl.WaitForDownload(ctx, timeout)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{Observation: d.String()}
+	if d.Path != "" {
+		res.Artifacts = []string{d.Path}
+	}
+	return res, nil
+}
+
+// TakeDownloads drains downloads that finished without a wait_for_download call
+func (s *standard) TakeDownloads() []browser.Download {
+	return s.ctrl.TakeDownloads()
+}
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// hover moves the mouse over an element to reveal hover-only controls (menus, row actions)
+func (s *standard) hover(ctx context.Context, input map[string]any) (Result, error) {
+	sel := optionalString(input, "selector")
+	label := sel
+	if _, ok := input["index"]; ok {
+		el, err := s.elementByIndex(optionalInt(input, "index"))
+		if err != nil {
+			return Result{}, err
+		}
+		if el.Sel == "" {
+			return Result{}, fmt.Errorf("element [%d] has no selector - use collect_texts to find it", el.Index)
+		}
+		if el.FrameURL != "" {
+			return Result{}, fmt.Errorf("element [%d] is inside an iframe - hover works on the main page only", el.Index)
+		}
+		sel, label = el.Sel, fmt.Sprintf("[%d]", el.Index)
+	}
+	if sel == "" {
+		return Result{}, fmt.Errorf("hover needs index or selector")
+	}
+	res, err := s.ctrl.HoverReveal(ctx, sel)
+	if err != nil {
+		return Result{}, err
+	}
+	switch diff := res.After - res.Before; {
+	case diff > 0:
+		return Result{Observation: fmt.Sprintf("hovered %s: %d new interactive elements appeared - they are in the next snapshot", label, diff)}, nil
+	case diff < 0:
+		return Result{Observation: fmt.Sprintf("hovered %s: %d interactive elements disappeared", label, -diff)}, nil
+	}
+	return Result{Observation: fmt.Sprintf("hovered %s: no new elements appeared", label)}, nil
+}
@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+const (
+	// DisabledClickWaitMs bounds waiting for a disabled click target (e.g. submit during validation)
+	DisabledClickWaitMs = 3000
+	// readByIndexChars is the default max_chars of read_by_index
+	readByIndexChars = 2000
+	// frameClickTimeoutMs bounds each locator attempt of a click inside a child frame
+	frameClickTimeoutMs = 5000
+)
+
+// IndexClick picks the click action for a snapshot element (browser-use pattern):
+// click_selector when the element has a usable selector, click_role with its name for
+// CDP elements without bbox (virtualized lists) whose selector is only a bare role
+func IndexClick(el snapshot.Element) (string, map[string]any) {
+	if el.BBox == "" && el.Role != "" && el.Role != "generic" && el.Role != "none" {
+		// A DOM selector or input[type]; role-based fallbacks ([role="link"][aria-label*=...])
+		// often match nothing since the name is inner text, not aria-label
+		hasValidSelector := el.Sel != "" && !strings.HasPrefix(el.Sel, "[role=\"")
+		if !hasValidSelector {
+			// Playwright Locator API handles virtualized lists
+			input := map[string]any{"role": el.Role}
+			if el.Text != "" {
+				input["name"] = el.Text
+			}
+			return "click_role", input
+		}
+	}
+	input := map[string]any{"selector": el.Sel}
+	// Element was disabled in the snapshot - let the click wait for enablement
+	if el.Disabled {
+		input["wait_enabled_ms"] = DisabledClickWaitMs
+	}
+	return "click_selector", input
+}
+
+// clickByIndex clicks a snapshot element; a failed selector click falls back to the bbox center
+func (s *standard) clickByIndex(ctx context.Context, input map[string]any) (Result, error) {
+	index, err := requiredInt(input, "index")
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid index type for click_by_index")
+	}
+	el, err := s.elementByIndex(index)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w. Use an index from the current snapshot", err)
+	}
+	if el.FrameURL != "" {
+		return s.clickInFrame(ctx, el)
+	}
+	action, clickInput := IndexClick(*el)
+	res, err := s.invoke(ctx, action, clickInput)
+	if err != nil && action == "click_selector" && el.BBox != "" {
+		var x, y, w, h float64
+		if n, _ := fmt.Sscanf(el.BBox, "%f,%f,%f,%f", &x, &y, &w, &h); n == 4 {
+			coordRes, coordErr := s.invoke(ctx, "click_coordinates", map[string]any{
+				"x": int(x + w/2),
+				"y": int(y + h/2),
+			})
+			if coordErr == nil {
+				res, err = coordRes, nil
+			}
+		}
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	res.Observation = fmt.Sprintf("[%d] %s", el.Index, res.Observation)
+	return res, nil
+}
+
+// clickInFrame clicks an element collected from a child frame: its selector and bbox only
+// make sense inside that frame, so locators are built on the frame itself, trying
+// selector -> role+name -> bbox center offset by the iframe's position
+func (s *standard) clickInFrame(ctx context.Context, el *snapshot.Element) (Result, error) {
+	frame, err := s.elementFrame(ctx, el)
+	if err != nil {
+		return Result{}, err
+	}
+	clickOpts := playwright.LocatorClickOptions{Timeout: playwright.Float(frameClickTimeoutMs)}
+	var errs []string
+	for _, fl := range frameLocators(frame, el) {
+		err := fl.loc.Click(clickOpts)
+		if err == nil {
+			return Result{Observation: fmt.Sprintf("[%d] clicked %s in frame %s", el.Index, fl.desc, el.FrameURL)}, nil
+		}
+		errs = append(errs, fl.how+": "+err.Error())
+	}
+	var x, y, w, h float64
+	if n, _ := fmt.Sscanf(el.BBox, "%f,%f,%f,%f", &x, &y, &w, &h); n == 4 {
+		offX, offY, err := frameOffset(frame)
+		if err == nil {
+			cx, cy := offX+x+w/2, offY+y+h/2
+			if err = s.ctrl.ClickByCoordinates(ctx, cx, cy); err == nil {
+				return Result{Observation: fmt.Sprintf("[%d] clicked at coordinates (%d, %d) in frame %s", el.Index, int(cx), int(cy), el.FrameURL)}, nil
+			}
+		}
+		errs = append(errs, "coordinates: "+err.Error())
+	}
+	if len(errs) == 0 {
+		return Result{}, fmt.Errorf("element [%d] in frame %s has no selector, role or bbox to click", el.Index, el.FrameURL)
+	}
+	return Result{}, fmt.Errorf("click element [%d] in frame %s failed: %s", el.Index, el.FrameURL, strings.Join(errs, "; "))
+}
+
+// fillInFrame fills an element collected from a child frame (selector -> role+name, see clickInFrame)
+func (s *standard) fillInFrame(ctx context.Context, el *snapshot.Element, text string) (Result, error) {
+	frame, err := s.elementFrame(ctx, el)
+	if err != nil {
+		return Result{}, err
+	}
+	fillOpts := playwright.LocatorFillOptions{Timeout: playwright.Float(frameClickTimeoutMs)}
+	var errs []string
+	for _, fl := range frameLocators(frame, el) {
+		err := fl.loc.Fill(text, fillOpts)
+		if err == nil {
+			return Result{Observation: fmt.Sprintf("filled element [%d] (%s) in frame %s", el.Index, fl.desc, el.FrameURL)}, nil
+		}
+		errs = append(errs, fl.how+": "+err.Error())
+	}
+	if len(errs) == 0 {
+		return Result{}, fmt.Errorf("element [%d] in frame %s has no selector or role to fill", el.Index, el.FrameURL)
+	}
+	return Result{}, fmt.Errorf("fill element [%d] in frame %s failed: %s", el.Index, el.FrameURL, strings.Join(errs, "; "))
+}
+
+// readInFrame reads the live text of an element collected from a child frame
+func (s *standard) readInFrame(ctx context.Context, el *snapshot.Element) (string, error) {
+	frame, err := s.elementFrame(ctx, el)
+	if err != nil {
+		return "", err
+	}
+	readOpts := playwright.LocatorInnerTextOptions{Timeout: playwright.Float(frameClickTimeoutMs)}
+	for _, fl := range frameLocators(frame, el) {
+		if text, err := fl.loc.InnerText(readOpts); err == nil {
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("element [%d] not found in frame %s", el.Index, el.FrameURL)
+}
+
+// frameLocator is one way to find a frame element: how names it in errors, desc in observations
+type frameLocator struct {
+	how, desc string
+	loc       playwright.Locator
+}
+
+// frameLocators builds locators on the frame itself, in order: selector, role + name
+func frameLocators(frame playwright.Frame, el *snapshot.Element) []frameLocator {
+	var locs []frameLocator
+	if el.Sel != "" {
+		locs = append(locs, frameLocator{how: "selector", desc: "selector " + el.Sel, loc: frame.Locator(el.Sel).First()})
+	}
+	if el.Role != "" && el.Role != "generic" && el.Role != "none" {
+		opts := playwright.FrameGetByRoleOptions{}
+		if el.Text != "" {
+			opts.Name = el.Text
+		}
+		locs = append(locs, frameLocator{
+			how:  "role",
+			desc: fmt.Sprintf("role=%s name=%s", el.Role, el.Text),
+			loc:  frame.GetByRole(playwright.AriaRole(el.Role), opts).First(),
+		})
+	}
+	return locs
+}
+
+// elementFrame resolves the child frame of an element. Frames are looked up by URL at action
+// time: a handle kept from the snapshot would be stale after the iframe reloads.
+func (s *standard) elementFrame(ctx context.Context, el *snapshot.Element) (playwright.Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	frame := s.frameByURL(el.FrameURL)
+	if frame == nil {
+		return nil, fmt.Errorf("element [%d]: frame %s not found on the page (reloaded or removed) - take a fresh look at the elements list", el.Index, el.FrameURL)
+	}
+	return frame, nil
+}
+
+// frameByURL finds a child frame of the current page by URL
+func (s *standard) frameByURL(url string) playwright.Frame {
+	page := s.ctrl.Page()
+	if page == nil {
+		return nil
+	}
+	for _, frame := range page.Frames() {
+		if frame != page.MainFrame() && frame.URL() == url {
+			return frame
+		}
+	}
+	return nil
+}
+
+// frameOffset is the position of a frame's viewport in the top-level page, summed over nested iframes
+func frameOffset(frame playwright.Frame) (float64, float64, error) {
+	var x, y float64
+	for f := frame; f.ParentFrame() != nil; f = f.ParentFrame() {
+		handle, err := f.FrameElement()
+		if err != nil {
+			return 0, 0, err
+		}
+		box, err := handle.BoundingBox()
+		if err != nil {
+			return 0, 0, err
+		}
+		if box == nil {
+			return 0, 0, fmt.Errorf("iframe is not visible")
+		}
+		x += box.X
+		y += box.Y
+	}
+	return x, y, nil
+}
+
+// readByIndex reads the live text of a snapshot element, falling back to the snapshot text
+func (s *standard) readByIndex(ctx context.Context, input map[string]any) (Result, error) {
+	index, err := requiredInt(input, "index")
+	if err != nil {
+		return Result{}, err
+	}
+	el, err := s.elementByIndex(index)
+	if err != nil {
+		return Result{}, err
+	}
+	maxChars := optionalInt(input, "max_chars")
+	if maxChars <= 0 {
+		maxChars = readByIndexChars
+	}
+	text := el.Text
+	if el.FrameURL != "" {
+		if live, err := s.readInFrame(ctx, el); err == nil && strings.TrimSpace(live) != "" {
+			text = live
+		}
+	} else if el.Sel != "" {
+		if live, err := s.ctrl.Read(ctx, el.Sel); err == nil && strings.TrimSpace(live) != "" {
+			text = live
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return Result{Observation: fmt.Sprintf("element [%d] (%s) has no text", el.Index, el.Role)}, nil
+	}
+	return Result{Observation: fmt.Sprintf("element [%d] (%s): %s", el.Index, el.Role, ellipsize(text, maxChars))}, nil
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func TestIndexClick(t *testing.T) {
+	tests := []struct {
+		name       string
+		el         snapshot.Element
+		wantAction string
+		wantInput  map[string]any
+	}{
+		{"DOM selector", snapshot.Element{Role: "button", Text: "Save", Sel: "#save", BBox: "10,10,80,30"}, "click_selector", map[string]any{"selector": "#save"}},
+		{"disabled waits", snapshot.Element{Role: "button", Text: "Submit", Sel: "#submit", BBox: "1,1,1,1", Disabled: true}, "click_selector", map[string]any{"selector": "#submit", "wait_enabled_ms": DisabledClickWaitMs}},
+		{"CDP element with a role selector", snapshot.Element{Role: "link", Text: "Message 40", Sel: `[role="link"]`}, "click_role", map[string]any{"role": "link", "name": "Message 40"}},
+		{"CDP element with a real selector", snapshot.Element{Role: "link", Text: "Message 40", Sel: "#m40"}, "click_selector", map[string]any{"selector": "#m40"}},
+		{"generic without bbox", snapshot.Element{Role: "generic", Text: "row", Sel: `[role="generic"]`}, "click_selector", map[string]any{"selector": `[role="generic"]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, input := IndexClick(tt.el)
+			if action != tt.wantAction || len(input) != len(tt.wantInput) {
+				t.Fatalf("IndexClick = %s %v, want %s %v", action, input, tt.wantAction, tt.wantInput)
+			}
+			for k, v := range tt.wantInput {
+				if input[k] != v {
+					t.Errorf("input[%s] = %v, want %v", k, input[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestClickByIndex(t *testing.T) {
+	page := browser.FakePage{URL: "https://mail.example/inbox", Elements: []browser.FakeElement{
+		{Selector: "#compose", Role: "button", Text: "Compose"},
+		{Selector: "#send", Role: "button", Text: "Send", Disabled: true},
+		{Selector: "#m40", Role: "link", Text: "Message 40"},
+	}}
+	summary := &snapshot.Summary{URL: page.URL, Elements: []snapshot.Element{
+		{Index: 1, Role: "button", Text: "Compose", Sel: "#compose", BBox: "100,200,80,40"},
+		{Index: 2, Role: "button", Text: "Send", Sel: "#send", BBox: "1,1,1,1", Disabled: true},
+		{Index: 3, Role: "link", Text: "Message 40", Sel: `[role="link"]`},
+	}}
+	tests := []struct {
+		name     string
+		index    int
+		failNext string
+		wantCall string
+		wantArgs []any
+		wantErr  string
+	}{
+		{name: "selector", index: 1, wantCall: "Click", wantArgs: []any{"#compose"}},
+		{name: "bbox center after a failed selector", index: 1, failNext: "Click", wantCall: "ClickByCoordinates", wantArgs: []any{140.0, 220.0}},
+		{name: "disabled element is waited for", index: 2, wantCall: "WaitForEnabled"},
+		{name: "role and name", index: 3, wantCall: "ClickRole", wantArgs: []any{"link", "Message 40", true}},
+		{name: "index not in the snapshot", index: 9, wantErr: "Use an index from the current snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(page)
+			if tt.failNext != "" {
+				ctrl.FailNext(tt.failNext, errors.New("element is not visible"))
+			}
+			box := New(ctrl, noPrompt)
+			box.SetSnapshot(summary)
+			res, err := box.Invoke(context.Background(), "click_by_index", map[string]any{"index": tt.index})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(res.Observation, "[") {
+				t.Errorf("observation %q does not name the index", res.Observation)
+			}
+			calls := ctrl.CallsTo(tt.wantCall)
+			if len(calls) != 1 {
+				t.Fatalf("calls = %v, want one %s", ctrl.Calls(), tt.wantCall)
+			}
+			for i, want := range tt.wantArgs {
+				if calls[0].Args[i] != want {
+					t.Errorf("%s arg %d = %v, want %v", tt.wantCall, i, calls[0].Args[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestClickSelectorWaitEnabledIsCapped(t *testing.T) {
+	page := browser.FakePage{URL: "https://shop.example/checkout", Elements: []browser.FakeElement{
+		{Selector: "#pay", Role: "button", Text: "Pay", Disabled: true},
+		{Selector: "#back", Role: "button", Text: "Back"},
+	}}
+	tests := []struct {
+		name     string
+		selector string
+		waitMs   int
+		wantWait time.Duration // 0 when no wait may happen
+		wantObs  string
+	}{
+		{"model asks for ten minutes", "#pay", 600000, DisabledClickWaitMs * time.Millisecond, "NOT clicked: element #pay stayed disabled for 3000ms"},
+		{"short wait kept", "#pay", 500, 500 * time.Millisecond, "stayed disabled for 500ms"},
+		{"negative wait ignored", "#back", -5, 0, "clicked selector #back"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(page)
+			res, err := New(ctrl, noPrompt).Invoke(context.Background(), "click_selector", map[string]any{"selector": tt.selector, "wait_enabled_ms": tt.waitMs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(res.Observation, tt.wantObs) {
+				t.Errorf("observation %q, want %q", res.Observation, tt.wantObs)
+			}
+			waits := ctrl.CallsTo("WaitForEnabled")
+			switch {
+			case tt.wantWait == 0 && len(waits) != 0:
+				t.Errorf("waited %v, want no wait", waits)
+			case tt.wantWait != 0 && (len(waits) != 1 || waits[0].Args[1] != tt.wantWait):
+				t.Errorf("waits %v, want one of %s", waits, tt.wantWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+const (
+	// listElementsLimit is the default page size of list_elements
+	listElementsLimit = 50
+	// listElementsMaxLimit caps one page so a listing fits the observation cap
+	listElementsMaxLimit = 100
+)
+
+// elementFilter selects snapshot elements for list_elements; empty fields match everything
+type elementFilter struct {
+	role         string // Exact role, case-insensitive ("link", "button")
+	textContains string // Substring of text or value, case- and whitespace-insensitive
+}
+
+// match reports whether el passes the filter
+func (f elementFilter) match(el snapshot.Element) bool {
+	if f.role != "" && !strings.EqualFold(el.Role, strings.TrimSpace(f.role)) {
+		return false
+	}
+	needle := foldSpaces(f.textContains)
+	if needle == "" {
+		return true
+	}
+	return strings.Contains(foldSpaces(el.Text), needle) || strings.Contains(foldSpaces(el.Value), needle)
+}
+
+// filterElements returns one page (offset, limit) of the elements matching f and the total match count
+func filterElements(elements []snapshot.Element, f elementFilter, offset, limit int) ([]snapshot.Element, int) {
+	var page []snapshot.Element
+	total := 0
+	for _, el := range elements {
+		if !f.match(el) {
+			continue
+		}
+		if total >= offset && len(page) < limit {
+			page = append(page, el)
+		}
+		total++
+	}
+	return page, total
+}
+
+// listElements pages through the current snapshot without re-collecting the DOM
+func (s *standard) listElements(input map[string]any) (Result, error) {
+	if s.curSnapshot == nil {
+		return Result{}, fmt.Errorf("no snapshot available")
+	}
+	offset := optionalInt(input, "offset")
+	if offset < 0 {
+		offset = 0
+	}
+	limit := optionalInt(input, "limit")
+	if limit <= 0 {
+		limit = listElementsLimit
+	}
+	if limit > listElementsMaxLimit {
+		limit = listElementsMaxLimit
+	}
+	filter := elementFilter{role: optionalString(input, "role"), textContains: optionalString(input, "text_contains")}
+	page, total := filterElements(s.curSnapshot.Elements, filter, offset, limit)
+	if total == 0 {
+		return Result{Observation: fmt.Sprintf("no elements match (%d elements in snapshot)", len(s.curSnapshot.Elements))}, nil
+	}
+	if len(page) == 0 {
+		return Result{Observation: fmt.Sprintf("offset %d is past the end: %d elements match", offset, total)}, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "elements %d-%d of %d matching:\n", offset+1, offset+len(page), total)
+	for _, el := range page {
+		fmt.Fprintf(&b, "[%d]%s:%q", el.Index, el.Role, truncateRunes(firstTextLine(el.Text), 80))
+		if el.Value != "" {
+			fmt.Fprintf(&b, " value=%q", truncateRunes(el.Value, 40))
+		}
+		if el.Disabled {
+			b.WriteString(" (disabled)")
+		}
+		if el.FrameURL != "" {
+			b.WriteString(" (iframe)")
+		}
+		b.WriteString("\n")
+	}
+	if next := offset + len(page); next < total {
+		fmt.Fprintf(&b, "more: offset=%d", next)
+	}
+	return Result{Observation: strings.TrimSpace(b.String())}, nil
+}
+
+// foldSpaces lower-cases s and collapses whitespace runs for substring matching
+func foldSpaces(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func firstTextLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
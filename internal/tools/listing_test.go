@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// bigSummary is a synthetic catalog: 500 products (every 5th an "Add to cart" button, the
+// rest links, one disabled, one framed) and a search box after the first 100
+func bigSummary() *snapshot.Summary {
+	s := &snapshot.Summary{URL: "https://shop.example.com/catalog"}
+	for i := 1; i <= 500; i++ {
+		el := snapshot.Element{Index: i, Role: "link", Text: fmt.Sprintf("Product %d", i), Sel: fmt.Sprintf("#p-%d", i)}
+		if i%5 == 0 {
+			el = snapshot.Element{Index: i, Role: "button", Text: fmt.Sprintf("Add to cart\nProduct %d", i), Sel: fmt.Sprintf("#add-%d", i)}
+		}
+		if i == 250 {
+			el.Disabled = true
+		}
+		if i == 499 {
+			el.FrameURL = "https://widget.example.com/"
+		}
+		s.Elements = append(s.Elements, el)
+	}
+	s.Elements = append(s.Elements[:100], append([]snapshot.Element{{Index: 501, Role: "textbox", Text: "Search", Value: "Blue  Lamp"}}, s.Elements[100:]...)...)
+	return s
+}
+
+func TestListElements(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]any
+		lines []string // Listing lines, in order
+		head  string   // First line
+		tail  string   // Last line
+	}{
+		{
+			name:  "first page by default",
+			input: map[string]any{},
+			head:  "elements 1-50 of 501 matching:",
+			lines: []string{`[1]link:"Product 1"`, `[5]button:"Add to cart"`},
+			tail:  "more: offset=50",
+		},
+		{
+			name:  "role filter pages through buttons",
+			input: map[string]any{"role": "BUTTON", "offset": float64(40), "limit": float64(20)},
+			head:  "elements 41-60 of 100 matching:",
+			lines: []string{`[205]button:"Add to cart"`, `[250]button:"Add to cart" (disabled)`, `[300]button:"Add to cart"`},
+			tail:  "more: offset=60",
+		},
+		{
+			name:  "text filter matches the second line and values",
+			input: map[string]any{"text_contains": "product 49"},
+			head:  "elements 1-11 of 11 matching:",
+			lines: []string{`[49]link:"Product 49"`, `[490]button:"Add to cart"`, `[499]link:"Product 499" (iframe)`},
+			tail:  `[499]link:"Product 499" (iframe)`,
+		},
+		{
+			name:  "value match with folded spaces",
+			input: map[string]any{"text_contains": "blue lamp"},
+			head:  "elements 1-1 of 1 matching:",
+			tail:  `[501]textbox:"Search" value="Blue  Lamp"`,
+		},
+		{
+			name:  "role and text together",
+			input: map[string]any{"role": "link", "text_contains": "Product 10"},
+			head:  "elements 1-8 of 8 matching:", // 101..109 without the 105 button
+			lines: []string{`[101]link:"Product 101"`, `[104]link:"Product 104"`, `[106]link:"Product 106"`},
+		},
+		{
+			name:  "limit is capped",
+			input: map[string]any{"limit": float64(1000)},
+			head:  fmt.Sprintf("elements 1-%d of 501 matching:", listElementsMaxLimit),
+			tail:  fmt.Sprintf("more: offset=%d", listElementsMaxLimit),
+		},
+		{
+			name:  "last page",
+			input: map[string]any{"offset": float64(480), "limit": float64(100)},
+			head:  "elements 481-501 of 501 matching:",
+			tail:  `[500]button:"Add to cart"`,
+		},
+		{
+			name:  "offset past the end",
+			input: map[string]any{"offset": float64(600)},
+			head:  "offset 600 is past the end: 501 elements match",
+		},
+		{
+			name:  "negative offset starts at the top",
+			input: map[string]any{"offset": float64(-5), "limit": float64(1)},
+			head:  "elements 1-1 of 501 matching:",
+		},
+		{
+			name:  "no match",
+			input: map[string]any{"text_contains": "checkout"},
+			head:  "no elements match (501 elements in snapshot)",
+		},
+	}
+	box := New(browser.NewFakeController(browser.FakePage{}), noPrompt)
+	box.SetSnapshot(bigSummary())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := box.Invoke(context.Background(), "list_elements", tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(res.Observation, "\n")
+			if lines[0] != tt.head {
+				t.Errorf("head = %q, want %q", lines[0], tt.head)
+			}
+			if tt.tail != "" && lines[len(lines)-1] != tt.tail {
+				t.Errorf("tail = %q, want %q", lines[len(lines)-1], tt.tail)
+			}
+			pos := 0
+			for _, want := range tt.lines {
+				i := indexOf(lines[pos:], want)
+				if i < 0 {
+					t.Fatalf("missing or out of order %q in:\n%s", want, res.Observation)
+				}
+				pos += i + 1
+			}
+		})
+	}
+}
+
+func indexOf(lines []string, want string) int {
+	for i, line := range lines {
+		if line == want {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestListElementsNeedsSnapshot(t *testing.T) {
+	_, err := New(browser.NewFakeController(browser.FakePage{}), noPrompt).Invoke(context.Background(), "list_elements", map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "no snapshot") {
+		t.Fatalf("err = %v, want no snapshot", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// screenshot saves a PNG of the page into Options.ScreenshotDir (the working directory when unset)
+func (s *standard) screenshot(ctx context.Context, input map[string]any) (Result, error) {
+	raw := optionalString(input, "path")
+	if raw == "" {
+		raw = fmt.Sprintf("screenshot_%d.png", time.Now().Unix())
+	}
+	path, err := s.screenshotPath(raw)
+	if err != nil {
+		return Result{}, err
+	}
+	data, err := s.ctrl.Screenshot(ctx, path, optionalBool(input, "full_page"))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: fmt.Sprintf("screenshot saved to %s (%d bytes)", path, len(data)), Artifacts: []string{path}}, nil
+}
+
+// screenshotPath resolves raw inside the screenshot directory: relative paths are taken from it,
+// absolute paths and symlinked directories must stay inside it, and only new or regular .png
+// files are written - the model can't overwrite arbitrary files through the tool
+func (s *standard) screenshotPath(raw string) (string, error) {
+	dir := s.opts.ScreenshotDir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("screenshot directory %s: %w", dir, err)
+	}
+	root, err := filepath.Abs(dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("screenshot directory %s: %w", dir, err)
+	}
+	path := strings.TrimSpace(raw)
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		return "", fmt.Errorf("screenshot path %q must end with .png", raw)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("screenshot path %q: the directory does not exist in %s", raw, root)
+	}
+	if rel, err := filepath.Rel(root, parent); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("screenshot path %q is outside the screenshot directory %s - pass a file name", raw, root)
+	}
+	path = filepath.Join(parent, filepath.Base(path))
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		return "", fmt.Errorf("screenshot path %q is not a regular file", raw)
+	}
+	return path, nil
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func TestScreenshotStaysInScreenshotDir(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(base, "shots")
+	if err := os.MkdirAll(filepath.Join(dir, "steps"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(base, "victim.png"), "keep me")
+	if err := os.Symlink(base, filepath.Join(dir, "out")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(base, "victim.png"), filepath.Join(dir, "link.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "file name", path: "page.png", want: filepath.Join(dir, "page.png")},
+		{name: "subdirectory", path: "steps/1.png", want: filepath.Join(dir, "steps", "1.png")},
+		{name: "absolute path inside", path: filepath.Join(dir, "abs.png"), want: filepath.Join(dir, "abs.png")},
+		{name: "default name", path: "", want: filepath.Join(dir, "screenshot_")},
+		{name: "dot-dot traversal", path: "../victim.png", wantErr: "outside the screenshot directory"},
+		{name: "absolute path outside", path: filepath.Join(base, "victim.png"), wantErr: "outside the screenshot directory"},
+		{name: "symlinked directory", path: "out/victim.png", wantErr: "outside the screenshot directory"},
+		{name: "symlinked file", path: "link.png", wantErr: "not a regular file"},
+		{name: "not a png", path: "notes.txt", wantErr: "must end with .png"},
+		{name: "dotfile", path: "../.bashrc", wantErr: "must end with .png"},
+		{name: "missing directory", path: "nope/x.png", wantErr: "directory does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(browser.FakePage{URL: "https://example.com/"})
+			box := NewWithOptions(ctrl, noPrompt, Options{ScreenshotDir: dir})
+			res, err := box.Invoke(context.Background(), "screenshot", map[string]any{"path": tt.path})
+			calls := ctrl.CallsTo("Screenshot")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if len(calls) != 0 {
+					t.Errorf("screenshot taken: %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(calls) != 1 {
+				t.Fatalf("calls = %v, want one screenshot", ctrl.Calls())
+			}
+			if got, _ := calls[0].Args[0].(string); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("screenshot written to %q, want %q", got, tt.want)
+			}
+			if len(res.Artifacts) != 1 || !strings.HasPrefix(res.Artifacts[0], dir) {
+				t.Errorf("artifacts = %v, want the screenshot", res.Artifacts)
+			}
+		})
+	}
+	if data, _ := os.ReadFile(filepath.Join(base, "victim.png")); string(data) != "keep me" {
+		t.Error("file outside the screenshot directory was overwritten")
+	}
+}
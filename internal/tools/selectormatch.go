@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// quotedRe finds quoted values in a selector: [aria-label="Sign in"], :has-text('Next')
+var quotedRe = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+// selectorNoise are CSS/selector words that say nothing about which element was meant
+var selectorNoise = map[string]bool{
+	"nth": true, "child": true, "type": true, "of": true, "has": true, "text": true, "is": true,
+	"not": true, "aria": true, "label": true, "data": true, "class": true, "id": true, "role": true,
+	"name": true, "div": true, "span": true, "href": true, "contains": true, "first": true,
+	"last": true, "visible": true, "testid": true, "test": true, "qa": true, "js": true,
+}
+
+// SelectorInSnapshot reports whether sel is the selector of a snapshot element,
+// i.e. taken from the page rather than invented by the model
+func SelectorInSnapshot(sel string, elements []snapshot.Element) bool {
+	sel = strings.TrimSpace(sel)
+	for _, el := range elements {
+		if el.Sel != "" && strings.TrimSpace(el.Sel) == sel {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchSelector ranks snapshot elements by how well their text, role and selector match
+// the words of a (usually invented) selector; at most limit elements with a positive score
+func MatchSelector(sel string, elements []snapshot.Element, limit int) []snapshot.Element {
+	phrases, words := selectorTerms(sel)
+	if len(phrases) == 0 && len(words) == 0 {
+		return nil
+	}
+	type scored struct {
+		el    snapshot.Element
+		score int
+	}
+	var ranked []scored
+	for _, el := range elements {
+		text := strings.ToLower(el.Text)
+		textWords := make(map[string]bool)
+		for _, w := range splitWords(text) {
+			textWords[w] = true
+		}
+		elSel := strings.ToLower(el.Sel)
+		score := 0
+		for _, p := range phrases {
+			if text != "" && (strings.Contains(text, p) || strings.Contains(p, text)) {
+				score += 3
+			}
+		}
+		for _, w := range words {
+			switch {
+			case textWords[w]:
+				score += 2
+			case w == strings.ToLower(el.Role):
+				score++
+			case elSel != "" && strings.Contains(elSel, w):
+				score++
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{el: el, score: score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	matches := make([]snapshot.Element, len(ranked))
+	for i, r := range ranked {
+		matches[i] = r.el
+	}
+	return matches
+}
+
+// DescribeMatch formats an element for hints: [12] button "Sign in"
+func DescribeMatch(el snapshot.Element) string {
+	return fmt.Sprintf("[%d] %s %q", el.Index, el.Role, ellipsize(el.Text, 40))
+}
+
+// selectorTerms splits a selector into quoted phrases and meaningful words (lower case)
+func selectorTerms(sel string) (phrases, words []string) {
+	sel = strings.ToLower(sel)
+	seen := make(map[string]bool)
+	for _, m := range quotedRe.FindAllStringSubmatch(sel, -1) {
+		if p := strings.TrimSpace(m[1] + m[2]); len([]rune(p)) >= 2 {
+			phrases = append(phrases, p)
+		}
+	}
+	for _, w := range splitWords(sel) {
+		if len([]rune(w)) < 2 || selectorNoise[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return phrases, words
+}
+
+// splitWords splits on everything but letters and digits (ids like "login-btn" or "submit_form")
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// withNearestElement adds the snapshot element closest to an invented selector to its parse
+// error, so the planner can switch to click_by_index instead of guessing another selector
+func (s *standard) withNearestElement(err error, sel string) error {
+	if s.curSnapshot == nil || !isSelectorParseError(err) || SelectorInSnapshot(sel, s.curSnapshot.Elements) {
+		return err
+	}
+	matches := MatchSelector(sel, s.curSnapshot.Elements, 1)
+	if len(matches) == 0 {
+		return fmt.Errorf("%w; selector is not from the snapshot - use click_by_index with an index from the elements list", err)
+	}
+	return fmt.Errorf("%w; selector is not from the snapshot, nearest element: %s - use click_by_index with index=%d", err, DescribeMatch(matches[0]), matches[0].Index)
+}
+
+// isSelectorParseError reports Playwright's complaints about selector syntax
+func isSelectorParseError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "badstring") || strings.Contains(msg, "unsupported token") || strings.Contains(msg, "parsing selector")
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// shopElements is the header and first product of a shop page as the collector reports them
+var shopElements = []snapshot.Element{
+	{Index: 1, Role: "link", Text: "Каталог", Sel: "header nav a[href='/catalog']"},
+	{Index: 2, Role: "textbox", Text: "Поиск", Sel: "#search-input"},
+	{Index: 3, Role: "button", Text: "Найти", Sel: "#search-form button[type=submit]"},
+	{Index: 4, Role: "link", Text: "Корзина", Sel: "a.header-cart"},
+	{Index: 5, Role: "button", Text: "Войти", Sel: "button.login-btn"},
+	{Index: 6, Role: "button", Text: "Add to cart\nWireless Mouse", Sel: "#product-42 .buy"},
+	{Index: 7, Role: "link", Text: "Next page", Sel: "a.pagination-next"},
+	{Index: 8, Role: "textbox", Text: "Email", Sel: "input[name='email']"},
+}
+
+func TestMatchSelector(t *testing.T) {
+	// Selectors models invented instead of using an index
+	tests := []struct {
+		sel  string
+		want int // Index of the best match, 0 = none
+	}{
+		{`button:contains('Войти')`, 5},          // jQuery pseudo-class, a parse error in Playwright
+		{`a:has-text("Корзина")`, 4},             // Right syntax, element has another selector
+		{`//button[text()='Найти']`, 3},          // XPath
+		{`#add-to-cart-button`, 6},               // Id made up from the button text
+		{`.pagination > a.next`, 7},              // Class guessed from the text
+		{`input[placeholder="Email address"`, 8}, // Unterminated attribute
+		{`div.search-box input#q`, 2},            // Guessed layout
+		{`text=Next page >> nth=0`, 7},
+		{`#promo-banner-close`, 0}, // Nothing like it on the page
+		{`div > span:nth-child(2)`, 0},
+	}
+	for _, tt := range tests {
+		got := MatchSelector(tt.sel, shopElements, 3)
+		switch {
+		case tt.want == 0 && len(got) != 0:
+			t.Errorf("MatchSelector(%q) = %v, want no match", tt.sel, got)
+		case tt.want != 0 && (len(got) == 0 || got[0].Index != tt.want):
+			t.Errorf("MatchSelector(%q) = %v, want [%d] first", tt.sel, got, tt.want)
+		case len(got) > 3:
+			t.Errorf("MatchSelector(%q) returned %d elements, limit 3", tt.sel, len(got))
+		}
+	}
+}
+
+func TestSelectorInSnapshot(t *testing.T) {
+	if !SelectorInSnapshot(" a.header-cart ", shopElements) {
+		t.Fatal("a selector from the snapshot counted as invented")
+	}
+	for _, sel := range []string{"a.header-cart > span", "#cart", ""} {
+		if SelectorInSnapshot(sel, shopElements) {
+			t.Errorf("SelectorInSnapshot(%q) = true", sel)
+		}
+	}
+}
+
+func TestClickSelectorParseErrorNamesNearestElement(t *testing.T) {
+	tests := []struct {
+		name string
+		sel  string
+		err  error
+		want string // Substring of the error; "" = the error is passed as is
+	}{
+		{
+			name: "invented selector",
+			sel:  `button:contains('Войти')`,
+			err:  errors.New(`Unexpected token "(" while parsing selector "button:contains('Войти')"`),
+			want: `nearest element: [5] button "Войти" - use click_by_index with index=5`,
+		},
+		{
+			name: "invented without a match",
+			sel:  `#promo-banner-close:visible(`,
+			err:  errors.New(`Unsupported token "(" while parsing selector "#promo-banner-close:visible("`),
+			want: "selector is not from the snapshot - use click_by_index with an index from the elements list",
+		},
+		{
+			name: "not a parse error",
+			sel:  `#add-to-cart-button`,
+			err:  errors.New("Timeout 5000ms exceeded"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(browser.FakePage{URL: "https://shop.example.com/"})
+			ctrl.FailNext("WaitFor", tt.err)
+			box := New(ctrl, noPrompt)
+			box.SetSnapshot(&snapshot.Summary{URL: "https://shop.example.com/", Elements: shopElements})
+			_, err := box.Invoke(context.Background(), "click_selector", map[string]any{"selector": tt.sel})
+			if err == nil || !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want it to wrap %v", err, tt.err)
+			}
+			if tt.want == "" {
+				if strings.Contains(err.Error(), "click_by_index") {
+					t.Fatalf("err = %v: only parse errors get a hint", err)
+				}
+				return
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// extractTableRows is the default max_rows of extract_table
+	extractTableRows = 50
+	// extractTableMaxRows caps max_rows so the JSON fits the observation cap
+	extractTableMaxRows = 200
+)
+
+// tableObservation is the JSON extract_table returns to the planner
+type tableObservation struct {
+	Caption string     `json:"caption,omitempty"`
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+	Note    string     `json:"note,omitempty"`
+}
+
+// extractTable reads a table as JSON {headers, rows} with a note on cut or virtualized rows
+func (s *standard) extractTable(ctx context.Context, input map[string]any) (Result, error) {
+	maxRows := optionalInt(input, "max_rows")
+	if maxRows <= 0 {
+		maxRows = extractTableRows
+	}
+	if maxRows > extractTableMaxRows {
+		maxRows = extractTableMaxRows
+	}
+	table, err := s.ctrl.ExtractTable(ctx, optionalString(input, "selector"), maxRows)
+	if err != nil {
+		return Result{}, err
+	}
+	obs := tableObservation{Caption: table.Caption, Headers: table.Headers, Rows: table.Rows}
+	if obs.Headers == nil {
+		obs.Headers = []string{}
+	}
+	if obs.Rows == nil {
+		obs.Rows = [][]string{}
+	}
+	var notes []string
+	if len(table.Headers) == 0 {
+		notes = append(notes, "table has no header row")
+	}
+	if len(table.Rows) < table.TotalRows {
+		notes = append(notes, fmt.Sprintf("first %d of %d rows (raise max_rows for more)", len(table.Rows), table.TotalRows))
+	}
+	if table.Virtualized() {
+		notes = append(notes, fmt.Sprintf("virtualized grid: only the %d rendered rows of %d were read - scroll the grid and extract again for the rest", table.TotalRows, table.RowCount))
+	}
+	if table.Frame != "" {
+		notes = append(notes, "table is inside iframe "+table.Frame)
+	}
+	for i, n := range notes {
+		if i > 0 {
+			obs.Note += "; "
+		}
+		obs.Note += n
+	}
+	raw, err := json.Marshal(obs)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: string(raw)}, nil
+}
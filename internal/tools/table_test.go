@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func TestExtractTableObservation(t *testing.T) {
+	flights := browser.Table{
+		Caption:   "Flights to Berlin",
+		Headers:   []string{"Flight", "Price"},
+		Rows:      [][]string{{"SU 100", "12 000 ₽"}, {"LH 200", "Sold out"}, {"TK 300", "9 500 ₽"}},
+		TotalRows: 3,
+	}
+	page := browser.FakePage{Tables: map[string]browser.Table{
+		"":         flights,
+		"#details": {Rows: [][]string{{"Passenger", "Anna"}}, TotalRows: 1},
+		"#quotes":  {Headers: []string{"Ticker", "Price"}, Rows: [][]string{{"AAPL", "189.10"}}, TotalRows: 1, RowCount: 1000},
+		"#prices":  {Headers: []string{"Product"}, Rows: [][]string{{"Lamp"}}, TotalRows: 1, Frame: "https://shop.example.com/prices"},
+	}}
+	tests := []struct {
+		name     string
+		input    map[string]any
+		wantRows int
+		wantNote string
+	}{
+		{"auto-detected", map[string]any{}, 3, ""},
+		{"cut by max_rows", map[string]any{"max_rows": float64(2)}, 2, "first 2 of 3 rows (raise max_rows for more)"},
+		{"header-less", map[string]any{"selector": "#details"}, 1, "table has no header row"},
+		{"virtualized", map[string]any{"selector": "#quotes"}, 1, "virtualized grid: only the 1 rendered rows of 1000 were read"},
+		{"in a frame", map[string]any{"selector": "#prices"}, 1, "table is inside iframe https://shop.example.com/prices"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := New(browser.NewFakeController(page), noPrompt).Invoke(context.Background(), "extract_table", tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var obs tableObservation
+			if err := json.Unmarshal([]byte(res.Observation), &obs); err != nil {
+				t.Fatalf("observation is not JSON: %v\n%s", err, res.Observation)
+			}
+			if len(obs.Rows) != tt.wantRows || !strings.HasPrefix(obs.Note, tt.wantNote) || (tt.wantNote == "" && obs.Note != "") {
+				t.Errorf("observation = %s, want %d rows and note %q", res.Observation, tt.wantRows, tt.wantNote)
+			}
+			if !strings.Contains(res.Observation, `"headers":[`) {
+				t.Errorf("headers must be an array even when empty: %s", res.Observation)
+			}
+		})
+	}
+}
+
+func TestExtractTableMaxRowsBounds(t *testing.T) {
+	for _, tt := range []struct {
+		maxRows any
+		want    int
+	}{
+		{nil, extractTableRows},
+		{float64(0), extractTableRows},
+		{float64(10), 10},
+		{float64(5000), extractTableMaxRows},
+	} {
+		ctrl := browser.NewFakeController(browser.FakePage{Tables: map[string]browser.Table{"": {}}})
+		input := map[string]any{}
+		if tt.maxRows != nil {
+			input["max_rows"] = tt.maxRows
+		}
+		if _, err := New(ctrl, noPrompt).Invoke(context.Background(), "extract_table", input); err != nil {
+			t.Fatal(err)
+		}
+		calls := ctrl.CallsTo("ExtractTable")
+		if len(calls) != 1 || calls[0].Args[1] != tt.want {
+			t.Errorf("max_rows %v: calls %+v, want maxRows %d", tt.maxRows, calls, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+// listTabs renders the open tabs, one per line: [index] 'title' url (active)
+func (s *standard) listTabs(ctx context.Context) (Result, error) {
+	tabs, err := s.ctrl.ListTabs(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	lines := make([]string, 0, len(tabs)+1)
+	lines = append(lines, fmt.Sprintf("%d tabs open:", len(tabs)))
+	for _, t := range tabs {
+		lines = append(lines, tabLine(t))
+	}
+	return Result{Observation: strings.Join(lines, "\n")}, nil
+}
+
+func (s *standard) switchTab(ctx context.Context, input map[string]any) (Result, error) {
+	index, err := requiredInt(input, "index")
+	if err != nil {
+		return Result{}, err
+	}
+	tab, err := s.ctrl.SwitchTab(ctx, index)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: "switched to " + tabLine(tab)}, nil
+}
+
+func (s *standard) closeTab(ctx context.Context, input map[string]any) (Result, error) {
+	index, err := requiredInt(input, "index")
+	if err != nil {
+		return Result{}, err
+	}
+	tab, err := s.ctrl.CloseTab(ctx, index)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: fmt.Sprintf("closed tab %d, active: %s (indices of later tabs moved down by one)", index, tabLine(tab))}, nil
+}
+
+// tabSwitchNote tells the planner the action moved it to another tab
+func tabSwitchNote(sw browser.TabSwitch) string {
+	if sw.Reason == "closed" {
+		return sw.String()
+	}
+	return sw.String() + " (switch_tab to go back)"
+}
+
+func tabLine(t browser.Tab) string {
+	line := fmt.Sprintf("[%d] '%s' %s", t.Index, truncateRunes(t.Title, 60), t.URL)
+	if t.Active {
+		line += " (active)"
+	}
+	return line
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+// DescribeTarget resolves the element an action would act on and describes it.
+// Falls back to snapshot data for index-based actions when the live page lookup fails.
+func (s *standard) DescribeTarget(ctx context.Context, action string, input map[string]any) (browser.ElementInfo, error) {
+	target, el, err := s.targetFor(action, input)
+	if err != nil {
+		return browser.ElementInfo{}, err
+	}
+	info, err := s.ctrl.DescribeElement(ctx, target)
+	if err != nil && el != nil {
+		return browser.ElementInfo{Text: el.Text, Role: el.Role}, nil
+	}
+	return info, err
+}
+
+// HighlightTarget highlights the element an action would act on (headed mode only)
+func (s *standard) HighlightTarget(ctx context.Context, action string, input map[string]any) error {
+	target, _, err := s.targetFor(action, input)
+	if err != nil {
+		return err
+	}
+	return s.ctrl.Highlight(ctx, target)
+}
+
+// targetFor maps action input to an element target; returns the snapshot element for index actions
+func (s *standard) targetFor(action string, input map[string]any) (browser.ElementTarget, *snapshot.Element, error) {
+	if action == "fill_and_submit" {
+		// Index when given, selector otherwise - like the tool itself
+		action = "fill"
+		if _, ok := input["index"]; ok {
+			action = "fill_by_index"
+		}
+	}
+	switch action {
+	case "click_by_index", "fill_by_index":
+		index, err := requiredInt(input, "index")
+		if err != nil {
+			return browser.ElementTarget{}, nil, err
+		}
+		if s.curSnapshot == nil {
+			return browser.ElementTarget{}, nil, fmt.Errorf("snapshot not available")
+		}
+		for i := range s.curSnapshot.Elements {
+			el := &s.curSnapshot.Elements[i]
+			if el.Index != index {
+				continue
+			}
+			if el.Sel != "" {
+				return browser.ElementTarget{Selector: el.Sel}, el, nil
+			}
+			return browser.ElementTarget{Role: el.Role, Name: el.Text}, el, nil
+		}
+		return browser.ElementTarget{}, nil, fmt.Errorf("element with index %d not found in current snapshot", index)
+	case "click_selector", "fill":
+		sel, err := requiredString(input, "selector")
+		if err != nil {
+			return browser.ElementTarget{}, nil, err
+		}
+		return browser.ElementTarget{Selector: sel}, nil, nil
+	case "click_role":
+		role, err := requiredString(input, "role")
+		if err != nil {
+			return browser.ElementTarget{}, nil, err
+		}
+		return browser.ElementTarget{Role: role, Name: optionalString(input, "name")}, nil, nil
+	case "click_text":
+		text, err := requiredString(input, "text")
+		if err != nil {
+			return browser.ElementTarget{}, nil, err
+		}
+		return browser.ElementTarget{Text: text}, nil, nil
+	default:
+		return browser.ElementTarget{}, nil, fmt.Errorf("action %s has no element target", action)
+	}
+}
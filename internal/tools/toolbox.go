@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -16,8 +17,23 @@ type Toolbox interface {
 	Describe() []Tool
 	Invoke(ctx context.Context, name string, input map[string]any) (Result, error)
 	WaitForStableDOM(ctx context.Context, timeout time.Duration) error
+	// PageActivity reports whether the page is still loading or rendering (see Controller.Activity)
+	PageActivity(ctx context.Context) (browser.PageActivity, error)
 	Page() playwright.Page                 // For checking element existence
-	SetSnapshot(summary *snapshot.Summary) // Set current snapshot for collect_texts to find real indices
+	SetSnapshot(summary *snapshot.Summary) // Current snapshot: index tools resolve elements against it
+	DismissConsent(ctx context.Context) (browser.ConsentResult, error)
+	DescribeTarget(ctx context.Context, action string, input map[string]any) (browser.ElementInfo, error)
+	HighlightTarget(ctx context.Context, action string, input map[string]any) error
+	RecreateContext(ctx context.Context) error
+	// SelectorMatches lists texts of all elements the selector matches
+	SelectorMatches(ctx context.Context, selector string) ([]string, error)
+	CaptureScreenshot(ctx context.Context) ([]byte, error)   // Viewport PNG (vision mode)
+	Ask(ctx context.Context, message string) (string, error) // Raw answer from the prompt function
+	// FollowPages switches to a popup the active page opened, or back to the opener when the
+	// active popup closed itself (see Controller.AdoptOpenedTab)
+	FollowPages(ctx context.Context) (browser.TabSwitch, bool)
+	// TakeDownloads returns files downloaded since the last call that no tool reported yet
+	TakeDownloads() []browser.Download
 }
 
 type Tool struct {
@@ -28,45 +44,92 @@ type Tool struct {
 
 type Result struct {
 	Observation string
+	Artifacts   []string // Files written by the tool (actual paths)
 }
 
 type PromptFunc func(ctx context.Context, message string) (string, error)
 
+// Options configures optional toolbox capabilities.
+type Options struct {
+	OCR      snapshot.OCR // Enables read_page_ocr tool when set
+	Language string       // Language hint for OCR ("ru", "en")
+	// CredentialDomains are related sites (SSO) that may share credentials the user supplied;
+	// otherwise a value requested on one site is refused by fill tools on another
+	CredentialDomains []string
+	// UploadDir is the only directory upload_file takes files from; "" disables uploads
+	UploadDir string
+	// ScreenshotDir is the only directory the screenshot tool writes to; "" is the working directory
+	ScreenshotDir string
+	// MaxWait caps one wait or wait_for_lazy_list call (30s when zero)
+	MaxWait time.Duration
+}
+
 type standard struct {
 	ctrl        browser.Controller
 	prompt      PromptFunc
 	tools       []Tool
 	curSnapshot *snapshot.Summary // Current snapshot for finding real indices
+	opts        Options
+	credentials []credential // request_user_input answers tagged with the site that asked
 }
 
 func New(ctrl browser.Controller, prompt PromptFunc) Toolbox {
-	return &standard{
+	return NewWithOptions(ctrl, prompt, Options{})
+}
+
+// NewWithOptions creates toolbox with optional capabilities enabled.
+func NewWithOptions(ctrl browser.Controller, prompt PromptFunc, opts Options) Toolbox {
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = defaultMaxWait
+	}
+	s := &standard{
 		ctrl:        ctrl,
 		prompt:      prompt,
 		curSnapshot: nil,
+		opts:        opts,
 		tools: []Tool{
 			newTool("navigate", "Open URL", schema{"url": str("url to open")}, []string{"url"}),
-			newTool("go_back", "Navigate back in browser history (use when you need to return to previous page)", schema{}, nil),
+			newTool("go_back", "Navigate back in browser history - the way to return to a list or search results after opening an item (keeps filters and scroll position)", schema{}, nil),
+			newTool("go_forward", "Navigate forward in browser history (undo a go_back)", schema{}, nil),
+			newTool("list_tabs", "List open browser tabs with their index, title and URL", schema{}, nil),
+			newTool("switch_tab", "Make another open tab the active page (index from list_tabs or the TABS line)", schema{"index": integer("tab index (0-based)")}, []string{"index"}),
+			newTool("close_tab", "Close a tab you no longer need; closing the active tab returns to the tab that opened it", schema{"index": integer("tab index (0-based)")}, []string{"index"}),
 			newTool("click_by_index", "Click element by index from snapshot (PREFERRED - use index from elements list, e.g. [1], [2], [3])", schema{"index": integer("element index from snapshot (1-based)")}, []string{"index"}),
 			newTool("click_text", "Click element by visible text", schema{"text": str("text to click"), "exact": boolean("exact match")}, []string{"text"}),
 			newTool("click_role", "Click element by role (button/link/checkbox/radio/option) and name", schema{"role": str("aria role"), "name": str("visible label"), "exact": boolean("exact name match")}, []string{"role"}),
-			newTool("click_selector", "Click element by CSS selector (fallback when index not available)", schema{"selector": str("CSS selector")}, []string{"selector"}),
+			newTool("click_selector", "Click element by CSS selector (fallback when index not available)", schema{"selector": str("CSS selector"), "wait_enabled_ms": integer(fmt.Sprintf("if the element is disabled, wait up to this many ms for it to become enabled (optional, max %d)", DisabledClickWaitMs))}, []string{"selector"}),
 			newTool("click_text_fuzzy", "Click element by partial text match (fallback when exact match fails)", schema{"text": str("partial text to match")}, []string{"text"}),
 			newTool("click_coordinates", "Click at specific coordinates from element bbox (last resort fallback)", schema{"x": integer("x coordinate"), "y": integer("y coordinate")}, []string{"x", "y"}),
 			newTool("fill_by_index", "Fill input by index from snapshot (PREFERRED - use index from elements list, e.g. [1], [2], [3])", schema{"index": integer("element index from snapshot (1-based)"), "text": str("text to type")}, []string{"index", "text"}),
 			newTool("fill", "Fill input by CSS selector (fallback when index not available)", schema{"selector": str("CSS selector"), "text": str("text to type")}, []string{"selector", "text"}),
+			newTool("fill_and_submit", "Search shortcut: fill an input (by index or selector), press Enter on it and wait until the page navigates or results update. Use for search boxes instead of fill + press_key", schema{"index": integer("element index from snapshot (preferred)"), "selector": str("CSS selector (when no index)"), "text": str("text to type")}, []string{"text"}),
+			newTool("set_date", "Set a date input or JS date picker (readonly inputs, calendar popups) to an ISO date. Use instead of clicking calendar cells", schema{"index": integer("element index from snapshot (preferred)"), "selector": str("CSS selector (when no index)"), "date": str("date as YYYY-MM-DD")}, []string{"date"}),
+			newTool("hover", "Move the mouse over an element to reveal controls that only show on hover (dropdown menus, row action icons like delete/archive in mail lists). Reports whether new elements appeared", schema{"index": integer("element index from snapshot (preferred)"), "selector": str("CSS selector (when no index)")}, nil),
+			newTool("choose_combobox_option", "Pick an option of a dropdown by its text: custom comboboxes (react-select style, options appear only while open; multi-selects - one call per value) and native <select>. Opens it, types to filter, clicks the option and reads the value back", schema{"index": integer("element index of the combobox or its input (preferred)"), "selector": str("CSS selector (when no index)"), "option": str("visible text of the option to pick")}, []string{"option"}),
+			newTool("wait_for_download", "Wait for the file a click started downloading (\"Download PDF\", export buttons) and return the saved path and file name. Call it right after the click", schema{"timeout_ms": integer("max wait in ms (default 15000, max 120000)")}, nil),
+			newTool("upload_file", "Attach a file from the upload directory to a file input or upload button (hidden inputs and custom buttons that open a file dialog work too). Never click upload buttons yourself - the OS dialog can't be used", schema{"index": integer("element index of the file input or upload button (preferred)"), "selector": str("CSS selector (when no index; defaults to the first file input)"), "path": str("file name in the upload directory")}, []string{"path"}),
+			newTool("press_key", "Press a keyboard key, optionally focusing an element first. Use Enter after fill/fill_by_index to submit search boxes and login forms when there is no visible submit button; Escape closes popups, ArrowDown/Enter pick combobox options, Tab moves to next field", schema{"key": str("key name: Enter, Escape, Tab, ArrowDown, ArrowUp, Backspace, PageDown, or combination like Control+A"), "selector": str("CSS selector to focus before pressing (optional, defaults to focused element)")}, []string{"key"}),
 			newTool("scroll_page", "Scroll page up/down/top/bottom. Distance is optional - if not provided, uses viewport height (~600-1000px). Use sparingly, max 1-2 times.", schema{"direction": str("down|up|top|bottom|page_down|page_up"), "distance": integer("pixels, optional (defaults to viewport height if not provided)")}, nil),
 			newTool("scroll_to_element", "Scroll element into view before clicking", schema{"selector": str("CSS selector")}, []string{"selector"}),
 			newTool("wait_for", "Wait for selector visible", schema{"selector": str("CSS selector"), "timeout_ms": integer("timeout ms")}, []string{"selector"}),
-			newTool("wait_for_lazy_list", "Wait for lazy-loaded list items to appear (for dynamic content like messages, posts, items)", schema{"timeout_ms": integer("timeout ms")}, nil),
+			newTool("wait_for_lazy_list", fmt.Sprintf("Wait for lazy-loaded list items to appear (for dynamic content like messages, posts, items). Max %s. Don't repeat it when the list is already in the snapshot - the page settles after every action anyway", opts.MaxWait), schema{"timeout_ms": integer("timeout ms")}, nil),
 			newTool("wait_for_lazy_content", "Wait for lazy-loaded content to appear after scroll", schema{"selector": str("CSS selector to wait for"), "timeout_ms": integer("timeout ms")}, []string{"selector"}),
 			newTool("read_page", "Read text from page or element by selector (use when snapshot doesn't show target elements, especially for iframe content)", schema{"selector": str("CSS selector (empty for full page)"), "max_chars": integer("max characters to return")}, nil),
+			newTool("page_to_markdown", "Read the page (or the element matching selector) as Markdown: headings, lists, links with URLs, tables and emphasis. Use for summaries and when you need the links or the structure of an article; includes same-origin iframes", schema{"selector": str("CSS selector to scope the page part (optional)"), "max_chars": integer("max characters to return (default 5000)")}, nil),
+			newTool("read_by_index", "Read the full text of one element by index from snapshot (long descriptions, table rows, messages cut short in the elements list)", schema{"index": integer("element index from snapshot (1-based)"), "max_chars": integer("max characters to return")}, []string{"index"}),
+			newTool("extract_table", "Read a table or data grid as JSON {headers, rows} (prices, schedules, search results in a table). Without selector reads the largest table on the page, including iframes", schema{"selector": str("CSS selector of the table or its container (optional)"), "max_rows": integer("max rows to return (default 50, max 200)")}, nil),
+			newTool("list_elements", "Page through ALL elements of the current snapshot, including those not shown in the elements list (long pages). Filter by role and/or text to find an element's index without scrolling", schema{"offset": integer("number of matching elements to skip (default 0)"), "limit": integer("max elements to return (default 50, max 100)"), "role": str("exact role to keep, e.g. link, button, textbox (optional)"), "text_contains": str("case-insensitive substring of the element text (optional)")}, nil),
 			newTool("collect_texts", "Collect texts AND selectors from elements by selector (use when snapshot doesn't show target elements, especially for iframe content). Returns both text and selector for each element so you can click them.", schema{"selector": str("CSS selector"), "attribute": str("attribute name instead of text"), "limit": integer("max elements to collect")}, []string{"selector"}),
 			newTool("request_user_input", "Ask user for data needed to fill form fields (login, password, email, etc.). After receiving the data, use fill_by_index or fill to enter it into the field. The response will be formatted as 'User provided: <value> (use this value in your next action)' - extract the value and use it in fill_by_index or fill.", schema{"prompt": str("question to user (e.g., 'Please provide your login/email', 'Please provide your password')")}, []string{"prompt"}),
-			newTool("wait", "Wait for specified number of seconds. Use when waiting for page to load, user to complete action (like login), or for dynamic content to appear. Maximum 30 seconds per call.", schema{"seconds": integer("seconds to wait (1-30)")}, []string{"seconds"}),
+			newTool("wait", fmt.Sprintf("Wait for specified number of seconds. Use when waiting for page to load, user to complete action (like login), or for dynamic content to appear. Maximum %s per call. Use sparingly: the page already settles after every action, and waiting on a page that doesn't change wastes steps", opts.MaxWait), schema{"seconds": integer(fmt.Sprintf("seconds to wait (1-%d)", int(opts.MaxWait/time.Second)))}, []string{"seconds"}),
+			newTool("screenshot", "Save a PNG screenshot of the page (for the user or for debugging)", schema{"path": str("PNG file name in the screenshot directory (optional, defaults to screenshot_<timestamp>.png)"), "full_page": boolean("capture the full scrollable page instead of the viewport")}, nil),
 			newTool("save_state", "Save current storage state", schema{"path": str("path to save")}, []string{"path"}),
 		},
 	}
+	if opts.OCR != nil {
+		s.tools = append(s.tools, newTool("read_page_ocr", "Read text from a screenshot of the visible viewport via OCR (use when read_page returns nothing, e.g. canvas dashboards or PDF viewers)", schema{"max_chars": integer("max characters to return")}, nil))
+	}
+	return s
 }
 
 func (s *standard) Describe() []Tool {
@@ -74,6 +137,31 @@ func (s *standard) Describe() []Tool {
 }
 
 func (s *standard) Invoke(ctx context.Context, name string, input map[string]any) (Result, error) {
+	res, err := s.invoke(ctx, name, input)
+	// Follow a tab the action opened (target=_blank, window.open) so the next snapshot shows it
+	if sw, ok := s.ctrl.AdoptOpenedTab(ctx); ok {
+		note := tabSwitchNote(sw)
+		if err != nil {
+			err = fmt.Errorf("%w; %s", err, note)
+		} else {
+			res.Observation = strings.TrimSpace(res.Observation + "\n" + note)
+		}
+	}
+	// Surface blocked form submissions and navigations so the planner knows why nothing happened
+	if blocked := s.ctrl.TakeBlockedSubmissions(); len(blocked) > 0 {
+		note := "⛔ BLOCKED: " + strings.Join(blocked, "; ")
+		if err != nil {
+			return res, fmt.Errorf("%w; %s", err, note)
+		}
+		res.Observation = strings.TrimSpace(res.Observation + "\n" + note)
+	}
+	if notes := s.ctrl.TakeNotes(); len(notes) > 0 {
+		res.Observation = strings.TrimSpace(res.Observation + "\nBrowser: " + strings.Join(notes, "; "))
+	}
+	return res, err
+}
+
+func (s *standard) invoke(ctx context.Context, name string, input map[string]any) (Result, error) {
 	switch name {
 	case "navigate":
 		url, err := requiredString(input, "url")
@@ -86,10 +174,22 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		return Result{Observation: fmt.Sprintf("opened %s", url)}, nil
 
 	case "go_back":
-		if err := s.ctrl.GoBack(ctx); err != nil {
-			return Result{}, err
-		}
-		return Result{Observation: "navigated back in browser history"}, nil
+		return s.historyStep(ctx, "back", s.ctrl.GoBack)
+
+	case "go_forward":
+		return s.historyStep(ctx, "forward", s.ctrl.GoForward)
+
+	case "list_tabs":
+		return s.listTabs(ctx)
+
+	case "switch_tab":
+		return s.switchTab(ctx, input)
+
+	case "close_tab":
+		return s.closeTab(ctx, input)
+
+	case "click_by_index":
+		return s.clickByIndex(ctx, input)
 
 	case "click_text":
 		text, err := requiredString(input, "text")
@@ -133,7 +233,21 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		// Use WaitFor with adequate timeout for SPA and lazy loading (5s instead of 2s)
 		if err := s.ctrl.WaitFor(ctx, sel, 5*time.Second); err != nil {
 			// Element doesn't exist or not visible - return error
-			return Result{}, fmt.Errorf("element not found or not visible: %w", err)
+			return Result{}, s.withNearestElement(fmt.Errorf("element not found or not visible: %w", err), sel)
+		}
+		// Submit buttons are often disabled for a moment while client-side validation runs
+		enabledNote := ""
+		// The model picks the wait - capped, or one decision could stall the run
+		if waitMs := min(optionalInt(input, "wait_enabled_ms"), DisabledClickWaitMs); waitMs > 0 {
+			started := time.Now()
+			enabled, err := s.ctrl.WaitForEnabled(ctx, sel, time.Duration(waitMs)*time.Millisecond)
+			if err != nil {
+				return Result{}, err
+			}
+			if !enabled {
+				return Result{Observation: fmt.Sprintf("NOT clicked: element %s stayed disabled for %dms - the form probably has a validation error or a missing required field; look for error messages near the inputs", sel, waitMs)}, nil
+			}
+			enabledNote = fmt.Sprintf(" (element was disabled, became enabled after %dms)", time.Since(started).Milliseconds())
 		}
 		// Try scrolling to element first
 		if err := s.ctrl.ScrollToElement(ctx, sel); err != nil {
@@ -144,9 +258,9 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		_ = s.ctrl.Hover(ctx, sel)
 		time.Sleep(200 * time.Millisecond) // Brief pause for hover effects
 		if err := s.ctrl.Click(ctx, sel); err != nil {
-			return Result{}, err
+			return Result{}, s.withNearestElement(err, sel)
 		}
-		return Result{Observation: fmt.Sprintf("clicked selector %s", sel)}, nil
+		return Result{Observation: fmt.Sprintf("clicked selector %s%s", sel, enabledNote)}, nil
 
 	case "click_text_fuzzy":
 		text, err := requiredString(input, "text")
@@ -183,14 +297,7 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		return Result{Observation: fmt.Sprintf("scrolled to element %s", sel)}, nil
 
 	case "wait_for_lazy_list":
-		timeout := optionalInt(input, "timeout_ms")
-		if timeout <= 0 {
-			timeout = 10000
-		}
-		if err := s.ctrl.WaitForLazyListItems(ctx, time.Duration(timeout)*time.Millisecond); err != nil {
-			return Result{}, err
-		}
-		return Result{Observation: "list items appeared"}, nil
+		return s.waitForLazyList(ctx, input)
 
 	case "wait_for_lazy_content":
 		// Wait for lazy-loaded content after scroll
@@ -241,11 +348,59 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 			}
 		}
 
-		if len(content) > maxChars {
-			content = content[:maxChars] + "..."
-		}
+		content = ellipsize(content, maxChars)
 		return Result{Observation: content}, nil
 
+	case "page_to_markdown":
+		maxChars := optionalInt(input, "max_chars")
+		if maxChars <= 0 {
+			maxChars = 5000
+		}
+		md, err := s.ctrl.ReadMarkdown(ctx, optionalString(input, "selector"), maxChars)
+		if err != nil {
+			return Result{}, err
+		}
+		if strings.TrimSpace(md) == "" {
+			return Result{Observation: "no readable text (canvas, images or a cross-origin iframe) - try read_page or read_page_ocr"}, nil
+		}
+		return Result{Observation: md}, nil
+
+	case "read_by_index":
+		return s.readByIndex(ctx, input)
+
+	case "list_elements":
+		return s.listElements(input)
+
+	case "extract_table":
+		return s.extractTable(ctx, input)
+
+	case "hover":
+		return s.hover(ctx, input)
+
+	case "choose_combobox_option":
+		return s.chooseComboboxOption(ctx, input)
+
+	case "upload_file":
+		return s.uploadFile(ctx, input)
+
+	case "wait_for_download":
+		return s.waitForDownload(ctx, input)
+
+	case "read_page_ocr":
+		maxChars := optionalInt(input, "max_chars")
+		if maxChars <= 0 {
+			maxChars = 5000
+		}
+		text, err := snapshot.RecognizeViewport(ctx, s.ctrl, s.opts.OCR, s.opts.Language)
+		if err != nil {
+			return Result{}, err
+		}
+		if strings.TrimSpace(text) == "" {
+			return Result{Observation: snapshot.OCRMarker + " no text recognized in viewport"}, nil
+		}
+		text = ellipsize(text, maxChars)
+		return Result{Observation: snapshot.OCRMarker + " " + text}, nil
+
 	case "collect_texts":
 		selector, err := requiredString(input, "selector")
 		if err != nil {
@@ -264,7 +419,7 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		type itemData struct {
 			Text     string `json:"text"`
 			Selector string `json:"selector"`
-			Index    int    `json:"index"`
+			Index    int    `json:"index,omitempty"` // Snapshot index, omitted when not in snapshot
 		}
 		items := make([]itemData, 0, limit)
 
@@ -349,35 +504,8 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 							selStr = fmt.Sprintf("%s:nth-of-type(%d)", selector, i+1)
 						}
 					}
-					// Find element in snapshot by selector or text to get real index
-					itemIndex := 0
-					if s.curSnapshot != nil {
-						// Try to find element in snapshot by selector or text
-						for _, el := range s.curSnapshot.Elements {
-							// Match by selector (exact or contains)
-							if el.Sel == selStr || strings.Contains(el.Sel, selStr) || strings.Contains(selStr, el.Sel) {
-								itemIndex = el.Index
-								break
-							}
-							// Match by text (first 50 chars for comparison)
-							textPreview := text
-							if len(textPreview) > 50 {
-								textPreview = textPreview[:50]
-							}
-							elTextPreview := el.Text
-							if len(elTextPreview) > 50 {
-								elTextPreview = elTextPreview[:50]
-							}
-							if strings.Contains(elTextPreview, textPreview) || strings.Contains(textPreview, elTextPreview) {
-								itemIndex = el.Index
-								break
-							}
-						}
-					}
-					// If not found in snapshot, use sequential index (fallback)
-					if itemIndex == 0 {
-						itemIndex = len(items) + 1
-					}
+					// Real snapshot index; 0 if the element is not in the snapshot
+					itemIndex := s.snapshotIndex(selStr, text)
 					items = append(items, itemData{
 						Text:     text,
 						Selector: selStr,
@@ -439,35 +567,8 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 								selStr = fmt.Sprintf("%s:nth-of-type(%d)", selector, i+1)
 							}
 						}
-						// Find element in snapshot by selector or text to get real index
-						itemIndex := 0
-						if s.curSnapshot != nil {
-							// Try to find element in snapshot by selector or text
-							for _, el := range s.curSnapshot.Elements {
-								// Match by selector (exact or contains)
-								if el.Sel == selStr || strings.Contains(el.Sel, selStr) || strings.Contains(selStr, el.Sel) {
-									itemIndex = el.Index
-									break
-								}
-								// Match by text (first 50 chars for comparison)
-								textPreview := text
-								if len(textPreview) > 50 {
-									textPreview = textPreview[:50]
-								}
-								elTextPreview := el.Text
-								if len(elTextPreview) > 50 {
-									elTextPreview = elTextPreview[:50]
-								}
-								if strings.Contains(elTextPreview, textPreview) || strings.Contains(textPreview, elTextPreview) {
-									itemIndex = el.Index
-									break
-								}
-							}
-						}
-						// If not found in snapshot, use sequential index (fallback)
-						if itemIndex == 0 {
-							itemIndex = len(items) + 1
-						}
+						// Real snapshot index; 0 if the element is not in the snapshot
+						itemIndex := s.snapshotIndex(selStr, text)
 						items = append(items, itemData{
 							Text:     text,
 							Selector: selStr,
@@ -503,14 +604,17 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 			if newlineIdx := strings.Index(textPreview, "\n"); newlineIdx > 0 {
 				textPreview = textPreview[:newlineIdx]
 			}
-			if len(textPreview) > 60 {
-				textPreview = textPreview[:60] + "..."
-			}
+			textPreview = ellipsize(textPreview, 60)
 			// Validate selector before showing
 			if item.Selector == "" || strings.Contains(item.Selector, "undefined") || strings.Contains(item.Selector, "NaN") {
 				// Fallback to simple nth-of-type
 				item.Selector = fmt.Sprintf("%s:nth-of-type(%d)", selector, i+1)
 			}
+			if item.Index == 0 {
+				// Never invent an index - it would collide with a real snapshot element
+				responseBuilder.WriteString(fmt.Sprintf("[-] text=\"%s\" → not in snapshot, USE click_selector with selector=%q\n", textPreview, item.Selector))
+				continue
+			}
 			responseBuilder.WriteString(fmt.Sprintf("[%d] text=\"%s\" → USE click_by_index with index=%d\n", item.Index, textPreview, item.Index))
 		}
 		if len(items) > maxShow {
@@ -518,7 +622,7 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		}
 
 		responseBuilder.WriteString("\n📋 ACTION REQUIRED: Use click_by_index with index from items[0].index to open first item!\n")
-		if len(items) > 0 {
+		if len(items) > 0 && items[0].Index > 0 {
 			responseBuilder.WriteString(fmt.Sprintf("Example: click_by_index with index=%d\n\n", items[0].Index))
 		}
 
@@ -526,7 +630,7 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		payload := map[string]any{
 			"items":       items,
 			"count":       len(items),
-			"instruction": "Use click_by_index with items[].index to click on elements. DO NOT use click_selector!",
+			"instruction": "Use click_by_index with items[].index to click on elements. Only items without index (not in snapshot) need click_selector with items[].selector.",
 		}
 		if len(items) > 0 {
 			payload["example"] = fmt.Sprintf("click_selector with selector=\"%s\"", items[0].Selector)
@@ -593,16 +697,19 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 			}
 			return Result{}, fmt.Errorf("element with index %d not found in current snapshot. Available indices: %v", indexInt, availableIndices)
 		}
+		if note := s.credentialMismatch(text, s.fillTargetURL(foundElement.FrameURL)); note != "" {
+			return Result{Observation: note}, nil
+		}
+		if foundElement.FrameURL != "" {
+			// Selector and role only resolve inside the element's own frame
+			return s.fillInFrame(ctx, foundElement, text)
+		}
 		// Use selector from element, or fallback to role-based selector
 		sel := foundElement.Sel
 		if sel == "" && foundElement.Role != "" {
 			// Fallback: try role-based selector
 			if foundElement.Text != "" {
-				textLen := len(foundElement.Text)
-				if textLen > 30 {
-					textLen = 30
-				}
-				sel = fmt.Sprintf(`[role="%s"][aria-label*="%s"]`, foundElement.Role, foundElement.Text[:textLen])
+				sel = fmt.Sprintf(`[role="%s"][aria-label*="%s"]`, cssString(foundElement.Role), cssString(truncateRunes(foundElement.Text, 30)))
 			} else {
 				sel = fmt.Sprintf(`[role="%s"]`, foundElement.Role)
 			}
@@ -685,11 +792,101 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		if err != nil {
 			return Result{}, err
 		}
+		if note := s.credentialMismatch(text, s.fillTargetURL("")); note != "" {
+			return Result{Observation: note}, nil
+		}
 		if err := s.ctrl.Fill(ctx, sel, text); err != nil {
 			return Result{}, err
 		}
 		return Result{Observation: fmt.Sprintf("filled %s", sel)}, nil
 
+	case "fill_and_submit":
+		text, err := requiredString(input, "text")
+		if err != nil {
+			return Result{}, err
+		}
+		sel := optionalString(input, "selector")
+		if _, ok := input["index"]; ok {
+			el, err := s.elementByIndex(optionalInt(input, "index"))
+			if err != nil {
+				return Result{}, err
+			}
+			if el.Sel == "" || el.FrameURL != "" {
+				return Result{}, fmt.Errorf("element [%d] has no page-level selector (no selector or inside an iframe) - use fill_by_index and press_key", el.Index)
+			}
+			sel = el.Sel
+		}
+		if sel == "" {
+			return Result{}, fmt.Errorf("fill_and_submit needs index or selector")
+		}
+		if note := s.credentialMismatch(text, s.fillTargetURL("")); note != "" {
+			return Result{Observation: note}, nil
+		}
+		res, err := s.ctrl.FillAndSubmit(ctx, sel, text)
+		if err != nil {
+			return Result{}, err
+		}
+		// Like fill, the observation never repeats the typed text - it may be a secret
+		obs := fmt.Sprintf("filled %s and pressed Enter", sel)
+		if res.Value != text {
+			obs += " (the field does not hold the exact text - it was reformatted or cut)"
+		}
+		switch res.Outcome {
+		case browser.SubmitNavigation:
+			obs += "; page navigated to " + res.URL
+		case browser.SubmitDOMChange:
+			obs += "; page content updated (same URL)"
+		default:
+			obs += "; no navigation or page change detected - look for a search button"
+		}
+		return Result{Observation: obs}, nil
+
+	case "set_date":
+		raw, err := requiredString(input, "date")
+		if err != nil {
+			return Result{}, err
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(raw))
+		if err != nil {
+			return Result{}, fmt.Errorf("date must be YYYY-MM-DD, got %q", raw)
+		}
+		sel := optionalString(input, "selector")
+		if _, ok := input["index"]; ok {
+			el, err := s.elementByIndex(optionalInt(input, "index"))
+			if err != nil {
+				return Result{}, err
+			}
+			if el.Sel == "" {
+				return Result{}, fmt.Errorf("element [%d] has no selector - use collect_texts to find the date input", el.Index)
+			}
+			if el.FrameURL != "" {
+				return Result{}, fmt.Errorf("element [%d] is inside an iframe - set_date works on the main page only, use fill_by_index with the date", el.Index)
+			}
+			sel = el.Sel
+		}
+		if sel == "" {
+			return Result{}, fmt.Errorf("set_date needs index or selector")
+		}
+		strategy, value, err := s.ctrl.SetDate(ctx, sel, date)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Observation: fmt.Sprintf("set date %s on %s via %s (field reads %q)", date.Format("2006-01-02"), sel, strategy, value)}, nil
+
+	case "press_key":
+		key, err := requiredString(input, "key")
+		if err != nil {
+			return Result{}, err
+		}
+		sel := optionalString(input, "selector")
+		if err := s.ctrl.PressKey(ctx, sel, key); err != nil {
+			return Result{}, err
+		}
+		if sel != "" {
+			return Result{Observation: fmt.Sprintf("pressed %s on %s", key, sel)}, nil
+		}
+		return Result{Observation: fmt.Sprintf("pressed %s", key)}, nil
+
 	case "scroll_page":
 		dir := optionalString(input, "direction")
 		dist := optionalInt(input, "distance")
@@ -729,6 +926,11 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 		if err != nil {
 			return Result{}, err
 		}
+		if s.needsBrowserInteraction(msg) {
+			// The window often opens behind the terminal - show the user where to act.
+			// Best effort: the prompt works without it
+			_ = s.ctrl.BringToFront(ctx)
+		}
 		answer, err := s.prompt(ctx, msg)
 		if err != nil {
 			return Result{}, err
@@ -739,33 +941,29 @@ func (s *standard) Invoke(ctx context.Context, name string, input map[string]any
 			return Result{Observation: "User confirmed: action completed (e.g., captcha solved). Continue with the task."}, nil
 		}
 		// Otherwise, return the value directly (like browser-use-reference does) - the LLM will use it in the next action
+		s.noteCredential(msg, answer)
 		return Result{Observation: answer}, nil
 
 	case "wait":
-		seconds := optionalInt(input, "seconds")
-		if seconds <= 0 {
-			seconds = 3 // Default 3 seconds
-		}
-		if seconds > 30 {
-			seconds = 30 // Cap at 30 seconds
-		}
-		// Reduce by 1 second to account for LLM call time (like browser-use)
-		actualSeconds := seconds - 1
-		if actualSeconds < 0 {
-			actualSeconds = 0
-		}
-		time.Sleep(time.Duration(actualSeconds) * time.Second)
-		return Result{Observation: fmt.Sprintf("waited for %d seconds", seconds)}, nil
+		return s.wait(ctx, input)
+
+	case "screenshot":
+		return s.screenshot(ctx, input)
 
 	case "save_state":
 		path, err := requiredString(input, "path")
 		if err != nil {
 			return Result{}, err
 		}
-		if err := s.ctrl.SaveState(ctx, path); err != nil {
+		used, err := s.ctrl.SaveState(ctx, path)
+		var fallback *browser.StateFallbackError
+		if errors.As(err, &fallback) {
+			return Result{Observation: fmt.Sprintf("state saved to %s (requested %s failed: %v)", used, path, fallback.Err), Artifacts: []string{used}}, nil
+		}
+		if err != nil {
 			return Result{}, err
 		}
-		return Result{Observation: fmt.Sprintf("state saved to %s", path)}, nil
+		return Result{Observation: fmt.Sprintf("state saved to %s", used), Artifacts: []string{used}}, nil
 	default:
 		return Result{}, fmt.Errorf("unknown tool %s", name)
 	}
@@ -872,6 +1070,23 @@ func requiredInt(input map[string]any, key string) (int, error) {
 	}
 }
 
+// elementByIndex looks up an element of the current snapshot
+func (s *standard) elementByIndex(index int) (*snapshot.Element, error) {
+	if s.curSnapshot == nil {
+		return nil, fmt.Errorf("snapshot not available - cannot resolve index %d", index)
+	}
+	for i := range s.curSnapshot.Elements {
+		if s.curSnapshot.Elements[i].Index == index {
+			return &s.curSnapshot.Elements[i], nil
+		}
+	}
+	availableIndices := make([]int, 0, len(s.curSnapshot.Elements))
+	for _, el := range s.curSnapshot.Elements {
+		availableIndices = append(availableIndices, el.Index)
+	}
+	return nil, fmt.Errorf("element with index %d not found in current snapshot. Available indices: %v", index, availableIndices)
+}
+
 func optionalInt(input map[string]any, key string) int {
 	val, ok := input[key]
 	if !ok {
@@ -919,9 +1134,7 @@ func sanitizeSelector(sel string) string {
 				// Remove newlines and limit length to prevent invalid selectors
 				value = strings.ReplaceAll(value, "\n", " ")
 				value = strings.ReplaceAll(value, "\r", " ")
-				if len(value) > 50 {
-					value = value[:50]
-				}
+				value = truncateRunes(value, 50)
 				// Reconstruct
 				sel = parts[0] + "aria-label*=" + value + valuePart[idx:]
 			}
@@ -937,6 +1150,142 @@ func (s *standard) WaitForStableDOM(ctx context.Context, timeout time.Duration)
 	return s.ctrl.WaitForStableDOM(ctx, timeout)
 }
 
+func (s *standard) PageActivity(ctx context.Context) (browser.PageActivity, error) {
+	return s.ctrl.Activity(ctx)
+}
+
+// browserPromptWords mark requests the user answers in the browser window, not the terminal
+var browserPromptWords = []string{
+	"captcha", "капч", "robot", "робот", "2fa", "two-factor", "двухфактор",
+	"verification code", "код подтверждения", "sms", "смс", "in the browser", "в браузере",
+	"log in", "login", "войдите", "авториз",
+}
+
+// needsBrowserInteraction reports whether answering the prompt likely requires acting in the
+// browser: a captcha page, or a prompt about captcha, 2FA codes or logging in
+func (s *standard) needsBrowserInteraction(message string) bool {
+	if page := s.ctrl.Page(); page != nil && strings.Contains(strings.ToLower(page.URL()), "captcha") {
+		return true
+	}
+	msg := strings.ToLower(message)
+	for _, w := range browserPromptWords {
+		if strings.Contains(msg, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *standard) DismissConsent(ctx context.Context) (browser.ConsentResult, error) {
+	return s.ctrl.DismissConsent(ctx)
+}
+
+func (s *standard) Ask(ctx context.Context, message string) (string, error) {
+	if s.prompt == nil {
+		return "", fmt.Errorf("prompt unavailable")
+	}
+	return s.prompt(ctx, message)
+}
+
+func (s *standard) CaptureScreenshot(ctx context.Context) ([]byte, error) {
+	return s.ctrl.Screenshot(ctx, "", false)
+}
+
+// maxSelectorMatches bounds candidates listed for an ambiguous selector
+const maxSelectorMatches = 10
+
+func (s *standard) SelectorMatches(ctx context.Context, selector string) ([]string, error) {
+	return s.ctrl.MatchTexts(ctx, selector, maxSelectorMatches)
+}
+
+func (s *standard) RecreateContext(ctx context.Context) error {
+	return s.ctrl.Recreate(ctx)
+}
+
+func (s *standard) FollowPages(ctx context.Context) (browser.TabSwitch, bool) {
+	return s.ctrl.AdoptOpenedTab(ctx)
+}
+
+// historyStep moves back or forward in browser history and reports the URL it arrived at
+func (s *standard) historyStep(ctx context.Context, direction string, step func(context.Context) error) (Result, error) {
+	before := s.ctrl.Page().URL()
+	if err := step(ctx); err != nil {
+		return Result{}, err
+	}
+	after := s.ctrl.Page().URL()
+	if after == before {
+		return Result{Observation: fmt.Sprintf("no %s page in browser history - still on %s", direction, after)}, nil
+	}
+	return Result{Observation: fmt.Sprintf("navigated %s to %s", direction, after)}, nil
+}
+
+// snapshotTextRunes is where snapshot element texts are cut (snapshot package)
+const snapshotTextRunes = 120
+
+// snapshotIndex finds the snapshot Element.Index for a collected item: the one element with
+// exactly this selector, otherwise the one element with the same normalized text. Returns 0 when
+// nothing or several elements match - callers must not substitute a positional index.
+func (s *standard) snapshotIndex(selector, text string) int {
+	if s.curSnapshot == nil {
+		return 0
+	}
+	if selector != "" {
+		if index := uniqueIndex(s.curSnapshot.Elements, func(el snapshot.Element) bool { return el.Sel == selector }); index != 0 {
+			return index
+		}
+	}
+	want := normalizeItemText(text)
+	if want == "" {
+		return 0
+	}
+	return uniqueIndex(s.curSnapshot.Elements, func(el snapshot.Element) bool { return normalizeItemText(el.Text) == want })
+}
+
+// uniqueIndex is the index of the only element accepted by match, 0 for none or several
+func uniqueIndex(elements []snapshot.Element, match func(snapshot.Element) bool) int {
+	index := 0
+	for _, el := range elements {
+		if !match(el) {
+			continue
+		}
+		if index != 0 {
+			return 0
+		}
+		index = el.Index
+	}
+	return index
+}
+
+// normalizeItemText collapses whitespace, folds case and cuts like snapshot texts are cut
+func normalizeItemText(text string) string {
+	return strings.ToLower(strings.TrimSpace(truncateRunes(strings.Join(strings.Fields(text), " "), snapshotTextRunes)))
+}
+
+// truncateRunes returns at most n runes of s without splitting a multi-byte character
+func truncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
+// cssString escapes s for a double-quoted CSS attribute value; line breaks become spaces
+func cssString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// ellipsize cuts s to n runes, adding "..." only when something was cut
+func ellipsize(s string, n int) string {
+	if cut := truncateRunes(s, n); len(cut) < len(s) {
+		return cut + "..."
+	}
+	return s
+}
+
 func (s *standard) SetSnapshot(summary *snapshot.Summary) {
 	s.curSnapshot = summary
 }
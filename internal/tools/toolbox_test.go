@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/snapshot"
+)
+
+func noPrompt(context.Context, string) (string, error) { return "", nil }
+
+func TestFillAndSubmit(t *testing.T) {
+	results := browser.FakePage{URL: "https://shop.example/search?q=lamp", Title: "Results"}
+	tests := []struct {
+		name    string
+		page    browser.FakePage
+		want    string
+		wantURL string
+	}{
+		{
+			name: "form that navigates",
+			page: browser.FakePage{URL: "https://shop.example/", Elements: []browser.FakeElement{
+				{Selector: "#q", Role: "searchbox", Text: "Search", Navigate: results.URL},
+			}},
+			want:    "filled #q and pressed Enter; page navigated to " + results.URL,
+			wantURL: results.URL,
+		},
+		{
+			name: "nothing happens",
+			page: browser.FakePage{URL: "https://shop.example/", Elements: []browser.FakeElement{
+				{Selector: "#q", Role: "searchbox", Text: "Search"},
+			}},
+			want:    "filled #q and pressed Enter; no navigation or page change detected",
+			wantURL: "https://shop.example/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(tt.page, results)
+			res, err := New(ctrl, noPrompt).Invoke(context.Background(), "fill_and_submit", map[string]any{"selector": "#q", "text": "desk lamp"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(res.Observation, tt.want) {
+				t.Errorf("observation %q, want prefix %q", res.Observation, tt.want)
+			}
+			if strings.Contains(res.Observation, "desk lamp") {
+				t.Errorf("observation echoes the typed text: %q", res.Observation)
+			}
+			if url := ctrl.Current().URL; url != tt.wantURL {
+				t.Errorf("page at %s, want %s", url, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestSnapshotIndex(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	s := &standard{curSnapshot: &snapshot.Summary{Elements: []snapshot.Element{
+		{Index: 1, Role: "link", Text: "Item 1", Sel: "#item-1"},
+		{Index: 2, Role: "link", Text: "Item 10", Sel: "#item-10"},
+		{Index: 3, Role: "link", Text: "Read  more", Sel: ".more"},
+		{Index: 4, Role: "link", Text: "Read more", Sel: ".more-2"},
+		{Index: 5, Role: "button", Text: "Buy now", Sel: "li > button"},
+		{Index: 6, Role: "link", Text: truncateRunes(long, snapshotTextRunes), Sel: "#long"},
+	}}}
+	tests := []struct {
+		name, selector, text string
+		want                 int
+	}{
+		{"exact selector", "#item-1", "whatever", 1},
+		{"selector prefix is not a match", "#item", "", 0},
+		{"selector containing another is not a match", "ul > li > button", "", 0},
+		{"exact text", "", "Item 1", 1},
+		{"text prefix is not a match", "", "Item", 0},
+		{"longer text is not a match", "", "Item 10 - sold out", 0},
+		{"whitespace and case normalized", "", "  ITEM 10\n", 2},
+		{"ambiguous text", "", "read more", 0},
+		{"text cut like the snapshot", "", long + "and more", 6},
+		{"selector miss falls back to text", "#gone", "Buy now", 5},
+		{"nothing", "", "", 0},
+	}
+	for _, tt := range tests {
+		if got := s.snapshotIndex(tt.selector, tt.text); got != tt.want {
+			t.Errorf("%s: snapshotIndex(%q, %q) = %d, want %d", tt.name, tt.selector, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFillByIndexRoleSelector(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"multi-byte text is cut on a rune", "Поиск по каталогу товаров и услуг магазина", `[role="searchbox"][aria-label*="Поиск по каталогу товаров и ус"]`},
+		{"quotes are escaped", `Say "hi" \ wave`, `[role="searchbox"][aria-label*="Say \"hi\" \\ wave"]`},
+		{"line breaks", "Search\nproducts", `[role="searchbox"][aria-label*="Search products"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(browser.FakePage{URL: "https://shop.example/"})
+			box := New(ctrl, noPrompt)
+			box.SetSnapshot(&snapshot.Summary{URL: "https://shop.example/", Elements: []snapshot.Element{{Index: 1, Role: "searchbox", Text: tt.text}}})
+			_, _ = box.Invoke(context.Background(), "fill_by_index", map[string]any{"index": 1, "text": "lamp"})
+			calls := ctrl.CallsTo("Fill")
+			if len(calls) == 0 || calls[0].Args[0] != tt.want {
+				t.Errorf("fill calls = %v, want selector %s", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	for _, s := range []string{"Привет, мир", "🍕🍕🍕🍕🍕", "a👍🏽b👍🏽c", ""} {
+		count := utf8.RuneCountInString(s)
+		for _, n := range []int{0, 1, count - 1, count, count + 1} {
+			got := truncateRunes(s, n)
+			want := min(max(n, 0), count)
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) != want || !strings.HasPrefix(s, got) {
+				t.Errorf("truncateRunes(%q, %d) = %q, want the first %d runes", s, n, got, want)
+			}
+		}
+	}
+}
+
+func TestSaveStateReportsFallback(t *testing.T) {
+	ctrl := browser.NewFakeController(browser.FakePage{})
+	ctrl.FailNext("SaveState", &browser.StateFallbackError{Requested: "/ro/state.json", Used: "/wd/.agent-state/state.json", Err: fs.ErrPermission})
+	box := New(ctrl, noPrompt)
+	res, err := box.Invoke(context.Background(), "save_state", map[string]any{"path": "/ro/state.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res.Observation, "saved to /wd/.agent-state/state.json") || !strings.Contains(res.Observation, "/ro/state.json failed: permission denied") {
+		t.Fatalf("observation = %q, want both attempts", res.Observation)
+	}
+	if len(res.Artifacts) != 1 || res.Artifacts[0] != "/wd/.agent-state/state.json" {
+		t.Fatalf("artifacts = %v, want the path actually used", res.Artifacts)
+	}
+
+	ctrl.FailNext("SaveState", errors.New("save state to /ro/state.json: read-only file system"))
+	if _, err := box.Invoke(context.Background(), "save_state", map[string]any{"path": "/ro/state.json"}); err == nil {
+		t.Fatal("failed save reported as success")
+	}
+}
+
+func TestRequestUserInputBringsWindowToFront(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		prompt string
+		fail   error // BringToFront error
+		want   bool
+	}{
+		{name: "captcha page", url: "https://shop.example/captcha?return=/cart", prompt: "Please solve the check and type done", want: true},
+		{name: "captcha prompt", url: "https://shop.example/cart", prompt: "Solve the CAPTCHA in the browser window", want: true},
+		{name: "sms code", url: "https://bank.example/confirm", prompt: "Введите код из SMS", want: true},
+		{name: "login", url: "https://mail.example/", prompt: "Please log in and type done", want: true},
+		{name: "plain question", url: "https://shop.example/cart", prompt: "What size do you wear?"},
+		{name: "raising fails", url: "https://shop.example/captcha", prompt: "Type done when solved", fail: errors.New("headless"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(browser.FakePage{URL: tt.url})
+			if tt.fail != nil {
+				ctrl.FailNext("BringToFront", tt.fail)
+			}
+			raisedBeforePrompt := false
+			prompt := func(context.Context, string) (string, error) {
+				raisedBeforePrompt = len(ctrl.CallsTo("BringToFront")) > 0
+				return "done", nil
+			}
+			res, err := New(ctrl, prompt).Invoke(context.Background(), "request_user_input", map[string]any{"prompt": tt.prompt})
+			if err != nil {
+				t.Fatalf("prompt failed: %v", err)
+			}
+			if !strings.Contains(res.Observation, "User confirmed") {
+				t.Fatalf("observation = %q", res.Observation)
+			}
+			if n := len(ctrl.CallsTo("BringToFront")); n != btoi(tt.want) || raisedBeforePrompt != tt.want {
+				t.Fatalf("BringToFront calls = %d (before the prompt: %v), want raised=%v", n, raisedBeforePrompt, tt.want)
+			}
+		})
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
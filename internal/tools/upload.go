@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadDirListing caps the file names suggested when the requested file is not found
+const uploadDirListing = 10
+
+// uploadFile attaches a file from Options.UploadDir to a file input or custom upload button
+func (s *standard) uploadFile(ctx context.Context, input map[string]any) (Result, error) {
+	raw, err := requiredString(input, "path")
+	if err != nil {
+		return Result{}, err
+	}
+	path, info, err := s.uploadPath(raw)
+	if err != nil {
+		return Result{}, err
+	}
+	sel := optionalString(input, "selector")
+	if _, ok := input["index"]; ok {
+		el, err := s.elementByIndex(optionalInt(input, "index"))
+		if err != nil {
+			return Result{}, err
+		}
+		if el.Sel == "" {
+			return Result{}, fmt.Errorf("element [%d] has no selector - pass the selector of the file input", el.Index)
+		}
+		if el.FrameURL != "" {
+			return Result{}, fmt.Errorf("element [%d] is inside an iframe - upload_file works on the main page only", el.Index)
+		}
+		sel = el.Sel
+	}
+	if sel == "" {
+		sel = "input[type=file]"
+	}
+	strategy, err := s.ctrl.UploadFile(ctx, sel, path)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: fmt.Sprintf("uploaded %s (%d bytes) to %s via %s", info.Name(), info.Size(), sel, strategy)}, nil
+}
+
+// uploadPath resolves raw inside Options.UploadDir: relative paths are taken from the directory,
+// absolute paths and symlinks must stay inside it. Returns the real path of a regular file.
+func (s *standard) uploadPath(raw string) (string, os.FileInfo, error) {
+	if s.opts.UploadDir == "" {
+		return "", nil, fmt.Errorf("file upload is disabled - the agent was started without an upload directory (-upload-dir); ask the user to upload the file or finish without it")
+	}
+	root, err := filepath.Abs(s.opts.UploadDir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("upload directory %s: %w", s.opts.UploadDir, err)
+	}
+	path := strings.TrimSpace(raw)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("file %q not found in the upload directory%s", raw, uploadDirFiles(root))
+	}
+	if rel, err := filepath.Rel(root, real); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil, fmt.Errorf("file %q is outside the upload directory - only files from it can be uploaded%s", raw, uploadDirFiles(root))
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil, fmt.Errorf("%q is not a regular file%s", raw, uploadDirFiles(root))
+	}
+	return real, info, nil
+}
+
+// uploadDirFiles lists the first files of the upload directory for an error message
+func uploadDirFiles(root string) string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return " (the directory is empty)"
+	}
+	more := ""
+	if len(names) > uploadDirListing {
+		more = fmt.Sprintf(", ... %d more", len(names)-uploadDirListing)
+		names = names[:uploadDirListing]
+	}
+	return " (available: " + strings.Join(names, ", ") + more + ")"
+}
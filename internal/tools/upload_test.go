@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUploadFileStaysInUploadDir(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir()) // Expected paths are real paths
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(base, "uploads")
+	writeFile(t, filepath.Join(dir, "resume.pdf"), "%PDF-1.7")
+	writeFile(t, filepath.Join(dir, "docs", "cover.txt"), "Dear team")
+	writeFile(t, filepath.Join(base, "secret.env"), "API_KEY=1")
+	for link, target := range map[string]string{
+		"escape.txt": filepath.Join(base, "secret.env"),
+		"latest.pdf": filepath.Join(dir, "resume.pdf"),
+		"docs-link":  base,
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	// The upload directory itself may be reached through a symlink
+	linkedDir := filepath.Join(base, "uploads-link")
+	if err := os.Symlink(dir, linkedDir); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		uploadDir string
+		path      string
+		want      string // Real path handed to the browser
+		wantErr   string
+	}{
+		{name: "file by name", path: "resume.pdf", want: filepath.Join(dir, "resume.pdf")},
+		{name: "nested file", path: "docs/cover.txt", want: filepath.Join(dir, "docs", "cover.txt")},
+		{name: "absolute path inside", path: filepath.Join(dir, "resume.pdf"), want: filepath.Join(dir, "resume.pdf")},
+		{name: "symlink inside the directory", path: "latest.pdf", want: filepath.Join(dir, "resume.pdf")},
+		{name: "directory reached via symlink", uploadDir: linkedDir, path: "resume.pdf", want: filepath.Join(dir, "resume.pdf")},
+		{name: "dot-dot traversal", path: "../secret.env", wantErr: "outside the upload directory"},
+		{name: "traversal through a subdirectory", path: "docs/../../secret.env", wantErr: "outside the upload directory"},
+		{name: "absolute path outside", path: filepath.Join(base, "secret.env"), wantErr: "outside the upload directory"},
+		{name: "symlink pointing outside", path: "escape.txt", wantErr: "outside the upload directory"},
+		{name: "through a symlinked directory", path: "docs-link/secret.env", wantErr: "outside the upload directory"},
+		{name: "directory", path: "docs", wantErr: "not a regular file"},
+		{name: "missing file", path: "photo.jpg", wantErr: "not found in the upload directory (available: resume.pdf)"},
+		{name: "uploads disabled", uploadDir: "-", path: "resume.pdf", wantErr: "file upload is disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploadDir := dir
+			switch tt.uploadDir {
+			case "":
+			case "-":
+				uploadDir = ""
+			default:
+				uploadDir = tt.uploadDir
+			}
+			ctrl := browser.NewFakeController(browser.FakePage{URL: "https://jobs.example/apply", Elements: []browser.FakeElement{{Selector: "#cv", Role: "button", Text: "Attach"}}})
+			box := NewWithOptions(ctrl, noPrompt, Options{UploadDir: uploadDir})
+			res, err := box.Invoke(context.Background(), "upload_file", map[string]any{"selector": "#cv", "path": tt.path})
+			calls := ctrl.CallsTo("UploadFile")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if len(calls) != 0 {
+					t.Errorf("browser got the file: %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(calls) != 1 || calls[0].Args[1] != tt.want {
+				t.Fatalf("UploadFile calls = %v, want %s", calls, tt.want)
+			}
+			if !strings.HasPrefix(res.Observation, "uploaded "+filepath.Base(tt.want)+" (") {
+				t.Errorf("observation %q, want the file name and size", res.Observation)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultMaxWait caps wait and wait_for_lazy_list when Options.MaxWait is not set
+	defaultMaxWait = 30 * time.Second
+	// defaultWaitSeconds is waited when the planner gives no seconds
+	defaultWaitSeconds = 3
+	// defaultLazyListWait bounds wait_for_lazy_list without timeout_ms
+	defaultLazyListWait = 10 * time.Second
+)
+
+// wait pauses for the requested seconds (at most Options.MaxWait), returning early when ctx ends
+func (s *standard) wait(ctx context.Context, input map[string]any) (Result, error) {
+	d := time.Duration(optionalInt(input, "seconds")) * time.Second
+	if d <= 0 {
+		d = defaultWaitSeconds * time.Second
+	}
+	capped := d > s.opts.MaxWait
+	if capped {
+		d = s.opts.MaxWait
+	}
+	// One second less: the next planner call takes about that long (like browser-use)
+	pause := d - time.Second
+	if pause > 0 {
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	obs := fmt.Sprintf("waited for %s", d)
+	if capped {
+		obs += fmt.Sprintf(" (capped at %s - wait again only if the page is still loading)", s.opts.MaxWait)
+	}
+	return Result{Observation: obs}, nil
+}
+
+// waitForLazyList waits for lazy-loaded list items, timeout_ms capped by Options.MaxWait
+func (s *standard) waitForLazyList(ctx context.Context, input map[string]any) (Result, error) {
+	timeout := time.Duration(optionalInt(input, "timeout_ms")) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultLazyListWait
+	}
+	if timeout > s.opts.MaxWait {
+		timeout = s.opts.MaxWait
+	}
+	if err := s.ctrl.WaitForLazyListItems(ctx, timeout); err != nil {
+		return Result{}, err
+	}
+	return Result{Observation: "list items appeared"}, nil
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/ai-agent-for-browser-fast/internal/browser"
+)
+
+func TestWaitIsCapped(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		maxWait time.Duration
+		want    string
+		capped  bool
+	}{
+		{name: "within the cap", seconds: 1, maxWait: 30 * time.Second, want: "waited for 1s"},
+		{name: "above the cap", seconds: 600, maxWait: 1500 * time.Millisecond, want: "waited for 1.5s", capped: true},
+		{name: "default seconds capped too", seconds: 0, maxWait: 1200 * time.Millisecond, want: "waited for 1.2s", capped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := NewWithOptions(browser.NewFakeController(browser.FakePage{}), noPrompt, Options{MaxWait: tt.maxWait})
+			start := time.Now()
+			res, err := box.Invoke(context.Background(), "wait", map[string]any{"seconds": float64(tt.seconds)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(res.Observation, tt.want) || strings.Contains(res.Observation, "capped") != tt.capped {
+				t.Fatalf("observation = %q, want %q (capped=%v)", res.Observation, tt.want, tt.capped)
+			}
+			if elapsed := time.Since(start); elapsed > tt.maxWait {
+				t.Fatalf("waited %s, cap is %s", elapsed, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestWaitStopsOnCancel(t *testing.T) {
+	box := New(browser.NewFakeController(browser.FakePage{}), noPrompt)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := box.Invoke(ctx, "wait", map[string]any{"seconds": float64(20)})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("cancelled wait took %s", elapsed)
+	}
+}
+
+func TestWaitForLazyListTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeoutMs int
+		maxWait   time.Duration
+		want      time.Duration
+	}{
+		{name: "default", want: defaultLazyListWait},
+		{name: "requested", timeoutMs: 4000, want: 4 * time.Second},
+		{name: "capped", timeoutMs: 120000, maxWait: 20 * time.Second, want: 20 * time.Second},
+		{name: "default above a small cap", maxWait: 2 * time.Second, want: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := browser.NewFakeController(browser.FakePage{})
+			input := map[string]any{}
+			if tt.timeoutMs > 0 {
+				input["timeout_ms"] = float64(tt.timeoutMs)
+			}
+			box := NewWithOptions(ctrl, noPrompt, Options{MaxWait: tt.maxWait})
+			if _, err := box.Invoke(context.Background(), "wait_for_lazy_list", input); err != nil {
+				t.Fatal(err)
+			}
+			calls := ctrl.CallsTo("WaitForLazyListItems")
+			if len(calls) != 1 || calls[0].Args[0] != tt.want {
+				t.Fatalf("WaitForLazyListItems calls = %+v, want timeout %s", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitForLazyListStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New(browser.NewFakeController(browser.FakePage{}), noPrompt).Invoke(ctx, "wait_for_lazy_list", map[string]any{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}